@@ -0,0 +1,66 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+	"os/signal"
+	"slices"
+	"time"
+
+	"caddy-ls/internal/check"
+)
+
+// runCheck implements `caddy-ls check [flags] [paths...]` and returns the
+// process exit code: 0 when clean, 1 when any file has errors or warnings,
+// 2 on usage or I/O errors.
+func runCheck(args []string) int {
+	fs := flag.NewFlagSet("check", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: caddy-ls check [flags] [files or directories...]")
+		fmt.Fprintln(fs.Output(), "Use - to check a document read from stdin.")
+		fs.PrintDefaults()
+	}
+	watch := fs.Bool("watch", false, "keep running and re-check files when they change")
+	interval := fs.Duration("interval", 500*time.Millisecond, "how often to look for changes in -watch mode")
+	opts := check.Options{Stdin: os.Stdin}
+	fs.StringVar(&opts.Format, "format", check.FormatText, "output format: text or json")
+	fs.StringVar(&opts.StdinName, "stdin-filename", "", "file name to report for a document read from stdin")
+	fs.Func("pattern", "glob naming the files checked inside directories, e.g. 'conf.d/*.conf'; repeatable (default Caddyfile, Caddyfile.*, *.caddyfile, *.caddy)", func(s string) error {
+		opts.Patterns = append(opts.Patterns, s)
+		return opts.Patterns.Validate()
+	})
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	paths := fs.Args()
+	if len(paths) == 0 {
+		paths = []string{"."}
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt)
+	defer stop()
+
+	if *watch {
+		if opts.Format != check.FormatText || slices.Contains(paths, check.Stdin) {
+			fmt.Fprintln(os.Stderr, "caddy-ls check: -watch only supports text output for files and directories")
+			return 2
+		}
+		if err := check.Watch(ctx, paths, opts.Patterns, *interval, os.Stdout); err != nil && !errors.Is(err, context.Canceled) {
+			fmt.Fprintf(os.Stderr, "caddy-ls check: %v\n", err)
+			return 2
+		}
+		return 0
+	}
+	failed, err := check.Run(ctx, paths, opts, os.Stdout)
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "caddy-ls check: %v\n", err)
+		return 2
+	}
+	if failed {
+		return 1
+	}
+	return 0
+}
@@ -4,6 +4,7 @@ import (
 	"flag"
 	"fmt"
 	"os"
+	"time"
 
 	"caddy-ls/internal/server"
 )
@@ -11,13 +12,26 @@ import (
 var appVersion = "dev"
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "check" {
+		os.Exit(runCheck(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "schema" {
+		os.Exit(runSchema(os.Args[2:]))
+	}
+	if len(os.Args) > 1 && os.Args[1] == "rules" {
+		os.Exit(runRules(os.Args[2:]))
+	}
+
 	var (
 		showVersion bool
-		logLevel    string
+		cfg         server.Config
 	)
 
 	flag.BoolVar(&showVersion, "version", false, "print version and exit")
-	flag.StringVar(&logLevel, "log-level", "warning", "log level: debug, info, warning, error")
+	flag.StringVar(&cfg.LogLevel, "log-level", "warning", "log level: debug, info, warning, error")
+	flag.StringVar(&cfg.LogFile, "log-file", "", "write logs to this file instead of stderr")
+	flag.StringVar(&cfg.PprofAddr, "pprof", "", "serve net/http/pprof on this address, e.g. localhost:6060")
+	flag.DurationVar(&cfg.SlowRequest, "slow-request", time.Second, "warn about requests taking longer than this; 0 disables")
 	flag.Parse()
 
 	if showVersion {
@@ -25,7 +39,7 @@ func main() {
 		os.Exit(0)
 	}
 
-	if err := server.Run(logLevel); err != nil {
+	if err := server.Run(cfg); err != nil {
 		fmt.Fprintf(os.Stderr, "caddy-ls: %v\n", err)
 		os.Exit(1)
 	}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+	"text/tabwriter"
+
+	"caddy-ls/pkg/caddyfile/analysis"
+)
+
+// runRules implements `caddy-ls rules [flags]`, which lists the lint rules,
+// and returns the process exit code: 0 on success, 2 on usage or I/O
+// errors.
+func runRules(args []string) int {
+	fs := flag.NewFlagSet("rules", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: caddy-ls rules [flags]")
+		fmt.Fprintln(fs.Output(), "List the lint rules. The opt-in ones are enabled by code in the lint setting.")
+		fs.PrintDefaults()
+	}
+	format := fs.String("format", "text", "output format: text, json or markdown")
+	if err := fs.Parse(args); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	var err error
+	switch *format {
+	case "text":
+		w := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "CODE\tSEVERITY\tDEFAULT\tDESCRIPTION")
+		for _, r := range analysis.Rules {
+			enabled := "on"
+			if r.OptIn {
+				enabled = "off"
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Code, analysis.SeverityName(r.Severity), enabled, r.Summary)
+		}
+		fmt.Fprintf(w, "\nSee %s for details.\n", analysis.RulesDocURL)
+		err = w.Flush()
+	case "json":
+		type rule struct {
+			analysis.Rule
+			Severity string `json:"severity"`
+			URL      string `json:"url"`
+		}
+		rules := make([]rule, 0, len(analysis.Rules))
+		for _, r := range analysis.Rules {
+			rules = append(rules, rule{Rule: r, Severity: analysis.SeverityName(r.Severity), URL: r.DocURL()})
+		}
+		enc := json.NewEncoder(os.Stdout)
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false)
+		err = enc.Encode(rules)
+	case "markdown":
+		_, err = fmt.Fprint(os.Stdout, analysis.RulesMarkdown())
+	default:
+		fmt.Fprintf(os.Stderr, "caddy-ls rules: unknown format %q\n", *format)
+		return 2
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "caddy-ls rules: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,57 @@
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"os"
+
+	"caddy-ls/internal/handler"
+	"caddy-ls/pkg/caddyfile/analysis"
+)
+
+// runSchema implements `caddy-ls schema export [flags]`, which prints the
+// directive model as JSON, and returns the process exit code: 0 on success,
+// 2 on usage or I/O errors.
+func runSchema(args []string) int {
+	if len(args) == 0 || args[0] != "export" {
+		fmt.Fprintln(os.Stderr, "usage: caddy-ls schema export [flags]")
+		return 2
+	}
+	fs := flag.NewFlagSet("schema export", flag.ContinueOnError)
+	fs.Usage = func() {
+		fmt.Fprintln(fs.Output(), "usage: caddy-ls schema export [flags]")
+		fmt.Fprintln(fs.Output(), "Print the directive model as JSON.")
+		fs.PrintDefaults()
+	}
+	schemaFile := fs.String("schema", "", "merge this schema file, a JSON object in the form of the schema setting")
+	if err := fs.Parse(args[1:]); err != nil {
+		return 2
+	}
+	if fs.NArg() > 0 {
+		fs.Usage()
+		return 2
+	}
+
+	schema := analysis.DefaultSchema()
+	if *schemaFile != "" {
+		layer, err := handler.ReadSchemaFile(*schemaFile)
+		if err != nil {
+			fmt.Fprintf(os.Stderr, "caddy-ls schema export: %v\n", err)
+			return 2
+		}
+		schema = analysis.NewSchema(*layer)
+		for _, p := range schema.Problems() {
+			fmt.Fprintf(os.Stderr, "caddy-ls schema export: %s schema: %s\n", p.Origin, p.Message)
+		}
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(schema.Export()); err != nil {
+		fmt.Fprintf(os.Stderr, "caddy-ls schema export: %v\n", err)
+		return 2
+	}
+	return 0
+}
@@ -0,0 +1,279 @@
+// Package check lints Caddyfiles outside the language server, for the
+// `caddy-ls check` command: the same parse and analysis diagnostics an
+// editor shows, printed one per line.
+package check
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/handler"
+	"caddy-ls/internal/workspace"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"time"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Result holds the diagnostics for one file. Err is set when the file could
+// not be read.
+type Result struct {
+	Path        string
+	Diagnostics []protocol.Diagnostic
+	Err         error
+}
+
+// Problems reports whether r should fail a check: the file is unreadable or
+// has an error or warning.
+func (r Result) Problems() bool {
+	if r.Err != nil {
+		return true
+	}
+	for _, d := range r.Diagnostics {
+		if d.Severity != nil && *d.Severity <= protocol.DiagnosticSeverityWarning {
+			return true
+		}
+	}
+	return false
+}
+
+// settings configure the language server for checking: every document is
+// analyzed, whatever its size, and every diagnostic is reported.
+var settings = handler.Settings{MaxDocumentSize: -1, MaxDiagnostics: -1}
+
+// Source returns the diagnostics for the Caddyfile src, identified by uri in
+// related information. It opens the document in a language server handler,
+// so the results are exactly those an editor would be sent. Positions on
+// the first line do not count a leading byte order mark. A problem reported
+// several times on one line is reported once.
+func Source(uri, src string) []protocol.Diagnostic {
+	var c collector
+	h := handler.NewWithPublisher(document.New(), &c)
+	h.Configure(settings)
+	_ = h.DidOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: protocol.DocumentUri(uri), Text: src, Version: 1},
+	})
+	return c.diags
+}
+
+// collector is a handler.DiagnosticsPublisher that keeps the last
+// diagnostics published.
+type collector struct {
+	diags []protocol.Diagnostic
+}
+
+// PublishDiagnostics implements handler.DiagnosticsPublisher.
+func (c *collector) PublishDiagnostics(_ string, _ int32, diags []protocol.Diagnostic) {
+	c.diags = diags
+}
+
+// File reads and lints the Caddyfile at path.
+func File(path string) Result {
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return Result{Path: path, Err: err}
+	}
+	return Result{Path: path, Diagnostics: Source(workspace.PathToURI(path), string(src))}
+}
+
+// Stdin is the path that makes Run read a document from Options.Stdin.
+const Stdin = "-"
+
+// Output formats accepted by Options.Format.
+const (
+	FormatText = "text"
+	FormatJSON = "json"
+)
+
+// Options configures Run.
+type Options struct {
+	// Format is FormatText (the default) or FormatJSON.
+	Format string
+	// Stdin is read for the path "-".
+	Stdin io.Reader
+	// StdinName is the file name reported for stdin, so that editors
+	// piping a buffer can map results back to it. Defaults to "<stdin>".
+	StdinName string
+	// Patterns name the files checked inside directories. Empty means
+	// workspace.DefaultPatterns.
+	Patterns workspace.Patterns
+}
+
+// Reader lints a document read from r, reporting it as name.
+func Reader(name string, r io.Reader) Result {
+	src, err := io.ReadAll(r)
+	if err != nil {
+		return Result{Path: name, Err: err}
+	}
+	uri := name
+	if abs, err := filepath.Abs(name); err == nil {
+		uri = workspace.PathToURI(abs)
+	}
+	return Result{Path: name, Diagnostics: Source(uri, string(src))}
+}
+
+// Files expands paths into the files to lint: directories are searched for
+// files matching patterns, other paths are used as given whatever their
+// name.
+func Files(ctx context.Context, paths []string, patterns workspace.Patterns) ([]string, error) {
+	var files, dirs []string
+	for _, p := range paths {
+		if p == Stdin {
+			files = append(files, p)
+		} else if info, err := os.Stat(p); err == nil && info.IsDir() {
+			dirs = append(dirs, p)
+		} else {
+			files = append(files, p)
+		}
+	}
+	found, err := workspace.FindCaddyfiles(ctx, dirs, patterns)
+	if err != nil {
+		return nil, err
+	}
+	return append(files, found...), nil
+}
+
+// Print writes r to w as "path:line:col: severity: message" lines, with
+// 1-based positions.
+func Print(w io.Writer, r Result) {
+	if r.Err != nil {
+		fmt.Fprintf(w, "%s: error: %v\n", r.Path, r.Err)
+		return
+	}
+	for _, d := range r.Diagnostics {
+		fmt.Fprintf(w, "%s:%d:%d: %s: %s\n", r.Path, d.Range.Start.Line+1, d.Range.Start.Character+1, severityName(d.Severity), d.Message)
+	}
+}
+
+// severityName spells out a diagnostic severity; unset means error, as in
+// the LSP specification's advice to clients.
+func severityName(s *protocol.DiagnosticSeverity) string {
+	if s == nil {
+		return "error"
+	}
+	switch *s {
+	case protocol.DiagnosticSeverityWarning:
+		return "warning"
+	case protocol.DiagnosticSeverityInformation:
+		return "info"
+	case protocol.DiagnosticSeverityHint:
+		return "hint"
+	}
+	return "error"
+}
+
+// Run lints every file under paths, printing the results to w in
+// opts.Format, and reports whether any file has problems. Text output is
+// written as each file is checked; JSON output is a single array of
+// diagnostics written at the end.
+func Run(ctx context.Context, paths []string, opts Options, w io.Writer) (bool, error) {
+	if opts.Format != "" && opts.Format != FormatText && opts.Format != FormatJSON {
+		return false, fmt.Errorf("unknown output format %q: want %q or %q", opts.Format, FormatText, FormatJSON)
+	}
+	files, err := Files(ctx, paths, opts.Patterns)
+	if err != nil {
+		return false, err
+	}
+	failed := false
+	var results []Result
+	for _, path := range files {
+		var r Result
+		if path == Stdin {
+			name := opts.StdinName
+			if name == "" {
+				name = "<stdin>"
+			}
+			r = Reader(name, opts.Stdin)
+		} else {
+			r = File(path)
+		}
+		failed = failed || r.Problems()
+		if opts.Format == FormatJSON {
+			results = append(results, r)
+			continue
+		}
+		Print(w, r)
+	}
+	if opts.Format == FormatJSON {
+		return failed, PrintJSON(w, results)
+	}
+	return failed, nil
+}
+
+// jsonDiagnostic is one entry of the JSON output. Positions are 1-based, as
+// in the text format; the end is exclusive.
+type jsonDiagnostic struct {
+	File      string `json:"file"`
+	Line      uint32 `json:"line"`
+	Column    uint32 `json:"column"`
+	EndLine   uint32 `json:"endLine"`
+	EndColumn uint32 `json:"endColumn"`
+	Severity  string `json:"severity"`
+	Message   string `json:"message"`
+}
+
+// PrintJSON writes the diagnostics of results to w as one JSON array. An
+// unreadable file is reported as an error at its first line.
+func PrintJSON(w io.Writer, results []Result) error {
+	out := []jsonDiagnostic{}
+	for _, r := range results {
+		if r.Err != nil {
+			out = append(out, jsonDiagnostic{File: r.Path, Line: 1, Column: 1, EndLine: 1, EndColumn: 1, Severity: "error", Message: r.Err.Error()})
+			continue
+		}
+		for _, d := range r.Diagnostics {
+			out = append(out, jsonDiagnostic{
+				File:      r.Path,
+				Line:      d.Range.Start.Line + 1,
+				Column:    d.Range.Start.Character + 1,
+				EndLine:   d.Range.End.Line + 1,
+				EndColumn: d.Range.End.Character + 1,
+				Severity:  severityName(d.Severity),
+				Message:   d.Message,
+			})
+		}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(out)
+}
+
+// Watch lints every file under paths once, directories contributing the
+// files matching patterns, then re-lints files as they change until ctx is
+// cancelled, polling every interval. Each round prints
+// only the changed files, with a summary line per file so that a fixed file
+// is visibly reported clean.
+func Watch(ctx context.Context, paths []string, patterns workspace.Patterns, interval time.Duration, w io.Writer) error {
+	watcher, err := workspace.NewWatcher(ctx, paths, patterns)
+	if err != nil {
+		return err
+	}
+	report := func(files []string) {
+		for _, path := range files {
+			if _, err := os.Stat(path); os.IsNotExist(err) {
+				fmt.Fprintf(w, "%s: removed\n", path)
+				continue
+			}
+			r := File(path)
+			Print(w, r)
+			fmt.Fprintf(w, "%s: %s\n", path, summary(r))
+		}
+	}
+	report(watcher.Files())
+	fmt.Fprintf(w, "watching %d file(s) for changes\n", len(watcher.Files()))
+	return watcher.Watch(ctx, interval, report)
+}
+
+// summary counts r's diagnostics for the per-file watch line.
+func summary(r Result) string {
+	if r.Err != nil {
+		return "unreadable"
+	}
+	if len(r.Diagnostics) == 0 {
+		return "ok"
+	}
+	return fmt.Sprintf("%d problem(s)", len(r.Diagnostics))
+}
@@ -0,0 +1,211 @@
+package check
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+func writeFile(t *testing.T, path, content string) {
+	t.Helper()
+	if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestRun(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "Caddyfile"), "example.com {\n\trevers_proxy app:8080\n}\n")
+	writeFile(t, filepath.Join(dir, "sites/ok.caddy"), "ok.example.com {\n\trespond \"ok\"\n}\n")
+
+	var out bytes.Buffer
+	failed, err := Run(context.Background(), []string{dir}, Options{}, &out)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !failed {
+		t.Error("want failure for a file with a warning")
+	}
+	want := filepath.Join(dir, "Caddyfile") + `:2:2: warning: unknown directive "revers_proxy"` + "\n"
+	if out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+}
+
+func TestRun_CleanAndUnreadable(t *testing.T) {
+	dir := t.TempDir()
+	clean := filepath.Join(dir, "site.conf")
+	writeFile(t, clean, "example.com {\n\trespond \"ok\"\n}\n")
+
+	var out bytes.Buffer
+	if failed, err := Run(context.Background(), []string{clean}, Options{}, &out); err != nil || failed || out.Len() != 0 {
+		t.Errorf("explicit clean file: failed=%v err=%v output=%q", failed, err, out.String())
+	}
+	missing := filepath.Join(dir, "missing")
+	if failed, _ := Run(context.Background(), []string{missing}, Options{}, &out); !failed || !strings.Contains(out.String(), missing+": error:") {
+		t.Errorf("missing file: failed=%v output=%q", failed, out.String())
+	}
+}
+
+func TestRun_Stdin(t *testing.T) {
+	var out bytes.Buffer
+	opts := Options{Stdin: strings.NewReader("example.com {\n\trevers_proxy app\n}\n"), StdinName: "conf/Caddyfile"}
+	failed, err := Run(context.Background(), []string{Stdin}, opts, &out)
+	if err != nil || !failed {
+		t.Fatalf("failed=%v err=%v", failed, err)
+	}
+	if want := `conf/Caddyfile:2:2: warning: unknown directive "revers_proxy"` + "\n"; out.String() != want {
+		t.Errorf("output = %q, want %q", out.String(), want)
+	}
+
+	out.Reset()
+	Run(context.Background(), []string{Stdin}, Options{Stdin: strings.NewReader("example.com {\n\trevers_proxy app\n}\n")}, &out)
+	if !strings.HasPrefix(out.String(), "<stdin>:2:2:") {
+		t.Errorf("default stdin name: got %q", out.String())
+	}
+}
+
+func TestRun_JSON(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Caddyfile")
+	writeFile(t, path, "example.com {\n\trevers_proxy app:8080\n}\n")
+
+	var out bytes.Buffer
+	if _, err := Run(context.Background(), []string{path}, Options{Format: FormatJSON}, &out); err != nil {
+		t.Fatal(err)
+	}
+	var got []jsonDiagnostic
+	if err := json.Unmarshal(out.Bytes(), &got); err != nil {
+		t.Fatalf("invalid JSON %q: %v", out.String(), err)
+	}
+	want := jsonDiagnostic{File: path, Line: 2, Column: 2, EndLine: 2, EndColumn: 14, Severity: "warning", Message: `unknown directive "revers_proxy"`}
+	if len(got) != 1 || got[0] != want {
+		t.Errorf("got %+v, want [%+v]", got, want)
+	}
+
+	out.Reset()
+	writeFile(t, path, "example.com {\n}\n")
+	Run(context.Background(), []string{path}, Options{Format: FormatJSON}, &out)
+	if strings.TrimSpace(out.String()) != "[]" {
+		t.Errorf("clean file: want an empty array, got %q", out.String())
+	}
+}
+
+func TestRun_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	writeFile(t, filepath.Join(dir, "conf.d/site.conf"), "example.com {\n\trevers_proxy app:8080\n}\n")
+	writeFile(t, filepath.Join(dir, "Caddyfile"), "import conf.d/*.conf\n")
+
+	var out bytes.Buffer
+	failed, err := Run(context.Background(), []string{dir}, Options{Patterns: []string{"conf.d/*.conf"}}, &out)
+	if err != nil || !failed {
+		t.Fatalf("Run = %v, %v", failed, err)
+	}
+	if !strings.Contains(out.String(), "site.conf:2:2") || strings.Contains(out.String(), "Caddyfile") {
+		t.Errorf("want only conf.d/site.conf checked, got:\n%s", out.String())
+	}
+}
+
+func TestRun_UnknownFormat(t *testing.T) {
+	if _, err := Run(context.Background(), nil, Options{Format: "xml"}, &bytes.Buffer{}); err == nil {
+		t.Error("unknown format: want error")
+	}
+}
+
+func TestSource_ParseErrorsFirst(t *testing.T) {
+	diags := Source("file:///Caddyfile", "example.com {\n\trespond ok\n")
+	if len(diags) == 0 || !strings.Contains(diags[0].Message, "unclosed") {
+		t.Errorf("want the unclosed block error, got %v", diags)
+	}
+}
+
+func TestSource_BOMAndCRLF(t *testing.T) {
+	const src = "exmple.com {\n\trevers_proxy app:8080\n\trespond \u201cok\u201d\n}\n"
+	want := Source("file:///Caddyfile", src)
+	if len(want) == 0 {
+		t.Fatal("want diagnostics for the plain source")
+	}
+	for _, variant := range []string{
+		"\ufeff" + src,
+		strings.ReplaceAll(src, "\n", "\r\n"),
+		"\ufeff" + strings.ReplaceAll(src, "\n", "\r\n"),
+	} {
+		if got := Source("file:///Caddyfile", variant); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q:\ngot  %v\nwant %v", variant, got, want)
+		}
+	}
+}
+
+func TestFile_UnresolvedImport(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Caddyfile")
+	writeFile(t, path, "import sites/*.caddy\nimport ./tls.caddy\n")
+	writeFile(t, filepath.Join(dir, "tls.caddy"), "(tls) {\n}\n")
+	res := File(path)
+	if len(res.Diagnostics) != 1 || !strings.Contains(res.Diagnostics[0].Message, `no files match import glob "sites/*.caddy"`) {
+		t.Errorf("want the empty glob warning only, got %v", res.Diagnostics)
+	}
+}
+
+// syncBuffer is a bytes.Buffer safe for the concurrent writes of Watch.
+type syncBuffer struct {
+	mu  sync.Mutex
+	buf bytes.Buffer
+}
+
+func (b *syncBuffer) Write(p []byte) (int, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.Write(p)
+}
+
+func (b *syncBuffer) String() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	return b.buf.String()
+}
+
+func TestWatch_ReportsChangedFiles(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "Caddyfile")
+	writeFile(t, path, "example.com {\n\trevers_proxy app:8080\n}\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	var out syncBuffer
+	done := make(chan error, 1)
+	go func() { done <- Watch(ctx, []string{dir}, nil, 10*time.Millisecond, &out) }()
+
+	waitFor := func(s string) {
+		t.Helper()
+		deadline := time.Now().Add(5 * time.Second)
+		for !strings.Contains(out.String(), s) {
+			if time.Now().After(deadline) {
+				t.Fatalf("timed out waiting for %q, output so far:\n%s", s, out.String())
+			}
+			time.Sleep(5 * time.Millisecond)
+		}
+	}
+	waitFor("watching 1 file(s)")
+	if !strings.Contains(out.String(), path+": 1 problem(s)") {
+		t.Errorf("initial run should report the problem, got:\n%s", out.String())
+	}
+
+	writeFile(t, path, "example.com {\n\treverse_proxy app:8080\n}\n")
+	waitFor(path + ": ok")
+
+	cancel()
+	if err := <-done; err != context.Canceled {
+		t.Errorf("Watch = %v, want context.Canceled", err)
+	}
+}
@@ -1,11 +1,16 @@
 package document
 
-import "sync"
+import (
+	"sort"
+	"sync"
+)
 
-// Document holds the text content of an open file.
+// Document holds the text content of an open file together with the
+// version number the client assigned to it.
 type Document struct {
 	URI     string
 	Content string
+	Version int32
 }
 
 // Store is a thread-safe map from document URI to Document.
@@ -20,20 +25,21 @@ func New() *Store {
 }
 
 // Open stores a newly opened document.
-func (s *Store) Open(uri, text string) {
+func (s *Store) Open(uri, text string, version int32) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
-	s.docs[uri] = &Document{URI: uri, Content: text}
+	s.docs[uri] = &Document{URI: uri, Content: text, Version: version}
 }
 
-// Update replaces the content of an existing document.
-func (s *Store) Update(uri, text string) {
+// Update replaces the content and version of an existing document.
+func (s *Store) Update(uri, text string, version int32) {
 	s.mu.Lock()
 	defer s.mu.Unlock()
 	if doc, ok := s.docs[uri]; ok {
 		doc.Content = text
+		doc.Version = version
 	} else {
-		s.docs[uri] = &Document{URI: uri, Content: text}
+		s.docs[uri] = &Document{URI: uri, Content: text, Version: version}
 	}
 }
 
@@ -54,3 +60,38 @@ func (s *Store) Get(uri string) (string, bool) {
 	}
 	return doc.Content, true
 }
+
+// Snapshot returns the content and version of a document. Returns
+// ("", 0, false) if not found.
+func (s *Store) Snapshot(uri string) (content string, version int32, ok bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	doc, ok := s.docs[uri]
+	if !ok {
+		return "", 0, false
+	}
+	return doc.Content, doc.Version, true
+}
+
+// Version returns the current version of a document.
+func (s *Store) Version(uri string) (int32, bool) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	doc, ok := s.docs[uri]
+	if !ok {
+		return 0, false
+	}
+	return doc.Version, true
+}
+
+// URIs returns the URIs of all open documents, sorted.
+func (s *Store) URIs() []string {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+	uris := make([]string, 0, len(s.docs))
+	for uri := range s.docs {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
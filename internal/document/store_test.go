@@ -7,7 +7,7 @@ import (
 
 func TestStore_OpenAndGet(t *testing.T) {
 	s := New()
-	s.Open("file:///test.caddyfile", "example.com {}")
+	s.Open("file:///test.caddyfile", "example.com {}", 1)
 	got, ok := s.Get("file:///test.caddyfile")
 	if !ok {
 		t.Fatal("Get returned ok=false after Open")
@@ -27,8 +27,8 @@ func TestStore_GetMissing(t *testing.T) {
 
 func TestStore_Update(t *testing.T) {
 	s := New()
-	s.Open("file:///test.caddyfile", "original")
-	s.Update("file:///test.caddyfile", "updated")
+	s.Open("file:///test.caddyfile", "original", 1)
+	s.Update("file:///test.caddyfile", "updated", 1)
 	got, ok := s.Get("file:///test.caddyfile")
 	if !ok {
 		t.Fatal("Get returned ok=false after Update")
@@ -41,7 +41,7 @@ func TestStore_Update(t *testing.T) {
 func TestStore_UpdateCreatesIfMissing(t *testing.T) {
 	// Update must behave like Open when the document does not exist yet.
 	s := New()
-	s.Update("file:///new.caddyfile", "content")
+	s.Update("file:///new.caddyfile", "content", 1)
 	got, ok := s.Get("file:///new.caddyfile")
 	if !ok {
 		t.Fatal("Get returned ok=false after Update on new document")
@@ -53,7 +53,7 @@ func TestStore_UpdateCreatesIfMissing(t *testing.T) {
 
 func TestStore_Close(t *testing.T) {
 	s := New()
-	s.Open("file:///test.caddyfile", "content")
+	s.Open("file:///test.caddyfile", "content", 1)
 	s.Close("file:///test.caddyfile")
 	_, ok := s.Get("file:///test.caddyfile")
 	if ok {
@@ -70,8 +70,8 @@ func TestStore_CloseNonExistent(t *testing.T) {
 func TestStore_OpenOverwrites(t *testing.T) {
 	// Opening the same URI twice should replace the content.
 	s := New()
-	s.Open("file:///test.caddyfile", "first")
-	s.Open("file:///test.caddyfile", "second")
+	s.Open("file:///test.caddyfile", "first", 1)
+	s.Open("file:///test.caddyfile", "second", 1)
 	got, _ := s.Get("file:///test.caddyfile")
 	if got != "second" {
 		t.Errorf("got %q, want 'second'", got)
@@ -80,8 +80,8 @@ func TestStore_OpenOverwrites(t *testing.T) {
 
 func TestStore_MultipleDocuments(t *testing.T) {
 	s := New()
-	s.Open("file:///a.caddyfile", "aaa")
-	s.Open("file:///b.caddyfile", "bbb")
+	s.Open("file:///a.caddyfile", "aaa", 1)
+	s.Open("file:///b.caddyfile", "bbb", 1)
 
 	a, ok := s.Get("file:///a.caddyfile")
 	if !ok || a != "aaa" {
@@ -93,18 +93,46 @@ func TestStore_MultipleDocuments(t *testing.T) {
 	}
 }
 
+func TestStore_Versions(t *testing.T) {
+	s := New()
+	s.Open("file:///test.caddyfile", "v1", 1)
+	s.Update("file:///test.caddyfile", "v4", 4)
+	content, version, ok := s.Snapshot("file:///test.caddyfile")
+	if !ok || content != "v4" || version != 4 {
+		t.Errorf("Snapshot = (%q, %d, %v), want (v4, 4, true)", content, version, ok)
+	}
+	if v, ok := s.Version("file:///test.caddyfile"); !ok || v != 4 {
+		t.Errorf("Version = (%d, %v), want (4, true)", v, ok)
+	}
+	if _, _, ok := s.Snapshot("file:///missing.caddyfile"); ok {
+		t.Error("Snapshot of missing document: want ok=false")
+	}
+}
+
+func TestStore_URIs(t *testing.T) {
+	s := New()
+	s.Open("file:///b.caddyfile", "", 1)
+	s.Open("file:///a.caddyfile", "", 1)
+	s.Open("file:///c.caddyfile", "", 1)
+	s.Close("file:///c.caddyfile")
+	got := s.URIs()
+	if len(got) != 2 || got[0] != "file:///a.caddyfile" || got[1] != "file:///b.caddyfile" {
+		t.Errorf("URIs() = %v, want [a b] sorted", got)
+	}
+}
+
 func TestStore_ConcurrentReadWrite(t *testing.T) {
 	// Exercise the RWMutex under concurrent load. Any data race will be caught
 	// by the race detector (go test -race).
 	s := New()
-	s.Open("file:///test.caddyfile", "initial")
+	s.Open("file:///test.caddyfile", "initial", 1)
 
 	var wg sync.WaitGroup
 	for i := 0; i < 50; i++ {
 		wg.Add(3)
 		go func(i int) {
 			defer wg.Done()
-			s.Update("file:///test.caddyfile", "updated")
+			s.Update("file:///test.caddyfile", "updated", 1)
 		}(i)
 		go func() {
 			defer wg.Done()
@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/env"
+	"caddy-ls/pkg/caddyfile/parser"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// siteAddressPrefix reports whether pos is where a site address is typed,
+// at the top level of f before the "{" of a block, and returns the part of
+// the address typed so far. Snippet definitions, imports and comments are
+// not addresses.
+func siteAddressPrefix(f *parser.File, content string, pos protocol.Position) (string, bool) {
+	if f.GlobalBlock != nil && f.GlobalBlock.BodyContains(pos) {
+		return "", false
+	}
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			return "", false
+		}
+	}
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return "", false
+	}
+	before := line[:col]
+	trimmed := strings.TrimLeft(before, " \t")
+	if strings.ContainsAny(before, "{}#") || strings.HasPrefix(trimmed, "(") || trimmed == "import" || strings.HasPrefix(trimmed, "import ") {
+		return "", false
+	}
+	return before[strings.LastIndexAny(before, " \t,")+1:], true
+}
+
+// addressCompletions offers the names found in the address sources that
+// start with partial and are not yet the address of a site in f.
+func addressCompletions(f *parser.File, sources []string, roots []string, partial string) []protocol.CompletionItem {
+	used := make(map[string]bool)
+	for _, sb := range f.SiteBlocks {
+		for _, a := range sb.Addresses {
+			used[strings.TrimSuffix(a.Value, ",")] = true
+		}
+	}
+	kind := protocol.CompletionItemKindValue
+	items := []protocol.CompletionItem{}
+	seen := make(map[string]bool)
+	for _, c := range loadAddressSources(sources, roots) {
+		if seen[c.name] || used[c.name] || !strings.HasPrefix(c.name, partial) {
+			continue
+		}
+		seen[c.name] = true
+		items = append(items, protocol.CompletionItem{
+			Label:  c.name,
+			Kind:   &kind,
+			Detail: strPtr(c.source),
+		})
+	}
+	return items
+}
+
+// addressCandidate is a host name from an address source.
+type addressCandidate struct {
+	name, source string
+}
+
+// loadAddressSources reads the host names of the address sources, looked
+// up in each workspace root unless absolute: the services of docker-compose
+// files, and the names of hosts files such as /etc/hosts. Missing and
+// unreadable files are skipped.
+func loadAddressSources(sources []string, roots []string) []addressCandidate {
+	var candidates []addressCandidate
+	read := func(path, name string) {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				log.Debugf("skipping address source: %v", err)
+			}
+			return
+		}
+		var names []string
+		if env.IsComposeFile(path) {
+			if names, err = env.ParseComposeServices(string(data)); err != nil {
+				log.Debugf("skipping address source %s: %v", path, err)
+				return
+			}
+		} else {
+			names = parseHosts(string(data))
+		}
+		for _, n := range names {
+			candidates = append(candidates, addressCandidate{name: n, source: name})
+		}
+	}
+	for _, file := range sources {
+		if filepath.IsAbs(file) {
+			read(file, file)
+			continue
+		}
+		for _, root := range roots {
+			read(filepath.Join(root, file), file)
+		}
+	}
+	return candidates
+}
+
+// parseHosts returns the sorted host names of a hosts file: the fields
+// after the address on each line, up to a comment.
+func parseHosts(src string) []string {
+	var names []string
+	for _, line := range strings.Split(src, "\n") {
+		line, _, _ = strings.Cut(line, "#")
+		fields := strings.Fields(line)
+		if len(fields) > 1 {
+			names = append(names, fields[1:]...)
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestSiteAddressPrefix(t *testing.T) {
+	src := "{\n\tadmin off\n}\n(snip) {\n}\nimport snip\napp.localhost {\n\trespond ok\n}\nfoo, ba\n# comment\n"
+	f := parseAST(src)
+	tests := []struct {
+		pos     protocol.Position
+		partial string
+		ok      bool
+	}{
+		{pos(9, 7), "ba", true},
+		{pos(9, 3), "foo", true},
+		{pos(6, 3), "app", true},
+		{pos(6, 15), "", false}, // after "{"
+		{pos(7, 3), "", false},  // site body
+		{pos(1, 3), "", false},  // global options
+		{pos(3, 3), "", false},  // snippet definition
+		{pos(5, 8), "", false},  // import
+		{pos(10, 5), "", false}, // comment
+	}
+	for _, tc := range tests {
+		partial, ok := siteAddressPrefix(f, src, tc.pos)
+		if partial != tc.partial || ok != tc.ok {
+			t.Errorf("%v: got %q, %v; want %q, %v", tc.pos, partial, ok, tc.partial, tc.ok)
+		}
+	}
+}
+
+func TestParseHosts(t *testing.T) {
+	got := parseHosts("127.0.0.1 localhost\n# 10.0.0.1 hidden\n10.0.0.2\tapi.local  api # comment\n\n")
+	if want := []string{"api", "api.local", "localhost"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestCompletion_SiteAddresses(t *testing.T) {
+	dir := t.TempDir()
+	compose := filepath.Join(dir, "docker-compose.yml")
+	hosts := filepath.Join(dir, "hosts")
+	if err := os.WriteFile(compose, []byte("services:\n  web:\n    image: nginx\n  api:\n    image: api\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(hosts, []byte("127.0.0.1 api.localhost web\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	src := "web {\n\treverse_proxy web:80\n}\n\na\n"
+	s := Settings{Completion: CompletionSettings{AddressSources: []string{compose, hosts, filepath.Join(dir, "missing")}}}
+
+	items := completionItems(t, s, src, pos(4, 1))
+	if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, []string{"api", "api.localhost"}) {
+		t.Errorf("got %v", got)
+	}
+	if d := items["api"].Detail; d == nil || *d != compose {
+		t.Errorf("api detail = %v", d)
+	}
+	if items := completionItems(t, Settings{}, src, pos(4, 1)); len(items) != 0 {
+		t.Errorf("without sources: got %v", items)
+	}
+}
@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// argumentDocAt documents the argument under pos from the signature it
+// binds to: an argument of a site-level directive, or of a request matcher
+// in a named matcher definition, e.g. the status code of `redir /old /new
+// 301`. Matcher tokens, quoted strings, whose contents are documented on
+// their own, and arguments past the last parameter have none.
+func argumentDocAt(f *parser.File, pos protocol.Position) (string, bool) {
+	if typ, args, ok := matcherCallAt(f, pos); ok {
+		forms, ok := analysis.MatcherForms(typ.Value)
+		if !ok {
+			return "", false
+		}
+		return paramDoc(typ.Value, false, forms, args, pos)
+	}
+	d, ok := argumentDirectiveAt(f, pos)
+	if !ok {
+		return "", false
+	}
+	forms, matcher, ok := analysis.DirectiveForms(d.Name.Value)
+	if !ok {
+		return "", false
+	}
+	return paramDoc(d.Name.Value, matcher, forms, analysis.DirectiveArgs(d), pos)
+}
+
+// argumentDirectiveAt returns the site-level directive, including those in
+// container blocks such as handle, with an argument under pos.
+func argumentDirectiveAt(f *parser.File, pos protocol.Position) (*parser.Directive, bool) {
+	var walk func(ds []*parser.Directive) (*parser.Directive, bool)
+	walk = func(ds []*parser.Directive) (*parser.Directive, bool) {
+		for _, d := range ds {
+			if strings.HasPrefix(d.Name.Value, "@") {
+				continue
+			}
+			if slices.ContainsFunc(d.Args, func(a *parser.Argument) bool { return tokenContains(a.Token, pos) }) {
+				return d, true
+			}
+			if containerDirectives[d.Name.Value] && d.BodyContains(pos) {
+				return walk(d.Body)
+			}
+		}
+		return nil, false
+	}
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			return walk(sb.Directives)
+		}
+	}
+	return nil, false
+}
+
+// paramDoc documents the parameter that the argument of args under pos
+// binds to, followed by the signature of name it belongs to.
+func paramDoc(name string, matcher bool, forms []analysis.ArgForm, args []*parser.Argument, pos protocol.Position) (string, bool) {
+	arg := slices.IndexFunc(args, func(a *parser.Argument) bool { return tokenContains(a.Token, pos) })
+	if arg < 0 || args[arg].Token.Type == parser.STRING {
+		return "", false
+	}
+	form, param := analysis.ActiveForm(forms, arg, len(args))
+	params := forms[form].Params
+	if param < 0 || (arg >= len(params) && !params[len(params)-1].Variadic) {
+		return "", false
+	}
+	sig := name
+	if matcher {
+		sig += " [<matcher>]"
+	}
+	for _, p := range params {
+		sig += " " + p.Label
+	}
+	p := params[param]
+	return "**`" + p.Label + "`** — " + p.Doc + "\n\n```\n" + sig + "\n```", true
+}
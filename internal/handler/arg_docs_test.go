@@ -0,0 +1,54 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestArgumentDocAt(t *testing.T) {
+	src := "example.com {\n" +
+		"\t@api header X-Api yes\n" +
+		"\tredir /old /new 301\n" +
+		"\trespond \"gone\" 410\n" +
+		"\thandle {\n" +
+		"\t\troot /srv\n" +
+		"\t}\n" +
+		"\ttls cert.pem key.pem\n" +
+		"\tredir /a /b 301 extra\n" +
+		"}\n"
+	f := parseAST(src)
+	tests := []struct {
+		name string
+		pos  protocol.Position
+		want []string // substrings of the doc, none for no doc
+	}{
+		{"redirect code", pos(2, 18), []string{"**`[<code>]`**", "`301` or `permanent`", "redir [<matcher>] <to> [<code>]"}},
+		{"redirect target", pos(2, 13), []string{"**`<to>`**"}},
+		{"matcher token", pos(2, 8), nil},
+		{"status after body", pos(3, 17), []string{"**`<status>`**", "respond [<matcher>] <body> <status>"}},
+		{"quoted body", pos(3, 11), nil},
+		{"lone root path", pos(5, 8), []string{"**`<path>`**"}},
+		{"key file", pos(7, 16), []string{"**`<key_file>`**", "tls <cert_file> <key_file>"}},
+		{"past the last parameter", pos(8, 19), nil},
+		{"matcher argument", pos(1, 20), []string{"**`[<value>]`**", "header <field> [<value>]"}},
+		{"directive name", pos(2, 3), nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got, ok := argumentDocAt(f, tc.pos)
+			if tc.want == nil {
+				if ok {
+					t.Errorf("want no doc, got %q", got)
+				}
+				return
+			}
+			for _, w := range tc.want {
+				if !strings.Contains(got, w) {
+					t.Errorf("want %q in %q", w, got)
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package handler
+
+import (
+	"bytes"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"context"
+	"errors"
+	"fmt"
+	"os/exec"
+	"strings"
+	"time"
+
+	"github.com/tliron/glsp"
+)
+
+// listModulesTimeout bounds a `caddy list-modules` run.
+const listModulesTimeout = 10 * time.Second
+
+// loadCaddyModules builds the generated schema layer from the plugin modules
+// of the local Caddy build when the caddyModules setting is on, and drops it
+// when it is off. A layer already loaded is kept unless reload is set, so
+// that caddy does not run on every settings change. On failure the previous
+// layer stays in place.
+func (h *Handler) loadCaddyModules(reload bool) error {
+	if !h.settings.CaddyModules {
+		h.modules = nil
+		return nil
+	}
+	if h.modules != nil && !reload {
+		return nil
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), listModulesTimeout)
+	defer cancel()
+	ids, err := listCaddyModules(ctx, h.caddyBinary())
+	if err != nil {
+		return err
+	}
+	layer := analysis.ModulesLayer(ids)
+	h.modules = &layer
+	return nil
+}
+
+// listCaddyModules runs `caddy list-modules --skip-standard` and returns the
+// IDs of the modules that are not part of standard Caddy.
+func listCaddyModules(ctx context.Context, binary string) ([]string, error) {
+	if binary == "" {
+		binary = "caddy"
+	}
+	cmd := exec.CommandContext(ctx, binary, "list-modules", "--skip-standard")
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return nil, fmt.Errorf("running %s list-modules: %w: %s", binary, err, msg)
+		}
+		return nil, fmt.Errorf("running %s list-modules: %w", binary, err)
+	}
+	return parseListModules(stdout.String()), nil
+}
+
+// parseListModules extracts the module IDs from the output of
+// `caddy list-modules`: the first field of every line except the count
+// lines closing each group, such as "  Non-standard modules: 2".
+func parseListModules(output string) []string {
+	var ids []string
+	for _, line := range strings.Split(output, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 0 || strings.Contains(line, "modules:") {
+			continue
+		}
+		ids = append(ids, fields[0])
+	}
+	return ids
+}
+
+// reloadSchemaCommand implements caddyls.reloadSchema. It takes no
+// arguments, re-reads the schema file, lists the modules of the local Caddy
+// build again when the caddyModules setting is on, and re-analyzes every
+// open document. It returns the merged schema like caddyls.schema.dump. When
+// a source cannot be read, the rest is still reloaded and the error
+// returned.
+func (h *Handler) reloadSchemaCommand(ctx *glsp.Context, args []any) (any, error) {
+	var errs []error
+	if err := h.loadSchemaFile(); err != nil {
+		errs = append(errs, fmt.Errorf("reading schema file: %w", err))
+	}
+	if err := h.loadCaddyModules(true); err != nil {
+		errs = append(errs, err)
+	}
+	h.rebuildSchema()
+	h.reanalyze(ctx, h.store.URIs())
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+	return h.currentSchema().Dump(), nil
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"os"
+	"path/filepath"
+	"runtime"
+	"slices"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestParseListModules(t *testing.T) {
+	out := "http.handlers.rate_limit\ndns.providers.cloudflare v0.2.1\n\n  Non-standard modules: 2\n\nhttp.handlers.broken [module not found]\n\n  Unknown modules: 1\n"
+	want := []string{"http.handlers.rate_limit", "dns.providers.cloudflare", "http.handlers.broken"}
+	if got := parseListModules(out); !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestReloadSchemaCommand(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("fake caddy binary is a shell script")
+	}
+	dir := t.TempDir()
+	write := func(name, content string) {
+		t.Helper()
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o755); err != nil {
+			t.Fatal(err)
+		}
+	}
+	write("caddy", "#!/bin/sh\ncat \""+filepath.Join(dir, "modules.txt")+"\"\n")
+	write("modules.txt", "http.handlers.rate_limit\n\n  Non-standard modules: 1\n")
+	write("schema.json", `{"directives": []}`)
+
+	var p recordingPublisher
+	h := NewWithPublisher(document.New(), &p)
+	rootURI := workspace.PathToURI(dir)
+	if _, err := h.Initialize(nil, &protocol.InitializeParams{
+		RootURI: &rootURI,
+		InitializationOptions: map[string]any{
+			"schemaPath":   "schema.json",
+			"caddyBinary":  filepath.Join(dir, "caddy"),
+			"caddyModules": true,
+		},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	uri := workspace.PathToURI(filepath.Join(dir, "Caddyfile"))
+	if err := h.DidOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: protocol.DocumentUri(uri), Text: "a.com {\n\trate_limit\n\tcache\n\tsouin\n}\n", Version: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	unknown := func(diags []protocol.Diagnostic) []string {
+		var names []string
+		for _, d := range diags {
+			if _, name, ok := strings.Cut(d.Message, "unknown directive "); ok {
+				names = append(names, strings.Fields(name)[0])
+			}
+		}
+		return names
+	}
+	if got := unknown(p.published[0].diags); !slices.Equal(got, []string{`"cache"`, `"souin"`}) {
+		t.Fatalf("before reload: unknown directives %v", got)
+	}
+
+	write("modules.txt", "http.handlers.rate_limit\nhttp.handlers.souin\n\n  Non-standard modules: 2\n")
+	write("schema.json", `{"directives": ["cache"]}`)
+	if _, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.reloadSchema"}); err != nil {
+		t.Fatal(err)
+	}
+	last := p.published[len(p.published)-1]
+	if len(p.published) != 2 || last.uri != uri || len(last.diags) != 0 {
+		t.Errorf("after reload: got %+v", p.published)
+	}
+
+	// A source that fails is reported, and the other one still reloaded.
+	write("schema.json", `{`)
+	write("modules.txt", "http.handlers.rate_limit\n\n  Non-standard modules: 1\n")
+	if _, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.reloadSchema"}); err == nil || !strings.Contains(err.Error(), "schema file") {
+		t.Errorf("want schema file error, got %v", err)
+	}
+	last = p.published[len(p.published)-1]
+	if got := unknown(last.diags); !slices.Equal(got, []string{`"souin"`}) {
+		t.Errorf("after failed reload: unknown directives %v, want only souin", got)
+	}
+}
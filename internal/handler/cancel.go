@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// operations tracks cancellable long-running work (e.g. workspace indexing)
+// keyed by the JSON-RPC request ID or progress token that identifies it.
+//
+// Keys are the raw JSON encoding of the ID so that the integer 1 and the
+// string "1" stay distinct, matching the LSP spec.
+type operations struct {
+	mu      sync.Mutex
+	cancels map[string]*operation
+}
+
+// operation is one registered operation. Entries are compared by pointer,
+// so that an operation finishing late cannot unregister a newer one
+// started under the same id.
+type operation struct {
+	cancel context.CancelFunc
+}
+
+func newOperations() *operations {
+	return &operations{cancels: make(map[string]*operation)}
+}
+
+// start registers a cancellable operation under id and returns its context
+// together with a done function that must be called when the work finishes.
+// done only unregisters this operation, not one registered under id since.
+func (o *operations) start(id string) (context.Context, func()) {
+	ctx, cancel := context.WithCancel(context.Background())
+	op := &operation{cancel: cancel}
+	o.mu.Lock()
+	o.cancels[id] = op
+	o.mu.Unlock()
+	return ctx, func() {
+		o.mu.Lock()
+		if o.cancels[id] == op {
+			delete(o.cancels, id)
+		}
+		o.mu.Unlock()
+		cancel()
+	}
+}
+
+// cancel cancels the operation registered under id. It reports whether an
+// operation was found.
+func (o *operations) cancel(id string) bool {
+	o.mu.Lock()
+	op, ok := o.cancels[id]
+	delete(o.cancels, id)
+	o.mu.Unlock()
+	if ok {
+		op.cancel()
+	}
+	return ok
+}
+
+// operationKey returns the map key for a raw JSON ID or token value.
+func operationKey(raw json.RawMessage) string {
+	var buf bytes.Buffer
+	if err := json.Compact(&buf, raw); err != nil {
+		return string(raw)
+	}
+	return buf.String()
+}
+
+// CancelRequest handles $/cancelRequest.
+//
+// The ID is decoded from the raw params because glsp's IntegerOrString
+// cannot unmarshal into itself.
+func (h *Handler) CancelRequest(ctx *glsp.Context, _ *protocol.CancelParams) error {
+	var params struct {
+		ID json.RawMessage `json:"id"`
+	}
+	if err := json.Unmarshal(ctx.Params, &params); err != nil || len(params.ID) == 0 {
+		return nil
+	}
+	if h.ops.cancel(operationKey(params.ID)) {
+		log.Debugf("cancelled operation %s", params.ID)
+	}
+	return nil
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/tliron/glsp"
+)
+
+func TestOperations_CancelStopsContext(t *testing.T) {
+	ops := newOperations()
+	ctx, done := ops.start("1")
+	defer done()
+	if !ops.cancel("1") {
+		t.Fatal("cancel: want true for registered operation")
+	}
+	if ctx.Err() == nil {
+		t.Error("context should be cancelled")
+	}
+}
+
+func TestOperations_CancelUnknown(t *testing.T) {
+	if newOperations().cancel("42") {
+		t.Error("cancel of unknown id: want false")
+	}
+}
+
+func TestOperations_DoneUnregisters(t *testing.T) {
+	ops := newOperations()
+	_, done := ops.start("1")
+	done()
+	if ops.cancel("1") {
+		t.Error("cancel after done: want false")
+	}
+}
+
+func TestCancelRequest_IntegerAndStringIDsDistinct(t *testing.T) {
+	h := New(nil)
+	intCtx, doneInt := h.ops.start(operationKey(json.RawMessage(`7`)))
+	defer doneInt()
+	strCtx, doneStr := h.ops.start(operationKey(json.RawMessage(`"7"`)))
+	defer doneStr()
+
+	h.CancelRequest(&glsp.Context{Params: json.RawMessage(`{"id": "7"}`)}, nil)
+	if strCtx.Err() == nil {
+		t.Error("string id 7 should be cancelled")
+	}
+	if intCtx.Err() != nil {
+		t.Error("integer id 7 must not be cancelled")
+	}
+}
+
+func TestOperations_DoneKeepsNewerOperation(t *testing.T) {
+	ops := newOperations()
+	_, doneOld := ops.start("1")
+	ctx, doneNew := ops.start("1")
+	defer doneNew()
+	doneOld()
+	if !ops.cancel("1") {
+		t.Fatal("done of the older operation unregistered the newer one")
+	}
+	if ctx.Err() == nil {
+		t.Error("newer operation should be cancelled")
+	}
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"regexp"
+	"slices"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// clientSupport records what the client declared it can handle during
+// initialize. The zero value, for a client that declared nothing, is full
+// support, so that bare clients keep working.
+type clientSupport struct {
+	// Content formats of hover text and of completion and signature
+	// documentation.
+	hoverFormat, completionDocFormat, signatureDocFormat protocol.MarkupKind
+	// Providers the client has no use for and that are not advertised.
+	noHover, noCompletion, noSignatureHelp, noCodeActions, noDocumentHighlight, noSemanticTokens bool
+	// noSnippets is set when completion items cannot insert snippets with
+	// tab stops.
+	noSnippets bool
+	// watchFiles is set when the client can register file watchers for
+	// workspace/didChangeWatchedFiles. Unlike the others, it must be
+	// declared.
+	watchFiles bool
+}
+
+// newClientSupport reads the client capabilities that shape responses. A
+// client that sends text document capabilities but leaves one out does not
+// support that request. Code actions are only returned as literals, so
+// clients without code action literal support get none.
+func newClientSupport(caps protocol.ClientCapabilities) clientSupport {
+	var c clientSupport
+	if w := caps.Workspace; w != nil && w.DidChangeWatchedFiles != nil {
+		c.watchFiles = w.DidChangeWatchedFiles.DynamicRegistration != nil && *w.DidChangeWatchedFiles.DynamicRegistration
+	}
+	td := caps.TextDocument
+	if td == nil {
+		return c
+	}
+	if td.Hover == nil {
+		c.noHover = true
+	} else {
+		c.hoverFormat = markupFormat(td.Hover.ContentFormat)
+	}
+	if td.Completion == nil {
+		c.noCompletion = true
+	} else if item := td.Completion.CompletionItem; item != nil {
+		c.completionDocFormat = markupFormat(item.DocumentationFormat)
+		c.noSnippets = item.SnippetSupport == nil || !*item.SnippetSupport
+	} else {
+		c.noSnippets = true
+	}
+	if td.SignatureHelp == nil {
+		c.noSignatureHelp = true
+	} else if info := td.SignatureHelp.SignatureInformation; info != nil {
+		c.signatureDocFormat = markupFormat(info.DocumentationFormat)
+	}
+	c.noCodeActions = td.CodeAction == nil || td.CodeAction.CodeActionLiteralSupport == nil
+	c.noDocumentHighlight = td.DocumentHighlight == nil
+	c.noSemanticTokens = td.SemanticTokens == nil
+	return c
+}
+
+// markupFormat picks the format to send for a client that accepts formats:
+// Markdown unless the list leaves it out.
+func markupFormat(formats []protocol.MarkupKind) protocol.MarkupKind {
+	if len(formats) == 0 || slices.Contains(formats, protocol.MarkupKindMarkdown) {
+		return protocol.MarkupKindMarkdown
+	}
+	return protocol.MarkupKindPlainText
+}
+
+// markup returns the Markdown text md in format, which is Markdown when
+// empty. Plain text drops the Markdown syntax but keeps the words.
+func markup(format protocol.MarkupKind, md string) protocol.MarkupContent {
+	if format == protocol.MarkupKindPlainText {
+		return protocol.MarkupContent{Kind: protocol.MarkupKindPlainText, Value: plainText(md)}
+	}
+	return protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: md}
+}
+
+// markdownHeading matches the marker of a heading line.
+var markdownHeading = regexp.MustCompile(`^#{1,6} `)
+
+// markdownLink matches an inline link, e.g. [text](https://...).
+var markdownLink = regexp.MustCompile(`\[([^\]]*)\]\(([^)\s]+)\)`)
+
+// plainText strips the Markdown the docs use: code fences, headings outside
+// them, bold markers, inline code and links, which become "text (url)".
+func plainText(md string) string {
+	lines := strings.Split(md, "\n")
+	out := lines[:0]
+	fenced := false
+	for _, line := range lines {
+		if strings.HasPrefix(strings.TrimSpace(line), "```") {
+			fenced = !fenced
+			continue
+		}
+		if !fenced {
+			line = markdownHeading.ReplaceAllString(line, "")
+		}
+		out = append(out, line)
+	}
+	text := strings.Join(out, "\n")
+	text = markdownLink.ReplaceAllString(text, "$1 ($2)")
+	return strings.NewReplacer("**", "", "`", "").Replace(text)
+}
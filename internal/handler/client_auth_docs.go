@@ -0,0 +1,100 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// clientAuthDocs documents the options of client_auth and of the trust
+// pool blocks, keyed like analysis.ClientAuthBlockKey. The generated docs
+// stop at `trust_pool <module>`, and mistakes here only show at runtime as
+// rejected handshakes.
+var clientAuthDocs = map[string]map[string]string{
+	"client_auth": {
+		"mode": "```\nmode request|require|verify_if_given|require_and_verify\n```\n\nWhether client certificates are asked for and checked. `request` and `require` do not verify them; `verify_if_given` verifies a certificate when one is sent, and `require_and_verify` always wants a valid one. Default `require_and_verify` with a `trust_pool`, otherwise `require`.",
+
+		"trust_pool": "```\ntrust_pool <provider> [<args...>] {\n    ...\n}\n```\n\nThe certificate authorities client certificates must chain to. Providers: `inline`, `file`, `pki_root`, `pki_intermediate`, `storage` and `http`.",
+
+		"trusted_leaf_cert": "```\ntrusted_leaf_cert <base64_der>\n```\n\nAccepts only this exact client certificate, given as base64 DER. May be repeated.",
+
+		"trusted_leaf_cert_file": "```\ntrusted_leaf_cert_file <pem_file>\n```\n\nAccepts only the client certificates in this PEM file. May be repeated.",
+
+		"verifier": "```\nverifier <module> [...]\n```\n\nAn extra check of client certificates done by a module, such as `leaf`.",
+
+		"trusted_ca_cert": "```\ntrusted_ca_cert <base64_der>\n```\n\nDeprecated: use `trust_pool inline { trust_der <base64_der> }`.",
+
+		"trusted_ca_cert_file": "```\ntrusted_ca_cert_file <pem_file>\n```\n\nDeprecated: use `trust_pool file <pem_file>`.",
+	},
+	"trust_pool inline": {
+		"trust_der": "```\ntrust_der <base64_der_cert...>\n```\n\nCA certificates to trust, as base64 DER. May be repeated.",
+	},
+	"trust_pool file": {
+		"pem_file": "```\npem_file <pem_files...>\n```\n\nPEM files holding the CA certificates to trust. They can also be given as arguments of `trust_pool file`. May be repeated.",
+	},
+	"trust_pool pki_root": {
+		"authority": "```\nauthority <ca_names...>\n```\n\nCertificate authorities of Caddy's `pki` app, such as `local`, whose root certificates are trusted. May be repeated.",
+	},
+	"trust_pool pki_intermediate": {
+		"authority": "```\nauthority <ca_names...>\n```\n\nCertificate authorities of Caddy's `pki` app, such as `local`, whose intermediate certificates are trusted. May be repeated.",
+	},
+	"trust_pool storage": {
+		"storage": "```\nstorage <module> {\n    ...\n}\n```\n\nThe storage holding the certificates. Default: the storage configured for Caddy.",
+
+		"keys": "```\nkeys <storage_keys...>\n```\n\nStorage keys of PEM files holding the CA certificates to trust. They can also be given as arguments of `trust_pool storage`. May be repeated.",
+	},
+	"trust_pool http": {
+		"endpoints": "```\nendpoints <urls...>\n```\n\nURLs serving PEM files of the CA certificates to trust, fetched when the config loads. They can also be given as arguments of `trust_pool http`.",
+
+		"tls": "```\ntls {\n    ca <provider> [...]\n    insecure_skip_verify\n    handshake_timeout <duration>\n    server_name <name>\n    renegotiation never|once|freely\n}\n```\n\nHow to connect to the endpoints over HTTPS.",
+	},
+	"trust_pool http>tls": {
+		"ca": "```\nca <provider> [...]\n```\n\nThe certificate authorities the endpoints' certificates must chain to, in the form of a trust pool. Default: the system roots.",
+
+		"insecure_skip_verify": "```\ninsecure_skip_verify\n```\n\nTurns off verification of the endpoints' certificates, leaving the trust pool open to man-in-the-middle attacks.",
+
+		"handshake_timeout": "```\nhandshake_timeout <duration>\n```\n\nHow long a TLS handshake with an endpoint may take. No timeout by default.",
+
+		"server_name": "```\nserver_name <name>\n```\n\nThe name the endpoints' certificates are verified against, instead of the host of their URLs.",
+
+		"renegotiation": "```\nrenegotiation never|once|freely\n```\n\nWhether endpoints may ask to renegotiate TLS. Default `never`.",
+	},
+}
+
+// lookupClientAuthDoc documents name inside the client_auth or trust pool
+// block innermost in parents.
+func lookupClientAuthDoc(name string, parents []*parser.Directive) (string, bool) {
+	key := analysis.ClientAuthBlockKey(parents)
+	doc, ok := clientAuthDocs[key][name]
+	if !ok {
+		return "", false
+	}
+	return "**`" + name + "`** in `" + strings.ReplaceAll(key, ">", " ") + "`\n\n" + doc, true
+}
+
+// clientAuthValueCompletions offers the modes of client_auth after `mode`.
+// Trust pool providers are offered by moduleCompletions.
+func clientAuthValueCompletions(f *parser.File, content string, pos protocol.Position) ([]protocol.CompletionItem, bool) {
+	chain := enclosingDirectives(f, pos)
+	if analysis.ClientAuthBlockKey(chain) != "client_auth" {
+		return nil, false
+	}
+	d := directiveOnLine(f, pos, "mode", chain[len(chain)-1].Name.Value)
+	if d == nil {
+		return nil, false
+	}
+	i, partial, ok := argumentAt(content, d, pos)
+	if !ok || i != 0 {
+		return nil, false
+	}
+	kind := protocol.CompletionItemKindEnumMember
+	items := []protocol.CompletionItem{}
+	for _, v := range analysis.ClientAuthModes() {
+		if strings.HasPrefix(v, partial) {
+			items = append(items, protocol.CompletionItem{Label: v, Kind: &kind})
+		}
+	}
+	return items, true
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"encoding/json"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestNewClientSupport(t *testing.T) {
+	if got := newClientSupport(protocol.ClientCapabilities{}); got != (clientSupport{}) {
+		t.Errorf("client without text document capabilities: got %+v, want full support", got)
+	}
+
+	got := newClientSupport(protocol.ClientCapabilities{
+		TextDocument: &protocol.TextDocumentClientCapabilities{
+			Hover:      &protocol.HoverClientCapabilities{ContentFormat: []protocol.MarkupKind{protocol.MarkupKindPlainText}},
+			Completion: &protocol.CompletionClientCapabilities{},
+			CodeAction: &protocol.CodeActionClientCapabilities{},
+		},
+	})
+	want := clientSupport{
+		hoverFormat:         protocol.MarkupKindPlainText,
+		noSignatureHelp:     true,
+		noCodeActions:       true, // no code action literal support
+		noDocumentHighlight: true,
+		noSemanticTokens:    true,
+		noSnippets:          true, // completion items without snippet support
+	}
+	if got != want {
+		t.Errorf("got %+v, want %+v", got, want)
+	}
+
+	var caps protocol.ClientCapabilities
+	if err := json.Unmarshal([]byte(`{"textDocument":{"completion":{"completionItem":{"snippetSupport":true}}}}`), &caps); err != nil {
+		t.Fatal(err)
+	}
+	got = newClientSupport(caps)
+	if got.noSnippets {
+		t.Error("snippet support not recognized")
+	}
+
+	if err := json.Unmarshal([]byte(`{"workspace":{"didChangeWatchedFiles":{"dynamicRegistration":true}}}`), &caps); err != nil {
+		t.Fatal(err)
+	}
+	if !newClientSupport(caps).watchFiles {
+		t.Error("file watcher registration not recognized")
+	}
+}
+
+func TestPlainText(t *testing.T) {
+	md := "## respond\n\nWrites a **hard-coded** response, see [the docs](https://caddyserver.com/docs).\n\n```caddyfile\n# a comment\nrespond `ok`\n```"
+	want := "respond\n\nWrites a hard-coded response, see the docs (https://caddyserver.com/docs).\n\n# a comment\nrespond ok"
+	if got := plainText(md); got != want {
+		t.Errorf("plainText:\ngot  %q\nwant %q", got, want)
+	}
+}
+
+func TestInitialize_ClientCapabilities(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	h := New(document.New())
+	res, err := h.Initialize(nil, &protocol.InitializeParams{
+		Capabilities: protocol.ClientCapabilities{
+			TextDocument: &protocol.TextDocumentClientCapabilities{
+				Hover: &protocol.HoverClientCapabilities{ContentFormat: []protocol.MarkupKind{protocol.MarkupKindPlainText}},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	caps := res.(protocol.InitializeResult).Capabilities
+	if caps.HoverProvider != true || caps.CompletionProvider != nil || caps.SignatureHelpProvider != nil || caps.CodeActionProvider != nil || caps.DocumentHighlightProvider != nil || caps.SemanticTokensProvider != nil {
+		t.Errorf("want only hover advertised, got %+v", caps)
+	}
+
+	h.store.Open(uri, "a.com {\n\trespond ok\n}\n", 1)
+	hover, err := h.Hover(nil, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Position:     pos(1, 3),
+	}})
+	if err != nil || hover == nil {
+		t.Fatalf("hover = %v, %v", hover, err)
+	}
+	content := hover.Contents.(protocol.MarkupContent)
+	if content.Kind != protocol.MarkupKindPlainText || strings.Contains(content.Value, "**") {
+		t.Errorf("want plain text hover, got %+v", content)
+	}
+}
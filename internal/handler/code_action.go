@@ -0,0 +1,87 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// CodeAction handles textDocument/codeAction. It offers a quick fix for each
+// diagnostic in the request that has one, and refactorings of the selected
+// directives. Fixes are recomputed from the current content rather than
+// carried in the diagnostics, so they never apply to a stale version of the
+// document.
+func (h *Handler) CodeAction(ctx *glsp.Context, params *protocol.CodeActionParams) (any, error) {
+	actions := []protocol.CodeAction{}
+	uri := string(params.TextDocument.URI)
+	content, ok := h.store.Get(uri)
+	if !ok || h.tooLarge(content) {
+		return actions, nil
+	}
+	ast, _ := parser.Parse(content)
+	if wantsKind(params.Context.Only, protocol.CodeActionKindRefactorRewrite) {
+		actions = append(actions, wrapActions(params.TextDocument.URI, content, ast, params.Range)...)
+	}
+	if len(params.Context.Diagnostics) == 0 {
+		return actions, nil
+	}
+	fixes := lintFixes(content, ast, h.settingsFor(params.TextDocument.URI).Lint)
+	kind := protocol.CodeActionKindQuickFix
+	for _, d := range params.Context.Diagnostics {
+		for _, fix := range fixes {
+			if !sameDiagnostic(d, fix.Diagnostic) {
+				continue
+			}
+			actions = append(actions, protocol.CodeAction{
+				Title:       fix.Title,
+				Kind:        &kind,
+				Diagnostics: []protocol.Diagnostic{d},
+				IsPreferred: boolPtr(!fix.Alternative),
+				Edit: &protocol.WorkspaceEdit{
+					Changes: map[protocol.DocumentUri][]protocol.TextEdit{params.TextDocument.URI: append([]protocol.TextEdit{fix.Edit}, fix.Also...)},
+				},
+			})
+		}
+	}
+	return actions, nil
+}
+
+// lintFixes returns the fixable diagnostics for content, whose parse is ast,
+// with the opt-in rules enabled in lint.
+func lintFixes(content string, ast *parser.File, lint map[string]bool) []analysis.Fix {
+	fixes := analysis.AnalyzeConfusables(content)
+	fixes = append(fixes, analysis.AnalyzeWhitespace(content, ast, lint)...)
+	fixes = append(fixes, analysis.AnalyzeEmptyBlocks(content, ast, lint)...)
+	fixes = append(fixes, analysis.AnalyzeNoOpSites(content, ast, lint)...)
+	return append(fixes, analysis.AnalyzeDuplicateRuns(content, ast, lint)...)
+}
+
+// wantsKind reports whether a client that asked for the code action kinds
+// only, or for all kinds when only is empty, wants actions of kind.
+func wantsKind(only []protocol.CodeActionKind, kind protocol.CodeActionKind) bool {
+	if len(only) == 0 {
+		return true
+	}
+	for _, k := range only {
+		if k == kind || strings.HasPrefix(string(kind), string(k)+".") {
+			return true
+		}
+	}
+	return false
+}
+
+// sameDiagnostic reports whether a diagnostic sent back by the client is b.
+func sameDiagnostic(a, b protocol.Diagnostic) bool {
+	return a.Range == b.Range && a.Message == b.Message && diagnosticCode(a) == diagnosticCode(b)
+}
+
+// diagnosticCode returns d's code, or nil.
+func diagnosticCode(d protocol.Diagnostic) any {
+	if d.Code == nil {
+		return nil
+	}
+	return d.Code.Value
+}
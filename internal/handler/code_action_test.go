@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestCodeAction_WhitespaceQuickFix(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	h := New(document.New())
+	h.applySettings(Settings{Lint: map[string]bool{"trailing-whitespace": true}})
+	h.store.Open(uri, "example.com { \n}\n", 1)
+
+	diags := h.diagnose(uri, "example.com { \n}\n")
+	if len(diags) != 1 {
+		t.Fatalf("diagnostics = %+v", diags)
+	}
+	got, err := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diags[0].Range,
+		Context:      protocol.CodeActionContext{Diagnostics: diags},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := got.([]protocol.CodeAction)
+	if len(actions) != 1 || actions[0].Title != "Remove trailing whitespace" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	edits := actions[0].Edit.Changes[uri]
+	if len(edits) != 1 || edits[0].Range != diags[0].Range || edits[0].NewText != "" {
+		t.Errorf("edits = %+v", edits)
+	}
+}
+
+func TestCodeAction_NoFixForOtherDiagnostics(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	h := New(document.New())
+	h.store.Open(uri, "example.com {\n\tbogus\n}\n", 1)
+	diags := h.diagnose(uri, "example.com {\n\tbogus\n}\n")
+	got, _ := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Context:      protocol.CodeActionContext{Diagnostics: diags},
+	})
+	if actions := got.([]protocol.CodeAction); len(actions) != 0 {
+		t.Errorf("actions = %+v", actions)
+	}
+}
+
+func TestCodeAction_ConfusableQuickFix(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	src := "example.com {\n\trespond “ok”\n}\n"
+	h := New(document.New())
+	h.store.Open(uri, src, 1)
+	diags := h.diagnose(uri, src)
+	got, _ := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Context:      protocol.CodeActionContext{Diagnostics: diags},
+	})
+	actions := got.([]protocol.CodeAction)
+	if len(actions) != 2 || actions[0].Title != `Replace with "\""` {
+		t.Fatalf("actions = %+v", actions)
+	}
+}
+
+func TestCodeAction_EmptyBlockFixes(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	const src = "example.com {\n\thandle {\n\t}\n\tfile_server\n}\n"
+	h := New(document.New())
+	h.applySettings(Settings{Lint: map[string]bool{"empty-block": true}})
+	h.store.Open(uri, src, 1)
+
+	diags := h.diagnose(uri, src)
+	if len(diags) != 1 || diags[0].Message != "empty handle block" {
+		t.Fatalf("diagnostics = %+v", diags)
+	}
+	got, err := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diags[0].Range,
+		Context:      protocol.CodeActionContext{Diagnostics: diags, Only: []protocol.CodeActionKind{protocol.CodeActionKindQuickFix}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := got.([]protocol.CodeAction)
+	if len(actions) != 2 || actions[0].Title != "Remove the empty braces" || actions[1].Title != "Remove handle" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	if !*actions[0].IsPreferred || *actions[1].IsPreferred {
+		t.Errorf("want only removing the braces preferred, got %v and %v", *actions[0].IsPreferred, *actions[1].IsPreferred)
+	}
+}
+
+func TestCodeAction_NoHandlerFix(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	const src = "example.com {\n\theader X-Frame-Options DENY\n}\n"
+	h := New(document.New())
+	h.applySettings(Settings{Lint: map[string]bool{"no-handler": true}})
+	h.store.Open(uri, src, 1)
+
+	diags := h.diagnose(uri, src)
+	if len(diags) != 1 || *diags[0].Severity != protocol.DiagnosticSeverityInformation {
+		t.Fatalf("diagnostics = %+v", diags)
+	}
+	got, err := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diags[0].Range,
+		Context:      protocol.CodeActionContext{Diagnostics: diags},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := got.([]protocol.CodeAction)
+	if len(actions) != 1 || actions[0].Title != `Add respond "OK"` {
+		t.Fatalf("actions = %+v", actions)
+	}
+	if edits := actions[0].Edit.Changes[uri]; len(edits) != 1 || edits[0].NewText != "\trespond \"OK\"\n" || edits[0].Range.Start.Line != 2 {
+		t.Errorf("edits = %+v", edits)
+	}
+}
+
+func TestCodeAction_ExtractDuplicateDirectives(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	run := "\tencode gzip\n\theader -Server\n\tfile_server\n"
+	src := "a.com {\n" + run + "}\n\nb.com {\n\troot * /srv\n" + run + "}\n"
+	h := New(document.New())
+	h.applySettings(Settings{Lint: map[string]bool{"duplicate-directives": true}})
+	h.store.Open(uri, src, 1)
+
+	diags := h.diagnose(uri, src)
+	if len(diags) != 2 {
+		t.Fatalf("diagnostics = %+v", diags)
+	}
+	got, err := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        diags[1].Range,
+		Context:      protocol.CodeActionContext{Diagnostics: diags[1:], Only: []protocol.CodeActionKind{protocol.CodeActionKindQuickFix}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	actions := got.([]protocol.CodeAction)
+	if len(actions) != 1 || actions[0].Title != "Extract into snippet (shared)" {
+		t.Fatalf("actions = %+v", actions)
+	}
+	edits := actions[0].Edit.Changes[uri]
+	out := src
+	for i := len(edits) - 1; i >= 0; i-- { // the edits are in document order
+		out = applyEdit(out, edits[i])
+	}
+	want := "(shared) {\n" + run + "}\n\na.com {\n\timport shared\n}\n\nb.com {\n\troot * /srv\n\timport shared\n}\n"
+	if out != want {
+		t.Errorf("after the fix:\n%s\nwant:\n%s", out, want)
+	}
+	if diags := h.diagnose(uri, out); len(diags) != 0 {
+		t.Errorf("diagnostics after the fix = %+v", diags)
+	}
+}
@@ -0,0 +1,52 @@
+package handler
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// commandFunc runs one workspace/executeCommand command.
+type commandFunc func(h *Handler, ctx *glsp.Context, args []any) (any, error)
+
+// commands are the commands advertised in executeCommandProvider.
+var commands = map[string]commandFunc{
+	"caddyls.reloadSchema":      (*Handler).reloadSchemaCommand,
+	"caddyls.schema.dump":       (*Handler).schemaDumpCommand,
+	"caddyls.stats":             (*Handler).statsCommand,
+	"caddyls.validateWithCaddy": (*Handler).validateWithCaddyCommand,
+}
+
+// commandNames returns the names of all commands, sorted.
+func commandNames() []string {
+	names := make([]string, 0, len(commands))
+	for name := range commands {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ExecuteCommand handles workspace/executeCommand.
+func (h *Handler) ExecuteCommand(ctx *glsp.Context, params *protocol.ExecuteCommandParams) (any, error) {
+	cmd, ok := commands[params.Command]
+	if !ok {
+		return nil, fmt.Errorf("unknown command %q", params.Command)
+	}
+	return cmd(h, ctx, params.Arguments)
+}
+
+// uriArgument returns the document URI passed as the first command
+// argument.
+func uriArgument(args []any) (string, error) {
+	if len(args) == 0 {
+		return "", fmt.Errorf("missing document URI argument")
+	}
+	uri, ok := args[0].(string)
+	if !ok || uri == "" {
+		return "", fmt.Errorf("document URI argument must be a string, got %v", args[0])
+	}
+	return uri, nil
+}
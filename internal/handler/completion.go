@@ -1,8 +1,9 @@
 package handler
 
 import (
-	"caddy-ls/internal/analysis"
-	"caddy-ls/internal/parser"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
 	"sort"
 	"strings"
 
@@ -10,31 +11,87 @@ import (
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-// topLevelDirectives is built from the authoritative KnownTopLevel set so that
-// completion items are always in sync with the analyzer's validation rules.
-var topLevelDirectives = func() []string {
-	names := make([]string, 0, len(analysis.KnownTopLevel))
-	for name := range analysis.KnownTopLevel {
-		names = append(names, name)
-	}
-	sort.Strings(names)
-	return names
-}()
-
 // Completion handles textDocument/completion.
 func (h *Handler) Completion(ctx *glsp.Context, params *protocol.CompletionParams) (any, error) {
 	empty := []protocol.CompletionItem{}
 
 	content, ok := h.store.Get(string(params.TextDocument.URI))
-	if !ok {
+	if !ok || h.tooLarge(content) || !h.init.Features.completion() {
 		return empty, nil
 	}
 
+	// After "{$" suggest environment variable names from the configured and
+	// workspace env sources.
+	if partial, ok := envNamePrefix(content, params.Position); ok {
+		return envCompletions(h.envCompletion, partial, nextCharIs(content, params.Position, '}')), nil
+	}
+
+	// Inside "{" suggest runtime placeholders, such as the variables set
+	// by vars directives.
+	if partial, start, ok := placeholderPrefix(content, params.Position); ok {
+		ast, _ := parser.Parse(content)
+		return placeholderCompletions(ast, partial, start, params.Position, nextCharIs(content, params.Position, '}')), nil
+	}
+
 	// When the cursor is in the argument position of an "import" directive,
-	// suggest snippet names defined in the current file.
+	// suggest snippet names defined in the current file and in the files it
+	// imports.
 	if partial, ok := importArgPrefix(content, params.Position); ok {
+		uri := string(params.TextDocument.URI)
 		ast, _ := parser.Parse(content)
-		return snippetCompletions(ast, partial), nil
+		items := snippetCompletions(content, ast, partial)
+		return append(items, h.importedSnippetCompletions(uri, ast, h.importedSnippets(uri, ast), partial)...), nil
+	}
+
+	// A "@" in argument position references a named matcher; only the
+	// matchers visible from the cursor's block are offered.
+	if partial, ok := matcherArgPrefix(content, params.Position); ok {
+		ast, _ := parser.Parse(content)
+		return matcherCompletions(ast, params.Position, partial), nil
+	}
+
+	ast, _ := parser.Parse(content)
+
+	// The protocols of a servers global option are offered as arguments.
+	if items, ok := serverProtocolCompletions(ast, params.Position); ok {
+		return items, nil
+	}
+
+	// The file systems declared in the global options are offered as the
+	// argument of fs.
+	if items, ok := filesystemCompletions(ast, content, params.Position); ok {
+		return items, nil
+	}
+
+	// Header names are offered in the field position of header, status
+	// codes where respond, error and redir take one, the modes of
+	// client_auth, and module names where a directive takes one, such as
+	// issuers and trust pool providers.
+	if items, ok := headerFieldCompletions(ast, content, params.Position, !h.client.noSnippets); ok {
+		return items, nil
+	}
+	if items, ok := statusCompletions(ast, content, params.Position); ok {
+		return items, nil
+	}
+	if items, ok := clientAuthValueCompletions(ast, content, params.Position); ok {
+		return items, nil
+	}
+	if items, ok := moduleCompletions(h.settingsFor(params.TextDocument.URI).schema, ast, content, params.Position); ok {
+		return items, nil
+	}
+
+	// Where a site address is typed, the host names of the configured
+	// address sources are offered.
+	if sources := h.settings.Completion.AddressSources; len(sources) > 0 {
+		if partial, ok := siteAddressPrefix(ast, content, params.Position); ok {
+			return addressCompletions(ast, sources, h.roots, partial), nil
+		}
+	}
+
+	// In a named matcher definition, its matcher types are offered, for the
+	// one-line and the block form alike.
+	if items, ok := h.matcherTypeCompletions(params.TextDocument.URI, ast, content, params.Position); ok {
+		return items, nil
 	}
 
 	// Only suggest directives when the cursor is on the first token of the
@@ -43,22 +100,52 @@ func (h *Handler) Completion(ctx *glsp.Context, params *protocol.CompletionParam
 		return empty, nil
 	}
 
-	ast, _ := parser.Parse(content)
-	names := completionNamesAt(ast, params.Position.Line)
-	if names == nil {
+	schema := h.settingsFor(params.TextDocument.URI).schema
+	scope := completionScopeAt(schema, ast, params.Position)
+	if scope.names == nil {
 		return empty, nil
 	}
+	// Argument templates are only inserted when nothing follows the cursor,
+	// where they cannot clash with arguments already typed.
+	templates := !h.client.noSnippets && scope.parent != "" &&
+		strings.TrimSpace(strings.Split(content, "\n")[params.Position.Line][len(lineBefore(content, params.Position)):]) == ""
+	snippetFormat := protocol.InsertTextFormatSnippet
 
 	kind := protocol.CompletionItemKindKeyword
-	items := make([]protocol.CompletionItem, 0, len(names))
-	for _, name := range names {
+	items := make([]protocol.CompletionItem, 0, len(scope.names))
+	for _, name := range scope.names {
 		n := name
+		sortText := completionSortText(scope, n)
 		item := protocol.CompletionItem{
-			Label: n,
-			Kind:  &kind,
+			Label:            n,
+			Kind:             &kind,
+			SortText:         &sortText,
+			CommitCharacters: directiveCommitCharacters,
 		}
-		if doc, ok := lookupDirectiveDoc(n); ok {
-			item.Documentation = protocol.MarkupContent{Kind: protocol.MarkupKindMarkdown, Value: doc}
+		if scope.global {
+			items = append(items, item)
+			continue
+		}
+		if scope.block != "" {
+			if doc, ok := clientAuthDocs[scope.block][n]; ok {
+				item.Documentation = markup(h.client.completionDocFormat, doc)
+			}
+			items = append(items, item)
+			continue
+		}
+		if tmpl := schema.InsertTemplate(scope.parent, n); templates && tmpl != "" {
+			// A space typed to commit would replace the first placeholder.
+			item.InsertText = strPtr(n + " " + tmpl)
+			item.InsertTextFormat = &snippetFormat
+			item.CommitCharacters = nil
+		}
+		if h.settings.Completion.InsertBraces && blockDirectives[n] {
+			if edit, ok := braceSkeleton(content, params.Position); ok {
+				item.AdditionalTextEdits = []protocol.TextEdit{edit}
+			}
+		}
+		if doc, ok := h.docs.Directive(n); ok {
+			item.Documentation = markup(h.client.completionDocFormat, doc)
 		}
 		items = append(items, item)
 	}
@@ -69,15 +156,10 @@ func (h *Handler) Completion(ctx *glsp.Context, params *protocol.CompletionParam
 // of an "import" directive on the current line. If so, it returns the partial
 // snippet name typed so far (may be empty) and true.
 func importArgPrefix(content string, pos protocol.Position) (string, bool) {
-	lines := strings.Split(content, "\n")
-	if int(pos.Line) >= len(lines) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
 		return "", false
 	}
-	line := lines[pos.Line]
-	col := int(pos.Character)
-	if col > len(line) {
-		col = len(line)
-	}
 	// Normalise indentation.
 	prefix := strings.TrimLeft(line[:col], " \t")
 	// Must start with "import" followed by at least one space/tab.
@@ -94,41 +176,115 @@ func importArgPrefix(content string, pos protocol.Position) (string, bool) {
 	return arg, true
 }
 
+// nextCharIs reports whether the character right after pos is c.
+func nextCharIs(content string, pos protocol.Position, c byte) bool {
+	line, col, ok := lineAt(content, pos)
+	return ok && col < len(line) && line[col] == c
+}
+
 // snippetCompletions returns CompletionItems for all snippet names defined in f
-// whose name starts with partial.
-func snippetCompletions(f *parser.File, partial string) []protocol.CompletionItem {
+// whose name starts with partial, documented by the comment above their
+// definition in src.
+func snippetCompletions(src string, f *parser.File, partial string) []protocol.CompletionItem {
 	names := analysis.CollectSnippetNames(f)
 	kind := protocol.CompletionItemKindModule
 	items := make([]protocol.CompletionItem, 0, len(names))
 	for _, name := range names {
 		if strings.HasPrefix(name, partial) {
-			n := name
-			items = append(items, protocol.CompletionItem{
-				Label: n,
+			item := protocol.CompletionItem{
+				Label: name,
 				Kind:  &kind,
-			})
+			}
+			if doc, _ := snippetComment(src, f, name); doc != "" {
+				item.Documentation = doc
+			}
+			items = append(items, item)
 		}
 	}
 	return items
 }
 
+// importedSnippetCompletions returns CompletionItems for the imported snippets
+// whose name starts with partial, skipping names f defines itself. The
+// defining file is shown as the item's detail, and the comment above the
+// definition as its documentation.
+func (h *Handler) importedSnippetCompletions(uri string, f *parser.File, snippets []importedSnippet, partial string) []protocol.CompletionItem {
+	local := make(map[string]bool)
+	for _, name := range analysis.CollectSnippetNames(f) {
+		local[name] = true
+	}
+	kind := protocol.CompletionItemKindModule
+	var items []protocol.CompletionItem
+	for _, s := range snippets {
+		if local[s.Name] || !strings.HasPrefix(s.Name, partial) {
+			continue
+		}
+		local[s.Name] = true
+		detail := relativeTo(uri, s.Path)
+		item := protocol.CompletionItem{
+			Label:  s.Name,
+			Kind:   &kind,
+			Detail: &detail,
+		}
+		if doc := h.importedSnippetComment(s); doc != "" {
+			item.Documentation = doc
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
+// matcherArgPrefix reports whether the cursor is at the end of a "@..."
+// token in argument position, i.e. not the first token of the line, which
+// would be a matcher definition. It returns the token typed so far.
+func matcherArgPrefix(content string, pos protocol.Position) (string, bool) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return "", false
+	}
+	before := strings.TrimLeft(line[:col], " \t")
+	i := strings.LastIndexAny(before, " \t")
+	if i < 0 {
+		return "", false
+	}
+	word := before[i+1:]
+	if !strings.HasPrefix(word, "@") {
+		return "", false
+	}
+	return word, true
+}
+
+// matcherCompletions returns CompletionItems for the named matchers visible
+// at pos whose name starts with partial. The matcher types a definition
+// uses are shown as the item's detail.
+func matcherCompletions(f *parser.File, pos protocol.Position, partial string) []protocol.CompletionItem {
+	kind := protocol.CompletionItemKindReference
+	items := []protocol.CompletionItem{}
+	for _, m := range analysis.CollectMatchers(f).Visible(pos) {
+		if !strings.HasPrefix(m.Name, partial) {
+			continue
+		}
+		item := protocol.CompletionItem{Label: m.Name, Kind: &kind}
+		if len(m.Types) > 0 {
+			detail := strings.Join(m.Types, ", ")
+			item.Detail = &detail
+		}
+		items = append(items, item)
+	}
+	return items
+}
+
 // atFirstTokenPosition reports whether the cursor is still within the first
 // non-whitespace token of the current line (i.e. the user is typing a
 // directive name, not an argument to one).
 func atFirstTokenPosition(content string, pos protocol.Position) bool {
-	lines := strings.Split(content, "\n")
-	lineIdx := int(pos.Line)
-	if lineIdx >= len(lines) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
 		return false
 	}
-	line := lines[lineIdx]
-	charIdx := int(pos.Character)
-	if charIdx > len(line) {
-		charIdx = len(line)
-	}
 	// Strip leading whitespace; if any whitespace remains, the cursor has
 	// already moved past the first token into an argument position.
-	trimmed := strings.TrimLeft(line[:charIdx], " \t")
+	trimmed := strings.TrimLeft(line[:col], " \t")
 	return !strings.ContainsAny(trimmed, " \t")
 }
 
@@ -141,49 +297,197 @@ var containerDirectives = map[string]bool{
 	"route":         true,
 }
 
-// completionNamesAt returns the sorted list of names to complete at cursorLine,
-// or nil when the cursor is not in a completable position (outside all site
+// completionNamesAt returns the sorted list of names to complete at pos, or
+// nil when the cursor is not in a completable position (outside all site
 // blocks, on an address line, or inside a freeform/unknown directive body).
-func completionNamesAt(f *parser.File, cursorLine uint32) []string {
+// Custom directives placed with the `order` global option are offered
+// alongside the built-in ones.
+func completionNamesAt(f *parser.File, pos protocol.Position) []string {
+	return completionScopeAt(analysis.DefaultSchema(), f, pos).names
+}
+
+// completionScope is the block completion happens in and the names valid
+// there.
+type completionScope struct {
+	names []string
+	// parent is the directive whose subdirectives names lists, or empty at
+	// site-block level and inside containers.
+	parent string
+	// global is set inside a block of the global options, whose names are
+	// not directives.
+	global bool
+	// block is set inside a client_auth or trust pool block, to its key as
+	// analysis.ClientAuthBlockKey returns it. Its names are documented by
+	// clientAuthDocs rather than as directives.
+	block string
+}
+
+// completionScopeAt is like completionNamesAt but offers the names of
+// schema s and also returns the surrounding block.
+func completionScopeAt(s *analysis.Schema, f *parser.File, pos protocol.Position) completionScope {
+	topLevel := s.Directives()
+	if ordered := analysis.OrderedDirectives(f); len(ordered) > 0 {
+		topLevel = append(ordered, topLevel...)
+		sort.Strings(topLevel)
+		topLevel = slices.Compact(topLevel)
+	}
 	for _, sb := range f.SiteBlocks {
-		if cursorLine <= sb.StartLine || cursorLine >= sb.EndLine {
+		if !sb.BodyContains(pos) {
+			continue
+		}
+		return directiveScopeAt(s, sb.Directives, pos, topLevel)
+	}
+	if f.GlobalBlock != nil && f.GlobalBlock.BodyContains(pos) {
+		return globalScopeAt(f.GlobalBlock.Directives, pos)
+	}
+	return completionScope{}
+}
+
+// globalScopeAt returns the scope to complete in at pos inside the global
+// options block: the names of the innermost known option block containing
+// pos, such as `servers` or its `timeouts`.
+func globalScopeAt(directives []*parser.Directive, pos protocol.Position) completionScope {
+	var path []string
+	for {
+		i := slices.IndexFunc(directives, func(d *parser.Directive) bool { return d.BodyContains(pos) })
+		if i < 0 {
+			break
+		}
+		path = append(path, directives[i].Name.Value)
+		directives = directives[i].Body
+	}
+	names, ok := analysis.GlobalBlockNames(path)
+	if !ok {
+		return completionScope{}
+	}
+	return completionScope{names: names, parent: strings.Join(path, " "), global: true}
+}
+
+// serverProtocolCompletions offers the protocols not yet listed when pos is
+// in the arguments of `protocols` inside a servers global option.
+func serverProtocolCompletions(f *parser.File, pos protocol.Position) ([]protocol.CompletionItem, bool) {
+	if f.GlobalBlock == nil {
+		return nil, false
+	}
+	for _, d := range f.GlobalBlock.Directives {
+		if d.Name.Value != "servers" || !d.BodyContains(pos) {
 			continue
 		}
-		return directiveNamesAt(sb.Directives, cursorLine)
+		for _, sub := range d.Body {
+			if sub.Name.Value != "protocols" || sub.Name.Line != pos.Line || pos.Character <= sub.Name.Range().End.Character {
+				continue
+			}
+			kind := protocol.CompletionItemKindEnumMember
+			items := []protocol.CompletionItem{}
+			for _, proto := range analysis.ServerProtocols() {
+				if slices.ContainsFunc(sub.Args, func(a *parser.Argument) bool {
+					return a.Token.Value == proto && !tokenContains(a.Token, pos)
+				}) {
+					continue
+				}
+				items = append(items, protocol.CompletionItem{Label: proto, Kind: &kind})
+			}
+			return items, true
+		}
 	}
-	return nil
+	return nil, false
 }
 
-// directiveNamesAt walks a directive list and returns the names to complete at
-// cursorLine. It recurses into container directives and returns subdirective
-// names when the cursor is inside a directive with known subdirectives.
-func directiveNamesAt(directives []*parser.Directive, cursorLine uint32) []string {
+// directiveScopeAt walks a directive list and returns the scope to complete
+// in at pos. It recurses into container directives and returns subdirective
+// names when the cursor is inside a directive with known subdirectives, and
+// topLevel when it is directly inside a site block or container.
+func directiveScopeAt(s *analysis.Schema, directives []*parser.Directive, pos protocol.Position, topLevel []string) completionScope {
 	for _, d := range directives {
-		if !hasBody(d) || cursorLine <= d.StartLine || cursorLine >= d.EndLine {
+		if !d.BodyContains(pos) {
 			continue
 		}
 		// Cursor is inside this directive's body block.
 		if containerDirectives[d.Name.Value] {
-			return directiveNamesAt(d.Body, cursorLine)
+			return directiveScopeAt(s, d.Body, pos, topLevel)
 		}
-		subDirs, known := analysis.SubDirectivesFor(d.Name.Value)
+		if d.Name.Value == "reverse_proxy" {
+			for _, sub := range d.Body {
+				if sub.Name.Value == "handle_response" && sub.BodyContains(pos) {
+					return responseScopeAt(s, sub, pos, topLevel)
+				}
+			}
+		}
+		if d.Name.Value == "intercept" {
+			// Its handle_response blocks hold site-level directives only.
+			for _, sub := range d.Body {
+				if sub.Name.Value == "handle_response" && sub.BodyContains(pos) {
+					return directiveScopeAt(s, sub.Body, pos, topLevel)
+				}
+			}
+		}
+		// The blocks of client_auth and of trust pools, also found in
+		// transport http as tls_trust_pool, have their own names.
+		if d.Name.Value == "tls" || d.Name.Value == "reverse_proxy" {
+			chain := []*parser.Directive{d}
+			for inner := d; ; {
+				i := slices.IndexFunc(inner.Body, func(sub *parser.Directive) bool { return sub.BodyContains(pos) })
+				if i < 0 {
+					break
+				}
+				inner = inner.Body[i]
+				chain = append(chain, inner)
+			}
+			if names, ok := analysis.ClientAuthBlockNames(chain); ok {
+				return completionScope{names: names, parent: chain[len(chain)-1].Name.Value, block: analysis.ClientAuthBlockKey(chain)}
+			}
+		}
+		if d.Name.Value == "push" && slices.ContainsFunc(d.Body, func(sub *parser.Directive) bool { return sub.BodyContains(pos) }) {
+			// The headers block holds header operations, not names.
+			return completionScope{}
+		}
+		subDirs, known := s.SubDirectivesFor(d.Name.Value)
 		if !known || subDirs == nil {
 			// Unknown or freeform directive — no completions.
-			return nil
+			return completionScope{}
 		}
 		names := make([]string, 0, len(subDirs))
 		for name := range subDirs {
+			if !s.IsRepeatable(d.Name.Value, name) && usedInBlock(d.Body, name, pos.Line) {
+				continue
+			}
 			names = append(names, name)
 		}
 		sort.Strings(names)
-		return names
+		return completionScope{names: names, parent: d.Name.Value}
 	}
 	// Not inside any directive body → site-block level.
-	return topLevelDirectives
+	return completionScope{names: topLevel}
 }
 
-// hasBody reports whether d has a body block (EndLine > StartLine),
-// regardless of whether any sub-directives were parsed inside it.
-func hasBody(d *parser.Directive) bool {
-	return d.EndLine > d.StartLine
+// usedInBlock reports whether block has a directive called name on a line
+// other than line. The directive on the cursor's line is the one being
+// typed and does not count.
+func usedInBlock(block []*parser.Directive, name string, line uint32) bool {
+	for _, d := range block {
+		if d.Name.Value == name && d.Name.Range().Start.Line != line {
+			return true
+		}
+	}
+	return false
+}
+
+// responseDirectives are the directives only valid directly inside a
+// reverse_proxy handle_response block, where they copy the upstream's
+// response instead of writing a new one.
+var responseDirectives = map[string]bool{
+	"copy_response":         true,
+	"copy_response_headers": true,
+}
+
+// responseScopeAt returns the scope to complete in at pos inside the
+// handle_response block hr. Its body holds site-level directives as well as
+// the response directives.
+func responseScopeAt(s *analysis.Schema, hr *parser.Directive, pos protocol.Position, topLevel []string) completionScope {
+	names := slices.Clone(topLevel)
+	for name := range responseDirectives {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return directiveScopeAt(s, hr.Body, pos, slices.Compact(names))
 }
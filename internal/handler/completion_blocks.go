@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// CompletionSettings tunes completion items.
+type CompletionSettings struct {
+	// InsertBraces makes accepting a block directive such as handle or
+	// route also insert an empty `{ }` block after it.
+	InsertBraces bool `json:"insertBraces"`
+	// AddressSources lists docker-compose and hosts files whose service
+	// and host names are offered when typing a site address. Relative
+	// paths are looked up in every workspace root.
+	AddressSources []string `json:"addressSources"`
+}
+
+// directiveCommitCharacters accept a directive completion and are then
+// typed, since a directive name is always followed by whitespace.
+var directiveCommitCharacters = []string{" ", "\t"}
+
+// blockDirectives are the directives whose body is the point of using them,
+// so that a brace skeleton saves typing.
+var blockDirectives = map[string]bool{
+	"handle":          true,
+	"handle_path":     true,
+	"handle_errors":   true,
+	"handle_response": true,
+	"route":           true,
+	"tls":             true,
+	"basic_auth":      true,
+	"basicauth":       true,
+}
+
+// braceSkeleton returns an edit that appends an empty block to the line at
+// pos, indented like it, with an empty line for the body in between. The
+// main completion edit only replaces the word being typed, so matcher
+// arguments can still be typed before the brace. ok is false when the line
+// already continues past the cursor.
+func braceSkeleton(content string, pos protocol.Position) (protocol.TextEdit, bool) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return protocol.TextEdit{}, false
+	}
+	line = strings.TrimSuffix(line, "\r")
+	if col < len(line) && strings.TrimSpace(line[col:]) != "" {
+		return protocol.TextEdit{}, false
+	}
+	indent := line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+	unit := "\t"
+	if strings.HasPrefix(indent, " ") {
+		unit = "    "
+	}
+	end := protocol.Position{Line: pos.Line, Character: parser.UTF16Len(line)}
+	return protocol.TextEdit{
+		Range:   protocol.Range{Start: end, End: end},
+		NewText: " {\n" + indent + unit + "\n" + indent + "}",
+	}, true
+}
@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"slices"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// completionItems runs a completion at pos with settings s and returns the
+// items by label.
+func completionItems(t *testing.T, s Settings, src string, pos protocol.Position) map[string]protocol.CompletionItem {
+	t.Helper()
+	h := New(document.New())
+	h.applySettings(s)
+	h.store.Open("file:///Caddyfile", src, 1)
+	got, err := h.Completion(nil, &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     pos,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	items := make(map[string]protocol.CompletionItem)
+	for _, item := range got.([]protocol.CompletionItem) {
+		items[item.Label] = item
+	}
+	return items
+}
+
+func TestCompletion_CommitCharacters(t *testing.T) {
+	items := completionItems(t, Settings{}, "example.com {\n\t\n}\n", protocol.Position{Line: 1, Character: 1})
+	if got := items["reverse_proxy"].CommitCharacters; !slices.Equal(got, []string{" ", "\t"}) {
+		t.Errorf("commit characters = %q", got)
+	}
+	if items["handle"].AdditionalTextEdits != nil {
+		t.Error("braces should only be inserted when enabled")
+	}
+}
+
+func TestCompletion_InsertBraces(t *testing.T) {
+	s := Settings{Completion: CompletionSettings{InsertBraces: true}}
+	items := completionItems(t, s, "example.com {\n\thand\n}\n", protocol.Position{Line: 1, Character: 5})
+	edits := items["handle"].AdditionalTextEdits
+	if len(edits) != 1 {
+		t.Fatalf("want one brace edit for handle, got %+v", edits)
+	}
+	end := protocol.Position{Line: 1, Character: 5}
+	if edits[0].Range.Start != end || edits[0].Range.End != end || edits[0].NewText != " {\n\t\t\n\t}" {
+		t.Errorf("edit = %+v", edits[0])
+	}
+	if items["reverse_proxy"].AdditionalTextEdits != nil {
+		t.Error("non-block directives should not get braces")
+	}
+}
+
+func TestBraceSkeleton(t *testing.T) {
+	if _, ok := braceSkeleton("example.com {\n\thandle {\n}\n", protocol.Position{Line: 1, Character: 7}); ok {
+		t.Error("no skeleton when the line already continues")
+	}
+	edit, ok := braceSkeleton("a {\n    route\n}\n", protocol.Position{Line: 1, Character: 9})
+	if !ok || edit.NewText != " {\n        \n    }" {
+		t.Errorf("space-indented skeleton = %q, %v", edit.NewText, ok)
+	}
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// filesystemCompletions offers the file systems declared with the
+// filesystem global option when pos is in the name argument of fs, either
+// the directive or the option of a file_server block.
+func filesystemCompletions(f *parser.File, content string, pos protocol.Position) ([]protocol.CompletionItem, bool) {
+	d := directiveOnLine(f, pos, "fs", "file_server")
+	if d == nil {
+		return nil, false
+	}
+	after := d.Name
+	if args := analysis.DirectiveArgs(d); len(args) < len(d.Args) {
+		after = d.Args[0].Token // the matcher
+	}
+	if !afterToken(content, after, pos) {
+		return nil, false
+	}
+	line, col, _ := lineAt(content, pos)
+	partial := strings.TrimLeft(line[int(after.Char)+len(after.Value):col], " \t")
+	kind := protocol.CompletionItemKindReference
+	items := []protocol.CompletionItem{}
+	for _, name := range analysis.FilesystemNames(f) {
+		if strings.HasPrefix(name, partial) {
+			items = append(items, protocol.CompletionItem{Label: name, Kind: &kind})
+		}
+	}
+	return items, true
+}
+
+// directiveOnLine returns the directive called name on the line of pos, or
+// nil. It is looked for among the site-level directives, including those in
+// routing containers, and in the body of a directive called parent when
+// parent is not empty.
+func directiveOnLine(f *parser.File, pos protocol.Position, name, parent string) *parser.Directive {
+	var block []*parser.Directive
+	chain := enclosingDirectives(f, pos)
+	if len(chain) == 0 {
+		i := slices.IndexFunc(f.SiteBlocks, func(sb *parser.SiteBlock) bool { return sb.BodyContains(pos) })
+		if i < 0 {
+			return nil
+		}
+		block = f.SiteBlocks[i].Directives
+	} else if outer := chain[len(chain)-1]; containerDirectives[outer.Name.Value] || (parent != "" && outer.Name.Value == parent) {
+		block = outer.Body
+	}
+	i := slices.IndexFunc(block, func(d *parser.Directive) bool { return d.Name.Value == name && d.Name.Line == pos.Line })
+	if i < 0 {
+		return nil
+	}
+	return block[i]
+}
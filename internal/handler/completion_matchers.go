@@ -0,0 +1,112 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// matcherTypeCompletions offers the matcher types of the schema of uri where a
+// named matcher definition expects one: as the first argument of the
+// one-line form, `@name <type> ...`, and at the start of a line of the
+// block form. It reports whether pos is such a place.
+func (h *Handler) matcherTypeCompletions(uri string, f *parser.File, content string, pos protocol.Position) ([]protocol.CompletionItem, bool) {
+	var d *parser.Directive
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			d = matcherDefinitionAt(sb.Directives, pos)
+			break
+		}
+	}
+	if d == nil || !matcherTypePosition(content, d, pos) {
+		return nil, false
+	}
+	kind := protocol.CompletionItemKindKeyword
+	names := h.settingsFor(uri).schema.Matchers()
+	items := make([]protocol.CompletionItem, 0, len(names))
+	for _, name := range names {
+		item := protocol.CompletionItem{Label: name, Kind: &kind}
+		if doc, ok := h.docs.Matcher(name); ok {
+			item.Documentation = markup(h.client.completionDocFormat, doc)
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// matcherDefinitionAt returns the named matcher definition in ds, or in the
+// bodies of the directives holding pos, that is on the line of pos or
+// whose block holds it.
+func matcherDefinitionAt(ds []*parser.Directive, pos protocol.Position) *parser.Directive {
+	for _, d := range ds {
+		if strings.HasPrefix(d.Name.Value, "@") {
+			if d.Name.Line == pos.Line || d.BodyContains(pos) {
+				return d
+			}
+			continue
+		}
+		if d.BodyContains(pos) {
+			return matcherDefinitionAt(d.Body, pos)
+		}
+	}
+	return nil
+}
+
+// matcherTypePosition reports whether pos is where the matcher definition
+// d names a matcher type: the first argument of its line or of a `not`
+// line, or the first token of a line in its block or in the block of a
+// `not`.
+func matcherTypePosition(content string, d *parser.Directive, pos protocol.Position) bool {
+	return matcherSetPosition(content, d.Name, d.Args, d.Body, &d.Braces, pos)
+}
+
+// matcherSetPosition reports whether pos names a matcher type in the
+// matcher set following owner, the name of a definition or a `not`: on
+// owner's line, each leading `not` starts another set, and the block only
+// holds matchers when all arguments are such a `not`.
+func matcherSetPosition(content string, owner parser.Token, args []*parser.Argument, body []*parser.Directive, braces *parser.Braces, pos protocol.Position) bool {
+	if pos.Line == owner.Line {
+		if afterToken(content, owner, pos) {
+			return true
+		}
+		for _, arg := range args {
+			if arg.Token.Value != "not" {
+				break
+			}
+			if afterToken(content, arg.Token, pos) {
+				return true
+			}
+		}
+		return false
+	}
+	if !braces.BodyContains(pos) || slices.ContainsFunc(args, func(a *parser.Argument) bool { return a.Token.Value != "not" }) {
+		return false
+	}
+	for _, sub := range body {
+		if sub.Name.Line == pos.Line && atFirstTokenPosition(content, pos) {
+			return true
+		}
+		if sub.Name.Value == "not" && (sub.Name.Line == pos.Line || sub.BodyContains(pos)) {
+			return matcherSetPosition(content, sub.Name, sub.Args, sub.Body, &sub.Braces, pos)
+		}
+		if sub.BodyContains(pos) {
+			return false
+		}
+	}
+	return atFirstTokenPosition(content, pos)
+}
+
+// afterToken reports whether pos is in the first argument after tok, on
+// its line, and that argument is not quoted or a placeholder.
+func afterToken(content string, tok parser.Token, pos protocol.Position) bool {
+	line, col, _ := lineAt(content, pos)
+	end := int(tok.Char) + len(tok.Value)
+	if col <= end {
+		return false
+	}
+	typed := line[end:col]
+	partial := strings.TrimLeft(typed, " \t")
+	return partial != typed && !strings.ContainsAny(partial, " \t\"`{")
+}
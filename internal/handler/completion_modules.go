@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// moduleCompletions offers the modules schema s knows where a directive
+// takes one as its first argument, such as `issuer` in a tls block, a
+// trust pool or the storage global option. Modules declared by plugins are
+// included.
+func moduleCompletions(s *analysis.Schema, f *parser.File, content string, pos protocol.Position) ([]protocol.CompletionItem, bool) {
+	chain, d, global := directiveChainOnLine(f, pos)
+	if d == nil {
+		return nil, false
+	}
+	ns, ok := analysis.ModuleNamespace(chain, d.Name.Value, global)
+	if !ok {
+		return nil, false
+	}
+	i, partial, ok := argumentAt(content, d, pos)
+	if !ok || i != 0 {
+		return nil, false
+	}
+	kind := protocol.CompletionItemKindModule
+	items := []protocol.CompletionItem{}
+	for _, name := range s.Modules(ns) {
+		if strings.HasPrefix(name, partial) {
+			items = append(items, protocol.CompletionItem{Label: name, Kind: &kind, Detail: strPtr(ns)})
+		}
+	}
+	return items, true
+}
+
+// directiveChainOnLine returns the directive whose name is on the line of
+// pos, the directives enclosing it, outermost first, and whether it is in
+// the global options block. d is nil when no directive starts on the line.
+func directiveChainOnLine(f *parser.File, pos protocol.Position) (chain []*parser.Directive, d *parser.Directive, global bool) {
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, dir := range ds {
+			switch {
+			case dir.Name.Line == pos.Line:
+				d = dir
+				return
+			case dir.BodyContains(pos):
+				chain = append(chain, dir)
+				walk(dir.Body)
+				return
+			}
+		}
+	}
+	if f.GlobalBlock != nil && f.GlobalBlock.BodyContains(pos) {
+		walk(f.GlobalBlock.Directives)
+		return chain, d, true
+	}
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			walk(sb.Directives)
+			break
+		}
+	}
+	return chain, d, false
+}
@@ -0,0 +1,40 @@
+package handler
+
+import "fmt"
+
+// Completion ranks, lowest first. Clients sort by sortText, so the rank
+// prefix orders the groups and the name orders each group.
+const (
+	rankSubDirective = iota // valid in the enclosing directive's body
+	rankCommon              // frequently used site-level directive
+	rankDirective           // any other site-level directive
+)
+
+// commonDirectives are the site-level directives most Caddyfiles use.
+var commonDirectives = map[string]bool{
+	"reverse_proxy": true,
+	"file_server":   true,
+	"tls":           true,
+	"encode":        true,
+	"header":        true,
+	"handle":        true,
+	"root":          true,
+	"respond":       true,
+	"redir":         true,
+	"log":           true,
+	"import":        true,
+}
+
+// completionSortText ranks name for completion in scope: subdirectives of the
+// enclosing block and the response directives of handle_response first,
+// then common directives, then the rest.
+func completionSortText(scope completionScope, name string) string {
+	rank := rankDirective
+	switch {
+	case scope.parent != "", responseDirectives[name]:
+		rank = rankSubDirective
+	case commonDirectives[name]:
+		rank = rankCommon
+	}
+	return fmt.Sprintf("%d_%s", rank, name)
+}
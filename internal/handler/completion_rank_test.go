@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// completionRanks runs a completion at pos and returns the sortText of
+// every item by label.
+func completionRanks(t *testing.T, src string, pos protocol.Position) map[string]string {
+	t.Helper()
+	ranks := make(map[string]string)
+	for label, item := range completionItems(t, Settings{}, src, pos) {
+		if item.SortText == nil {
+			t.Fatalf("%s has no sortText", label)
+		}
+		ranks[label] = *item.SortText
+	}
+	return ranks
+}
+
+func TestCompletionSortText_CommonDirectivesFirst(t *testing.T) {
+	ranks := completionRanks(t, "example.com {\n\t\n}\n", protocol.Position{Line: 1, Character: 1})
+	if ranks["reverse_proxy"] >= ranks["acme_server"] || ranks["file_server"] >= ranks["abort"] {
+		t.Errorf("common directives should rank first: reverse_proxy=%s acme_server=%s", ranks["reverse_proxy"], ranks["acme_server"])
+	}
+}
+
+func TestCompletionSortText_SubDirectivesFirst(t *testing.T) {
+	src := "example.com {\n\treverse_proxy a b {\n\t\t\n\t}\n}\n"
+	ranks := completionRanks(t, src, protocol.Position{Line: 2, Character: 2})
+	if ranks["transport"] >= completionSortText(completionScope{}, "reverse_proxy") {
+		t.Error("subdirectives of the enclosing block should rank above everything else")
+	}
+}
+
+func TestCompletionSortText_ResponseDirectivesFirst(t *testing.T) {
+	src := "example.com {\n\treverse_proxy app:8080 {\n\t\thandle_response {\n\t\t\t\n\t\t}\n\t}\n}\n"
+	ranks := completionRanks(t, src, protocol.Position{Line: 3, Character: 3})
+	if ranks["copy_response"] >= ranks["respond"] || ranks["copy_response_headers"] >= ranks["respond"] {
+		t.Errorf("response directives should rank first: copy_response=%s respond=%s", ranks["copy_response"], ranks["respond"])
+	}
+}
@@ -1,7 +1,15 @@
 package handler
 
 import (
-	"caddy-ls/internal/parser"
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
 	"testing"
 
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -72,7 +80,7 @@ func TestAtFirstTokenPosition_LineOutOfBounds(t *testing.T) {
 func TestCompletionNamesAt_InsideSiteBlock(t *testing.T) {
 	src := "example.com {\n    reverse_proxy localhost\n}\n"
 	f := parseAST(src)
-	names := completionNamesAt(f, 1)
+	names := completionNamesAt(f, protocol.Position{Line: 1})
 	if names == nil {
 		t.Fatal("line inside site block: want top-level directives, got nil")
 	}
@@ -84,11 +92,26 @@ func TestCompletionNamesAt_InsideSiteBlock(t *testing.T) {
 	t.Errorf("expected 'reverse_proxy' in site-block completions, got %v", names)
 }
 
+func TestCompletionNamesAt_IncludesOrderedDirectives(t *testing.T) {
+	src := "{\n\torder rate_limit before basic_auth\n}\nexample.com {\n\thandle {\n\t\t\n\t}\n}\n"
+	f := parseAST(src)
+	names := completionNamesAt(f, protocol.Position{Line: 5, Character: 2})
+	if !slices.Contains(names, "rate_limit") || !slices.Contains(names, "reverse_proxy") {
+		t.Errorf("want ordered plugin directive alongside built-ins, got %v", names)
+	}
+	if !slices.IsSorted(names) {
+		t.Error("completion names should stay sorted")
+	}
+	if analysis.DefaultSchema().IsDirective("rate_limit") {
+		t.Error("ordered directives must not leak into the shared built-in list")
+	}
+}
+
 func TestCompletionNamesAt_OutsideAllBlocks(t *testing.T) {
 	src := "example.com {\n    reverse_proxy localhost\n}\n"
 	f := parseAST(src)
 	// Line 3 is beyond the closing brace on line 2.
-	if completionNamesAt(f, 3) != nil {
+	if completionNamesAt(f, protocol.Position{Line: 3}) != nil {
 		t.Error("line outside all site blocks: want nil")
 	}
 }
@@ -97,7 +120,7 @@ func TestCompletionNamesAt_OnAddressLine(t *testing.T) {
 	src := "example.com {\n    respond \"ok\"\n}\n"
 	f := parseAST(src)
 	// Line 0 is the address+brace line — not inside the block.
-	if completionNamesAt(f, 0) != nil {
+	if completionNamesAt(f, protocol.Position{Line: 0}) != nil {
 		t.Error("cursor on address line: want nil")
 	}
 }
@@ -106,7 +129,7 @@ func TestCompletionNamesAt_InsideNonContainerBody(t *testing.T) {
 	// Cursor inside reverse_proxy block should yield its subdirectives.
 	src := "example.com {\n    reverse_proxy {\n        to localhost\n    }\n}\n"
 	f := parseAST(src)
-	names := completionNamesAt(f, 2)
+	names := completionNamesAt(f, protocol.Position{Line: 2})
 	if names == nil {
 		t.Fatal("line inside reverse_proxy body: want subdirectives, got nil")
 	}
@@ -122,7 +145,7 @@ func TestCompletionNamesAt_InsideFreeformBody(t *testing.T) {
 	// basicauth has a freeform (nil) body — no completions.
 	src := "example.com {\n    basicauth {\n        user $2a$...\n    }\n}\n"
 	f := parseAST(src)
-	if completionNamesAt(f, 2) != nil {
+	if completionNamesAt(f, protocol.Position{Line: 2}) != nil {
 		t.Error("line inside basicauth (freeform) body: want nil")
 	}
 }
@@ -130,7 +153,7 @@ func TestCompletionNamesAt_InsideFreeformBody(t *testing.T) {
 func TestCompletionNamesAt_InsideHandleContainer(t *testing.T) {
 	src := "example.com {\n    handle /api/* {\n        reverse_proxy localhost\n    }\n}\n"
 	f := parseAST(src)
-	names := completionNamesAt(f, 2)
+	names := completionNamesAt(f, protocol.Position{Line: 2})
 	if names == nil {
 		t.Fatal("line inside handle body: want top-level directives, got nil")
 	}
@@ -145,7 +168,7 @@ func TestCompletionNamesAt_InsideHandleContainer(t *testing.T) {
 func TestCompletionNamesAt_InsideRouteContainer(t *testing.T) {
 	src := "example.com {\n    route {\n        file_server\n    }\n}\n"
 	f := parseAST(src)
-	if completionNamesAt(f, 2) == nil {
+	if completionNamesAt(f, protocol.Position{Line: 2}) == nil {
 		t.Error("line inside route body: want top-level directives, got nil")
 	}
 }
@@ -153,7 +176,7 @@ func TestCompletionNamesAt_InsideRouteContainer(t *testing.T) {
 func TestCompletionNamesAt_InsideHandleErrorsContainer(t *testing.T) {
 	src := "example.com {\n    handle_errors {\n        respond \"error\" 500\n    }\n}\n"
 	f := parseAST(src)
-	if completionNamesAt(f, 2) == nil {
+	if completionNamesAt(f, protocol.Position{Line: 2}) == nil {
 		t.Error("line inside handle_errors body: want top-level directives, got nil")
 	}
 }
@@ -163,14 +186,14 @@ func TestCompletionNamesAt_NestedContainer(t *testing.T) {
 	src := "example.com {\n    handle {\n        handle /inner/* {\n            respond \"inner\"\n        }\n    }\n}\n"
 	f := parseAST(src)
 	// Line 3 is inside the inner handle block.
-	if completionNamesAt(f, 3) == nil {
+	if completionNamesAt(f, protocol.Position{Line: 3}) == nil {
 		t.Error("line inside nested handle body: want top-level directives, got nil")
 	}
 }
 
 func TestCompletionNamesAt_EmptyFile(t *testing.T) {
 	f := parseAST("")
-	if completionNamesAt(f, 0) != nil {
+	if completionNamesAt(f, protocol.Position{Line: 0}) != nil {
 		t.Error("empty file: want nil")
 	}
 }
@@ -178,7 +201,7 @@ func TestCompletionNamesAt_EmptyFile(t *testing.T) {
 func TestCompletionNamesAt_InsideHandlePathContainer(t *testing.T) {
 	src := "example.com {\n    handle_path /static/* {\n        file_server\n    }\n}\n"
 	f := parseAST(src)
-	names := completionNamesAt(f, 2)
+	names := completionNamesAt(f, protocol.Position{Line: 2})
 	if names == nil {
 		t.Fatal("line inside handle_path body: want top-level directives, got nil")
 	}
@@ -196,7 +219,7 @@ func TestCompletionNamesAt_InsideTransportHTTPBody(t *testing.T) {
 	src := "example.com {\n    reverse_proxy localhost {\n        transport http {\n            \n        }\n    }\n}\n"
 	f := parseAST(src)
 	// Line 3 is inside the transport http body.
-	result := completionNamesAt(f, 3)
+	result := completionNamesAt(f, protocol.Position{Line: 3})
 	// The completion for sub-subdirective bodies is not yet supported; nil is correct.
 	_ = result // either nil or a list is acceptable — this test just confirms no panic
 }
@@ -204,7 +227,7 @@ func TestCompletionNamesAt_InsideTransportHTTPBody(t *testing.T) {
 func TestCompletionNamesAt_TLSSubdirectives(t *testing.T) {
 	src := "example.com {\n    tls {\n        \n    }\n}\n"
 	f := parseAST(src)
-	names := completionNamesAt(f, 2)
+	names := completionNamesAt(f, protocol.Position{Line: 2})
 	if names == nil {
 		t.Fatal("line inside tls body: want subdirectives, got nil")
 	}
@@ -216,8 +239,74 @@ func TestCompletionNamesAt_TLSSubdirectives(t *testing.T) {
 	t.Errorf("expected 'protocols' in tls subdirectives, got %v", names)
 }
 
+func TestCompletionNamesAt_OneShotSubdirectivesFiltered(t *testing.T) {
+	src := "example.com {\n\treverse_proxy a b {\n\t\tlb_policy first\n\t\tflush_interval -1\n\t\theader_up X-A 1\n\t\t\n\t}\n}\n"
+	f := parseAST(src)
+	names := completionNamesAt(f, protocol.Position{Line: 5, Character: 2})
+	for _, gone := range []string{"lb_policy", "flush_interval"} {
+		if slices.Contains(names, gone) {
+			t.Errorf("%q is already set and may appear once, got %v", gone, names)
+		}
+	}
+	for _, want := range []string{"header_up", "to", "transport"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("want %q, got %v", want, names)
+		}
+	}
+
+	// The lb_policy being typed is not counted as set.
+	src = "example.com {\n\treverse_proxy a {\n\t\tlb_policy\n\t}\n}\n"
+	if names := completionNamesAt(parseAST(src), protocol.Position{Line: 2, Character: 11}); !slices.Contains(names, "lb_policy") {
+		t.Errorf("the option on the cursor's line should be offered, got %v", names)
+	}
+}
+
 // --- importArgPrefix ---------------------------------------------------------
 
+func TestCompletionNamesAt_InsideInterceptHandleResponse(t *testing.T) {
+	src := "example.com {\n\tintercept {\n\t\t\n\t\thandle_response {\n\t\t\t\n\t\t}\n\t}\n}\n"
+	f := parseAST(src)
+	names := completionNamesAt(f, protocol.Position{Line: 4, Character: 3})
+	if !slices.Contains(names, "rewrite") || slices.Contains(names, "copy_response") {
+		t.Errorf("inside handle_response of intercept: got %v", names)
+	}
+	if names := completionNamesAt(f, protocol.Position{Line: 2, Character: 2}); !slices.Equal(names, []string{"handle_response", "replace_status"}) {
+		t.Errorf("inside intercept: got %v", names)
+	}
+}
+
+func TestCompletionNamesAt_InsideHandleResponse(t *testing.T) {
+	src := "example.com {\n\treverse_proxy app:8080 {\n\t\t@err status 5xx\n\t\thandle_response @err {\n\t\t\t\n\t\t}\n\t}\n}\n"
+	names := completionNamesAt(parseAST(src), protocol.Position{Line: 4, Character: 3})
+	for _, want := range []string{"copy_response", "copy_response_headers", "respond", "rewrite"} {
+		if !slices.Contains(names, want) {
+			t.Errorf("want %q inside handle_response, got %v", want, names)
+		}
+	}
+	if slices.Contains(names, "lb_policy") {
+		t.Error("reverse_proxy options are not valid inside handle_response")
+	}
+	if !slices.IsSorted(names) {
+		t.Error("completion names should stay sorted")
+	}
+}
+
+func TestCompletionNamesAt_InsideCopyResponseHeaders(t *testing.T) {
+	src := "example.com {\n\treverse_proxy app:8080 {\n\t\thandle_response {\n\t\t\tcopy_response_headers {\n\t\t\t\t\n\t\t\t}\n\t\t}\n\t}\n}\n"
+	names := completionNamesAt(parseAST(src), protocol.Position{Line: 4, Character: 4})
+	if !slices.Equal(names, []string{"exclude", "include"}) {
+		t.Errorf("got %v, want [exclude include]", names)
+	}
+}
+
+func TestCompletionNamesAt_ResponseDirectivesOnlyInHandleResponse(t *testing.T) {
+	src := "example.com {\n\thandle {\n\t\t\n\t}\n}\n"
+	names := completionNamesAt(parseAST(src), protocol.Position{Line: 2, Character: 2})
+	if slices.Contains(names, "copy_response") {
+		t.Error("copy_response is only valid inside handle_response")
+	}
+}
+
 func TestImportArgPrefix_NotImport(t *testing.T) {
 	_, ok := importArgPrefix("reverse_proxy localhost", protocol.Position{Line: 0, Character: 14})
 	if ok {
@@ -298,8 +387,8 @@ func TestImportArgPrefix_MultiLine(t *testing.T) {
 // --- snippetCompletions ------------------------------------------------------
 
 func TestSnippetCompletions_Empty(t *testing.T) {
-	f := parseAST("example.com {\n\trespond \"ok\"\n}\n")
-	items := snippetCompletions(f, "")
+	src := "example.com {\n\trespond \"ok\"\n}\n"
+	items := snippetCompletions(src, parseAST(src), "")
 	if len(items) != 0 {
 		t.Errorf("no snippets defined: want 0 items, got %d", len(items))
 	}
@@ -307,8 +396,7 @@ func TestSnippetCompletions_Empty(t *testing.T) {
 
 func TestSnippetCompletions_AllSnippets(t *testing.T) {
 	src := "(alpha) {\n\trespond \"a\"\n}\n(beta) {\n\trespond \"b\"\n}\nexample.com {\n\trespond \"ok\"\n}\n"
-	f := parseAST(src)
-	items := snippetCompletions(f, "")
+	items := snippetCompletions(src, parseAST(src), "")
 	if len(items) != 2 {
 		t.Fatalf("want 2 items, got %d", len(items))
 	}
@@ -320,8 +408,7 @@ func TestSnippetCompletions_AllSnippets(t *testing.T) {
 
 func TestSnippetCompletions_FilterByPrefix(t *testing.T) {
 	src := "(alpha) {\n\trespond \"a\"\n}\n(bravo) {\n\trespond \"b\"\n}\n(alcazar) {\n\trespond \"c\"\n}\n"
-	f := parseAST(src)
-	items := snippetCompletions(f, "al")
+	items := snippetCompletions(src, parseAST(src), "al")
 	if len(items) != 2 {
 		t.Fatalf("want 2 items matching \"al*\", got %d: %v", len(items), items)
 	}
@@ -329,8 +416,7 @@ func TestSnippetCompletions_FilterByPrefix(t *testing.T) {
 
 func TestSnippetCompletions_KindIsModule(t *testing.T) {
 	src := "(mysnippet) {\n\trespond \"ok\"\n}\n"
-	f := parseAST(src)
-	items := snippetCompletions(f, "")
+	items := snippetCompletions(src, parseAST(src), "")
 	if len(items) != 1 {
 		t.Fatalf("want 1 item, got %d", len(items))
 	}
@@ -339,18 +425,452 @@ func TestSnippetCompletions_KindIsModule(t *testing.T) {
 	}
 }
 
-// --- hasBody -----------------------------------------------------------------
+func TestImportedSnippetCompletions(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"snippets/proxy.caddy": "import ../shared/*.caddy\n(proxy) {\n\treverse_proxy app:8080\n}\n(local) {\n}\n",
+		"shared/tls.caddy":     "(tls_internal) {\n\ttls internal\n}\n",
+	}
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	uri := workspace.PathToURI(filepath.Join(dir, "Caddyfile"))
+	f := parseAST("import snippets/*.caddy\n(local) {\n}\nexample.com {\n\timport \n}\n")
+
+	h := New(nil)
+	items := h.importedSnippetCompletions(uri, f, h.importedSnippets(uri, f), "")
+	got := make(map[string]string)
+	for _, item := range items {
+		got[item.Label] = *item.Detail
+	}
+	want := map[string]string{
+		"proxy":        filepath.Join("snippets", "proxy.caddy"),
+		"tls_internal": filepath.Join("shared", "tls.caddy"),
+	}
+	if len(got) != len(want) {
+		t.Fatalf("got %v, want %v (local snippets must not be repeated)", got, want)
+	}
+	for name, detail := range want {
+		if got[name] != detail {
+			t.Errorf("%s: detail %q, want %q", name, got[name], detail)
+		}
+	}
+}
+
+// --- matcher completion -----------------------------------------------------
+
+func TestMatcherArgPrefix(t *testing.T) {
+	cases := []struct {
+		line string
+		want string
+		ok   bool
+	}{
+		{"\treverse_proxy @", "@", true},
+		{"\treverse_proxy @ap", "@ap", true},
+		{"\t@api path /api/*", "", false}, // definition, not a reference
+		{"\t@ap", "", false},
+		{"\treverse_proxy app", "", false},
+	}
+	for _, c := range cases {
+		got, ok := matcherArgPrefix(c.line, protocol.Position{Character: uint32(len(c.line))})
+		if got != c.want || ok != c.ok {
+			t.Errorf("%q: got (%q, %v), want (%q, %v)", c.line, got, ok, c.want, c.ok)
+		}
+	}
+}
+
+func TestMatcherCompletions_Scoped(t *testing.T) {
+	src := "example.com {\n\t@api path /api/*\n\thandle {\n\t\t@static file\n\t\trespond @\n\t}\n\trespond @\n}\nother.com {\n\t@other host other.com\n}\n"
+	f := parseAST(src)
+
+	labels := func(items []protocol.CompletionItem) []string {
+		var out []string
+		for _, item := range items {
+			out = append(out, item.Label)
+		}
+		return out
+	}
+	inHandle := matcherCompletions(f, protocol.Position{Line: 4, Character: 11}, "@")
+	if got := labels(inHandle); len(got) != 2 || got[0] != "@static" || got[1] != "@api" {
+		t.Errorf("inside handle: got %v, want [@static @api]", got)
+	}
+	if inHandle[1].Detail == nil || *inHandle[1].Detail != "path" {
+		t.Errorf("@api detail = %v, want path", inHandle[1].Detail)
+	}
+	if got := labels(matcherCompletions(f, protocol.Position{Line: 6, Character: 10}, "@")); len(got) != 1 || got[0] != "@api" {
+		t.Errorf("site level: got %v, want [@api]", got)
+	}
+	if got := labels(matcherCompletions(f, protocol.Position{Line: 6, Character: 10}, "@x")); len(got) != 0 {
+		t.Errorf("prefix filter: got %v, want none", got)
+	}
+}
+
+// --- brace tracking ---------------------------------------------------------
+
+func TestCompletionNamesAt_OneLineBlockHasBody(t *testing.T) {
+	// A block opened and closed on one line must still count as a body so the
+	// cursor between its braces is scoped to the directive.
+	src := "example.com {\n    tls { protocols tls1.2 }\n}\n"
+	f := parseAST(src)
+	d := f.SiteBlocks[0].Directives[0]
+	if !d.HasBody() {
+		t.Fatal("one-line tls block: want HasBody=true")
+	}
+	names := completionNamesAt(f, protocol.Position{Line: 1, Character: 10})
+	for _, n := range names {
+		if n == "protocols" {
+			return
+		}
+	}
+	t.Errorf("cursor inside one-line tls block: want tls subdirectives, got %v", names)
+}
+
+func TestCompletionNamesAt_AfterSameLineClosingBrace(t *testing.T) {
+	src := "example.com {\n    tls { protocols tls1.2 }\n    \n}\n"
+	f := parseAST(src)
+	names := completionNamesAt(f, protocol.Position{Line: 2, Character: 4})
+	for _, n := range names {
+		if n == "reverse_proxy" {
+			return
+		}
+	}
+	t.Errorf("cursor after one-line block: want site-level directives, got %v", names)
+}
+
+func TestCompletionNamesAt_NoBodyDirective(t *testing.T) {
+	src := "example.com {\n    respond \"ok\" 200\n    \n}\n"
+	f := parseAST(src)
+	if f.SiteBlocks[0].Directives[0].HasBody() {
+		t.Error("respond without block: want HasBody=false")
+	}
+}
+
+func TestCompletionNamesAt_UnclosedSiteBlockAtEOF(t *testing.T) {
+	src := "example.com {\n    \n"
+	f := parseAST(src)
+	if completionNamesAt(f, protocol.Position{Line: 1, Character: 4}) == nil {
+		t.Error("cursor inside unclosed site block: want top-level directives, got nil")
+	}
+}
+
+func TestCompletion_ServersGlobalOption(t *testing.T) {
+	src := "{\n\tservers {\n\t\t\n\t\tlistener_wrappers {\n\t\t\t\n\t\t}\n\t\ttimeouts {\n\t\t\t\n\t\t}\n\t\tprotocols h1 \n\t}\n}\n"
+	tests := []struct {
+		name    string
+		pos     protocol.Position
+		want    []string
+		notWant []string
+	}{
+		{"servers body", pos(2, 2), []string{"listener_wrappers", "protocols", "timeouts", "trusted_proxies"}, []string{"reverse_proxy", "tls"}},
+		{"listener wrappers", pos(4, 3), []string{"http_redirect", "proxy_protocol", "tls"}, []string{"timeouts"}},
+		{"timeouts", pos(7, 3), []string{"idle", "read_body", "read_header", "write"}, []string{"protocols"}},
+		{"protocols", pos(9, 15), []string{"h2", "h2c", "h3"}, []string{"h1"}},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			items := completionItems(t, Settings{}, src, tc.pos)
+			for _, w := range tc.want {
+				if _, ok := items[w]; !ok {
+					t.Errorf("missing %q in %v", w, slices.Sorted(maps.Keys(items)))
+				}
+			}
+			for _, n := range tc.notWant {
+				if _, ok := items[n]; ok {
+					t.Errorf("unexpected %q", n)
+				}
+			}
+		})
+	}
+
+	// The tls listener wrapper is not the tls directive.
+	if item := completionItems(t, Settings{}, src, pos(4, 3))["tls"]; item.Documentation != nil {
+		t.Errorf("listener wrapper tls must not carry directive docs, got %+v", item.Documentation)
+	}
+
+	admin := completionItems(t, Settings{}, "{\n\tadmin localhost:2019 {\n\t\t\n\t}\n}\n", pos(2, 2))
+	if got := slices.Sorted(maps.Keys(admin)); !slices.Equal(got, []string{"enforce_origin", "origins"}) {
+		t.Errorf("admin body: got %v", got)
+	}
+
+	globalLog := completionItems(t, Settings{}, "{\n\tlog {\n\t\t\n\t}\n}\n", pos(2, 2))
+	if _, ok := globalLog["include"]; !ok {
+		t.Errorf("global log body: missing include in %v", slices.Sorted(maps.Keys(globalLog)))
+	}
+	if _, ok := globalLog["hostnames"]; ok {
+		t.Errorf("global log body: unexpected hostnames")
+	}
+	siteLog := completionItems(t, Settings{}, "a.com {\n\tlog {\n\t\t\n\t}\n}\n", pos(2, 2))
+	if _, ok := siteLog["include"]; ok {
+		t.Errorf("site log body: unexpected include")
+	}
+	if _, ok := siteLog["hostnames"]; !ok {
+		t.Errorf("site log body: missing hostnames in %v", slices.Sorted(maps.Keys(siteLog)))
+	}
+}
+
+func TestCompletion_MatcherTypes(t *testing.T) {
+	src := "example.com {\n\t@api pa\n\t@block {\n\t\tpath /api/*\n\t\t\n\t}\n\thandle {\n\t\t@inner \n\t}\n}\n"
+	for _, p := range []protocol.Position{pos(1, 8), pos(4, 2), pos(7, 9)} {
+		items := completionItems(t, Settings{}, src, p)
+		if _, ok := items["path"]; !ok {
+			t.Errorf("%v: missing path in %v", p, slices.Sorted(maps.Keys(items)))
+		}
+		if _, ok := items["reverse_proxy"]; ok {
+			t.Errorf("%v: directives offered as matcher types", p)
+		}
+		if item := items["tls"]; item.Documentation == nil {
+			t.Errorf("%v: tls has no documentation", p)
+		}
+	}
+	// The arguments of a matcher are not matcher types.
+	if items := completionItems(t, Settings{}, src, pos(3, 7)); len(items) != 0 {
+		t.Errorf("matcher argument: want no items, got %v", slices.Sorted(maps.Keys(items)))
+	}
+
+	nested := "example.com {\n\t@a not \n\t@b {\n\t\tnot {\n\t\t\t\n\t\t}\n\t\tnot not \n\t}\n}\n"
+	for _, p := range []protocol.Position{pos(1, 8), pos(4, 3), pos(6, 10)} {
+		if _, ok := completionItems(t, Settings{}, nested, p)["path"]; !ok {
+			t.Errorf("nested %v: missing path", p)
+		}
+	}
+
+	s := Settings{Schema: SchemaSettings{Matchers: []string{"maxmind_geolocation"}}}
+	if _, ok := completionItems(t, s, src, pos(1, 8))["maxmind_geolocation"]; !ok {
+		t.Error("schema matchers are not offered")
+	}
+}
+
+func TestCompletion_Push(t *testing.T) {
+	src := "example.com {\n\tpush {\n\t\t\n\t\theaders {\n\t\t\t\n\t\t}\n\t}\n}\n"
+	items := completionItems(t, Settings{}, src, pos(2, 2))
+	if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, []string{"GET", "HEAD", "headers"}) {
+		t.Errorf("push block: got %v", got)
+	}
+	if items := completionItems(t, Settings{}, src, pos(4, 3)); len(items) != 0 {
+		t.Errorf("headers block: want no items, got %v", slices.Sorted(maps.Keys(items)))
+	}
+}
 
-func TestHasBody_WithBody(t *testing.T) {
-	d := &parser.Directive{StartLine: 1, EndLine: 3}
-	if !hasBody(d) {
-		t.Error("directive with EndLine > StartLine: want hasBody=true")
+func TestCompletion_Filesystems(t *testing.T) {
+	src := "{\n\tfilesystem assets s3 {\n\t}\n\tfilesystem archive s3\n}\nexample.com {\n\tfs as\n\tfs @api \n\tfile_server {\n\t\tfs \n\t}\n}\n"
+	for _, tc := range []struct {
+		at   protocol.Position
+		want []string
+	}{
+		{pos(6, 6), []string{"assets"}},
+		{pos(7, 9), []string{"archive", "assets"}},
+		{pos(9, 5), []string{"archive", "assets"}},
+	} {
+		items := completionItems(t, Settings{}, src, tc.at)
+		if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.at, got, tc.want)
+		}
 	}
 }
 
-func TestHasBody_NoBody(t *testing.T) {
-	d := &parser.Directive{StartLine: 2, EndLine: 2}
-	if hasBody(d) {
-		t.Error("directive with EndLine == StartLine: want hasBody=false")
+func TestCompletion_HeaderFields(t *testing.T) {
+	src := "example.com {\n\theader X-Fr\n\theader @api +cache\n\theader -Se\n\theader {\n\t\tStrict\n\t}\n\theader X-Frame-Options \n\theader Vary foo\n}\n"
+	for _, tc := range []struct {
+		at   protocol.Position
+		want []string
+	}{
+		{pos(1, 12), []string{"X-Frame-Options"}},
+		{pos(2, 19), []string{"Cache-Control"}},
+		{pos(3, 11), []string{"Server"}},
+		{pos(5, 8), []string{"Strict-Transport-Security"}},
+		{pos(7, 24), nil}, // the value position
+	} {
+		items := completionItems(t, Settings{}, src, tc.at)
+		if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.at, got, tc.want)
+		}
+	}
+
+	edit := func(item protocol.CompletionItem) protocol.TextEdit {
+		t.Helper()
+		e, ok := item.TextEdit.(protocol.TextEdit)
+		if !ok {
+			t.Fatalf("%s: text edit %#v", item.Label, item.TextEdit)
+		}
+		return e
+	}
+	items := completionItems(t, Settings{}, src, pos(1, 12))
+	e := edit(items["X-Frame-Options"])
+	if e.NewText != "X-Frame-Options ${1:DENY}" || e.Range.Start != pos(1, 8) {
+		t.Errorf("X-Frame-Options: got %q at %v", e.NewText, e.Range.Start)
+	}
+	if f := items["X-Frame-Options"].InsertTextFormat; f == nil || *f != protocol.InsertTextFormatSnippet {
+		t.Error("X-Frame-Options: not a snippet")
+	}
+	items = completionItems(t, Settings{}, src, pos(5, 8))
+	if e := edit(items["Strict-Transport-Security"]); e.NewText != `Strict-Transport-Security "${1:max-age=31536000; includeSubDomains}"` {
+		t.Errorf("Strict-Transport-Security: got %q", e.NewText)
+	}
+	// The op stays, and a deleted field takes no value.
+	items = completionItems(t, Settings{}, src, pos(2, 19))
+	if e := edit(items["Cache-Control"]); e.Range.Start != pos(2, 14) {
+		t.Errorf("Cache-Control: edit starts at %v", e.Range.Start)
+	}
+	items = completionItems(t, Settings{}, src, pos(3, 11))
+	if e := edit(items["Server"]); e.NewText != "Server" {
+		t.Errorf("Server: got %q", e.NewText)
+	}
+	// Text after the cursor is not followed by another value.
+	items = completionItems(t, Settings{}, src, pos(8, 10))
+	if e := edit(items["Vary"]); e.NewText != "Vary" {
+		t.Errorf("Vary: got %q", e.NewText)
+	}
+
+	h := New(document.New())
+	h.client.noSnippets = true
+	h.store.Open("file:///Caddyfile", src, 1)
+	got, err := h.Completion(nil, &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     pos(1, 12),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	item := got.([]protocol.CompletionItem)[0]
+	if e := edit(item); e.NewText != "X-Frame-Options" || item.InsertTextFormat != nil || item.Detail == nil || *item.Detail != "DENY" {
+		t.Errorf("without snippets: got %q, detail %v", e.NewText, item.Detail)
+	}
+}
+
+func TestCompletion_StatusCodes(t *testing.T) {
+	src := "example.com {\n\trespond 40\n\trespond \"gone\" 41\n\trespond 404 \n\terror @x 50\n\terror 30\n\tredir https://example.org \n\tredir https://example.org per\n\tredir \n}\n"
+	for _, tc := range []struct {
+		at   protocol.Position
+		want []string
+	}{
+		{pos(1, 11), []string{"400", "401", "403", "404", "405", "406", "408", "409"}},
+		{pos(2, 18), []string{"410", "413", "415", "418"}},
+		{pos(3, 13), nil},
+		{pos(4, 12), []string{"500", "501", "502", "503", "504"}},
+		{pos(5, 9), []string{}},
+		{pos(6, 27), []string{"301", "302", "303", "307", "308", "html", "permanent", "temporary"}},
+		{pos(7, 30), []string{"permanent"}},
+		{pos(8, 7), nil},
+	} {
+		items := completionItems(t, Settings{}, src, tc.at)
+		if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.at, got, tc.want)
+		}
+	}
+	items := completionItems(t, Settings{}, src, pos(1, 11))
+	if d := items["404"].Detail; d == nil || *d != "Not Found" {
+		t.Errorf("404 detail = %v", d)
+	}
+}
+
+func TestCompletion_SubDirectiveTemplates(t *testing.T) {
+	src := "example.com {\n\treverse_proxy a {\n\t\t\n\t\theal /x\n\t}\n\trequest_body {\n\t\t\n\t}\n}\n"
+	insert := func(item protocol.CompletionItem) string {
+		t.Helper()
+		if item.InsertText == nil {
+			return ""
+		}
+		if f := item.InsertTextFormat; f == nil || *f != protocol.InsertTextFormatSnippet {
+			t.Errorf("%s: not a snippet", item.Label)
+		}
+		return *item.InsertText
+	}
+
+	items := completionItems(t, Settings{}, src, pos(2, 2))
+	if got := insert(items["health_uri"]); got != "health_uri ${1:/healthz}" {
+		t.Errorf("health_uri: got %q", got)
+	}
+	if got := insert(items["lb_policy"]); !strings.HasPrefix(got, "lb_policy ${1|") || !strings.Contains(got, ",round_robin,") {
+		t.Errorf("lb_policy: got %q", got)
+	}
+	if items["health_uri"].CommitCharacters != nil {
+		t.Error("health_uri: a space typed to commit would replace the placeholder")
+	}
+	if got := insert(items["dynamic"]); got != "" || items["dynamic"].CommitCharacters == nil {
+		t.Errorf("dynamic has no template, got %q", got)
+	}
+	if got := insert(completionItems(t, Settings{}, src, pos(6, 2))["max_size"]); got != "max_size ${1:10MB}" {
+		t.Errorf("max_size: got %q", got)
+	}
+	// Arguments already follow the cursor.
+	if got := insert(completionItems(t, Settings{}, src, pos(3, 6))["health_uri"]); got != "" {
+		t.Errorf("health_uri before arguments: got %q", got)
+	}
+	// Site-level directives get none.
+	if got := insert(completionItems(t, Settings{}, "example.com {\n\t\n}\n", pos(1, 1))["tls"]); got != "" {
+		t.Errorf("tls at site level: got %q", got)
+	}
+
+	h := New(document.New())
+	h.client.noSnippets = true
+	h.store.Open("file:///Caddyfile", src, 1)
+	got, err := h.Completion(nil, &protocol.CompletionParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     pos(2, 2),
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, item := range got.([]protocol.CompletionItem) {
+		if item.InsertText != nil {
+			t.Errorf("without snippets: %s inserts %q", item.Label, *item.InsertText)
+		}
+	}
+}
+
+func TestCompletion_ClientAuth(t *testing.T) {
+	src := "example.com {\n\ttls {\n\t\tclient_auth {\n\t\t\t\n\t\t\tmode \n\t\t\ttrust_pool f\n\t\t\ttrust_pool file {\n\t\t\t\t\n\t\t\t}\n\t\t}\n\t}\n\treverse_proxy https://app {\n\t\ttransport http {\n\t\t\ttls_trust_pool p\n\t\t}\n\t}\n}\n"
+	for _, tc := range []struct {
+		at   protocol.Position
+		want []string
+	}{
+		{pos(3, 3), []string{"mode", "trust_pool", "trusted_ca_cert", "trusted_ca_cert_file", "trusted_leaf_cert", "trusted_leaf_cert_file", "verifier"}},
+		{pos(4, 8), []string{"request", "require", "require_and_verify", "verify_if_given"}},
+		{pos(5, 15), []string{"file"}},
+		{pos(7, 4), []string{"pem_file"}},
+		{pos(13, 19), []string{"pki_intermediate", "pki_root"}},
+	} {
+		items := completionItems(t, Settings{}, src, tc.at)
+		if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.at, got, tc.want)
+		}
+	}
+	items := completionItems(t, Settings{}, src, pos(7, 4))
+	if doc, ok := items["pem_file"].Documentation.(protocol.MarkupContent); !ok || !strings.Contains(doc.Value, "PEM files") {
+		t.Errorf("pem_file documentation = %#v", items["pem_file"].Documentation)
+	}
+}
+
+func TestCompletion_ModuleArguments(t *testing.T) {
+	src := "{\n\tstorage \n\tcert_issuer z\n}\n\nexample.com {\n\ttls {\n\t\tissuer \n\t\tget_certificate t\n\t}\n\treverse_proxy {\n\t\tdynamic \n\t}\n}\n"
+	settings := Settings{Plugins: PluginSettings{Modules: map[string][]string{"tls.issuance": {"acmedns"}}}}
+	for _, tc := range []struct {
+		at   protocol.Position
+		want []string
+	}{
+		{pos(1, 9), []string{"file_system"}},
+		{pos(2, 14), []string{"zerossl"}},
+		{pos(7, 9), []string{"acme", "acmedns", "internal", "zerossl"}},
+		{pos(8, 19), []string{"tailscale"}},
+		{pos(11, 10), []string{"a", "multi", "srv"}},
+	} {
+		items := completionItems(t, settings, src, tc.at)
+		if got := slices.Sorted(maps.Keys(items)); !slices.Equal(got, tc.want) {
+			t.Errorf("%v: got %v, want %v", tc.at, got, tc.want)
+		}
+	}
+	items := completionItems(t, settings, src, pos(7, 9))
+	if item := items["acmedns"]; item.Detail == nil || *item.Detail != "tls.issuance" {
+		t.Errorf("acmedns detail = %v, want tls.issuance", item.Detail)
 	}
 }
@@ -0,0 +1,240 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"net/http"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// commonHeader is a response header offered in the field position of
+// header, with a value that is typical for it. An empty value is for
+// headers that are usually deleted rather than set.
+type commonHeader struct {
+	name, value string
+}
+
+var commonHeaders = []commonHeader{
+	{"Strict-Transport-Security", "max-age=31536000; includeSubDomains"},
+	{"Content-Security-Policy", "default-src 'self'"},
+	{"X-Frame-Options", "DENY"},
+	{"X-Content-Type-Options", "nosniff"},
+	{"Referrer-Policy", "strict-origin-when-cross-origin"},
+	{"Permissions-Policy", "geolocation=(), microphone=(), camera=()"},
+	{"Cache-Control", "public, max-age=3600"},
+	{"Access-Control-Allow-Origin", "*"},
+	{"Access-Control-Allow-Methods", "GET, POST, OPTIONS"},
+	{"Access-Control-Allow-Headers", "Content-Type, Authorization"},
+	{"Cross-Origin-Opener-Policy", "same-origin"},
+	{"Cross-Origin-Embedder-Policy", "require-corp"},
+	{"Cross-Origin-Resource-Policy", "same-origin"},
+	{"Content-Type", "text/html; charset=utf-8"},
+	{"X-Robots-Tag", "noindex, nofollow"},
+	{"Link", "</style.css>; rel=preload; as=style"},
+	{"Vary", "Accept-Encoding"},
+	{"Server", ""},
+	{"X-Powered-By", ""},
+}
+
+// headerOps are the operators that may prefix a header field: add, delete,
+// set as default and defer.
+const headerOps = "+-?>"
+
+// headerFieldCompletions offers common header names where a header field is
+// typed: the first argument of header or request_header, after the matcher,
+// or the first token of a line in a header block. With snippet support the
+// name is followed by a typical value as a tab stop, unless the field is
+// deleted or the line goes on after the cursor.
+func headerFieldCompletions(f *parser.File, content string, pos protocol.Position, snippets bool) ([]protocol.CompletionItem, bool) {
+	var partial string
+	d := directiveOnLine(f, pos, "header", "")
+	if d == nil {
+		d = directiveOnLine(f, pos, "request_header", "")
+	}
+	if d != nil {
+		i, p, ok := argumentAt(content, d, pos)
+		if !ok || i != 0 {
+			return nil, false
+		}
+		partial = p
+	} else if chain := enclosingDirectives(f, pos); len(chain) > 0 && chain[len(chain)-1].Name.Value == "header" && atFirstTokenPosition(content, pos) {
+		partial = strings.TrimLeft(lineBefore(content, pos), " \t")
+	} else {
+		return nil, false
+	}
+
+	op := ""
+	if partial != "" && strings.ContainsRune(headerOps, rune(partial[0])) {
+		op, partial = partial[:1], partial[1:]
+	}
+	line, col, _ := lineAt(content, pos)
+	rest := line[col:]
+	withValue := snippets && op != "-" && strings.TrimSpace(rest) == ""
+	edit := protocol.Range{
+		Start: protocol.Position{Line: pos.Line, Character: pos.Character - parser.UTF16Len(partial)},
+		End:   pos,
+	}
+
+	kind := protocol.CompletionItemKindField
+	format := protocol.InsertTextFormatSnippet
+	items := []protocol.CompletionItem{}
+	for _, h := range commonHeaders {
+		if !strings.HasPrefix(strings.ToLower(h.name), strings.ToLower(partial)) {
+			continue
+		}
+		item := protocol.CompletionItem{
+			Label:    h.name,
+			Kind:     &kind,
+			TextEdit: protocol.TextEdit{Range: edit, NewText: h.name},
+		}
+		if h.value != "" {
+			item.Detail = &h.value
+			if withValue {
+				value := "${1:" + h.value + "}"
+				if strings.ContainsAny(h.value, " \t") {
+					value = `"` + value + `"`
+				}
+				item.TextEdit = protocol.TextEdit{Range: edit, NewText: h.name + " " + value}
+				item.InsertTextFormat = &format
+			}
+		}
+		items = append(items, item)
+	}
+	return items, true
+}
+
+// statusCodes are the HTTP status codes offered for respond and error.
+var statusCodes = []int{
+	200, 201, 204,
+	301, 302, 303, 304, 307, 308,
+	400, 401, 403, 404, 405, 406, 408, 409, 410, 413, 415, 418, 429,
+	500, 501, 502, 503, 504,
+}
+
+// redirCodes are the redirect status codes and the named forms redir
+// accepts in place of one.
+// Source: modules/caddyhttp/staticresp.go (parseRedir)
+var redirCodes = []struct{ code, detail string }{
+	{"301", "Moved Permanently"},
+	{"302", "Found"},
+	{"303", "See Other"},
+	{"307", "Temporary Redirect"},
+	{"308", "Permanent Redirect"},
+	{"html", "Redirect with an HTML page and a meta refresh"},
+	{"permanent", "301 Moved Permanently"},
+	{"temporary", "302 Found"},
+}
+
+// statusCompletions offers status codes with their reason phrases where
+// respond, error and redir take one: the first argument of respond and
+// error, or the second one when the first is a body or message, and the
+// second argument of redir. error is only offered the 4xx and 5xx codes.
+func statusCompletions(f *parser.File, content string, pos protocol.Position) ([]protocol.CompletionItem, bool) {
+	var d *parser.Directive
+	for _, name := range []string{"respond", "error", "redir"} {
+		if d = directiveOnLine(f, pos, name, ""); d != nil {
+			break
+		}
+	}
+	if d == nil {
+		return nil, false
+	}
+	i, partial, ok := argumentAt(content, d, pos)
+	if !ok {
+		return nil, false
+	}
+
+	kind := protocol.CompletionItemKindConstant
+	items := []protocol.CompletionItem{}
+	add := func(label, detail string) {
+		if strings.HasPrefix(label, partial) {
+			items = append(items, protocol.CompletionItem{Label: label, Kind: &kind, Detail: &detail})
+		}
+	}
+
+	if d.Name.Value == "redir" {
+		if i != 1 {
+			return nil, false
+		}
+		for _, c := range redirCodes {
+			add(c.code, c.detail)
+		}
+		return items, true
+	}
+	switch args := analysis.DirectiveArgs(d); {
+	case i == 0:
+	case i == 1 && len(args) > 0 && !isStatusCode(args[0].Token.Value):
+	default:
+		return nil, false
+	}
+	for _, code := range statusCodes {
+		if d.Name.Value == "error" && code < 400 {
+			continue
+		}
+		add(strconv.Itoa(code), http.StatusText(code))
+	}
+	return items, true
+}
+
+// isStatusCode reports whether s is a three-digit status code, which respond
+// and error read as the status rather than the body.
+func isStatusCode(s string) bool {
+	_, err := strconv.Atoi(s)
+	return len(s) == 3 && err == nil
+}
+
+// argumentAt returns the index, among the arguments of d after its matcher,
+// of the argument the cursor is in or about to start, and the part of it
+// typed so far. It fails when pos is not after d's name and matcher, or is
+// inside a quoted argument.
+func argumentAt(content string, d *parser.Directive, pos protocol.Position) (int, string, bool) {
+	after := d.Name
+	if args := analysis.DirectiveArgs(d); len(args) < len(d.Args) {
+		after = d.Args[0].Token // the matcher
+	}
+	before := lineBefore(content, pos)
+	start := int(after.Char) + len(after.Value)
+	if len(before) <= start {
+		return 0, "", false
+	}
+	typed := before[start:]
+	if typed[0] != ' ' && typed[0] != '\t' {
+		return 0, "", false
+	}
+
+	n, tokStart := 0, -1
+	var quote byte
+	for j := 0; j < len(typed); j++ {
+		c := typed[j]
+		switch {
+		case quote != 0:
+			if c == quote && typed[j-1] != '\\' {
+				quote = 0
+			}
+		case c == ' ' || c == '\t':
+			tokStart = -1
+		case tokStart < 0:
+			n++
+			tokStart = j
+			if c == '"' || c == '`' {
+				quote = c
+			}
+		}
+	}
+	switch {
+	case quote != 0:
+		return 0, "", false
+	case tokStart >= 0:
+		return n - 1, typed[tokStart:], true
+	}
+	return n, "", true
+}
+
+// lineBefore returns the text of pos's line up to pos.
+func lineBefore(content string, pos protocol.Position) string {
+	line, col, _ := lineAt(content, pos)
+	return line[:col]
+}
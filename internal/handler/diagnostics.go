@@ -1,8 +1,14 @@
 package handler
 
 import (
-	"caddy-ls/internal/analysis"
-	"caddy-ls/internal/parser"
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"context"
+	"fmt"
+	"os"
+	"slices"
+	"time"
 
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -10,30 +16,141 @@ import (
 
 const version = "0.0.1"
 
-// Analyze parses and analyzes content, then publishes diagnostics for uri.
-func (h *Handler) Analyze(ctx *glsp.Context, uri, content string) {
-	ast, parseErrors := parser.Parse(content)
+// Analyze parses and analyzes content, then publishes diagnostics for
+// version of uri. Results are dropped when the document has been edited or
+// closed in the meantime, since their positions no longer match the buffer.
+// With the validate feature off only caddy validate results are published.
+//
+// When analysis takes longer than earlyPublishDelay, as for very large
+// files, the diagnostics found so far are published every
+// earlyPublishDelay until it is done, so the problems at the top of the
+// file show up first.
+func (h *Handler) Analyze(ctx *glsp.Context, uri, content string, version int32) {
+	publish := func(diags []protocol.Diagnostic) {
+		diags = append(diags, h.validations.get(uri, version)...)
+		diags = analysis.CapDiagnostics(analysis.DedupeDiagnostics(diags), h.settings.maxDiagnostics())
+		if current, ok := h.store.Version(uri); !ok || current != version {
+			log.Debugf("dropping diagnostics for %s: version %d is outdated", uri, version)
+			return
+		}
+		h.publisher(ctx).PublishDiagnostics(uri, version, diags)
+	}
+	var diags []protocol.Diagnostic
+	if h.init.Features.validate() {
+		start := time.Now()
+		next := start.Add(earlyPublishDelay)
+		diags = h.diagnoseStream(uri, content, func(sofar []protocol.Diagnostic) {
+			if now := time.Now(); !now.Before(next) {
+				publish(slices.Clone(sofar))
+				next = now.Add(earlyPublishDelay)
+			}
+		})
+		h.analysisTimes.record(uri, version, start)
+	}
+	publish(diags)
+}
+
+// earlyPublishDelay is how long analysis of a document runs before the
+// diagnostics found so far are published, and how often they are
+// published again until it is done.
+var earlyPublishDelay = 300 * time.Millisecond
+
+// reanalyze re-runs analysis for every open document in uris on a bounded
+// worker pool, publishing each document's diagnostics as soon as it is done.
+func (h *Handler) reanalyze(ctx *glsp.Context, uris []string) {
+	_ = workspace.ForEach(context.Background(), 0, uris, func(uri string) {
+		if content, version, ok := h.store.Snapshot(uri); ok {
+			h.Analyze(ctx, uri, content, version)
+		}
+	})
+}
 
-	diags := []protocol.Diagnostic{}
+// readFile returns the text of the open document at path, which may hold
+// unsaved edits, and otherwise reads the file from disk.
+func (h *Handler) readFile(path string) ([]byte, error) {
+	if h.store != nil {
+		if text, _, ok := h.store.Snapshot(workspace.PathToURI(path)); ok {
+			return []byte(text), nil
+		}
+	}
+	return os.ReadFile(path)
+}
 
-	// Convert parse errors to diagnostics
-	for _, pe := range parseErrors {
-		severity := protocol.DiagnosticSeverityError
-		diags = append(diags, protocol.Diagnostic{
-			Range:    pe.Rng,
+// reanalyzeImporters updates the index entry of the document at uri, which
+// now holds text, and re-analyzes the open documents importing it, whose
+// diagnostics depend on the directives and snippets it defines.
+func (h *Handler) reanalyzeImporters(ctx *glsp.Context, uri, text string) {
+	path, ok := workspace.URIToPath(uri)
+	if !ok {
+		return
+	}
+	if _, indexed := h.index.File(uri); indexed {
+		f, _ := parser.Parse(text)
+		h.index.Set(uri, f)
+	}
+	h.reanalyze(ctx, h.index.Importers(path))
+}
+
+// tooLarge reports whether content exceeds the configured document size
+// limit. Such documents are neither analyzed nor parsed for completion and
+// hover, so an accidentally opened generated file cannot stall the editor.
+func (h *Handler) tooLarge(content string) bool {
+	limit := h.settings.maxDocumentSize()
+	return limit >= 0 && len(content) > limit
+}
+
+// diagnose returns the parse and analysis diagnostics for content. It only
+// reads handler state and is safe to call concurrently.
+func (h *Handler) diagnose(uri, content string) []protocol.Diagnostic {
+	return h.diagnoseStream(uri, content, nil)
+}
+
+// diagnoseStream is like diagnose but, when progress is not nil, also
+// passes it the diagnostics found so far each time analysis has finished
+// a part of the document, such as a site block. progress must not keep
+// the slice.
+func (h *Handler) diagnoseStream(uri, content string, progress func(sofar []protocol.Diagnostic)) []protocol.Diagnostic {
+	if h.tooLarge(content) {
+		severity := protocol.DiagnosticSeverityInformation
+		return []protocol.Diagnostic{{
 			Severity: &severity,
 			Source:   strPtr("caddy-ls"),
-			Message:  pe.Message,
-		})
+			Message: fmt.Sprintf("document is %d bytes, larger than the %d byte analysis limit; diagnostics, completion and hover are disabled (setting: caddy.maxDocumentSize)",
+				len(content), h.settings.maxDocumentSize()),
+		}}
 	}
 
-	// Run semantic analysis
-	diags = append(diags, analysis.Analyze(ast)...)
+	ast, parseErrors := parser.Parse(content)
+
+	diags := analysis.ParseErrorDiagnostics(uri, parseErrors)
 
-	ctx.Notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
-		URI:         uri,
-		Diagnostics: diags,
+	// Run semantic analysis
+	settings := h.settingsFor(uri)
+	opts := settings.analysisOptions()
+	opts.URI = uri
+	path, isFile := workspace.URIToPath(uri)
+	if isFile {
+		opts.Imported = func() bool { return h.index.IsImported(path) }
+	}
+	analysis.AnalyzeStream(ast, opts, func(part []protocol.Diagnostic) {
+		diags = append(diags, part...)
+		if progress != nil {
+			progress(diags)
+		}
 	})
+	diags = append(diags, analysis.AnalyzeEnv(ast, h.env)...)
+	if isFile {
+		files := settings.Files.options(h.roots)
+		files.ReadFile = h.readFile
+		diags = append(diags, workspace.ImportDiagnostics(path, ast, settings.schema, files)...)
+		diags = append(diags, workspace.FileDiagnostics(path, ast, files)...)
+	}
+	for _, fix := range lintFixes(content, ast, settings.Lint) {
+		if !fix.Alternative { // its diagnostic comes with the preferred fix
+			diags = append(diags, fix.Diagnostic)
+		}
+	}
+	return diags
 }
 
 func strPtr(s string) *string { return &s }
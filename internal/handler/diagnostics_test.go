@@ -0,0 +1,275 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// TestDiagnose_ConcurrentMatchesSequential analyzes many documents on the
+// worker pool and checks the results equal a sequential run. Run with -race
+// to catch unsynchronized access to the shared schema maps.
+func TestDiagnose_ConcurrentMatchesSequential(t *testing.T) {
+	h := New(document.New())
+	h.applySettings(Settings{Env: EnvSettings{Vars: map[string]string{"HOST": "example.com"}}})
+
+	sources := []string{
+		"{$HOST} {\n\treverse_proxy {$UPSTREAM} {\n\t\tlb_policy round_robbin\n\t\ttransport grpc\n\t}\n}\n",
+		"example.com {\n\ttls {\n\t\tprotocols tls1.3 tls1.2\n\t}\n\theader {\n\t\t?X-A 1\n\t}\n}\n",
+		"example.com {\n\tphp_fastcgi localhost:9000 {\n\t\tindex off\n\t\ttry_files {path}\n\t}\n",
+		"example.com {\n\tbasic_auth {\n\t\tbob plaintext\n\t}\n\turi strip_perfix /x\n}\n",
+	}
+	var uris []string
+	want := make(map[string]any)
+	for i := range 40 {
+		uri := fmt.Sprintf("file:///%02d.caddyfile", i)
+		src := sources[i%len(sources)]
+		uris = append(uris, uri)
+		h.store.Open(uri, src, 1)
+		want[uri] = h.diagnose(uri, src)
+	}
+
+	var mu sync.Mutex
+	got := make(map[string]any)
+	err := workspace.ForEach(context.Background(), 8, uris, func(uri string) {
+		content, _ := h.store.Get(uri)
+		diags := h.diagnose(uri, content)
+		mu.Lock()
+		got[uri] = diags
+		mu.Unlock()
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(got, want) {
+		t.Error("concurrent diagnostics differ from sequential results")
+	}
+}
+
+func TestDiagnose_OversizedDocument(t *testing.T) {
+	h := New(document.New())
+	h.applySettings(Settings{MaxDocumentSize: 64})
+	src := "example.com {\n\tnot_a_directive\n}\n" + strings.Repeat("# padding\n", 10)
+
+	diags := h.diagnose("file:///big.caddyfile", src)
+	if len(diags) != 1 {
+		t.Fatalf("want a single diagnostic, got %v", diags)
+	}
+	if *diags[0].Severity != protocol.DiagnosticSeverityInformation || !strings.Contains(diags[0].Message, "analysis limit") {
+		t.Errorf("want informational size-limit diagnostic, got %+v", diags[0])
+	}
+}
+
+func TestDiagnose_SizeLimitDisabled(t *testing.T) {
+	h := New(document.New())
+	h.applySettings(Settings{MaxDocumentSize: -1})
+	src := strings.Repeat("# padding\n", defaultMaxDocumentSize/10+1)
+	if diags := h.diagnose("file:///big.caddyfile", src); len(diags) != 0 {
+		t.Errorf("limit disabled: want no diagnostics, got %v", diags)
+	}
+}
+
+func TestSettings_MaxDocumentSizeDefault(t *testing.T) {
+	if got := (Settings{}).maxDocumentSize(); got != defaultMaxDocumentSize {
+		t.Errorf("default limit = %d, want %d", got, defaultMaxDocumentSize)
+	}
+}
+
+// recordNotify returns a glsp.Context whose notifications are appended to
+// *published.
+func recordNotify(published *[]protocol.PublishDiagnosticsParams) *glsp.Context {
+	return &glsp.Context{Notify: func(method string, params any) {
+		if p, ok := params.(protocol.PublishDiagnosticsParams); ok {
+			*published = append(*published, p)
+		}
+	}}
+}
+
+func TestAnalyze_PublishesVersion(t *testing.T) {
+	h := New(document.New())
+	var published []protocol.PublishDiagnosticsParams
+	ctx := recordNotify(&published)
+
+	h.store.Open("file:///a.caddyfile", "example.com {\n}\n", 3)
+	h.Analyze(ctx, "file:///a.caddyfile", "example.com {\n}\n", 3)
+	if len(published) != 1 || published[0].Version == nil || *published[0].Version != 3 {
+		t.Fatalf("want diagnostics published for version 3, got %+v", published)
+	}
+}
+
+func TestAnalyze_DropsOutdatedVersion(t *testing.T) {
+	h := New(document.New())
+	var published []protocol.PublishDiagnosticsParams
+	ctx := recordNotify(&published)
+
+	h.store.Open("file:///a.caddyfile", "example.com {\n}\n", 1)
+	h.store.Update("file:///a.caddyfile", "example.com {\n\trespond ok\n}\n", 2)
+	h.Analyze(ctx, "file:///a.caddyfile", "example.com {\n}\n", 1)
+	if len(published) != 0 {
+		t.Errorf("results for an outdated version must be dropped, got %+v", published)
+	}
+
+	h.store.Close("file:///a.caddyfile")
+	h.Analyze(ctx, "file:///a.caddyfile", "example.com {\n\trespond ok\n}\n", 2)
+	if len(published) != 0 {
+		t.Errorf("results for a closed document must be dropped, got %+v", published)
+	}
+}
+
+func TestAnalyze_CapsDiagnostics(t *testing.T) {
+	src := "example.com {\n" + strings.Repeat("\tnot_a_directive\n", 20) + "}\n"
+	for _, tc := range []struct {
+		max  int
+		want int
+	}{
+		{max: 5, want: 6},
+		{max: -1, want: 20},
+		{max: 0, want: 20},
+	} {
+		h := New(document.New())
+		h.applySettings(Settings{MaxDiagnostics: tc.max})
+		var published []protocol.PublishDiagnosticsParams
+		h.store.Open("file:///a.caddyfile", src, 1)
+		h.Analyze(recordNotify(&published), "file:///a.caddyfile", src, 1)
+		if len(published) != 1 {
+			t.Fatalf("max %d: want one publication, got %d", tc.max, len(published))
+		}
+		diags := published[0].Diagnostics
+		if len(diags) != tc.want {
+			t.Errorf("max %d: got %d diagnostics, want %d", tc.max, len(diags), tc.want)
+		}
+		if tc.max > 0 && !strings.Contains(diags[len(diags)-1].Message, "15 more problems not shown") {
+			t.Errorf("max %d: want a summary diagnostic last, got %q", tc.max, diags[len(diags)-1].Message)
+		}
+	}
+}
+
+// publication is one call to a DiagnosticsPublisher.
+type publication struct {
+	uri     string
+	version int32
+	diags   []protocol.Diagnostic
+}
+
+// recordingPublisher is a DiagnosticsPublisher that keeps every call.
+type recordingPublisher struct {
+	published []publication
+}
+
+func (p *recordingPublisher) PublishDiagnostics(uri string, version int32, diags []protocol.Diagnostic) {
+	p.published = append(p.published, publication{uri, version, diags})
+}
+
+func TestPublisher_OpenChangeSave(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	var p recordingPublisher
+	h := NewWithPublisher(document.New(), &p)
+
+	if err := h.DidOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Text: "a.com {\n\tbogus\n}\n", Version: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DidChange(nil, &protocol.DidChangeTextDocumentParams{
+		TextDocument: protocol.VersionedTextDocumentIdentifier{
+			TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: uri},
+			Version:                2,
+		},
+		ContentChanges: []any{protocol.TextDocumentContentChangeEventWhole{Text: "a.com {\n\trespond ok\n}\n"}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DidSave(nil, &protocol.DidSaveTextDocumentParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+	}); err != nil {
+		t.Fatal(err)
+	}
+
+	if len(p.published) != 3 {
+		t.Fatalf("want 3 publications, got %+v", p.published)
+	}
+	open, change, save := p.published[0], p.published[1], p.published[2]
+	if open.uri != uri || open.version != 1 || len(open.diags) != 1 || !strings.Contains(open.diags[0].Message, `unknown directive "bogus"`) {
+		t.Errorf("open: got %+v", open)
+	}
+	if change.version != 2 || len(change.diags) != 0 {
+		t.Errorf("change: got %+v", change)
+	}
+	if save.version != 2 || len(save.diags) != 0 {
+		t.Errorf("save: got %+v", save)
+	}
+}
+
+func TestPublisher_EarlyDiagnostics(t *testing.T) {
+	defer func(d time.Duration) { earlyPublishDelay = d }(earlyPublishDelay)
+	earlyPublishDelay = 0
+
+	const uri = "file:///Caddyfile"
+	var p recordingPublisher
+	h := NewWithPublisher(document.New(), &p)
+	if err := h.DidOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Text: "a.com {\n\tbogus\n}\nb.com {\n\trespond ok\n}\nc.com {\n\tbogus2\n}\n", Version: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	var counts []int
+	for _, pub := range p.published {
+		counts = append(counts, len(pub.diags))
+	}
+	// One publication per site block with problems, then the final one.
+	if want := []int{1, 2, 2}; !slices.Equal(counts, want) {
+		t.Fatalf("diagnostics per publication: got %v, want %v", counts, want)
+	}
+	if msg := p.published[0].diags[0].Message; !strings.Contains(msg, `"bogus"`) {
+		t.Errorf("first publication: want the first site's problem, got %q", msg)
+	}
+}
+
+func TestDidChange_ReanalyzesImporters(t *testing.T) {
+	dir := t.TempDir()
+	main, site := filepath.Join(dir, "Caddyfile"), filepath.Join(dir, "snippets", "site.conf")
+	mainSrc := "example.com {\n\timport snippets/site.conf\n}\n"
+	if err := os.Mkdir(filepath.Dir(site), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	for path, src := range map[string]string{main: mainSrc, site: "encode gzip\n"} {
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	h := New(document.New())
+	h.index.Load(main)
+	h.index.Load(site)
+	var published []protocol.PublishDiagnosticsParams
+	ctx := recordNotify(&published)
+	mainURI, siteURI := workspace.PathToURI(main), workspace.PathToURI(site)
+	h.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{TextDocument: protocol.TextDocumentItem{URI: mainURI, Text: mainSrc, Version: 1}})
+	h.DidOpen(ctx, &protocol.DidOpenTextDocumentParams{TextDocument: protocol.TextDocumentItem{URI: siteURI, Text: "encode gzip\n", Version: 1}})
+
+	published = nil
+	h.DidChange(ctx, &protocol.DidChangeTextDocumentParams{
+		TextDocument:   protocol.VersionedTextDocumentIdentifier{TextDocumentIdentifier: protocol.TextDocumentIdentifier{URI: siteURI}, Version: 2},
+		ContentChanges: []any{protocol.TextDocumentContentChangeEventWhole{Text: "lb_policy first\n"}},
+	})
+	var importer []protocol.Diagnostic
+	for _, p := range published {
+		if p.URI == mainURI {
+			importer = p.Diagnostics
+		}
+	}
+	if len(importer) != 1 || !strings.Contains(importer[0].Message, `"lb_policy" from imported snippets/site.conf`) {
+		t.Errorf("want the importer's diagnostics published again for the unsaved edit, got %+v", importer)
+	}
+}
@@ -0,0 +1,24 @@
+package handler
+
+// directiveDocOverrides replace the generated directiveDocs of a directive.
+// They document the directives whose Caddy source code lacks a syntax doc
+// comment, which docgen skips; everything else comes from docs_gen.go.
+var directiveDocOverrides = map[string]string{
+	"abort": "abort parses the abort directive.\n\n```\nabort [<matcher>]\n```\n\nAborts the HTTP request with no response.",
+
+	"handle": "handle sets up a mutually-exclusive request handler.\n\n```\nhandle [<matcher>] {\n    <directives...>\n}\n```\n\nLike route, but handlers are mutually exclusive by default based on their matcher.",
+
+	"handle_errors": "handle_errors defines routes for handling HTTP errors.\n\n```\nhandle_errors [<status_codes...>] {\n    <directives...>\n}\n```\n\nStatus codes can be 3-digit numbers, 4xx, 5xx, or a range like 500-599.",
+
+	"invoke": "invoke invokes a named route defined elsewhere in the config.\n\n```\ninvoke <name>\n```",
+
+	"route": "route groups directives that are applied in order, without reordering.\n\n```\nroute [<matcher>] {\n    <directives...>\n}\n```\n\nUnlike handle, directives inside a route block are applied in the order they appear.",
+}
+
+// directiveNotes are short explanations shown above a directive's syntax
+// docs, for directives whose behaviour is not obvious from the syntax alone.
+var directiveNotes = map[string]string{
+	"log": "**Access log:** `log` in a site enables the access log of its requests. Caddy's runtime logs are configured by the `log` global option, which is also where `include` and `exclude` filter them.",
+
+	"php_fastcgi": "**Shorthand:** `php_fastcgi` expands into a `route` containing a `redir` that adds a trailing slash to directory requests, a `rewrite` to the index file (unless `index off`), and a `reverse_proxy` using `transport fastcgi`. That is why reverse_proxy subdirectives such as `lb_policy` or `header_up` also work inside its block; only `transport` is fixed.",
+}
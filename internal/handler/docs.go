@@ -0,0 +1,46 @@
+package handler
+
+import "caddy-ls/pkg/caddyfile/analysis"
+
+// DocProvider supplies the Markdown documentation that hover, completion
+// and signature help show.
+type DocProvider interface {
+	// Directive documents a directive, or a subdirective documented by
+	// its own syntax comment in Caddy.
+	Directive(name string) (string, bool)
+	// Matcher documents a request matcher type.
+	Matcher(name string) (string, bool)
+}
+
+// builtinDocs is the DocProvider of the server: the directive docs that
+// cmd/docgen generates from Caddy's source, under the hand-written
+// overrides, and the hand-written matcher docs.
+type builtinDocs struct{}
+
+// Directive returns the override for name, else its generated docs. A note
+// from directiveNotes, if any, is placed above the syntax, and the Caddy
+// versions that added or deprecated name below it.
+func (builtinDocs) Directive(name string) (string, bool) {
+	doc, ok := directiveDocOverrides[name]
+	if !ok {
+		doc, ok = directiveDocs[name]
+	}
+	if note, hasNote := directiveNotes[name]; ok && hasNote {
+		doc = note + "\n\n" + doc
+	}
+	if v, hasVersion := analysis.DirectiveVersionOf(name); ok && hasVersion {
+		doc += "\n\n_" + v.Note() + "_"
+	}
+	return doc, ok
+}
+
+func (builtinDocs) Matcher(name string) (string, bool) {
+	doc, ok := matcherDocs[name]
+	return doc, ok
+}
+
+// SetDocProvider replaces the documentation the handler shows, e.g. with
+// docs for a custom Caddy build.
+func (h *Handler) SetDocProvider(p DocProvider) {
+	h.docs = p
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"bytes"
+	"caddy-ls/internal/docgen"
+	"caddy-ls/internal/document"
+	"os"
+	"slices"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// TestDocsGenUpToDate fails when docs_gen.go differs from what
+// `go generate ./internal/handler` produces for the Caddy version in go.mod.
+func TestDocsGenUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("parses the Caddy source tree")
+	}
+	dir, err := docgen.CaddyDir()
+	if err != nil {
+		t.Skipf("Caddy module not available: %v", err)
+	}
+	want, _, err := docgen.Generate(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("docs_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("docs_gen.go is stale; run go generate ./internal/handler")
+	}
+}
+
+func TestBuiltinDocs_OverridesWin(t *testing.T) {
+	for name, override := range directiveDocOverrides {
+		if doc, _ := (builtinDocs{}).Directive(name); !strings.Contains(doc, override) {
+			t.Errorf("%s: got %q, want the override", name, doc)
+		}
+		if _, ok := directiveDocs[name]; ok {
+			t.Errorf("%s: docgen now documents it; drop the override unless it is better", name)
+		}
+	}
+}
+
+// fakeDocs documents every directive and matcher with its name.
+type fakeDocs struct{}
+
+func (fakeDocs) Directive(name string) (string, bool) { return "directive " + name, true }
+func (fakeDocs) Matcher(name string) (string, bool)   { return "matcher " + name + "\n\nmore", true }
+
+func TestSetDocProvider(t *testing.T) {
+	src := "example.com {\n\t@api header X-Api\n\treverse_proxy @api app:8080\n\t\n}\n"
+	h := New(document.New())
+	h.SetDocProvider(fakeDocs{})
+	h.store.Open("file:///Caddyfile", src, 1)
+	doc := protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"}
+
+	hover := func(p protocol.Position) string {
+		got, err := h.Hover(nil, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{TextDocument: doc, Position: p}})
+		if err != nil || got == nil {
+			t.Fatalf("hover at %v: %v, %v", p, got, err)
+		}
+		return got.Contents.(protocol.MarkupContent).Value
+	}
+	if got := hover(pos(2, 3)); got != "directive reverse_proxy" {
+		t.Errorf("directive hover = %q", got)
+	}
+	if got := hover(pos(1, 8)); !strings.HasPrefix(got, "matcher header") {
+		t.Errorf("matcher hover = %q", got)
+	}
+
+	items, err := h.Completion(nil, &protocol.CompletionParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{TextDocument: doc, Position: pos(3, 1)}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	i := slices.IndexFunc(items.([]protocol.CompletionItem), func(item protocol.CompletionItem) bool { return item.Label == "respond" })
+	if i < 0 {
+		t.Fatal("respond not offered")
+	}
+	if d, ok := items.([]protocol.CompletionItem)[i].Documentation.(protocol.MarkupContent); !ok || d.Value != "directive respond" {
+		t.Errorf("completion documentation = %v", items.([]protocol.CompletionItem)[i].Documentation)
+	}
+
+	help, err := h.SignatureHelp(nil, &protocol.SignatureHelpParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{TextDocument: doc, Position: pos(1, 15)}})
+	if err != nil || help == nil {
+		t.Fatalf("signature help: %v, %v", help, err)
+	}
+	if d, ok := help.Signatures[0].Documentation.(protocol.MarkupContent); !ok || d.Value != "matcher header" {
+		t.Errorf("signature documentation = %v", help.Signatures[0].Documentation)
+	}
+}
+
+func TestBuiltinDocs_VersionNote(t *testing.T) {
+	if doc, _ := (builtinDocs{}).Directive("fs"); !strings.HasSuffix(doc, "_Available since Caddy v2.8._") {
+		t.Errorf("fs: got %q", doc)
+	}
+	if doc, _ := (builtinDocs{}).Directive("skip_log"); !strings.Contains(doc, "Deprecated since Caddy v2.8; use `log_skip` instead.") {
+		t.Errorf("skip_log: got %q", doc)
+	}
+	if doc, _ := (builtinDocs{}).Directive("respond"); strings.Contains(doc, "Caddy v") {
+		t.Errorf("respond: got %q", doc)
+	}
+}
+
+func TestAnalysisOptions_CaddyVersion(t *testing.T) {
+	h := New(document.New())
+	h.applyInitOptions(InitOptions{CaddyVersion: "2.7"})
+	if got := h.analysisOptions().CaddyVersion; got != "2.7" {
+		t.Errorf("CaddyVersion = %q", got)
+	}
+}
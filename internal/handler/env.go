@@ -0,0 +1,194 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/env"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// buildEnvResolver turns the env settings into a resolver. Unreadable .env
+// files are logged and skipped.
+func buildEnvResolver(s EnvSettings, roots []string) *env.Resolver {
+	var sources []env.Source
+	if len(s.Vars) > 0 {
+		sources = append(sources, env.Source{Name: "settings", Vars: s.Vars})
+	}
+	for _, file := range s.Files {
+		src, err := loadEnvFile(resolveRootPath(file, roots), file)
+		if err != nil {
+			log.Warningf("cannot read env file: %v", err)
+			continue
+		}
+		sources = append(sources, src)
+	}
+	if s.Process {
+		vars := make(map[string]string)
+		for _, kv := range os.Environ() {
+			if k, v, ok := strings.Cut(kv, "="); ok {
+				vars[k] = v
+			}
+		}
+		sources = append(sources, env.Source{Name: "process environment", Vars: vars})
+	}
+	return env.NewResolver(sources...)
+}
+
+// loadEnvFile reads a .env or docker-compose file into a source called name.
+func loadEnvFile(path, name string) (env.Source, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return env.Source{}, err
+	}
+	if env.IsComposeFile(path) {
+		vars, err := env.ParseCompose(string(data))
+		if err != nil {
+			return env.Source{}, fmt.Errorf("%s: %w", path, err)
+		}
+		return env.Source{Name: name, Vars: vars}, nil
+	}
+	return env.Source{Name: name, Vars: env.ParseDotenv(string(data))}, nil
+}
+
+// envCompletionResolver extends the configured resolver with the completion
+// sources found in each workspace root. Missing files are silently skipped;
+// they are only defaults. It reads the files, so the handler builds it when
+// the settings are applied and when the files change rather than for every
+// completion.
+func envCompletionResolver(s EnvSettings, roots []string, base *env.Resolver) *env.Resolver {
+	var extra []env.Source
+	for _, root := range roots {
+		for _, file := range s.completionSources() {
+			src, err := loadEnvFile(completionSourcePath(root, file), file)
+			if err != nil {
+				if !os.IsNotExist(err) {
+					log.Debugf("skipping env completion source: %v", err)
+				}
+				continue
+			}
+			extra = append(extra, src)
+		}
+	}
+	return base.With(extra...)
+}
+
+// completionSources returns the files of CompletionSources, or the
+// defaults.
+func (s EnvSettings) completionSources() []string {
+	if s.CompletionSources == nil {
+		return defaultEnvCompletionSources
+	}
+	return s.CompletionSources
+}
+
+// completionSourcePath returns where the completion source file is looked
+// up in the workspace root.
+func completionSourcePath(root, file string) string {
+	if filepath.IsAbs(file) {
+		return file
+	}
+	return filepath.Join(root, file)
+}
+
+// envFiles returns the paths of the files the env resolvers are built from:
+// the configured .env files and the completion sources in each workspace
+// root.
+func envFiles(s EnvSettings, roots []string) []string {
+	var paths []string
+	for _, file := range s.Files {
+		paths = append(paths, resolveRootPath(file, roots))
+	}
+	for _, root := range roots {
+		for _, file := range s.completionSources() {
+			paths = append(paths, completionSourcePath(root, file))
+		}
+	}
+	return paths
+}
+
+// rebuildEnv builds the env resolvers from the settings, reading the env
+// files again.
+func (h *Handler) rebuildEnv() {
+	h.env = buildEnvResolver(h.settings.Env, h.roots)
+	h.envCompletion = envCompletionResolver(h.settings.Env, h.roots, h.env)
+}
+
+// envNamePrefix reports whether the cursor follows an unterminated "{$" on
+// its line and returns the partial variable name typed so far.
+func envNamePrefix(content string, pos protocol.Position) (string, bool) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return "", false
+	}
+	before := line[:col]
+	i := strings.LastIndex(before, "{$")
+	if i < 0 {
+		return "", false
+	}
+	partial := before[i+2:]
+	if strings.ContainsAny(partial, "}: \t") {
+		return "", false
+	}
+	return partial, true
+}
+
+// envCompletions returns completion items for the variables in r whose
+// names start with partial. The closing brace is inserted unless it is
+// already present after the cursor.
+func envCompletions(r *env.Resolver, partial string, closed bool) []protocol.CompletionItem {
+	names := r.Names()
+	sort.Strings(names)
+	kind := protocol.CompletionItemKindVariable
+	items := make([]protocol.CompletionItem, 0, len(names))
+	for _, name := range names {
+		if !strings.HasPrefix(name, partial) {
+			continue
+		}
+		value, source, _ := r.Lookup(name)
+		insert := name
+		if !closed {
+			insert += "}"
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:         name,
+			Kind:          &kind,
+			Detail:        strPtr(source),
+			Documentation: value,
+			InsertText:    strPtr(insert),
+		})
+	}
+	return items
+}
+
+// envRefAt returns the {$VAR} placeholder under pos, if any.
+func envRefAt(content string, pos protocol.Position) (env.Ref, bool) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return env.Ref{}, false
+	}
+	for _, ref := range env.FindRefs(line) {
+		if col >= ref.Start && col <= ref.End {
+			return ref, true
+		}
+	}
+	return env.Ref{}, false
+}
+
+// envHoverText describes how ref resolves against r.
+func envHoverText(ref env.Ref, r *env.Resolver) string {
+	header := fmt.Sprintf("**`{$%s}`** — environment variable, substituted when the Caddyfile is loaded.", ref.Name)
+	if value, source, ok := r.Lookup(ref.Name); ok {
+		return fmt.Sprintf("%s\n\nValue: `%s`\n\nFrom: %s", header, value, source)
+	}
+	if ref.HasDefault {
+		return fmt.Sprintf("%s\n\nNot defined; the default `%s` is used.", header, ref.Default)
+	}
+	if r.Empty() {
+		return header + "\n\nNo environment sources are configured."
+	}
+	return header + "\n\nNot defined in any configured source."
+}
@@ -0,0 +1,149 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/env"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestBuildEnvResolver_FilesAndVars(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("UPSTREAM=app:8080\nHOST=file.example\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := buildEnvResolver(EnvSettings{
+		Vars:  map[string]string{"HOST": "settings.example"},
+		Files: []string{".env", "missing.env"},
+	}, []string{root})
+
+	if v, src, ok := r.Lookup("UPSTREAM"); !ok || v != "app:8080" || src != ".env" {
+		t.Errorf("UPSTREAM = %q from %q (ok=%v)", v, src, ok)
+	}
+	if v, _, _ := r.Lookup("HOST"); v != "settings.example" {
+		t.Errorf("HOST = %q, want settings value to take precedence", v)
+	}
+}
+
+func TestBuildEnvResolver_Empty(t *testing.T) {
+	if !buildEnvResolver(EnvSettings{}, nil).Empty() {
+		t.Error("no env settings: want empty resolver")
+	}
+}
+
+func TestEnvRefAt(t *testing.T) {
+	content := "example.com {\n\treverse_proxy {$UPSTREAM:app:80}\n}\n"
+	ref, ok := envRefAt(content, pos(1, 20))
+	if !ok || ref.Name != "UPSTREAM" || ref.Default != "app:80" {
+		t.Errorf("envRefAt = %+v (ok=%v)", ref, ok)
+	}
+	if _, ok := envRefAt(content, pos(1, 3)); ok {
+		t.Error("cursor on directive name: want no env ref")
+	}
+}
+
+func TestEnvHoverText(t *testing.T) {
+	r := env.NewResolver(env.Source{Name: ".env", Vars: map[string]string{"A": "1"}})
+	if got := envHoverText(env.Ref{Name: "A"}, r); !strings.Contains(got, "`1`") || !strings.Contains(got, ".env") {
+		t.Errorf("resolved hover = %q", got)
+	}
+	if got := envHoverText(env.Ref{Name: "B", Default: "x", HasDefault: true}, r); !strings.Contains(got, "default `x`") {
+		t.Errorf("default hover = %q", got)
+	}
+	if got := envHoverText(env.Ref{Name: "B"}, r); !strings.Contains(got, "Not defined") {
+		t.Errorf("undefined hover = %q", got)
+	}
+}
+
+func TestEnvNamePrefix(t *testing.T) {
+	cases := []struct {
+		line    string
+		col     uint32
+		partial string
+		ok      bool
+	}{
+		{"\treverse_proxy {$", 17, "", true},
+		{"\treverse_proxy {$UP", 19, "UP", true},
+		{"\treverse_proxy {$UP:default", 27, "", false},
+		{"\treverse_proxy {$UP} x", 22, "", false},
+		{"\treverse_proxy {path}", 21, "", false},
+	}
+	for _, c := range cases {
+		partial, ok := envNamePrefix(c.line, pos(0, c.col))
+		if ok != c.ok || partial != c.partial {
+			t.Errorf("%q@%d: got (%q, %v), want (%q, %v)", c.line, c.col, partial, ok, c.partial, c.ok)
+		}
+	}
+}
+
+func TestEnvCompletionResolver_DefaultSources(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		".env":               "FROM_DOTENV=1\n",
+		"docker-compose.yml": "services:\n  app:\n    environment:\n      - FROM_COMPOSE=2\n",
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(root, name), []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	r := envCompletionResolver(EnvSettings{}, []string{root}, nil)
+	items := envCompletions(r, "FROM_", false)
+	if len(items) != 2 || items[0].Label != "FROM_COMPOSE" || items[1].Label != "FROM_DOTENV" {
+		t.Fatalf("items = %+v, want FROM_COMPOSE and FROM_DOTENV", items)
+	}
+	if *items[0].InsertText != "FROM_COMPOSE}" {
+		t.Errorf("insert text = %q, want closing brace appended", *items[0].InsertText)
+	}
+	if *items[0].Detail != "docker-compose.yml" {
+		t.Errorf("detail = %q, want source file name", *items[0].Detail)
+	}
+}
+
+func TestEnvCompletionResolver_ConfiguredSourcesOnly(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, ".env"), []byte("A=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	r := envCompletionResolver(EnvSettings{CompletionSources: []string{}}, []string{root}, nil)
+	if items := envCompletions(r, "", true); len(items) != 0 {
+		t.Errorf("empty completion source list: want no items, got %+v", items)
+	}
+}
+
+func TestEnvCompletion_RebuiltWhenFilesChange(t *testing.T) {
+	root := t.TempDir()
+	path := filepath.Join(root, ".env")
+	if err := os.WriteFile(path, []byte("OLD=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	h := New(document.New())
+	h.roots = []string{root}
+	h.applySettings(Settings{})
+	names := func() []string {
+		var names []string
+		for _, item := range envCompletions(h.envCompletion, "", true) {
+			names = append(names, item.Label)
+		}
+		return names
+	}
+
+	if err := os.WriteFile(path, []byte("NEW=1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if got := names(); !slices.Equal(got, []string{"OLD"}) {
+		t.Errorf("before the change is reported: got %v, want the resolver built with the settings", got)
+	}
+	h.DidChangeWatchedFiles(recordNotify(new([]protocol.PublishDiagnosticsParams)), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: workspace.PathToURI(path), Type: protocol.FileChangeTypeChanged}},
+	})
+	if got := names(); !slices.Equal(got, []string{"NEW"}) {
+		t.Errorf("after the change: got %v", got)
+	}
+}
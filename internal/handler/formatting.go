@@ -0,0 +1,31 @@
+package handler
+
+import (
+	"caddy-ls/pkg/format"
+	"strings"
+	"unicode/utf16"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// DocumentFormatting handles textDocument/formatting. The document is laid
+// out like `caddy fmt` does, adjusted by the format settings in effect for
+// it, and replaced as a whole. The client's tab size and insertSpaces
+// options are ignored, so that the result matches `caddy fmt` unless the
+// format settings say otherwise.
+func (h *Handler) DocumentFormatting(ctx *glsp.Context, params *protocol.DocumentFormattingParams) ([]protocol.TextEdit, error) {
+	uri := params.TextDocument.URI
+	content, ok := h.store.Get(uri)
+	if !ok || h.tooLarge(content) {
+		return nil, nil
+	}
+	formatted := string(format.Format([]byte(content), h.settingsFor(uri).Format.options()))
+	if formatted == content {
+		return nil, nil
+	}
+	lines := strings.Split(content, "\n")
+	last := lines[len(lines)-1]
+	end := protocol.Position{Line: uint32(len(lines) - 1), Character: uint32(len(utf16.Encode([]rune(last))))}
+	return []protocol.TextEdit{{Range: protocol.Range{End: end}, NewText: formatted}}, nil
+}
@@ -1,3 +1,3 @@
 package handler
 
-//go:generate go run ../../cmd/docgen/main.go
+//go:generate go run caddy-ls/cmd/docgen
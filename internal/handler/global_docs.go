@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// globalLogDoc documents the log global option, which shares its name and
+// most of its syntax with the log directive but configures Caddy's runtime
+// logs rather than a site's access log.
+const globalLogDoc = "**`log`** global option — configures a logger of Caddy's runtime logs, not a site's access log.\n\n```\nlog [<name>] {\n    output  <writer_module> ...\n    format  <encoder_module> ...\n    level   <level>\n    sampling {\n        interval   <duration>\n        first      <count>\n        thereafter <count>\n    }\n    core    <core_module> ...\n    include <namespaces...>\n    exclude <namespaces...>\n}\n```\n\nWithout a name it configures the `default` logger. Each name may be configured once."
+
+// globalLogOptionDocs documents the options of the log global option.
+var globalLogOptionDocs = map[string]string{
+	"output": "```\noutput stdout|stderr|discard\noutput file <path> {\n    roll_size <size>\n    roll_keep <count>\n    roll_keep_for <duration>\n    ...\n}\noutput net <address>\n```\n\nWhere the logs are written. Default `stderr`.",
+
+	"format": "```\nformat console|json|filter|append ...\n```\n\nHow log entries are encoded. Default `console` in a terminal, `json` otherwise.",
+
+	"level": "```\nlevel DEBUG|INFO|WARN|ERROR|PANIC|FATAL\n```\n\nThe minimum level of the entries logged. Default `INFO`.",
+
+	"sampling": "```\nsampling {\n    interval   <duration>\n    first      <count>\n    thereafter <count>\n}\n```\n\nLogs only some of the entries when there are many of them.",
+
+	"core": "```\ncore <core_module> ...\n```\n\nThe zap core that writes the entries, such as `mock` for tests or a plugin module.",
+
+	"include": "```\ninclude <namespaces...>\n```\n\nLogs only the entries of the given logger namespaces, e.g. `http.log.access.example` for the access log of a site named `example`.",
+
+	"exclude": "```\nexclude <namespaces...>\n```\n\nLogs everything except the entries of the given logger namespaces, e.g. `http.log.access`.",
+}
+
+// globalOptionDocAt documents the log global option, or one of its options,
+// when its name is under pos. Other global options are documented like the
+// directives of the same name.
+func globalOptionDocAt(f *parser.File, pos protocol.Position) (string, bool) {
+	if f.GlobalBlock == nil || !f.GlobalBlock.BodyContains(pos) {
+		return "", false
+	}
+	for _, d := range f.GlobalBlock.Directives {
+		if d.Name.Value != "log" {
+			continue
+		}
+		if tokenContains(d.Name, pos) {
+			return globalLogDoc, true
+		}
+		if !d.BodyContains(pos) {
+			continue
+		}
+		for _, sub := range d.Body {
+			if tokenContains(sub.Name, pos) {
+				doc, ok := globalLogOptionDocs[sub.Name.Value]
+				return doc, ok
+			}
+		}
+	}
+	return "", false
+}
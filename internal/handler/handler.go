@@ -1,13 +1,72 @@
 package handler
 
-import "caddy-ls/internal/document"
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/env"
+
+	"github.com/tliron/commonlog"
+)
+
+var log = commonlog.GetLogger("caddy-ls.handler")
 
 // Handler holds references to shared server state.
 type Handler struct {
 	store *document.Store
+	index *workspace.Index
+	ops   *operations
+	scans workspaceScans
+
+	// Set during initialize.
+	roots            []string
+	workDoneProgress bool
+	client           clientSupport
+	baseLogLevel     commonlog.Level
+	init             InitOptions
+
+	// Schema layers read from files and the caddy binary.
+	schemaFile *analysis.SchemaLayer
+	modules    *analysis.SchemaLayer
+
+	// Replaced on workspace/didChangeConfiguration.
+	settings      Settings
+	env           *env.Resolver
+	envCompletion *env.Resolver // env with the completion sources
+	schema        *analysis.Schema
+	schemaVersion int
+
+	// projectConfigs caches the project configuration files read so far.
+	projectConfigs *projectConfigCache
+
+	// Results of caddyls.validateWithCaddy, per document.
+	validations validations
+	// Duration of the last analysis, per open document.
+	analysisTimes analysisTimes
+
+	// publish receives diagnostics instead of the client when set.
+	publish DiagnosticsPublisher
+	// docs documents directives and matchers.
+	docs DocProvider
 }
 
 // New creates a Handler backed by the given document store.
 func New(store *document.Store) *Handler {
-	return &Handler{store: store}
+	return &Handler{store: store, index: workspace.New(), ops: newOperations(), docs: builtinDocs{},
+		projectConfigs: &projectConfigCache{}}
+}
+
+// NewWithPublisher is like New but hands diagnostics to p rather than
+// sending them to the client, so requests may be served without a
+// connection.
+func NewWithPublisher(store *document.Store, p DiagnosticsPublisher) *Handler {
+	h := New(store)
+	h.publish = p
+	return h
+}
+
+// Configure applies s as if the client had sent it in
+// workspace/didChangeConfiguration, without re-analyzing open documents.
+func (h *Handler) Configure(s Settings) {
+	h.applySettings(s)
 }
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// DocumentHighlight handles textDocument/documentHighlight. On a "{" or "}"
+// of a block it highlights the pair, using the brace tokens the parser
+// recorded, so the match is exact in nested and one-line blocks. A brace
+// without its partner highlights nothing.
+func (h *Handler) DocumentHighlight(ctx *glsp.Context, params *protocol.DocumentHighlightParams) ([]protocol.DocumentHighlight, error) {
+	content, ok := h.store.Get(string(params.TextDocument.URI))
+	if !ok || h.tooLarge(content) {
+		return nil, nil
+	}
+	ast, _ := parser.Parse(content)
+	b, ok := bracesAt(ast, params.Position)
+	if !ok {
+		return nil, nil
+	}
+	kind := protocol.DocumentHighlightKindText
+	return []protocol.DocumentHighlight{
+		{Range: b.LBrace.Range(), Kind: &kind},
+		{Range: b.RBrace.Range(), Kind: &kind},
+	}, nil
+}
+
+// bracesAt returns the closed block with a brace under pos.
+func bracesAt(f *parser.File, pos protocol.Position) (*parser.Braces, bool) {
+	on := func(b *parser.Braces) bool {
+		return b.LBrace != nil && b.RBrace != nil && (tokenContains(*b.LBrace, pos) || tokenContains(*b.RBrace, pos))
+	}
+	var walk func(ds []*parser.Directive) (*parser.Braces, bool)
+	walk = func(ds []*parser.Directive) (*parser.Braces, bool) {
+		for _, d := range ds {
+			if on(&d.Braces) {
+				return &d.Braces, true
+			}
+			if d.BodyContains(pos) {
+				return walk(d.Body)
+			}
+		}
+		return nil, false
+	}
+	if g := f.GlobalBlock; g != nil {
+		if on(&g.Braces) {
+			return &g.Braces, true
+		}
+		if g.BodyContains(pos) {
+			return walk(g.Directives)
+		}
+	}
+	for _, sb := range f.SiteBlocks {
+		if on(&sb.Braces) {
+			return &sb.Braces, true
+		}
+		if sb.BodyContains(pos) {
+			return walk(sb.Directives)
+		}
+	}
+	return nil, false
+}
@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestDocumentHighlight_Braces(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	src := "{\n" +
+		"\tadmin off\n" +
+		"}\n" +
+		"a.com {\n" +
+		"\treverse_proxy app:8080 {\n" +
+		"\t\ttransport http {\n" +
+		"\t\t\tversions 2\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"\theader { X-A 1 }\n" +
+		"\trespond {path}\n" +
+		"}\n" +
+		"b.com {\n" +
+		"\thandle {\n"
+	store := document.New()
+	store.Open(uri, src, 1)
+	h := New(store)
+	highlight := func(p protocol.Position) []protocol.Range {
+		got, err := h.DocumentHighlight(nil, &protocol.DocumentHighlightParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     p,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		var ranges []protocol.Range
+		for _, hl := range got {
+			ranges = append(ranges, hl.Range)
+		}
+		return ranges
+	}
+	brace := func(line, char uint32) protocol.Range {
+		return protocol.Range{Start: pos(line, char), End: pos(line, char+1)}
+	}
+
+	tests := []struct {
+		name string
+		pos  protocol.Position
+		want []protocol.Range
+	}{
+		{"global block", pos(0, 0), []protocol.Range{brace(0, 0), brace(2, 0)}},
+		{"site block close", pos(11, 0), []protocol.Range{brace(3, 6), brace(11, 0)}},
+		{"reverse_proxy open", pos(4, 24), []protocol.Range{brace(4, 24), brace(8, 1)}},
+		{"nested transport close", pos(7, 2), []protocol.Range{brace(5, 17), brace(7, 2)}},
+		{"one-line block", pos(9, 16), []protocol.Range{brace(9, 8), brace(9, 16)}},
+		{"placeholder", pos(10, 10), nil},
+		{"directive name", pos(4, 3), nil},
+		{"unclosed block", pos(13, 8), nil},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := highlight(tc.pos)
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %v, want %v", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("got %v, want %v", got, tc.want)
+				}
+			}
+		})
+	}
+}
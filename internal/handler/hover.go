@@ -1,61 +1,148 @@
 package handler
 
 import (
+	"caddy-ls/pkg/caddyfile/parser"
 	"strings"
 	"unicode"
+	"unicode/utf8"
 
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
-// lookupDirectiveDoc returns the Markdown documentation for a directive name.
-// It checks the generated map first, then the hand-maintained fallback map.
-func lookupDirectiveDoc(name string) (string, bool) {
-	if doc, ok := directiveDocs[name]; ok {
-		return doc, true
-	}
-	doc, ok := directiveDocsExtra[name]
-	return doc, ok
-}
-
 // Hover handles textDocument/hover.
 func (h *Handler) Hover(ctx *glsp.Context, params *protocol.HoverParams) (*protocol.Hover, error) {
 	uri := string(params.TextDocument.URI)
 	content, ok := h.store.Get(uri)
-	if !ok {
+	if !ok || h.tooLarge(content) || !h.init.Features.hover() {
 		return nil, nil
 	}
 
+	if ref, ok := envRefAt(content, params.Position); ok {
+		return &protocol.Hover{
+			Contents: markup(h.client.hoverFormat, envHoverText(ref, h.env)),
+		}, nil
+	}
+
+	ast, _ := parser.Parse(content)
+	if name, ok := placeholderAt(content, params.Position); ok {
+		for _, p := range scopedPlaceholdersAt(ast, params.Position) {
+			if p.matches(name) {
+				return &protocol.Hover{
+					Contents: markup(h.client.hoverFormat, "**`{"+p.label()+"}`** — "+p.Doc),
+				}, nil
+			}
+		}
+	}
+
+	if doc, ok := quotingHoverAt(content, params.Position); ok {
+		return &protocol.Hover{
+			Contents: markup(h.client.hoverFormat, doc),
+		}, nil
+	}
+
 	word := wordAtPosition(content, params.Position)
 	if word == "" {
 		return nil, nil
 	}
 
-	doc, found := lookupDirectiveDoc(word)
+	// Matcher types inside a named matcher take precedence over directives
+	// of the same name (header, file, vars).
+	doc, found := "", false
+	if name, ok := matcherTypeAt(ast, params.Position); ok {
+		doc, found = h.docs.Matcher(name)
+	}
+	if !found {
+		doc, found = h.snippetHoverAt(uri, content, ast, params.Position)
+	}
+	if !found {
+		doc, found = argumentDocAt(ast, params.Position)
+	}
+	if !found {
+		doc, found = globalOptionDocAt(ast, params.Position)
+	}
+	if !found {
+		if sub, parents, ok := subdirectiveAt(ast, params.Position); ok {
+			doc, found = lookupSubdirectiveDoc(h.docs, sub.Name.Value, parents)
+		} else {
+			doc, found = h.docs.Directive(word)
+		}
+	}
 	if !found {
 		return nil, nil
 	}
 
 	return &protocol.Hover{
-		Contents: protocol.MarkupContent{
-			Kind:  protocol.MarkupKindMarkdown,
-			Value: doc,
-		},
+		Contents: markup(h.client.hoverFormat, doc),
 	}, nil
 }
 
+// matcherTypeAt returns the matcher type whose name token is under pos when
+// pos lies inside a named matcher definition: either the first argument of a
+// single-line `@name <type> ...` or a line of an `@name { ... }` block,
+// including blocks nested under `not`.
+func matcherTypeAt(f *parser.File, pos protocol.Position) (string, bool) {
+	var walk func(ds []*parser.Directive) (string, bool)
+	walk = func(ds []*parser.Directive) (string, bool) {
+		for _, d := range ds {
+			if strings.HasPrefix(d.Name.Value, "@") {
+				if len(d.Args) > 0 && tokenContains(d.Args[0].Token, pos) {
+					return d.Args[0].Token.Value, true
+				}
+				if d.BodyContains(pos) {
+					return matcherInBody(d.Body, pos)
+				}
+				continue
+			}
+			if d.BodyContains(pos) {
+				return walk(d.Body)
+			}
+		}
+		return "", false
+	}
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			return walk(sb.Directives)
+		}
+	}
+	return "", false
+}
+
+// matcherInBody resolves pos against the matcher lines of a named matcher
+// block. "not" may carry a nested matcher, on its line or in its own block.
+func matcherInBody(ds []*parser.Directive, pos protocol.Position) (string, bool) {
+	for _, d := range ds {
+		if tokenContains(d.Name, pos) {
+			return d.Name.Value, true
+		}
+		if d.Name.Value != "not" {
+			continue
+		}
+		if len(d.Args) > 0 && tokenContains(d.Args[0].Token, pos) {
+			return d.Args[0].Token.Value, true
+		}
+		if d.BodyContains(pos) {
+			return matcherInBody(d.Body, pos)
+		}
+	}
+	return "", false
+}
+
+// tokenContains reports whether pos touches tok, including the position just
+// past its last character.
+func tokenContains(tok parser.Token, pos protocol.Position) bool {
+	r := tok.Range()
+	return pos.Line == r.Start.Line && pos.Character >= r.Start.Character && pos.Character <= r.End.Character
+}
+
 // wordAtPosition extracts the word under the cursor position.
 func wordAtPosition(content string, pos protocol.Position) string {
-	lines := strings.Split(content, "\n")
-	if int(pos.Line) >= len(lines) {
+	line, offset, ok := lineAt(content, pos)
+	if !ok {
 		return ""
 	}
-	line := lines[pos.Line]
 	runes := []rune(line)
-	col := int(pos.Character)
-	if col > len(runes) {
-		col = len(runes)
-	}
+	col := utf8.RuneCountInString(line[:offset])
 
 	// Find start of word
 	start := col
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"caddy-ls/internal/document"
+	"strings"
 	"testing"
 
 	protocol "github.com/tliron/glsp/protocol_3_16"
@@ -118,8 +120,147 @@ func TestDirectiveDocs_CommonDirectivesPresent(t *testing.T) {
 		"handle_errors", "handle_path", "abort", "error",
 	}
 	for _, name := range mustHave {
-		if _, ok := lookupDirectiveDoc(name); !ok {
+		if _, ok := (builtinDocs{}).Directive(name); !ok {
 			t.Errorf("directive docs missing entry for %q", name)
 		}
 	}
 }
+
+// --- matcher context -----------------------------------------------------------
+
+func TestMatcherTypeAt_BlockMatcher(t *testing.T) {
+	src := "example.com {\n    @api {\n        path /api/*\n        header X-Key *\n    }\n}\n"
+	f := parseAST(src)
+	if got, ok := matcherTypeAt(f, pos(3, 10)); !ok || got != "header" {
+		t.Errorf("cursor on header matcher: want header, got %q (ok=%v)", got, ok)
+	}
+	if got, ok := matcherTypeAt(f, pos(2, 8)); !ok || got != "path" {
+		t.Errorf("cursor on path matcher: want path, got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestMatcherTypeAt_SingleLine(t *testing.T) {
+	f := parseAST("example.com {\n    @post method POST\n}\n")
+	if got, ok := matcherTypeAt(f, pos(1, 12)); !ok || got != "method" {
+		t.Errorf("want method, got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestMatcherTypeAt_Not(t *testing.T) {
+	src := "example.com {\n    @x {\n        not {\n            remote_ip 10.0.0.0/8\n        }\n        not path /admin*\n    }\n}\n"
+	f := parseAST(src)
+	if got, ok := matcherTypeAt(f, pos(3, 14)); !ok || got != "remote_ip" {
+		t.Errorf("nested not block: want remote_ip, got %q (ok=%v)", got, ok)
+	}
+	if got, ok := matcherTypeAt(f, pos(5, 13)); !ok || got != "path" {
+		t.Errorf("single-line not: want path, got %q (ok=%v)", got, ok)
+	}
+	if got, ok := matcherTypeAt(f, pos(2, 9)); !ok || got != "not" {
+		t.Errorf("not itself: want not, got %q (ok=%v)", got, ok)
+	}
+}
+
+func TestMatcherTypeAt_HeaderDirectiveIsNotMatcher(t *testing.T) {
+	f := parseAST("example.com {\n    header X-A 1\n}\n")
+	if _, ok := matcherTypeAt(f, pos(1, 6)); ok {
+		t.Error("header directive outside a matcher: want no matcher context")
+	}
+}
+
+func TestMatcherDocs_CommonMatchersPresent(t *testing.T) {
+	for _, name := range []string{
+		"path", "header", "method", "expression", "not",
+		"remote_ip", "client_ip", "query", "file",
+	} {
+		if matcherDocs[name] == "" {
+			t.Errorf("matcher docs missing entry for %q", name)
+		}
+	}
+	if matcherDocs["header"] == directiveDocs["header"] {
+		t.Error("header matcher docs must differ from header directive docs")
+	}
+}
+
+func TestLookupDirectiveDoc_PHPFastCGINote(t *testing.T) {
+	doc, ok := (builtinDocs{}).Directive("php_fastcgi")
+	if !ok || !strings.HasPrefix(doc, "**Shorthand:**") || !strings.Contains(doc, "transport fastcgi") {
+		t.Errorf("php_fastcgi docs should start with the expansion note, got %q", doc)
+	}
+}
+
+func TestHover_ErrPlaceholderInsideHandleErrors(t *testing.T) {
+	src := "example.com {\n\thandle_errors {\n\t\trespond \"{err.message}\"\n\t}\n\trespond \"{err.message}\"\n}\n"
+	store := document.New()
+	store.Open("file:///Caddyfile", src, 1)
+	h := New(store)
+	hover := func(p protocol.Position) *protocol.Hover {
+		params := &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     p,
+		}}
+		got, err := h.Hover(nil, params)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return got
+	}
+	got := hover(pos(2, 15))
+	if got == nil || !strings.Contains(got.Contents.(protocol.MarkupContent).Value, "error message") {
+		t.Errorf("inside handle_errors: want err.message docs, got %+v", got)
+	}
+	if got := hover(pos(4, 14)); got != nil {
+		t.Errorf("outside handle_errors: want no hover, got %+v", got)
+	}
+}
+
+func TestHover_InterceptPlaceholders(t *testing.T) {
+	src := "example.com {\n\tintercept {\n\t\t@accel header X-Accel-Redirect *\n\t\thandle_response @accel {\n\t\t\trewrite {http.intercept.header.X-Accel-Redirect}\n\t\t\trespond {http.intercept.status_code}\n\t\t}\n\t}\n}\n"
+	store := document.New()
+	store.Open("file:///Caddyfile", src, 1)
+	h := New(store)
+	hover := func(p protocol.Position) string {
+		got, err := h.Hover(nil, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     p,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil {
+			return ""
+		}
+		return got.Contents.(protocol.MarkupContent).Value
+	}
+	if got := hover(pos(4, 20)); !strings.Contains(got, "`{http.intercept.header.*}`") || !strings.Contains(got, "intercepted response") {
+		t.Errorf("header placeholder: got %q", got)
+	}
+	if got := hover(pos(5, 20)); !strings.Contains(got, "status code of the intercepted response") {
+		t.Errorf("status_code placeholder: got %q", got)
+	}
+}
+
+func TestHover_GlobalLog(t *testing.T) {
+	src := "{\n\tlog {\n\t\tinclude http.log.access\n\t\tlevel DEBUG\n\t}\n}\n\nexample.com {\n\tlog {\n\t\toutput stdout\n\t}\n}\n"
+	store := document.New()
+	store.Open("file:///Caddyfile", src, 1)
+	h := New(store)
+	hover := func(p protocol.Position) string {
+		got, err := h.Hover(nil, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     p,
+		}})
+		if err != nil || got == nil {
+			t.Fatalf("hover at %v: %v, %v", p, got, err)
+		}
+		return got.Contents.(protocol.MarkupContent).Value
+	}
+	if got := hover(pos(1, 2)); !strings.Contains(got, "runtime logs") || !strings.Contains(got, "include") {
+		t.Errorf("global log: got %q", got)
+	}
+	if got := hover(pos(2, 3)); !strings.Contains(got, "logger namespaces") {
+		t.Errorf("global log include: got %q", got)
+	}
+	if got := hover(pos(8, 2)); !strings.HasPrefix(got, "**Access log:**") || !strings.Contains(got, "hostnames") {
+		t.Errorf("site log: got %q", got)
+	}
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"path/filepath"
+)
+
+// importedSnippet is a snippet defined in a file pulled in by a top-level
+// `import <path>` line.
+type importedSnippet struct {
+	Name string
+	Path string // the defining file
+}
+
+// importedSnippets follows the file imports of f, the document at uri, and
+// returns the snippets the imported files define, in import order. Imports
+// are followed transitively; each file is visited once.
+func (h *Handler) importedSnippets(uri string, f *parser.File) []importedSnippet {
+	path, ok := workspace.URIToPath(uri)
+	if !ok {
+		return nil
+	}
+	visited := map[string]bool{path: true}
+	var snippets []importedSnippet
+	var visit func(from string, f *parser.File)
+	visit = func(from string, f *parser.File) {
+		for _, d := range f.Imports {
+			if len(d.Args) == 0 || !analysis.IsFileImport(d.Args[0].Token.Value) {
+				continue
+			}
+			for _, p := range workspace.ResolveImport(from, d.Args[0].Token.Value) {
+				if visited[p] {
+					continue
+				}
+				visited[p] = true
+				imported, ok := h.index.Load(p)
+				if !ok {
+					continue
+				}
+				for _, name := range analysis.CollectSnippetNames(imported) {
+					snippets = append(snippets, importedSnippet{Name: name, Path: p})
+				}
+				visit(p, imported)
+			}
+		}
+	}
+	visit(path, f)
+	return snippets
+}
+
+// relativeTo returns path relative to the directory of the document at uri
+// when possible, for display.
+func relativeTo(uri, path string) string {
+	from, ok := workspace.URIToPath(uri)
+	if !ok {
+		return path
+	}
+	if rel, err := filepath.Rel(filepath.Dir(from), path); err == nil {
+		return rel
+	}
+	return path
+}
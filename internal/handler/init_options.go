@@ -0,0 +1,125 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+)
+
+// InitOptions is the initializationOptions of the initialize request, for
+// clients that cannot send workspace/didChangeConfiguration. Like settings,
+// it may be given bare or under the "caddy" section.
+type InitOptions struct {
+	// Settings are used until the client sends settings of its own, which
+	// replace them.
+	Settings
+	// Features switches whole features off. They cannot be changed later
+	// because they decide which capabilities the server advertises.
+	Features FeatureSettings `json:"features"`
+	// SchemaPath is a JSON file of schema overrides in the form of the
+	// schema setting, applied below that setting. Relative paths are
+	// resolved against the first workspace root.
+	SchemaPath string `json:"schemaPath"`
+	// CaddyBinary is the caddy executable used when validate.binary is not
+	// set.
+	CaddyBinary string `json:"caddyBinary"`
+	// CaddyVersion is the Caddy version the Caddyfiles are written for,
+	// e.g. "2.8" or "v2.8.4".
+	CaddyVersion string `json:"caddyVersion"`
+}
+
+// FeatureSettings toggles features. A missing toggle means enabled.
+type FeatureSettings struct {
+	// Validate publishes the built-in diagnostics.
+	Validate   *bool `json:"validate"`
+	Completion *bool `json:"completion"`
+	Hover      *bool `json:"hover"`
+	Formatting *bool `json:"formatting"`
+}
+
+func (f FeatureSettings) validate() bool   { return enabled(f.Validate) }
+func (f FeatureSettings) completion() bool { return enabled(f.Completion) }
+func (f FeatureSettings) hover() bool      { return enabled(f.Hover) }
+func (f FeatureSettings) formatting() bool { return enabled(f.Formatting) }
+
+func enabled(toggle *bool) bool { return toggle == nil || *toggle }
+
+// caddyVersionPattern matches the accepted forms of InitOptions.CaddyVersion.
+var caddyVersionPattern = regexp.MustCompile(`^v?2\.\d+(\.\d+)?$`)
+
+// decodeInitOptions extracts InitOptions from the raw initializationOptions.
+// A missing value yields the zero InitOptions.
+func decodeInitOptions(raw any) (InitOptions, error) {
+	var o InitOptions
+	if raw == nil {
+		return o, nil
+	}
+	err := decodeSection(raw, &o)
+	return o, err
+}
+
+// applyInitOptions stores o and applies its settings. The schema file and
+// Caddy version are checked here; mistakes are logged and the offending
+// option ignored. It runs after the workspace roots are known.
+func (h *Handler) applyInitOptions(o InitOptions) {
+	if o.CaddyVersion != "" && !caddyVersionPattern.MatchString(o.CaddyVersion) {
+		log.Warningf("ignoring initialization option caddyVersion: %q is not a Caddy 2 version such as \"2.8\"", o.CaddyVersion)
+		o.CaddyVersion = ""
+	}
+	h.init = o
+	if err := h.loadSchemaFile(); err != nil {
+		log.Warningf("ignoring initialization option schemaPath: %v", err)
+	}
+	h.applySettings(o.Settings)
+}
+
+// resolveRootPath resolves a relative path against the first workspace root.
+func resolveRootPath(path string, roots []string) string {
+	if filepath.IsAbs(path) || len(roots) == 0 {
+		return path
+	}
+	return filepath.Join(roots[0], path)
+}
+
+// loadSchemaFile reads the schema file named by the schemaPath option, if
+// any. On failure the previous layer, if any, stays in place.
+func (h *Handler) loadSchemaFile() error {
+	if h.init.SchemaPath == "" {
+		h.schemaFile = nil
+		return nil
+	}
+	layer, err := ReadSchemaFile(resolveRootPath(h.init.SchemaPath, h.roots))
+	if err != nil {
+		return err
+	}
+	h.schemaFile = layer
+	return nil
+}
+
+// ReadSchemaFile reads a schema file, a JSON object in the form of the
+// schema setting, as a user schema layer.
+func ReadSchemaFile(path string) (*analysis.SchemaLayer, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var s SchemaSettings
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	layer := s.layer()
+	return &layer, nil
+}
+
+// caddyBinary returns the caddy executable to run: the validate.binary
+// setting, else the caddyBinary initialization option, else "" for caddy
+// from PATH.
+func (h *Handler) caddyBinary() string {
+	if h.settings.Validate.Binary != "" {
+		return h.settings.Validate.Binary
+	}
+	return h.init.CaddyBinary
+}
@@ -1,12 +1,28 @@
 package handler
 
 import (
+	"github.com/tliron/commonlog"
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 // Initialize handles the LSP initialize request and returns server capabilities.
 func (h *Handler) Initialize(ctx *glsp.Context, params *protocol.InitializeParams) (any, error) {
+	h.baseLogLevel = commonlog.GetMaxLevel()
+	if params.Trace != nil {
+		h.applyTrace(*params.Trace)
+	}
+	h.roots = workspaceRoots(params)
+	h.client = newClientSupport(params.Capabilities)
+	if w := params.Capabilities.Window; w != nil && w.WorkDoneProgress != nil {
+		h.workDoneProgress = *w.WorkDoneProgress
+	}
+	opts, err := decodeInitOptions(params.InitializationOptions)
+	if err != nil {
+		log.Warningf("ignoring invalid initialization options: %v", err)
+	}
+	h.applyInitOptions(opts)
+
 	return protocol.InitializeResult{
 		Capabilities: h.CreateServerCapabilities(),
 		ServerInfo: &protocol.InitializeResultServerInfo{
@@ -17,7 +33,11 @@ func (h *Handler) Initialize(ctx *glsp.Context, params *protocol.InitializeParam
 }
 
 // Initialized is called after the client acknowledges initialize.
+// Workspace indexing and the registration of file watchers start here, in
+// the background, because they need to call back into the client.
 func (h *Handler) Initialized(ctx *glsp.Context, params *protocol.InitializedParams) error {
+	h.registerFileWatchers(ctx)
+	h.scanWorkspace(ctx, h.settings.filePatterns(), false)
 	return nil
 }
 
@@ -26,17 +46,38 @@ func (h *Handler) Shutdown(ctx *glsp.Context) error {
 	return nil
 }
 
-// SetTrace updates the trace level (no-op for now).
+// SetTrace handles $/setTrace by adjusting log verbosity at runtime.
 func (h *Handler) SetTrace(ctx *glsp.Context, params *protocol.SetTraceParams) error {
+	h.applyTrace(params.Value)
 	return nil
 }
 
+// applyTrace sets the log level for an LSP trace value and records the value
+// for glsp.
+func (h *Handler) applyTrace(value protocol.TraceValue) {
+	protocol.SetTraceValue(value)
+	commonlog.SetMaxLevel(traceLogLevel(h.baseLogLevel, value))
+}
+
+// traceLogLevel maps an LSP trace value onto a log level: "messages" enables
+// info logs and "verbose" debug logs, while "off" restores base, the level
+// chosen with -log-level. Tracing never lowers verbosity below base.
+func traceLogLevel(base commonlog.Level, value protocol.TraceValue) commonlog.Level {
+	switch value {
+	case protocol.TraceValueMessage, "messages":
+		return max(base, commonlog.Info)
+	case protocol.TraceValueVerbose:
+		return commonlog.Debug
+	}
+	return base
+}
+
 // CreateServerCapabilities returns the capabilities advertised to the client.
 func (h *Handler) CreateServerCapabilities() protocol.ServerCapabilities {
 	syncKind := protocol.TextDocumentSyncKindFull
-	triggerChars := []string{"."}
+	triggerChars := []string{".", "$", "@", "{"}
 
-	return protocol.ServerCapabilities{
+	caps := protocol.ServerCapabilities{
 		TextDocumentSync: &protocol.TextDocumentSyncOptions{
 			OpenClose: boolPtr(true),
 			Change:    &syncKind,
@@ -46,7 +87,44 @@ func (h *Handler) CreateServerCapabilities() protocol.ServerCapabilities {
 		CompletionProvider: &protocol.CompletionOptions{
 			TriggerCharacters: triggerChars,
 		},
+		SignatureHelpProvider: &protocol.SignatureHelpOptions{
+			TriggerCharacters: []string{" "},
+		},
+		CodeActionProvider: &protocol.CodeActionOptions{
+			CodeActionKinds: []protocol.CodeActionKind{protocol.CodeActionKindQuickFix, protocol.CodeActionKindRefactorRewrite},
+		},
+		DocumentHighlightProvider: true,
+		SemanticTokensProvider: &protocol.SemanticTokensOptions{
+			Legend: semanticLegend,
+			Full:   true,
+		},
+		ExecuteCommandProvider: &protocol.ExecuteCommandOptions{
+			Commands: commandNames(),
+		},
+		DocumentFormattingProvider: true,
+	}
+	if !h.init.Features.hover() || h.client.noHover {
+		caps.HoverProvider = false
+	}
+	if !h.init.Features.completion() || h.client.noCompletion {
+		caps.CompletionProvider = nil
+	}
+	if !h.init.Features.formatting() {
+		caps.DocumentFormattingProvider = false
+	}
+	if h.client.noSignatureHelp {
+		caps.SignatureHelpProvider = nil
+	}
+	if h.client.noCodeActions {
+		caps.CodeActionProvider = nil
+	}
+	if h.client.noDocumentHighlight {
+		caps.DocumentHighlightProvider = nil
+	}
+	if h.client.noSemanticTokens {
+		caps.SemanticTokensProvider = nil
 	}
+	return caps
 }
 
 func boolPtr(b bool) *bool { return &b }
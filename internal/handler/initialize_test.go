@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/tliron/commonlog"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestTraceLogLevel(t *testing.T) {
+	cases := []struct {
+		base  commonlog.Level
+		value protocol.TraceValue
+		want  commonlog.Level
+	}{
+		{commonlog.Warning, protocol.TraceValueOff, commonlog.Warning},
+		{commonlog.Warning, protocol.TraceValueMessage, commonlog.Info},
+		{commonlog.Warning, "messages", commonlog.Info},
+		{commonlog.Warning, protocol.TraceValueVerbose, commonlog.Debug},
+		// Tracing never lowers verbosity below the command-line level.
+		{commonlog.Debug, protocol.TraceValueMessage, commonlog.Debug},
+		{commonlog.Debug, protocol.TraceValueOff, commonlog.Debug},
+	}
+	for _, c := range cases {
+		if got := traceLogLevel(c.base, c.value); got != c.want {
+			t.Errorf("traceLogLevel(%v, %q) = %v, want %v", c.base, c.value, got, c.want)
+		}
+	}
+}
+
+func TestWorkspaceRoots_PrefersFolders(t *testing.T) {
+	root := "file:///srv/root"
+	params := &protocol.InitializeParams{
+		RootURI:          &root,
+		WorkspaceFolders: []protocol.WorkspaceFolder{{URI: "file:///srv/a"}, {URI: "file:///srv/b"}},
+	}
+	got := workspaceRoots(params)
+	if len(got) != 2 || got[0] != "/srv/a" || got[1] != "/srv/b" {
+		t.Errorf("want workspace folder paths, got %v", got)
+	}
+	params.WorkspaceFolders = nil
+	if got := workspaceRoots(params); len(got) != 1 || got[0] != "/srv/root" {
+		t.Errorf("want root URI path, got %v", got)
+	}
+}
+
+func TestInitialize_InitializationOptions(t *testing.T) {
+	root := t.TempDir()
+	if err := os.WriteFile(filepath.Join(root, "schema.json"), []byte(`{"directives": ["rate_limit"]}`), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	rootURI := "file://" + filepath.ToSlash(root)
+	h := New(document.New())
+	res, err := h.Initialize(nil, &protocol.InitializeParams{
+		RootURI: &rootURI,
+		InitializationOptions: map[string]any{"caddy": map[string]any{
+			"features":       map[string]any{"hover": false, "completion": false},
+			"schemaPath":     "schema.json",
+			"caddyBinary":    "/opt/caddy/bin/caddy",
+			"caddyVersion":   "latest",
+			"maxDiagnostics": 5,
+		}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	caps := res.(protocol.InitializeResult).Capabilities
+	if caps.HoverProvider != false || caps.CompletionProvider != nil {
+		t.Errorf("want hover and completion not advertised, got %v and %+v", caps.HoverProvider, caps.CompletionProvider)
+	}
+	if !h.currentSchema().IsDirective("rate_limit") {
+		t.Error("want rate_limit declared by the schema file")
+	}
+	if got := h.caddyBinary(); got != "/opt/caddy/bin/caddy" {
+		t.Errorf("caddyBinary() = %q", got)
+	}
+	if h.init.CaddyVersion != "" {
+		t.Errorf("want malformed caddyVersion dropped, got %q", h.init.CaddyVersion)
+	}
+	if h.settings.MaxDiagnostics != 5 {
+		t.Errorf("want the embedded settings applied, got %+v", h.settings)
+	}
+
+	// Settings sent later replace the embedded ones but keep the schema
+	// file and binary.
+	if err := h.DidChangeConfiguration(nil, &protocol.DidChangeConfigurationParams{Settings: map[string]any{}}); err != nil {
+		t.Fatal(err)
+	}
+	if !h.currentSchema().IsDirective("rate_limit") || h.caddyBinary() != "/opt/caddy/bin/caddy" || h.settings.MaxDiagnostics != 0 {
+		t.Errorf("after didChangeConfiguration: settings %+v, binary %q", h.settings, h.caddyBinary())
+	}
+}
+
+func TestInitialize_ValidateFeatureOff(t *testing.T) {
+	const uri = "file:///Caddyfile"
+	var p recordingPublisher
+	h := NewWithPublisher(document.New(), &p)
+	if _, err := h.Initialize(nil, &protocol.InitializeParams{
+		InitializationOptions: map[string]any{"features": map[string]any{"validate": false}},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if err := h.DidOpen(nil, &protocol.DidOpenTextDocumentParams{
+		TextDocument: protocol.TextDocumentItem{URI: uri, Text: "a.com {\n\tbogus\n}\n", Version: 1},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(p.published) != 1 || len(p.published[0].diags) != 0 {
+		t.Errorf("want one empty publication, got %+v", p.published)
+	}
+}
@@ -0,0 +1,40 @@
+package handler
+
+// matcherDocs documents the request matcher types that may appear inside a
+// named matcher definition (`@name { ... }` or `@name <type> ...`). Several of
+// them share a name with a directive or subdirective (header, file, vars), so
+// they are kept apart from directiveDocs and only used when the cursor is in
+// a matcher context.
+var matcherDocs = map[string]string{
+	"client_ip": "client_ip matches requests by the client IP address.\n\n```\nclient_ip <ranges...>\n```\n\nRanges are IP addresses or CIDR ranges. When trusted proxies are configured, the client IP is taken from the forwarded headers; otherwise it is the remote IP.",
+
+	"expression": "expression matches requests using a CEL (Common Expression Language) expression.\n\n```\nexpression <cel...>\n```\n\nThe expression must evaluate to a boolean. Placeholders like `{path}` may be used, and other matchers are available as functions, e.g. `path('/api/*')`.",
+
+	"file": "file matches requests by the existence of files on disk.\n\n```\nfile {\n    root       <path>\n    try_files  <files...>\n    try_policy first_exist|smallest_size|largest_size|most_recently_modified\n    split_path <delims...>\n}\nfile <files...>\n```\n\nOn match, the `{file_match.*}` placeholders describe the file that was found.",
+
+	"header": "header matches requests by request header fields.\n\n```\nheader <field> [<value>]\n```\n\nValues may start or end with `*` for prefix/suffix matching. A field prefixed with `!` matches when the header is absent. Multiple header matchers are AND-ed; multiple values for one field are OR-ed.",
+
+	"header_regexp": "header_regexp matches requests by a request header field against a regular expression.\n\n```\nheader_regexp [<name>] <field> <regexp>\n```\n\nCapture groups are available as `{re.<name>.<group>}` placeholders.",
+
+	"host": "host matches requests by the Host header.\n\n```\nhost <hosts...>\n```\n\nWildcards are allowed in the left-most label, e.g. `*.example.com`.",
+
+	"method": "method matches requests by the HTTP method.\n\n```\nmethod <verbs...>\n```\n\nVerbs are case-sensitive and should be uppercase, e.g. `GET POST`.",
+
+	"not": "not negates the matcher sets it encloses.\n\n```\nnot <matcher>\nnot {\n    <matchers...>\n}\n```\n\nMatchers inside one `not` block are AND-ed before being negated; use multiple `not` lines to negate each separately.",
+
+	"path": "path matches requests by the request path.\n\n```\npath <paths...>\n```\n\nPaths are case-insensitive and support `*` wildcards at the start, end or middle. Matching is exact otherwise: `/foo` does not match `/foo/`.",
+
+	"path_regexp": "path_regexp matches requests by the request path against a regular expression.\n\n```\npath_regexp [<name>] <regexp>\n```\n\nCapture groups are available as `{re.<name>.<group>}` placeholders.",
+
+	"protocol": "protocol matches requests by the protocol.\n\n```\nprotocol http|https|grpc|http/<version>[+]\n```\n\nA trailing `+` matches that HTTP version or newer, e.g. `http/2+`.",
+
+	"query": "query matches requests by query string parameters.\n\n```\nquery <key>=<val>...\n```\n\nValues may be `*` to match any value. Multiple parameters are AND-ed; repeated keys are OR-ed.",
+
+	"remote_ip": "remote_ip matches requests by the immediate peer IP address.\n\n```\nremote_ip <ranges...>\n```\n\nRanges are IP addresses or CIDR ranges. Forwarded headers are ignored; use `client_ip` to match the original client behind trusted proxies.",
+
+	"tls": "tls matches requests made over TLS.\n\n```\ntls [early_data]\n```\n\nWith `early_data`, only requests sent as TLS 1.3 early data (0-RTT), before the handshake completes, match.",
+
+	"vars": "vars matches requests by the value of a variable or placeholder.\n\n```\nvars <variable> <values...>\n```\n\nThe variable may be a name set with the `vars` directive or a placeholder such as `{http.request.uri}`.",
+
+	"vars_regexp": "vars_regexp matches a variable or placeholder against a regular expression.\n\n```\nvars_regexp [<name>] <variable> <regexp>\n```\n\nCapture groups are available as `{re.<name>.<group>}` placeholders.",
+}
@@ -0,0 +1,164 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// placeholderDoc documents one runtime placeholder, named without braces. A
+// name ending in "." covers every placeholder under it, such as a header
+// field.
+type placeholderDoc struct {
+	Name string
+	Doc  string
+}
+
+// scopedPlaceholders lists placeholders that are only set inside the body of
+// a particular directive, keyed by that directive.
+var scopedPlaceholders = map[string][]placeholderDoc{
+	"handle_errors": {
+		{"err.status_code", "The recommended HTTP status code for the error."},
+		{"err.status_text", "The status text matching `{err.status_code}`."},
+		{"err.message", "The error message."},
+		{"err.trace", "The origin of the error, as file and line in Caddy's source."},
+		{"err.id", "An identifier for this occurrence of the error, also logged with it."},
+	},
+	// Source: modules/caddyhttp/intercept/intercept.go
+	"intercept": {
+		{"http.intercept.status_code", "The status code of the intercepted response."},
+		{"http.intercept.header.", "A header field of the intercepted response, e.g. `{http.intercept.header.X-Accel-Redirect}`."},
+	},
+}
+
+// matches reports whether p documents the placeholder name.
+func (p placeholderDoc) matches(name string) bool {
+	if strings.HasSuffix(p.Name, ".") {
+		return strings.HasPrefix(name, p.Name) && len(name) > len(p.Name)
+	}
+	return name == p.Name
+}
+
+// label returns the name of p as shown to the user, with "*" standing for
+// the rest of a name that covers several placeholders.
+func (p placeholderDoc) label() string {
+	if strings.HasSuffix(p.Name, ".") {
+		return p.Name + "*"
+	}
+	return p.Name
+}
+
+// scopedPlaceholdersAt returns the directive-scoped placeholders available
+// at pos, innermost directive last.
+func scopedPlaceholdersAt(f *parser.File, pos protocol.Position) []placeholderDoc {
+	var docs []placeholderDoc
+	for _, d := range enclosingDirectives(f, pos) {
+		docs = append(docs, scopedPlaceholders[d.Name.Value]...)
+	}
+	return docs
+}
+
+// enclosingDirectives returns the directives whose bodies contain pos, from
+// the outermost inwards.
+func enclosingDirectives(f *parser.File, pos protocol.Position) []*parser.Directive {
+	var chain []*parser.Directive
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			if d.BodyContains(pos) {
+				chain = append(chain, d)
+				walk(d.Body)
+				return
+			}
+		}
+	}
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			walk(sb.Directives)
+			break
+		}
+	}
+	return chain
+}
+
+// placeholderAt returns the name of the "{...}" placeholder under pos, if
+// any. Environment placeholders are left to envRefAt.
+func placeholderAt(content string, pos protocol.Position) (string, bool) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return "", false
+	}
+	open := strings.LastIndexByte(line[:min(col+1, len(line))], '{')
+	if open < 0 {
+		return "", false
+	}
+	end := strings.IndexByte(line[open:], '}')
+	if end < 0 || open+end < col-1 {
+		return "", false
+	}
+	name := line[open+1 : open+end]
+	if name == "" || strings.HasPrefix(name, "$") || strings.ContainsAny(name, "{ \t") {
+		return "", false
+	}
+	return name, true
+}
+
+// placeholderPrefix reports whether the cursor follows an unterminated "{"
+// that opens a runtime placeholder on its line, and returns the text typed
+// since it and the position just after the brace. "{$" environment
+// placeholders are left to envNamePrefix.
+func placeholderPrefix(content string, pos protocol.Position) (string, protocol.Position, bool) {
+	line, col, ok := lineAt(content, pos)
+	if !ok {
+		return "", protocol.Position{}, false
+	}
+	before := line[:col]
+	i := strings.LastIndexByte(before, '{')
+	if i < 0 || (i > 0 && before[i-1] == '\\') {
+		return "", protocol.Position{}, false
+	}
+	partial := before[i+1:]
+	if strings.HasPrefix(partial, "$") || strings.ContainsAny(partial, "{} \t\"`") {
+		return "", protocol.Position{}, false
+	}
+	// A lone "{" at the end of a line opens a block, not a placeholder.
+	if partial == "" && (i == 0 || before[i-1] == ' ' || before[i-1] == '\t') && strings.TrimSpace(line[col:]) == "" {
+		return "", protocol.Position{}, false
+	}
+	return partial, protocol.Position{Line: pos.Line, Character: parser.UTF16Len(line[:i+1])}, true
+}
+
+// placeholderCompletions returns completion items for the placeholders that
+// start with partial: {vars.<name>} for every variable set by a `vars`
+// directive in f, and those scoped to the directives enclosing pos, such as
+// {err.*} inside handle_errors. Items replace the text from start to pos and
+// add the closing brace unless it is already present, or the name still
+// needs its last part, as for a header field.
+func placeholderCompletions(f *parser.File, partial string, start, pos protocol.Position, closed bool) []protocol.CompletionItem {
+	var docs []placeholderDoc
+	for _, name := range analysis.VarNames(f) {
+		docs = append(docs, placeholderDoc{Name: "vars." + name, Doc: "Set by vars."})
+	}
+	docs = append(docs, scopedPlaceholdersAt(f, pos)...)
+
+	kind := protocol.CompletionItemKindVariable
+	items := []protocol.CompletionItem{}
+	for _, p := range docs {
+		if !strings.HasPrefix(p.Name, partial) {
+			continue
+		}
+		insert := p.Name
+		if !closed && !strings.HasSuffix(p.Name, ".") {
+			insert += "}"
+		}
+		items = append(items, protocol.CompletionItem{
+			Label:         p.Name,
+			Kind:          &kind,
+			Documentation: p.Doc,
+			TextEdit:      protocol.TextEdit{Range: protocol.Range{Start: start, End: pos}, NewText: insert},
+		})
+	}
+	return items
+}
@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"maps"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestPlaceholderPrefix(t *testing.T) {
+	cases := []struct {
+		line    string
+		partial string
+		start   uint32
+		ok      bool
+	}{
+		{"\trespond {vars.", "vars.", 10, true},
+		{"\trewrite /x/{", "", 13, true},
+		{"\trespond \"{va\"", "va", 10, false}, // cursor after the closing quote
+		{"\treverse_proxy {", "", 0, false},    // block
+		{"\trespond {$HO", "", 0, false},       // env placeholder
+		{"\trespond {path} x", "", 0, false},
+		{"\trespond \\{x", "", 0, false},
+	}
+	for _, c := range cases {
+		partial, start, ok := placeholderPrefix(c.line, protocol.Position{Character: uint32(len(c.line))})
+		if ok != c.ok || (ok && (partial != c.partial || start.Character != c.start)) {
+			t.Errorf("%q: got (%q, %d, %v), want (%q, %d, %v)", c.line, partial, start.Character, ok, c.partial, c.start, c.ok)
+		}
+	}
+}
+
+func TestPlaceholderCompletions_Vars(t *testing.T) {
+	src := "example.com {\n\tvars region eu\n\tvars {\n\t\tdebug on\n\t}\n\trespond {vars.d\n}\n"
+	f := parseAST(src)
+	start := protocol.Position{Line: 5, Character: 10}
+	pos := protocol.Position{Line: 5, Character: 16}
+
+	items := placeholderCompletions(f, "vars.d", start, pos, false)
+	if len(items) != 1 || items[0].Label != "vars.debug" {
+		t.Fatalf("got %v, want only vars.debug", items)
+	}
+	edit, ok := items[0].TextEdit.(protocol.TextEdit)
+	if !ok || edit.NewText != "vars.debug}" || edit.Range.Start != start || edit.Range.End != pos {
+		t.Errorf("text edit = %+v", items[0].TextEdit)
+	}
+	if items := placeholderCompletions(f, "", start, start, true); len(items) != 2 {
+		t.Errorf("empty prefix: want both variables, got %v", items)
+	}
+	closed := placeholderCompletions(f, "vars.r", start, pos, true)
+	if edit := closed[0].TextEdit.(protocol.TextEdit); edit.NewText != "vars.region" {
+		t.Errorf("closed placeholder: got %q, want no extra brace", edit.NewText)
+	}
+}
+
+func TestPlaceholderCompletions_HandleErrorsScope(t *testing.T) {
+	src := "example.com {\n\thandle_errors 5xx {\n\t\trespond \"{err.\"\n\t}\n\trespond \"{err.\"\n}\n"
+	f := parseAST(src)
+	inside := protocol.Position{Line: 2, Character: 16}
+	items := placeholderCompletions(f, "err.", protocol.Position{Line: 2, Character: 12}, inside, false)
+	if len(items) != len(scopedPlaceholders["handle_errors"]) {
+		t.Errorf("inside handle_errors: got %d items, want all err placeholders", len(items))
+	}
+	outside := protocol.Position{Line: 4, Character: 15}
+	if items := placeholderCompletions(f, "err.", protocol.Position{Line: 4, Character: 11}, outside, false); len(items) != 0 {
+		t.Errorf("outside handle_errors: want no err placeholders, got %v", items)
+	}
+}
+
+func TestPlaceholderCompletions_InterceptScope(t *testing.T) {
+	src := "example.com {\n\tintercept {\n\t\thandle_response {\n\t\t\trespond {http.intercept.\n\t\t}\n\t}\n}\n"
+	f := parseAST(src)
+	start, at := protocol.Position{Line: 3, Character: 12}, protocol.Position{Line: 3, Character: 27}
+	got := make(map[string]string)
+	for _, item := range placeholderCompletions(f, "http.intercept.", start, at, false) {
+		got[item.Label] = item.TextEdit.(protocol.TextEdit).NewText
+	}
+	want := map[string]string{
+		"http.intercept.status_code": "http.intercept.status_code}",
+		"http.intercept.header.":     "http.intercept.header.", // the field is still to type
+	}
+	if !maps.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestPlaceholderAt(t *testing.T) {
+	line := "\trespond \"{err.status_code} {$HOME}\""
+	for col, want := range map[uint32]string{10: "err.status_code", 15: "err.status_code", 26: "err.status_code", 8: "", 30: ""} {
+		got, _ := placeholderAt(line, protocol.Position{Character: col})
+		if got != want {
+			t.Errorf("col %d: got %q, want %q", col, got, want)
+		}
+	}
+}
+
+func TestScopedPlaceholdersAt_Nested(t *testing.T) {
+	src := "example.com {\n\thandle_errors {\n\t\thandle /api/* {\n\t\t\trespond {err.message}\n\t\t}\n\t}\n}\n"
+	f := parseAST(src)
+	docs := scopedPlaceholdersAt(f, protocol.Position{Line: 3, Character: 14})
+	if len(docs) == 0 || docs[0].Name != "err.status_code" {
+		t.Errorf("handle inside handle_errors should still see err placeholders, got %v", docs)
+	}
+}
@@ -0,0 +1,20 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// lineAt returns line pos.Line of content and the byte offset in it of
+// pos.Character, which clients count in UTF-16 code units. ok is false
+// when content has no such line.
+func lineAt(content string, pos protocol.Position) (line string, col int, ok bool) {
+	lines := strings.Split(content, "\n")
+	if int(pos.Line) >= len(lines) {
+		return "", 0, false
+	}
+	line = lines[pos.Line]
+	return line, parser.ByteOffset(line, pos.Character), true
+}
@@ -0,0 +1,37 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// TestPositions_NonASCIILine checks the lookups at a client position on a
+// line with characters outside ASCII, whose columns count UTF-16 code units
+// rather than bytes.
+func TestPositions_NonASCIILine(t *testing.T) {
+	line := "\trespond \"📚 Bücher\" {http.re} {$HO}"
+	content := "bücher.example {\n" + line + "\n}\n"
+	at := func(s string) protocol.Position {
+		return protocol.Position{Line: 1, Character: parser.UTF16Len(s)}
+	}
+
+	pos := at("\trespond \"📚 Bücher\" {http.re")
+	partial, start, ok := placeholderPrefix(content, pos)
+	if !ok || partial != "http.re" || start != at("\trespond \"📚 Bücher\" {") {
+		t.Errorf("placeholderPrefix = %q, %+v, %v", partial, start, ok)
+	}
+	if !nextCharIs(content, pos, '}') {
+		t.Error("nextCharIs: want the closing brace after the cursor")
+	}
+	if name, ok := envNamePrefix(content, at("\trespond \"📚 Bücher\" {http.re} {$HO")); !ok || name != "HO" {
+		t.Errorf("envNamePrefix = %q, %v", name, ok)
+	}
+	if ref, ok := envRefAt(content, at("\trespond \"📚 Bücher\" {http.re} {$H")); !ok || ref.Name != "HO" {
+		t.Errorf("envRefAt = %+v, %v", ref, ok)
+	}
+	if word := wordAtPosition(content, at("\trespond \"📚 Bü")); word != "Bücher" {
+		t.Errorf("wordAtPosition = %q, want Bücher", word)
+	}
+}
@@ -0,0 +1,56 @@
+package handler
+
+import (
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// progress reports window/workDoneProgress for a single server-initiated
+// operation. A zero progress (client without workDoneProgress support) turns
+// every method into a no-op.
+type progress struct {
+	notify glsp.NotifyFunc
+	token  protocol.ProgressToken
+}
+
+// newProgress asks the client to create a progress token. It must not be
+// called from inside a request handler: the client's response can only be
+// read once the handler has returned.
+func (h *Handler) newProgress(ctx *glsp.Context, token string) *progress {
+	if !h.workDoneProgress {
+		return &progress{}
+	}
+	t := protocol.ProgressToken{Value: token}
+	ctx.Call(protocol.ServerWindowWorkDoneProgressCreate, protocol.WorkDoneProgressCreateParams{Token: t}, nil)
+	return &progress{notify: ctx.Notify, token: t}
+}
+
+func (p *progress) begin(title string, cancellable bool) {
+	if p.notify == nil {
+		return
+	}
+	p.notify(protocol.MethodProgress, protocol.ProgressParams{
+		Token: p.token,
+		Value: protocol.WorkDoneProgressBegin{Kind: "begin", Title: title, Cancellable: boolPtr(cancellable)},
+	})
+}
+
+func (p *progress) report(message string, percentage uint32) {
+	if p.notify == nil {
+		return
+	}
+	p.notify(protocol.MethodProgress, protocol.ProgressParams{
+		Token: p.token,
+		Value: protocol.WorkDoneProgressReport{Kind: "report", Message: &message, Percentage: &percentage},
+	})
+}
+
+func (p *progress) end(message string) {
+	if p.notify == nil {
+		return
+	}
+	p.notify(protocol.MethodProgress, protocol.ProgressParams{
+		Token: p.token,
+		Value: protocol.WorkDoneProgressEnd{Kind: "end", Message: &message},
+	})
+}
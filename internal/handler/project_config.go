@@ -0,0 +1,423 @@
+package handler
+
+import (
+	"bytes"
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/format"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"go.yaml.in/yaml/v2"
+)
+
+// projectConfigNames are the names of project configuration files, in the
+// order they are looked for in each directory. The first one found in a
+// directory is used.
+var projectConfigNames = []string{".caddy-ls.json", ".caddy-ls.yaml", ".caddy-ls.yml", ".caddy-ls.toml"}
+
+// ProjectConfig is a configuration file checked in next to Caddyfiles. It
+// applies to the Caddyfiles in its directory and below, on top of the
+// editor's settings. Files nearer to a Caddyfile take precedence over those
+// further up.
+type ProjectConfig struct {
+	// Root stops the search: files in parent directories are not read.
+	Root bool `json:"root"`
+	// Lint enables or disables opt-in lint rules by code.
+	Lint map[string]bool `json:"lint"`
+	// Plugins and Schema extend those of the settings.
+	Plugins PluginSettings `json:"plugins"`
+	Schema  SchemaSettings `json:"schema"`
+	// CaddyVersion overrides the caddyVersion initialization option.
+	CaddyVersion string `json:"caddyVersion"`
+	// Format overrides the fields of the format setting it sets.
+	Format FormatSettings `json:"format"`
+	// Files overrides the fields of the files setting it sets. A relative
+	// deployRoot is resolved against the directory of the file.
+	Files FileSettings `json:"files"`
+}
+
+// FormatSettings are the options of document formatting.
+type FormatSettings struct {
+	// Indent is one level of indentation. Empty means a tab.
+	Indent string `json:"indent"`
+	// DropBlankLines removes blank lines inside blocks.
+	DropBlankLines *bool `json:"dropBlankLines"`
+	// AlignComments lines up trailing comments of consecutive lines.
+	AlignComments *bool `json:"alignComments"`
+}
+
+// FileSettings configure the checks that the files a Caddyfile names, such
+// as certificates and imports, exist.
+type FileSettings struct {
+	// Check enables the checks; nil means true.
+	Check *bool `json:"check"`
+	// DeployRoot is a directory mirroring the file system of the machine
+	// the Caddyfiles are deployed to: absolute paths are looked up below
+	// it. Relative paths are resolved against the first workspace folder.
+	DeployRoot string `json:"deployRoot"`
+}
+
+// options returns s as file check options, resolving a relative deploy
+// root against roots.
+func (s FileSettings) options(roots []string) workspace.FileOptions {
+	opts := workspace.FileOptions{Skip: s.Check != nil && !*s.Check}
+	if s.DeployRoot != "" {
+		opts.DeployRoot = resolveRootPath(s.DeployRoot, roots)
+	}
+	return opts
+}
+
+// options returns s as formatter options.
+func (s FormatSettings) options() format.Options {
+	return format.Options{
+		Indent:         s.Indent,
+		DropBlankLines: s.DropBlankLines != nil && *s.DropBlankLines,
+		AlignComments:  s.AlignComments != nil && *s.AlignComments,
+	}
+}
+
+// decodeProjectConfig parses the project configuration file name, holding
+// data. The format follows the extension; YAML and TOML files use the keys
+// of the JSON form. Unknown keys are errors, so that typos are noticed.
+func decodeProjectConfig(name string, data []byte) (ProjectConfig, error) {
+	var c ProjectConfig
+	switch filepath.Ext(name) {
+	case ".yaml", ".yml":
+		var doc any
+		if err := yaml.Unmarshal(data, &doc); err != nil {
+			return c, err
+		}
+		doc, err := stringKeys(doc)
+		if err != nil {
+			return c, err
+		}
+		if data, err = json.Marshal(doc); err != nil {
+			return c, err
+		}
+	case ".toml":
+		var doc map[string]any
+		err := toml.Unmarshal(data, &doc)
+		if err == nil {
+			data, err = json.Marshal(doc)
+		}
+		if err != nil {
+			return c, err
+		}
+	}
+	if len(bytes.TrimSpace(data)) == 0 || string(bytes.TrimSpace(data)) == "null" {
+		return c, nil
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&c); err != nil {
+		return c, err
+	}
+	if c.CaddyVersion != "" && !caddyVersionPattern.MatchString(c.CaddyVersion) {
+		return c, fmt.Errorf("caddyVersion: %q is not a Caddy 2 version such as \"2.8\"", c.CaddyVersion)
+	}
+	return c, nil
+}
+
+// stringKeys converts the maps YAML decodes, keyed by any value, into maps
+// keyed by string, which JSON can encode.
+func stringKeys(v any) (any, error) {
+	switch v := v.(type) {
+	case map[any]any:
+		m := make(map[string]any, len(v))
+		for k, val := range v {
+			key, ok := k.(string)
+			if !ok {
+				return nil, fmt.Errorf("key %v is not a string", k)
+			}
+			val, err := stringKeys(val)
+			if err != nil {
+				return nil, err
+			}
+			m[key] = val
+		}
+		return m, nil
+	case []any:
+		for i, val := range v {
+			val, err := stringKeys(val)
+			if err != nil {
+				return nil, err
+			}
+			v[i] = val
+		}
+	}
+	return v, nil
+}
+
+// merge applies c on top of s: lint rules and format fields set in c
+// replace those of s, and plugin and schema declarations are added.
+func (c ProjectConfig) merge(s *docSettings) {
+	if len(c.Lint) > 0 {
+		lint := make(map[string]bool, len(s.Lint)+len(c.Lint))
+		for code, on := range s.Lint {
+			lint[code] = on
+		}
+		for code, on := range c.Lint {
+			lint[code] = on
+		}
+		s.Lint = lint
+	}
+	s.Plugins = PluginSettings{
+		Transports:   concat(s.Plugins.Transports, c.Plugins.Transports),
+		Upstreams:    concat(s.Plugins.Upstreams, c.Plugins.Upstreams),
+		Modules:      concatMaps(s.Plugins.Modules, c.Plugins.Modules),
+		Placeholders: concat(s.Plugins.Placeholders, c.Plugins.Placeholders),
+	}
+	s.Schema = SchemaSettings{
+		Directives:    concat(s.Schema.Directives, c.Schema.Directives),
+		GlobalOptions: concat(s.Schema.GlobalOptions, c.Schema.GlobalOptions),
+		SubDirectives: concatMaps(s.Schema.SubDirectives, c.Schema.SubDirectives),
+		Storage:       concat(s.Schema.Storage, c.Schema.Storage),
+		Matchers:      concat(s.Schema.Matchers, c.Schema.Matchers),
+		Disable:       concat(s.Schema.Disable, c.Schema.Disable),
+	}
+	if c.CaddyVersion != "" {
+		s.caddyVersion = c.CaddyVersion
+	}
+	if c.Format.Indent != "" {
+		s.Format.Indent = c.Format.Indent
+	}
+	if c.Format.DropBlankLines != nil {
+		s.Format.DropBlankLines = c.Format.DropBlankLines
+	}
+	if c.Format.AlignComments != nil {
+		s.Format.AlignComments = c.Format.AlignComments
+	}
+	if c.Files.Check != nil {
+		s.Files.Check = c.Files.Check
+	}
+	if c.Files.DeployRoot != "" {
+		s.Files.DeployRoot = c.Files.DeployRoot
+	}
+}
+
+// concat returns a followed by b, without sharing storage with a.
+func concat(a, b []string) []string {
+	if len(b) == 0 {
+		return a
+	}
+	return append(a[:len(a):len(a)], b...)
+}
+
+// concatMaps returns a with the lists of b appended to those under the
+// same keys, without sharing storage with a.
+func concatMaps(a, b map[string][]string) map[string][]string {
+	if len(b) == 0 {
+		return a
+	}
+	m := make(map[string][]string, len(a)+len(b))
+	for key, names := range a {
+		m[key] = names
+	}
+	for key, names := range b {
+		m[key] = concat(m[key], names)
+	}
+	return m
+}
+
+// docSettings are the settings in effect for one document: the editor's
+// settings with the project configuration files above the document merged
+// in.
+type docSettings struct {
+	Settings
+	caddyVersion string
+	schema       *analysis.Schema
+}
+
+// analysisOptions returns the analyzer options derived from s.
+func (s docSettings) analysisOptions() analysis.Options {
+	return analysis.Options{
+		Plugins: analysis.Plugins{
+			Transports:   s.Plugins.Transports,
+			Upstreams:    s.Plugins.Upstreams,
+			Modules:      s.Plugins.Modules,
+			Placeholders: s.Plugins.Placeholders,
+		},
+		Schema:       s.schema,
+		CaddyVersion: s.caddyVersion,
+	}
+}
+
+// settingsFor returns the settings in effect for the document at uri.
+// Documents that are not files, and files without a project configuration
+// above them, get the editor's settings.
+func (h *Handler) settingsFor(uri string) docSettings {
+	s := docSettings{Settings: h.settings, caddyVersion: h.init.CaddyVersion, schema: h.currentSchema()}
+	path, ok := workspace.URIToPath(uri)
+	if !ok {
+		return s
+	}
+	configs := h.projectConfigs.find(filepath.Dir(path))
+	if len(configs) == 0 {
+		return s
+	}
+	var key strings.Builder
+	for i := len(configs) - 1; i >= 0; i-- { // the nearest file goes last
+		configs[i].config.merge(&s)
+		fmt.Fprintf(&key, "%s@%d;", configs[i].path, configs[i].stamp.UnixNano())
+	}
+	s.schema = h.projectConfigs.schema(key.String(), h.schemaVersion, func() *analysis.Schema {
+		return buildSchema(s.Settings, h.schemaFile, h.modules)
+	})
+	return s
+}
+
+// projectConfigTTL is how long the lookup of the project configuration
+// file in a directory is reused before the directory is looked at again.
+// Changes the client reports through workspace/didChangeWatchedFiles apply
+// at once.
+const projectConfigTTL = 2 * time.Second
+
+// projectConfigCache caches the project configuration files read from disk,
+// rereading a file when its modification time changes, the lookup of the
+// file in each directory, and the schemas built from them. It is safe for
+// concurrent use.
+type projectConfigCache struct {
+	mu      sync.Mutex
+	files   map[string]projectConfigFile
+	dirs    map[string]projectConfigDir
+	schemas map[string]*analysis.Schema
+	// schemaVersion is the handler's schema version the schemas were built
+	// for.
+	schemaVersion int
+	// now returns the current time; nil means time.Now.
+	now func() time.Time
+}
+
+// projectConfigDir is the outcome of looking for a project configuration
+// file in a directory at checked.
+type projectConfigDir struct {
+	file    projectConfigFile
+	found   bool
+	checked time.Time
+}
+
+// projectConfigFile is a project configuration file read from path.
+type projectConfigFile struct {
+	path   string
+	stamp  time.Time
+	config ProjectConfig
+}
+
+// find returns the project configuration files that apply to the
+// Caddyfiles in dir, nearest first. The search goes up to the root of the
+// file system, or to a file setting root. Files that cannot be read or
+// parsed are logged and skipped.
+func (c *projectConfigCache) find(dir string) []projectConfigFile {
+	var found []projectConfigFile
+	for {
+		if f, ok := c.load(dir); ok {
+			found = append(found, f)
+			if f.config.Root {
+				break
+			}
+		}
+		parent := filepath.Dir(dir)
+		if parent == dir {
+			break
+		}
+		dir = parent
+	}
+	return found
+}
+
+// load returns the project configuration file in dir, if there is one. The
+// lookup is reused for projectConfigTTL.
+func (c *projectConfigCache) load(dir string) (projectConfigFile, bool) {
+	now := time.Now()
+	if c.now != nil {
+		now = c.now()
+	}
+	c.mu.Lock()
+	d, ok := c.dirs[dir]
+	c.mu.Unlock()
+	if ok && now.Sub(d.checked) < projectConfigTTL {
+		return d.file, d.found
+	}
+	f, found := c.read(dir)
+	c.mu.Lock()
+	if c.dirs == nil {
+		c.dirs = make(map[string]projectConfigDir)
+	}
+	c.dirs[dir] = projectConfigDir{file: f, found: found, checked: now}
+	c.mu.Unlock()
+	return f, found
+}
+
+// invalidate forgets the project configuration file at path, which has
+// been created, changed or deleted, and the lookup of its directory.
+func (c *projectConfigCache) invalidate(path string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	delete(c.files, path)
+	delete(c.dirs, filepath.Dir(path))
+	// The schemas are keyed by modification time, which may not have
+	// changed.
+	c.schemas = nil
+}
+
+// read looks for the project configuration file in dir, rereading it when
+// it has changed since it was last read.
+func (c *projectConfigCache) read(dir string) (projectConfigFile, bool) {
+	for _, name := range projectConfigNames {
+		path := filepath.Join(dir, name)
+		info, err := os.Stat(path)
+		if err != nil || info.IsDir() {
+			continue
+		}
+		c.mu.Lock()
+		f, ok := c.files[path]
+		c.mu.Unlock()
+		if ok && f.stamp.Equal(info.ModTime()) {
+			return f, true
+		}
+		f = projectConfigFile{path: path, stamp: info.ModTime()}
+		data, err := os.ReadFile(path)
+		if err == nil {
+			f.config, err = decodeProjectConfig(name, data)
+		}
+		if root := f.config.Files.DeployRoot; err == nil && root != "" && !filepath.IsAbs(root) {
+			f.config.Files.DeployRoot = filepath.Join(dir, root)
+		}
+		if err != nil {
+			log.Warningf("ignoring project configuration %s: %v", path, err)
+			f.config = ProjectConfig{}
+		}
+		c.mu.Lock()
+		if c.files == nil {
+			c.files = make(map[string]projectConfigFile)
+		}
+		c.files[path] = f
+		c.mu.Unlock()
+		return f, true
+	}
+	return projectConfigFile{}, false
+}
+
+// schema returns the schema cached under key for the handler's schema
+// version, building it with build when there is none.
+func (c *projectConfigCache) schema(key string, version int, build func() *analysis.Schema) *analysis.Schema {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.schemaVersion != version || c.schemas == nil {
+		c.schemas = make(map[string]*analysis.Schema)
+		c.schemaVersion = version
+	}
+	s, ok := c.schemas[key]
+	if !ok {
+		s = build()
+		c.schemas[key] = s
+	}
+	return s
+}
@@ -0,0 +1,221 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"testing"
+	"time"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestDecodeProjectConfig_Formats(t *testing.T) {
+	want := ProjectConfig{
+		Root:         true,
+		Lint:         map[string]bool{"no-handler": true, "empty-block": false},
+		Schema:       SchemaSettings{Directives: []string{"crowdsec"}, SubDirectives: map[string][]string{"crowdsec": {"api_url"}}},
+		CaddyVersion: "2.8",
+		Format:       FormatSettings{Indent: "    ", DropBlankLines: boolPtr(true)},
+	}
+	files := map[string]string{
+		".caddy-ls.json": `{
+			"root": true,
+			"lint": {"no-handler": true, "empty-block": false},
+			"schema": {"directives": ["crowdsec"], "subdirectives": {"crowdsec": ["api_url"]}},
+			"caddyVersion": "2.8",
+			"format": {"indent": "    ", "dropBlankLines": true}
+		}`,
+		".caddy-ls.yaml": "root: true\n" +
+			"lint:\n  no-handler: true\n  empty-block: false\n" +
+			"schema:\n  directives: [crowdsec]\n  subdirectives:\n    crowdsec: [api_url]\n" +
+			"caddyVersion: \"2.8\"\n" +
+			"format:\n  indent: \"    \"\n  dropBlankLines: true\n",
+		".caddy-ls.toml": "root = true # stop here\n" +
+			"caddyVersion = '2.8'\n\n" +
+			"[lint]\n\"no-handler\" = true\nempty-block = false\n\n" +
+			"[schema]\ndirectives = [\n  \"crowdsec\", # a plugin\n]\nsubdirectives.crowdsec = [\"api_url\"]\n\n" +
+			"[format]\nindent = \"    \"\ndropBlankLines = true\n",
+	}
+	for name, src := range files {
+		got, err := decodeProjectConfig(name, []byte(src))
+		if err != nil {
+			t.Errorf("%s: %v", name, err)
+			continue
+		}
+		if !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %+v, want %+v", name, got, want)
+		}
+	}
+}
+
+func TestDecodeProjectConfig_Errors(t *testing.T) {
+	for name, src := range map[string]string{
+		".caddy-ls.json": `{"lnit": {}}`,
+		".caddy-ls.yaml": "caddyVersion: latest\n",
+		".caddy-ls.toml": "[lint\n",
+		".caddy-ls.yml":  "lint: [a\n",
+	} {
+		if _, err := decodeProjectConfig(name, []byte(src)); err == nil {
+			t.Errorf("%s %q: want error", name, src)
+		}
+	}
+	if c, err := decodeProjectConfig(".caddy-ls.yaml", []byte("# nothing yet\n")); err != nil || !reflect.DeepEqual(c, ProjectConfig{}) {
+		t.Errorf("empty YAML file: got %+v, %v", c, err)
+	}
+}
+
+// writeProjectFiles creates each relative path under dir with the given
+// content.
+func writeProjectFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestSettingsFor_MergesProjectConfigs(t *testing.T) {
+	top := t.TempDir()
+	writeProjectFiles(t, top, map[string]string{
+		".caddy-ls.json":               `{"lint": {"final-newline": true}}`, // above the root
+		"repo/.caddy-ls.json":          `{"root": true, "lint": {"no-handler": true, "empty-block": true}, "schema": {"directives": ["outer"]}}`,
+		"repo/app/.caddy-ls.yaml":      "lint:\n  empty-block: false\nschema:\n  directives: [inner]\ncaddyVersion: \"2.7\"\n",
+		"repo/app/.caddy-ls.toml":      "caddyVersion = '2.6'\n", // shadowed by the YAML file
+		"repo/app/sites/.caddy-ls.yml": "",
+	})
+	dir := filepath.Join(top, "repo")
+	h := New(document.New())
+	h.applyInitOptions(InitOptions{CaddyVersion: "2.9", Settings: Settings{
+		Lint:   map[string]bool{"trailing-whitespace": true},
+		Schema: SchemaSettings{Directives: []string{"editor"}},
+	}})
+
+	s := h.settingsFor(workspace.PathToURI(filepath.Join(dir, "app", "sites", "Caddyfile")))
+	if want := map[string]bool{"trailing-whitespace": true, "no-handler": true, "empty-block": false}; !reflect.DeepEqual(s.Lint, want) {
+		t.Errorf("lint = %v, want %v", s.Lint, want)
+	}
+	if s.caddyVersion != "2.7" || s.analysisOptions().CaddyVersion != "2.7" {
+		t.Errorf("caddyVersion = %q, want the nearest file's", s.caddyVersion)
+	}
+	if !slices.Equal(s.Schema.Directives, []string{"editor", "outer", "inner"}) {
+		t.Errorf("schema directives = %v", s.Schema.Directives)
+	}
+	for _, name := range []string{"editor", "outer", "inner"} {
+		if !s.schema.IsDirective(name) {
+			t.Errorf("schema should know %s", name)
+		}
+	}
+	if h.settings.Lint["no-handler"] || h.currentSchema().IsDirective("inner") {
+		t.Error("project configuration leaked into the editor's settings")
+	}
+
+	other := h.settingsFor(workspace.PathToURI(filepath.Join(dir, "other", "Caddyfile")))
+	if other.caddyVersion != "2.9" || other.schema.IsDirective("inner") || !other.schema.IsDirective("outer") {
+		t.Errorf("sibling directory: caddyVersion %q", other.caddyVersion)
+	}
+	if untitled := h.settingsFor("untitled:1"); untitled.schema != h.currentSchema() {
+		t.Error("non-file documents should use the editor's schema")
+	}
+
+	// An edited file is read again once the client reports the change.
+	path := filepath.Join(dir, "app", ".caddy-ls.yaml")
+	writeProjectFiles(t, dir, map[string]string{"app/.caddy-ls.yaml": "caddyVersion: \"2.8\"\n"})
+	h.DidChangeWatchedFiles(recordNotify(new([]protocol.PublishDiagnosticsParams)), &protocol.DidChangeWatchedFilesParams{
+		Changes: []protocol.FileEvent{{URI: workspace.PathToURI(path), Type: protocol.FileChangeTypeChanged}},
+	})
+	if s := h.settingsFor(workspace.PathToURI(filepath.Join(dir, "app", "Caddyfile"))); s.caddyVersion != "2.8" {
+		t.Errorf("after edit: caddyVersion = %q", s.caddyVersion)
+	}
+}
+
+func TestProjectConfigCache_ReusesLookups(t *testing.T) {
+	dir := t.TempDir()
+	now := time.Now()
+	c := &projectConfigCache{now: func() time.Time { return now }}
+	if configs := c.find(dir); len(configs) != 0 {
+		t.Fatalf("got %d files in an empty directory", len(configs))
+	}
+
+	writeProjectFiles(t, dir, map[string]string{".caddy-ls.json": `{"caddyVersion": "2.8"}`})
+	if configs := c.find(dir); len(configs) != 0 {
+		t.Error("a lookup younger than projectConfigTTL should be reused")
+	}
+	now = now.Add(projectConfigTTL)
+	if configs := c.find(dir); len(configs) != 1 || configs[0].config.CaddyVersion != "2.8" {
+		t.Errorf("after projectConfigTTL: got %+v, want the new file", configs)
+	}
+
+	// Rewritten within the same modification time, the file is only read
+	// again when invalidated.
+	path := filepath.Join(dir, ".caddy-ls.json")
+	stamp := c.find(dir)[0].stamp
+	writeProjectFiles(t, dir, map[string]string{".caddy-ls.json": `{"caddyVersion": "2.9"}`})
+	if err := os.Chtimes(path, stamp, stamp); err != nil {
+		t.Fatal(err)
+	}
+	c.invalidate(path)
+	if configs := c.find(dir); len(configs) != 1 || configs[0].config.CaddyVersion != "2.9" {
+		t.Errorf("after invalidate: got %+v", configs)
+	}
+}
+
+func TestDiagnose_ProjectConfigLint(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFiles(t, dir, map[string]string{".caddy-ls.toml": "root = true\n[lint]\nno-handler = true\n"})
+	uri := workspace.PathToURI(filepath.Join(dir, "Caddyfile"))
+	h := New(document.New())
+	diags := h.diagnose(uri, "example.com {\n\tlog\n}\n")
+	if len(diags) != 1 || diags[0].Code == nil || diags[0].Code.Value != "no-handler" {
+		t.Errorf("diagnostics = %+v", diags)
+	}
+}
+
+func TestDiagnose_ProjectConfigFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFiles(t, dir, map[string]string{
+		"deploy/etc/ssl/site.pem": "",
+		"deploy/etc/ssl/site.key": "",
+		"prod/.caddy-ls.json":     `{"root": true, "files": {"deployRoot": "../deploy"}}`,
+		"remote/.caddy-ls.json":   `{"root": true, "files": {"check": false}}`,
+	})
+	h := New(document.New())
+	src := "example.com {\n\ttls /etc/ssl/site.pem /etc/ssl/site.key\n\timport /etc/caddy/common.caddy\n}\n"
+	diags := h.diagnose(workspace.PathToURI(filepath.Join(dir, "prod", "Caddyfile")), src)
+	if len(diags) != 1 || diags[0].Range.Start.Line != 2 {
+		t.Errorf("deploy root: want only the missing import, got %+v", diags)
+	}
+	if diags := h.diagnose(workspace.PathToURI(filepath.Join(dir, "remote", "Caddyfile")), src); len(diags) != 0 {
+		t.Errorf("checks off: got %+v", diags)
+	}
+}
+
+func TestDocumentFormatting(t *testing.T) {
+	dir := t.TempDir()
+	writeProjectFiles(t, dir, map[string]string{".caddy-ls.json": `{"root": true, "format": {"indent": "  "}}`})
+	uri := workspace.PathToURI(filepath.Join(dir, "Caddyfile"))
+	h := New(document.New())
+	h.store.Open(uri, "a.com {\nrespond \"é\"\n}", 1)
+
+	edits, err := h.DocumentFormatting(nil, &protocol.DocumentFormattingParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := protocol.Range{End: protocol.Position{Line: 2, Character: 1}}
+	if len(edits) != 1 || edits[0].Range != want || edits[0].NewText != "a.com {\n  respond \"é\"\n}\n" {
+		t.Fatalf("edits = %+v", edits)
+	}
+
+	h.store.Open(uri, edits[0].NewText, 2)
+	if edits, _ := h.DocumentFormatting(nil, &protocol.DocumentFormattingParams{TextDocument: protocol.TextDocumentIdentifier{URI: uri}}); edits != nil {
+		t.Errorf("formatted document: edits = %+v", edits)
+	}
+}
@@ -0,0 +1,36 @@
+package handler
+
+import (
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// DiagnosticsPublisher receives the diagnostics computed for a version of a
+// document. The language server sends them to the client; tests and the
+// check command collect them instead.
+type DiagnosticsPublisher interface {
+	PublishDiagnostics(uri string, version int32, diags []protocol.Diagnostic)
+}
+
+// clientPublisher publishes diagnostics to the client as
+// textDocument/publishDiagnostics notifications.
+type clientPublisher glsp.NotifyFunc
+
+// PublishDiagnostics implements DiagnosticsPublisher.
+func (notify clientPublisher) PublishDiagnostics(uri string, version int32, diags []protocol.Diagnostic) {
+	v := protocol.UInteger(version)
+	notify(protocol.ServerTextDocumentPublishDiagnostics, protocol.PublishDiagnosticsParams{
+		URI:         uri,
+		Version:     &v,
+		Diagnostics: diags,
+	})
+}
+
+// publisher returns where to publish diagnostics computed while serving
+// ctx: the injected publisher, or else the client behind ctx.
+func (h *Handler) publisher(ctx *glsp.Context) DiagnosticsPublisher {
+	if h.publish != nil {
+		return h.publish
+	}
+	return clientPublisher(ctx.Notify)
+}
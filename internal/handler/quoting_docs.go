@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// backtickDoc explains backtick quoting, which differs from double quotes
+// only in how backslashes are treated.
+const backtickDoc = "**Backtick-quoted string**\n\n" +
+	"Like a double-quoted string, this is one token that may contain spaces and span lines. " +
+	"Unlike one, nothing inside is escaped: backslashes are kept as written and double quotes need no `\\\"`, " +
+	"which suits JSON, regular expressions and CEL expressions. " +
+	"A backtick cannot appear inside; use double quotes or a heredoc for text that contains one."
+
+// heredocDoc explains the heredoc opened by <<marker.
+func heredocDoc(marker string) string {
+	return "**Heredoc** `<<" + marker + "` … `" + marker + "`\n\n" +
+		"The lines between `<<" + marker + "` and the closing `" + marker + "` marker are one token, taken verbatim: " +
+		"quotes and backslashes have no special meaning.\n\n" +
+		"- The whitespace before the closing marker is removed from the start of every line, " +
+		"so each non-empty line must begin with exactly that indentation.\n" +
+		"- The newline before the closing marker is not part of the value.\n" +
+		"- Placeholders are still replaced where the directive supports them."
+}
+
+// quotingHoverAt returns the hover text for the quoting syntax under pos: a
+// heredoc's opening or closing marker, or a backtick-quoted string.
+func quotingHoverAt(content string, pos protocol.Position) (string, bool) {
+	for _, tok := range parser.Tokenize(content) {
+		if tok.Type != parser.STRING {
+			continue
+		}
+		switch {
+		case strings.HasPrefix(tok.Value, "<<"):
+			marker := strings.TrimSpace(tok.Value[2:])
+			if tokenContains(tok, pos) || heredocCloserContains(content, tok.Line, marker, pos) {
+				return heredocDoc(marker), true
+			}
+		case strings.HasPrefix(tok.Value, "`"):
+			if tokenContains(tok, pos) {
+				return backtickDoc, true
+			}
+		}
+	}
+	return "", false
+}
+
+// heredocCloserContains reports whether pos lies on the closing marker of
+// the heredoc opened on line open. Like Caddy, the heredoc ends at the
+// first occurrence of the marker after the opening line.
+func heredocCloserContains(content string, open uint32, marker string, pos protocol.Position) bool {
+	if marker == "" || pos.Line <= open {
+		return false
+	}
+	start := 0
+	for range open + 1 {
+		i := strings.IndexByte(content[start:], '\n')
+		if i < 0 {
+			return false
+		}
+		start += i + 1
+	}
+	i := strings.Index(content[start:], marker)
+	if i < 0 {
+		return false
+	}
+	before := content[:start+i]
+	line := uint32(strings.Count(before, "\n"))
+	char := parser.UTF16Len(before[strings.LastIndexByte(before, '\n')+1:])
+	return line == pos.Line && pos.Character >= char && pos.Character <= char+parser.UTF16Len(marker)
+}
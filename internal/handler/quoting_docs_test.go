@@ -0,0 +1,66 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestHover_Quoting(t *testing.T) {
+	src := "example.com {\n" +
+		"\trespond <<HTML\n" +
+		"\t\t<p>{path}</p>\n" +
+		"\t\tHTML 200\n" +
+		"\theader X-Json `{\"a\": \"\\d\"}`\n" +
+		"\trespond \"plain\"\n" +
+		"}\n"
+	store := document.New()
+	store.Open("file:///Caddyfile", src, 1)
+	h := New(store)
+	hover := func(p protocol.Position) string {
+		got, err := h.Hover(nil, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     p,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil {
+			return ""
+		}
+		return got.Contents.(protocol.MarkupContent).Value
+	}
+
+	tests := []struct {
+		name string
+		pos  protocol.Position
+		want string // substring of the hover, "" for none
+	}{
+		{"opening marker", pos(1, 11), "**Heredoc** `<<HTML`"},
+		{"closing marker", pos(3, 4), "whitespace before the closing marker"},
+		{"heredoc body", pos(2, 3), ""},
+		{"after closing marker", pos(3, 8), "**`<status>`**"},
+		{"backtick string", pos(4, 18), "**Backtick-quoted string**"},
+		{"double-quoted string", pos(5, 11), ""},
+		{"directive", pos(1, 3), "respond"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			got := hover(tc.pos)
+			if tc.want == "" {
+				if got != "" {
+					t.Errorf("want no hover, got %q", got)
+				}
+				return
+			}
+			if !strings.Contains(got, tc.want) {
+				t.Errorf("want hover containing %q, got %q", tc.want, got)
+			}
+			if tc.name == "directive" && strings.Contains(got, "Heredoc") {
+				t.Errorf("directive name must not get heredoc docs, got %q", got)
+			}
+		})
+	}
+}
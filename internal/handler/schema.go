@@ -0,0 +1,74 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+
+	"github.com/tliron/glsp"
+)
+
+// SchemaSettings overrides the directive schema for Caddy builds the
+// built-in schema does not describe. Overrides take precedence over the
+// built-in schema and the plugin declarations.
+type SchemaSettings struct {
+	// Directives are extra site-level directives.
+	Directives []string `json:"directives"`
+	// GlobalOptions are extra global options.
+	GlobalOptions []string `json:"globalOptions"`
+	// SubDirectives maps a directive to extra names valid in its body.
+	SubDirectives map[string][]string `json:"subdirectives"`
+	// Storage are extra storage modules, e.g. "redis".
+	Storage []string `json:"storage"`
+	// Matchers are extra request matcher types, e.g. "maxmind_geolocation".
+	Matchers []string `json:"matchers"`
+	// Disable lists site-level directives the Caddy build lacks.
+	Disable []string `json:"disable"`
+}
+
+// layer returns s as a user schema layer.
+func (s SchemaSettings) layer() analysis.SchemaLayer {
+	return analysis.SchemaLayer{
+		Origin:        analysis.OriginUser,
+		Directives:    s.Directives,
+		GlobalOptions: s.GlobalOptions,
+		SubDirectives: s.SubDirectives,
+		Storage:       s.Storage,
+		Matchers:      s.Matchers,
+		Disable:       s.Disable,
+	}
+}
+
+// buildSchema merges the plugin declarations and user overrides in s into
+// the built-in schema, along with the loaded layers, such as the schema
+// file, which go below the overrides of their origin. Nil layers are
+// skipped. Mistakes in them are logged and otherwise ignored;
+// caddyls.schema.dump lists them too.
+func buildSchema(s Settings, loaded ...*analysis.SchemaLayer) *analysis.Schema {
+	plugins := analysis.Plugins{Transports: s.Plugins.Transports, Upstreams: s.Plugins.Upstreams, Modules: s.Plugins.Modules}
+	layers := []analysis.SchemaLayer{plugins.Layer()}
+	for _, l := range loaded {
+		if l != nil {
+			layers = append(layers, *l)
+		}
+	}
+	schema := analysis.NewSchema(append(layers, s.Schema.layer())...)
+	for _, p := range schema.Problems() {
+		log.Warningf("%s schema: %s", p.Origin, p.Message)
+	}
+	return schema
+}
+
+// currentSchema returns the schema built from the settings, or the built-in
+// one before any settings arrive.
+func (h *Handler) currentSchema() *analysis.Schema {
+	if h.schema == nil {
+		return analysis.DefaultSchema()
+	}
+	return h.schema
+}
+
+// schemaDumpCommand implements caddyls.schema.dump. It takes no arguments
+// and returns the merged schema, with the layer every name comes from and
+// any problems found in the layers.
+func (h *Handler) schemaDumpCommand(ctx *glsp.Context, args []any) (any, error) {
+	return h.currentSchema().Dump(), nil
+}
@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"slices"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestSchemaDumpCommand(t *testing.T) {
+	s, err := decodeSettings(map[string]any{
+		"plugins": map[string]any{"transports": []any{"h2c"}},
+		"schema":  map[string]any{"directives": []any{"rate_limit", "reverse_proxy"}},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	h := New(document.New())
+	h.applySettings(s)
+
+	got, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.schema.dump"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := got.(analysis.SchemaDump)
+	origin := func(entries []analysis.NamedSchemaEntry, name string) analysis.Origin {
+		i := slices.IndexFunc(entries, func(e analysis.NamedSchemaEntry) bool { return e.Name == name })
+		if i < 0 {
+			return ""
+		}
+		return entries[i].Origin
+	}
+	if o := origin(d.Directives, "rate_limit"); o != analysis.OriginUser {
+		t.Errorf("rate_limit origin = %q, want user", o)
+	}
+	if o := origin(d.Directives, "file_server"); o != analysis.OriginBuiltin {
+		t.Errorf("file_server origin = %q, want builtin", o)
+	}
+	if o := origin(d.Transports, "h2c"); o != analysis.OriginPlugin {
+		t.Errorf("h2c origin = %q, want plugin", o)
+	}
+	if len(d.Problems) != 1 {
+		t.Errorf("want the redundant reverse_proxy override reported, got %+v", d.Problems)
+	}
+}
+
+func TestCompletion_UserSchemaDirectives(t *testing.T) {
+	h := New(document.New())
+	h.applySettings(Settings{Schema: SchemaSettings{Directives: []string{"rate_limit"}, Disable: []string{"php_fastcgi"}}})
+	f := parseAST("example.com {\n\t\n}\n")
+	names := completionScopeAt(h.currentSchema(), f, protocol.Position{Line: 1, Character: 1}).names
+	if !slices.Contains(names, "rate_limit") || slices.Contains(names, "php_fastcgi") {
+		t.Errorf("completions should follow the user schema, got %v", names)
+	}
+}
@@ -0,0 +1,138 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"strings"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Semantic token types and modifiers, as indexes into semanticLegend.
+const (
+	semanticKeyword  = iota // directive and option names
+	semanticVariable        // named matchers
+	semanticMacro           // snippets
+)
+
+const (
+	semanticDeclaration = 1 << iota
+	semanticDeprecated
+)
+
+var semanticLegend = protocol.SemanticTokensLegend{
+	TokenTypes: []string{
+		string(protocol.SemanticTokenTypeKeyword),
+		string(protocol.SemanticTokenTypeVariable),
+		string(protocol.SemanticTokenTypeMacro),
+	},
+	TokenModifiers: []string{
+		string(protocol.SemanticTokenModifierDeclaration),
+		string(protocol.SemanticTokenModifierDeprecated),
+	},
+}
+
+// semanticToken is a token to classify, before the relative encoding.
+type semanticToken struct {
+	line, char, length uint32
+	typ, modifiers     uint32
+}
+
+// SemanticTokensFull handles textDocument/semanticTokens/full. Directive
+// names are keywords, named matchers variables and snippets macros. The
+// definitions of matchers and snippets carry the declaration modifier, so
+// editors can tell them from references, and directives Caddy deprecated or
+// removed carry the deprecated modifier whatever caddyVersion is.
+func (h *Handler) SemanticTokensFull(ctx *glsp.Context, params *protocol.SemanticTokensParams) (*protocol.SemanticTokens, error) {
+	content, ok := h.store.Get(string(params.TextDocument.URI))
+	if !ok || h.tooLarge(content) {
+		return nil, nil
+	}
+	ast, _ := parser.Parse(content)
+	return &protocol.SemanticTokens{Data: encodeSemanticTokens(semanticTokens(ast))}, nil
+}
+
+// semanticTokens collects the classified tokens of f in document order.
+func semanticTokens(f *parser.File) []semanticToken {
+	var tokens []semanticToken
+	add := func(t parser.Token, typ, modifiers uint32) {
+		if t.Type == parser.IDENT {
+			r := t.Range()
+			tokens = append(tokens, semanticToken{t.Line, r.Start.Character, r.End.Character - r.Start.Character, typ, modifiers})
+		}
+	}
+
+	// site is set for the directives of a site block, a snippet or a
+	// routing container, which are the ones Caddy may deprecate.
+	var walk func(ds []*parser.Directive, site bool)
+	walk = func(ds []*parser.Directive, site bool) {
+		for _, d := range ds {
+			switch name := d.Name.Value; {
+			case strings.HasPrefix(name, "@"):
+				add(d.Name, semanticVariable, semanticDeclaration)
+			case site && deprecatedDirective(name):
+				add(d.Name, semanticKeyword, semanticDeprecated)
+			default:
+				add(d.Name, semanticKeyword, 0)
+			}
+			for i, arg := range d.Args {
+				switch {
+				case d.Name.Value == "import" && i == 0 && !analysis.IsFileImport(arg.Token.Value):
+					add(arg.Token, semanticMacro, 0)
+				case strings.HasPrefix(arg.Token.Value, "@"):
+					add(arg.Token, semanticVariable, 0)
+				}
+			}
+			walk(d.Body, site && containerDirectives[d.Name.Value])
+		}
+	}
+
+	walk(f.Imports, false)
+	if f.GlobalBlock != nil {
+		walk(f.GlobalBlock.Directives, false)
+	}
+	for _, sb := range f.SiteBlocks {
+		if len(sb.Addresses) > 0 {
+			if addr := sb.Addresses[0]; strings.HasPrefix(addr.Value, "(") && strings.HasSuffix(addr.Value, ")") && len(addr.Value) > 2 {
+				// The name between the parentheses.
+				start, end := addr.Position(1), addr.Position(len(addr.Value)-1)
+				tokens = append(tokens, semanticToken{addr.Line, start.Character, end.Character - start.Character, semanticMacro, semanticDeclaration})
+			}
+		}
+		walk(sb.Directives, true)
+	}
+
+	slices.SortStableFunc(tokens, func(a, b semanticToken) int {
+		if a.line != b.line {
+			return int(a.line) - int(b.line)
+		}
+		return int(a.char) - int(b.char)
+	})
+	return tokens
+}
+
+// deprecatedDirective reports whether Caddy deprecated or removed the
+// directive name in some release.
+func deprecatedDirective(name string) bool {
+	v, ok := analysis.DirectiveVersionOf(name)
+	return ok && (v.Deprecated != "" || v.Removed != "")
+}
+
+// encodeSemanticTokens encodes tokens, in document order, in the relative
+// form of the protocol: five integers per token, its line and start relative
+// to the previous token, its length, type and modifiers.
+func encodeSemanticTokens(tokens []semanticToken) []protocol.UInteger {
+	data := make([]protocol.UInteger, 0, 5*len(tokens))
+	var line, char uint32
+	for _, t := range tokens {
+		deltaChar := t.char
+		if t.line == line {
+			deltaChar -= char
+		}
+		data = append(data, t.line-line, deltaChar, t.length, t.typ, t.modifiers)
+		line, char = t.line, t.char
+	}
+	return data
+}
@@ -0,0 +1,51 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"slices"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestSemanticTokens(t *testing.T) {
+	src := "(common) {\n\tbasicauth {\n\t}\n}\nexample.com {\n\t@api path /api/*\n\timport common\n\thandle @api {\n\t\tskip_log\n\t}\n\treverse_proxy @api {\n\t\tbasicauth\n\t}\n}\n"
+	type tok struct {
+		line, char, length, typ, modifiers uint32
+	}
+	want := []tok{
+		{0, 1, 6, semanticMacro, semanticDeclaration},
+		{1, 1, 9, semanticKeyword, semanticDeprecated},
+		{5, 1, 4, semanticVariable, semanticDeclaration},
+		{6, 1, 6, semanticKeyword, 0},
+		{6, 8, 6, semanticMacro, 0},
+		{7, 1, 6, semanticKeyword, 0},
+		{7, 8, 4, semanticVariable, 0},
+		{8, 2, 8, semanticKeyword, semanticDeprecated},
+		{10, 1, 13, semanticKeyword, 0},
+		{10, 15, 4, semanticVariable, 0},
+		{11, 2, 9, semanticKeyword, 0}, // not a site directive here
+	}
+
+	h := New(document.New())
+	h.store.Open("file:///Caddyfile", src, 1)
+	res, err := h.SemanticTokensFull(nil, &protocol.SemanticTokensParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got []tok
+	var line, char uint32
+	for d := res.Data; len(d) >= 5; d = d[5:] {
+		if d[0] > 0 {
+			char = 0
+		}
+		line += d[0]
+		char += d[1]
+		got = append(got, tok{line, char, d[2], d[3], d[4]})
+	}
+	if !slices.Equal(got, want) {
+		t.Errorf("got  %v\nwant %v", got, want)
+	}
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"encoding/json"
+	"slices"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// settingsSection is the configuration section clients send our settings
+// under, e.g. {"caddy": {"env": {...}}}.
+const settingsSection = "caddy"
+
+// Settings is the user configuration for the server.
+type Settings struct {
+	Env     EnvSettings    `json:"env"`
+	Plugins PluginSettings `json:"plugins"`
+	// Schema overrides the directive schema.
+	Schema SchemaSettings `json:"schema"`
+	// MaxDocumentSize is the size in bytes above which a document is not
+	// analyzed. Zero means defaultMaxDocumentSize; negative disables the
+	// limit.
+	MaxDocumentSize int `json:"maxDocumentSize"`
+	// MaxDiagnostics caps the diagnostics published per document. Zero
+	// means defaultMaxDiagnostics; negative disables the cap.
+	MaxDiagnostics int `json:"maxDiagnostics"`
+	// Validate configures validation with the local caddy binary.
+	Validate ValidateSettings `json:"validate"`
+	// Completion tunes completion items.
+	Completion CompletionSettings `json:"completion"`
+	// Lint enables opt-in lint rules by code, e.g. "trailing-whitespace".
+	Lint map[string]bool `json:"lint"`
+	// CaddyModules declares the plugin modules of the local Caddy build,
+	// listed with `caddy list-modules`, in the generated schema layer.
+	CaddyModules bool `json:"caddyModules"`
+	// FilePatterns name the files indexed as Caddyfiles. Empty means
+	// workspace.DefaultPatterns.
+	FilePatterns []string `json:"filePatterns"`
+	// Format configures document formatting.
+	Format FormatSettings `json:"format"`
+	// Files configures the checks that the files a Caddyfile names exist.
+	Files FileSettings `json:"files"`
+}
+
+// filePatterns returns the valid patterns of FilePatterns, logging the
+// others.
+func (s Settings) filePatterns() workspace.Patterns {
+	var patterns workspace.Patterns
+	for _, p := range s.FilePatterns {
+		if err := (workspace.Patterns{p}).Validate(); err != nil {
+			log.Warningf("ignoring setting caddy.filePatterns: %v", err)
+			continue
+		}
+		patterns = append(patterns, p)
+	}
+	return patterns
+}
+
+// defaultMaxDocumentSize is the document size limit used when
+// Settings.MaxDocumentSize is zero.
+const defaultMaxDocumentSize = 2 << 20
+
+// maxDocumentSize returns the effective document size limit, or -1 when
+// there is none.
+func (s Settings) maxDocumentSize() int {
+	switch {
+	case s.MaxDocumentSize == 0:
+		return defaultMaxDocumentSize
+	case s.MaxDocumentSize < 0:
+		return -1
+	}
+	return s.MaxDocumentSize
+}
+
+// defaultMaxDiagnostics is the per-document diagnostic cap used when
+// Settings.MaxDiagnostics is zero.
+const defaultMaxDiagnostics = 200
+
+// maxDiagnostics returns the effective per-document diagnostic cap, or -1
+// when there is none.
+func (s Settings) maxDiagnostics() int {
+	switch {
+	case s.MaxDiagnostics == 0:
+		return defaultMaxDiagnostics
+	case s.MaxDiagnostics < 0:
+		return -1
+	}
+	return s.MaxDiagnostics
+}
+
+// PluginSettings declares modules provided by Caddy plugins so they are not
+// reported as unknown.
+type PluginSettings struct {
+	// Transports are extra reverse_proxy transport modules.
+	Transports []string `json:"transports"`
+	// Upstreams are extra reverse_proxy dynamic upstream modules.
+	Upstreams []string `json:"upstreams"`
+	// Modules maps a module namespace, such as "tls.issuance", to extra
+	// modules in it, e.g. {"tls.issuance": ["acmedns"]}.
+	Modules map[string][]string `json:"modules"`
+	// Placeholders are extra runtime placeholders set by plugins. Names
+	// ending in "." cover a whole namespace, e.g. "http.auth.jwt.".
+	Placeholders []string `json:"placeholders"`
+}
+
+// EnvSettings configures where {$VAR} placeholders are resolved from.
+// Sources are consulted in the order Vars, Files, process environment.
+type EnvSettings struct {
+	// Vars maps variable names to values directly.
+	Vars map[string]string `json:"vars"`
+	// Files lists .env files. Relative paths are resolved against the first
+	// workspace root.
+	Files []string `json:"files"`
+	// Process also consults the language server's own environment.
+	Process bool `json:"process"`
+	// CompletionSources lists additional .env or docker-compose files, looked
+	// up in every workspace root, whose variable names are offered when
+	// typing "{$". Nil means defaultEnvCompletionSources.
+	CompletionSources []string `json:"completionSources"`
+}
+
+// defaultEnvCompletionSources are the files scanned for variable names when
+// EnvSettings.CompletionSources is not set.
+var defaultEnvCompletionSources = []string{
+	".env",
+	"docker-compose.yml",
+	"docker-compose.yaml",
+	"compose.yml",
+	"compose.yaml",
+}
+
+// decodeSettings extracts Settings from a raw settings value. Both the
+// sectioned form {"caddy": {...}} and the bare section are accepted.
+func decodeSettings(raw any) (Settings, error) {
+	var s Settings
+	err := decodeSection(raw, &s)
+	return s, err
+}
+
+// decodeSection unmarshals the settingsSection of raw into v, or all of raw
+// when it has no such section.
+func decodeSection(raw any, v any) error {
+	data, err := json.Marshal(raw)
+	if err != nil {
+		return err
+	}
+	var sections map[string]json.RawMessage
+	if err := json.Unmarshal(data, &sections); err == nil {
+		if section, ok := sections[settingsSection]; ok {
+			data = section
+		}
+	}
+	return json.Unmarshal(data, v)
+}
+
+// DidChangeConfiguration handles workspace/didChangeConfiguration. The new
+// settings replace the old ones and every open document is re-analyzed.
+// The workspace is indexed again when the file patterns change.
+func (h *Handler) DidChangeConfiguration(ctx *glsp.Context, params *protocol.DidChangeConfigurationParams) error {
+	s, err := decodeSettings(params.Settings)
+	if err != nil {
+		log.Warningf("ignoring invalid settings: %v", err)
+		return nil
+	}
+	reindex := !slices.Equal(s.filePatterns(), h.settings.filePatterns())
+	h.applySettings(s)
+	h.reanalyze(ctx, h.store.URIs())
+	if reindex {
+		h.reindexWorkspace(ctx)
+	}
+	return nil
+}
+
+// applySettings stores s and rebuilds everything derived from it.
+func (h *Handler) applySettings(s Settings) {
+	h.settings = s
+	h.rebuildEnv()
+	if err := h.loadCaddyModules(false); err != nil {
+		log.Warningf("ignoring setting caddy.caddyModules: %v", err)
+	}
+	h.rebuildSchema()
+}
+
+// rebuildSchema merges the schema from the settings and the loaded layers.
+func (h *Handler) rebuildSchema() {
+	h.schema = buildSchema(h.settings, h.schemaFile, h.modules)
+	h.schemaVersion++
+}
+
+// analysisOptions returns the analyzer options derived from the settings,
+// without any project configuration.
+func (h *Handler) analysisOptions() analysis.Options {
+	opts := h.settingsFor("").analysisOptions()
+	opts.Schema = h.schema
+	return opts
+}
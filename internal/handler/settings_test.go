@@ -0,0 +1,48 @@
+package handler
+
+import (
+	"caddy-ls/internal/workspace"
+	"slices"
+	"testing"
+)
+
+func TestDecodeSettings_Sectioned(t *testing.T) {
+	raw := map[string]any{
+		"caddy": map[string]any{
+			"env": map[string]any{
+				"files": []any{".env"},
+				"vars":  map[string]any{"A": "1"},
+			},
+		},
+	}
+	s, err := decodeSettings(raw)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(s.Env.Files) != 1 || s.Env.Files[0] != ".env" || s.Env.Vars["A"] != "1" {
+		t.Errorf("decoded settings = %+v", s)
+	}
+}
+
+func TestDecodeSettings_BareSection(t *testing.T) {
+	s, err := decodeSettings(map[string]any{"env": map[string]any{"process": true}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !s.Env.Process {
+		t.Errorf("decoded settings = %+v, want env.process=true", s)
+	}
+}
+
+func TestDecodeSettings_Invalid(t *testing.T) {
+	if _, err := decodeSettings(map[string]any{"env": "nope"}); err == nil {
+		t.Error("env as string: want error")
+	}
+}
+
+func TestSettings_FilePatterns(t *testing.T) {
+	s := Settings{FilePatterns: []string{"Caddyfile*", "[bad", "conf.d/*.conf"}}
+	if got := s.filePatterns(); !slices.Equal(got, workspace.Patterns{"Caddyfile*", "conf.d/*.conf"}) {
+		t.Errorf("got %v, want the malformed pattern dropped", got)
+	}
+}
@@ -0,0 +1,123 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// SignatureHelp shows the arguments of the request matcher on the line being
+// typed inside a named matcher definition, e.g. `@api header <field>
+// [<value>]`, with the argument under the cursor active.
+func (h *Handler) SignatureHelp(ctx *glsp.Context, params *protocol.SignatureHelpParams) (*protocol.SignatureHelp, error) {
+	content, ok := h.store.Get(string(params.TextDocument.URI))
+	if !ok || h.tooLarge(content) {
+		return nil, nil
+	}
+	ast, _ := parser.Parse(content)
+	name, args, ok := matcherCallAt(ast, params.Position)
+	if !ok {
+		return nil, nil
+	}
+	forms, ok := analysis.MatcherForms(name.Value)
+	if !ok {
+		return nil, nil
+	}
+
+	arg := len(args)
+	for i, a := range args {
+		if params.Position.Character <= a.Token.Range().End.Character {
+			arg = i
+			break
+		}
+	}
+	form, param := analysis.ActiveForm(forms, arg, len(args))
+
+	doc, _ := h.docs.Matcher(name.Value)
+	doc, _, _ = strings.Cut(doc, "\n\n")
+	help := &protocol.SignatureHelp{
+		ActiveSignature: uintegerPtr(form),
+		ActiveParameter: uintegerPtr(param),
+	}
+	for _, f := range forms {
+		sig := protocol.SignatureInformation{Label: name.Value}
+		if doc != "" {
+			sig.Documentation = markup(h.client.signatureDocFormat, doc)
+		}
+		for _, p := range f.Params {
+			sig.Label += " " + p.Label
+			sig.Parameters = append(sig.Parameters, protocol.ParameterInformation{
+				Label:         p.Label,
+				Documentation: markup(h.client.signatureDocFormat, p.Doc),
+			})
+		}
+		help.Signatures = append(help.Signatures, sig)
+	}
+	return help, nil
+}
+
+// matcherCallAt finds the matcher line of a named matcher definition that
+// pos lies on, past its matcher type: the one-line `@name <type> ...` form
+// or a line of an `@name { ... }` block. It returns the type token and the
+// arguments after it. A `not` followed by a type is looked through, so the
+// negated matcher's arguments are returned.
+func matcherCallAt(f *parser.File, pos protocol.Position) (parser.Token, []*parser.Argument, bool) {
+	var walk func(ds []*parser.Directive) (parser.Token, []*parser.Argument, bool)
+	walk = func(ds []*parser.Directive) (parser.Token, []*parser.Argument, bool) {
+		for _, d := range ds {
+			if strings.HasPrefix(d.Name.Value, "@") {
+				if d.Name.Line == pos.Line && len(d.Args) > 0 && d.Args[0].Token.Type != parser.STRING {
+					return matcherCall(d.Args[0].Token, d.Args[1:], pos)
+				}
+				if d.BodyContains(pos) {
+					return matcherLineAt(d.Body, pos)
+				}
+				continue
+			}
+			if d.BodyContains(pos) {
+				return walk(d.Body)
+			}
+		}
+		return parser.Token{}, nil, false
+	}
+	for _, sb := range f.SiteBlocks {
+		if sb.BodyContains(pos) {
+			return walk(sb.Directives)
+		}
+	}
+	return parser.Token{}, nil, false
+}
+
+// matcherLineAt resolves pos against the lines of a named matcher block,
+// descending into `not { ... }` blocks.
+func matcherLineAt(ds []*parser.Directive, pos protocol.Position) (parser.Token, []*parser.Argument, bool) {
+	for _, d := range ds {
+		if d.Name.Line == pos.Line {
+			return matcherCall(d.Name, d.Args, pos)
+		}
+		if d.Name.Value == "not" && d.BodyContains(pos) {
+			return matcherLineAt(d.Body, pos)
+		}
+	}
+	return parser.Token{}, nil, false
+}
+
+// matcherCall returns typ and args when pos lies past typ, looking through
+// a leading `not`.
+func matcherCall(typ parser.Token, args []*parser.Argument, pos protocol.Position) (parser.Token, []*parser.Argument, bool) {
+	if pos.Character <= typ.Range().End.Character {
+		return parser.Token{}, nil, false
+	}
+	if typ.Value == "not" && len(args) > 0 && pos.Character > args[0].Token.Range().End.Character {
+		return args[0].Token, args[1:], true
+	}
+	return typ, args, true
+}
+
+func uintegerPtr(n int) *protocol.UInteger {
+	u := protocol.UInteger(n)
+	return &u
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/pkg/caddyfile/analysis"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// signatureHelp requests signature help at p in src and returns the active
+// signature's label and active parameter label, or ok false when there is
+// none.
+func signatureHelp(t *testing.T, src string, p protocol.Position) (label, param string, ok bool) {
+	t.Helper()
+	h := New(document.New())
+	h.store.Open("file:///Caddyfile", src, 1)
+	help, err := h.SignatureHelp(nil, &protocol.SignatureHelpParams{
+		TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: "file:///Caddyfile"},
+			Position:     p,
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if help == nil {
+		return "", "", false
+	}
+	sig := help.Signatures[*help.ActiveSignature]
+	return sig.Label, sig.Parameters[*help.ActiveParameter].Label.(string), true
+}
+
+func TestSignatureHelp_Matchers(t *testing.T) {
+	tests := []struct {
+		name      string
+		src       string
+		pos       protocol.Position
+		wantLabel string
+		wantParam string
+	}{
+		{"one-line first arg", "a.com {\n\t@m header \n}\n", pos(1, 11), "header <field> [<value>]", "<field>"},
+		{"one-line second arg", "a.com {\n\t@m header X-Foo \n}\n", pos(1, 17), "header <field> [<value>]", "[<value>]"},
+		{"inside an arg", "a.com {\n\t@m header X-Foo bar\n}\n", pos(1, 13), "header <field> [<value>]", "<field>"},
+		{"block line", "a.com {\n\t@m {\n\t\tremote_ip 10.0.0.0/8 \n\t}\n}\n", pos(2, 24), "remote_ip <ranges...>", "<ranges...>"},
+		{"negated", "a.com {\n\t@m not path \n}\n", pos(1, 13), "path <paths...>", "<paths...>"},
+		{"not block", "a.com {\n\t@m {\n\t\tnot {\n\t\t\tvars {x} \n\t\t}\n\t}\n}\n", pos(3, 12), "vars <variable> <values...>", "<values...>"},
+		{"optional name", "a.com {\n\t@m header_regexp X-Foo ^a\n}\n", pos(1, 24), "header_regexp <field> <regexp>", "<regexp>"},
+		{"with name", "a.com {\n\t@m header_regexp n X-Foo ^a\n}\n", pos(1, 19), "header_regexp <name> <field> <regexp>", "<name>"},
+		{"nested in handle", "a.com {\n\thandle {\n\t\t@m method \n\t}\n}\n", pos(2, 12), "method <verbs...>", "<verbs...>"},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			label, param, ok := signatureHelp(t, tc.src, tc.pos)
+			if !ok {
+				t.Fatal("want signature help, got none")
+			}
+			if label != tc.wantLabel || param != tc.wantParam {
+				t.Errorf("got %q (active %q), want %q (active %q)", label, param, tc.wantLabel, tc.wantParam)
+			}
+		})
+	}
+}
+
+func TestSignatureHelp_None(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		pos  protocol.Position
+	}{
+		{"on the type", "a.com {\n\t@m header\n}\n", pos(1, 10)},
+		{"directive", "a.com {\n\treverse_proxy \n}\n", pos(1, 15)},
+		{"unknown type", "a.com {\n\t@m nope \n}\n", pos(1, 9)},
+		{"expression shorthand", "a.com {\n\t@m `{path} == '/'` \n}\n", pos(1, 21)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if label, _, ok := signatureHelp(t, tc.src, tc.pos); ok {
+				t.Errorf("want no signature help, got %q", label)
+			}
+		})
+	}
+}
+
+func TestMatcherForms_CoverMatcherDocs(t *testing.T) {
+	for name := range matcherDocs {
+		if _, ok := analysis.MatcherForms(name); !ok {
+			t.Errorf("matcher %q is documented but has no argument forms", name)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"os"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// snippetComment returns the documentation of the snippet name in f,
+// parsed from src: the comment block directly above its definition. It
+// reports whether f defines the snippet.
+func snippetComment(src string, f *parser.File, name string) (string, bool) {
+	for _, sb := range f.SiteBlocks {
+		if len(sb.Addresses) > 0 && sb.Addresses[0].Value == "("+name+")" {
+			return parser.LeadingComment(src, sb.Addresses[0].Line), true
+		}
+	}
+	return "", false
+}
+
+// importedSnippetComment returns the documentation of the imported snippet
+// s, read from the file defining it.
+func (h *Handler) importedSnippetComment(s importedSnippet) string {
+	src, err := os.ReadFile(s.Path)
+	if err != nil {
+		return ""
+	}
+	f, ok := h.index.Load(s.Path)
+	if !ok {
+		return ""
+	}
+	doc, _ := snippetComment(string(src), f, s.Name)
+	return doc
+}
+
+// snippetHoverAt returns the documentation of the snippet named by the
+// `import` line under pos, taken from the comment above its definition in
+// the document or in a file it imports. Snippets without such a comment
+// have none.
+func (h *Handler) snippetHoverAt(uri, content string, f *parser.File, pos protocol.Position) (string, bool) {
+	name, ok := snippetImportAt(f, pos)
+	if !ok {
+		return "", false
+	}
+	if doc, defined := snippetComment(content, f, name); defined {
+		return "**`(" + name + ")`** — snippet\n\n" + doc, doc != ""
+	}
+	for _, s := range h.importedSnippets(uri, f) {
+		if s.Name != name {
+			continue
+		}
+		if doc := h.importedSnippetComment(s); doc != "" {
+			return "**`(" + name + ")`** — snippet from `" + relativeTo(uri, s.Path) + "`\n\n" + doc, true
+		}
+		break
+	}
+	return "", false
+}
+
+// snippetImportAt returns the snippet name of the `import` line whose first
+// argument is under pos. File imports are not snippets.
+func snippetImportAt(f *parser.File, pos protocol.Position) (string, bool) {
+	at := func(d *parser.Directive) (string, bool) {
+		if d.Name.Value != "import" || len(d.Args) == 0 || !tokenContains(d.Args[0].Token, pos) {
+			return "", false
+		}
+		name := d.Args[0].Token.Value
+		return name, !analysis.IsFileImport(name)
+	}
+	var walk func(ds []*parser.Directive) (string, bool)
+	walk = func(ds []*parser.Directive) (string, bool) {
+		for _, d := range ds {
+			if name, ok := at(d); ok {
+				return name, true
+			}
+			if name, ok := walk(d.Body); ok {
+				return name, true
+			}
+		}
+		return "", false
+	}
+	if name, ok := walk(f.Imports); ok {
+		return name, true
+	}
+	if f.GlobalBlock != nil {
+		if name, ok := walk(f.GlobalBlock.Directives); ok {
+			return name, true
+		}
+	}
+	for _, sb := range f.SiteBlocks {
+		if name, ok := walk(sb.Directives); ok {
+			return name, true
+		}
+	}
+	return "", false
+}
@@ -0,0 +1,65 @@
+package handler
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestHover_SnippetDocs(t *testing.T) {
+	dir := t.TempDir()
+	shared := filepath.Join(dir, "snippets", "tls.caddy")
+	if err := os.MkdirAll(filepath.Dir(shared), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(shared, []byte("# Internal TLS for staging.\n(tls_internal) {\n\ttls internal\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	uri := workspace.PathToURI(filepath.Join(dir, "Caddyfile"))
+	src := "import snippets/*.caddy\n\n# Proxies to the app.\n# Takes the upstream port.\n(proxy) {\n\treverse_proxy app:{args[0]}\n}\n\n(bare) {\n}\n\nexample.com {\n\timport proxy 8080\n\timport tls_internal\n\timport bare\n}\n"
+	store := document.New()
+	store.Open(uri, src, 1)
+	h := New(store)
+	hover := func(p protocol.Position) string {
+		got, err := h.Hover(nil, &protocol.HoverParams{TextDocumentPositionParams: protocol.TextDocumentPositionParams{
+			TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+			Position:     p,
+		}})
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got == nil {
+			return ""
+		}
+		return got.Contents.(protocol.MarkupContent).Value
+	}
+	if got := hover(pos(12, 10)); !strings.HasPrefix(got, "**`(proxy)`** — snippet") || !strings.Contains(got, "Proxies to the app.\nTakes the upstream port.") {
+		t.Errorf("local snippet: got %q", got)
+	}
+	if got := hover(pos(13, 10)); !strings.Contains(got, "snippet from `"+filepath.Join("snippets", "tls.caddy")+"`") || !strings.Contains(got, "Internal TLS for staging.") {
+		t.Errorf("imported snippet: got %q", got)
+	}
+	if got := hover(pos(14, 10)); strings.Contains(got, "snippet") {
+		t.Errorf("undocumented snippet: want no snippet docs, got %q", got)
+	}
+}
+
+func TestSnippetCompletions_Documentation(t *testing.T) {
+	src := "# Proxies to the app.\n(proxy) {\n}\n(bare) {\n}\n"
+	docs := make(map[string]any)
+	for _, item := range snippetCompletions(src, parseAST(src), "") {
+		docs[item.Label] = item.Documentation
+	}
+	if docs["proxy"] != "Proxies to the app." {
+		t.Errorf("proxy: documentation %v", docs["proxy"])
+	}
+	if docs["bare"] != nil {
+		t.Errorf("bare: want no documentation, got %v", docs["bare"])
+	}
+}
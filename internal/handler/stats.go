@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"sort"
+
+	"github.com/tliron/glsp"
+)
+
+// StatsResult is returned by caddyls.stats: the counts of every file
+// together, and of each file on its own.
+type StatsResult struct {
+	analysis.Stats
+	Files []FileStats `json:"files"`
+}
+
+// FileStats are the counts of one file.
+type FileStats struct {
+	URI string `json:"uri"`
+	analysis.Stats
+}
+
+// statsCommand implements caddyls.stats. Given a document URI, it counts
+// the directives, site blocks, snippets, named matchers and imports of that
+// document; without arguments, those of every Caddyfile in the workspace
+// index and every open document. Open documents are counted as edited, not
+// as saved.
+func (h *Handler) statsCommand(ctx *glsp.Context, args []any) (any, error) {
+	files := map[string]*parser.File{}
+	if len(args) > 0 {
+		uri, err := uriArgument(args)
+		if err != nil {
+			return nil, err
+		}
+		f, ok := h.parsedFile(uri)
+		if !ok {
+			return nil, fmt.Errorf("document %s is neither open nor indexed", uri)
+		}
+		files[uri] = f
+	} else {
+		for _, uri := range append(h.index.URIs(), h.store.URIs()...) {
+			if f, ok := h.parsedFile(uri); ok {
+				files[uri] = f
+			}
+		}
+	}
+
+	result := StatsResult{Stats: analysis.Stats{DirectiveCounts: map[string]int{}}, Files: []FileStats{}}
+	for uri, f := range files {
+		s := analysis.CollectStats(f)
+		result.Add(s)
+		result.Files = append(result.Files, FileStats{URI: uri, Stats: s})
+	}
+	sort.Slice(result.Files, func(i, j int) bool { return result.Files[i].URI < result.Files[j].URI })
+	return result, nil
+}
+
+// parsedFile returns the parsed document at uri: the open buffer when
+// there is one, and otherwise the indexed file.
+func (h *Handler) parsedFile(uri string) (*parser.File, bool) {
+	if content, ok := h.store.Get(uri); ok {
+		f, _ := parser.Parse(content)
+		return f, true
+	}
+	return h.index.File(uri)
+}
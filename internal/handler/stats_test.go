@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestStatsCommand(t *testing.T) {
+	store := document.New()
+	h := New(store)
+	indexed, _ := parser.Parse("a.example.com {\n\tfile_server\n}\n")
+	h.index.Set("file:///srv/a.caddyfile", indexed)
+	// The open buffer wins over the indexed copy of the same file.
+	stale, _ := parser.Parse("b.example.com {\n}\n")
+	h.index.Set("file:///srv/b.caddyfile", stale)
+	store.Open("file:///srv/b.caddyfile", "(common) {\n\tencode\n}\n\nb.example.com {\n\timport common\n\t@static path /static/*\n\tfile_server @static\n}\n", 1)
+
+	got, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.stats"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	r := got.(StatsResult)
+	if r.SiteBlocks != 2 || r.Snippets != 1 || r.Directives != 3 || r.Matchers != 1 || r.Imports != 1 || r.DirectiveCounts["file_server"] != 2 {
+		t.Errorf("workspace totals = %+v", r.Stats)
+	}
+	if len(r.Files) != 2 || r.Files[0].URI != "file:///srv/a.caddyfile" || r.Files[1].Directives != 2 {
+		t.Errorf("files = %+v", r.Files)
+	}
+
+	got, err = h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.stats", Arguments: []any{"file:///srv/a.caddyfile"}})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if r := got.(StatsResult); len(r.Files) != 1 || r.Directives != 1 {
+		t.Errorf("document stats = %+v", r)
+	}
+
+	uri := workspace.PathToURI("/nowhere/Caddyfile")
+	if _, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.stats", Arguments: []any{uri}}); err == nil {
+		t.Error("want an error for a document that is neither open nor indexed")
+	}
+}
@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/tliron/glsp"
+)
+
+// MethodStatus is the custom request answered by Status.
+const MethodStatus = "caddyls/status"
+
+// Status is the result of caddyls/status, for status bar indicators and for
+// debugging slow responses.
+type Status struct {
+	Version string       `json:"version"`
+	Schema  SchemaStatus `json:"schema"`
+	// IndexedFiles counts the Caddyfiles parsed from the workspace and
+	// from imports.
+	IndexedFiles int         `json:"indexedFiles"`
+	Caches       CacheStatus `json:"caches"`
+	// Analyses hold the last analysis of each open document, most recent
+	// first.
+	Analyses []AnalysisTime `json:"analyses"`
+}
+
+// SchemaStatus describes the schema in use.
+type SchemaStatus struct {
+	// Version counts schema rebuilds, so that a client can tell when the
+	// settings or caddyls.reloadSchema changed the schema.
+	Version int `json:"version"`
+	// CaddyVersion is the caddyVersion initialization option.
+	CaddyVersion string `json:"caddyVersion,omitempty"`
+	// SchemaFile and CaddyModules report whether those layers are loaded.
+	SchemaFile   bool `json:"schemaFile"`
+	CaddyModules bool `json:"caddyModules"`
+	Problems     int  `json:"problems"`
+}
+
+// CacheStatus counts the entries the server holds in memory.
+type CacheStatus struct {
+	OpenDocuments int `json:"openDocuments"`
+	Validations   int `json:"validations"`
+}
+
+// AnalysisTime is how long the last analysis of an open document took.
+type AnalysisTime struct {
+	URI        string    `json:"uri"`
+	Version    int32     `json:"version"`
+	DurationMs float64   `json:"durationMs"`
+	At         time.Time `json:"at"`
+}
+
+// analysisTimes records the last analysis of each document.
+type analysisTimes struct {
+	mu    sync.Mutex
+	times map[string]AnalysisTime
+}
+
+func (a *analysisTimes) record(uri string, version int32, start time.Time) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if a.times == nil {
+		a.times = make(map[string]AnalysisTime)
+	}
+	a.times[uri] = AnalysisTime{
+		URI:        uri,
+		Version:    version,
+		DurationMs: float64(time.Since(start).Microseconds()) / 1000,
+		At:         start,
+	}
+}
+
+func (a *analysisTimes) forget(uri string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	delete(a.times, uri)
+}
+
+// list returns the recorded times, most recent first.
+func (a *analysisTimes) list() []AnalysisTime {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	list := make([]AnalysisTime, 0, len(a.times))
+	for _, t := range a.times {
+		list = append(list, t)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].At.After(list[j].At) })
+	return list
+}
+
+// Status handles the caddyls/status custom request, which takes no
+// parameters.
+func (h *Handler) Status(ctx *glsp.Context) (Status, error) {
+	h.validations.mu.Lock()
+	validations := len(h.validations.results)
+	h.validations.mu.Unlock()
+	return Status{
+		Version: version,
+		Schema: SchemaStatus{
+			Version:      h.schemaVersion,
+			CaddyVersion: h.init.CaddyVersion,
+			SchemaFile:   h.schemaFile != nil,
+			CaddyModules: h.modules != nil,
+			Problems:     len(h.currentSchema().Problems()),
+		},
+		IndexedFiles: h.index.Len(),
+		Caches: CacheStatus{
+			OpenDocuments: len(h.store.URIs()),
+			Validations:   validations,
+		},
+		Analyses: h.analysisTimes.list(),
+	}, nil
+}
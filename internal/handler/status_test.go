@@ -0,0 +1,42 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestStatus(t *testing.T) {
+	var p recordingPublisher
+	h := NewWithPublisher(document.New(), &p)
+	if _, err := h.Initialize(nil, &protocol.InitializeParams{
+		InitializationOptions: map[string]any{"caddyVersion": "2.8"},
+	}); err != nil {
+		t.Fatal(err)
+	}
+	for i, uri := range []protocol.DocumentUri{"file:///a/Caddyfile", "file:///b/Caddyfile"} {
+		if err := h.DidOpen(nil, &protocol.DidOpenTextDocumentParams{
+			TextDocument: protocol.TextDocumentItem{URI: uri, Text: "a.com {\n\trespond ok\n}\n", Version: int32(i + 1)},
+		}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := h.DidClose(nil, &protocol.DidCloseTextDocumentParams{TextDocument: protocol.TextDocumentIdentifier{URI: "file:///a/Caddyfile"}}); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.reloadSchema"}); err != nil {
+		t.Fatal(err)
+	}
+
+	s, err := h.Status(nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if s.Version != version || s.Schema.Version != 2 || s.Schema.CaddyVersion != "2.8" || s.Schema.SchemaFile || s.Schema.CaddyModules {
+		t.Errorf("got %+v", s)
+	}
+	if s.Caches.OpenDocuments != 1 || len(s.Analyses) != 1 || s.Analyses[0].URI != "file:///b/Caddyfile" || s.Analyses[0].Version != 2 {
+		t.Errorf("want only the open document, got caches %+v and analyses %+v", s.Caches, s.Analyses)
+	}
+}
@@ -0,0 +1,99 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// subdirectiveAt returns the body directive whose name token is under pos,
+// together with the chain of directives enclosing it, outermost first. ok is
+// false when pos is not on a subdirective name, including lines directly
+// inside a site block or a routing container such as handle.
+func subdirectiveAt(f *parser.File, pos protocol.Position) (sub *parser.Directive, parents []*parser.Directive, ok bool) {
+	parents = enclosingDirectives(f, pos)
+	if len(parents) == 0 || containerDirectives[parents[len(parents)-1].Name.Value] {
+		return nil, nil, false
+	}
+	for _, d := range parents[len(parents)-1].Body {
+		if tokenContains(d.Name, pos) {
+			return d, parents, true
+		}
+	}
+	return nil, nil, false
+}
+
+// lookupSubdirectiveDoc documents the subdirective name inside parents,
+// innermost parent last, from docs. Options of a module block such as
+// `transport http`, and of client_auth and its trust pools, have their own
+// entries. Otherwise a dedicated entry is
+// preferred unless name is also a site-level directive, whose docs would
+// describe something else (such as `method` or `rewrite` inside
+// reverse_proxy). Otherwise the syntax lines for name are taken from the
+// nearest parent's documentation.
+func lookupSubdirectiveDoc(docs DocProvider, name string, parents []*parser.Directive) (string, bool) {
+	if doc, ok := lookupSubSubdirectiveDoc(name, parents[len(parents)-1]); ok {
+		return doc, true
+	}
+	if doc, ok := lookupClientAuthDoc(name, parents); ok {
+		return doc, true
+	}
+	if !analysis.KnownTopLevel[name] {
+		if doc, ok := docs.Directive(name); ok {
+			return doc, true
+		}
+	}
+	for i := len(parents) - 1; i >= 0; i-- {
+		parent := parents[i].Name.Value
+		doc, ok := docs.Directive(parent)
+		if !ok {
+			continue
+		}
+		if syntax, ok := syntaxLinesFor(doc, name); ok {
+			return "**`" + name + "`** in `" + parent + "`\n\n```\n" + syntax + "\n```", true
+		}
+	}
+	return docs.Directive(name)
+}
+
+// syntaxLinesFor extracts the lines describing name from the first code
+// block of doc: the line starting with name and, when it opens a block, the
+// lines up to the matching "}". Common indentation is removed.
+func syntaxLinesFor(doc, name string) (string, bool) {
+	_, rest, ok := strings.Cut(doc, "```\n")
+	if !ok {
+		return "", false
+	}
+	block, _, _ := strings.Cut(rest, "```")
+	lines := strings.Split(block, "\n")
+	// Skip the first line: it is the parent directive's own syntax.
+	for i := 1; i < len(lines); i++ {
+		trimmed := strings.TrimLeft(lines[i], " \t")
+		word, _, _ := strings.Cut(trimmed, " ")
+		word, _, _ = strings.Cut(word, "\t")
+		if word != name {
+			continue
+		}
+		end := i + 1
+		if strings.HasSuffix(strings.TrimSpace(trimmed), "{") {
+			depth := 1
+			for ; end < len(lines) && depth > 0; end++ {
+				depth += strings.Count(lines[end], "{") - strings.Count(lines[end], "}")
+			}
+		}
+		return dedent(lines[i:end]), true
+	}
+	return "", false
+}
+
+// dedent removes the indentation of the first line from every line.
+func dedent(lines []string) string {
+	indent := lines[0][:len(lines[0])-len(strings.TrimLeft(lines[0], " \t"))]
+	out := make([]string, len(lines))
+	for i, l := range lines {
+		out[i] = strings.TrimPrefix(l, indent)
+	}
+	return strings.Join(out, "\n")
+}
@@ -0,0 +1,53 @@
+package handler
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestSyntaxLinesFor(t *testing.T) {
+	doc := "```\nreverse_proxy [<matcher>] [<upstreams...>] {\n    to      <upstreams...>\n    health_headers {\n        <field> [<values...>]\n    }\n    transport <name> {\n        ...\n    }\n}\n```\nMore text."
+	if got, ok := syntaxLinesFor(doc, "to"); !ok || got != "to      <upstreams...>" {
+		t.Errorf("to: got %q (ok=%v)", got, ok)
+	}
+	want := "health_headers {\n    <field> [<values...>]\n}"
+	if got, ok := syntaxLinesFor(doc, "health_headers"); !ok || got != want {
+		t.Errorf("health_headers: got %q, want %q", got, want)
+	}
+	if _, ok := syntaxLinesFor(doc, "reverse_proxy"); ok {
+		t.Error("the parent's own syntax line must not match")
+	}
+	if _, ok := syntaxLinesFor(doc, "header_up"); ok {
+		t.Error("absent subdirective: want not found")
+	}
+}
+
+func TestLookupSubdirectiveDoc(t *testing.T) {
+	src := "example.com {\n\treverse_proxy app:8080 {\n\t\theader_up Host {host}\n\t\tmethod GET\n\t\tlb_policy cookie\n\t\ttransport http {\n\t\t\ttls_timeout 10s\n\t\t}\n\t}\n\thandle {\n\t\trewrite /x\n\t}\n}\n"
+	f := parseAST(src)
+
+	doc := func(line, char uint32) string {
+		t.Helper()
+		sub, parents, ok := subdirectiveAt(f, pos(line, char))
+		if !ok {
+			t.Fatalf("(%d,%d): no subdirective found", line, char)
+		}
+		d, _ := lookupSubdirectiveDoc(builtinDocs{}, sub.Name.Value, parents)
+		return d
+	}
+	if got := doc(2, 4); !strings.HasPrefix(got, "**`header_up`** in `reverse_proxy`") || !strings.Contains(got, "header_up   [+|-]<field>") {
+		t.Errorf("header_up: got %q", got)
+	}
+	if got := doc(3, 3); !strings.Contains(got, "in `reverse_proxy`") || !strings.Contains(got, "method <method>") {
+		t.Errorf("method inside reverse_proxy should use the parent's syntax, got %q", got)
+	}
+	if got := doc(4, 4); got != directiveDocs["lb_policy"] {
+		t.Errorf("lb_policy has a dedicated entry, got %q", got)
+	}
+	if got := doc(6, 5); !strings.Contains(got, "in `transport http`") || !strings.Contains(got, "tls_timeout <duration>") {
+		t.Errorf("tls_timeout inside transport http: got %q", got)
+	}
+	if _, _, ok := subdirectiveAt(f, pos(10, 4)); ok {
+		t.Error("directive inside handle is site-level, not a subdirective")
+	}
+}
@@ -1,6 +1,8 @@
 package handler
 
 import (
+	"caddy-ls/pkg/caddyfile/parser"
+
 	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
@@ -8,9 +10,10 @@ import (
 // DidOpen handles textDocument/didOpen.
 func (h *Handler) DidOpen(ctx *glsp.Context, params *protocol.DidOpenTextDocumentParams) error {
 	uri := string(params.TextDocument.URI)
-	text := params.TextDocument.Text
-	h.store.Open(uri, text)
-	h.Analyze(ctx, uri, text)
+	text := parser.StripBOM(params.TextDocument.Text)
+	version := params.TextDocument.Version
+	h.store.Open(uri, text, version)
+	h.Analyze(ctx, uri, text, version)
 	return nil
 }
 
@@ -29,26 +32,27 @@ func (h *Handler) DidChange(ctx *glsp.Context, params *protocol.DidChangeTextDoc
 	case protocol.TextDocumentContentChangeEventWhole:
 		text = c.Text
 	}
-	h.store.Update(uri, text)
-	h.Analyze(ctx, uri, text)
+	text = parser.StripBOM(text)
+	version := params.TextDocument.Version
+	h.store.Update(uri, text, version)
+	h.Analyze(ctx, uri, text, version)
+	h.reanalyzeImporters(ctx, uri, text)
 	return nil
 }
 
 // DidSave handles textDocument/didSave.
 func (h *Handler) DidSave(ctx *glsp.Context, params *protocol.DidSaveTextDocumentParams) error {
 	uri := string(params.TextDocument.URI)
-	var text string
+	// Saving does not change the version.
+	text, version, ok := h.store.Snapshot(uri)
 	if params.Text != nil {
-		text = *params.Text
-		h.store.Update(uri, text)
-	} else {
-		var ok bool
-		text, ok = h.store.Get(uri)
-		if !ok {
-			return nil
-		}
+		text = parser.StripBOM(*params.Text)
+		h.store.Update(uri, text, version)
+	} else if !ok {
+		return nil
 	}
-	h.Analyze(ctx, uri, text)
+	h.Analyze(ctx, uri, text, version)
+	h.reanalyzeImporters(ctx, uri, text)
 	return nil
 }
 
@@ -56,5 +60,6 @@ func (h *Handler) DidSave(ctx *glsp.Context, params *protocol.DidSaveTextDocumen
 func (h *Handler) DidClose(ctx *glsp.Context, params *protocol.DidCloseTextDocumentParams) error {
 	uri := string(params.TextDocument.URI)
 	h.store.Close(uri)
+	h.analysisTimes.forget(uri)
 	return nil
 }
@@ -0,0 +1,100 @@
+package handler
+
+import "caddy-ls/pkg/caddyfile/parser"
+
+// subSubdirectiveDocs documents the options inside the body of a
+// subdirective that takes a module name, keyed like the analyzer's
+// sub-subdirective sets ("transport:http"). The generated docs only cover
+// the block's syntax, and the same option name means different things in
+// different modules (`dial_timeout` in http and fastcgi, `root` in
+// fastcgi and as a directive).
+var subSubdirectiveDocs = map[string]map[string]string{
+	"transport:http": {
+		"read_buffer": "```\nread_buffer <size>\n```\n\nSize of the buffer used to read from the upstream, e.g. `8KiB`. Default `4KiB`.",
+
+		"write_buffer": "```\nwrite_buffer <size>\n```\n\nSize of the buffer used to write to the upstream, e.g. `8KiB`. Default `4KiB`.",
+
+		"max_response_header": "```\nmax_response_header <size>\n```\n\nMaximum size of the upstream's response headers. Default `10MiB`.",
+
+		"proxy_protocol": "```\nproxy_protocol v1|v2\n```\n\nSends a PROXY protocol header of the given version to the upstream, so that it sees the client's address rather than Caddy's.",
+
+		"network_proxy": "```\nnetwork_proxy <module> {\n    ...\n}\n```\n\nConnects to the upstream through a forward proxy. `url <url>` names the proxy, `none` ignores the `HTTP_PROXY` family of environment variables, which are used by default.",
+
+		"dial_timeout": "```\ndial_timeout <duration>\n```\n\nHow long to wait for a connection to the upstream to be established. Default `3s`.",
+
+		"dial_fallback_delay": "```\ndial_fallback_delay <duration>\n```\n\nHow long to wait before trying the other IP family when connecting (RFC 6555 Fast Fallback). A negative value disables it. Default `300ms`.",
+
+		"response_header_timeout": "```\nresponse_header_timeout <duration>\n```\n\nHow long to wait for the upstream's response headers after the request is written. No timeout by default.",
+
+		"expect_continue_timeout": "```\nexpect_continue_timeout <duration>\n```\n\nHow long to wait for the upstream's first response headers before sending the body of a request with `Expect: 100-continue`. No timeout by default.",
+
+		"read_timeout": "```\nread_timeout <duration>\n```\n\nHow long to wait for the next read from the upstream. No timeout by default.",
+
+		"write_timeout": "```\nwrite_timeout <duration>\n```\n\nHow long to wait for the next write to the upstream. No timeout by default.",
+
+		"resolvers": "```\nresolvers <resolvers...>\n```\n\nDNS resolvers used to look up upstream host names instead of the system's, e.g. `1.1.1.1`.",
+
+		"tls": "```\ntls\n```\n\nConnects to the upstream over HTTPS. Implied by `https://` upstream addresses and by any other `tls_*` option.",
+
+		"tls_client_auth": "```\ntls_client_auth <automate_name> | <cert_file> <key_file>\n```\n\nPresents a client certificate to the upstream: either the certificate Caddy manages for `<automate_name>`, or the given certificate and key files.",
+
+		"tls_insecure_skip_verify": "```\ntls_insecure_skip_verify\n```\n\nTurns off verification of the upstream's certificate. The connection is then open to man-in-the-middle attacks; prefer `tls_trust_pool` for private CAs.",
+
+		"tls_curves": "```\ntls_curves <curves...>\n```\n\nElliptic curves offered in the TLS handshake with the upstream, e.g. `x25519 secp256r1`.",
+
+		"tls_timeout": "```\ntls_timeout <duration>\n```\n\nHow long to wait for the TLS handshake with the upstream to complete. No timeout by default.",
+
+		"tls_trust_pool": "```\ntls_trust_pool <module> {\n    ...\n}\n```\n\nCertificate authorities trusted for the upstream's certificate, e.g. `file <pem_files...>` or `pki_root <ca_name>`, instead of the system roots.",
+
+		"tls_server_name": "```\ntls_server_name <sni>\n```\n\nServer name sent in the TLS handshake (SNI) and checked against the upstream's certificate. Placeholders are allowed, e.g. `{http.request.host}`.",
+
+		"tls_renegotiation": "```\ntls_renegotiation never|once|freely\n```\n\nWhether the upstream may request TLS renegotiation. Default `never`.",
+
+		"tls_except_ports": "```\ntls_except_ports <ports...>\n```\n\nPorts on which the upstream is contacted without TLS even though TLS is enabled, useful with dynamic upstreams.",
+
+		"keepalive": "```\nkeepalive [off|<duration>]\n```\n\nHow long idle connections to the upstream are kept open, or `off` to close them after each request. Default `2m`.",
+
+		"keepalive_interval": "```\nkeepalive_interval <interval>\n```\n\nInterval between TCP keep-alive probes on upstream connections. Default `30s`.",
+
+		"keepalive_idle_conns": "```\nkeepalive_idle_conns <max_count>\n```\n\nMaximum number of idle connections kept across all upstreams. No limit by default.",
+
+		"keepalive_idle_conns_per_host": "```\nkeepalive_idle_conns_per_host <count>\n```\n\nMaximum number of idle connections kept per upstream. Default `32`.",
+
+		"versions": "```\nversions <versions...>\n```\n\nHTTP versions to use with the upstream: `1.1`, `2`, `h2c` or `3`. Default `1.1 2`; `h2c` is needed for cleartext HTTP/2, such as gRPC without TLS.",
+
+		"compression": "```\ncompression off\n```\n\nStops Caddy from asking the upstream for a gzip-compressed response, which it would otherwise decompress before relaying.",
+
+		"max_conns_per_host": "```\nmax_conns_per_host <count>\n```\n\nMaximum number of connections per upstream, counting those being dialed, active and idle. No limit by default.",
+	},
+	"transport:fastcgi": {
+		"root": "```\nroot <path>\n```\n\nSite root passed to the FastCGI server, used to build `SCRIPT_FILENAME`. Defaults to the `root` directive's value, or the current directory.",
+
+		"split": "```\nsplit <substrings...>\n```\n\nWhere to split the request path into the script name and `PATH_INFO`, e.g. `.php`.",
+
+		"env": "```\nenv <key> <value>\n```\n\nSets an extra environment variable for the FastCGI server. May be repeated.",
+
+		"resolve_root_symlink": "```\nresolve_root_symlink\n```\n\nResolves the root directory when it is a symbolic link, so that `SCRIPT_FILENAME` names the real path.",
+
+		"dial_timeout": "```\ndial_timeout <duration>\n```\n\nHow long to wait for a connection to the FastCGI server to be established. Default `3s`.",
+
+		"read_timeout": "```\nread_timeout <duration>\n```\n\nHow long to wait for the next read from the FastCGI server. No timeout by default.",
+
+		"write_timeout": "```\nwrite_timeout <duration>\n```\n\nHow long to wait for the next write to the FastCGI server. No timeout by default.",
+
+		"capture_stderr": "```\ncapture_stderr\n```\n\nLogs what the FastCGI server writes to stderr: as warnings, or as errors when the response status is 4xx or 5xx.",
+	},
+}
+
+// lookupSubSubdirectiveDoc documents name inside the body of parent, a
+// subdirective such as `transport http`.
+func lookupSubSubdirectiveDoc(name string, parent *parser.Directive) (string, bool) {
+	if len(parent.Args) == 0 {
+		return "", false
+	}
+	module := parent.Args[0].Token.Value
+	doc, ok := subSubdirectiveDocs[parent.Name.Value+":"+module][name]
+	if !ok {
+		return "", false
+	}
+	return "**`" + name + "`** in `" + parent.Name.Value + " " + module + "`\n\n" + doc, true
+}
@@ -0,0 +1,107 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"strings"
+	"testing"
+)
+
+func TestSubSubdirectiveDocs_CoverSchema(t *testing.T) {
+	for key, docs := range subSubdirectiveDocs {
+		names, ok := analysis.SubSubDirectivesFor(key)
+		if !ok {
+			t.Errorf("%s: no such sub-subdirective set", key)
+			continue
+		}
+		for name := range names {
+			if docs[name] == "" {
+				t.Errorf("%s: %s is undocumented", key, name)
+			}
+		}
+		for name := range docs {
+			if !names[name] {
+				t.Errorf("%s: documented %s is not a valid option", key, name)
+			}
+		}
+	}
+}
+
+func TestLookupSubdirectiveDoc_TransportModules(t *testing.T) {
+	src := "example.com {\n\treverse_proxy app:8080 {\n\t\ttransport http {\n\t\t\tkeepalive_idle_conns_per_host 8\n\t\t\tdial_timeout 5s\n\t\t\ttls\n\t\t}\n\t}\n\treverse_proxy php:9000 {\n\t\ttransport fastcgi {\n\t\t\tdial_timeout 5s\n\t\t\troot /srv\n\t\t}\n\t}\n}\n"
+	f := parseAST(src)
+	doc := func(line, char uint32) string {
+		t.Helper()
+		sub, parents, ok := subdirectiveAt(f, pos(line, char))
+		if !ok {
+			t.Fatalf("(%d,%d): no subdirective found", line, char)
+		}
+		d, _ := lookupSubdirectiveDoc(builtinDocs{}, sub.Name.Value, parents)
+		return d
+	}
+	if got := doc(3, 5); !strings.HasPrefix(got, "**`keepalive_idle_conns_per_host`** in `transport http`") {
+		t.Errorf("keepalive_idle_conns_per_host: got %q", got)
+	}
+	if got := doc(5, 3); !strings.Contains(got, "HTTPS") {
+		t.Errorf("tls inside transport http should not show the tls directive, got %q", got)
+	}
+	http, fastcgi := doc(4, 4), doc(10, 4)
+	if !strings.Contains(http, "in `transport http`") || !strings.Contains(fastcgi, "in `transport fastcgi`") || http == fastcgi {
+		t.Errorf("dial_timeout should be documented per transport:\nhttp:    %q\nfastcgi: %q", http, fastcgi)
+	}
+	if got := doc(11, 4); !strings.Contains(got, "SCRIPT_FILENAME") {
+		t.Errorf("root inside transport fastcgi should not show the root directive, got %q", got)
+	}
+}
+
+func TestClientAuthDocs_CoverSchema(t *testing.T) {
+	keys := []string{"client_auth", "trust_pool http>tls"}
+	for _, provider := range analysis.TrustPoolProviders() {
+		keys = append(keys, "trust_pool "+provider)
+	}
+	if len(clientAuthDocs) != len(keys) {
+		t.Errorf("documented %d blocks, want %v", len(clientAuthDocs), keys)
+	}
+	for _, key := range keys {
+		names, ok := analysis.ClientAuthOptionsFor(key)
+		if !ok {
+			t.Errorf("%s: no such block", key)
+			continue
+		}
+		for name := range names {
+			if clientAuthDocs[key][name] == "" {
+				t.Errorf("%s: %s is undocumented", key, name)
+			}
+		}
+		for name := range clientAuthDocs[key] {
+			if !names[name] {
+				t.Errorf("%s: documented %s is not a valid option", key, name)
+			}
+		}
+	}
+}
+
+func TestLookupSubdirectiveDoc_ClientAuth(t *testing.T) {
+	src := "example.com {\n\ttls {\n\t\tca https://acme.example.com\n\t\tclient_auth {\n\t\t\tmode require_and_verify\n\t\t\ttrust_pool http {\n\t\t\t\ttls {\n\t\t\t\t\tca file root.pem\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t}\n}\n"
+	f := parseAST(src)
+	doc := func(line, char uint32) string {
+		t.Helper()
+		sub, parents, ok := subdirectiveAt(f, pos(line, char))
+		if !ok {
+			t.Fatalf("(%d,%d): no subdirective found", line, char)
+		}
+		d, _ := lookupSubdirectiveDoc(builtinDocs{}, sub.Name.Value, parents)
+		return d
+	}
+	if got := doc(4, 4); !strings.HasPrefix(got, "**`mode`** in `client_auth`") || !strings.Contains(got, "do not verify") {
+		t.Errorf("mode: got %q", got)
+	}
+	if got := doc(6, 5); !strings.HasPrefix(got, "**`tls`** in `trust_pool http`") {
+		t.Errorf("tls of an http trust pool: got %q", got)
+	}
+	if got := doc(7, 6); !strings.HasPrefix(got, "**`ca`** in `trust_pool http tls`") {
+		t.Errorf("ca of an http trust pool: got %q", got)
+	}
+	if got := doc(2, 3); strings.Contains(got, "trust pool") {
+		t.Errorf("the ACME ca of tls should keep its own docs, got %q", got)
+	}
+}
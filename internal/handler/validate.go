@@ -0,0 +1,206 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"caddy-ls/internal/workspace"
+	"caddy-ls/pkg/caddyfile/parser"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// validateTimeout bounds a single `caddy validate` run.
+const validateTimeout = 30 * time.Second
+
+// ValidateSettings configures the opt-in caddyls.validateWithCaddy command.
+type ValidateSettings struct {
+	// Enabled allows the command to run the caddy binary.
+	Enabled bool `json:"enabled"`
+	// Binary is the caddy executable; "caddy" from PATH when empty.
+	Binary string `json:"binary"`
+}
+
+// validations holds the diagnostics from the last caddy validate run per
+// document, tagged with the document version they apply to, so they can be
+// published alongside the built-in diagnostics until the next edit.
+type validations struct {
+	mu      sync.Mutex
+	results map[string]validation
+}
+
+type validation struct {
+	version int32
+	diags   []protocol.Diagnostic
+}
+
+func (v *validations) set(uri string, version int32, diags []protocol.Diagnostic) {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if v.results == nil {
+		v.results = make(map[string]validation)
+	}
+	v.results[uri] = validation{version: version, diags: diags}
+}
+
+// get returns the diagnostics recorded for version of uri; results for any
+// other version are stale and dropped.
+func (v *validations) get(uri string, version int32) []protocol.Diagnostic {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	r, ok := v.results[uri]
+	if !ok {
+		return nil
+	}
+	if r.version != version {
+		delete(v.results, uri)
+		return nil
+	}
+	return r.diags
+}
+
+// validateWithCaddyCommand implements caddyls.validateWithCaddy: it starts
+// `caddy validate` on the current buffer of the document given as the first
+// argument and returns. The run can take up to validateTimeout, so it goes
+// on in the background, like a workspace scan, and publishes any error caddy
+// reports with the other diagnostics when done. Only a missing binary is
+// reported to the caller; later failures are logged.
+func (h *Handler) validateWithCaddyCommand(ctx *glsp.Context, args []any) (any, error) {
+	if !h.settings.Validate.Enabled {
+		return nil, fmt.Errorf("validation with caddy is disabled (setting: caddy.validate.enabled)")
+	}
+	uri, err := uriArgument(args)
+	if err != nil {
+		return nil, err
+	}
+	content, version, ok := h.store.Snapshot(uri)
+	if !ok {
+		return nil, fmt.Errorf("document %s is not open", uri)
+	}
+
+	binary := h.caddyBinary()
+	if binary == "" {
+		binary = "caddy"
+	}
+	if _, err := exec.LookPath(binary); err != nil {
+		return nil, fmt.Errorf("running %s validate: %w", binary, err)
+	}
+
+	go func() {
+		runCtx, cancel := context.WithTimeout(context.Background(), validateTimeout)
+		defer cancel()
+		diag, err := runCaddyValidate(runCtx, binary, uri, content)
+		if err != nil {
+			log.Warningf("validating %s with caddy: %v", uri, err)
+			return
+		}
+		var diags []protocol.Diagnostic
+		if diag != nil {
+			diags = append(diags, *diag)
+		}
+		h.validations.set(uri, version, diags)
+		h.Analyze(ctx, uri, content, version)
+	}()
+	return nil, nil
+}
+
+// runCaddyValidate writes content to a temporary file and runs
+// `caddy validate --adapter caddyfile` on it. It returns nil when caddy
+// accepts the config and a diagnostic describing the failure otherwise. err
+// is only set when caddy could not be run at all.
+//
+// The copy goes to os.TempDir(), unless the document imports files by a
+// relative path: Caddy resolves those against the directory of the
+// importing file, so the copy is then written next to the document, with a
+// hidden name, and falls back to os.TempDir() when that directory is
+// read-only.
+func runCaddyValidate(ctx context.Context, binary, uri, content string) (*protocol.Diagnostic, error) {
+	dir := os.TempDir()
+	if path, ok := workspace.URIToPath(uri); ok {
+		if f, _ := parser.Parse(content); workspace.HasRelativeImports(f) {
+			dir = filepath.Dir(path)
+		}
+	}
+	tmp, err := os.CreateTemp(dir, ".caddy-ls-validate-*.caddyfile")
+	if err != nil && dir != os.TempDir() {
+		tmp, err = os.CreateTemp("", ".caddy-ls-validate-*.caddyfile")
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer os.Remove(tmp.Name())
+	if _, err := tmp.WriteString(content); err != nil {
+		tmp.Close()
+		return nil, err
+	}
+	if err := tmp.Close(); err != nil {
+		return nil, err
+	}
+
+	cmd := exec.CommandContext(ctx, binary, "validate", "--config", tmp.Name(), "--adapter", "caddyfile")
+	var output bytes.Buffer
+	cmd.Stdout = &output
+	cmd.Stderr = &output
+	err = cmd.Run()
+	if err == nil {
+		return nil, nil
+	}
+	if _, exited := err.(*exec.ExitError); !exited || ctx.Err() != nil {
+		return nil, fmt.Errorf("running %s validate: %w", binary, err)
+	}
+	diag := caddyErrorDiagnostic(output.String(), tmp.Name())
+	return &diag, nil
+}
+
+// caddyErrorLocation matches the "<file>:<line>" Caddy appends to Caddyfile
+// errors, e.g. "..., at /srv/Caddyfile:12".
+var caddyErrorLocation = regexp.MustCompile(`(\S+?):(\d+)\b`)
+
+// caddyErrorDiagnostic turns the output of a failed `caddy validate` into a
+// diagnostic. The message is the last "Error:" line, or the last line of
+// output. It is placed on the reported line when that refers to file, the
+// validated copy of the document, and on the first line otherwise.
+func caddyErrorDiagnostic(output, file string) protocol.Diagnostic {
+	var message string
+	lines := strings.Split(strings.TrimSpace(output), "\n")
+	for i := len(lines) - 1; i >= 0 && message == ""; i-- {
+		if rest, ok := strings.CutPrefix(strings.TrimSpace(lines[i]), "Error: "); ok {
+			message = rest
+		}
+	}
+	if message == "" {
+		message = strings.TrimSpace(lines[len(lines)-1])
+	}
+
+	var line uint32
+	for _, m := range caddyErrorLocation.FindAllStringSubmatch(message, -1) {
+		if m[1] != file {
+			continue
+		}
+		if n, err := strconv.Atoi(m[2]); err == nil && n > 0 {
+			line = uint32(n - 1)
+		}
+		message = strings.ReplaceAll(message, file, "this file")
+		break
+	}
+	severity := protocol.DiagnosticSeverityError
+	return protocol.Diagnostic{
+		Range: protocol.Range{
+			Start: protocol.Position{Line: line},
+			End:   protocol.Position{Line: line + 1},
+		},
+		Severity: &severity,
+		Source:   strPtr("caddy"),
+		Message:  message,
+	}
+}
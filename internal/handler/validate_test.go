@@ -0,0 +1,160 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"caddy-ls/internal/workspace"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestCaddyErrorDiagnostic(t *testing.T) {
+	file := "/srv/.caddy-ls-validate-123.caddyfile"
+	output := `{"level":"info","msg":"using config from file","file":"` + file + `"}
+Error: adapting config using caddyfile: parsing caddyfile tokens for 'reverse_proxy': unrecognized subdirective 'lb_polcy', at ` + file + `:7 import chain ['']
+`
+	d := caddyErrorDiagnostic(output, file)
+	if d.Range.Start.Line != 6 || d.Range.End.Line != 7 {
+		t.Errorf("range = %v, want line 6", d.Range)
+	}
+	if !strings.HasPrefix(d.Message, "adapting config using caddyfile") || !strings.Contains(d.Message, "at this file:7") {
+		t.Errorf("message = %q", d.Message)
+	}
+	if d.Source == nil || *d.Source != "caddy" {
+		t.Errorf("source = %v, want caddy", d.Source)
+	}
+
+	other := caddyErrorDiagnostic("Error: loading module: at /etc/caddy/common.conf:3\n", file)
+	if other.Range.Start.Line != 0 || !strings.Contains(other.Message, "/etc/caddy/common.conf:3") {
+		t.Errorf("error in another file: want line 0 with the path kept, got %+v", other)
+	}
+	if got := caddyErrorDiagnostic("exit status 1\n", file); got.Message != "exit status 1" {
+		t.Errorf("no Error: line: got %q", got.Message)
+	}
+}
+
+// fakeCaddy writes a script standing in for the caddy binary that records
+// the path of the config it is given in the file named after itself plus
+// ".config", then fails with output when the config contains "bad", and
+// succeeds otherwise.
+func fakeCaddy(t *testing.T, output string) string {
+	t.Helper()
+	if runtime.GOOS == "windows" {
+		t.Skip("fake caddy binary is a shell script")
+	}
+	path := filepath.Join(t.TempDir(), "caddy")
+	script := "#!/bin/sh\necho \"$3\" > \"$0.config\"\nif grep -q bad \"$3\"; then echo \"" + output + "$3:2\" >&2; exit 1; fi\nexit 0\n"
+	if err := os.WriteFile(path, []byte(script), 0o755); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestValidateWithCaddy(t *testing.T) {
+	dir := t.TempDir()
+	uri := workspace.PathToURI(filepath.Join(dir, "Caddyfile"))
+	binary := fakeCaddy(t, "Error: bad directive, at ")
+	h := New(document.New())
+	h.settings.Validate = ValidateSettings{Enabled: true, Binary: binary}
+	published := make(chan protocol.PublishDiagnosticsParams, 4)
+	ctx := &glsp.Context{Notify: func(_ string, params any) {
+		if p, ok := params.(protocol.PublishDiagnosticsParams); ok {
+			published <- p
+		}
+	}}
+	next := func() []protocol.Diagnostic {
+		t.Helper()
+		select {
+		case p := <-published:
+			return p.Diagnostics
+		case <-time.After(10 * time.Second):
+			t.Fatal("no diagnostics published")
+			return nil
+		}
+	}
+	// validate runs the command, which returns before caddy is done, and
+	// returns the caddy diagnostics published once it is, and the directory
+	// caddy was given the copy of the document in.
+	validate := func() ([]protocol.Diagnostic, string) {
+		t.Helper()
+		got, err := h.ExecuteCommand(ctx, &protocol.ExecuteCommandParams{Command: "caddyls.validateWithCaddy", Arguments: []any{uri}})
+		if err != nil || got != nil {
+			t.Fatalf("command = %v, %v; want it to return at once", got, err)
+		}
+		var caddy []protocol.Diagnostic
+		for _, d := range next() {
+			if d.Source != nil && *d.Source == "caddy" {
+				caddy = append(caddy, d)
+			}
+		}
+		config, err := os.ReadFile(binary + ".config")
+		if err != nil {
+			t.Fatal(err)
+		}
+		return caddy, filepath.Dir(strings.TrimSpace(string(config)))
+	}
+
+	h.store.Open(uri, "example.com {\n\tbad\n}\n", 1)
+	diags, copyDir := validate()
+	if len(diags) != 1 || diags[0].Range.Start.Line != 1 || !strings.HasPrefix(diags[0].Message, "bad directive") {
+		t.Errorf("want caddy diagnostic on line 1 published, got %+v", diags)
+	}
+	if copyDir != filepath.Clean(os.TempDir()) {
+		t.Errorf("copy written to %s, want the temporary directory", copyDir)
+	}
+
+	// The next edit makes the result stale.
+	h.store.Update(uri, "example.com {\n\trespond ok\n}\n", 2)
+	h.Analyze(ctx, uri, "example.com {\n\trespond ok\n}\n", 2)
+	for _, d := range next() {
+		if d.Source != nil && *d.Source == "caddy" {
+			t.Errorf("stale caddy diagnostic still published: %+v", d)
+		}
+	}
+	if diags, _ := validate(); len(diags) != 0 {
+		t.Errorf("valid config: got %+v", diags)
+	}
+
+	// Relative imports resolve against the document's directory, so the
+	// copy is written there.
+	h.store.Update(uri, "import ./common.conf\nexample.com {\n\tbad\n}\n", 3)
+	diags, copyDir = validate()
+	if len(diags) != 1 || copyDir != dir {
+		t.Errorf("relative import: got %+v from a copy in %s, want one diagnostic from a copy in %s", diags, copyDir, dir)
+	}
+	entries, _ := os.ReadDir(dir)
+	if len(entries) != 0 {
+		t.Errorf("temporary copy left behind: %v", entries)
+	}
+}
+
+func TestValidateWithCaddy_Errors(t *testing.T) {
+	h := New(document.New())
+	cmd := func(args ...any) error {
+		_, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.validateWithCaddy", Arguments: args})
+		return err
+	}
+	if err := cmd("file:///Caddyfile"); err == nil || !strings.Contains(err.Error(), "caddy.validate.enabled") {
+		t.Errorf("disabled: got %v", err)
+	}
+	h.settings.Validate = ValidateSettings{Enabled: true, Binary: filepath.Join(t.TempDir(), "no-caddy")}
+	if err := cmd(); err == nil {
+		t.Error("missing argument: want error")
+	}
+	if err := cmd("file:///closed"); err == nil || !strings.Contains(err.Error(), "not open") {
+		t.Errorf("closed document: got %v", err)
+	}
+	h.store.Open("file:///tmp/Caddyfile", "example.com {\n}\n", 1)
+	if err := cmd("file:///tmp/Caddyfile"); err == nil || !strings.Contains(err.Error(), "running") {
+		t.Errorf("missing binary: got %v", err)
+	}
+	if _, err := h.ExecuteCommand(nil, &protocol.ExecuteCommandParams{Command: "caddyls.nope"}); err == nil {
+		t.Error("unknown command: want error")
+	}
+}
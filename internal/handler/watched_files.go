@@ -0,0 +1,73 @@
+package handler
+
+import (
+	"caddy-ls/internal/workspace"
+	"path/filepath"
+	"slices"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// fileWatchersID identifies the registration of the server's file watchers.
+const fileWatchersID = "caddy-ls/watched-files"
+
+// fileWatchers returns the files whose changes the server acts on: the
+// project configuration files and the files named like the env files of
+// the settings at the time.
+func (h *Handler) fileWatchers() []protocol.FileSystemWatcher {
+	watchers := []protocol.FileSystemWatcher{{GlobPattern: "**/.caddy-ls.{json,yaml,yml,toml}"}}
+	var names []string
+	for _, path := range envFiles(h.settings.Env, h.roots) {
+		names = append(names, filepath.Base(path))
+	}
+	slices.Sort(names)
+	for _, name := range slices.Compact(names) {
+		watchers = append(watchers, protocol.FileSystemWatcher{GlobPattern: "**/" + name})
+	}
+	return watchers
+}
+
+// registerFileWatchers asks the client to report changes to the files of
+// fileWatchers, when it can register watchers. The client's reply is only
+// read once the handler returns, so the request is sent from a goroutine of
+// its own.
+func (h *Handler) registerFileWatchers(ctx *glsp.Context) {
+	if !h.client.watchFiles {
+		return
+	}
+	params := protocol.RegistrationParams{Registrations: []protocol.Registration{{
+		ID:              fileWatchersID,
+		Method:          string(protocol.MethodWorkspaceDidChangeWatchedFiles),
+		RegisterOptions: protocol.DidChangeWatchedFilesRegistrationOptions{Watchers: h.fileWatchers()},
+	}}}
+	go ctx.Call(protocol.ServerClientRegisterCapability, params, nil)
+}
+
+// DidChangeWatchedFiles handles workspace/didChangeWatchedFiles. Project
+// configuration files and env files that changed are read again, and the
+// open documents analyzed again with them.
+func (h *Handler) DidChangeWatchedFiles(ctx *glsp.Context, params *protocol.DidChangeWatchedFilesParams) error {
+	envPaths := envFiles(h.settings.Env, h.roots)
+	configs, envChanged := false, false
+	for _, change := range params.Changes {
+		path, ok := workspace.URIToPath(string(change.URI))
+		if !ok {
+			continue
+		}
+		if slices.Contains(projectConfigNames, filepath.Base(path)) {
+			h.projectConfigs.invalidate(path)
+			configs = true
+		}
+		if slices.Contains(envPaths, path) {
+			envChanged = true
+		}
+	}
+	if envChanged {
+		h.rebuildEnv()
+	}
+	if configs || envChanged {
+		h.reanalyze(ctx, h.store.URIs())
+	}
+	return nil
+}
@@ -0,0 +1,145 @@
+package handler
+
+import (
+	"caddy-ls/internal/workspace"
+	"context"
+	"encoding/json"
+	"fmt"
+	"runtime/debug"
+	"sync"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// indexProgressToken prefixes the progress tokens of workspace scans, which
+// identify a scan in progress notifications and in
+// window/workDoneProgress/cancel requests. Every scan gets a token of its
+// own, so that a scan that was cancelled and one started after it never
+// share one.
+const indexProgressToken = "caddy-ls/index"
+
+// workspaceScans tracks the latest workspace scan.
+type workspaceScans struct {
+	mu sync.Mutex
+	// generation counts the scans started, numbering their tokens.
+	generation int
+	latest     *workspaceScan
+}
+
+// workspaceScan is one scan of the workspace.
+type workspaceScan struct {
+	token string
+	// done is closed when the scan has stopped.
+	done chan struct{}
+}
+
+// key returns the operations key of the scan's progress token.
+func (s *workspaceScan) key() string {
+	raw, _ := json.Marshal(s.token)
+	return operationKey(raw)
+}
+
+// workspaceRoots extracts the file system roots to index from the initialize
+// params, preferring workspace folders over the deprecated root URI/path.
+func workspaceRoots(params *protocol.InitializeParams) []string {
+	var roots []string
+	for _, folder := range params.WorkspaceFolders {
+		if path, ok := workspace.URIToPath(folder.URI); ok {
+			roots = append(roots, path)
+		}
+	}
+	if len(roots) > 0 {
+		return roots
+	}
+	if params.RootURI != nil {
+		if path, ok := workspace.URIToPath(*params.RootURI); ok {
+			return []string{path}
+		}
+	}
+	if params.RootPath != nil && *params.RootPath != "" {
+		return []string{*params.RootPath}
+	}
+	return nil
+}
+
+// scanWorkspace cancels the scan in progress, if any, and starts scanning
+// the workspace roots for the files matching patterns on a goroutine of its
+// own. With reset, the index is emptied first, once the cancelled scan has
+// stopped, so that none of the files it finds land in the new index. It is
+// called on the handler goroutine, which must not wait for the scan: the
+// scan calls back into the client.
+func (h *Handler) scanWorkspace(ctx *glsp.Context, patterns workspace.Patterns, reset bool) {
+	h.scans.mu.Lock()
+	prev := h.scans.latest
+	h.scans.generation++
+	scan := &workspaceScan{token: fmt.Sprintf("%s/%d", indexProgressToken, h.scans.generation), done: make(chan struct{})}
+	h.scans.latest = scan
+	h.scans.mu.Unlock()
+
+	// The scan is registered before it starts so that a cancellation can
+	// never miss it.
+	opCtx, done := h.ops.start(scan.key())
+	if prev != nil {
+		h.ops.cancel(prev.key())
+	}
+	go func() {
+		defer close(scan.done)
+		defer done()
+		if prev != nil {
+			<-prev.done
+		}
+		if reset {
+			h.index.Reset()
+		}
+		h.indexWorkspace(ctx, opCtx, scan.token, patterns)
+	}()
+}
+
+// indexWorkspace scans the workspace roots for the Caddyfiles matching
+// patterns, reporting progress to the client under token. It can be
+// cancelled through window/workDoneProgress/cancel or $/cancelRequest with
+// the token, which cancels opCtx.
+func (h *Handler) indexWorkspace(ctx *glsp.Context, opCtx context.Context, token string, patterns workspace.Patterns) {
+	defer func() {
+		if p := recover(); p != nil {
+			log.Errorf("recovered from panic while indexing workspace: %v\n%s", p, debug.Stack())
+		}
+	}()
+	if len(h.roots) == 0 || opCtx.Err() != nil {
+		return
+	}
+
+	p := h.newProgress(ctx, token)
+	p.begin("Indexing Caddyfiles", true)
+	err := h.index.Scan(opCtx, h.roots, patterns, func(n, total int) {
+		p.report(fmt.Sprintf("%d/%d", n, total), uint32(n*100/total))
+	})
+	if err != nil {
+		p.end("Indexing cancelled")
+		log.Infof("workspace indexing stopped: %v", err)
+		return
+	}
+	p.end(fmt.Sprintf("Indexed %d Caddyfiles", h.index.Len()))
+}
+
+// reindexWorkspace cancels a scan in progress, empties the index and scans
+// the workspace again, for when the files that count as Caddyfiles change.
+func (h *Handler) reindexWorkspace(ctx *glsp.Context) {
+	h.scanWorkspace(ctx, h.settings.filePatterns(), true)
+}
+
+// WorkDoneProgressCancel handles window/workDoneProgress/cancel.
+//
+// Like CancelRequest, the token is read from the raw params because glsp's
+// IntegerOrString cannot unmarshal into itself.
+func (h *Handler) WorkDoneProgressCancel(ctx *glsp.Context, _ *protocol.WorkDoneProgressCancelParams) error {
+	var params struct {
+		Token json.RawMessage `json:"token"`
+	}
+	if err := json.Unmarshal(ctx.Params, &params); err != nil || len(params.Token) == 0 {
+		return nil
+	}
+	h.ops.cancel(operationKey(params.Token))
+	return nil
+}
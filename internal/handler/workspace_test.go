@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/tliron/glsp"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// waitForScan blocks until the latest workspace scan has stopped.
+func waitForScan(h *Handler) {
+	h.scans.mu.Lock()
+	scan := h.scans.latest
+	h.scans.mu.Unlock()
+	if scan != nil {
+		<-scan.done
+	}
+}
+
+func TestReindexWorkspace_FilePatternsChangedDuringScan(t *testing.T) {
+	root := t.TempDir()
+	for i := range 200 {
+		for _, name := range []string{fmt.Sprintf("%d.caddyfile", i), fmt.Sprintf("%d.conf", i)} {
+			if err := os.WriteFile(filepath.Join(root, name), []byte("example.com {\n}\n"), 0o644); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+	h := New(document.New())
+	h.roots = []string{root}
+	ctx := &glsp.Context{Notify: func(string, any) {}, Call: func(string, any, any) {}}
+
+	h.scanWorkspace(ctx, h.settings.filePatterns(), false)
+	h.DidChangeConfiguration(ctx, &protocol.DidChangeConfigurationParams{
+		Settings: map[string]any{"caddy": map[string]any{"filePatterns": []any{"*.conf"}}},
+	})
+	waitForScan(h)
+
+	uris := h.index.URIs()
+	if len(uris) != 200 {
+		t.Errorf("indexed %d files, want the 200 matching the new patterns", len(uris))
+	}
+	for _, uri := range uris {
+		if !strings.HasSuffix(uri, ".conf") {
+			t.Errorf("%s indexed after the patterns changed", uri)
+		}
+	}
+}
+
+func TestScanWorkspace_TokenPerScan(t *testing.T) {
+	h := New(document.New())
+	h.roots = []string{t.TempDir()}
+	ctx := &glsp.Context{Notify: func(string, any) {}, Call: func(string, any, any) {}}
+
+	h.scanWorkspace(ctx, nil, false)
+	first := h.scans.latest
+	h.scanWorkspace(ctx, nil, true)
+	second := h.scans.latest
+	if first.token == second.token {
+		t.Fatalf("both scans use token %q", first.token)
+	}
+	<-first.done
+	if h.ops.cancel(first.key()) {
+		t.Error("the first scan should be unregistered once cancelled")
+	}
+	waitForScan(h)
+}
@@ -0,0 +1,183 @@
+package handler
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// wrapContainers are the blocks selected directives can be wrapped in.
+var wrapContainers = []string{"handle", "route"}
+
+// wrapActions returns refactorings that wrap the directives selected by rng
+// in a `handle` or `route` block. The block gets the matcher every selected
+// directive shares, which is then removed from them, or else `/*`, which
+// matches every request so the config keeps its meaning until the matcher
+// is narrowed. Nothing is offered for an empty selection, for directives
+// that are not siblings in a site block or routing container, or when the
+// selection holds a named matcher definition, whose scope would shrink.
+func wrapActions(uri protocol.DocumentUri, content string, f *parser.File, rng protocol.Range) []protocol.CodeAction {
+	if rng.Start == rng.End {
+		return nil
+	}
+	block, braces, depth := routeBlockAt(f, rng)
+	if block == nil {
+		return nil
+	}
+	var selected []*parser.Directive
+	for _, d := range block {
+		if d.BodyContains(rng.Start) && d.BodyContains(rng.End) {
+			return nil // subdirectives, not directives
+		}
+		if d.EndLine >= rng.Start.Line && d.StartLine <= rng.End.Line {
+			selected = append(selected, d)
+		}
+	}
+	if len(selected) == 0 {
+		return nil
+	}
+	first, last := selected[0], selected[len(selected)-1]
+	if braces.LBrace.Line >= first.StartLine || (braces.RBrace != nil && braces.RBrace.Line <= last.EndLine) {
+		return nil
+	}
+	for _, d := range selected {
+		if strings.HasPrefix(d.Name.Value, "@") {
+			return nil
+		}
+	}
+	if spansMultilineString(content, first.StartLine, last.EndLine) {
+		return nil
+	}
+
+	lines := strings.Split(content, "\n")
+	if int(last.EndLine) >= len(lines) {
+		return nil
+	}
+	eol := "\n"
+	if strings.HasSuffix(lines[first.StartLine], "\r") {
+		eol = "\r\n"
+	}
+	indent := leadingSpace(lines[first.StartLine])
+	unit := indentUnit(indent, depth)
+
+	matcher := sharedMatcher(selected)
+	body := make([]string, 0, last.EndLine-first.StartLine+1)
+	for i := first.StartLine; i <= last.EndLine; i++ {
+		line := lines[i]
+		if i == last.EndLine {
+			line = strings.TrimSuffix(line, "\r")
+		}
+		if strings.TrimSpace(line) != "" {
+			line = unit + line
+		}
+		body = append(body, line)
+	}
+	if matcher != "" {
+		for _, d := range selected {
+			tok := d.Args[0].Token
+			i := d.Name.Line - first.StartLine
+			start := int(tok.Char) + len(unit)
+			end := start + len(tok.Value)
+			end += len(body[i][end:]) - len(strings.TrimLeft(body[i][end:], " \t"))
+			body[i] = body[i][:start] + body[i][end:]
+		}
+	}
+	display := matcher
+	if display == "" {
+		display = "/*"
+	}
+
+	end := protocol.Position{Line: last.EndLine, Character: parser.UTF16Len(strings.TrimSuffix(lines[last.EndLine], "\r"))}
+	edit := protocol.Range{Start: protocol.Position{Line: first.StartLine}, End: end}
+	kind := protocol.CodeActionKindRefactorRewrite
+	var actions []protocol.CodeAction
+	for _, container := range wrapContainers {
+		text := indent + container + " " + display + " {" + eol + strings.Join(body, "\n") + eol + indent + "}"
+		actions = append(actions, protocol.CodeAction{
+			Title: fmt.Sprintf("Wrap in %s %s { … }", container, display),
+			Kind:  &kind,
+			Edit: &protocol.WorkspaceEdit{
+				Changes: map[protocol.DocumentUri][]protocol.TextEdit{uri: {{Range: edit, NewText: text}}},
+			},
+		})
+	}
+	return actions
+}
+
+// routeBlockAt returns the innermost site block or routing container body
+// holding all of rng, with its braces and its nesting depth (1 for a site
+// block).
+func routeBlockAt(f *parser.File, rng protocol.Range) ([]*parser.Directive, *parser.Braces, int) {
+	for _, sb := range f.SiteBlocks {
+		if !sb.BodyContains(rng.Start) || !sb.BodyContains(rng.End) {
+			continue
+		}
+		block, braces, depth := sb.Directives, &sb.Braces, 1
+		for {
+			var inner *parser.Directive
+			for _, d := range block {
+				if containerDirectives[d.Name.Value] && d.BodyContains(rng.Start) && d.BodyContains(rng.End) {
+					inner = d
+					break
+				}
+			}
+			if inner == nil {
+				return block, braces, depth
+			}
+			block, braces, depth = inner.Body, &inner.Braces, depth+1
+		}
+	}
+	return nil, nil, 0
+}
+
+// sharedMatcher returns the path or named matcher that is the first argument
+// of every directive in ds, or "" when they do not all share one.
+func sharedMatcher(ds []*parser.Directive) string {
+	var m string
+	for _, d := range ds {
+		if len(d.Args) == 0 {
+			return ""
+		}
+		v := d.Args[0].Token.Value
+		if !strings.HasPrefix(v, "/") && !strings.HasPrefix(v, "@") {
+			return ""
+		}
+		if m != "" && v != m {
+			return ""
+		}
+		m = v
+	}
+	return m
+}
+
+// spansMultilineString reports whether a quoted string starting between
+// lines from and to continues onto a later line, whose text re-indenting
+// would change.
+func spansMultilineString(content string, from, to uint32) bool {
+	for _, tok := range parser.Tokenize(content) {
+		if tok.Type != parser.STRING || tok.Line < from || tok.Line > to || strings.HasPrefix(tok.Value, "<<") {
+			continue
+		}
+		q := tok.Value[:1]
+		if len(tok.Value) < 2 || !strings.HasSuffix(tok.Value, q) {
+			return true
+		}
+	}
+	return false
+}
+
+// leadingSpace returns the indentation of line.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// indentUnit guesses one level of indentation from indent, the indentation
+// of a line depth levels deep: a tab unless the file indents with spaces.
+func indentUnit(indent string, depth int) string {
+	if indent == "" || strings.Contains(indent, "\t") || depth == 0 || len(indent)%depth != 0 {
+		return "\t"
+	}
+	return indent[:len(indent)/depth]
+}
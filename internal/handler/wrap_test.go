@@ -0,0 +1,127 @@
+package handler
+
+import (
+	"caddy-ls/internal/document"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// wrapResults runs the code actions for the selection from..to in src and
+// returns each refactoring's title and the document it produces.
+func wrapResults(t *testing.T, src string, from, to protocol.Position) map[string]string {
+	t.Helper()
+	const uri = "file:///Caddyfile"
+	h := New(document.New())
+	h.store.Open(uri, src, 1)
+	got, err := h.CodeAction(nil, &protocol.CodeActionParams{
+		TextDocument: protocol.TextDocumentIdentifier{URI: uri},
+		Range:        protocol.Range{Start: from, End: to},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	results := make(map[string]string)
+	for _, a := range got.([]protocol.CodeAction) {
+		if a.Kind == nil || *a.Kind != protocol.CodeActionKindRefactorRewrite {
+			continue
+		}
+		edits := a.Edit.Changes[uri]
+		if len(edits) != 1 {
+			t.Fatalf("%s: want one edit, got %+v", a.Title, edits)
+		}
+		results[a.Title] = applyEdit(src, edits[0])
+	}
+	return results
+}
+
+// applyEdit applies e to src, whose positions are byte offsets.
+func applyEdit(src string, e protocol.TextEdit) string {
+	offset := func(p protocol.Position) int {
+		lines := strings.SplitAfter(src, "\n")
+		n := 0
+		for _, l := range lines[:p.Line] {
+			n += len(l)
+		}
+		return n + int(p.Character)
+	}
+	return src[:offset(e.Range.Start)] + e.NewText + src[offset(e.Range.End):]
+}
+
+func TestWrapActions(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		from, to protocol.Position
+		title    string
+		want     string
+	}{
+		{
+			name:  "default matcher",
+			src:   "a.com {\n\troot * /srv\n\tfile_server\n\tencode gzip\n}\n",
+			from:  pos(1, 1),
+			to:    pos(2, 5),
+			title: "Wrap in handle /* { … }",
+			want:  "a.com {\n\thandle /* {\n\t\troot * /srv\n\t\tfile_server\n\t}\n\tencode gzip\n}\n",
+		},
+		{
+			name:  "shared matcher moves to the block",
+			src:   "a.com {\n\treverse_proxy /api/* api:80\n\theader /api/* X-A 1\n}\n",
+			from:  pos(1, 0),
+			to:    pos(2, 3),
+			title: "Wrap in route /api/* { … }",
+			want:  "a.com {\n\troute /api/* {\n\t\treverse_proxy api:80\n\t\theader X-A 1\n\t}\n}\n",
+		},
+		{
+			name:  "multi-line directive and spaces",
+			src:   "a.com {\n  handle {\n    reverse_proxy app:80 {\n      lb_policy first\n    }\n  }\n}\n",
+			from:  pos(2, 4),
+			to:    pos(3, 8),
+			title: "Wrap in handle /* { … }",
+			want:  "a.com {\n  handle {\n    handle /* {\n      reverse_proxy app:80 {\n        lb_policy first\n      }\n    }\n  }\n}\n",
+		},
+		{
+			name:  "CRLF",
+			src:   "a.com {\r\n\trespond ok\r\n}\r\n",
+			from:  pos(1, 1),
+			to:    pos(1, 4),
+			title: "Wrap in handle /* { … }",
+			want:  "a.com {\r\n\thandle /* {\r\n\t\trespond ok\r\n\t}\r\n}\r\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			results := wrapResults(t, tc.src, tc.from, tc.to)
+			got, ok := results[tc.title]
+			if !ok {
+				t.Fatalf("no %q action, got %v", tc.title, results)
+			}
+			if got != tc.want {
+				t.Errorf("got\n%q\nwant\n%q", got, tc.want)
+			}
+		})
+	}
+}
+
+func TestWrapActions_NotOffered(t *testing.T) {
+	tests := []struct {
+		name     string
+		src      string
+		from, to protocol.Position
+	}{
+		{"empty selection", "a.com {\n\trespond ok\n}\n", pos(1, 2), pos(1, 2)},
+		{"subdirectives", "a.com {\n\treverse_proxy app:80 {\n\t\tlb_policy first\n\t}\n}\n", pos(2, 2), pos(2, 5)},
+		{"matcher definition", "a.com {\n\t@api path /api/*\n\trespond @api ok\n}\n", pos(1, 1), pos(2, 3)},
+		{"one-line block", "a.com { respond ok }\n", pos(0, 9), pos(0, 12)},
+		{"multi-line string", "a.com {\n\trespond \"a\nb\"\n}\n", pos(1, 1), pos(1, 3)},
+		{"global options", "{\n\tdebug\n}\n", pos(1, 1), pos(1, 3)},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			if results := wrapResults(t, tc.src, tc.from, tc.to); len(results) != 0 {
+				t.Errorf("want no refactorings, got %v", results)
+			}
+		})
+	}
+}
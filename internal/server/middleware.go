@@ -0,0 +1,110 @@
+package server
+
+import (
+	"encoding/json"
+	"runtime/debug"
+	"time"
+
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+)
+
+// recoverHandler wraps a glsp.Handler so that a panic raised while serving a
+// single message is logged and answered with an empty result instead of
+// tearing down the stdio connection.
+type recoverHandler struct {
+	next glsp.Handler
+	log  commonlog.Logger
+}
+
+// Handle implements glsp.Handler.
+func (h recoverHandler) Handle(ctx *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	defer func() {
+		if p := recover(); p != nil {
+			h.log.Errorf("recovered from panic in %s: %v\n%s", ctx.Method, p, debug.Stack())
+			r, validMethod, validParams, err = nil, true, true, nil
+		}
+	}()
+	return h.next.Handle(ctx)
+}
+
+// methodHandler serves the server's own requests, which protocol.Handler
+// does not know, and passes every other message on.
+type methodHandler struct {
+	next    glsp.Handler
+	methods map[string]func(ctx *glsp.Context) (any, error)
+}
+
+// Handle implements glsp.Handler.
+func (h methodHandler) Handle(ctx *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	if m, ok := h.methods[ctx.Method]; ok {
+		r, err = m(ctx)
+		return r, true, true, err
+	}
+	return h.next.Handle(ctx)
+}
+
+// traceHandler times every message. At debug level it logs the method, the
+// document it concerns, how long it took and the size of its result, and
+// it warns about messages taking longer than slow, to diagnose editor lag
+// without a profiler. A zero slow turns the warnings off.
+type traceHandler struct {
+	next glsp.Handler
+	log  commonlog.Logger
+	slow time.Duration
+	// now is time.Now, replaceable in tests.
+	now func() time.Time
+}
+
+// Handle implements glsp.Handler.
+func (h traceHandler) Handle(ctx *glsp.Context) (r any, validMethod bool, validParams bool, err error) {
+	now := h.now
+	if now == nil {
+		now = time.Now
+	}
+	debugging := h.log.AllowLevel(commonlog.Debug)
+	start := now()
+	r, validMethod, validParams, err = h.next.Handle(ctx)
+	elapsed := now().Sub(start)
+	slow := h.slow > 0 && elapsed > h.slow
+	if !debugging && !slow {
+		return
+	}
+
+	subject := traceSubject(ctx)
+	if debugging {
+		size := 0
+		if r != nil {
+			if data, err := json.Marshal(r); err == nil {
+				size = len(data)
+			}
+		}
+		h.log.Debugf("%s%s: %s, %d bytes", ctx.Method, subject, elapsed.Round(time.Microsecond), size)
+	}
+	if slow {
+		h.log.Warningf("slow request %s%s took %s (threshold %s)", ctx.Method, subject, elapsed.Round(time.Millisecond), h.slow)
+	}
+	return
+}
+
+// traceSubject names what the message ctx concerns for logging: the
+// document, or the command of workspace/executeCommand, preceded by a
+// space, or "".
+func traceSubject(ctx *glsp.Context) string {
+	var params struct {
+		TextDocument struct {
+			URI string `json:"uri"`
+		} `json:"textDocument"`
+		Command string `json:"command"`
+	}
+	if json.Unmarshal(ctx.Params, &params) != nil {
+		return ""
+	}
+	switch {
+	case params.TextDocument.URI != "":
+		return " " + params.TextDocument.URI
+	case params.Command != "":
+		return " " + params.Command
+	}
+	return ""
+}
@@ -0,0 +1,134 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/tliron/commonlog"
+	"github.com/tliron/glsp"
+)
+
+type panicHandler struct{}
+
+func (panicHandler) Handle(*glsp.Context) (any, bool, bool, error) {
+	panic("boom")
+}
+
+func TestRecoverHandler_PanicReturnsEmptyResult(t *testing.T) {
+	h := recoverHandler{next: panicHandler{}, log: commonlog.GetLogger("test")}
+	r, validMethod, validParams, err := h.Handle(&glsp.Context{Method: "textDocument/hover"})
+	if r != nil || err != nil {
+		t.Errorf("want nil result and error, got %v, %v", r, err)
+	}
+	if !validMethod || !validParams {
+		t.Errorf("want valid method and params, got %v, %v", validMethod, validParams)
+	}
+}
+
+type nextHandler struct{ called *bool }
+
+func (n nextHandler) Handle(*glsp.Context) (any, bool, bool, error) {
+	*n.called = true
+	return nil, false, false, nil
+}
+
+func TestMethodHandler_RoutesOwnMethods(t *testing.T) {
+	var called bool
+	h := methodHandler{
+		next: nextHandler{&called},
+		methods: map[string]func(*glsp.Context) (any, error){
+			"caddyls/status": func(*glsp.Context) (any, error) { return "ok", nil },
+		},
+	}
+	r, validMethod, validParams, err := h.Handle(&glsp.Context{Method: "caddyls/status"})
+	if r != "ok" || !validMethod || !validParams || err != nil || called {
+		t.Errorf("caddyls/status: got %v, %v, %v, %v (next called: %v)", r, validMethod, validParams, err, called)
+	}
+	if _, _, _, _ = h.Handle(&glsp.Context{Method: "textDocument/hover"}); !called {
+		t.Error("other methods should reach the next handler")
+	}
+}
+
+// recordingLogger keeps the debug and warning lines logged through it.
+type recordingLogger struct {
+	commonlog.MockLogger
+	debug bool
+	lines *[]string
+}
+
+func (l recordingLogger) AllowLevel(level commonlog.Level) bool {
+	return l.debug || level <= commonlog.Warning
+}
+
+func (l recordingLogger) Debugf(format string, args ...any) {
+	*l.lines = append(*l.lines, "debug: "+fmt.Sprintf(format, args...))
+}
+
+func (l recordingLogger) Warningf(format string, args ...any) {
+	*l.lines = append(*l.lines, "warning: "+fmt.Sprintf(format, args...))
+}
+
+type resultHandler struct{ result any }
+
+func (h resultHandler) Handle(*glsp.Context) (any, bool, bool, error) {
+	return h.result, true, true, nil
+}
+
+// fakeClock returns times step apart.
+func fakeClock(step time.Duration) func() time.Time {
+	t := time.Unix(0, 0)
+	return func() time.Time {
+		t = t.Add(step)
+		return t
+	}
+}
+
+func TestTraceHandler(t *testing.T) {
+	ctx := &glsp.Context{
+		Method: "textDocument/hover",
+		Params: json.RawMessage(`{"textDocument":{"uri":"file:///srv/Caddyfile"},"position":{"line":1,"character":2}}`),
+	}
+	for _, tc := range []struct {
+		name  string
+		debug bool
+		step  time.Duration
+		slow  time.Duration
+		want  []string
+	}{
+		{"quiet", false, 10 * time.Millisecond, time.Second, nil},
+		{"debug", true, 10 * time.Millisecond, time.Second, []string{"debug: textDocument/hover file:///srv/Caddyfile: 10ms, 9 bytes"}},
+		{"slow", false, 2 * time.Second, time.Second, []string{"warning: slow request textDocument/hover file:///srv/Caddyfile took 2s (threshold 1s)"}},
+		{"no threshold", false, 2 * time.Second, 0, nil},
+	} {
+		var lines []string
+		h := traceHandler{
+			next: resultHandler{map[string]string{"a": "b"}},
+			log:  recordingLogger{debug: tc.debug, lines: &lines},
+			slow: tc.slow,
+			now:  fakeClock(tc.step),
+		}
+		if r, _, _, _ := h.Handle(ctx); r == nil {
+			t.Errorf("%s: the result should be passed on", tc.name)
+		}
+		if !slices.Equal(lines, tc.want) {
+			t.Errorf("%s: logged %q, want %q", tc.name, lines, tc.want)
+		}
+	}
+}
+
+func TestTraceSubject(t *testing.T) {
+	for params, want := range map[string]string{
+		`{"textDocument":{"uri":"file:///a"}}`:       " file:///a",
+		`{"command":"caddyls.stats","arguments":[]}`: " caddyls.stats",
+		`{"settings":{}}`:                            "",
+		`null`:                                       "",
+		`[1]`:                                        "",
+	} {
+		if got := traceSubject(&glsp.Context{Params: json.RawMessage(params)}); got != want {
+			t.Errorf("%s: got %q, want %q", params, got, want)
+		}
+	}
+}
@@ -0,0 +1,39 @@
+package server
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/tliron/commonlog"
+)
+
+// startPprof serves the net/http/pprof handlers on addr, e.g.
+// "localhost:6060", for as long as the process runs. The address is bound
+// before returning so that a port in use is reported up front; the bound
+// address is returned, which matters for port 0. Profiles expose internals,
+// so binding to anything but a loopback address is logged as a warning.
+func startPprof(addr string, log commonlog.Logger) (string, error) {
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return "", fmt.Errorf("pprof: %w", err)
+	}
+	if ip := ln.Addr().(*net.TCPAddr).IP; !ip.IsLoopback() {
+		log.Warningf("pprof is listening on %s, which is reachable from other hosts", ln.Addr())
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("/debug/pprof/", pprof.Index)
+	mux.HandleFunc("/debug/pprof/cmdline", pprof.Cmdline)
+	mux.HandleFunc("/debug/pprof/profile", pprof.Profile)
+	mux.HandleFunc("/debug/pprof/symbol", pprof.Symbol)
+	mux.HandleFunc("/debug/pprof/trace", pprof.Trace)
+	go func() {
+		if err := http.Serve(ln, mux); err != nil {
+			log.Errorf("pprof: %v", err)
+		}
+	}()
+	log.Noticef("pprof listening on http://%s/debug/pprof/", ln.Addr())
+	return ln.Addr().String(), nil
+}
@@ -3,49 +3,116 @@ package server
 import (
 	"caddy-ls/internal/document"
 	"caddy-ls/internal/handler"
+	"fmt"
+	"os"
+	"time"
 
 	"github.com/tliron/commonlog"
 	_ "github.com/tliron/commonlog/simple"
+	"github.com/tliron/glsp"
 	protocol "github.com/tliron/glsp/protocol_3_16"
 	glspServer "github.com/tliron/glsp/server"
 )
 
+// Config holds the command-line options that affect the server.
+type Config struct {
+	LogLevel string // debug, info, warning or error
+	LogFile  string // log destination; stderr when empty
+	// PprofAddr is where to serve net/http/pprof while the server runs;
+	// profiling is off when empty.
+	PprofAddr string
+	// SlowRequest is how long a message may take before a warning is
+	// logged; zero turns the warnings off.
+	SlowRequest time.Duration
+}
+
 // Run wires up the LSP handler and starts the server on stdio.
-func Run(logLevel string) error {
-	configureLogging(logLevel)
+func Run(cfg Config) error {
+	if err := configureLogging(cfg.LogLevel, cfg.LogFile); err != nil {
+		return err
+	}
+
+	log := commonlog.GetLogger("caddy-ls.server")
+	if cfg.PprofAddr != "" {
+		if _, err := startPprof(cfg.PprofAddr, log); err != nil {
+			return err
+		}
+	}
 
 	store := document.New()
 	h := handler.New(store)
 
 	lspHandler := protocol.Handler{
-		Initialize:             h.Initialize,
-		Initialized:            h.Initialized,
-		Shutdown:               h.Shutdown,
-		SetTrace:               h.SetTrace,
-		TextDocumentDidOpen:    h.DidOpen,
-		TextDocumentDidChange:  h.DidChange,
-		TextDocumentDidSave:    h.DidSave,
-		TextDocumentDidClose:   h.DidClose,
-		TextDocumentCompletion: h.Completion,
-		TextDocumentHover:      h.Hover,
+		CancelRequest:                   h.CancelRequest,
+		Initialize:                      h.Initialize,
+		Initialized:                     h.Initialized,
+		Shutdown:                        h.Shutdown,
+		SetTrace:                        h.SetTrace,
+		WorkspaceDidChangeConfiguration: h.DidChangeConfiguration,
+		WorkspaceDidChangeWatchedFiles:  h.DidChangeWatchedFiles,
+		WorkspaceExecuteCommand:         h.ExecuteCommand,
+		WindowWorkDoneProgressCancel:    h.WorkDoneProgressCancel,
+		TextDocumentDidOpen:             h.DidOpen,
+		TextDocumentDidChange:           h.DidChange,
+		TextDocumentDidSave:             h.DidSave,
+		TextDocumentDidClose:            h.DidClose,
+		TextDocumentCompletion:          h.Completion,
+		TextDocumentHover:               h.Hover,
+		TextDocumentSignatureHelp:       h.SignatureHelp,
+		TextDocumentCodeAction:          h.CodeAction,
+		TextDocumentDocumentHighlight:   h.DocumentHighlight,
+		TextDocumentSemanticTokensFull:  h.SemanticTokensFull,
+		TextDocumentFormatting:          h.DocumentFormatting,
 	}
 
-	s := glspServer.NewServer(&lspHandler, "caddy-ls", false)
+	s := glspServer.NewServer(traceHandler{
+		next: recoverHandler{
+			next: methodHandler{
+				next: &lspHandler,
+				methods: map[string]func(ctx *glsp.Context) (any, error){
+					handler.MethodStatus: func(ctx *glsp.Context) (any, error) { return h.Status(ctx) },
+				},
+			},
+			log: log,
+		},
+		log:  commonlog.GetLogger("caddy-ls.trace"),
+		slow: cfg.SlowRequest,
+	}, "caddy-ls", false)
 	return s.RunStdio()
 }
 
-func configureLogging(level string) {
-	// commonlog.Configure verbosity: 1=Error, 2=Warning, 3=Notice, 4=Info, 5=Debug
-	verbosity := 2 // Warning by default
+// logVerbosity maps a -log-level name to commonlog's verbosity scale
+// (-2=Error, -1=Warning, 0=Notice, 1=Info, 2=Debug).
+func logVerbosity(level string) (int, error) {
 	switch level {
 	case "debug":
-		verbosity = 5
+		return 2, nil
 	case "info":
-		verbosity = 4
+		return 1, nil
 	case "warning", "warn":
-		verbosity = 2
+		return -1, nil
 	case "error":
-		verbosity = 1
+		return -2, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", level)
+}
+
+func configureLogging(level, file string) error {
+	verbosity, err := logVerbosity(level)
+	if err != nil {
+		return err
+	}
+	if file == "" {
+		commonlog.Configure(verbosity, nil)
+		return nil
+	}
+	// The simple backend exits the process when it cannot open the file, so
+	// check it up front to report a regular error instead.
+	f, err := os.OpenFile(file, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o600)
+	if err != nil {
+		return fmt.Errorf("open log file: %w", err)
 	}
-	commonlog.Configure(verbosity, nil)
+	f.Close()
+	commonlog.Configure(verbosity, &file)
+	return nil
 }
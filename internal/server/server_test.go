@@ -0,0 +1,45 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	"github.com/tliron/commonlog"
+)
+
+func TestLogVerbosity(t *testing.T) {
+	for level, want := range map[string]int{
+		"debug":   2,
+		"info":    1,
+		"warning": -1,
+		"warn":    -1,
+		"error":   -2,
+	} {
+		got, err := logVerbosity(level)
+		if err != nil || got != want {
+			t.Errorf("logVerbosity(%q) = %d, %v; want %d", level, got, err, want)
+		}
+	}
+	if _, err := logVerbosity("loud"); err == nil {
+		t.Error("unknown level: want error")
+	}
+}
+
+func TestStartPprof(t *testing.T) {
+	addr, err := startPprof("127.0.0.1:0", commonlog.GetLogger("test"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp, err := http.Get("http://" + addr + "/debug/pprof/")
+	if err != nil {
+		t.Fatal(err)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		t.Errorf("GET /debug/pprof/: status %d", resp.StatusCode)
+	}
+
+	if _, err := startPprof(addr, commonlog.GetLogger("test")); err == nil {
+		t.Error("address in use: want error")
+	}
+}
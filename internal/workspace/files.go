@@ -0,0 +1,129 @@
+package workspace
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// FileOptions configures the checks that the files a Caddyfile names
+// exist.
+type FileOptions struct {
+	// Skip turns the checks off, for configs deployed to machines whose
+	// files are not at hand.
+	Skip bool
+	// DeployRoot is a directory mirroring the file system of the machine
+	// the config is deployed to: absolute paths are looked up below it.
+	DeployRoot string
+	// ReadFile reads the files imported inside blocks, so that unsaved
+	// edits can be taken into account. Nil means os.ReadFile.
+	ReadFile func(name string) ([]byte, error)
+}
+
+// readFile reads the file name with ReadFile, or os.ReadFile.
+func (o FileOptions) readFile(name string) ([]byte, error) {
+	if o.ReadFile != nil {
+		return o.ReadFile(name)
+	}
+	return os.ReadFile(name)
+}
+
+// Resolve returns where the path p, named in the file at from, is looked
+// up: below DeployRoot when p is absolute and one is set, and in the
+// directory of from, where Caddy is usually run, when p is relative.
+func (o FileOptions) Resolve(from, p string) string {
+	if filepath.IsAbs(p) {
+		if o.DeployRoot != "" {
+			return filepath.Join(o.DeployRoot, p)
+		}
+		return p
+	}
+	return filepath.Join(filepath.Dir(from), p)
+}
+
+// filePath is a path argument and what it must name.
+type filePath struct {
+	token parser.Token
+	// what describes the file in messages.
+	what string
+	dir  bool
+}
+
+// FileDiagnostics warns about the files and directories named by the tls
+// directives of f, the file at path, that do not exist: the certificate
+// and key of `tls <cert_file> <key_file>`, the directories of `load` and
+// the PEM file of `ca_root`. Paths with placeholders are skipped.
+func FileDiagnostics(path string, f *parser.File, opts FileOptions) []protocol.Diagnostic {
+	if opts.Skip {
+		return nil
+	}
+	var diags []protocol.Diagnostic
+	for _, sb := range f.SiteBlocks {
+		for _, d := range sb.Directives {
+			if d.Name.Value != "tls" {
+				continue
+			}
+			for _, p := range tlsPaths(d) {
+				if strings.Contains(p.token.Value, "{") {
+					continue
+				}
+				if problem := checkPath(opts.Resolve(path, p.token.Value), p); problem != "" {
+					severity := protocol.DiagnosticSeverityWarning
+					source := "caddy-ls"
+					diag := protocol.Diagnostic{
+						Range:    p.token.Range(),
+						Severity: &severity,
+						Source:   &source,
+						Message:  problem,
+					}
+					analysis.SetRuleCode(&diag, analysis.RuleMissingFile)
+					diags = append(diags, diag)
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// tlsPaths returns the path arguments of the tls directive d.
+func tlsPaths(d *parser.Directive) []filePath {
+	var paths []filePath
+	if len(d.Args) == 2 {
+		paths = append(paths,
+			filePath{token: d.Args[0].Token, what: "certificate file"},
+			filePath{token: d.Args[1].Token, what: "key file"})
+	}
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "load":
+			for _, arg := range sub.Args {
+				paths = append(paths, filePath{token: arg.Token, what: "certificate directory", dir: true})
+			}
+		case "ca_root":
+			if len(sub.Args) > 0 {
+				paths = append(paths, filePath{token: sub.Args[0].Token, what: "CA root certificate"})
+			}
+		}
+	}
+	return paths
+}
+
+// checkPath returns what is wrong with the file at resolved, which p
+// names, or "".
+func checkPath(resolved string, p filePath) string {
+	info, err := os.Stat(resolved)
+	switch {
+	case err != nil:
+		return fmt.Sprintf("%s not found: %s", p.what, p.token.Value)
+	case p.dir && !info.IsDir():
+		return fmt.Sprintf("%s %s is not a directory", p.what, p.token.Value)
+	case !p.dir && info.IsDir():
+		return fmt.Sprintf("%s %s is a directory", p.what, p.token.Value)
+	}
+	return ""
+}
@@ -0,0 +1,77 @@
+package workspace
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestFileDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"certs/site.pem":   "",
+		"certs/site.key":   "",
+		"certs/more/a.pem": "",
+		"ca.pem":           "",
+	})
+	src := "a.example.com {\n\ttls certs/site.pem certs/site.key\n}\n\n" +
+		"b.example.com {\n\ttls certs/other.pem certs/site.key {\n\t\tload certs/more certs/site.pem certs/gone\n\t\tca_root ca.pem\n\t}\n}\n\n" +
+		"c.example.com {\n\ttls {\n\t\tca_root certs\n\t\tload {$CERTS}\n\t}\n}\n"
+	f, _ := parser.Parse(src)
+	diags := FileDiagnostics(filepath.Join(dir, "Caddyfile"), f, FileOptions{})
+	want := []string{
+		"certificate file not found: certs/other.pem",
+		"certificate directory certs/site.pem is not a directory",
+		"certificate directory not found: certs/gone",
+		"CA root certificate certs is a directory",
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %d diagnostics, want %d: %v", len(diags), len(want), diags)
+	}
+	for i, w := range want {
+		if diags[i].Message != w {
+			t.Errorf("diagnostic %d: got %q, want %q", i, diags[i].Message, w)
+		}
+	}
+	if r := diags[0].Range; r.Start.Line != 5 || r.Start.Character != 5 {
+		t.Errorf("want the first diagnostic on the certificate argument, got %v", r)
+	}
+	if c := diags[0].Code; c == nil || c.Value != analysis.RuleMissingFile {
+		t.Errorf("code = %+v, want %s", c, analysis.RuleMissingFile)
+	}
+}
+
+func TestFileDiagnostics_DeployRootAndSkip(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"deploy/etc/ssl/site.pem": "", "deploy/etc/ssl/site.key": ""})
+	src := "example.com {\n\ttls /etc/ssl/site.pem /etc/ssl/site.key\n}\n"
+	f, _ := parser.Parse(src)
+	path := filepath.Join(dir, "Caddyfile")
+	if diags := FileDiagnostics(path, f, FileOptions{DeployRoot: filepath.Join(dir, "deploy")}); len(diags) != 0 {
+		t.Errorf("files below the deploy root: got %v", diags)
+	}
+	if diags := FileDiagnostics(path, f, FileOptions{DeployRoot: dir}); len(diags) != 2 {
+		t.Errorf("files missing below the deploy root: got %v", diags)
+	}
+	if diags := FileDiagnostics(path, f, FileOptions{DeployRoot: dir, Skip: true}); len(diags) != 0 {
+		t.Errorf("skipped checks: got %v", diags)
+	}
+}
+
+func TestImportDiagnostics_FileOptions(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"deploy/etc/caddy/common.caddy": "encode gzip\n"})
+	src := "example.com {\n\timport /etc/caddy/common.caddy\n\timport ./missing.caddy\n\timport a/*/*.caddy\n}\n"
+	f, _ := parser.Parse(src)
+	path := filepath.Join(dir, "Caddyfile")
+	diags := ImportDiagnostics(path, f, analysis.DefaultSchema(), FileOptions{DeployRoot: filepath.Join(dir, "deploy")})
+	if len(diags) != 2 || !strings.Contains(diags[0].Message, "missing.caddy") {
+		t.Errorf("deploy root: got %v", diags)
+	}
+	diags = ImportDiagnostics(path, f, analysis.DefaultSchema(), FileOptions{Skip: true})
+	if len(diags) != 1 || !strings.Contains(diags[0].Message, "may only contain one wildcard") {
+		t.Errorf("skipped checks should still report bad patterns, got %v", diags)
+	}
+}
@@ -0,0 +1,261 @@
+package workspace
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Import is the outcome of resolving the argument of an `import <pattern>`
+// line the way Caddy does when it loads the config.
+type Import struct {
+	// Files are the files imported, sorted, excluding the importing file.
+	Files []string
+	// Problem is what Caddy reports about the pattern, or empty.
+	Problem string
+	// Fatal is set when Caddy refuses to load the config because of
+	// Problem; otherwise Caddy only logs it.
+	Fatal bool
+	// Missing is set when Problem is that nothing matched the pattern.
+	Missing bool
+}
+
+// ResolveImportPattern resolves the argument of an `import <pattern>` line
+// in the file at from. As in Caddy, a relative pattern is resolved against
+// the directory of the importing file and may contain one "*" or "?"
+// wildcard. A glob that matches nothing is only a warning, but a plain path
+// must exist. Files starting with "." are skipped when the last path
+// element starts with the wildcard.
+func ResolveImportPattern(from, pattern string) Import {
+	glob := pattern
+	if !filepath.IsAbs(glob) {
+		glob = filepath.Join(filepath.Dir(from), glob)
+	}
+	if strings.Count(glob, "*") > 1 || strings.Count(glob, "?") > 1 ||
+		(strings.Contains(glob, "[") && strings.Contains(glob, "]")) {
+		return Import{Problem: fmt.Sprintf("import glob %q may only contain one wildcard", pattern), Fatal: true}
+	}
+	matches, err := filepath.Glob(glob)
+	if err != nil {
+		return Import{Problem: fmt.Sprintf("invalid import pattern %q: %v", pattern, err), Fatal: true}
+	}
+	isGlob := strings.ContainsAny(glob, "*?[]")
+	if len(matches) == 0 {
+		if isGlob {
+			return Import{Problem: fmt.Sprintf("no files match import glob %q", pattern), Missing: true}
+		}
+		return Import{Problem: fmt.Sprintf("file to import not found: %s", pattern), Fatal: true, Missing: true}
+	}
+	hideDotfiles := strings.HasPrefix(filepath.Base(glob), "*")
+	var files []string
+	for _, m := range matches {
+		if hideDotfiles && strings.HasPrefix(filepath.Base(m), ".") {
+			continue
+		}
+		info, err := os.Stat(m)
+		switch {
+		case err != nil || m == from:
+		case info.IsDir() && !isGlob:
+			return Import{Problem: fmt.Sprintf("cannot import %s: it is a directory", pattern), Fatal: true}
+		case !info.IsDir():
+			files = append(files, m)
+		}
+	}
+	sort.Strings(files)
+	return Import{Files: files}
+}
+
+// ResolveImport returns the files matched by the argument of an
+// `import <pattern>` line in the file at from, as ResolveImportPattern
+// resolves them.
+func ResolveImport(from, pattern string) []string {
+	return ResolveImportPattern(from, pattern).Files
+}
+
+// ImportDiagnostics reports the file imports of f, the file at path, that
+// Caddy could not resolve: an error for a missing file or a bad pattern and
+// a warning for a glob that matches nothing. Files imported inside a block
+// are checked against schema s for directives that are not valid there.
+// Absolute patterns are looked up below opts.DeployRoot, if set, and
+// missing files are not reported when opts.Skip is set. Snippet imports
+// and patterns with placeholders are skipped.
+func ImportDiagnostics(path string, f *parser.File, s *analysis.Schema, opts FileOptions) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, site := range importSites(f) {
+		d := site.directive
+		if len(d.Args) == 0 {
+			continue
+		}
+		arg := d.Args[0].Token
+		if !analysis.IsFileImport(arg.Value) || strings.Contains(arg.Value, "{") {
+			continue
+		}
+		pattern := arg.Value
+		if filepath.IsAbs(pattern) && opts.DeployRoot != "" {
+			pattern = opts.Resolve(path, pattern)
+		}
+		imp := ResolveImportPattern(path, pattern)
+		if imp.Missing && opts.Skip {
+			continue
+		}
+		if imp.Problem != "" {
+			severity := protocol.DiagnosticSeverityWarning
+			if imp.Fatal {
+				severity = protocol.DiagnosticSeverityError
+			}
+			source := "caddy-ls"
+			diag := protocol.Diagnostic{
+				Range:    arg.Range(),
+				Severity: &severity,
+				Source:   &source,
+				Message:  imp.Problem,
+			}
+			analysis.SetRuleCode(&diag, analysis.RuleImportPath)
+			diags = append(diags, diag)
+			continue
+		}
+		if !site.inBlock {
+			continue
+		}
+		var bodies []analysis.ImportedBody
+		for _, file := range imp.Files {
+			src, err := opts.readFile(file)
+			if err != nil {
+				continue
+			}
+			ds, _ := parser.ParseDirectives(string(src))
+			bodies = append(bodies, analysis.ImportedBody{URI: PathToURI(file), Directives: ds})
+		}
+		diags = append(diags, analysis.AnalyzeImportedBodies(s, f, site.parent, d, bodies)...)
+	}
+	return diags
+}
+
+// importSite is an import line and the block it sits in.
+type importSite struct {
+	directive *parser.Directive
+	// inBlock is false for imports outside any block, in the global
+	// options block and at the top level of a snippet; parent is the
+	// enclosing directive, or empty directly inside a site block.
+	inBlock bool
+	parent  string
+}
+
+// importSites returns the import lines of f at any depth, in source order.
+func importSites(f *parser.File) []importSite {
+	var sites []importSite
+	var walk func(ds []*parser.Directive, inBlock bool, parent string, nested bool)
+	walk = func(ds []*parser.Directive, inBlock bool, parent string, nested bool) {
+		for _, d := range ds {
+			if d.Name.Value == "import" {
+				sites = append(sites, importSite{directive: d, inBlock: inBlock, parent: parent})
+			}
+			walk(d.Body, nested, d.Name.Value, nested)
+		}
+	}
+	if f.GlobalBlock != nil {
+		walk(f.GlobalBlock.Directives, false, "", false)
+	}
+	for _, d := range f.Imports {
+		sites = append(sites, importSite{directive: d})
+	}
+	for _, sb := range f.SiteBlocks {
+		// A snippet may itself be imported inside a directive, so what its
+		// top level holds is unknown.
+		snippet := len(sb.Addresses) > 0 && strings.HasPrefix(sb.Addresses[0].Value, "(")
+		walk(sb.Directives, !snippet, "", true)
+	}
+	sort.SliceStable(sites, func(i, j int) bool { return sites[i].directive.Name.Line < sites[j].directive.Name.Line })
+	return sites
+}
+
+// Load returns the parsed file at path, from the index when it has been
+// scanned and otherwise by reading it from disk. Files read from disk are
+// added to the index.
+func (ix *Index) Load(path string) (*parser.File, bool) {
+	uri := PathToURI(path)
+	if f, ok := ix.File(uri); ok {
+		return f, true
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		return nil, false
+	}
+	f, _ := parser.Parse(string(src))
+	ix.Set(uri, f)
+	return f, true
+}
+
+// IsImported reports whether a file of the index imports the file at path,
+// at any depth. Patterns with placeholders are skipped.
+func (ix *Index) IsImported(path string) bool {
+	for _, uri := range ix.URIs() {
+		from, ok := URIToPath(uri)
+		f, indexed := ix.File(uri)
+		if ok && indexed && from != path && importsAny(from, f, []string{path}) {
+			return true
+		}
+	}
+	return false
+}
+
+// Importers returns the URIs of the files of the index that import the
+// file at path, directly or through other imported files, sorted. Patterns
+// with placeholders are skipped.
+func (ix *Index) Importers(path string) []string {
+	targets := []string{path}
+	found := map[string]bool{}
+	for changed := true; changed; {
+		changed = false
+		for _, uri := range ix.URIs() {
+			from, ok := URIToPath(uri)
+			f, indexed := ix.File(uri)
+			if !ok || !indexed || found[uri] || from == path || !importsAny(from, f, targets) {
+				continue
+			}
+			found[uri] = true
+			targets = append(targets, from)
+			changed = true
+		}
+	}
+	importers := slices.Collect(maps.Keys(found))
+	sort.Strings(importers)
+	return importers
+}
+
+// HasRelativeImports reports whether an import line of f names files by a
+// relative path, which Caddy resolves against the directory of f.
+func HasRelativeImports(f *parser.File) bool {
+	for _, site := range importSites(f) {
+		d := site.directive
+		if len(d.Args) > 0 && analysis.IsFileImport(d.Args[0].Token.Value) && !filepath.IsAbs(d.Args[0].Token.Value) {
+			return true
+		}
+	}
+	return false
+}
+
+// importsAny reports whether an import line of f, the file at from, names
+// one of paths.
+func importsAny(from string, f *parser.File, paths []string) bool {
+	for _, site := range importSites(f) {
+		d := site.directive
+		if len(d.Args) == 0 || !analysis.IsFileImport(d.Args[0].Token.Value) || strings.Contains(d.Args[0].Token.Value, "{") {
+			continue
+		}
+		for _, p := range ResolveImport(from, d.Args[0].Token.Value) {
+			if slices.Contains(paths, p) {
+				return true
+			}
+		}
+	}
+	return false
+}
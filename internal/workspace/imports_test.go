@@ -0,0 +1,178 @@
+package workspace
+
+import (
+	"caddy-ls/pkg/caddyfile/analysis"
+	"caddy-ls/pkg/caddyfile/parser"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestResolveImport(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Caddyfile":            "import snippets/*.caddy\n",
+		"snippets/a.caddy":     "(a) {\n}\n",
+		"snippets/b.caddy":     "(b) {\n}\n",
+		"snippets/sub/c.caddy": "(c) {\n}\n",
+		"shared/tls.conf":      "tls internal\n",
+	})
+	from := filepath.Join(dir, "Caddyfile")
+
+	got := ResolveImport(from, "snippets/*.caddy")
+	want := []string{filepath.Join(dir, "snippets/a.caddy"), filepath.Join(dir, "snippets/b.caddy")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("glob: got %v, want %v", got, want)
+	}
+	if got := ResolveImport(from, filepath.Join(dir, "shared/tls.conf")); len(got) != 1 {
+		t.Errorf("absolute path: got %v", got)
+	}
+	if got := ResolveImport(from, "missing.conf"); len(got) != 0 {
+		t.Errorf("missing file: got %v, want none", got)
+	}
+	if got := ResolveImport(from, "*"); len(got) != 0 {
+		t.Errorf("glob matching only directories and the importer: got %v, want none", got)
+	}
+}
+
+func TestResolveImportPattern(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"sites/Caddyfile":      "import ../snippets/*\n",
+		"snippets/a.caddy":     "(a) {\n}\n",
+		"snippets/.hidden":     "(h) {\n}\n",
+		"snippets/sub/b.caddy": "(b) {\n}\n",
+	})
+	from := filepath.Join(dir, "sites/Caddyfile")
+
+	tests := []struct {
+		pattern string
+		files   []string
+		problem string
+		fatal   bool
+	}{
+		{pattern: "../snippets/*", files: []string{filepath.Join(dir, "snippets/a.caddy")}},
+		{pattern: "../snippets/.*", files: []string{filepath.Join(dir, "snippets/.hidden")}},
+		{pattern: "../snippets/*.conf", problem: `no files match import glob "../snippets/*.conf"`},
+		{pattern: "../snippets/missing.caddy", problem: "file to import not found: ../snippets/missing.caddy", fatal: true},
+		{pattern: "../snippets/*/*.caddy", problem: `import glob "../snippets/*/*.caddy" may only contain one wildcard`, fatal: true},
+		{pattern: "../snippets/sub", problem: "cannot import ../snippets/sub: it is a directory", fatal: true},
+	}
+	for _, tt := range tests {
+		got := ResolveImportPattern(from, tt.pattern)
+		if !reflect.DeepEqual(got.Files, tt.files) || got.Problem != tt.problem || got.Fatal != tt.fatal {
+			t.Errorf("%s: got %+v, want files %v, problem %q, fatal %v", tt.pattern, got, tt.files, tt.problem, tt.fatal)
+		}
+	}
+}
+
+func TestImportDiagnostics(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"snippets/a.caddy": "(a) {\n}\n"})
+	src := "import snippets/*.caddy\nimport conf.d/*\n\n(local) {\n}\n\nexample.com {\n\timport local\n\timport {$SITE_CONF}/extra\n\thandle {\n\t\timport ./missing.caddy\n\t}\n}\n"
+	f, _ := parser.Parse(src)
+	diags := ImportDiagnostics(filepath.Join(dir, "Caddyfile"), f, analysis.DefaultSchema(), FileOptions{})
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2: %v", len(diags), diags)
+	}
+	if d := diags[0]; d.Range.Start.Line != 1 || *d.Severity != protocol.DiagnosticSeverityWarning {
+		t.Errorf("empty glob: got %+v", d)
+	}
+	if d := diags[1]; d.Range.Start.Line != 10 || d.Range.Start.Character != 9 || *d.Severity != protocol.DiagnosticSeverityError {
+		t.Errorf("missing file: got %+v", d)
+	}
+	if c := diags[1].Code; c == nil || c.Value != analysis.RuleImportPath {
+		t.Errorf("code = %+v, want %s", c, analysis.RuleImportPath)
+	}
+}
+
+func TestImportDiagnostics_ImportContext(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"snippets/proxy.conf": "lb_policy first\nencode gzip\n",
+		"snippets/site.conf":  "encode gzip\n",
+	})
+	src := "(snip) {\n\timport snippets/proxy.conf\n}\n\nexample.com {\n\timport snippets/site.conf\n\timport snippets/proxy.conf\n\treverse_proxy app:8080 {\n\t\timport snippets/proxy.conf\n\t}\n}\n"
+	f, _ := parser.Parse(src)
+	diags := ImportDiagnostics(filepath.Join(dir, "Caddyfile"), f, analysis.DefaultSchema(), FileOptions{})
+	if len(diags) != 2 {
+		t.Fatalf("got %d diagnostics, want 2: %v", len(diags), diags)
+	}
+	if d := diags[0]; d.Range.Start.Line != 6 || !strings.Contains(d.Message, `"lb_policy" from imported snippets/proxy.conf is not valid in a site block`) {
+		t.Errorf("site level: got %+v", d)
+	}
+	if d := diags[1]; d.Range.Start.Line != 8 || !strings.Contains(d.Message, `"encode" from imported snippets/proxy.conf is not valid inside "reverse_proxy"`) {
+		t.Errorf("reverse_proxy: got %+v", d)
+	}
+	want := PathToURI(filepath.Join(dir, "snippets/proxy.conf"))
+	if related := diags[1].RelatedInformation; len(related) != 1 || related[0].Location.URI != want || related[0].Location.Range.Start.Line != 1 {
+		t.Errorf("want encode in proxy.conf as related information, got %+v", related)
+	}
+}
+
+func TestIndexLoad(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"common.caddy": "(common) {\n\tencode gzip\n}\n"})
+	path := filepath.Join(dir, "common.caddy")
+
+	ix := New()
+	f, ok := ix.Load(path)
+	if !ok || len(f.SiteBlocks) != 1 {
+		t.Fatalf("Load = %v, %v", f, ok)
+	}
+	if _, ok := ix.File(PathToURI(path)); !ok {
+		t.Error("loaded file should be added to the index")
+	}
+	if _, ok := ix.Load(filepath.Join(dir, "missing.caddy")); ok {
+		t.Error("missing file: want not ok")
+	}
+}
+
+func TestIndexIsImported(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Caddyfile":        "example.com {\n\timport sites/*.caddy app\n}\n",
+		"sites/app.caddy":  "reverse_proxy {args[0]}:8080\n",
+		"other.caddy":      "respond {args[0]}\n",
+		"sites/self.caddy": "import self.caddy\n",
+	})
+	ix := New()
+	for _, name := range []string{"Caddyfile", "sites/app.caddy", "other.caddy", "sites/self.caddy"} {
+		ix.Load(filepath.Join(dir, name))
+	}
+	for name, want := range map[string]bool{
+		"sites/app.caddy": true,
+		"other.caddy":     false,
+		"Caddyfile":       false,
+	} {
+		if got := ix.IsImported(filepath.Join(dir, name)); got != want {
+			t.Errorf("IsImported(%s) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestIndexImporters(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Caddyfile":         "import sites/*.caddy\n",
+		"sites/app.caddy":   "app.example.com {\n\timport ../common.conf\n}\n",
+		"common.conf":       "encode gzip\n",
+		"other.caddy":       "example.com {\n}\n",
+		"sites/other.caddy": "{args[0]} {\n}\n",
+	})
+	ix := New()
+	for _, name := range []string{"Caddyfile", "sites/app.caddy", "common.conf", "other.caddy", "sites/other.caddy"} {
+		ix.Load(filepath.Join(dir, name))
+	}
+	want := []string{PathToURI(filepath.Join(dir, "Caddyfile")), PathToURI(filepath.Join(dir, "sites/app.caddy"))}
+	if got := ix.Importers(filepath.Join(dir, "common.conf")); !slices.Equal(got, want) {
+		t.Errorf("Importers(common.conf) = %v, want %v", got, want)
+	}
+	if got := ix.Importers(filepath.Join(dir, "other.caddy")); len(got) != 0 {
+		t.Errorf("Importers(other.caddy) = %v, want none", got)
+	}
+}
@@ -0,0 +1,164 @@
+// Package workspace discovers and indexes the Caddyfiles that live under the
+// editor's workspace folders.
+package workspace
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"context"
+	"io/fs"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// skipDirs are directory names never descended into while scanning.
+var skipDirs = map[string]bool{
+	".git":         true,
+	".hg":          true,
+	".svn":         true,
+	"node_modules": true,
+	"vendor":       true,
+}
+
+// IsCaddyfile reports whether a file name matches DefaultPatterns:
+// "Caddyfile", "Caddyfile.<env>", "*.caddyfile" or "*.caddy".
+func IsCaddyfile(name string) bool {
+	return DefaultPatterns.Match(name)
+}
+
+// PathToURI converts an absolute file system path to a file:// URI.
+func PathToURI(path string) string {
+	u := url.URL{Scheme: "file", Path: filepath.ToSlash(path)}
+	return u.String()
+}
+
+// URIToPath converts a file:// URI to a file system path. ok is false for
+// URIs with any other scheme.
+func URIToPath(uri string) (path string, ok bool) {
+	u, err := url.Parse(uri)
+	if err != nil || u.Scheme != "file" {
+		return "", false
+	}
+	return filepath.FromSlash(u.Path), true
+}
+
+// FindCaddyfiles walks every root directory and returns the files matching
+// patterns, sorted. Version control and dependency directories are skipped,
+// as are unreadable entries. It stops early and returns ctx.Err() when ctx
+// is cancelled.
+func FindCaddyfiles(ctx context.Context, roots []string, patterns Patterns) ([]string, error) {
+	var paths []string
+	for _, root := range roots {
+		err := filepath.WalkDir(root, func(path string, d fs.DirEntry, err error) error {
+			if err != nil {
+				// Unreadable entries are skipped rather than aborting the scan.
+				if d != nil && d.IsDir() {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if ctx.Err() != nil {
+				return ctx.Err()
+			}
+			if d.IsDir() {
+				if path != root && skipDirs[d.Name()] {
+					return fs.SkipDir
+				}
+				return nil
+			}
+			if patterns.Match(path) {
+				paths = append(paths, path)
+			}
+			return nil
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+	sort.Strings(paths)
+	return paths, nil
+}
+
+// Index holds the parsed AST of every Caddyfile found in the workspace.
+// It is safe for concurrent use.
+type Index struct {
+	mu    sync.RWMutex
+	files map[string]*parser.File // keyed by URI
+}
+
+// New returns an empty Index.
+func New() *Index {
+	return &Index{files: make(map[string]*parser.File)}
+}
+
+// Scan walks every root directory, parses each file matching patterns and
+// stores it in the index. Files are parsed concurrently. report, when
+// non-nil, is called after each file is parsed with the number of files done so far and
+// the total; calls are serialized. Scan stops early and
+// returns ctx.Err() when ctx is cancelled.
+func (ix *Index) Scan(ctx context.Context, roots []string, patterns Patterns, report func(done, total int)) error {
+	paths, err := FindCaddyfiles(ctx, roots, patterns)
+	if err != nil {
+		return err
+	}
+
+	// Files are parsed on a bounded pool; report sees a monotonically
+	// increasing count regardless of completion order.
+	var mu sync.Mutex
+	done := 0
+	return ForEach(ctx, 0, paths, func(path string) {
+		if src, err := os.ReadFile(path); err == nil {
+			f, _ := parser.Parse(string(src))
+			ix.Set(PathToURI(path), f)
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		done++
+		if report != nil {
+			report(done, len(paths))
+		}
+	})
+}
+
+// Set stores (or replaces) the parsed file for uri.
+func (ix *Index) Set(uri string, f *parser.File) {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	ix.files[uri] = f
+}
+
+// File returns the parsed file for uri.
+func (ix *Index) File(uri string) (*parser.File, bool) {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	f, ok := ix.files[uri]
+	return f, ok
+}
+
+// URIs returns the URIs of all indexed files, sorted.
+func (ix *Index) URIs() []string {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	uris := make([]string, 0, len(ix.files))
+	for uri := range ix.files {
+		uris = append(uris, uri)
+	}
+	sort.Strings(uris)
+	return uris
+}
+
+// Reset removes every file from the index.
+func (ix *Index) Reset() {
+	ix.mu.Lock()
+	defer ix.mu.Unlock()
+	clear(ix.files)
+}
+
+// Len returns the number of indexed files.
+func (ix *Index) Len() int {
+	ix.mu.RLock()
+	defer ix.mu.RUnlock()
+	return len(ix.files)
+}
@@ -0,0 +1,88 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates each relative path under dir with the given content.
+func writeFiles(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	for rel, content := range files {
+		path := filepath.Join(dir, rel)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestIsCaddyfile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"Caddyfile":            true,
+		"Caddyfile.prod":       true,
+		"site.caddyfile":       true,
+		"site.caddy":           true,
+		"/etc/caddy/Caddyfile": true,
+		"caddy.json":           false,
+		"README.md":            false,
+	} {
+		if got := IsCaddyfile(name); got != want {
+			t.Errorf("IsCaddyfile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
+
+func TestPathURIRoundTrip(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "my dir", "Caddyfile")
+	got, ok := URIToPath(PathToURI(path))
+	if !ok || got != path {
+		t.Errorf("round trip: got %q (ok=%v), want %q", got, ok, path)
+	}
+	if _, ok := URIToPath("untitled:Untitled-1"); ok {
+		t.Error("non-file URI: want ok=false")
+	}
+}
+
+func TestScan_FindsCaddyfilesAndReportsProgress(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Caddyfile":                  "example.com {\n\trespond ok\n}\n",
+		"sites/api.caddy":            "api.example.com {\n\treverse_proxy :8080\n}\n",
+		"node_modules/pkg/Caddyfile": "ignored.com {\n}\n",
+		"notes.txt":                  "not a caddyfile",
+	})
+
+	ix := New()
+	var calls [][2]int
+	err := ix.Scan(context.Background(), []string{dir}, nil, func(done, total int) {
+		calls = append(calls, [2]int{done, total})
+	})
+	if err != nil {
+		t.Fatalf("Scan: %v", err)
+	}
+	if ix.Len() != 2 {
+		t.Errorf("want 2 indexed files, got %d: %v", ix.Len(), ix.URIs())
+	}
+	if len(calls) != 2 || calls[1] != [2]int{2, 2} {
+		t.Errorf("unexpected progress calls: %v", calls)
+	}
+	if _, ok := ix.File(PathToURI(filepath.Join(dir, "Caddyfile"))); !ok {
+		t.Error("root Caddyfile should be indexed")
+	}
+}
+
+func TestScan_Cancelled(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{"Caddyfile": "example.com {\n}\n"})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := New().Scan(ctx, []string{dir}, nil, nil); err != context.Canceled {
+		t.Errorf("want context.Canceled, got %v", err)
+	}
+}
@@ -0,0 +1,47 @@
+package workspace
+
+import (
+	"context"
+	"runtime"
+	"sync"
+)
+
+// ForEach calls fn for every item using at most workers goroutines and
+// returns once all calls have finished. workers <= 0 means GOMAXPROCS. Items
+// not yet started when ctx is cancelled are skipped and ctx.Err() is
+// returned. fn may be called concurrently and must be safe for that.
+func ForEach[T any](ctx context.Context, workers int, items []T, fn func(T)) error {
+	if workers <= 0 {
+		workers = runtime.GOMAXPROCS(0)
+	}
+	workers = min(workers, len(items))
+
+	next := make(chan T)
+	var wg sync.WaitGroup
+	for range workers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for item := range next {
+				fn(item)
+			}
+		}()
+	}
+
+	var err error
+feed:
+	for _, item := range items {
+		if err = ctx.Err(); err != nil {
+			break
+		}
+		select {
+		case <-ctx.Done():
+			err = ctx.Err()
+			break feed
+		case next <- item:
+		}
+	}
+	close(next)
+	wg.Wait()
+	return err
+}
@@ -0,0 +1,74 @@
+package workspace
+
+import (
+	"context"
+	"runtime"
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestForEach_VisitsEveryItem(t *testing.T) {
+	items := make([]int, 100)
+	for i := range items {
+		items[i] = i
+	}
+	var mu sync.Mutex
+	seen := make(map[int]bool)
+	if err := ForEach(context.Background(), 4, items, func(i int) {
+		mu.Lock()
+		seen[i] = true
+		mu.Unlock()
+	}); err != nil {
+		t.Fatal(err)
+	}
+	if len(seen) != len(items) {
+		t.Errorf("visited %d items, want %d", len(seen), len(items))
+	}
+}
+
+func TestForEach_BoundsConcurrency(t *testing.T) {
+	var active, peak atomic.Int32
+	release := make(chan struct{})
+	items := make([]int, 10)
+	go func() {
+		// Let the workers pile up, then release them all.
+		for active.Load() < 3 {
+			runtime.Gosched()
+		}
+		close(release)
+	}()
+	_ = ForEach(context.Background(), 3, items, func(int) {
+		n := active.Add(1)
+		for {
+			p := peak.Load()
+			if n <= p || peak.CompareAndSwap(p, n) {
+				break
+			}
+		}
+		<-release
+		active.Add(-1)
+	})
+	if got := peak.Load(); got > 3 {
+		t.Errorf("peak concurrency = %d, want <= 3", got)
+	}
+}
+
+func TestForEach_Cancelled(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	var calls atomic.Int32
+	err := ForEach(ctx, 2, []int{1, 2, 3}, func(int) { calls.Add(1) })
+	if err != context.Canceled {
+		t.Errorf("err = %v, want context.Canceled", err)
+	}
+	if calls.Load() != 0 {
+		t.Errorf("fn called %d times after cancellation, want 0", calls.Load())
+	}
+}
+
+func TestForEach_Empty(t *testing.T) {
+	if err := ForEach(context.Background(), 0, []string(nil), func(string) { t.Error("fn called for empty input") }); err != nil {
+		t.Fatal(err)
+	}
+}
@@ -0,0 +1,52 @@
+package workspace
+
+import (
+	"fmt"
+	"path"
+	"path/filepath"
+	"strings"
+)
+
+// Patterns are glob patterns, in path.Match syntax, naming the files
+// treated as Caddyfiles. A pattern without "/" is matched against the file
+// name; one with "/" is matched against as many trailing path elements as
+// it has, so "conf.d/*.conf" matches the .conf files directly inside any
+// conf.d directory. File names are also tried in lower case, so "*.caddy"
+// matches "Site.CADDY". An empty list means DefaultPatterns.
+type Patterns []string
+
+// DefaultPatterns are the Caddyfile names Caddy's own tooling recognizes.
+var DefaultPatterns = Patterns{"Caddyfile", "Caddyfile.*", "*.caddyfile", "*.caddy"}
+
+// Validate returns an error naming the first malformed pattern.
+func (p Patterns) Validate() error {
+	for _, pattern := range p {
+		if _, err := path.Match(pattern, ""); err != nil || pattern == "" {
+			return fmt.Errorf("invalid Caddyfile pattern %q", pattern)
+		}
+	}
+	return nil
+}
+
+// Match reports whether the file at name matches any of the patterns.
+// Malformed patterns never match.
+func (p Patterns) Match(name string) bool {
+	if len(p) == 0 {
+		p = DefaultPatterns
+	}
+	elems := strings.Split(filepath.ToSlash(name), "/")
+	for _, pattern := range p {
+		n := strings.Count(pattern, "/") + 1
+		if n > len(elems) {
+			continue
+		}
+		tail := strings.Join(elems[len(elems)-n:], "/")
+		if ok, _ := path.Match(pattern, tail); ok {
+			return true
+		}
+		if ok, _ := path.Match(pattern, strings.ToLower(tail)); ok {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,58 @@
+package workspace
+
+import (
+	"context"
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func TestPatternsMatch(t *testing.T) {
+	patterns := Patterns{"Caddyfile*", "*.caddy", "conf.d/*.conf"}
+	for name, want := range map[string]bool{
+		"Caddyfile":                true,
+		"Caddyfile.prod":           true,
+		"Caddyfile-old":            true,
+		"site.caddy":               true,
+		"Site.CADDY":               true,
+		"/etc/caddy/conf.d/a.conf": true,
+		"conf.d/a.conf":            true,
+		"a.conf":                   false,
+		"conf.d/sub/a.conf":        false,
+		"site.caddyfile":           false,
+	} {
+		if got := patterns.Match(name); got != want {
+			t.Errorf("Match(%q) = %v, want %v", name, got, want)
+		}
+	}
+	if !(Patterns{}).Match("site.caddyfile") {
+		t.Error("empty patterns should fall back to DefaultPatterns")
+	}
+}
+
+func TestPatternsValidate(t *testing.T) {
+	if err := (Patterns{"*.caddy", "conf.d/*.conf"}).Validate(); err != nil {
+		t.Errorf("valid patterns: %v", err)
+	}
+	if err := (Patterns{"*.caddy", "[a-"}).Validate(); err == nil || err.Error() != `invalid Caddyfile pattern "[a-"` {
+		t.Errorf("malformed pattern: got %v", err)
+	}
+}
+
+func TestFindCaddyfiles_Patterns(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Caddyfile":       "example.com {\n}\n",
+		"conf.d/a.conf":   "a.example.com {\n}\n",
+		"other/b.conf":    "b.example.com {\n}\n",
+		"sites/api.caddy": "api.example.com {\n}\n",
+	})
+	got, err := FindCaddyfiles(context.Background(), []string{dir}, Patterns{"Caddyfile", "conf.d/*.conf"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{filepath.Join(dir, "Caddyfile"), filepath.Join(dir, "conf.d/a.conf")}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,117 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"sort"
+	"time"
+)
+
+// fileStamp is what the watcher compares to detect a change.
+type fileStamp struct {
+	size    int64
+	modTime time.Time
+}
+
+// Watcher detects Caddyfiles that were created, modified or removed under a
+// set of paths by comparing successive snapshots of their size and
+// modification time. Polling needs no platform support and copes with
+// editors that replace files on save. A Watcher is not safe for concurrent
+// use.
+type Watcher struct {
+	paths    []string // directories are scanned; files are watched as given
+	patterns Patterns // names the files watched inside directories
+	seen     map[string]fileStamp
+}
+
+// NewWatcher returns a Watcher over paths, watching the files matching
+// patterns inside directories, and takes the initial snapshot, so the first
+// Poll only reports changes made after this call.
+func NewWatcher(ctx context.Context, paths []string, patterns Patterns) (*Watcher, error) {
+	w := &Watcher{paths: paths, patterns: patterns}
+	seen, err := w.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	w.seen = seen
+	return w, nil
+}
+
+// Files returns the files currently watched, sorted.
+func (w *Watcher) Files() []string {
+	files := make([]string, 0, len(w.seen))
+	for path := range w.seen {
+		files = append(files, path)
+	}
+	sort.Strings(files)
+	return files
+}
+
+// Poll takes a new snapshot and returns the files that changed since the
+// previous one, sorted. Removed files are included; callers can tell them
+// apart because they no longer exist.
+func (w *Watcher) Poll(ctx context.Context) ([]string, error) {
+	seen, err := w.snapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	var changed []string
+	for path, stamp := range seen {
+		if old, ok := w.seen[path]; !ok || old != stamp {
+			changed = append(changed, path)
+		}
+	}
+	for path := range w.seen {
+		if _, ok := seen[path]; !ok {
+			changed = append(changed, path)
+		}
+	}
+	w.seen = seen
+	sort.Strings(changed)
+	return changed, nil
+}
+
+// Watch polls every interval until ctx is cancelled, calling fn with each
+// non-empty set of changed files. It returns ctx.Err() once cancelled, or
+// the first error from scanning.
+func (w *Watcher) Watch(ctx context.Context, interval time.Duration, fn func(changed []string)) error {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-ticker.C:
+		}
+		changed, err := w.Poll(ctx)
+		if err != nil {
+			return err
+		}
+		if len(changed) > 0 {
+			fn(changed)
+		}
+	}
+}
+
+// snapshot stats every watched file.
+func (w *Watcher) snapshot(ctx context.Context) (map[string]fileStamp, error) {
+	var dirs, files []string
+	for _, p := range w.paths {
+		if info, err := os.Stat(p); err == nil && info.IsDir() {
+			dirs = append(dirs, p)
+		} else {
+			files = append(files, p)
+		}
+	}
+	found, err := FindCaddyfiles(ctx, dirs, w.patterns)
+	if err != nil {
+		return nil, err
+	}
+	seen := make(map[string]fileStamp, len(found)+len(files))
+	for _, path := range append(found, files...) {
+		if info, err := os.Stat(path); err == nil && !info.IsDir() {
+			seen[path] = fileStamp{size: info.Size(), modTime: info.ModTime()}
+		}
+	}
+	return seen, nil
+}
@@ -0,0 +1,69 @@
+package workspace
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestWatcher_Poll(t *testing.T) {
+	dir := t.TempDir()
+	writeFiles(t, dir, map[string]string{
+		"Caddyfile":       "example.com {\n}\n",
+		"sites/a.caddy":   "a.example.com {\n}\n",
+		"sites/notes.txt": "not a Caddyfile\n",
+	})
+	extra := filepath.Join(t.TempDir(), "standalone.conf")
+	if err := os.WriteFile(extra, []byte("b.example.com {\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	ctx := context.Background()
+	w, err := NewWatcher(ctx, []string{dir, extra}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got := len(w.Files()); got != 3 {
+		t.Errorf("watching %d files, want 3 (explicit files are watched regardless of name): %v", got, w.Files())
+	}
+	if changed, _ := w.Poll(ctx); len(changed) != 0 {
+		t.Errorf("no edits: got %v", changed)
+	}
+
+	// Make the modification visible even on file systems with coarse
+	// timestamps by also changing the size.
+	caddyfile := filepath.Join(dir, "Caddyfile")
+	if err := os.WriteFile(caddyfile, []byte("example.com {\n\trespond ok\n}\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Remove(filepath.Join(dir, "sites/a.caddy")); err != nil {
+		t.Fatal(err)
+	}
+	writeFiles(t, dir, map[string]string{"sites/b.caddy": "b {\n}\n"})
+
+	changed, err := w.Poll(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []string{caddyfile, filepath.Join(dir, "sites/a.caddy"), filepath.Join(dir, "sites/b.caddy")}
+	if !reflect.DeepEqual(changed, want) {
+		t.Errorf("changed = %v, want %v", changed, want)
+	}
+	if changed, _ := w.Poll(ctx); len(changed) != 0 {
+		t.Errorf("second poll without edits: got %v", changed)
+	}
+}
+
+func TestWatcher_WatchStopsOnCancel(t *testing.T) {
+	w, err := NewWatcher(context.Background(), []string{t.TempDir()}, nil)
+	if err != nil {
+		t.Fatal(err)
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	if err := w.Watch(ctx, time.Millisecond, func([]string) { t.Error("fn called after cancel") }); err != context.Canceled {
+		t.Errorf("Watch = %v, want context.Canceled", err)
+	}
+}
@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"net"
+	"regexp"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// adminNetworks are the networks the admin endpoint can listen on.
+// Source: listeners.go (ParseNetworkAddress) and admin.go (parseAdminListenAddr)
+var adminNetworks = map[string]bool{
+	"tcp": true, "tcp4": true, "tcp6": true,
+	"unix": true, "unixpacket": true,
+	"fd": true,
+}
+
+// socketPermissions matches the optional "|0220" suffix of a unix socket.
+var socketPermissions = regexp.MustCompile(`^[0-7]{3,4}$`)
+
+// analyzeAdmin checks the admin global option: an optional listen address
+// or `off`, and a body of `origins` and `enforce_origin`. A mistake here
+// does not stop Caddy from starting; it silently moves or disables the
+// admin API, which only shows at deploy time.
+// Source: caddyconfig/httpcaddyfile/options.go (parseOptAdmin)
+func analyzeAdmin(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	if len(d.Args) > 0 && d.Args[0].Token.Value == "off" {
+		if len(d.Args) > 1 || d.HasBody() {
+			diags = append(diags, errorf(d.Args[0].Range(), "admin off takes no other arguments or options"))
+		}
+		return diags
+	}
+	if len(d.Args) > 1 {
+		diags = append(diags, errorf(d.Args[1].Range(), "admin takes at most one listen address"))
+	}
+	unix := false
+	if len(d.Args) > 0 {
+		tok := d.Args[0].Token
+		if !strings.Contains(tok.Value, "{") { // placeholders are resolved at runtime
+			if msg := adminListenProblem(tok.Value); msg != "" {
+				diags = append(diags, errorf(tok.Range(), "invalid admin listen address %q: %s", tok.Value, msg))
+			}
+			network, _, _ := strings.Cut(tok.Value, "/")
+			unix = strings.Contains(tok.Value, "/") && strings.HasPrefix(network, "unix")
+		}
+	}
+
+	names, _ := GlobalBlockNames([]string{"admin"})
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "import":
+		case "enforce_origin":
+			if len(sub.Args) > 0 {
+				diags = append(diags, errorf(sub.Args[0].Range(), "enforce_origin takes no arguments"))
+			}
+		case "origins":
+			switch {
+			case len(sub.Args) == 0:
+				diags = append(diags, warningf(sub.Name.Range(), "origins without arguments allows no origin, so every admin request is rejected"))
+			case unix:
+				diags = append(diags, newDiag(sub.Name.Range(), protocol.DiagnosticSeverityInformation,
+					"origins has no effect when the admin endpoint listens on a unix socket"))
+			}
+		default:
+			diags = append(diags, errorf(sub.Name.Range(), "unrecognized admin option %q%s", sub.Name.Value, didYouMean(sub.Name.Value, names)))
+		}
+	}
+	return diags
+}
+
+// adminListenProblem describes what is wrong with the admin listen address
+// addr, or returns "" when Caddy accepts it. Besides addresses Caddy
+// rejects, it flags a missing port, which makes Caddy listen on a random
+// one.
+func adminListenProblem(addr string) string {
+	if strings.HasPrefix(addr, "/") {
+		return fmt.Sprintf("a unix socket needs the unix/ prefix, e.g. unix/%s", addr)
+	}
+	if network, rest, ok := strings.Cut(addr, "/"); ok {
+		network = strings.ToLower(network)
+		if !adminNetworks[network] {
+			return fmt.Sprintf("unknown network %q", network)
+		}
+		if strings.HasPrefix(network, "unix") {
+			path, perm, hasPerm := strings.Cut(rest, "|")
+			switch {
+			case path == "":
+				return "missing socket path"
+			case hasPerm && !socketPermissions.MatchString(perm):
+				return fmt.Sprintf("invalid socket permissions %q: want octal digits such as 0220", perm)
+			}
+			return ""
+		}
+		if network == "fd" {
+			return ""
+		}
+		addr = rest
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		if _, convErr := strconv.Atoi(addr); convErr == nil {
+			return fmt.Sprintf("missing host or colon; did you mean %q?", ":"+addr)
+		}
+		if strings.Count(addr, ":") == 0 || strings.HasSuffix(addr, "]") {
+			return fmt.Sprintf("missing port, so Caddy would listen on a random one; did you mean %q?", net.JoinHostPort(strings.Trim(addr, "[]"), "2019"))
+		}
+		return strings.TrimPrefix(err.Error(), "address "+addr+": ")
+	}
+	if strings.Contains(port, "-") {
+		return "the admin endpoint listens on a single port, not a range"
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Sprintf("invalid port %q", port)
+	}
+	return ""
+}
@@ -0,0 +1,66 @@
+package analysis
+
+import "testing"
+
+func TestAnalyzeAdmin_ListenAddress(t *testing.T) {
+	for addr, want := range map[string]string{
+		"localhost:2019":            "",
+		":2019":                     "",
+		"[::1]:2019":                "",
+		"tcp/localhost:2019":        "",
+		"unix//run/caddy.sock":      "",
+		"unix//run/caddy.sock|0220": "",
+		"fd/3":                      "",
+		"{$ADMIN_ADDR}":             "",
+		"localhost":                 `missing port, so Caddy would listen on a random one; did you mean "localhost:2019"?`,
+		"2019":                      `missing host or colon; did you mean ":2019"?`,
+		"localhost:2019-2020":       "the admin endpoint listens on a single port, not a range",
+		"localhost:99999":           `invalid port "99999"`,
+		"/run/caddy.sock":           "a unix socket needs the unix/ prefix, e.g. unix//run/caddy.sock",
+		"unix/":                     "missing socket path",
+		"unix//run/caddy.sock|rw":   `invalid socket permissions "rw"`,
+		"udp/localhost:2019":        `unknown network "udp"`,
+	} {
+		diags := analyze("{\n\tadmin " + addr + "\n}\n")
+		if want == "" {
+			if len(diags) != 0 {
+				t.Errorf("admin %s: want no diagnostics, got %v", addr, diags)
+			}
+			continue
+		}
+		if len(diags) != 1 || !hasMsg(diags, `invalid admin listen address "`+addr+`"`, want) {
+			t.Errorf("admin %s: want %q, got %v", addr, want, diags)
+		}
+	}
+}
+
+func TestAnalyzeAdmin_Options(t *testing.T) {
+	src := "{\n" +
+		"\tadmin localhost:2019 extra {\n" +
+		"\t\torigins\n" +
+		"\t\tenforce_origin yes\n" +
+		"\t\torigin localhost:2019\n" +
+		"\t}\n" +
+		"}\n"
+	diags := analyze(src)
+	for _, want := range [][]string{
+		{"admin takes at most one listen address"},
+		{"origins without arguments allows no origin"},
+		{"enforce_origin takes no arguments"},
+		{`unrecognized admin option "origin"`, `did you mean "origins"?`},
+	} {
+		if !hasMsg(diags, want...) {
+			t.Errorf("missing diagnostic %q in %v", want, diags)
+		}
+	}
+	if len(diags) != 4 {
+		t.Errorf("got %d diagnostics, want 4: %v", len(diags), diags)
+	}
+
+	if diags := analyze("{\n\tadmin off {\n\t\tenforce_origin\n\t}\n}\n"); !hasMsg(diags, "admin off takes no other arguments or options") {
+		t.Errorf("admin off with a body: got %v", diags)
+	}
+	if diags := analyze("{\n\tadmin unix//run/caddy.sock {\n\t\torigins example.com\n\t}\n}\n"); len(diags) != 1 || !hasMsg(diags, "origins has no effect") {
+		t.Errorf("origins on a unix socket: got %v", diags)
+	}
+}
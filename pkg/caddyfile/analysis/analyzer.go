@@ -1,14 +1,36 @@
+// Package analysis checks a Caddyfile parsed by package parser against a
+// model of Caddy's directives, global options, matchers and modules, the
+// schema, and reports problems as LSP diagnostics. AnalyzeFile also returns
+// the symbols of the file. Options tune the checks for a Caddy build: plugin
+// modules, a custom Schema built with NewSchema, or the Caddy version the
+// file targets.
+//
+// The TLS checks take the protocols and curves Caddy accepts from its
+// caddytls package, so importing this package also pulls in caddytls and
+// its dependencies, such as certmagic and the ACME client.
 package analysis
 
 import (
-	"caddy-ls/internal/parser"
+	"caddy-ls/pkg/caddyfile/parser"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// Codes of the rules checking directives and global options against the
+// model of Caddy. RuleInvalidArgument covers the checks of the arguments and
+// blocks of particular directives that have no code of their own.
+const (
+	RuleUnknownOption       = "unknown-option"
+	RuleUnknownDirective    = "unknown-directive"
+	RuleUnknownSubdirective = "unknown-subdirective"
+	RuleUndefinedSnippet    = "undefined-snippet"
+	RuleInvalidArgument     = "invalid-argument"
+)
+
 // knownSubDirectiveParent maps subdirectives that are only valid inside a specific
 // parent to that parent's name. Used to produce better "wrong level" diagnostics
 // when one of these appears at the top of a site block.
@@ -96,6 +118,9 @@ var knownSubDirectives = map[string]map[string]bool{
 		"handle_response": true, "replace_status": true,
 		"copy_response": true, "copy_response_headers": true,
 	},
+	// only valid inside a reverse_proxy handle_response block
+	"copy_response":         {"status": true},
+	"copy_response_headers": {"include": true, "exclude": true},
 	"tls": {
 		"protocols": true, "ciphers": true, "curves": true, "alpn": true,
 		"load": true, "ca": true, "ca_root": true, "key_type": true,
@@ -105,12 +130,17 @@ var knownSubDirectives = map[string]map[string]bool{
 		"issuer": true, "get_certificate": true,
 		"insecure_secrets_log": true, "reuse_private_keys": true,
 	},
+	"bind": {"protocols": true},
+	// Source: modules/caddyhttp/intercept/intercept.go (UnmarshalCaddyfile)
+	"intercept": {"replace_status": true, "handle_response": true},
 	"encode": {
 		"gzip": true, "zstd": true, "br": true, "minimum_length": true, "match": true,
 	},
+	// Source: caddyconfig/httpcaddyfile/builtins.go (parseLogHelper); the
+	// log global option has its own set in globalOptionBlocks.
 	"log": {
-		"hostnames": true, "output": true, "format": true, "level": true,
-		"include": true, "exclude": true, "sampling": true,
+		"hostnames": true, "no_hostname": true, "output": true, "format": true,
+		"level": true, "sampling": true, "core": true,
 	},
 	"file_server": {
 		"fs": true, "root": true, "hide": true, "index": true, "browse": true,
@@ -132,19 +162,63 @@ var knownSubDirectives = map[string]map[string]bool{
 	"acme_server": {
 		"ca": true, "lifetime": true, "resolvers": true, "challenges": true,
 	},
+	// Source: modules/caddyhttp/push/caddyfile.go. Any other line names a
+	// resource to push; analyzePush checks the lines.
+	"push": {
+		"GET": true, "HEAD": true, "headers": true,
+	},
+	// Source: modules/caddyhttp/templates/caddyfile.go
 	"templates": {
-		"mime_type": true, "delimiters": true, "root": true, "extensions": true,
+		"mime": true, "between": true, "root": true, "extensions": true,
 	},
 	"tracing": {
 		"span": true,
 	},
-	// freeform bodies – structure is user-defined, not validated
+	"try_files": {
+		"policy": true,
+	},
+	// freeform bodies – not validated against a subdirective set; header
+	// operations and basicauth accounts are checked separately by
+	// analyzeHeader and analyzeBasicAuth.
 	"basicauth":      nil,
+	"basic_auth":     nil,
 	"header":         nil,
 	"request_header": nil,
 	"map":            nil,
 }
 
+// onceSubDirectives are the subdirectives a block may hold only once; Caddy
+// rejects or silently overrides a second occurrence. The others, such as
+// header_up and to, may be repeated.
+var onceSubDirectives = func() map[string]map[string]bool {
+	proxy := map[string]bool{
+		"transport": true, "dynamic": true,
+		"lb_policy": true, "lb_retries": true, "lb_try_duration": true, "lb_try_interval": true,
+		"health_uri": true, "health_port": true, "health_interval": true, "health_timeout": true,
+		"health_status": true, "health_body": true, "health_passes": true, "health_fails": true,
+		"health_request_body": true, "max_fails": true, "fail_duration": true,
+		"unhealthy_latency": true, "unhealthy_request_count": true,
+		"flush_interval": true, "request_buffers": true, "response_buffers": true,
+		"stream_timeout": true, "stream_close_delay": true,
+	}
+	php := map[string]bool{
+		"root": true, "split": true, "resolve_root_symlink": true, "index": true,
+		"dial_timeout": true, "read_timeout": true, "write_timeout": true, "capture_stderr": true,
+	}
+	for name := range proxy {
+		php[name] = true
+	}
+	return map[string]map[string]bool{
+		"reverse_proxy": proxy,
+		"php_fastcgi":   php,
+		"encode":        {"minimum_length": true},
+		"file_server":   {"fs": true, "root": true, "status": true},
+		"log":           {"output": true, "format": true, "level": true, "sampling": true},
+		"request_body":  {"max_size": true},
+		"tls":           {"protocols": true, "key_type": true, "on_demand": true},
+	}
+}()
+
 // knownSubSubDirectives maps a "subdirective:arg" key to the set of valid
 // sub-subdirective names inside its body block.  The key is formed from the
 // subdirective name and its first argument (e.g. "transport:http").
@@ -170,6 +244,15 @@ var knownSubSubDirectives = map[string]map[string]bool{
 		// misc
 		"versions": true, "compression": true, "max_conns_per_host": true,
 	},
+	"dynamic:a": {
+		"name": true, "port": true, "refresh": true, "resolvers": true,
+		"dial_timeout": true, "dial_fallback_delay": true, "versions": true,
+	},
+	"dynamic:srv": {
+		"service": true, "proto": true, "name": true, "refresh": true,
+		"resolvers": true, "dial_timeout": true, "dial_fallback_delay": true,
+		"grace_period": true,
+	},
 	"transport:fastcgi": {
 		"root": true, "split": true, "env": true,
 		"resolve_root_symlink": true, "dial_timeout": true,
@@ -187,58 +270,26 @@ var containerDirectives = map[string]bool{
 	"route":         true,
 }
 
-// KnownTopLevel is the set of directives valid at the site-block level.
-// Source: https://caddyserver.com/docs/caddyfile/directives
-var KnownTopLevel = map[string]bool{
-	// Core / routing
-	"abort":          true,
-	"error":          true,
-	"handle":         true,
-	"handle_errors":  true,
-	"handle_path":    true,
-	"invoke":         true,
-	"map":            true,
-	"method":         true,
-	"redir":          true,
-	"request_body":   true,
-	"respond":        true,
-	"rewrite":        true,
-	"route":          true,
-	"try_files":      true,
-	"uri":            true,
-	"vars":           true,
-	// Reverse proxy / fastcgi
-	"forward_auth":  true,
-	"php_fastcgi":   true,
-	"reverse_proxy": true,
-	// Static files
-	"file_server": true,
-	"push":        true,
-	"root":        true,
-	// TLS / PKI
-	"acme_server": true,
-	"tls":         true,
-	// Headers
-	"header":         true,
-	"request_header": true,
-	// Encoding / templates
-	"encode":    true,
-	"templates": true,
-	// Auth
-	"basicauth": true,
-	// Logging
-	"log":        true,
-	"log_append": true,
-	"log_skip":   true,
-	"log_name":   true,
-	// Observability
-	"intercept": true,
-	"metrics":   true,
-	"tracing":   true,
-	// Misc
-	"bind":        true,
-	"import":      true,
-	"local_certs": true,
+// KnownTopLevel is the set of directives valid at the site-block level: the
+// directives Caddy registers, listed in directives_gen.go, except those only
+// valid inside a reverse_proxy handle_response block, plus import.
+var KnownTopLevel = knownTopLevel()
+
+// responseOnlyDirectives are registered directives that are only valid
+// directly inside a reverse_proxy handle_response block.
+var responseOnlyDirectives = map[string]bool{
+	"copy_response":         true,
+	"copy_response_headers": true,
+}
+
+func knownTopLevel() map[string]bool {
+	known := map[string]bool{"import": true}
+	for _, name := range caddyDirectives {
+		if !responseOnlyDirectives[name] {
+			known[name] = true
+		}
+	}
+	return known
 }
 
 // SubDirectivesFor returns the set of valid subdirective names for parentName.
@@ -249,6 +300,14 @@ func SubDirectivesFor(parentName string) (subs map[string]bool, ok bool) {
 	return
 }
 
+// SubSubDirectivesFor returns the set of valid names inside the body of a
+// subdirective, keyed by the subdirective and its first argument as in
+// "transport:http". ok is false when the body is not validated.
+func SubSubDirectivesFor(key string) (subs map[string]bool, ok bool) {
+	subs, ok = knownSubSubDirectives[key]
+	return
+}
+
 // KnownGlobalOptions is the set of directives valid inside the global options block.
 // Source: https://caddyserver.com/docs/caddyfile/options
 var KnownGlobalOptions = map[string]bool{
@@ -267,6 +326,7 @@ var KnownGlobalOptions = map[string]bool{
 	"acme_eab":           true,
 	"cert_issuer":        true,
 	"skip_install_trust": true,
+	"filesystem":         true,
 	"email":              true,
 	"ocsp_stapling":      true,
 	"ocsp_interval":      true,
@@ -287,6 +347,21 @@ var KnownGlobalOptions = map[string]bool{
 // analyzer holds per-file state used during a single analysis pass.
 type analyzer struct {
 	snippets map[string]bool // snippet names defined in the file (without parens)
+	ordered  map[string]bool // custom directives placed by `order` global options
+	opts     Options
+	schema   *Schema
+	site     *parser.SiteBlock // site block being analyzed
+	// globalLogs holds the names of the log global options seen so far.
+	globalLogs map[string]bool
+	// filesystems holds the file system names the global options declare;
+	// declaredFilesystems those seen so far while checking them.
+	filesystems, declaredFilesystems map[string]bool
+	// snippetArgs holds the number of import arguments the snippets of
+	// the file use.
+	snippetArgs map[string]int
+	// imported reports whether another file imports this one, asking the
+	// options at most once.
+	imported func() bool
 }
 
 // CollectSnippetNames returns the names of all snippets defined in f, without
@@ -326,9 +401,9 @@ func collectSnippets(f *parser.File) map[string]bool {
 	return m
 }
 
-// isFileImport reports whether an import argument is a file path or glob
+// IsFileImport reports whether an import argument is a file path or glob
 // pattern. File imports are not validated against the snippet registry.
-func isFileImport(arg string) bool {
+func IsFileImport(arg string) bool {
 	return strings.Contains(arg, "/") ||
 		strings.Contains(arg, "*") ||
 		strings.Contains(arg, "\\") ||
@@ -341,6 +416,13 @@ func isCaddyPlaceholder(arg string) bool {
 	return strings.HasPrefix(arg, "{") && strings.HasSuffix(arg, "}")
 }
 
+// isMatcherToken reports whether arg is a matcher token as accepted in the
+// first argument position of most directives: a named matcher reference
+// (@name), a path matcher (/path) or the wildcard matcher (*).
+func isMatcherToken(arg string) bool {
+	return strings.HasPrefix(arg, "@") || strings.HasPrefix(arg, "/") || arg == "*"
+}
+
 func severityWarning() *protocol.DiagnosticSeverity {
 	s := protocol.DiagnosticSeverityWarning
 	return &s
@@ -355,29 +437,71 @@ func isSnippet(sb *parser.SiteBlock) bool {
 
 // Analyze walks the AST and returns diagnostics.
 func Analyze(f *parser.File) []protocol.Diagnostic {
-	a := &analyzer{snippets: collectSnippets(f)}
+	return AnalyzeWith(f, Options{})
+}
+
+// AnalyzeWith is like Analyze but takes the setup-specific options into
+// account.
+func AnalyzeWith(f *parser.File, opts Options) []protocol.Diagnostic {
 	var diags []protocol.Diagnostic
+	AnalyzeStream(f, opts, func(part []protocol.Diagnostic) {
+		diags = append(diags, part...)
+	})
+	return diags
+}
+
+// AnalyzeStream is like AnalyzeWith but hands the diagnostics to emit part
+// by part as soon as each is checked, in source order: first those of the
+// global options block and the top-level imports, then those of each site
+// block. Parts without diagnostics are skipped. A caller can so report the
+// problems at the top of a very large file while the rest is still being
+// checked.
+func AnalyzeStream(f *parser.File, opts Options, emit func([]protocol.Diagnostic)) {
+	a := &analyzer{snippets: collectSnippets(f), ordered: collectOrdered(f), opts: opts, schema: opts.schema(),
+		snippetArgs: collectSnippetArgs(f), filesystems: collectFilesystems(f)}
+	a.imported = sync.OnceValue(func() bool { return opts.Imported != nil && opts.Imported() })
+	placeholders := newPlaceholderSet(placeholderCatalog, opts.Plugins.Placeholders, mapDestinations(f))
+	flush := func(diags []protocol.Diagnostic) {
+		if len(diags) > 0 {
+			emit(diags)
+		}
+	}
 
+	var diags []protocol.Diagnostic
 	if f.GlobalBlock != nil {
 		for _, d := range f.GlobalBlock.Directives {
-			diags = append(diags, a.analyzeGlobalDirective(d)...)
+			diags = append(diags, withRule(RuleInvalidArgument, a.analyzeGlobalDirective(d))...)
+			diags = append(diags, analyzeDirectivePlaceholders(d)...)
 		}
+		diags = append(diags, a.analyzeImportArgs(func(fn func(parser.Token)) { directiveTokens(f.GlobalBlock.Directives, fn) }, false)...)
 	}
 
+	for _, d := range f.Imports {
+		diags = append(diags, a.analyzeImport(d)...)
+	}
+	diags = append(diags, a.analyzeImportArgs(func(fn func(parser.Token)) { directiveTokens(f.Imports, fn) }, false)...)
+	flush(diags)
+
 	for _, sb := range f.SiteBlocks {
 		// Snippets can be imported at any nesting level (e.g. inside a
 		// reverse_proxy block), so their bodies may legitimately contain
 		// subdirective-level tokens. Pass inSnippet=true to suppress the
 		// "must appear inside X" placement hint for those tokens.
 		inSnippet := isSnippet(sb)
+		a.site = sb
+		var diags []protocol.Diagnostic
 		for _, d := range sb.Directives {
-			diags = append(diags, a.analyzeSiteDirective(d, inSnippet)...)
+			diags = append(diags, withRule(RuleInvalidArgument, a.analyzeSiteDirective(d, inSnippet))...)
+		}
+		if !inSnippet {
+			diags = append(diags, a.analyzeTerminals(sb.Directives, false)...)
+			diags = append(diags, a.analyzeEncodeOrder(sb.Directives, false)...)
 		}
+		diags = append(diags, analyzeSitePlaceholders(sb)...)
+		diags = append(diags, analyzePlaceholderNames(sb, placeholders)...)
+		diags = append(diags, a.analyzeImportArgs(func(fn func(parser.Token)) { siteTokens(sb, fn) }, inSnippet)...)
+		flush(diags)
 	}
-
-	diags = append(diags, analyzeFilePlaceholders(f)...)
-
-	return diags
 }
 
 func (a *analyzer) analyzeGlobalDirective(d *parser.Directive) []protocol.Diagnostic {
@@ -385,16 +509,36 @@ func (a *analyzer) analyzeGlobalDirective(d *parser.Directive) []protocol.Diagno
 	if strings.HasPrefix(name, "@") {
 		return nil
 	}
-	if !KnownGlobalOptions[name] {
-		return []protocol.Diagnostic{{
+	if !a.schema.IsGlobalOption(name) {
+		return withRule(RuleUnknownOption, []protocol.Diagnostic{{
 			Range:    d.Name.Range(),
 			Severity: severityWarning(),
 			Source:   strPtr("caddy-ls"),
 			Message:  fmt.Sprintf("unknown global option %q", name),
-		}}
+		}})
 	}
-	if name == "import" {
+	switch name {
+	case "import":
 		return a.analyzeImport(d)
+	case "order":
+		return a.analyzeOrder(d)
+	case "servers":
+		return analyzeServers(d)
+	case "admin":
+		return analyzeAdmin(d)
+	case "storage":
+		return a.analyzeStorage(d)
+	case "cert_issuer":
+		return a.analyzeModuleArgument(d, "tls.issuance")
+	case "log":
+		return a.analyzeGlobalLog(d)
+	case "default_bind":
+		return analyzeBind(d)
+	case "filesystem":
+		return a.analyzeFilesystemOption(d)
+	}
+	if flagGlobalOptions[name] {
+		return analyzeFlagOption(d)
 	}
 	return nil
 }
@@ -409,9 +553,9 @@ func (a *analyzer) analyzeSiteDirective(d *parser.Directive, inSnippet bool) []p
 	name := d.Name.Value
 	// Named matcher declarations (@name) are always valid inside a site block.
 	if strings.HasPrefix(name, "@") {
-		return diags
+		return a.analyzeMatcherDefinition(d)
 	}
-	if !KnownTopLevel[name] {
+	if !a.schema.IsDirective(name) && !a.ordered[name] {
 		// Inside a snippet we don't know the import context, so a token that
 		// belongs to a known parent directive is accepted without complaint.
 		if inSnippet {
@@ -419,12 +563,10 @@ func (a *analyzer) analyzeSiteDirective(d *parser.Directive, inSnippet bool) []p
 				return diags
 			}
 		}
-		var msg string
 		if parent, ok := knownSubDirectiveParent[name]; ok {
-			msg = fmt.Sprintf("%q must appear inside a %q block, not at the site level", name, parent)
-		} else {
-			msg = fmt.Sprintf("unknown directive %q", name)
+			return append(diags, a.placementDiagnostic(d, parent))
 		}
+		msg := fmt.Sprintf("unknown directive %q", name)
 		diags = append(diags, protocol.Diagnostic{
 			Range:    d.Name.Range(),
 			Severity: severityWarning(),
@@ -432,7 +574,7 @@ func (a *analyzer) analyzeSiteDirective(d *parser.Directive, inSnippet bool) []p
 			Message:  msg,
 		})
 		// Don't attempt to validate the body of an unknown directive.
-		return diags
+		return withRule(RuleUnknownDirective, diags)
 	}
 
 	// import is handled separately so the snippet reference can be validated.
@@ -441,11 +583,60 @@ func (a *analyzer) analyzeSiteDirective(d *parser.Directive, inSnippet bool) []p
 		return diags
 	}
 
+	diags = append(diags, withRule(RuleCaddyVersion, a.checkDirectiveVersion(d.Name))...)
+	diags = append(diags, analyzeNoArgs(d)...)
+	diags = append(diags, a.validateDirective(d)...)
+
 	// Validate subdirectives inside the body block.
 	diags = append(diags, a.analyzeDirectiveBody(name, d.Body, inSnippet)...)
 	return diags
 }
 
+// validateDirective runs the checks specific to a single directive, such as
+// argument forms or the structure of a freeform body, in addition to the
+// generic subdirective validation.
+func (a *analyzer) validateDirective(d *parser.Directive) []protocol.Diagnostic {
+	switch d.Name.Value {
+	case "header", "request_header":
+		return analyzeHeader(d)
+	case "basicauth", "basic_auth":
+		return analyzeBasicAuth(d)
+	case "try_files":
+		return analyzeTryFiles(d)
+	case "uri":
+		return analyzeURI(d)
+	case "reverse_proxy":
+		return a.analyzeReverseProxy(d)
+	case "intercept":
+		return a.analyzeIntercept(d)
+	case "handle_path":
+		return analyzeHandlePath(d)
+	case "php_fastcgi":
+		return a.analyzePHPFastCGI(d)
+	case "tls":
+		return a.analyzeTLS(d)
+	case "vars":
+		return analyzeVars(d)
+	case "handle_errors":
+		return analyzeHandleErrors(d)
+	case "log":
+		return analyzeSiteLog(d)
+	case "bind":
+		return analyzeBind(d)
+	case "push":
+		return analyzePush(d)
+	case "templates":
+		return analyzeTemplates(d)
+	case "fs":
+		return a.analyzeFS(d)
+	case "encode":
+		return analyzeEncode(d)
+	case "file_server":
+		return a.analyzeFileServer(d)
+	}
+	return nil
+}
+
 // analyzeDirectiveBody validates the subdirectives inside a directive's body block.
 func (a *analyzer) analyzeDirectiveBody(parentName string, body []*parser.Directive, inSnippet bool) []protocol.Diagnostic {
 	if len(body) == 0 {
@@ -461,7 +652,7 @@ func (a *analyzer) analyzeDirectiveBody(parentName string, body []*parser.Direct
 		return diags
 	}
 
-	subDirs, known := knownSubDirectives[parentName]
+	subDirs, known := a.schema.SubDirectivesFor(parentName)
 	if !known || subDirs == nil {
 		// Either we have no subdirective list for this directive, or it is
 		// explicitly marked as freeform (nil). Skip body validation.
@@ -481,12 +672,19 @@ func (a *analyzer) analyzeDirectiveBody(parentName string, body []*parser.Direct
 			continue
 		}
 		if !subDirs[subName] {
-			diags = append(diags, protocol.Diagnostic{
+			if parentName == "push" {
+				continue // a resource line
+			}
+			if msg, ok := misplacedLogOption(parentName, subName); ok {
+				diags = append(diags, ruleError(RuleMisplacedDirective, sub.Name.Range(), "%s", msg))
+				continue
+			}
+			diags = append(diags, withRule(RuleUnknownSubdirective, []protocol.Diagnostic{{
 				Range:    sub.Name.Range(),
 				Severity: severityWarning(),
 				Source:   strPtr("caddy-ls"),
 				Message:  fmt.Sprintf("unknown subdirective %q for %q", subName, parentName),
-			})
+			}})...)
 			continue
 		}
 		// Validate sub-subdirective bodies when we know the schema
@@ -523,12 +721,12 @@ func (a *analyzer) analyzeNestedBody(validDirs map[string]bool, parent *parser.D
 			continue
 		}
 		if !validDirs[subName] {
-			diags = append(diags, protocol.Diagnostic{
+			diags = append(diags, withRule(RuleUnknownSubdirective, []protocol.Diagnostic{{
 				Range:    sub.Name.Range(),
 				Severity: severityWarning(),
 				Source:   strPtr("caddy-ls"),
 				Message:  fmt.Sprintf("unknown subdirective %q for %q %q", subName, grandparentName, qualifiedParent),
-			})
+			}})...)
 		}
 	}
 	return diags
@@ -542,18 +740,18 @@ func (a *analyzer) analyzeImport(d *parser.Directive) []protocol.Diagnostic {
 		return nil
 	}
 	arg := d.Args[0].Token.Value
-	if isFileImport(arg) || isCaddyPlaceholder(arg) {
+	if IsFileImport(arg) || isCaddyPlaceholder(arg) {
 		return nil
 	}
 	if !a.snippets[arg] {
-		return []protocol.Diagnostic{{
+		return withRule(RuleUndefinedSnippet, []protocol.Diagnostic{{
 			Range:    d.Args[0].Range(),
 			Severity: severityWarning(),
 			Source:   strPtr("caddy-ls"),
 			Message:  fmt.Sprintf("undefined snippet %q", arg),
-		}}
+		}})
 	}
-	return nil
+	return a.importArgCount(d)
 }
 
 func strPtr(s string) *string { return &s }
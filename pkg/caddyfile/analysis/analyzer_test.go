@@ -1,7 +1,8 @@
 package analysis
 
 import (
-	"caddy-ls/internal/parser"
+	"caddy-ls/pkg/caddyfile/parser"
+	"reflect"
 	"strings"
 	"testing"
 
@@ -165,8 +166,11 @@ func TestAnalyze_MultipleSiteBlocks(t *testing.T) {
 // --- global options block validation -----------------------------------------
 
 func TestAnalyze_GlobalKnownOptionNoWarning(t *testing.T) {
-	for _, name := range []string{"email", "http_port", "https_port", "admin", "storage", "log"} {
-		diags := analyze("{\n\t" + name + " foo\n}\nexample.com {\n\trespond \"ok\"\n}\n")
+	for name, arg := range map[string]string{
+		"email": "foo", "http_port": "foo", "https_port": "foo",
+		"admin": "localhost:2019", "storage": "file_system /data", "log": "foo",
+	} {
+		diags := analyze("{\n\t" + name + " " + arg + "\n}\nexample.com {\n\trespond \"ok\"\n}\n")
 		if len(diags) != 0 {
 			t.Errorf("global option %q: expected no diagnostics, got %d: %v", name, len(diags), diags)
 		}
@@ -274,9 +278,10 @@ func TestAnalyze_ContainerDirective_InvalidContents(t *testing.T) {
 }
 
 func TestAnalyze_FreeformBody_NoWarning(t *testing.T) {
-	// basicauth, header, map have freeform bodies that must not be validated.
+	// basicauth, header, map have freeform bodies that must not be validated
+	// against a subdirective set.
 	cases := []string{
-		"example.com {\n\tbasicauth {\n\t\tBob $2y$10$abc\n\t}\n}\n",
+		"example.com {\n\tbasicauth {\n\t\tBob $2a$14$Zkx19XLiW6VYouLHR5NmfOFU0z2GTNmpkT/5qqR7hx4IjWJPDhjvG\n\t}\n}\n",
 		"example.com {\n\theader {\n\t\tX-Custom-Header value\n\t\t-X-Remove-Me\n\t}\n}\n",
 		"example.com {\n\tmap {path} {output} {\n\t\t/foo bar\n\t\tdefault baz\n\t}\n}\n",
 	}
@@ -366,6 +371,13 @@ func TestAnalyze_ImportUndefinedSnippet_Warning(t *testing.T) {
 	}
 }
 
+func TestAnalyze_TopLevelImportUndefinedSnippet_Warning(t *testing.T) {
+	src := "import ghost\nexample.com {\n\trespond \"ok\"\n}\n"
+	if diags := analyze(src); len(diags) != 1 || !hasMsg(diags, `undefined snippet "ghost"`) {
+		t.Errorf("top-level import of undefined snippet: got %v", diags)
+	}
+}
+
 func TestAnalyze_ImportFilePath_NoWarning(t *testing.T) {
 	// Paths and globs reference external files and must not be validated.
 	cases := []string{
@@ -505,7 +517,7 @@ func TestParseSnippetName_Invalid(t *testing.T) {
 	}
 }
 
-// --- isFileImport ------------------------------------------------------------
+// --- IsFileImport ------------------------------------------------------------
 
 func TestIsFileImport_Paths(t *testing.T) {
 	cases := []string{
@@ -516,8 +528,8 @@ func TestIsFileImport_Paths(t *testing.T) {
 		`C:\caddy\conf`,
 	}
 	for _, c := range cases {
-		if !isFileImport(c) {
-			t.Errorf("isFileImport(%q): expected true", c)
+		if !IsFileImport(c) {
+			t.Errorf("IsFileImport(%q): expected true", c)
 		}
 	}
 }
@@ -525,8 +537,8 @@ func TestIsFileImport_Paths(t *testing.T) {
 func TestIsFileImport_SnippetNames(t *testing.T) {
 	cases := []string{"mysnippet", "common_tls", "backend", "tls_opts"}
 	for _, c := range cases {
-		if isFileImport(c) {
-			t.Errorf("isFileImport(%q): expected false", c)
+		if IsFileImport(c) {
+			t.Errorf("IsFileImport(%q): expected false", c)
 		}
 	}
 }
@@ -641,3 +653,63 @@ func TestKnownGlobalOptions_NotEmpty(t *testing.T) {
 		t.Error("KnownGlobalOptions must not be empty")
 	}
 }
+
+// --- order -------------------------------------------------------------------
+
+func TestAnalyze_Order_Valid_NoWarning(t *testing.T) {
+	src := "{\n\torder rate_limit before basic_auth\n\torder cache first\n\torder geoip after rate_limit\n\torder replace last\n}\nexample.com {\n\trate_limit {\n\t\tzone api\n\t}\n\tcache\n\treverse_proxy app:8080\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("valid order options and ordered plugin directives: expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyze_Order_Problems(t *testing.T) {
+	cases := map[string]string{
+		"order":                            "order requires a directive name and a position",
+		"order mydir":                      `order "mydir" requires a position`,
+		"order mydir middle":               `unrecognized order position "middle"`,
+		"order mydir befor header":         `(did you mean "before"?)`,
+		"order mydir before":               `order "mydir" before requires a directive to position against`,
+		"order mydir after revers_proxy":   `unknown directive "revers_proxy" (did you mean "reverse_proxy"?)`,
+		"order mydir first header":         `unexpected argument "header" after order first`,
+		"order mydir after header respond": `unexpected argument "respond" after order after`,
+		"order mydir before mydir":         `"mydir" cannot be ordered relative to itself`,
+	}
+	for line, want := range cases {
+		src := "{\n\t" + line + "\n}\n"
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", line, want, diags)
+		}
+	}
+}
+
+func TestOrderedDirectives(t *testing.T) {
+	f, _ := parser.Parse("{\n\torder rate_limit before basic_auth\n\torder cache first\n}\n")
+	if got := OrderedDirectives(f); len(got) != 2 || got[0] != "cache" || got[1] != "rate_limit" {
+		t.Errorf("OrderedDirectives = %v, want [cache rate_limit]", got)
+	}
+}
+
+func TestAnalyzeStream_PartsInSourceOrder(t *testing.T) {
+	src := "{\n\tbogus_option\n}\na.com {\n\tbogus\n}\nb.com {\n\trespond ok\n}\nc.com {\n\trespond {http.request.urI}\n}\n"
+	f, _ := parser.Parse(src)
+	var parts [][]protocol.Diagnostic
+	AnalyzeStream(f, Options{}, func(part []protocol.Diagnostic) {
+		parts = append(parts, part)
+	})
+	if len(parts) != 3 {
+		t.Fatalf("want parts for the global block, a.com and c.com, got %v", parts)
+	}
+	for i, want := range []string{"bogus_option", `"bogus"`, "{http.request.urI}"} {
+		if !hasMsg(parts[i], want) {
+			t.Errorf("part %d: want %q, got %v", i, want, parts[i])
+		}
+	}
+	var all []protocol.Diagnostic
+	for _, part := range parts {
+		all = append(all, part...)
+	}
+	if !reflect.DeepEqual(all, AnalyzeWith(f, Options{})) {
+		t.Errorf("parts differ from AnalyzeWith")
+	}
+}
@@ -0,0 +1,32 @@
+package analysis
+
+// ArgParam is one argument of a request matcher or directive.
+type ArgParam struct {
+	Label string `json:"label"` // e.g. "<field>" or "[<value>]"
+	Doc   string `json:"doc,omitempty"`
+	// Variadic marks a last parameter that takes all remaining arguments.
+	Variadic bool `json:"variadic,omitempty"`
+}
+
+// ArgForm is one way of writing the arguments of a request matcher or
+// directive on its line, e.g. `header <field> [<value>]`.
+type ArgForm struct {
+	Params []ArgParam `json:"params"`
+}
+
+// ActiveForm picks the form in forms for a line with count arguments whose
+// argument at index arg (0-based) is being edited, and returns it with the
+// index of the parameter that argument binds to. The first form with room
+// for all the arguments wins; when none has, the last is used.
+func ActiveForm(forms []ArgForm, arg, count int) (form, param int) {
+	need := max(arg+1, count)
+	form = len(forms) - 1
+	for i, f := range forms {
+		last := len(f.Params) - 1
+		if need <= len(f.Params) || (last >= 0 && f.Params[last].Variadic) {
+			form = i
+			break
+		}
+	}
+	return form, min(arg, len(forms[form].Params)-1)
+}
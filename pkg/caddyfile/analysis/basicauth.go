@@ -0,0 +1,85 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"encoding/base64"
+	"regexp"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// basicAuthAlgorithms are the hash algorithms accepted by basic_auth.
+var basicAuthAlgorithms = map[string]bool{"bcrypt": true, "argon2id": true}
+
+// bcryptHashRE matches a modular-crypt bcrypt hash such as the output of
+// `caddy hash-password`: $2a$<cost>$<53 chars of salt+digest>.
+var bcryptHashRE = regexp.MustCompile(`^\$2[abxy]?\$\d{2}\$[./A-Za-z0-9]{53}$`)
+
+// analyzeBasicAuth validates the optional hash algorithm and realm arguments
+// of basic_auth (and its deprecated basicauth spelling) and checks that every
+// body line is a `<username> <hashed_password>` pair.
+func analyzeBasicAuth(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+
+	args := d.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	algorithm := "bcrypt"
+	if len(args) > 0 {
+		algorithm = args[0].Token.Value
+		if !basicAuthAlgorithms[algorithm] && !isCaddyPlaceholder(algorithm) {
+			diags = append(diags, warningf(args[0].Range(), "unrecognized hash algorithm %q (expected bcrypt or argon2id)", algorithm))
+		}
+	}
+	for _, extra := range args[min(len(args), 2):] {
+		diags = append(diags, warningf(extra.Range(), "unexpected argument %q: %s takes at most a hash algorithm and a realm", extra.Token.Value, d.Name.Value))
+	}
+
+	for _, entry := range d.Body {
+		user := entry.Name.Value
+		if user == "import" || strings.HasPrefix(user, "@") {
+			continue
+		}
+		if len(entry.Args) == 0 {
+			diags = append(diags, warningf(entry.Name.Range(), "missing password hash for user %q", user))
+			continue
+		}
+		for _, extra := range entry.Args[1:] {
+			diags = append(diags, warningf(extra.Range(), "unexpected token %q: expected `<username> <hashed_password>`", extra.Token.Value))
+		}
+		if msg := checkPasswordHash(entry.Args[0].Token.Value, algorithm); msg != "" {
+			diags = append(diags, warningf(entry.Args[0].Range(), "user %q: %s", user, msg))
+		}
+	}
+	return diags
+}
+
+// checkPasswordHash returns a problem description if hash does not look like
+// a valid hash for algorithm, or "" if it does. Placeholders are accepted, as
+// are hashes wrapped in the base64 encoding older Caddy versions required.
+func checkPasswordHash(hash, algorithm string) string {
+	if isCaddyPlaceholder(hash) || isCaddyPlaceholder(algorithm) {
+		return ""
+	}
+	if !strings.HasPrefix(hash, "$") {
+		if decoded, err := base64.StdEncoding.DecodeString(hash); err == nil && strings.HasPrefix(string(decoded), "$") {
+			hash = string(decoded)
+		}
+	}
+	if !strings.HasPrefix(hash, "$") {
+		return "password looks like plaintext; basic_auth expects a hash generated with `caddy hash-password`"
+	}
+	switch algorithm {
+	case "bcrypt":
+		if !bcryptHashRE.MatchString(hash) {
+			return "malformed bcrypt hash (expected 60 characters starting with $2a$, $2b$ or $2y$)"
+		}
+	case "argon2id":
+		if !strings.HasPrefix(hash, "$argon2id$") {
+			return "malformed argon2id hash (expected a PHC string starting with $argon2id$)"
+		}
+	}
+	return ""
+}
@@ -0,0 +1,55 @@
+package analysis
+
+import "testing"
+
+const testBcryptHash = "$2a$14$Zkx19XLiW6VYouLHR5NmfOFU0z2GTNmpkT/5qqR7hx4IjWJPDhjvG"
+
+func TestAnalyze_BasicAuth_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"example.com {\n\tbasic_auth {\n\t\tBob " + testBcryptHash + "\n\t}\n}\n",
+		"example.com {\n\tbasicauth /admin/* bcrypt \"Admin area\" {\n\t\tBob " + testBcryptHash + "\n\t}\n}\n",
+		"example.com {\n\tbasic_auth {\n\t\tBob {$BOB_HASH}\n\t}\n}\n",
+		"example.com {\n\tbasic_auth argon2id {\n\t\tBob $argon2id$v=19$m=47104,t=1,p=1$c2FsdA$a2V5\n\t}\n}\n",
+		// Base64-wrapped hashes are still accepted by Caddy.
+		"example.com {\n\tbasic_auth {\n\t\tBob JDJhJDE0JFpreDE5WExpVzZWWW91TEhSNU5tZk9GVTB6MkdUTm1wa1QvNXFxUjdoeDRJaldKUERoanZH\n\t}\n}\n",
+	}
+	for _, src := range cases {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %d: %v", src, len(diags), diags)
+		}
+	}
+}
+
+func TestAnalyze_BasicAuth_PlaintextPassword(t *testing.T) {
+	diags := analyze("example.com {\n\tbasic_auth {\n\t\tBob hunter2\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, `"Bob"`, "plaintext") {
+		t.Fatalf("expected plaintext warning, got %v", diags)
+	}
+	if diags[0].Range.Start.Line != 2 || diags[0].Range.Start.Character != 6 {
+		t.Errorf("diagnostic should point at the password, got %v", diags[0].Range)
+	}
+}
+
+func TestAnalyze_BasicAuth_MalformedBcrypt(t *testing.T) {
+	diags := analyze("example.com {\n\tbasic_auth {\n\t\tBob $2y$10$abc\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "malformed bcrypt hash") {
+		t.Errorf("expected malformed hash warning, got %v", diags)
+	}
+}
+
+func TestAnalyze_BasicAuth_MissingAndExtraTokens(t *testing.T) {
+	diags := analyze("example.com {\n\tbasic_auth {\n\t\tAlice\n\t\tBob " + testBcryptHash + " extra\n\t}\n}\n")
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %d: %v", len(diags), diags)
+	}
+	if !hasMsg(diags, "missing password hash", `"Alice"`) || !hasMsg(diags, "unexpected token", `"extra"`) {
+		t.Errorf("unexpected messages: %v", diags)
+	}
+}
+
+func TestAnalyze_BasicAuth_UnknownAlgorithm(t *testing.T) {
+	diags := analyze("example.com {\n\tbasic_auth scrypt {\n\t\tBob " + testBcryptHash + "\n\t}\n}\n")
+	if !hasMsg(diags, "unrecognized hash algorithm", "scrypt") {
+		t.Errorf("expected unknown algorithm warning, got %v", diags)
+	}
+}
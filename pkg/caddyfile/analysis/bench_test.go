@@ -0,0 +1,35 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeCaddyfile builds a synthetic multi-tenant Caddyfile with roughly the
+// given number of lines.
+func largeCaddyfile(lines int) string {
+	var b strings.Builder
+	b.WriteString("{\n\temail admin@example.com\n}\n\n(common) {\n\tencode zstd gzip\n\theader -Server\n}\n\n")
+	for i, n := 0, 9; n < lines; i, n = i+1, n+11 {
+		fmt.Fprintf(&b, "tenant%d.example.com, www.tenant%d.example.com {\n", i, i)
+		b.WriteString("\timport common\n")
+		fmt.Fprintf(&b, "\t@api path /api/* # tenant %d\n", i)
+		b.WriteString("\treverse_proxy @api app:8080 {\n")
+		b.WriteString("\t\tlb_policy round_robin\n")
+		b.WriteString("\t\theader_up X-Tenant \"{host}\"\n")
+		b.WriteString("\t}\n")
+		fmt.Fprintf(&b, "\troot * /srv/tenants/%d\n", i)
+		b.WriteString("\tfile_server\n")
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkAnalyze10k(b *testing.B) {
+	f, _ := parser.Parse(largeCaddyfile(10000))
+	for b.Loop() {
+		Analyze(f)
+	}
+}
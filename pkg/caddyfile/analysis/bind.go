@@ -0,0 +1,113 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"net"
+	"regexp"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// bindNetworks are the network prefixes a bind address may carry, as in
+// tcp4/127.0.0.1 or unix//run/caddy.sock.
+// Source: listeners.go (ParseNetworkAddress, IsUnixNetwork, IsFdNetwork)
+var bindNetworks = map[string]bool{
+	"tcp": true, "tcp4": true, "tcp6": true,
+	"udp": true, "udp4": true, "udp6": true,
+	"unix": true, "unixgram": true, "unixpacket": true,
+	"fd": true, "fdgram": true,
+}
+
+// dottedNumbers matches hosts made of digits and dots only, which are meant
+// as IPv4 addresses even when they are not valid ones.
+var dottedNumbers = regexp.MustCompile(`^[0-9.]+$`)
+
+// analyzeBind checks the addresses of the bind directive or the
+// default_bind global option and the protocols in its block. The options of
+// a bind directive's block are checked like other subdirectives; those of
+// default_bind are checked here.
+// Source: caddyconfig/httpcaddyfile/builtins.go (parseBind) and options.go
+// (parseOptDefaultBind)
+func analyzeBind(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	if d.Name.Value == "bind" && len(d.Args) == 0 {
+		diags = append(diags, errorf(d.Name.Range(), "bind requires at least one address"))
+	}
+	for _, arg := range d.Args {
+		tok := arg.Token
+		if strings.Contains(tok.Value, "{") { // placeholders are resolved at runtime
+			continue
+		}
+		if msg, severity := bindAddressProblem(tok.Value); msg != "" {
+			diags = append(diags, newDiag(tok.Range(), severity, "%s", msg))
+		}
+	}
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "protocols":
+			if len(sub.Args) == 0 {
+				diags = append(diags, errorf(sub.Name.Range(), "protocols requires one or more arguments"))
+			}
+			diags = append(diags, analyzeServerProtocols(sub)...)
+		case "import":
+		default:
+			if d.Name.Value == "default_bind" {
+				diags = append(diags, unknownInBlock(sub.Name, "default_bind option", "default_bind")...)
+			}
+		}
+	}
+	return diags
+}
+
+// bindAddressProblem describes what is wrong with the bind address addr and
+// how severe it is, or returns "" when Caddy can listen on it. A bind
+// address is a host without a port, which comes from the site address,
+// optionally prefixed by a network.
+func bindAddressProblem(addr string) (string, protocol.DiagnosticSeverity) {
+	if strings.HasPrefix(addr, "/") {
+		return fmt.Sprintf("a unix socket needs the unix/ prefix, e.g. unix/%s", addr), protocol.DiagnosticSeverityError
+	}
+	host := addr
+	if network, rest, ok := strings.Cut(addr, "/"); ok {
+		network = strings.ToLower(network)
+		if !bindNetworks[network] {
+			if _, _, err := net.ParseCIDR(addr); err == nil {
+				return fmt.Sprintf("bind takes IP addresses, not CIDR ranges such as %q", addr), protocol.DiagnosticSeverityWarning
+			}
+			if net.ParseIP(network) != nil {
+				return fmt.Sprintf("malformed CIDR %q; bind takes IP addresses, not ranges", addr), protocol.DiagnosticSeverityWarning
+			}
+			return fmt.Sprintf("unknown network %q in bind address %q", network, addr), protocol.DiagnosticSeverityError
+		}
+		switch {
+		case strings.HasPrefix(network, "unix"):
+			path, perm, hasPerm := strings.Cut(rest, "|")
+			switch {
+			case path == "":
+				return fmt.Sprintf("missing socket path in bind address %q", addr), protocol.DiagnosticSeverityError
+			case hasPerm && !socketPermissions.MatchString(perm):
+				return fmt.Sprintf("invalid socket permissions %q: want octal digits such as 0220", perm), protocol.DiagnosticSeverityError
+			}
+			return "", 0
+		case strings.HasPrefix(network, "fd"):
+			return "", 0
+		}
+		host = rest
+	}
+	if h, port, err := net.SplitHostPort(host); err == nil {
+		return fmt.Sprintf("bind takes an address without a port; use %q and give port %s in the site address", h, port), protocol.DiagnosticSeverityError
+	}
+	ip := strings.Trim(host, "[]")
+	if i := strings.IndexByte(ip, '%'); i > 0 { // zone of a link-local address, e.g. fe80::1%eth0
+		ip = ip[:i]
+	}
+	switch {
+	case host == "" || net.ParseIP(ip) != nil:
+		return "", 0
+	case dottedNumbers.MatchString(ip) || strings.Contains(ip, ":"):
+		return fmt.Sprintf("invalid IP address %q", host), protocol.DiagnosticSeverityError
+	}
+	return fmt.Sprintf("bind address %q is a host name; Caddy listens on the address it resolves to at startup, so prefer an IP address", host), protocol.DiagnosticSeverityWarning
+}
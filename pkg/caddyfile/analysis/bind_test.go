@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyzeBind_Addresses(t *testing.T) {
+	for addr, want := range map[string]string{
+		"127.0.0.1":                 "",
+		"::1":                       "",
+		"[::1]":                     "",
+		"fe80::1%eth0":              "",
+		"tcp4/0.0.0.0":              "",
+		"unix//run/caddy.sock":      "",
+		"unix//run/caddy.sock|0222": "",
+		"fd/3":                      "",
+		"{$BIND_HOST}":              "",
+		"localhost":                 `bind address "localhost" is a host name`,
+		"10.0.0.0/8":                `bind takes IP addresses, not CIDR ranges such as "10.0.0.0/8"`,
+		"10.0.0.0/40":               `malformed CIDR "10.0.0.0/40"`,
+		"127.0.0.1:8080":            `bind takes an address without a port; use "127.0.0.1" and give port 8080 in the site address`,
+		"256.1.1.1":                 `invalid IP address "256.1.1.1"`,
+		"sctp/127.0.0.1":            `unknown network "sctp"`,
+		"unix/":                     `missing socket path`,
+		"/run/caddy.sock":           "a unix socket needs the unix/ prefix",
+	} {
+		diags := analyze("example.com {\n\tbind " + addr + "\n}\n")
+		if want == "" {
+			if len(diags) != 0 {
+				t.Errorf("bind %s: want no diagnostics, got %v", addr, diags)
+			}
+			continue
+		}
+		if len(diags) != 1 || !hasMsg(diags, want) {
+			t.Errorf("bind %s: want %q, got %v", addr, want, diags)
+		}
+	}
+}
+
+func TestAnalyzeBind_Severity(t *testing.T) {
+	diags := analyze("example.com {\n\tbind localhost 127.0.0.1:80\n}\n")
+	if len(diags) != 2 || *diags[0].Severity != protocol.DiagnosticSeverityWarning || *diags[1].Severity != protocol.DiagnosticSeverityError {
+		t.Errorf("want a warning for the host name and an error for the port, got %v", diags)
+	}
+}
+
+func TestAnalyzeBind_DefaultBindAndProtocols(t *testing.T) {
+	src := "{\n" +
+		"\tdefault_bind 10.0.0.1 localhost {\n" +
+		"\t\tprotocols h1 h4\n" +
+		"\t\tprotocol h2\n" +
+		"\t}\n" +
+		"}\n" +
+		"example.com {\n" +
+		"\tbind 127.0.0.1 {\n" +
+		"\t\tprotocols\n" +
+		"\t\tport 80\n" +
+		"\t}\n" +
+		"}\n"
+	diags := analyze(src)
+	for _, want := range [][]string{
+		{`bind address "localhost" is a host name`},
+		{`unknown protocol "h4"`},
+		{`unrecognized default_bind option "protocol"`, `did you mean "protocols"?`},
+		{"protocols requires one or more arguments"},
+		{`unknown subdirective "port" for "bind"`},
+	} {
+		if !hasMsg(diags, want...) {
+			t.Errorf("missing %q in %v", want, diags)
+		}
+	}
+	if len(diags) != 5 {
+		t.Errorf("want 5 diagnostics, got %d: %v", len(diags), diags)
+	}
+}
@@ -0,0 +1,256 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// clientAuthBlocks maps the blocks of a tls client_auth option to the names
+// valid in them. Trust pool blocks are keyed by their provider, as in
+// "trust_pool file", and the tls block of the http provider by the path
+// "trust_pool http>tls". Trust pools configured elsewhere, such as with
+// `tls_trust_pool` in `transport http`, use the same keys.
+// Source: modules/caddytls/connpolicy.go (ClientAuthentication),
+// modules/caddytls/capools.go
+var clientAuthBlocks = map[string]map[string]bool{
+	"client_auth": {
+		"mode": true, "trust_pool": true, "trusted_leaf_cert": true, "trusted_leaf_cert_file": true,
+		"verifier": true, "trusted_ca_cert": true, "trusted_ca_cert_file": true,
+	},
+	"trust_pool inline":           {"trust_der": true},
+	"trust_pool file":             {"pem_file": true},
+	"trust_pool pki_root":         {"authority": true},
+	"trust_pool pki_intermediate": {"authority": true},
+	"trust_pool storage":          {"storage": true, "keys": true},
+	"trust_pool http":             {"endpoints": true, "tls": true},
+	"trust_pool http>tls": {
+		"ca": true, "insecure_skip_verify": true, "handshake_timeout": true,
+		"server_name": true, "renegotiation": true,
+	},
+}
+
+// clientAuthModes are the values of `client_auth { mode }`. Only the
+// verifying ones check client certificates against the trust pool.
+var clientAuthModes = []string{"request", "require", "verify_if_given", "require_and_verify"}
+
+// renegotiationLevels are the values of `renegotiation` in the tls block of
+// an http trust pool.
+var renegotiationLevels = []string{"never", "once", "freely"}
+
+// trustPoolProviders are the built-in trust pool providers.
+var trustPoolProviders = func() []string {
+	var names []string
+	for key := range clientAuthBlocks {
+		if name, ok := strings.CutPrefix(key, "trust_pool "); ok && !strings.Contains(name, ">") {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// TrustPoolProviders returns the names of the built-in trust pool providers,
+// as taken by `trust_pool` and `tls_trust_pool`.
+func TrustPoolProviders() []string {
+	return slices.Clone(trustPoolProviders)
+}
+
+// ClientAuthModes returns the values of `client_auth { mode }`.
+func ClientAuthModes() []string {
+	return slices.Clone(clientAuthModes)
+}
+
+// ClientAuthBlockNames returns the sorted names valid in the body of the
+// innermost directive of chain, a client_auth block or a trust pool block
+// inside one, given with the directives enclosing it, outermost first. ok is
+// false for other blocks.
+func ClientAuthBlockNames(chain []*parser.Directive) (names []string, ok bool) {
+	set, ok := clientAuthBlocks[ClientAuthBlockKey(chain)]
+	if !ok {
+		return nil, false
+	}
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// ClientAuthOptionsFor returns the set of names valid in the client_auth or
+// trust pool block with the given key, as ClientAuthBlockKey names it.
+func ClientAuthOptionsFor(key string) (opts map[string]bool, ok bool) {
+	opts, ok = clientAuthBlocks[key]
+	return
+}
+
+// ClientAuthBlockKey names the block of the innermost directive of chain,
+// given with the directives enclosing it, outermost first: "client_auth",
+// a trust pool by its provider as in "trust_pool file", or
+// "trust_pool http>tls" for the tls block of an http trust pool. It returns
+// "" for other blocks.
+func ClientAuthBlockKey(chain []*parser.Directive) string {
+	if len(chain) == 0 {
+		return ""
+	}
+	d := chain[len(chain)-1]
+	parent := ClientAuthBlockKey(chain[:len(chain)-1])
+	switch name := d.Name.Value; {
+	case name == "client_auth":
+		return "client_auth"
+	case name == "trust_pool" && parent == "client_auth", name == "tls_trust_pool",
+		name == "ca" && parent == "trust_pool http>tls":
+		if len(d.Args) > 0 {
+			return "trust_pool " + d.Args[0].Token.Value
+		}
+	case name == "tls" && parent == "trust_pool http":
+		return parent + ">tls"
+	}
+	return ""
+}
+
+// analyzeClientAuth checks a `client_auth` block: its option names, the
+// mode, the trust pools it configures and whether the mode verifies client
+// certificates against them at all.
+// Source: modules/caddytls/connpolicy.go (ClientAuthentication.UnmarshalCaddyfile)
+func (a *analyzer) analyzeClientAuth(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	var mode, pool, legacy *parser.Directive
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		if name == "import" {
+			continue
+		}
+		if !clientAuthBlocks["client_auth"][name] {
+			diags = append(diags, unknownClientAuthOption(sub.Name, "client_auth option", "client_auth")...)
+			continue
+		}
+		switch name {
+		case "mode":
+			mode = sub
+			if len(sub.Args) != 1 {
+				diags = append(diags, warningf(sub.Name.Range(), "mode takes one of %s", joinQuoted(clientAuthModes)))
+				continue
+			}
+			diags = append(diags, checkOneOf(sub.Args[0].Token, "client_auth mode", clientAuthModes)...)
+		case "trust_pool":
+			pool = sub
+			diags = append(diags, a.analyzeTrustPool(sub)...)
+		case "trusted_ca_cert", "trusted_ca_cert_file":
+			legacy = sub
+			replacement := "trust_pool inline { trust_der <base64_der> }"
+			if name == "trusted_ca_cert_file" {
+				replacement = "trust_pool file <pem_file>"
+			}
+			diag := warningf(sub.Name.Range(), "%s is deprecated; use %s", name, replacement)
+			diag.Tags = []protocol.DiagnosticTag{protocol.DiagnosticTagDeprecated}
+			diags = append(diags, diag)
+		case "verifier":
+			diags = append(diags, a.analyzeModuleArgument(sub, "tls.client_auth.verifier")...)
+		case "trusted_leaf_cert", "trusted_leaf_cert_file":
+			if len(sub.Args) == 0 {
+				diags = append(diags, warningf(sub.Name.Range(), "%s requires an argument", name))
+			}
+		}
+	}
+	if pool != nil && legacy != nil {
+		diags = append(diags, errorf(legacy.Name.Range(), "%s cannot be combined with trust_pool", legacy.Name.Value))
+	}
+	if pool != nil && mode != nil && len(mode.Args) == 1 {
+		if m := mode.Args[0].Token.Value; m == "request" || m == "require" {
+			diags = append(diags, warningf(mode.Args[0].Range(),
+				"mode %s does not verify client certificates, so the trust_pool is not used; use require_and_verify, or verify_if_given to make certificates optional", m))
+		}
+	}
+	return diags
+}
+
+// analyzeTrustPool checks `trust_pool <provider> [<args...>] { ... }` and
+// the same form under other names, such as `tls_trust_pool` in
+// `transport http`: the provider, the options of its block and that it is
+// given something to trust. Providers from plugins cannot be told apart from
+// typos, so an unknown provider is only a warning. The blocks of providers
+// declared by plugins are not checked.
+// Source: modules/caddytls/capools.go
+func (a *analyzer) analyzeTrustPool(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "%s requires a provider: one of %s", d.Name.Value, joinQuoted(a.schema.Modules("tls.ca_pool.source")))}
+	}
+	provider := d.Args[0].Token
+	key := "trust_pool " + provider.Value
+	options, ok := clientAuthBlocks[key]
+	if !ok {
+		return a.analyzeModuleArgument(d, "tls.ca_pool.source")
+	}
+
+	var diags []protocol.Diagnostic
+	items := len(d.Args) - 1
+	if provider.Value == "inline" && items > 0 {
+		diags = append(diags, errorf(d.Args[1].Range(), "trust_pool inline takes no arguments; list the certificates with trust_der in its block"))
+	}
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		if name == "import" {
+			continue
+		}
+		if !options[name] {
+			diags = append(diags, unknownClientAuthOption(sub.Name, "option", key)...)
+			continue
+		}
+		switch name {
+		case "trust_der", "pem_file", "authority", "keys", "endpoints":
+			items += len(sub.Args)
+			if len(sub.Args) == 0 {
+				diags = append(diags, warningf(sub.Name.Range(), "%s requires at least one argument", name))
+			}
+		case "storage":
+			diags = append(diags, a.analyzeModuleArgument(sub, storageNamespace)...)
+		case "tls":
+			diags = append(diags, a.analyzeTrustPoolTLS(sub)...)
+		}
+	}
+	switch provider.Value {
+	case "inline", "file":
+		if items == 0 {
+			diags = append(diags, errorf(provider.Range(), "trust_pool %s has no certificates to trust", provider.Value))
+		}
+	case "pki_root", "pki_intermediate":
+		if items == 0 {
+			diags = append(diags, errorf(provider.Range(), "trust_pool %s has no authorities to trust, e.g. local", provider.Value))
+		}
+	}
+	return diags
+}
+
+// analyzeTrustPoolTLS checks the tls block of an http trust pool, which
+// configures the connection to its endpoints.
+func (a *analyzer) analyzeTrustPoolTLS(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, sub := range d.Body {
+		switch name := sub.Name.Value; {
+		case name == "import":
+		case !clientAuthBlocks["trust_pool http>tls"][name]:
+			diags = append(diags, unknownClientAuthOption(sub.Name, "tls option", "trust_pool http>tls")...)
+		case name == "ca":
+			diags = append(diags, a.analyzeTrustPool(sub)...)
+		case name == "renegotiation":
+			if len(sub.Args) != 1 {
+				diags = append(diags, warningf(sub.Name.Range(), "renegotiation takes one of %s", joinQuoted(renegotiationLevels)))
+				continue
+			}
+			diags = append(diags, checkOneOf(sub.Args[0].Token, "renegotiation level", renegotiationLevels)...)
+		}
+	}
+	return diags
+}
+
+// unknownClientAuthOption reports tok, which is not valid in the block of
+// clientAuthBlocks at key, as an unrecognized what.
+func unknownClientAuthOption(tok parser.Token, what, key string) []protocol.Diagnostic {
+	names := slices.Sorted(maps.Keys(clientAuthBlocks[key]))
+	return []protocol.Diagnostic{warningf(tok.Range(), "unrecognized %s %q in %s%s", what, tok.Value, strings.ReplaceAll(key, ">", " "), didYouMean(tok.Value, names))}
+}
@@ -0,0 +1,58 @@
+package analysis
+
+import "testing"
+
+// clientAuthWith wraps lines in a client_auth block of a tls block.
+func clientAuthWith(lines string) string {
+	return tlsWith("\t\tclient_auth {\n" + lines + "\t\t}\n")
+}
+
+func TestAnalyze_ClientAuth_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\t\t\tmode require_and_verify\n\t\t\ttrust_pool file ca.pem\n",
+		"\t\t\ttrust_pool file {\n\t\t\t\tpem_file ca.pem other.pem\n\t\t\t}\n",
+		"\t\t\ttrust_pool inline {\n\t\t\t\ttrust_der MIIB\n\t\t\t}\n",
+		"\t\t\ttrust_pool pki_root local\n",
+		"\t\t\ttrust_pool pki_intermediate {\n\t\t\t\tauthority local\n\t\t\t}\n",
+		"\t\t\ttrust_pool storage ca.pem {\n\t\t\t\tstorage file_system /data\n\t\t\t}\n",
+		"\t\t\ttrust_pool http https://ca.example.com/roots.pem {\n\t\t\t\ttls {\n\t\t\t\t\tca file internal-ca.pem\n\t\t\t\t\trenegotiation never\n\t\t\t\t}\n\t\t\t}\n",
+		"\t\t\tmode verify_if_given\n\t\t\ttrusted_leaf_cert_file client.pem\n",
+		"\t\t\ttrust_pool {$POOL}\n",
+	}
+	for _, lines := range cases {
+		if diags := analyze(clientAuthWith(lines)); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", lines, diags)
+		}
+	}
+}
+
+func TestAnalyze_ClientAuth_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"\t\t\tmode requre\n":                                          `did you mean "require"`,
+		"\t\t\ttrustpool file ca.pem\n":                                `unrecognized client_auth option "trustpool" in client_auth (did you mean "trust_pool"?)`,
+		"\t\t\ttrust_pool fil ca.pem\n":                                `unknown trust pool provider "fil" (did you mean "file"?)`,
+		"\t\t\ttrust_pool\n":                                           "trust_pool requires a provider",
+		"\t\t\ttrust_pool file\n":                                      "trust_pool file has no certificates to trust",
+		"\t\t\ttrust_pool pki_root\n":                                  "trust_pool pki_root has no authorities to trust",
+		"\t\t\ttrust_pool inline MIIB\n":                               "trust_pool inline takes no arguments",
+		"\t\t\ttrust_pool file {\n\t\t\t\tpem_files ca.pem\n\t\t\t}\n": `unrecognized option "pem_files" in trust_pool file (did you mean "pem_file"?)`,
+		"\t\t\ttrust_pool http {\n\t\t\t\ttls {\n\t\t\t\t\tsni x\n\t\t\t\t}\n\t\t\t}\n":         `unrecognized tls option "sni" in trust_pool http tls`,
+		"\t\t\ttrust_pool http {\n\t\t\t\ttls {\n\t\t\t\t\tca fiel x.pem\n\t\t\t\t}\n\t\t\t}\n": `unknown trust pool provider "fiel"`,
+		"\t\t\tmode require\n\t\t\ttrust_pool file ca.pem\n":                                    "mode require does not verify client certificates",
+		"\t\t\ttrusted_ca_cert_file ca.pem\n":                                                   "deprecated; use trust_pool file <pem_file>",
+		"\t\t\ttrust_pool file ca.pem\n\t\t\ttrusted_ca_cert MIIB\n":                            "trusted_ca_cert cannot be combined with trust_pool",
+	}
+	for lines, want := range cases {
+		if diags := analyze(clientAuthWith(lines)); !hasMsg(diags, want) {
+			t.Errorf("%q: expected diagnostic containing %q, got %v", lines, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_TransportTrustPool(t *testing.T) {
+	src := "example.com {\n\treverse_proxy https://app {\n\t\ttransport http {\n\t\t\ttls_trust_pool file {\n\t\t\t\tpem ca.pem\n\t\t\t}\n\t\t}\n\t}\n}\n"
+	diags := analyze(src)
+	if len(diags) != 2 || !hasMsg(diags, `unrecognized option "pem" in trust_pool file`) || !hasMsg(diags, "no certificates to trust") {
+		t.Errorf("expected the trust pool to be checked, got %v", diags)
+	}
+}
@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"unicode/utf16"
+	"unicode/utf8"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleConfusableCharacter is the code of the rule flagging look-alike and
+// invisible Unicode characters.
+const RuleConfusableCharacter = "confusable-character"
+
+// confusable describes a character that looks like, or is invisibly
+// different from, ASCII text, as commonly pasted from web pages and
+// word processors.
+type confusable struct {
+	name        string
+	replacement string // ASCII equivalent; empty to delete
+	invisible   bool   // renders as nothing or as a plain space
+}
+
+// confusables are the characters AnalyzeConfusables flags.
+var confusables = map[rune]confusable{
+	'\u00a0': {name: "non-breaking space", replacement: " ", invisible: true},
+	'\u2007': {name: "figure space", replacement: " ", invisible: true},
+	'\u202f': {name: "narrow non-breaking space", replacement: " ", invisible: true},
+	'\u3000': {name: "ideographic space", replacement: " ", invisible: true},
+	'\u200b': {name: "zero-width space", invisible: true},
+	'\u200c': {name: "zero-width non-joiner", invisible: true},
+	'\u200d': {name: "zero-width joiner", invisible: true},
+	'\u2060': {name: "word joiner", invisible: true},
+	'\ufeff': {name: "zero-width no-break space", invisible: true},
+	'\u201c': {name: "left double quotation mark", replacement: `"`},
+	'\u201d': {name: "right double quotation mark", replacement: `"`},
+	'\u2018': {name: "left single quotation mark", replacement: "'"},
+	'\u2019': {name: "right single quotation mark", replacement: "'"},
+	'\u2010': {name: "hyphen", replacement: "-"},
+	'\u2011': {name: "non-breaking hyphen", replacement: "-"},
+	'\u2013': {name: "en dash", replacement: "-"},
+	'\u2212': {name: "minus sign", replacement: "-"},
+}
+
+// AnalyzeConfusables flags look-alike and invisible Unicode characters in
+// src, each with a fix that replaces it with its ASCII equivalent. Caddy
+// treats most of them as ordinary characters, so a smart quote does not
+// start a string and a zero-width space becomes part of the word next to it.
+//
+// Comments are skipped. Inside quoted strings and heredocs typographic
+// quotes and dashes are usually intended, so only invisible characters are
+// flagged there. A byte order mark at the very start of the file is left
+// alone.
+func AnalyzeConfusables(src string) []Fix {
+	var fixes []Fix
+	spans := parser.Spans(src)
+	line, lineStart := uint32(0), 0
+	for i := 0; i < len(src); {
+		r, size := utf8.DecodeRuneInString(src[i:])
+		if r == '\n' {
+			line, lineStart = line+1, i+1
+		}
+		for len(spans) > 0 && spans[0].End <= i {
+			spans = spans[1:]
+		}
+		inSpan := len(spans) > 0 && spans[0].Start <= i
+		c, ok := confusables[r]
+		switch {
+		case !ok, i == 0 && r == '\ufeff':
+		case inSpan && spans[0].Kind == parser.SpanComment:
+		case inSpan && !c.invisible:
+		default:
+			fixes = append(fixes, confusableFix(r, c, line, parser.UTF16Len(src[lineStart:i])))
+		}
+		i += size
+	}
+	return fixes
+}
+
+// confusableFix builds the fix for the confusable r at UTF-16 column col
+// of line.
+func confusableFix(r rune, c confusable, line, col uint32) Fix {
+	rng := protocol.Range{
+		Start: protocol.Position{Line: line, Character: col},
+		End:   protocol.Position{Line: line, Character: col + uint32(utf16.RuneLen(r))},
+	}
+	what := fmt.Sprintf("%s (U+%04X)", c.name, r)
+	if c.replacement == "" {
+		return Fix{
+			Diagnostic: ruleDiag(RuleConfusableCharacter, rng, "invisible %s; Caddy treats it as part of the surrounding text", what),
+			Title:      "Remove " + c.name,
+			Edit:       protocol.TextEdit{Range: rng},
+		}
+	}
+	return Fix{
+		Diagnostic: ruleDiag(RuleConfusableCharacter, rng, "%s looks like %q but is not ASCII", what, c.replacement),
+		Title:      fmt.Sprintf("Replace with %q", c.replacement),
+		Edit:       protocol.TextEdit{Range: rng, NewText: c.replacement},
+	}
+}
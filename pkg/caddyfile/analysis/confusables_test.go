@@ -0,0 +1,83 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyzeConfusables(t *testing.T) {
+	src := "example.com {\n\trespond \u201chello\u201d\n\troot\u00a0* /srv\n\tfile_server\u200b\n}\n"
+	fixes := AnalyzeConfusables(src)
+	if len(fixes) != 4 {
+		t.Fatalf("want 4 fixes, got %+v", fixes)
+	}
+	want := []struct {
+		start, end protocol.Position
+		text       string
+	}{
+		{protocol.Position{Line: 1, Character: 9}, protocol.Position{Line: 1, Character: 10}, `"`},
+		{protocol.Position{Line: 1, Character: 15}, protocol.Position{Line: 1, Character: 16}, `"`},
+		{protocol.Position{Line: 2, Character: 5}, protocol.Position{Line: 2, Character: 6}, " "},
+		{protocol.Position{Line: 3, Character: 12}, protocol.Position{Line: 3, Character: 13}, ""},
+	}
+	for i, w := range want {
+		e := fixes[i].Edit
+		if e.Range.Start != w.start || e.Range.End != w.end || e.NewText != w.text {
+			t.Errorf("fix %d = %+v, want %v-%v -> %q", i, e, w.start, w.end, w.text)
+		}
+	}
+	if msg := fixes[3].Diagnostic.Message; msg != "invisible zero-width space (U+200B); Caddy treats it as part of the surrounding text" {
+		t.Errorf("message = %q", msg)
+	}
+	if msg := fixes[0].Diagnostic.Message; msg != `left double quotation mark (U+201C) looks like "\"" but is not ASCII` {
+		t.Errorf("message = %q", msg)
+	}
+}
+
+func TestAnalyzeConfusables_StringsAndComments(t *testing.T) {
+	src := "\ufeff# it\u2019s \u201cfine\u201d\u00a0here\nexample.com {\n\trespond \"it\u2019s\u00a0here\"\n}\n"
+	fixes := AnalyzeConfusables(src)
+	if len(fixes) != 1 {
+		t.Fatalf("want only the NBSP inside the string, got %+v", fixes)
+	}
+	if fixes[0].Edit.Range.Start != (protocol.Position{Line: 2, Character: 14}) {
+		t.Errorf("fix at %+v", fixes[0].Edit.Range.Start)
+	}
+}
+
+func TestAnalyzeConfusables_NonASCIIPrefix(t *testing.T) {
+	src := "caf\u00e9.example {\n\trespond\u00a0ok\n\theader X-Name \"caf\u00e9\" \"\U0001F600\"\u200b\n}\n"
+	fixes := AnalyzeConfusables(src)
+	if len(fixes) != 2 {
+		t.Fatalf("want 2 fixes, got %+v", fixes)
+	}
+	want := []protocol.Range{
+		{Start: protocol.Position{Line: 1, Character: 8}, End: protocol.Position{Line: 1, Character: 9}},
+		{Start: protocol.Position{Line: 2, Character: 26}, End: protocol.Position{Line: 2, Character: 27}},
+	}
+	for i, w := range want {
+		if got := fixes[i].Edit.Range; got != w {
+			t.Errorf("fix %d range = %+v, want %+v", i, got, w)
+		}
+	}
+	got := applyEdit(applyEdit(src, fixes[1].Edit), fixes[0].Edit)
+	if want := "caf\u00e9.example {\n\trespond ok\n\theader X-Name \"caf\u00e9\" \"\U0001F600\"\n}\n"; got != want {
+		t.Errorf("fixed source = %q, want %q", got, want)
+	}
+}
+
+// applyEdit applies e to src, whose positions count UTF-16 code units.
+func applyEdit(src string, e protocol.TextEdit) string {
+	offset := func(p protocol.Position) int {
+		lines := strings.SplitAfter(src, "\n")
+		n := 0
+		for _, l := range lines[:p.Line] {
+			n += len(l)
+		}
+		return n + parser.ByteOffset(lines[p.Line], p.Character)
+	}
+	return src[:offset(e.Range.Start)] + e.NewText + src[offset(e.Range.End):]
+}
@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// newDiag builds a caddy-ls diagnostic with the given severity and a
+// printf-style message.
+func newDiag(rng protocol.Range, severity protocol.DiagnosticSeverity, format string, args ...any) protocol.Diagnostic {
+	return protocol.Diagnostic{
+		Range:    rng,
+		Severity: &severity,
+		Source:   strPtr("caddy-ls"),
+		Message:  fmt.Sprintf(format, args...),
+	}
+}
+
+// ParseErrorDiagnostics converts parser errors in the document at uri to
+// error diagnostics, keeping their related locations.
+func ParseErrorDiagnostics(uri string, errs []*parser.ParseError) []protocol.Diagnostic {
+	diags := make([]protocol.Diagnostic, 0, len(errs))
+	for _, pe := range errs {
+		var related []protocol.DiagnosticRelatedInformation
+		for _, r := range pe.Related {
+			related = append(related, protocol.DiagnosticRelatedInformation{
+				Location: protocol.Location{URI: uri, Range: r.Rng},
+				Message:  r.Message,
+			})
+		}
+		d := errorf(pe.Rng, "%s", pe.Message)
+		d.RelatedInformation = related
+		diags = append(diags, d)
+	}
+	return diags
+}
+
+// warningf builds a warning diagnostic.
+func warningf(rng protocol.Range, format string, args ...any) protocol.Diagnostic {
+	return newDiag(rng, protocol.DiagnosticSeverityWarning, format, args...)
+}
+
+// errorf builds an error diagnostic.
+func errorf(rng protocol.Range, format string, args ...any) protocol.Diagnostic {
+	return newDiag(rng, protocol.DiagnosticSeverityError, format, args...)
+}
+
+// checkOneOf reports a warning when tok is not one of the allowed values,
+// suggesting the closest match for likely typos. Placeholders are accepted.
+// what names the kind of value in the message, e.g. "try policy".
+func checkOneOf(tok parser.Token, what string, allowed []string) []protocol.Diagnostic {
+	if isCaddyPlaceholder(tok.Value) || slices.Contains(allowed, tok.Value) {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(tok.Range(), "unrecognized %s %q%s", what, tok.Value, didYouMean(tok.Value, allowed))}
+}
+
+// checkDuration reports a warning when tok is not a duration Caddy accepts:
+// a Go duration such as "1m30s", optionally using a "d" (day) unit.
+// Placeholders are accepted.
+func checkDuration(tok parser.Token) []protocol.Diagnostic {
+	if isCaddyPlaceholder(tok.Value) || isDuration(trimQuotes(tok.Value)) {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(tok.Range(), "invalid duration %q: expected a value like \"30s\", \"5m\" or \"1d\"", tok.Value)}
+}
+
+// checkStatusMatch reports a warning when tok is not an HTTP status code
+// (404), status class (5xx) or inclusive range of codes (500-599), the forms
+// used to select responses. Placeholders are accepted.
+func checkStatusMatch(tok parser.Token) []protocol.Diagnostic {
+	if isCaddyPlaceholder(tok.Value) || isStatusMatch(tok.Value) {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(tok.Range(), "invalid status %q: expected a code like 404, a class like 5xx or a range like 500-599", tok.Value)}
+}
+
+// isStatusMatch reports whether s is a status code, class or range between
+// 100 and 599.
+func isStatusMatch(s string) bool {
+	if len(s) == 3 && s[1:] == "xx" {
+		return s[0] >= '1' && s[0] <= '5'
+	}
+	lo, hi, isRange := strings.Cut(s, "-")
+	if !isRange {
+		hi = lo
+	}
+	from, err1 := strconv.Atoi(lo)
+	to, err2 := strconv.Atoi(hi)
+	return err1 == nil && err2 == nil && from >= 100 && to <= 599 && from <= to
+}
+
+// dayUnitRE matches the day unit that Caddy adds on top of Go durations.
+var dayUnitRE = regexp.MustCompile(`(\d+(?:\.\d+)?)d`)
+
+// isDuration mirrors caddy.ParseDuration: time.ParseDuration plus "d" for
+// 24 hours.
+func isDuration(s string) bool {
+	var bad bool
+	s = dayUnitRE.ReplaceAllStringFunc(s, func(m string) string {
+		days, err := strconv.ParseFloat(strings.TrimSuffix(m, "d"), 64)
+		if err != nil {
+			bad = true
+		}
+		return strconv.FormatFloat(days*24, 'f', -1, 64) + "h"
+	})
+	if bad {
+		return false
+	}
+	_, err := time.ParseDuration(s)
+	return err == nil
+}
+
+// joinQuoted formats values as a comma-separated list of quoted strings.
+func joinQuoted(values []string) string {
+	quoted := make([]string, len(values))
+	for i, v := range values {
+		quoted[i] = strconv.Quote(v)
+	}
+	return strings.Join(quoted, ", ")
+}
@@ -0,0 +1,141 @@
+package analysis
+
+import "caddy-ls/pkg/caddyfile/parser"
+
+// directiveSignature describes the arguments of a directive: whether a
+// matcher token may come first, and the forms of the arguments after it.
+type directiveSignature struct {
+	matcher bool
+	// loneArg marks directives whose only argument is never a matcher
+	// token, such as `root /srv`.
+	loneArg bool
+	forms   []ArgForm
+}
+
+// directiveSignatures lists the argument forms of the directives whose
+// arguments are worth explaining one by one, in the order they should be
+// offered.
+// Source: caddyconfig/httpcaddyfile/builtins.go and the directives' parse
+// functions in modules/caddyhttp
+var directiveSignatures = map[string]directiveSignature{
+	"basic_auth": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[<hash_algorithm>]", Doc: "Algorithm of the password hashes: `bcrypt` (default) or `argon2id`."},
+		{Label: "[<realm>]", Doc: "Realm shown by the browser's login prompt. Default `restricted`."},
+	}}}},
+	"bind": {forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<hosts...>", Doc: "Interfaces to listen on: IP addresses, host names, or network addresses such as `unix//run/caddy.sock`.", Variadic: true},
+	}}}},
+	"encode": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<formats...>", Doc: "Encodings to offer, in order of preference: `zstd`, `gzip`, or plugins such as `br`.", Variadic: true},
+	}}}},
+	"error": {matcher: true, forms: []ArgForm{
+		{Params: []ArgParam{
+			{Label: "<status>|<message>", Doc: "Status code of the error, default `500`, or its message."},
+		}},
+		{Params: []ArgParam{
+			{Label: "<message>", Doc: "Error message, available as `{err.message}` in `handle_errors`."},
+			{Label: "<status>", Doc: "HTTP status code of the error, e.g. `403`."},
+		}},
+	}},
+	"file_server": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[browse]", Doc: "Lists the contents of directories without an index file."},
+	}}}},
+	"fs": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<filesystem>", Doc: "File system that file_server, try_files and the file matcher read from after this directive: one declared with the `filesystem` global option, or `default` for the local disk."},
+	}}}},
+	"handle_errors": {forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[<status_codes...>]", Doc: "Status codes to handle: codes such as `404`, classes such as `5xx`, or ranges such as `500-599`. All errors by default.", Variadic: true},
+	}}}},
+	"header": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[+|-|?|>]<field>", Doc: "Response header field. `+` adds a value, `-` deletes the field, `?` sets it only if it is not set yet, `>` sets it when the response is written."},
+		{Label: "[<value|regexp>]", Doc: "Value to set, or with a replacement, a substring or regular expression to find in the current value."},
+		{Label: "[<replacement>]", Doc: "Replacement for the matches of the previous argument; may use `$1` and friends."},
+	}}}},
+	"import": {forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<pattern>", Doc: "Snippet name, or a file path or glob relative to this file."},
+		{Label: "[<args...>]", Doc: "Values for the `{args[0]}`, `{args[1]}`, … placeholders of the imported tokens.", Variadic: true},
+	}}}},
+	"invoke": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<name>", Doc: "Name of the route, defined with `&(name) { ... }`."},
+	}}}},
+	"log": {forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[<logger_name>]", Doc: "Name of the logger, for `log_name` or to share it between sites."},
+	}}}},
+	"map": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<source>", Doc: "Value to map, usually a placeholder such as `{host}`."},
+		{Label: "<destinations...>", Doc: "Placeholders to set, e.g. `{backend}`.", Variadic: true},
+	}}}},
+	"method": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<method>", Doc: "HTTP method to change the request to, e.g. `GET`."},
+	}}}},
+	"php_fastcgi": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<php-fpm_gateways...>", Doc: "Addresses of the PHP-FPM servers, e.g. `localhost:9000` or `unix//run/php/php-fpm.sock`.", Variadic: true},
+	}}}},
+	"redir": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<to>", Doc: "Location to redirect to, e.g. `https://{host}{uri}`."},
+		{Label: "[<code>]", Doc: "Redirect status code: `302` or `temporary` (the default), `301` or `permanent`, `307` and `308` to keep the request method and body, any other `3xx` code, or `html` for a page that redirects in the browser."},
+	}}}},
+	"request_header": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[+|-]<field>", Doc: "Request header field. `+` adds a value, `-` deletes the field."},
+		{Label: "[<value|regexp>]", Doc: "Value to set, or with a replacement, a substring or regular expression to find in the current value."},
+		{Label: "[<replacement>]", Doc: "Replacement for the matches of the previous argument; may use `$1` and friends."},
+	}}}},
+	"respond": {matcher: true, forms: []ArgForm{
+		{Params: []ArgParam{
+			{Label: "<status>|<body>", Doc: "Status code of the response, default `200`, or its body."},
+		}},
+		{Params: []ArgParam{
+			{Label: "<body>", Doc: "Body of the response."},
+			{Label: "<status>", Doc: "HTTP status code, e.g. `404`, or a placeholder."},
+		}},
+	}},
+	"reverse_proxy": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[<upstreams...>]", Doc: "Backends to proxy to, e.g. `localhost:8080` or `https://example.com`.", Variadic: true},
+	}}}},
+	"rewrite": {matcher: true, loneArg: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<to>", Doc: "New URI; a path, a query string, or both. Placeholders such as `{path}` and `{query}` keep parts of the original."},
+	}}}},
+	"root": {matcher: true, loneArg: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<path>", Doc: "Site root for file_server, try_files and php_fastcgi."},
+	}}}},
+	"tls": {forms: []ArgForm{
+		{Params: []ArgParam{
+			{Label: "internal|force_automate|<email>", Doc: "`internal` to use certificates from Caddy's local CA, `force_automate` to manage certificates even for names a loaded certificate covers, or the email address of the ACME account."},
+		}},
+		{Params: []ArgParam{
+			{Label: "<cert_file>", Doc: "PEM certificate file to serve, with its chain."},
+			{Label: "<key_file>", Doc: "PEM private key of the certificate."},
+		}},
+	}},
+	"try_files": {forms: []ArgForm{{Params: []ArgParam{
+		{Label: "<files...>", Doc: "Files to try in order, relative to the site root; the request is rewritten to the first that exists. `=<status>` as the last one responds with an error instead.", Variadic: true},
+	}}}},
+	"uri": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "strip_prefix|strip_suffix|replace|path_regexp|query", Doc: "Operation on the request URI."},
+		{Label: "<args...>", Doc: "Arguments of the operation, e.g. the prefix to strip or the substring and its replacement.", Variadic: true},
+	}}}},
+	"vars": {matcher: true, forms: []ArgForm{{Params: []ArgParam{
+		{Label: "[<name>]", Doc: "Variable to set, read back with `{vars.<name>}`."},
+		{Label: "[<value>]", Doc: "Value of the variable."},
+	}}}},
+}
+
+// DirectiveForms returns the argument forms of the directive name, which
+// leave out the matcher token, and whether the directive takes one.
+func DirectiveForms(name string) (forms []ArgForm, matcher bool, ok bool) {
+	sig, ok := directiveSignatures[name]
+	return sig.forms, sig.matcher, ok
+}
+
+// DirectiveArgs returns the arguments of d its forms describe: all of them
+// but a leading matcher token.
+func DirectiveArgs(d *parser.Directive) []*parser.Argument {
+	sig := directiveSignatures[d.Name.Value]
+	if !sig.matcher || len(d.Args) == 0 || (sig.loneArg && len(d.Args) == 1) {
+		return d.Args
+	}
+	if isMatcherToken(d.Args[0].Token.Value) {
+		return d.Args[1:]
+	}
+	return d.Args
+}
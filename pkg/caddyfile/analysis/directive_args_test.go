@@ -0,0 +1,35 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func TestDirectiveSignatures_KnownDirectives(t *testing.T) {
+	for name, sig := range directiveSignatures {
+		if !KnownTopLevel[name] {
+			t.Errorf("%s: not a known directive", name)
+		}
+		for _, f := range sig.forms {
+			if len(f.Params) == 0 {
+				t.Errorf("%s: form without parameters", name)
+			}
+		}
+	}
+}
+
+func TestDirectiveArgs(t *testing.T) {
+	for src, want := range map[string]int{
+		"redir /old /new 301": 2,
+		"redir @old /new":     1,
+		"rewrite /new":        1,
+		"rewrite * /new":      1,
+		"tls /a.pem /a.key":   2,
+		"respond 404":         1,
+	} {
+		f, _ := parser.Parse("a.com {\n\t" + src + "\n}\n")
+		if got := len(DirectiveArgs(f.SiteBlocks[0].Directives[0])); got != want {
+			t.Errorf("%s: got %d arguments, want %d", src, got, want)
+		}
+	}
+}
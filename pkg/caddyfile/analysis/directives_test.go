@@ -0,0 +1,50 @@
+package analysis
+
+import (
+	"bytes"
+	"caddy-ls/internal/docgen"
+	"os"
+	"testing"
+)
+
+// TestDirectivesGenUpToDate fails when directives_gen.go differs from what
+// `go generate ./pkg/caddyfile/analysis` produces for the Caddy version in go.mod.
+func TestDirectivesGenUpToDate(t *testing.T) {
+	if testing.Short() {
+		t.Skip("parses the Caddy source tree")
+	}
+	dir, err := docgen.CaddyDir()
+	if err != nil {
+		t.Skipf("Caddy module not available: %v", err)
+	}
+	want, _, err := docgen.GenerateDirectives(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, err := os.ReadFile("directives_gen.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !bytes.Equal(got, want) {
+		t.Error("directives_gen.go is stale; run go generate ./pkg/caddyfile/analysis")
+	}
+}
+
+func TestKnownTopLevel(t *testing.T) {
+	for _, name := range []string{"fs", "invoke", "log_name", "skip_log", "tls", "bind", "import"} {
+		if !KnownTopLevel[name] {
+			t.Errorf("%s is not a known directive", name)
+		}
+	}
+	for name := range responseOnlyDirectives {
+		if KnownTopLevel[name] {
+			t.Errorf("%s is only valid in handle_response", name)
+		}
+	}
+	if diags := analyze("example.com {\n\tlog_name access\n\tskip_log /health\n\tinvoke shared\n}\n&(shared) {\n\trespond ok\n}\n"); hasMsg(diags, "unknown directive") {
+		t.Errorf("registered directives reported as unknown: %v", diags)
+	}
+	if diags := analyze("example.com {\n\tlocal_certs\n}\n"); !hasMsg(diags, `unknown directive "local_certs"`) {
+		t.Errorf("the local_certs global option is accepted in a site: %v", diags)
+	}
+}
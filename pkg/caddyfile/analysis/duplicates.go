@@ -0,0 +1,266 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleDuplicateDirectives is the code of the opt-in rule flagging runs of
+// directives repeated across site blocks, which a snippet could hold once.
+const RuleDuplicateDirectives = "duplicate-directives"
+
+// minDuplicateRun is the length from which a repeated run of directives is
+// flagged. Shorter runs, such as a shared `encode` and `log`, are usually
+// clearer written out.
+const minDuplicateRun = 3
+
+// duplicateRun is a run of directives found in several site blocks.
+type duplicateRun struct {
+	length int
+	// at holds the runs, in source order.
+	at [][]*parser.Directive
+	// sites holds the first address of the site block of each run.
+	sites []string
+}
+
+// AnalyzeDuplicateRuns flags the runs of minDuplicateRun or more
+// consecutive site-level directives that appear, written the same way, in
+// two or more site blocks of f, parsed from src, when the
+// duplicate-directives rule is enabled in rules. Each run gets a hint with
+// a fix that moves it into a new snippet, defined before the first site
+// using it, and imports the snippet in its place. Directives are compared
+// by their tokens, so layout and comments do not matter. Runs spanning a
+// multi-line string, or sharing lines with other directives, are skipped.
+func AnalyzeDuplicateRuns(src string, f *parser.File, rules map[string]bool) []Fix {
+	if !rules[RuleDuplicateDirectives] {
+		return nil
+	}
+	lines := strings.Split(src, "\n")
+	verbatim := parser.VerbatimLines(src)
+	var sites []*parser.SiteBlock
+	var keys [][]string
+	snippets := make(map[string]bool)
+	for _, sb := range f.SiteBlocks {
+		if isSnippet(sb) {
+			snippets[strings.Trim(sb.Addresses[0].Value, "()")] = true
+			continue
+		}
+		if len(sb.Addresses) == 0 || sb.LBrace == nil || sb.RBrace == nil {
+			continue
+		}
+		sites = append(sites, sb)
+		k := make([]string, len(sb.Directives))
+		for i, d := range sb.Directives {
+			k[i] = directiveKey(d)
+		}
+		keys = append(keys, k)
+	}
+
+	// Collect the maximal common runs of every pair of sites, by content.
+	runs := make(map[string]*duplicateRun)
+	type start struct{ site, at int }
+	starts := make(map[string]map[start]bool)
+	for i := range sites {
+		for j := i + 1; j < len(sites); j++ {
+			for p := range keys[i] {
+				for q := range keys[j] {
+					if p > 0 && q > 0 && keys[i][p-1] == keys[j][q-1] {
+						continue // not maximal
+					}
+					n := 0
+					for p+n < len(keys[i]) && q+n < len(keys[j]) && keys[i][p+n] == keys[j][q+n] {
+						n++
+					}
+					if n < minDuplicateRun {
+						continue
+					}
+					key := strings.Join(keys[i][p:p+n], "\n")
+					if starts[key] == nil {
+						starts[key] = make(map[start]bool)
+						runs[key] = &duplicateRun{length: n}
+					}
+					starts[key][start{i, p}] = true
+					starts[key][start{j, q}] = true
+				}
+			}
+		}
+	}
+
+	// Longer runs first; a directive belongs to one run at most.
+	order := make([]string, 0, len(runs))
+	for key := range runs {
+		order = append(order, key)
+	}
+	sort.Slice(order, func(a, b int) bool {
+		if runs[order[a]].length != runs[order[b]].length {
+			return runs[order[a]].length > runs[order[b]].length
+		}
+		return order[a] < order[b]
+	})
+	used := make(map[*parser.Directive]bool)
+	var fixes []Fix
+	for _, key := range order {
+		r := runs[key]
+		var at []start
+		for s := range starts[key] {
+			at = append(at, s)
+		}
+		sort.Slice(at, func(a, b int) bool { return at[a].site < at[b].site || at[a].site == at[b].site && at[a].at < at[b].at })
+		for _, s := range at {
+			run := sites[s.site].Directives[s.at : s.at+r.length]
+			if slices.ContainsFunc(run, func(d *parser.Directive) bool { return used[d] }) ||
+				!ownLines(run, lines, verbatim) {
+				continue
+			}
+			r.at = append(r.at, run)
+			r.sites = append(r.sites, sites[s.site].Addresses[0].Value)
+			for _, d := range run {
+				used[d] = true
+			}
+		}
+		if len(slices.Compact(slices.Clone(r.sites))) < 2 {
+			for _, run := range r.at {
+				for _, d := range run {
+					delete(used, d)
+				}
+			}
+			continue
+		}
+		name := snippetName(snippets)
+		snippets[name] = true
+		fixes = append(fixes, r.fixes(name, sites, lines)...)
+	}
+	return fixes
+}
+
+// fixes returns a hint for each run of r, all with the fix extracting r
+// into a snippet called name.
+func (r *duplicateRun) fixes(name string, sites []*parser.SiteBlock, lines []string) []Fix {
+	first := r.at[0]
+	var site *parser.SiteBlock
+	for _, sb := range sites {
+		if sb.BodyContains(first[0].Name.Range().Start) {
+			site = sb
+			break
+		}
+	}
+	eol := "\n"
+	if strings.HasSuffix(lines[first[0].StartLine], "\r") {
+		eol = "\r\n"
+	}
+	indent := leadingSpace(lines[first[0].StartLine])
+	var body strings.Builder
+	fmt.Fprintf(&body, "(%s) {%s", name, eol)
+	for l := first[0].StartLine; l <= lastLine(first); l++ {
+		line := strings.TrimSuffix(lines[l], "\r")
+		if strings.TrimSpace(line) != "" {
+			line = "\t" + strings.TrimPrefix(line, indent)
+		}
+		body.WriteString(line + eol)
+	}
+	body.WriteString("}" + eol + eol)
+	at := protocol.Position{Line: site.Addresses[0].Line}
+	edits := []protocol.TextEdit{{Range: protocol.Range{Start: at, End: at}, NewText: body.String()}}
+	for _, run := range r.at {
+		start := protocol.Position{Line: run[0].StartLine}
+		end := protocol.Position{Line: lastLine(run) + 1}
+		edits = append(edits, protocol.TextEdit{
+			Range:   protocol.Range{Start: start, End: end},
+			NewText: leadingSpace(lines[run[0].StartLine]) + "import " + name + eol,
+		})
+	}
+
+	var fixes []Fix
+	for i, run := range r.at {
+		var others []string
+		for j, s := range r.sites {
+			if j != i && !slices.Contains(others, s) {
+				others = append(others, s)
+			}
+		}
+		rng := protocol.Range{Start: run[0].Name.Range().Start, End: directiveEnd(run[len(run)-1])}
+		diag := newDiag(rng, protocol.DiagnosticSeverityHint,
+			"these %d directives are repeated in %s; extract them into a snippet", r.length, strings.Join(others, ", "))
+		SetRuleCode(&diag, RuleDuplicateDirectives)
+		fixes = append(fixes, Fix{
+			Diagnostic: diag,
+			Title:      fmt.Sprintf("Extract into snippet (%s)", name),
+			Edit:       edits[0],
+			Also:       edits[1:],
+		})
+	}
+	return fixes
+}
+
+// directiveKey returns a string identifying d by its tokens.
+func directiveKey(d *parser.Directive) string {
+	var b strings.Builder
+	var write func(d *parser.Directive)
+	write = func(d *parser.Directive) {
+		b.WriteString(d.Name.Value)
+		for _, a := range d.Args {
+			b.WriteString("\x00" + a.Token.Value)
+		}
+		if d.HasBody() {
+			b.WriteString("\x00{")
+			for _, sub := range d.Body {
+				b.WriteString("\x01")
+				write(sub)
+			}
+			b.WriteString("\x00}")
+		}
+	}
+	write(d)
+	return b.String()
+}
+
+// ownLines reports whether run occupies whole lines, without multi-line
+// strings, so that it can be moved line by line.
+func ownLines(run []*parser.Directive, lines []string, verbatim map[uint32]bool) bool {
+	first := run[0]
+	end := directiveEnd(run[len(run)-1])
+	if int(end.Line) >= len(lines) {
+		return false
+	}
+	for l := first.StartLine; l <= end.Line+1; l++ {
+		if verbatim[l] {
+			return false
+		}
+	}
+	line := lines[end.Line]
+	rest := strings.TrimSpace(line[parser.ByteOffset(line, end.Character):])
+	return blankBetween(lines, protocol.Position{Line: first.StartLine}, first.Name.Range().Start) &&
+		(rest == "" || strings.HasPrefix(rest, "#"))
+}
+
+// lastLine returns the line the last directive of run ends on.
+func lastLine(run []*parser.Directive) uint32 {
+	return directiveEnd(run[len(run)-1]).Line
+}
+
+// directiveEnd returns the end of d: its closing brace, or its last token.
+func directiveEnd(d *parser.Directive) protocol.Position {
+	if d.RBrace != nil {
+		return d.RBrace.Range().End
+	}
+	return lastTokenEnd(d)
+}
+
+// leadingSpace returns the indentation of line.
+func leadingSpace(line string) string {
+	return line[:len(line)-len(strings.TrimLeft(line, " \t"))]
+}
+
+// snippetName returns a snippet name that is not in taken.
+func snippetName(taken map[string]bool) string {
+	name := "shared"
+	for n := 2; taken[name]; n++ {
+		name = fmt.Sprintf("shared%d", n)
+	}
+	return name
+}
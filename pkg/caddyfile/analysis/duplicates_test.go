@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+	"testing"
+)
+
+func duplicateFixes(src string) []Fix {
+	f, _ := parser.Parse(src)
+	return AnalyzeDuplicateRuns(src, f, map[string]bool{RuleDuplicateDirectives: true})
+}
+
+func TestAnalyzeDuplicateRuns(t *testing.T) {
+	src := "(shared) {\n" +
+		"\tlog\n" +
+		"}\n" +
+		"a.com {\n" +
+		"\troot * /srv/a\n" +
+		"\tencode gzip\n" +
+		"\theader {\n" +
+		"\t\t-Server\n" +
+		"\t}\n" +
+		"\tfile_server # static\n" +
+		"}\n" +
+		"b.com {\n" +
+		"    encode   gzip\n" +
+		"    # headers\n" +
+		"    header {\n" +
+		"        -Server\n" +
+		"    }\n" +
+		"    file_server\n" +
+		"    respond /x 200\n" +
+		"}\n" +
+		"c.com {\n" +
+		"\tencode gzip\n" +
+		"\theader {\n" +
+		"\t\t-Server\n" +
+		"\t}\n" +
+		"}\n"
+	fixes := duplicateFixes(src)
+	if len(fixes) != 2 {
+		t.Fatalf("got %d fixes, want one per run: %+v", len(fixes), fixes)
+	}
+	d := fixes[0].Diagnostic
+	if d.Message != "these 3 directives are repeated in b.com; extract them into a snippet" || d.Range.Start.Line != 5 || d.Range.End.Line != 9 ||
+		d.Code.Value != RuleDuplicateDirectives {
+		t.Errorf("diagnostic = %+v", d)
+	}
+	if d := fixes[1].Diagnostic; d.Range.Start.Line != 12 || !strings.Contains(d.Message, "repeated in a.com;") {
+		t.Errorf("second diagnostic = %+v", d)
+	}
+	fix := fixes[0]
+	if fix.Title != "Extract into snippet (shared2)" || len(fix.Also) != 2 {
+		t.Fatalf("fix = %+v", fix)
+	}
+	if e := fix.Edit; e.Range.Start.Line != 3 || e.Range.Start != e.Range.End ||
+		e.NewText != "(shared2) {\n\tencode gzip\n\theader {\n\t\t-Server\n\t}\n\tfile_server # static\n}\n\n" {
+		t.Errorf("snippet edit = %+v", e)
+	}
+	if e := fix.Also[1]; e.Range.Start.Line != 12 || e.Range.End.Line != 18 || e.NewText != "    import shared2\n" {
+		t.Errorf("replacement = %+v", e)
+	}
+
+	f, _ := parser.Parse(src)
+	if fixes := AnalyzeDuplicateRuns(src, f, nil); len(fixes) != 0 {
+		t.Errorf("the rule should be opt-in, got %+v", fixes)
+	}
+}
+
+func TestAnalyzeDuplicateRuns_NonASCIILine(t *testing.T) {
+	site := " {\n\tencode gzip\n\tfile_server\n\theader X-Name \"caf\u00e9\" # note\n}\n"
+	fixes := duplicateFixes("a.com" + site + "b.com" + site)
+	if len(fixes) != 2 {
+		t.Fatalf("got %d fixes, want one per run: %+v", len(fixes), fixes)
+	}
+	if want := "(shared) {\n\tencode gzip\n\tfile_server\n\theader X-Name \"caf\u00e9\" # note\n}\n\n"; fixes[0].Edit.NewText != want {
+		t.Errorf("snippet = %q, want %q", fixes[0].Edit.NewText, want)
+	}
+}
+
+func TestAnalyzeDuplicateRuns_NotFlagged(t *testing.T) {
+	cases := map[string]string{
+		"short run":      "a.com {\n\tencode gzip\n\tlog\n}\nb.com {\n\tencode gzip\n\tlog\n}\n",
+		"one site":       "a.com {\n\tencode gzip\n\tlog\n\tfile_server\n\tencode gzip\n\tlog\n\tfile_server\n}\n",
+		"different args": "a.com {\n\tencode gzip\n\tlog\n\tfile_server\n}\nb.com {\n\tencode zstd\n\tlog\n\tfile_server\n}\n",
+		"heredoc":        "a.com {\n\tencode gzip\n\tlog\n\trespond <<TXT\n\t\thi\n\t\tTXT\n}\nb.com {\n\tencode gzip\n\tlog\n\trespond <<TXT\n\t\thi\n\t\tTXT\n}\n",
+		"snippets":       "(a) {\n\tencode gzip\n\tlog\n\tfile_server\n}\n(b) {\n\tencode gzip\n\tlog\n\tfile_server\n}\n",
+	}
+	for name, src := range cases {
+		if fixes := duplicateFixes(src); len(fixes) != 0 {
+			t.Errorf("%s: got %+v", name, fixes)
+		}
+	}
+}
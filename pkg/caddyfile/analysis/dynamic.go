@@ -0,0 +1,116 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// dynamicUpstreams describes the positional arguments of the built-in
+// dynamic upstream modules. The valid body options live in
+// knownSubSubDirectives under "dynamic:<module>".
+// Source: modules/caddyhttp/reverseproxy/upstreams.go
+var dynamicUpstreams = map[string]struct {
+	argCounts []int  // accepted numbers of positional arguments
+	usage     string // argument syntax shown in messages
+}{
+	"a":     {argCounts: []int{0, 2}, usage: "[<name> <port>]"},
+	"srv":   {argCounts: []int{0, 1}, usage: "[<full_name>]"},
+	"multi": {argCounts: []int{0}, usage: "{ <source> ... }"},
+}
+
+// dynamicUpstreamNames is the sorted list of dynamicUpstreams keys.
+var dynamicUpstreamNames = func() []string {
+	names := make([]string, 0, len(dynamicUpstreams))
+	for name := range dynamicUpstreams {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// analyzeDynamic validates `dynamic <module> [<args...>] [{ ... }]` inside a
+// reverse_proxy block. Body option names are checked by the generic
+// sub-subdirective validation; this checks the module, its positional
+// arguments and the option values.
+func (a *analyzer) analyzeDynamic(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "dynamic requires an upstream source module: one of %s", joinQuoted(dynamicUpstreamNames))}
+	}
+	name := d.Args[0].Token
+	if _, builtin := dynamicUpstreams[name.Value]; isCaddyPlaceholder(name.Value) || (!builtin && a.schema.IsUpstream(name.Value)) {
+		return nil
+	}
+	if diags := checkOneOf(name, "dynamic upstream module", dynamicUpstreamNames); len(diags) > 0 {
+		return diags
+	}
+
+	var diags []protocol.Diagnostic
+	module := dynamicUpstreams[name.Value]
+	args := d.Args[1:]
+	if !slices.Contains(module.argCounts, len(args)) {
+		rng := name.Range()
+		if len(args) > 0 {
+			rng = args[len(args)-1].Range()
+		}
+		diags = append(diags, warningf(rng, "dynamic %s expects %s, got %d argument(s)", name.Value, module.usage, len(args)))
+	} else if name.Value == "a" && len(args) == 2 {
+		diags = append(diags, checkPort(args[1].Token)...)
+	}
+
+	for _, opt := range d.Body {
+		diags = append(diags, analyzeDynamicOption(opt)...)
+	}
+	return diags
+}
+
+// analyzeDynamicOption checks the value of one option in a dynamic a/srv
+// block.
+func analyzeDynamicOption(opt *parser.Directive) []protocol.Diagnostic {
+	switch opt.Name.Value {
+	case "refresh", "dial_timeout", "dial_fallback_delay", "grace_period":
+		if len(opt.Args) != 1 {
+			return []protocol.Diagnostic{warningf(opt.Name.Range(), "%s expects a single duration", opt.Name.Value)}
+		}
+		return checkDuration(opt.Args[0].Token)
+	case "port":
+		if len(opt.Args) != 1 {
+			return []protocol.Diagnostic{warningf(opt.Name.Range(), "port expects a single port number")}
+		}
+		return checkPort(opt.Args[0].Token)
+	case "resolvers":
+		if len(opt.Args) == 0 {
+			return []protocol.Diagnostic{warningf(opt.Name.Range(), "resolvers expects at least one DNS server address")}
+		}
+	case "versions":
+		var diags []protocol.Diagnostic
+		for _, arg := range opt.Args {
+			diags = append(diags, checkOneOf(arg.Token, "IP version", []string{"ipv4", "ipv6"})...)
+		}
+		return diags
+	}
+	return nil
+}
+
+// checkPort reports a warning when tok is not a valid port number.
+func checkPort(tok parser.Token) []protocol.Diagnostic {
+	if isCaddyPlaceholder(tok.Value) {
+		return nil
+	}
+	if n, err := strconv.Atoi(tok.Value); err != nil || n < 0 || n > 65535 {
+		return []protocol.Diagnostic{warningf(tok.Range(), "invalid port %q: must be a number between 0 and 65535", tok.Value)}
+	}
+	return nil
+}
+
+// trimQuotes strips the surrounding quotes of a quoted token value.
+func trimQuotes(s string) string {
+	if len(s) >= 2 && (s[0] == '"' || s[0] == '`') && s[len(s)-1] == s[0] {
+		return s[1 : len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
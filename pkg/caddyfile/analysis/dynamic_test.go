@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func TestAnalyze_Dynamic_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\t\tdynamic a demo.example.com 8080\n",
+		"\t\tdynamic srv _api._tcp.example.com\n",
+		"\t\tdynamic a {\n\t\t\tname demo.example.com\n\t\t\tport 8080\n\t\t\trefresh 1m\n\t\t\tresolvers 1.1.1.1\n\t\t\tversions ipv4\n\t\t}\n",
+		"\t\tdynamic srv {\n\t\t\tservice api\n\t\t\tproto tcp\n\t\t\tname example.com\n\t\t\tdial_timeout 2s\n\t\t\tgrace_period 1d\n\t\t}\n",
+		"\t\tdynamic {$UPSTREAMS}\n",
+	}
+	for _, lines := range cases {
+		src := proxyWith(lines)
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_Dynamic_Problems(t *testing.T) {
+	cases := map[string]string{
+		"\t\tdynamic\n":                                   "dynamic requires an upstream source module",
+		"\t\tdynamic dns example.com\n":                   `unrecognized dynamic upstream module "dns"`,
+		"\t\tdynamic a demo.example.com\n":                "dynamic a expects [<name> <port>], got 1 argument(s)",
+		"\t\tdynamic a demo.example.com http\n":           `invalid port "http"`,
+		"\t\tdynamic srv a b\n":                           "dynamic srv expects [<full_name>]",
+		"\t\tdynamic a {\n\t\t\trefresh soon\n\t\t}\n":    `invalid duration "soon"`,
+		"\t\tdynamic a {\n\t\t\tversions ipv5\n\t\t}\n":   `unrecognized IP version "ipv5"`,
+		"\t\tdynamic a {\n\t\t\tgrace_period 1s\n\t\t}\n": `unknown subdirective "grace_period" for "reverse_proxy" "dynamic a"`,
+		"\t\tdynamic srv {\n\t\t\tport 80\n\t\t}\n":       `unknown subdirective "port" for "reverse_proxy" "dynamic srv"`,
+		"\t\tdynamic srv {\n\t\t\tresolvers\n\t\t}\n":     "resolvers expects at least one DNS server address",
+	}
+	for lines, want := range cases {
+		src := proxyWith(lines)
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_Dynamic_PluginDeclared_NoWarning(t *testing.T) {
+	f, _ := parser.Parse(proxyWith("\t\tdynamic docker {\n\t\t\tlabel app\n\t\t}\n"))
+	if diags := AnalyzeWith(f, Options{Plugins: Plugins{Upstreams: []string{"docker"}}}); len(diags) != 0 {
+		t.Errorf("plugin upstream: expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestIsDuration(t *testing.T) {
+	for s, want := range map[string]bool{
+		"30s": true, "1m30s": true, "1.5h": true, "1d": true, "2d12h": true,
+		"": false, "soon": false, "10": false, "1w": false,
+	} {
+		if got := isDuration(s); got != want {
+			t.Errorf("isDuration(%q) = %v, want %v", s, got, want)
+		}
+	}
+}
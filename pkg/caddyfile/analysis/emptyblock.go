@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleEmptyBlock is the code of the opt-in rule flagging directives with an
+// empty block, such as `tls { }`, which are usually left over from editing.
+const RuleEmptyBlock = "empty-block"
+
+// AnalyzeEmptyBlocks flags the directives of f, parsed from src, whose block
+// holds nothing, when the empty-block rule is enabled in rules. Each gets a
+// hint, faded as unnecessary, with two fixes: removing the braces, and
+// removing the whole directive. Blocks holding a comment are left alone.
+func AnalyzeEmptyBlocks(src string, f *parser.File, rules map[string]bool) []Fix {
+	if !rules[RuleEmptyBlock] {
+		return nil
+	}
+	var fixes []Fix
+	lines := strings.Split(src, "\n")
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			if len(d.Body) > 0 {
+				walk(d.Body)
+				continue
+			}
+			if !d.HasBody() || d.RBrace == nil || !blankBetween(lines, d.LBrace.Range().End, d.RBrace.Range().Start) {
+				continue
+			}
+			diag := newDiag(protocol.Range{Start: d.LBrace.Range().Start, End: d.RBrace.Range().End},
+				protocol.DiagnosticSeverityHint, "empty %s block", d.Name.Value)
+			SetRuleCode(&diag, RuleEmptyBlock)
+			diag.Tags = []protocol.DiagnosticTag{protocol.DiagnosticTagUnnecessary}
+			fixes = append(fixes,
+				Fix{
+					Diagnostic: diag,
+					Title:      "Remove the empty braces",
+					Edit:       protocol.TextEdit{Range: protocol.Range{Start: lastTokenEnd(d), End: d.RBrace.Range().End}},
+				},
+				Fix{
+					Diagnostic:  diag,
+					Title:       "Remove " + d.Name.Value,
+					Edit:        protocol.TextEdit{Range: directiveExtent(d, lines)},
+					Alternative: true,
+				})
+		}
+	}
+	if f.GlobalBlock != nil {
+		walk(f.GlobalBlock.Directives)
+	}
+	for _, sb := range f.SiteBlocks {
+		walk(sb.Directives)
+	}
+	return fixes
+}
+
+// lastTokenEnd returns the end of the last token of d before its block.
+func lastTokenEnd(d *parser.Directive) protocol.Position {
+	if len(d.Args) > 0 {
+		return d.Args[len(d.Args)-1].Range().End
+	}
+	return d.Name.Range().End
+}
+
+// directiveExtent returns the range covering d and its block: its whole
+// lines when nothing else shares them, otherwise just its tokens.
+func directiveExtent(d *parser.Directive, lines []string) protocol.Range {
+	start := protocol.Position{Line: d.Name.Line}
+	end := protocol.Position{Line: d.RBrace.Line + 1}
+	if int(end.Line) >= len(lines) { // no newline after the block
+		end = d.RBrace.Range().End
+	}
+	if !blankBetween(lines, start, d.Name.Range().Start) || !blankBetween(lines, d.RBrace.Range().End, end) {
+		return protocol.Range{Start: d.Name.Range().Start, End: d.RBrace.Range().End}
+	}
+	return protocol.Range{Start: start, End: end}
+}
+
+// blankBetween reports whether only whitespace lies between from and to in
+// lines.
+func blankBetween(lines []string, from, to protocol.Position) bool {
+	for l := from.Line; l <= to.Line && int(l) < len(lines); l++ {
+		line := lines[l]
+		lo, hi := 0, len(line)
+		if l == from.Line {
+			lo = parser.ByteOffset(line, from.Character)
+		}
+		if l == to.Line {
+			hi = parser.ByteOffset(line, to.Character)
+		}
+		if lo < hi && strings.TrimSpace(line[lo:hi]) != "" {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,76 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func emptyBlockFixes(src string) []Fix {
+	f, _ := parser.Parse(src)
+	return AnalyzeEmptyBlocks(src, f, map[string]bool{RuleEmptyBlock: true})
+}
+
+func TestAnalyzeEmptyBlocks(t *testing.T) {
+	src := "{\n" +
+		"\tservers {\n" +
+		"\t}\n" +
+		"}\n" +
+		"example.com {\n" +
+		"\ttls internal { }\n" +
+		"\thandle {\n" +
+		"\t\t# later\n" +
+		"\t}\n" +
+		"\treverse_proxy app:8080 {\n" +
+		"\t\ttransport http {\n" +
+		"\t\t}\n" +
+		"\t}\n" +
+		"\tfile_server\n" +
+		"}\n" +
+		"b.com { route { } }"
+	fixes := emptyBlockFixes(src)
+	rng := func(l1, c1, l2, c2 uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: l1, Character: c1}, End: protocol.Position{Line: l2, Character: c2}}
+	}
+	want := []struct {
+		title string
+		edit  protocol.Range
+	}{
+		{"Remove the empty braces", rng(1, 8, 2, 2)},
+		{"Remove servers", rng(1, 0, 3, 0)},
+		{"Remove the empty braces", rng(5, 13, 5, 17)},
+		{"Remove tls", rng(5, 0, 6, 0)},
+		{"Remove the empty braces", rng(10, 16, 11, 3)},
+		{"Remove transport", rng(10, 0, 12, 0)},
+		{"Remove the empty braces", rng(15, 13, 15, 17)},
+		{"Remove route", rng(15, 8, 15, 17)},
+	}
+	if len(fixes) != len(want) {
+		t.Fatalf("got %d fixes, want %d: %+v", len(fixes), len(want), fixes)
+	}
+	for i, w := range want {
+		f := fixes[i]
+		if f.Title != w.title || f.Edit.Range != w.edit || f.Edit.NewText != "" || f.Alternative != (i%2 == 1) {
+			t.Errorf("fix %d: got %q %+v (alternative %v), want %q %+v", i, f.Title, f.Edit.Range, f.Alternative, w.title, w.edit)
+		}
+	}
+	d := fixes[2].Diagnostic
+	if d.Message != "empty tls block" || *d.Severity != protocol.DiagnosticSeverityHint || d.Range != rng(5, 14, 5, 17) ||
+		len(d.Tags) != 1 || d.Tags[0] != protocol.DiagnosticTagUnnecessary || d.Code.Value != RuleEmptyBlock {
+		t.Errorf("diagnostic = %+v", d)
+	}
+
+	f, _ := parser.Parse(src)
+	if fixes := AnalyzeEmptyBlocks(src, f, nil); len(fixes) != 0 {
+		t.Errorf("the rule should be opt-in, got %+v", fixes)
+	}
+}
+
+func TestAnalyzeEmptyBlocks_NonASCIILine(t *testing.T) {
+	fixes := emptyBlockFixes("a.com {\n\theader \"\u00fc\" { }\n}\n")
+	want := protocol.Range{Start: protocol.Position{Line: 1}, End: protocol.Position{Line: 2}}
+	if len(fixes) != 2 || fixes[1].Title != "Remove header" || fixes[1].Edit.Range != want {
+		t.Errorf("want header removed by whole lines, got %+v", fixes)
+	}
+}
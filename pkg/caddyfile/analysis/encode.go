@@ -0,0 +1,107 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleEncodeOrder is the code of the rule noting encode directives that
+// come too late in a route to compress every response.
+const RuleEncodeOrder = "encode-order"
+
+// encodeWrapped lists the directives that write a response encode can
+// compress, or, for templates, read it. Caddy's default directive order
+// places encode before all of them, so outside a route it wraps them
+// wherever it is written.
+// Source: caddyconfig/httpcaddyfile/directives.go (defaultDirectiveOrder)
+var encodeWrapped = append([]string{"templates", "php_fastcgi"}, terminalOrder...)
+
+// analyzeEncodeOrder notes the encode directives of the routes in block,
+// at any depth, that come after a handler writing the response or after
+// templates. A route runs its directives as written, and encode only
+// compresses what the handlers after it write, so the responses of the
+// earlier ones go out uncompressed. The notes are informational, since a
+// route may mean to leave some responses alone. Nothing is reported when
+// the `order` global option moves encode.
+func (a *analyzer) analyzeEncodeOrder(block []*parser.Directive, inRoute bool) []protocol.Diagnostic {
+	if a.ordered["encode"] {
+		return nil
+	}
+	var diags []protocol.Diagnostic
+	var before *parser.Directive // first earlier directive encode cannot wrap
+	for _, d := range block {
+		name := d.Name.Value
+		switch {
+		case containerDirectives[name]:
+			diags = append(diags, a.analyzeEncodeOrder(d.Body, name == "route")...)
+		case !inRoute:
+		case name == "encode" && before != nil:
+			diags = append(diags, a.encodeAfter(d, before))
+		case before == nil && slices.Contains(encodeWrapped, name):
+			before = d
+		}
+	}
+	return diags
+}
+
+// encodeAfter explains why the encode directive d, in a route, does not
+// compress the response of the earlier directive before.
+func (a *analyzer) encodeAfter(d, before *parser.Directive) protocol.Diagnostic {
+	var diag protocol.Diagnostic
+	if before.Name.Value == "templates" {
+		diag = newDiag(d.Name.Range(), protocol.DiagnosticSeverityInformation,
+			"encode after templates in a route compresses responses before templates renders them, so templates gets compressed bytes; routes run directives in the order written, so move encode before templates")
+	} else {
+		diag = newDiag(d.Name.Range(), protocol.DiagnosticSeverityInformation,
+			"encode does not compress responses from %s on line %d: routes run directives in the order written and encode only wraps the handlers after it; move encode to the top of the route",
+			before.Name.Value, before.Name.Line+1)
+	}
+	SetRuleCode(&diag, RuleEncodeOrder)
+	if a.opts.URI != "" {
+		diag.RelatedInformation = []protocol.DiagnosticRelatedInformation{{
+			Location: protocol.Location{URI: a.opts.URI, Range: before.Name.Range()},
+			Message:  fmt.Sprintf("%s runs before encode", before.Name.Value),
+		}}
+	}
+	return diag
+}
+
+// zstdLevels are the named compression levels zstd accepts.
+// Source: github.com/klauspost/compress/zstd (EncoderLevel.String)
+var zstdLevels = []string{"fastest", "default", "better", "best"}
+
+// analyzeEncode checks the compression level of the gzip and zstd encoders
+// in an encode block: gzip takes a number, zstd a named level, and both at
+// most one.
+// Source: modules/caddyhttp/encode/gzip/gzip.go, modules/caddyhttp/encode/zstd/zstd.go
+func analyzeEncode(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		if name != "gzip" && name != "zstd" || len(sub.Args) == 0 {
+			continue
+		}
+		level := sub.Args[0].Token
+		for _, extra := range sub.Args[1:] {
+			diags = append(diags, errorf(extra.Range(), "unexpected argument %q: %s takes a single compression level", extra.Token.Value, name))
+		}
+		if isCaddyPlaceholder(level.Value) {
+			continue
+		}
+		if name == "gzip" {
+			if n, err := strconv.Atoi(level.Value); err != nil || n < -3 || n > 9 {
+				diags = append(diags, errorf(level.Range(), "invalid gzip level %q: use a number from 1 (fastest) to 9 (smallest)", level.Value))
+			}
+			continue
+		}
+		if !slices.ContainsFunc(zstdLevels, func(l string) bool { return strings.EqualFold(l, level.Value) }) {
+			diags = append(diags, errorf(level.Range(), "invalid zstd level %q: use one of %s", level.Value, joinQuoted(zstdLevels)))
+		}
+	}
+	return diags
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyzeEncodeOrder(t *testing.T) {
+	for body, want := range map[string]string{
+		// Outside a route, Caddy sorts encode before the handlers.
+		"\treverse_proxy localhost:8080\n\tencode gzip\n":                          "",
+		"\thandle /api/* {\n\t\tfile_server\n\t\tencode\n\t}\n":                    "",
+		"\troute {\n\t\tencode zstd gzip\n\t\treverse_proxy localhost:8080\n\t}\n": "",
+		"\troute {\n\t\theader X-A 1\n\t\tencode\n\t\tfile_server\n\t}\n":          "",
+		"\troute {\n\t\tencode\n\t\ttemplates\n\t\tfile_server\n\t}\n":             "",
+		"\troute {\n\t\treverse_proxy /api/* localhost:8080\n\t\tencode\n\t}\n":    "encode does not compress responses from reverse_proxy on line 3",
+		"\troute {\n\t\tfile_server\n\t\tencode gzip\n\t}\n":                       "move encode to the top of the route",
+		"\thandle {\n\t\troute {\n\t\t\trespond hi\n\t\t\tencode\n\t\t}\n\t}\n":    "encode does not compress responses from respond on line 4",
+		"\troute {\n\t\ttemplates\n\t\tencode\n\t\tfile_server\n\t}\n":             "encode after templates in a route",
+	} {
+		src := "example.com {\n" + body + "}\n"
+		var notes []protocol.Diagnostic
+		for _, d := range analyze(src) {
+			if *d.Severity == protocol.DiagnosticSeverityInformation {
+				notes = append(notes, d)
+			}
+		}
+		if want == "" {
+			if len(notes) != 0 {
+				t.Errorf("%q: want no notes, got %v", src, notes)
+			}
+			continue
+		}
+		if len(notes) != 1 || !hasMsg(notes, want) {
+			t.Errorf("%q: want %q, got %v", src, want, notes)
+		}
+	}
+}
+
+func TestAnalyzeEncodeOrder_Related(t *testing.T) {
+	f, _ := parser.Parse("{\n\torder encode last\n}\nexample.com {\n\troute {\n\t\tfile_server\n\t\tencode\n\t}\n}\n")
+	if diags := AnalyzeWith(f, Options{}); hasMsg(diags, "encode does not compress") {
+		t.Errorf("encode moved by order: want no note, got %v", diags)
+	}
+	f, _ = parser.Parse("example.com {\n\troute {\n\t\tfile_server\n\t\tencode\n\t}\n}\n")
+	diags := AnalyzeWith(f, Options{URI: "file:///Caddyfile"})
+	if len(diags) != 1 || len(diags[0].RelatedInformation) != 1 || diags[0].RelatedInformation[0].Location.Range.Start.Line != 2 {
+		t.Errorf("want a note pointing at file_server, got %+v", diags)
+	}
+}
+
+func TestAnalyze_Encode_Levels(t *testing.T) {
+	if diags := analyze("example.com {\n\tencode {\n\t\tgzip 6\n\t\tzstd better\n\t\tzstd\n\t}\n}\n"); len(diags) != 0 {
+		t.Errorf("valid levels: got %v", diags)
+	}
+	diags := analyze("example.com {\n\tencode {\n\t\tzstd 5\n\t\tgzip fast\n\t\tgzip 12 3\n\t}\n}\n")
+	for _, want := range [][]string{
+		{`invalid zstd level "5"`, `"fastest"`},
+		{`invalid gzip level "fast"`},
+		{`invalid gzip level "12"`},
+		{`unexpected argument "3"`, "single compression level"},
+	} {
+		if !hasMsg(diags, want...) {
+			t.Errorf("missing %q in %v", want, diags)
+		}
+	}
+	if len(diags) != 4 {
+		t.Errorf("expected 4 diagnostics, got %v", diags)
+	}
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/env"
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleUndefinedEnv is the code of the rule flagging environment variables
+// that no configured source defines.
+const RuleUndefinedEnv = "undefined-env"
+
+// AnalyzeEnv warns about {$VAR} placeholders that no configured environment
+// source defines. Placeholders with a default ({$VAR:default}) are never
+// reported. Nothing is reported when r has no sources, since the variables
+// may then come from anywhere.
+func AnalyzeEnv(f *parser.File, r *env.Resolver) []protocol.Diagnostic {
+	if r.Empty() {
+		return nil
+	}
+	var diags []protocol.Diagnostic
+	forEachToken(f, func(tok parser.Token) {
+		for _, ref := range env.FindRefs(tok.Value) {
+			if ref.HasDefault {
+				continue
+			}
+			if _, _, ok := r.Lookup(ref.Name); ok {
+				continue
+			}
+			rng := protocol.Range{Start: tok.Position(ref.Start), End: tok.Position(ref.End)}
+			diags = append(diags, ruleDiag(RuleUndefinedEnv, rng, "environment variable %q is not defined in any configured source", ref.Name))
+		}
+	})
+	return diags
+}
+
+// forEachToken calls fn for every address, directive name and argument token
+// in f, in document order.
+func forEachToken(f *parser.File, fn func(parser.Token)) {
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			fn(d.Name)
+			for _, arg := range d.Args {
+				fn(arg.Token)
+			}
+			walk(d.Body)
+		}
+	}
+	if f.GlobalBlock != nil {
+		walk(f.GlobalBlock.Directives)
+	}
+	walk(f.Imports)
+	for _, sb := range f.SiteBlocks {
+		for _, addr := range sb.Addresses {
+			fn(addr)
+		}
+		walk(sb.Directives)
+	}
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/env"
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func analyzeEnv(src string, r *env.Resolver) []string {
+	f, _ := parser.Parse(src)
+	var msgs []string
+	for _, d := range AnalyzeEnv(f, r) {
+		msgs = append(msgs, d.Message)
+	}
+	return msgs
+}
+
+func TestAnalyzeEnv_UndefinedVariable(t *testing.T) {
+	r := env.NewResolver(env.Source{Name: ".env", Vars: map[string]string{"HOST": "example.com"}})
+	f, _ := parser.Parse("{$HOST} {\n\treverse_proxy {$UPSTREAM}\n}\n")
+	diags := AnalyzeEnv(f, r)
+	if len(diags) != 1 || !hasMsg(diags, `"UPSTREAM" is not defined`) {
+		t.Fatalf("expected one undefined-variable warning, got %v", diags)
+	}
+	if got := diags[0].Range; got.Start.Line != 1 || got.Start.Character != 15 || got.End.Character != 26 {
+		t.Errorf("range = %+v, want 1:15-1:26", got)
+	}
+	if c := diags[0].Code; c == nil || c.Value != RuleUndefinedEnv {
+		t.Errorf("code = %+v, want %s", c, RuleUndefinedEnv)
+	}
+}
+
+func TestAnalyzeEnv_DefaultSuppressesWarning(t *testing.T) {
+	r := env.NewResolver(env.Source{Name: "settings", Vars: map[string]string{}})
+	if msgs := analyzeEnv("example.com {\n\trespond \"{$MSG:hello}\"\n}\n", r); len(msgs) != 0 {
+		t.Errorf("placeholder with default: want no warnings, got %v", msgs)
+	}
+}
+
+func TestAnalyzeEnv_NoSourcesConfigured(t *testing.T) {
+	if msgs := analyzeEnv("example.com {\n\treverse_proxy {$UPSTREAM}\n}\n", env.NewResolver()); len(msgs) != 0 {
+		t.Errorf("no sources configured: want no warnings, got %v", msgs)
+	}
+}
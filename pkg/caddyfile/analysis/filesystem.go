@@ -0,0 +1,108 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"sort"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// defaultFilesystem is the name of the local file system, which needs no
+// declaration.
+// Source: internal/filesystems/map.go (DefaultFileSystemKey)
+const defaultFilesystem = "default"
+
+// FilesystemNames returns the names of the file systems declared with the
+// filesystem global option of f, sorted alphabetically, for completion of
+// `fs` arguments.
+func FilesystemNames(f *parser.File) []string {
+	var names []string
+	for name := range collectFilesystems(f) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectFilesystems returns the set of file system names declared in the
+// global options block of f with `filesystem <name> <module>`.
+func collectFilesystems(f *parser.File) map[string]bool {
+	names := make(map[string]bool)
+	if f.GlobalBlock == nil {
+		return names
+	}
+	for _, d := range f.GlobalBlock.Directives {
+		if d.Name.Value == "filesystem" && len(d.Args) > 0 {
+			names[d.Args[0].Token.Value] = true
+		}
+	}
+	return names
+}
+
+// analyzeFilesystemOption checks a filesystem global option: a name and the
+// module that provides the file system, and that the name is not declared
+// twice. The module's own options are not validated.
+// Source: modules/caddyfs/filesystem.go (parseFilesystems)
+func (a *analyzer) analyzeFilesystemOption(d *parser.Directive) []protocol.Diagnostic {
+	switch len(d.Args) {
+	case 0:
+		return []protocol.Diagnostic{errorf(d.Name.Range(), "filesystem requires a name and a file system module, e.g. filesystem assets <module>")}
+	case 1:
+		return []protocol.Diagnostic{errorf(d.Args[0].Range(), "filesystem %q requires a file system module after its name", d.Args[0].Token.Value)}
+	}
+	name := d.Args[0]
+	if a.declaredFilesystems == nil {
+		a.declaredFilesystems = make(map[string]bool)
+	}
+	if a.declaredFilesystems[name.Token.Value] {
+		return []protocol.Diagnostic{warningf(name.Range(), "duplicate filesystem %q", name.Token.Value)}
+	}
+	a.declaredFilesystems[name.Token.Value] = true
+	return nil
+}
+
+// analyzeFS checks the fs directive: exactly one file system name.
+// Source: caddyconfig/httpcaddyfile/builtins.go (parseFilesystem)
+func (a *analyzer) analyzeFS(d *parser.Directive) []protocol.Diagnostic {
+	args := DirectiveArgs(d)
+	if len(args) == 0 {
+		return []protocol.Diagnostic{errorf(d.Name.Range(), "fs requires the name of a file system")}
+	}
+	var diags []protocol.Diagnostic
+	for _, extra := range args[1:] {
+		diags = append(diags, errorf(extra.Range(), "unexpected argument %q: fs takes a single file system name", extra.Token.Value))
+	}
+	return append(diags, a.checkFilesystemRef(args[0])...)
+}
+
+// analyzeFileServer checks the file system file_server reads from, given
+// as `fs <name>` in its block.
+func (a *analyzer) analyzeFileServer(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, sub := range d.Body {
+		if sub.Name.Value != "fs" {
+			continue
+		}
+		if len(sub.Args) != 1 {
+			diags = append(diags, errorf(sub.Name.Range(), "fs takes exactly one file system name"))
+			continue
+		}
+		diags = append(diags, a.checkFilesystemRef(sub.Args[0])...)
+	}
+	return diags
+}
+
+// checkFilesystemRef warns about a reference to a file system that no
+// filesystem global option declares. A file imported by another one is not
+// checked, since its importer holds the global options.
+func (a *analyzer) checkFilesystemRef(arg *parser.Argument) []protocol.Diagnostic {
+	name := arg.Token.Value
+	if name == defaultFilesystem || isCaddyPlaceholder(name) || a.filesystems[name] || a.imported() {
+		return nil
+	}
+	declared := make([]string, 0, len(a.filesystems))
+	for fs := range a.filesystems {
+		declared = append(declared, fs)
+	}
+	return []protocol.Diagnostic{warningf(arg.Range(), "undefined file system %q%s; declare it with the filesystem global option", name, didYouMean(name, declared))}
+}
@@ -0,0 +1,62 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"testing"
+)
+
+const filesystemGlobals = "{\n\tfilesystem assets s3 {\n\t\tbucket site\n\t}\n}\n"
+
+func TestAnalyze_Filesystem_Valid(t *testing.T) {
+	src := filesystemGlobals + "example.com {\n\tfs assets\n\tfs @legacy default\n\tfile_server {\n\t\tfs assets\n\t}\n\tfile_server /static/* {\n\t\tfs {vars.fs}\n\t}\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyze_Filesystem_Undefined(t *testing.T) {
+	diags := analyze(filesystemGlobals + "example.com {\n\tfs asset\n\tfile_server {\n\t\tfs cdn\n\t}\n}\n")
+	if len(diags) != 2 {
+		t.Fatalf("expected 2 diagnostics, got %v", diags)
+	}
+	if !hasMsg(diags, `undefined file system "asset"`, `did you mean "assets"?`) {
+		t.Errorf("fs directive reference not reported: %v", diags)
+	}
+	if !hasMsg(diags, `undefined file system "cdn"`, "filesystem global option") {
+		t.Errorf("file_server reference not reported: %v", diags)
+	}
+}
+
+func TestAnalyze_Filesystem_ImportedFile(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\tfs assets\n}\n")
+	if diags := AnalyzeWith(f, Options{Imported: func() bool { return true }}); len(diags) != 0 {
+		t.Errorf("expected no diagnostics in an imported file, got %v", diags)
+	}
+}
+
+func TestAnalyze_Filesystem_Arguments(t *testing.T) {
+	diags := analyze("{\n\tfilesystem\n\tfilesystem lonely\n\tfilesystem a s3\n\tfilesystem a s3\n}\nexample.com {\n\tfs\n\tfs a b\n\tfile_server {\n\t\tfs\n\t}\n}\n")
+	for _, want := range []string{
+		"filesystem requires a name and a file system module",
+		`filesystem "lonely" requires a file system module`,
+		`duplicate filesystem "a"`,
+		"fs requires the name of a file system",
+		`unexpected argument "b"`,
+		"fs takes exactly one file system name",
+	} {
+		if !hasMsg(diags, want) {
+			t.Errorf("missing %q in %v", want, diags)
+		}
+	}
+	if len(diags) != 6 {
+		t.Errorf("expected 6 diagnostics, got %v", diags)
+	}
+}
+
+func TestFilesystemNames(t *testing.T) {
+	f, _ := parser.Parse("{\n\tfilesystem b s3\n\tfilesystem a s3\n}\n")
+	if got := FilesystemNames(f); !slices.Equal(got, []string{"a", "b"}) {
+		t.Errorf("got %v", got)
+	}
+}
@@ -0,0 +1,45 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func FuzzAnalyze(f *testing.F) {
+	for _, s := range []string{
+		"",
+		"{\n\tdebug\n\tservers {\n\t\tprotocols h1 h2\n\t}\n}\n(snip) {\n\trespond {args[0]}\n}\nexample.com {\n\timport snip ok\n\t@api path /api/*\n\treverse_proxy @api localhost:8080 {\n\t\tlb_policy first\n\t}\n}\n",
+		"a.com {\n\trespond <<HTML\n\t\t<p>{</p>\n\t\tHTML 200\n}\n",
+		"a.com {\n\trespond \"}\" # }\n\theader X-A a\\\"b \\\n\t\tc\n}\n",
+		"a.com {\r\n\ttls {\r\n\t}\r\n\tencode zstd gzip 5\r\n}",
+		"a.com {\x00\n\thandle_path /x* {\n\t\troot * {$ROOT:/srv}\n\t}\n}\n",
+		"}}}{{{\n@m {\n",
+		"a.com {\n\tbasicauth {\n\t\tuser $2a$14$hash\n\t}\n\tfs assets\n}\n",
+	} {
+		f.Add(s)
+	}
+	rules := map[string]bool{RuleMixedIndentation: true, RuleTrailingWhitespace: true, RuleFinalNewline: true, RuleEmptyBlock: true}
+	f.Fuzz(func(t *testing.T, src string) {
+		src = parser.StripBOM(src)
+		file, _ := parser.Parse(src)
+		lines := uint32(strings.Count(src, "\n") + 1)
+		check := func(what string, r protocol.Range) {
+			t.Helper()
+			if r.Start.Line >= lines || r.End.Line >= lines || r.End.Line < r.Start.Line {
+				t.Fatalf("%s: range %v outside the %d lines of the document", what, r, lines)
+			}
+		}
+		res := AnalyzeFile(file, Options{CaddyVersion: "2.7"})
+		for _, d := range res.Diagnostics {
+			check(d.Message, d.Range)
+		}
+		for _, fixes := range [][]Fix{AnalyzeWhitespace(src, file, rules), AnalyzeConfusables(src), AnalyzeEmptyBlocks(src, file, rules)} {
+			for _, fix := range fixes {
+				check(fix.Diagnostic.Message, fix.Edit.Range)
+			}
+		}
+	})
+}
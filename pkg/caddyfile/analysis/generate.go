@@ -0,0 +1,3 @@
+package analysis
+
+//go:generate go run caddy-ls/cmd/docgen -directives
@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the corpus tests")
+
+// TestGolden analyzes each Caddyfile of the shared corpus and compares the
+// diagnostics, with their ranges, with testdata/golden. Run with -update
+// after an intended change and review the diff.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob("../testdata/corpus/*.caddyfile")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no corpus files: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".caddyfile")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			f, _ := parser.Parse(string(src))
+			var b strings.Builder
+			for _, d := range Analyze(f) {
+				r := d.Range
+				fmt.Fprintf(&b, "%d:%d-%d:%d %s: %s\n", r.Start.Line, r.Start.Character, r.End.Line, r.End.Character, severityName(d.Severity), d.Message)
+			}
+			checkGolden(t, filepath.Join("testdata", "golden", name+".golden"), b.String())
+		})
+	}
+}
+
+func severityName(s *protocol.DiagnosticSeverity) string {
+	switch {
+	case s == nil:
+		return "none"
+	case *s == protocol.DiagnosticSeverityError:
+		return "error"
+	case *s == protocol.DiagnosticSeverityWarning:
+		return "warning"
+	case *s == protocol.DiagnosticSeverityInformation:
+		return "info"
+	}
+	return "hint"
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the
+// file with -update.
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s is out of date (run with -update and review the diff)\ngot:\n%s", path, got)
+	}
+}
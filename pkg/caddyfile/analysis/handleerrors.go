@@ -0,0 +1,22 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// analyzeHandleErrors checks the optional status arguments of
+// `handle_errors [<status...>] { ... }`, which restrict the block to errors
+// with those codes, classes or ranges. The body is validated like a site
+// block by analyzeDirectiveBody.
+func analyzeHandleErrors(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, arg := range d.Args {
+		diags = append(diags, checkStatusMatch(arg.Token)...)
+	}
+	if !d.HasBody() {
+		diags = append(diags, warningf(d.Name.Range(), "handle_errors requires a block of directives to run on errors"))
+	}
+	return diags
+}
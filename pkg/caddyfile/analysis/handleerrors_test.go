@@ -0,0 +1,34 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_HandleErrors_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\thandle_errors {\n\t\trespond \"{err.status_code} {err.message}\"\n\t}\n",
+		"\thandle_errors 404 410 {\n\t\trespond \"gone\"\n\t}\n",
+		"\thandle_errors 5xx {\n\t\treverse_proxy errors:8080\n\t}\n",
+		"\thandle_errors 500-503 {$ERR_CODE} {\n\t\tfile_server\n\t}\n",
+	}
+	for _, lines := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_HandleErrors_Problems(t *testing.T) {
+	cases := map[string]string{
+		"\thandle_errors 4040 {\n\t}\n":     `invalid status "4040"`,
+		"\thandle_errors 6xx {\n\t}\n":      `invalid status "6xx"`,
+		"\thandle_errors 503-500 {\n\t}\n":  `invalid status "503-500"`,
+		"\thandle_errors notfound {\n\t}\n": `invalid status "notfound"`,
+		"\thandle_errors 404\n":             "handle_errors requires a block of directives",
+	}
+	for lines, want := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", src, want, diags)
+		}
+	}
+}
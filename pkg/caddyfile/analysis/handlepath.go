@@ -0,0 +1,121 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleHandlePathStrip is the code of the rule flagging uses of the prefix a
+// handle_path block has already stripped.
+const RuleHandlePathStrip = "handle-path-strip"
+
+// analyzeHandlePath checks `handle_path <path> { ... }`. handle_path is
+// handle with a rewrite stripping the matched prefix, so the directives in
+// its block see the path without it: a `uri strip_prefix` of that prefix
+// strips it a second time, and a path matcher starting with it no longer
+// matches. Nested handle_path blocks are checked on their own.
+// Source: modules/caddyhttp/rewrite/caddyfile.go (parseCaddyfileHandlePath)
+func analyzeHandlePath(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return nil
+	}
+	path := d.Args[0].Token
+	if !strings.HasPrefix(path.Value, "/") {
+		return []protocol.Diagnostic{errorf(path.Range(), "handle_path only takes a path matcher, which must begin with \"/\", got %q; use handle for other matchers", path.Value)}
+	}
+	prefix := handlePathPrefix(path.Value)
+	if prefix == "" || strings.ContainsAny(prefix, "*{") {
+		return nil
+	}
+
+	var diags []protocol.Diagnostic
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, sub := range ds {
+			switch name := sub.Name.Value; {
+			case name == "uri":
+				diags = append(diags, checkStripPrefix(sub, path.Value, prefix)...)
+			case name == "handle_path":
+				continue
+			case hasPathMatcher(sub) && underPrefix(sub.Args[0].Token.Value, prefix):
+				arg := sub.Args[0].Token
+				diags = append(diags, warningf(arg.Range(),
+					"handle_path %s strips %q before the directives in its block run, so the path no longer starts with it and %s never matches; use %s",
+					path.Value, prefix, arg.Value, strippedPath(arg.Value, prefix)))
+			}
+			if containerDirectives[sub.Name.Value] {
+				walk(sub.Body)
+			}
+		}
+	}
+	walk(d.Body)
+	return withRule(RuleHandlePathStrip, diags)
+}
+
+// checkStripPrefix warns when uri, inside a handle_path for pattern, strips
+// prefix, which handle_path has already stripped, or a path below it.
+func checkStripPrefix(uri *parser.Directive, pattern, prefix string) []protocol.Diagnostic {
+	args := uri.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	if len(args) != 2 || args[0].Token.Value != "strip_prefix" {
+		return nil
+	}
+	target := args[1].Token
+	value := target.Value
+	if !strings.HasPrefix(value, "/") {
+		value = "/" + value // as Caddy does
+	}
+	switch {
+	case strings.TrimSuffix(value, "/") == prefix:
+		return []protocol.Diagnostic{warningf(target.Range(),
+			"handle_path %s already strips %q from the path, so this strips it a second time; remove this line, or use handle to keep the prefix",
+			pattern, prefix)}
+	case strings.HasPrefix(value, prefix+"/"):
+		return []protocol.Diagnostic{warningf(target.Range(),
+			"handle_path %s already strips %q from the path, so it no longer starts with %s; strip %s instead",
+			pattern, prefix, value, strippedPath(value, prefix))}
+	}
+	return nil
+}
+
+// handlePathPrefix returns the prefix handle_path strips for the path
+// pattern: the pattern without a trailing "/*" or "*".
+func handlePathPrefix(pattern string) string {
+	if p, ok := strings.CutSuffix(pattern, "/*"); ok {
+		return p
+	}
+	return strings.TrimSuffix(pattern, "*")
+}
+
+// pathArgDirectives are directives whose first argument may be a path that
+// is not a matcher, such as the files of try_files or the target of redir.
+var pathArgDirectives = map[string]bool{"import": true, "redir": true, "rewrite": true, "root": true, "try_files": true}
+
+// hasPathMatcher reports whether the first argument of d is surely a path
+// matcher: d is a routing block, or a directive outside pathArgDirectives
+// with more arguments after the path.
+func hasPathMatcher(d *parser.Directive) bool {
+	if len(d.Args) == 0 || !strings.HasPrefix(d.Args[0].Token.Value, "/") {
+		return false
+	}
+	return containerDirectives[d.Name.Value] || len(d.Args) >= 2 && !pathArgDirectives[d.Name.Value]
+}
+
+// underPrefix reports whether the path matcher m starts with the path
+// segment prefix.
+func underPrefix(m, prefix string) bool {
+	return m == prefix || strings.HasPrefix(m, prefix+"/") || m == prefix+"*"
+}
+
+// strippedPath returns p with prefix removed, as handle_path leaves it.
+func strippedPath(p, prefix string) string {
+	rest := strings.TrimPrefix(p, prefix)
+	if !strings.HasPrefix(rest, "/") {
+		rest = "/" + rest
+	}
+	return rest
+}
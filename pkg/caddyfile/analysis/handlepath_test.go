@@ -0,0 +1,38 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_HandlePath_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\thandle_path /api/* {\n\t\turi strip_prefix /v1\n\t\treverse_proxy /users/* app:8080\n\t}\n",
+		"\thandle_path /api/* {\n\t\ttry_files /api/index.html {path}\n\t\tredir /api/x 301\n\t\trewrite /api/x\n\t}\n",
+		"\thandle_path /* {\n\t\turi strip_prefix /api\n\t}\n",
+		"\thandle_path /api* {\n\t\turi strip_prefix /apis\n\t}\n",
+		"\thandle_path /api/* {\n\t\thandle_path /v1/* {\n\t\t\treverse_proxy /api/x app\n\t\t}\n\t}\n",
+		"\thandle /api/* {\n\t\turi strip_prefix /api\n\t\treverse_proxy app:8080\n\t}\n",
+	}
+	for _, lines := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_HandlePath_Problems(t *testing.T) {
+	cases := map[string]string{
+		"\t@api path /api/*\n\thandle_path @api {\n\t\trespond 1\n\t}\n":                   `handle_path only takes a path matcher, which must begin with "/", got "@api"`,
+		"\thandle_path /api/* {\n\t\turi strip_prefix /api\n\t\treverse_proxy app\n\t}\n":  `handle_path /api/* already strips "/api" from the path, so this strips it a second time`,
+		"\thandle_path /api/* {\n\t\turi strip_prefix api/\n\t}\n":                         "strips it a second time",
+		"\thandle_path /api* {\n\t\troute {\n\t\t\turi * strip_prefix /api\n\t\t}\n\t}\n":  "strips it a second time",
+		"\thandle_path /api/* {\n\t\turi strip_prefix /api/v1\n\t}\n":                      "so it no longer starts with /api/v1; strip /v1 instead",
+		"\thandle_path /api/* {\n\t\thandle /api/users/* {\n\t\t\trespond 1\n\t\t}\n\t}\n": "so the path no longer starts with it and /api/users/* never matches; use /users/*",
+		"\thandle_path /api/* {\n\t\treverse_proxy /api/* app\n\t}\n":                      "/api/* never matches; use /*",
+	}
+	for lines, want := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", src, want, diags)
+		}
+	}
+}
@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// headerOps are the single-character operators that may prefix a header field:
+// add (+), delete (-), default (?) and deferred set/replace (>).
+const headerOps = "+-?>"
+
+// analyzeHeader validates the header operations of a header or request_header
+// directive, both in the one-line form (`header [<matcher>] <field> …`) and,
+// for header, in the block form. Field names themselves are free-form.
+func analyzeHeader(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	isRequest := d.Name.Value == "request_header"
+
+	args := d.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		diags = append(diags, analyzeHeaderOp(args[0].Token, args[1:], isRequest)...)
+	} else if isRequest {
+		diags = append(diags, warningf(d.Name.Range(), "request_header requires a header field"))
+	}
+
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		switch {
+		case name == "defer":
+			for _, arg := range sub.Args {
+				diags = append(diags, warningf(arg.Range(), "unexpected argument %q: defer takes no arguments", arg.Token.Value))
+			}
+			continue
+		case name == "match", name == "import", strings.HasPrefix(name, "@"):
+			continue
+		}
+		if len(args) > 0 {
+			diags = append(diags, warningf(sub.Name.Range(), "cannot specify headers in both arguments and block"))
+			continue
+		}
+		diags = append(diags, analyzeHeaderOp(sub.Name, sub.Args, isRequest)...)
+	}
+	return diags
+}
+
+// analyzeHeaderOp validates one header operation: a field token with an
+// optional operator prefix followed by its value tokens.
+func analyzeHeaderOp(field parser.Token, values []*parser.Argument, isRequest bool) []protocol.Diagnostic {
+	raw := strings.TrimSuffix(field.Value, ":")
+	if isCaddyPlaceholder(raw) {
+		return nil
+	}
+
+	var op byte
+	name := raw
+	if raw != "" && strings.IndexByte(headerOps, raw[0]) >= 0 {
+		op, name = raw[0], raw[1:]
+	}
+	switch {
+	case name == "":
+		return []protocol.Diagnostic{warningf(field.Range(), "missing header field name after %q", string(op))}
+	case op != 0 && strings.IndexByte(headerOps, name[0]) >= 0:
+		return []protocol.Diagnostic{warningf(field.Range(), "malformed header operation %q: only one of + - ? > may prefix a field name", raw)}
+	case op == '?' && isRequest:
+		return []protocol.Diagnostic{warningf(field.Range(), "the default header operator '?' can only be used on response headers")}
+	}
+	if !strings.Contains(name, "{") {
+		if i := strings.IndexFunc(name, func(r rune) bool { return !isHeaderTokenChar(r) }); i >= 0 {
+			return []protocol.Diagnostic{warningf(field.Range(), "invalid character %q in header field name %q", name[i:i+1], name)}
+		}
+	}
+
+	// Number of value tokens each operation accepts.
+	minVals, maxVals := 1, 2 // set or find/replace
+	switch op {
+	case '-':
+		minVals, maxVals = 0, 0
+	case '+', '?':
+		maxVals = 1
+	}
+
+	var diags []protocol.Diagnostic
+	if len(values) < minVals {
+		diags = append(diags, warningf(field.Range(), "missing value for header %q", name))
+	}
+	for _, extra := range values[min(len(values), maxVals):] {
+		if op == '-' {
+			diags = append(diags, warningf(extra.Range(), "unexpected token %q: deleting header %q takes no value", extra.Token.Value, name))
+		} else {
+			diags = append(diags, warningf(extra.Range(), "unexpected token %q after header %q", extra.Token.Value, name))
+		}
+	}
+	return diags
+}
+
+// isHeaderTokenChar reports whether r is valid in an HTTP header field name
+// (RFC 9110 tchar). '*' doubles as Caddy's wildcard in field names.
+func isHeaderTokenChar(r rune) bool {
+	switch {
+	case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		return true
+	}
+	return strings.ContainsRune("!#$%&'*+-.^_`|~", r)
+}
@@ -0,0 +1,79 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_Header_ValidOperations_NoWarning(t *testing.T) {
+	cases := []string{
+		"example.com {\n\theader X-Frame-Options DENY\n}\n",
+		"example.com {\n\theader /api/* Cache-Control no-store\n}\n",
+		"example.com {\n\theader @static +Link \"</a.css>; rel=preload\"\n}\n",
+		"example.com {\n\theader -Server\n}\n",
+		"example.com {\n\theader Location http:// https://\n}\n",
+		"example.com {\n\theader {\n\t\t+X-Add a\n\t\t-X-Del\n\t\t?X-Default b\n\t\t>X-Deferred c\n\t\tX-Replace old new\n\t\tX-Trailing-Colon: ok\n\t\t-Server*\n\t\tdefer\n\t}\n}\n",
+		"example.com {\n\theader {\n\t\tmatch {\n\t\t\tstatus 200\n\t\t}\n\t\tX-Ok yes\n\t}\n}\n",
+		"example.com {\n\trequest_header X-Forwarded-Proto https\n}\n",
+		"example.com {\n\theader X-Host {host}\n}\n",
+	}
+	for _, src := range cases {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %d: %v", src, len(diags), diags)
+		}
+	}
+}
+
+func TestAnalyze_Header_MalformedOperator(t *testing.T) {
+	diags := analyze("example.com {\n\theader {\n\t\t+-X-Foo bar\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "malformed header operation", "+-X-Foo") {
+		t.Errorf("expected malformed operator diagnostic, got %v", diags)
+	}
+}
+
+func TestAnalyze_Header_OperatorWithoutField(t *testing.T) {
+	diags := analyze("example.com {\n\theader {\n\t\t+ bar\n\t}\n}\n")
+	if !hasMsg(diags, "missing header field name") {
+		t.Errorf("expected missing field diagnostic, got %v", diags)
+	}
+}
+
+func TestAnalyze_Header_MissingValue(t *testing.T) {
+	for _, field := range []string{"X-Set", "+X-Add", "?X-Default", ">X-Deferred"} {
+		diags := analyze("example.com {\n\theader {\n\t\t" + field + "\n\t}\n}\n")
+		if len(diags) != 1 || !hasMsg(diags, "missing value") {
+			t.Errorf("%s: expected missing value diagnostic, got %v", field, diags)
+		}
+	}
+}
+
+func TestAnalyze_Header_ExtraTokens(t *testing.T) {
+	diags := analyze("example.com {\n\theader {\n\t\t+X-Add one two\n\t\t-X-Del value\n\t\tX-Set a b c\n\t}\n}\n")
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %d: %v", len(diags), diags)
+	}
+	if diags[0].Range.Start.Line != 2 || diags[0].Range.Start.Character != 13 {
+		t.Errorf("extra token range should point at %q, got %v", "two", diags[0].Range)
+	}
+	if !hasMsg(diags, "deleting header", "takes no value") {
+		t.Errorf("expected delete-specific message, got %v", diags)
+	}
+}
+
+func TestAnalyze_Header_InvalidFieldCharacter(t *testing.T) {
+	diags := analyze("example.com {\n\theader X:Frame DENY\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "invalid character", "X:Frame") {
+		t.Errorf("expected invalid character diagnostic, got %v", diags)
+	}
+}
+
+func TestAnalyze_Header_ArgsAndBlock(t *testing.T) {
+	diags := analyze("example.com {\n\theader X-A 1 {\n\t\tX-B 2\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "both arguments and block") {
+		t.Errorf("expected args-and-block diagnostic, got %v", diags)
+	}
+}
+
+func TestAnalyze_RequestHeader_DefaultOperatorRejected(t *testing.T) {
+	diags := analyze("example.com {\n\trequest_header ?X-Foo bar\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "response headers") {
+		t.Errorf("expected response-only diagnostic, got %v", diags)
+	}
+}
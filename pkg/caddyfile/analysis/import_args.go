@@ -0,0 +1,181 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleImportArgs is the code of the rule flagging misused import argument
+// placeholders.
+const RuleImportArgs = "import-args"
+
+// importArgRef is a placeholder for the arguments of an import line:
+// {args[N]}, the deprecated {args.N}, or the variadic {args[N:M]}, which
+// expands to several tokens and takes any of its bounds from the argument
+// count when left out.
+type importArgRef struct {
+	text string // the placeholder, with braces
+	rng  protocol.Range
+	// need is the number of arguments the import must pass for the
+	// placeholder to be replaced.
+	need    int
+	problem string
+}
+
+// importArgRefs returns the import argument placeholders in tok.
+// Source: caddyconfig/caddyfile/importargs.go (parseVariadic,
+// makeArgsReplacer)
+func importArgRefs(tok parser.Token) []importArgRef {
+	var refs []importArgRef
+	for _, p := range placeholdersIn(tok.Value) {
+		name := tok.Value[p.start:p.end]
+		if !strings.HasPrefix(name, "args[") && !strings.HasPrefix(name, "args.") {
+			continue
+		}
+		ref := importArgRef{
+			text: "{" + name + "}",
+			rng:  protocol.Range{Start: tok.Position(p.start - 1), End: tok.Position(p.end + 1)},
+		}
+		if index, ok := strings.CutPrefix(name, "args."); ok {
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 {
+				ref.problem = fmt.Sprintf("invalid index in %s", ref.text)
+			} else {
+				ref.need = n + 1
+				ref.problem = fmt.Sprintf("%s is deprecated; use {args[%d]}", ref.text, n)
+			}
+			refs = append(refs, ref)
+			continue
+		}
+		index, ok := strings.CutSuffix(strings.TrimPrefix(name, "args["), "]")
+		switch start, end, variadic := strings.Cut(index, ":"); {
+		case !ok:
+			ref.problem = fmt.Sprintf("malformed import argument placeholder %s; want {args[N]} or {args[N:M]}", ref.text)
+		case index == "":
+			ref.problem = fmt.Sprintf("%s needs an index", ref.text)
+		case variadic && trimQuotes(tok.Value) != ref.text:
+			ref.problem = fmt.Sprintf("variadic placeholder %s must be a token on its own", ref.text)
+		case variadic:
+			ref.need, ref.problem = sliceNeed(ref.text, start, end)
+		default:
+			n, err := strconv.Atoi(index)
+			if err != nil || n < 0 {
+				ref.problem = fmt.Sprintf("invalid index in %s", ref.text)
+			} else {
+				ref.need = n + 1
+			}
+		}
+		refs = append(refs, ref)
+	}
+	return refs
+}
+
+// sliceNeed returns the number of arguments the variadic placeholder text,
+// with the bounds start and end, needs, or what is wrong with the bounds.
+// An omitted start is 0 and an omitted end is the argument count, so only
+// the given bounds need arguments.
+func sliceNeed(text, start, end string) (int, string) {
+	bound := func(s string) (int, bool) {
+		if s == "" {
+			return 0, true
+		}
+		n, err := strconv.Atoi(s)
+		return n, err == nil && n >= 0
+	}
+	lo, okLo := bound(start)
+	hi, okHi := bound(end)
+	switch {
+	case !okLo || !okHi:
+		return 0, fmt.Sprintf("invalid index in %s", text)
+	case end != "" && lo > hi:
+		return 0, fmt.Sprintf("%s starts after it ends", text)
+	case end != "":
+		return hi, ""
+	}
+	return lo, ""
+}
+
+// siteTokens calls fn with every address and directive token of the site
+// block sb.
+func siteTokens(sb *parser.SiteBlock, fn func(parser.Token)) {
+	for _, addr := range sb.Addresses {
+		fn(addr)
+	}
+	directiveTokens(sb.Directives, fn)
+}
+
+// directiveTokens calls fn with the name and arguments of every directive
+// in ds, at any depth.
+func directiveTokens(ds []*parser.Directive, fn func(parser.Token)) {
+	for _, d := range ds {
+		fn(d.Name)
+		for _, arg := range d.Args {
+			fn(arg.Token)
+		}
+		directiveTokens(d.Body, fn)
+	}
+}
+
+// collectSnippetArgs returns the number of import arguments each snippet
+// of f needs, for the snippets that use any.
+func collectSnippetArgs(f *parser.File) map[string]int {
+	needs := make(map[string]int)
+	for _, sb := range f.SiteBlocks {
+		name, ok := "", isSnippet(sb)
+		if ok {
+			name, ok = parseSnippetName(sb.Addresses[0].Value)
+		}
+		if !ok {
+			continue
+		}
+		siteTokens(sb, func(tok parser.Token) {
+			for _, ref := range importArgRefs(tok) {
+				if ref.need > needs[name] {
+					needs[name] = ref.need
+				}
+			}
+		})
+	}
+	return needs
+}
+
+// analyzeImportArgs checks the import argument placeholders among the
+// tokens walk visits. They are only replaced in snippets and in files
+// imported by another one, which the options tell about; elsewhere Caddy
+// leaves them as they are.
+func (a *analyzer) analyzeImportArgs(walk func(fn func(parser.Token)), inSnippet bool) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	walk(func(tok parser.Token) {
+		for _, ref := range importArgRefs(tok) {
+			switch {
+			case !inSnippet && !a.imported():
+				diags = append(diags, ruleError(RuleImportArgs, ref.rng, "%s is only replaced in snippets and imported files", ref.text))
+			case ref.problem != "":
+				diags = append(diags, ruleDiag(RuleImportArgs, ref.rng, "%s", ref.problem))
+			}
+		}
+	})
+	return diags
+}
+
+// importArgCount reports an import of a snippet of this file that passes
+// fewer arguments than the snippet uses.
+func (a *analyzer) importArgCount(d *parser.Directive) []protocol.Diagnostic {
+	need := a.snippetArgs[d.Args[0].Token.Value]
+	passed := d.Args[1:]
+	for _, arg := range passed {
+		if len(importArgRefs(arg.Token)) > 0 { // forwarded arguments, count unknown
+			return nil
+		}
+	}
+	if len(passed) >= need {
+		return nil
+	}
+	return []protocol.Diagnostic{ruleDiag(RuleImportArgs, d.Args[0].Range(),
+		"snippet %q uses %d import argument(s), but %d given; the placeholders for the missing ones are left as they are",
+		d.Args[0].Token.Value, need, len(passed))}
+}
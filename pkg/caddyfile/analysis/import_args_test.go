@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyzeImportArgs_Snippets(t *testing.T) {
+	for src, want := range map[string]string{
+		"(s) {\n\trespond {args[0]} {args[1]}\n}\nexample.com {\n\timport s a b\n}\n": "",
+		"(s) {\n\treverse_proxy {args[:]}\n}\nexample.com {\n\timport s a b c\n}\n":   "",
+		"(s) {\n\treverse_proxy {args[1:]}\n}\nexample.com {\n\timport s a\n}\n":      "",
+		"(s) {\n\treverse_proxy {args[0:2]}\n}\nexample.com {\n\timport s a b\n}\n":   "",
+		"(s) {\n\trespond {args[0]}\n}\n(t) {\n\timport s {args[0]}\n}\n":             "",
+		"(s) {\n\trespond {args[0]} {args[2]}\n}\nexample.com {\n\timport s a b\n}\n": `snippet "s" uses 3 import argument(s), but 2 given`,
+		"(s) {\n\treverse_proxy {args[0:2]}\n}\nexample.com {\n\timport s a\n}\n":     `snippet "s" uses 2 import argument(s), but 1 given`,
+		"(s) {\n\treverse_proxy {args[2:]}\n}\nexample.com {\n\timport s a\n}\n":      `snippet "s" uses 2 import argument(s), but 1 given`,
+		"(s) {\n\treverse_proxy {args[2:1]}\n}\n":                                     "{args[2:1]} starts after it ends",
+		"(s) {\n\treverse_proxy {args[x:]}\n}\n":                                      "invalid index in {args[x:]}",
+		"(s) {\n\treverse_proxy http://{args[0:2]}\n}\n":                              "variadic placeholder {args[0:2]} must be a token on its own",
+		"(s) {\n\trespond {args[]}\n}\n":                                              "{args[]} needs an index",
+		"(s) {\n\trespond {args[a]}\n}\n":                                             "invalid index in {args[a]}",
+		"(s) {\n\trespond {args.0}\n}\nexample.com {\n\timport s a\n}\n":              "{args.0} is deprecated; use {args[0]}",
+	} {
+		diags := analyze(src)
+		if want == "" {
+			if len(diags) != 0 {
+				t.Errorf("%q: want no diagnostics, got %v", src, diags)
+			}
+			continue
+		}
+		if len(diags) != 1 || !hasMsg(diags, want) {
+			t.Errorf("%q: want %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestAnalyzeImportArgs_OutsideSnippets(t *testing.T) {
+	src := "{args[0]} {\n\treverse_proxy {args[1:]}\n}\n"
+	f, _ := parser.Parse(src)
+	diags := AnalyzeWith(f, Options{})
+	if len(diags) != 2 || !hasMsg(diags, "{args[0]} is only replaced in snippets and imported files") || !hasMsg(diags, "{args[1:]} is only replaced") {
+		t.Fatalf("want errors for both placeholders, got %v", diags)
+	}
+	for _, d := range diags {
+		if *d.Severity != protocol.DiagnosticSeverityError {
+			t.Errorf("%q: want an error, got severity %v", d.Message, *d.Severity)
+		}
+	}
+	want := protocol.Range{Start: protocol.Position{Line: 1, Character: 15}, End: protocol.Position{Line: 1, Character: 25}}
+	if diags[1].Range != want {
+		t.Errorf("range: want %v, got %v", want, diags[1].Range)
+	}
+
+	asked := 0
+	opts := Options{Imported: func() bool { asked++; return true }}
+	if diags := AnalyzeWith(f, opts); len(diags) != 0 {
+		t.Errorf("imported file: want no diagnostics, got %v", diags)
+	}
+	if asked != 1 {
+		t.Errorf("Imported called %d times, want once", asked)
+	}
+	f, _ = parser.Parse("example.com {\n\trespond hi\n}\n")
+	if AnalyzeWith(f, opts); asked != 1 {
+		t.Error("Imported should not be called for a file without import placeholders")
+	}
+}
@@ -0,0 +1,94 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"slices"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// ImportedBody is the contents of a file imported inside a block, parsed
+// with parser.ParseDirectives.
+type ImportedBody struct {
+	URI        string
+	Directives []*parser.Directive
+}
+
+// AnalyzeImportedBodies checks the files pulled in by the import line d of
+// f against the block the line sits in: a block of parent, or a site block
+// when parent is empty. Caddy splices an imported file in place of the
+// import, so a file imported in a site block or routing container must hold
+// site-level directives, and one imported in a reverse_proxy block must hold
+// reverse_proxy subdirectives. Mismatches are reported at the import's
+// argument, each offending directive as related information. Blocks whose
+// contents are not validated are skipped.
+func AnalyzeImportedBodies(s *Schema, f *parser.File, parent string, d *parser.Directive, bodies []ImportedBody) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return nil
+	}
+	valid, where, ok := blockValidator(s, f, parent)
+	if !ok {
+		return nil
+	}
+	var names []string
+	var related []protocol.DiagnosticRelatedInformation
+	for _, body := range bodies {
+		for _, sub := range body.Directives {
+			name := sub.Name.Value
+			if strings.HasPrefix(name, "@") || name == "import" || valid(name) {
+				continue
+			}
+			if !slices.Contains(names, name) {
+				names = append(names, name)
+			}
+			related = append(related, protocol.DiagnosticRelatedInformation{
+				Location: protocol.Location{URI: body.URI, Range: sub.Name.Range()},
+				Message:  importedMismatch(name, parent),
+			})
+		}
+	}
+	if len(names) == 0 {
+		return nil
+	}
+	verb := "is"
+	if len(names) > 1 {
+		verb = "are"
+	}
+	diag := warningf(d.Args[0].Range(), "%s from imported %s %s not valid %s", joinQuoted(names), d.Args[0].Token.Value, verb, where)
+	diag.RelatedInformation = related
+	SetRuleCode(&diag, RuleUnknownSubdirective)
+	return []protocol.Diagnostic{diag}
+}
+
+// blockValidator returns the check for directive names directly inside a
+// block of parent, and how to name that block in messages. ok is false when
+// the block's contents are not validated.
+func blockValidator(s *Schema, f *parser.File, parent string) (valid func(string) bool, where string, ok bool) {
+	if parent == "" || containerDirectives[parent] {
+		ordered := collectOrdered(f)
+		where = "in a site block"
+		if parent != "" {
+			where = fmt.Sprintf("inside %q", parent)
+		}
+		return func(name string) bool { return s.IsDirective(name) || ordered[name] }, where, true
+	}
+	subs, known := s.SubDirectivesFor(parent)
+	if !known || subs == nil {
+		return nil, "", false
+	}
+	return func(name string) bool { return subs[name] }, fmt.Sprintf("inside %q", parent), true
+}
+
+// importedMismatch explains why name cannot be used where a file was
+// imported inside a block of parent.
+func importedMismatch(name, parent string) string {
+	if parent == "" || containerDirectives[parent] {
+		if p, ok := knownSubDirectiveParent[name]; ok {
+			return fmt.Sprintf("%q must appear inside a %q block", name, p)
+		}
+		return fmt.Sprintf("unknown directive %q", name)
+	}
+	return fmt.Sprintf("%q is not a subdirective of %q", name, parent)
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+// importedBodies parses each file's contents as a body imported from uri.
+func importedBodies(files map[string]string) []ImportedBody {
+	var bodies []ImportedBody
+	for uri, src := range files {
+		ds, _ := parser.ParseDirectives(src)
+		bodies = append(bodies, ImportedBody{URI: uri, Directives: ds})
+	}
+	return bodies
+}
+
+func TestAnalyzeImportedBodies_Subdirectives(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\treverse_proxy app:8080 {\n\t\timport ./proxy.conf\n\t}\n}\n")
+	imp := f.SiteBlocks[0].Directives[0].Body[0]
+	bodies := importedBodies(map[string]string{"file:///proxy.conf": "lb_policy first\n@err status 5xx\nencode gzip\nimport ./more.conf\n"})
+
+	diags := AnalyzeImportedBodies(DefaultSchema(), f, "reverse_proxy", imp, bodies)
+	if len(diags) != 1 || !hasMsg(diags, `"encode" from imported ./proxy.conf is not valid inside "reverse_proxy"`) {
+		t.Fatalf("got %v", diags)
+	}
+	d := diags[0]
+	if d.Range != imp.Args[0].Range() {
+		t.Errorf("want the import's argument, got %v", d.Range)
+	}
+	if len(d.RelatedInformation) != 1 || d.RelatedInformation[0].Location.URI != "file:///proxy.conf" || d.RelatedInformation[0].Location.Range.Start.Line != 2 {
+		t.Errorf("want encode in proxy.conf as related information, got %+v", d.RelatedInformation)
+	}
+}
+
+func TestAnalyzeImportedBodies_SiteLevel(t *testing.T) {
+	f, _ := parser.Parse("{\n\torder rate_limit before basic_auth\n}\nexample.com {\n\thandle /api/* {\n\t\timport ./api.conf\n\t}\n}\n")
+	imp := f.SiteBlocks[0].Directives[0].Body[0]
+	bodies := importedBodies(map[string]string{"file:///api.conf": "rate_limit 10\nreverse_proxy api:8080\nlb_policy first\nrevers_proxy x\n"})
+
+	diags := AnalyzeImportedBodies(DefaultSchema(), f, "handle", imp, bodies)
+	if len(diags) != 1 || !hasMsg(diags, `"lb_policy", "revers_proxy" from imported ./api.conf are not valid inside "handle"`) {
+		t.Fatalf("got %v", diags)
+	}
+	related := diags[0].RelatedInformation
+	if len(related) != 2 || related[0].Message != `"lb_policy" must appear inside a "reverse_proxy" block` || related[1].Message != `unknown directive "revers_proxy"` {
+		t.Errorf("got %+v", related)
+	}
+	if diags := AnalyzeImportedBodies(DefaultSchema(), f, "", imp, importedBodies(map[string]string{"file:///ok.conf": "encode gzip\n"})); len(diags) != 0 {
+		t.Errorf("site-level directives in a site block: got %v", diags)
+	}
+}
+
+func TestAnalyzeImportedBodies_UnvalidatedBlock(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\theader {\n\t\timport ./headers.conf\n\t}\n}\n")
+	imp := f.SiteBlocks[0].Directives[0].Body[0]
+	bodies := importedBodies(map[string]string{"file:///headers.conf": "X-Frame-Options DENY\n"})
+	if diags := AnalyzeImportedBodies(DefaultSchema(), f, "header", imp, bodies); len(diags) != 0 {
+		t.Errorf("freeform body: got %v", diags)
+	}
+}
@@ -0,0 +1,65 @@
+package analysis
+
+import "strings"
+
+// subDirectiveTemplates maps a directive to templates, in LSP snippet
+// syntax, for the arguments of its subdirectives: typical values as
+// placeholders, or the accepted values as a choice. Completion inserts them
+// after the name.
+var subDirectiveTemplates = func() map[string]map[string]string {
+	timeout := "${1:5s}"
+	proxy := map[string]string{
+		"to":                      "${1:localhost:8080}",
+		"transport":               choice(builtinTransports),
+		"header_up":               "${1:Host} ${2:{upstream_hostport\\}}",
+		"header_down":             "${1:-Server}",
+		"lb_policy":               choice(lbPolicyNames),
+		"lb_retries":              "${1:3}",
+		"lb_try_duration":         timeout,
+		"lb_try_interval":         "${1:250ms}",
+		"health_uri":              "${1:/healthz}",
+		"health_port":             "${1:8080}",
+		"health_interval":         "${1:30s}",
+		"health_timeout":          timeout,
+		"health_status":           "${1:2xx}",
+		"health_passes":           "${1:1}",
+		"health_fails":            "${1:1}",
+		"max_fails":               "${1:3}",
+		"fail_duration":           "${1:30s}",
+		"unhealthy_status":        "${1:5xx}",
+		"unhealthy_latency":       timeout,
+		"unhealthy_request_count": "${1:100}",
+		"flush_interval":          "${1:-1}",
+		"trusted_proxies":         "${1:private_ranges}",
+		"stream_timeout":          "${1:24h}",
+		"stream_close_delay":      "${1:5m}",
+	}
+	return map[string]map[string]string{
+		"reverse_proxy": proxy,
+		"php_fastcgi": {
+			"root": "${1:/var/www/html}", "split": "${1:.php}", "index": "${1:index.php}",
+			"env": "${1:KEY} ${2:value}", "dial_timeout": "${1:3s}",
+			"read_timeout": "${1:30s}", "write_timeout": "${1:30s}",
+		},
+		"request_body": {"max_size": "${1:10MB}"},
+		"encode":       {"minimum_length": "${1:256}"},
+		"file_server": {
+			"root": "${1:/srv}", "index": "${1:index.html}", "hide": "${1:.git}",
+			"status": "${1:404}", "precompressed": "${1:zstd br gzip}",
+		},
+		"log": {
+			"output": choice([]string{"stdout", "stderr", "discard", "file"}),
+			"format": choice([]string{"console", "json"}),
+			"level":  choice([]string{"DEBUG", "INFO", "WARN", "ERROR"}),
+		},
+		"tls": {
+			"protocols": "${1:tls1.2} ${2:tls1.3}",
+			"key_type":  choice([]string{"ed25519", "p256", "p384", "rsa2048", "rsa4096"}),
+		},
+	}
+}()
+
+// choice returns a snippet choice of names as the first tab stop.
+func choice(names []string) string {
+	return "${1|" + strings.Join(names, ",") + "|}"
+}
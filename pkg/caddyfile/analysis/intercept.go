@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"strconv"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// responseMatcherTypes are the types a named response matcher may use.
+// Source: modules/caddyhttp/responsematchers.go (ParseNamedResponseMatcher)
+var responseMatcherTypes = []string{"header", "status"}
+
+// analyzeIntercept checks the body of `intercept [<matcher>] { ... }`: its
+// named response matchers, and the replace_status and handle_response lines
+// that use them. Caddy only looks these matchers up among the ones defined
+// in the same intercept block, so request matchers of the site do not
+// count. The bodies of handle_response hold site-level directives.
+// Source: modules/caddyhttp/intercept/intercept.go (UnmarshalCaddyfile)
+func (a *analyzer) analyzeIntercept(d *parser.Directive) []protocol.Diagnostic {
+	if !d.HasBody() {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "intercept requires a block with replace_status or handle_response to act on responses")}
+	}
+
+	var diags []protocol.Diagnostic
+	defined := make(map[string]bool)
+	for _, sub := range d.Body {
+		if isMatcherName(sub.Name.Value) {
+			defined[sub.Name.Value] = true
+			diags = append(diags, analyzeResponseMatcher(sub)...)
+		}
+	}
+	checkMatcher := func(sub *parser.Directive, tok parser.Token) []protocol.Diagnostic {
+		switch {
+		case !isMatcherName(tok.Value):
+			return []protocol.Diagnostic{errorf(tok.Range(), "%s takes a named response matcher, starting with \"@\", got %q", sub.Name.Value, tok.Value)}
+		case !defined[tok.Value]:
+			return []protocol.Diagnostic{errorf(tok.Range(), "no response matcher %s is defined in this intercept block", tok.Value)}
+		}
+		return nil
+	}
+
+	inSnippet := a.site != nil && isSnippet(a.site)
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "replace_status":
+			diags = append(diags, analyzeReplaceStatus(sub, checkMatcher)...)
+		case "handle_response":
+			switch len(sub.Args) {
+			case 0:
+			case 1:
+				diags = append(diags, checkMatcher(sub, sub.Args[0].Token)...)
+			case 2:
+				diags = append(diags, errorf(sub.Args[1].Range(), "handle_response no longer replaces the status code; use replace_status instead"))
+			default:
+				diags = append(diags, errorf(sub.Args[1].Range(), "handle_response takes at most one argument, a named response matcher"))
+			}
+			for _, inner := range sub.Body {
+				diags = append(diags, a.analyzeSiteDirective(inner, inSnippet)...)
+			}
+		}
+	}
+	return diags
+}
+
+// analyzeReplaceStatus checks `replace_status [<matcher>] <status_code>`,
+// which takes no block. checkMatcher checks the response matcher.
+func analyzeReplaceStatus(d *parser.Directive, checkMatcher func(*parser.Directive, parser.Token) []protocol.Diagnostic) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	switch args := d.Args; {
+	case len(args) == 0 || len(args) > 2:
+		diags = append(diags, errorf(d.Name.Range(), "replace_status requires a status code, optionally after a named response matcher"))
+	case len(args) == 2:
+		diags = append(diags, checkMatcher(d, args[0].Token)...)
+		diags = append(diags, checkStatusCode(args[1].Token)...)
+	case isMatcherName(args[0].Token.Value):
+		diags = append(diags, errorf(args[0].Range(), "replace_status requires a status code after the response matcher"))
+	default:
+		diags = append(diags, checkStatusCode(args[0].Token)...)
+	}
+	if d.HasBody() {
+		diags = append(diags, errorf(d.LBrace.Range(), "replace_status cannot have a block of routes; use handle_response instead"))
+	}
+	return diags
+}
+
+// analyzeResponseMatcher checks a named response matcher, which may only
+// match on the status code and header fields of the response.
+func analyzeResponseMatcher(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, line := range MatcherLines(d) {
+		switch {
+		case !slices.Contains(responseMatcherTypes, line.Type):
+			diags = append(diags, warningf(line.Token.Range(), "unknown response matcher %q%s; response matchers match on status and header",
+				line.Type, didYouMean(line.Type, responseMatcherTypes)))
+		case len(line.Args) == 0:
+			diags = append(diags, errorf(line.Token.Range(), "%s requires at least one argument", line.Type))
+		case line.Type == "status":
+			for _, arg := range line.Args {
+				if v := arg.Token.Value; !isCaddyPlaceholder(v) && !isResponseStatus(v) {
+					diags = append(diags, warningf(arg.Range(), "invalid status %q: expected a code like 404 or a class like 5xx", v))
+				}
+			}
+		}
+	}
+	return diags
+}
+
+// isResponseStatus reports whether s is a status code or class a response
+// matcher accepts. Unlike handle_errors, ranges are not.
+func isResponseStatus(s string) bool {
+	return len(s) == 3 && s[1:] == "xx" && s[0] >= '1' && s[0] <= '5' || isStatusCode(s)
+}
+
+// checkStatusCode reports a warning when tok is not an HTTP status code
+// between 100 and 599. Placeholders are accepted.
+func checkStatusCode(tok parser.Token) []protocol.Diagnostic {
+	if isCaddyPlaceholder(tok.Value) || isStatusCode(tok.Value) {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(tok.Range(), "invalid status code %q: expected a code like 200 or 404", tok.Value)}
+}
+
+// isStatusCode reports whether s is a three-digit status code between 100
+// and 599.
+func isStatusCode(s string) bool {
+	n, err := strconv.Atoi(s)
+	return len(s) == 3 && err == nil && n >= 100 && n <= 599
+}
@@ -0,0 +1,50 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_Intercept_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\tintercept {\n\t\t@accel header X-Accel-Redirect *\n\t\thandle_response @accel {\n\t\t\troot * /srv/private\n\t\t\trewrite {http.intercept.header.X-Accel-Redirect}\n\t\t\tfile_server\n\t\t}\n\t}\n",
+		"\tintercept {\n\t\t@err {\n\t\t\tstatus 5xx 404\n\t\t}\n\t\treplace_status @err 200\n\t\treplace_status 203\n\t}\n",
+		"\tintercept /api/* {\n\t\thandle_response {\n\t\t\trespond {http.intercept.status_code}\n\t\t}\n\t}\n",
+		"\tintercept {\n\t\treplace_status {$CODE}\n\t}\n",
+	}
+	for _, lines := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_Intercept_Problems(t *testing.T) {
+	cases := map[string]string{
+		"\tintercept\n": "intercept requires a block",
+		"\tintercept {\n\t\treplace_status\n\t}\n":                           "replace_status requires a status code",
+		"\tintercept {\n\t\treplace_status 20\n\t}\n":                        `invalid status code "20"`,
+		"\tintercept {\n\t\treplace_status @x 200\n\t}\n":                    "no response matcher @x is defined in this intercept block",
+		"\tintercept {\n\t\treplace_status x 200\n\t}\n":                     `replace_status takes a named response matcher, starting with "@", got "x"`,
+		"\tintercept {\n\t\t@e status 500\n\t\treplace_status @e\n\t}\n":     "requires a status code after the response matcher",
+		"\tintercept {\n\t\treplace_status 200 {\n\t\t}\n\t}\n":              "replace_status cannot have a block of routes",
+		"\tintercept {\n\t\t@e path /x\n\t}\n":                               `unknown response matcher "path"`,
+		"\tintercept {\n\t\t@e statuss 500\n\t}\n":                           `unknown response matcher "statuss" (did you mean "status"?)`,
+		"\tintercept {\n\t\t@e status 500-599\n\t}\n":                        `invalid status "500-599"`,
+		"\tintercept {\n\t\t@e status\n\t}\n":                                "status requires at least one argument",
+		"\tintercept {\n\t\thandle_response @e 200 {\n\t\t}\n\t}\n":          "use replace_status instead",
+		"\tintercept {\n\t\thandle_response {\n\t\t\trespnd 1\n\t\t}\n\t}\n": `unknown directive "respnd"`,
+		"\tintercept {\n\t\thandle {\n\t\t}\n\t}\n":                          `unknown subdirective "handle" for "intercept"`,
+	}
+	for lines, want := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_Intercept_SiteMatcherNotVisible(t *testing.T) {
+	src := "example.com {\n\t@err path /x\n\tintercept {\n\t\treplace_status @err 200\n\t}\n}\n"
+	if diags := analyze(src); !hasMsg(diags, "no response matcher @err is defined in this intercept block") {
+		t.Errorf("a request matcher of the site is not a response matcher, got %v", diags)
+	}
+}
@@ -0,0 +1,74 @@
+package analysis
+
+import (
+	"cmp"
+	"slices"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// diagKey identifies diagnostics that report the same problem on the same
+// line.
+type diagKey struct {
+	code    any
+	message string
+	line    protocol.UInteger
+}
+
+// DedupeDiagnostics drops diagnostics that repeat the code, message and line
+// of an earlier one, so a systemic mistake reported once per token shows up
+// once per line.
+func DedupeDiagnostics(diags []protocol.Diagnostic) []protocol.Diagnostic {
+	seen := make(map[diagKey]bool, len(diags))
+	out := diags[:0:0]
+	for _, d := range diags {
+		k := diagKey{message: d.Message, line: d.Range.Start.Line}
+		if d.Code != nil {
+			k.code = d.Code.Value
+		}
+		if seen[k] {
+			continue
+		}
+		seen[k] = true
+		out = append(out, d)
+	}
+	return out
+}
+
+// CapDiagnostics keeps at most limit of diags, preferring the most severe
+// and otherwise keeping their order, and appends an informational
+// diagnostic at the top of the document counting the rest. A negative limit
+// keeps everything.
+func CapDiagnostics(diags []protocol.Diagnostic, limit int) []protocol.Diagnostic {
+	if limit < 0 || len(diags) <= limit {
+		return diags
+	}
+	order := make([]int, len(diags))
+	for i := range order {
+		order[i] = i
+	}
+	slices.SortStableFunc(order, func(a, b int) int {
+		return cmp.Compare(severityOf(diags[a]), severityOf(diags[b]))
+	})
+	kept := order[:limit]
+	slices.Sort(kept)
+	out := make([]protocol.Diagnostic, 0, limit+1)
+	for _, i := range kept {
+		out = append(out, diags[i])
+	}
+	noun := "problems"
+	if len(diags)-limit == 1 {
+		noun = "problem"
+	}
+	return append(out, newDiag(protocol.Range{}, protocol.DiagnosticSeverityInformation,
+		"%d more %s not shown; at most %d diagnostics are reported per file", len(diags)-limit, noun, limit))
+}
+
+// severityOf returns the severity of d, treating a missing one as an error
+// as the protocol does.
+func severityOf(d protocol.Diagnostic) protocol.DiagnosticSeverity {
+	if d.Severity == nil {
+		return protocol.DiagnosticSeverityError
+	}
+	return *d.Severity
+}
@@ -0,0 +1,58 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func diagAt(line protocol.UInteger, severity protocol.DiagnosticSeverity, msg string) protocol.Diagnostic {
+	return newDiag(protocol.Range{Start: protocol.Position{Line: line}, End: protocol.Position{Line: line}}, severity, "%s", msg)
+}
+
+func TestDedupeDiagnostics(t *testing.T) {
+	coded := diagAt(1, protocol.DiagnosticSeverityWarning, "same")
+	coded.Code = &protocol.IntegerOrString{Value: "rule"}
+	diags := []protocol.Diagnostic{
+		diagAt(1, protocol.DiagnosticSeverityWarning, "same"),
+		diagAt(1, protocol.DiagnosticSeverityWarning, "same"),
+		diagAt(2, protocol.DiagnosticSeverityWarning, "same"),
+		diagAt(1, protocol.DiagnosticSeverityWarning, "other"),
+		coded,
+	}
+	got := DedupeDiagnostics(diags)
+	if len(got) != 4 {
+		t.Fatalf("got %d diagnostics, want 4: %+v", len(got), got)
+	}
+	if got[1].Range.Start.Line != 2 || got[2].Message != "other" || got[3].Code == nil {
+		t.Errorf("unexpected order or selection: %+v", got)
+	}
+}
+
+func TestCapDiagnostics(t *testing.T) {
+	var diags []protocol.Diagnostic
+	for i := range 4 {
+		diags = append(diags, diagAt(protocol.UInteger(i), protocol.DiagnosticSeverityWarning, "warning"))
+	}
+	diags = append(diags, diagAt(10, protocol.DiagnosticSeverityError, "error"))
+
+	got := CapDiagnostics(diags, 3)
+	if len(got) != 4 {
+		t.Fatalf("got %d diagnostics, want 3 plus a summary", len(got))
+	}
+	if got[0].Range.Start.Line != 0 || got[1].Range.Start.Line != 1 || got[2].Message != "error" {
+		t.Errorf("want the error kept and document order preserved, got %+v", got[:3])
+	}
+	summary := got[3]
+	if *summary.Severity != protocol.DiagnosticSeverityInformation || !strings.HasPrefix(summary.Message, "2 more problems not shown") {
+		t.Errorf("summary = %+v", summary)
+	}
+
+	if got := CapDiagnostics(diags, len(diags)); len(got) != len(diags) {
+		t.Errorf("at the limit: got %d diagnostics, want %d", len(got), len(diags))
+	}
+	if got := CapDiagnostics(diags, -1); len(got) != len(diags) {
+		t.Errorf("no limit: got %d diagnostics, want %d", len(got), len(diags))
+	}
+}
@@ -0,0 +1,72 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// analyzeSiteLog checks the log directive of a site: at most one logger
+// name.
+func analyzeSiteLog(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) > 1 {
+		return []protocol.Diagnostic{errorf(d.Args[1].Range(), "log takes at most one logger name")}
+	}
+	return nil
+}
+
+// misplacedLogOption explains an option of the log directive that only the
+// log global option accepts, or the other way round. The two share a syntax
+// but configure different things: the directive enables the access log of
+// its site, the global option configures Caddy's runtime logs.
+// Source: caddyconfig/httpcaddyfile/builtins.go (parseLogHelper)
+func misplacedLogOption(parent, name string) (string, bool) {
+	if parent != "log" {
+		return "", false
+	}
+	switch name {
+	case "include", "exclude":
+		return fmt.Sprintf("%s is only allowed in the log global option, which filters the runtime logs; the log directive configures the access log of this site", name), true
+	case "hostnames":
+		return "hostnames is only allowed in the log directive of a site; the log global option configures Caddy's runtime logs", true
+	}
+	return "", false
+}
+
+// analyzeGlobalLog checks a log global option: at most one logger name,
+// unique among the log global options, and the options valid there.
+func (a *analyzer) analyzeGlobalLog(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	name, rng := "default", d.Name.Range()
+	if len(d.Args) > 0 {
+		name, rng = d.Args[0].Token.Value, d.Args[0].Range()
+	}
+	if len(d.Args) > 1 {
+		diags = append(diags, errorf(d.Args[1].Range(), "log takes at most one logger name"))
+	}
+	if a.globalLogs == nil {
+		a.globalLogs = make(map[string]bool)
+	}
+	if a.globalLogs[name] {
+		diags = append(diags, errorf(rng, "duplicate log global option for logger %q", name))
+	}
+	a.globalLogs[name] = true
+
+	names, _ := GlobalBlockNames([]string{"log"})
+	for _, sub := range d.Body {
+		if sub.Name.Value == "import" || globalOptionBlocks["log"][sub.Name.Value] {
+			continue
+		}
+		if sub.Name.Value == "no_hostname" { // accepted, but only sites have hostnames
+			diags = append(diags, warningf(sub.Name.Range(), "no_hostname has no effect in the log global option"))
+			continue
+		}
+		if msg, ok := misplacedLogOption("log", sub.Name.Value); ok {
+			diags = append(diags, errorf(sub.Name.Range(), "%s", msg))
+			continue
+		}
+		diags = append(diags, errorf(sub.Name.Range(), "unrecognized log option %q%s", sub.Name.Value, didYouMean(sub.Name.Value, names)))
+	}
+	return diags
+}
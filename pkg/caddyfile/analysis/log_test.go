@@ -0,0 +1,50 @@
+package analysis
+
+import "testing"
+
+func TestAnalyzeLog_SiteAndGlobal(t *testing.T) {
+	src := "{\n" +
+		"\tlog {\n" +
+		"\t\toutput stderr\n" +
+		"\t\tinclude http.log.access\n" +
+		"\t\thostnames a.com\n" +
+		"\t\tno_hostname\n" +
+		"\t\tlevl DEBUG\n" +
+		"\t}\n" +
+		"\tlog access a b\n" +
+		"\tlog access\n" +
+		"\tlog\n" +
+		"}\n" +
+		"a.com {\n" +
+		"\tlog {\n" +
+		"\t\thostnames a.com\n" +
+		"\t\tno_hostname\n" +
+		"\t\texclude http.log.access\n" +
+		"\t}\n" +
+		"}\n"
+	diags := analyze(src)
+	for _, want := range [][]string{
+		{"hostnames is only allowed in the log directive of a site"},
+		{"no_hostname has no effect in the log global option"},
+		{`unrecognized log option "levl"`, `did you mean "level"?`},
+		{"log takes at most one logger name"},
+		{`duplicate log global option for logger "access"`},
+		{`duplicate log global option for logger "default"`},
+		{"exclude is only allowed in the log global option"},
+	} {
+		if !hasMsg(diags, want...) {
+			t.Errorf("missing %q in %v", want, diags)
+		}
+	}
+	if len(diags) != 7 {
+		t.Errorf("want 7 diagnostics, got %d: %v", len(diags), diags)
+	}
+}
+
+func TestAnalyzeLog_Valid(t *testing.T) {
+	src := "{\n\tlog {\n\t\tformat json\n\t\texclude http.log.access\n\t}\n\tlog access {\n\t\tinclude http.log.access\n\t}\n}\n" +
+		"a.com {\n\tlog access {\n\t\toutput file /var/log/a.log\n\t\tsampling {\n\t\t\tfirst 10\n\t\t}\n\t}\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("want no diagnostics, got %v", diags)
+	}
+}
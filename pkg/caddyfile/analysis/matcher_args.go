@@ -0,0 +1,88 @@
+package analysis
+
+// matcherForms lists the argument forms of each request matcher, in the
+// order they should be offered. Matchers whose leading argument is an
+// optional name get one form without it and one with it, so the active
+// parameter follows the number of arguments typed.
+// Source: modules/caddyhttp/matchers.go, ip_matchers.go, fileserver/matcher.go
+var matcherForms = map[string][]ArgForm{
+	"client_ip": {{Params: []ArgParam{
+		{Label: "<ranges...>", Doc: "IP addresses or CIDR ranges, or `private_ranges`.", Variadic: true},
+	}}},
+	"expression": {{Params: []ArgParam{
+		{Label: "<cel...>", Doc: "A CEL expression that evaluates to a boolean.", Variadic: true},
+	}}},
+	"file": {{Params: []ArgParam{
+		{Label: "<files...>", Doc: "Files to try, relative to the site root.", Variadic: true},
+	}}},
+	"header": {{Params: []ArgParam{
+		{Label: "<field>", Doc: "Request header field; prefix with `!` to match its absence."},
+		{Label: "[<value>]", Doc: "Value to match; may start or end with `*`."},
+	}}},
+	"header_regexp": {
+		{Params: []ArgParam{
+			{Label: "<field>", Doc: "Request header field."},
+			{Label: "<regexp>", Doc: "Regular expression the field must match."},
+		}},
+		{Params: []ArgParam{
+			{Label: "<name>", Doc: "Name for the `{re.<name>.<group>}` placeholders."},
+			{Label: "<field>", Doc: "Request header field."},
+			{Label: "<regexp>", Doc: "Regular expression the field must match."},
+		}},
+	},
+	"host": {{Params: []ArgParam{
+		{Label: "<hosts...>", Doc: "Host names; the left-most label may be `*`.", Variadic: true},
+	}}},
+	"method": {{Params: []ArgParam{
+		{Label: "<verbs...>", Doc: "Uppercase HTTP methods, e.g. `GET`.", Variadic: true},
+	}}},
+	"not": {{Params: []ArgParam{
+		{Label: "<matcher>", Doc: "Matcher type to negate."},
+		{Label: "<args...>", Doc: "Arguments of the negated matcher.", Variadic: true},
+	}}},
+	"path": {{Params: []ArgParam{
+		{Label: "<paths...>", Doc: "Request paths; `*` matches any characters.", Variadic: true},
+	}}},
+	"path_regexp": {
+		{Params: []ArgParam{
+			{Label: "<regexp>", Doc: "Regular expression the path must match."},
+		}},
+		{Params: []ArgParam{
+			{Label: "<name>", Doc: "Name for the `{re.<name>.<group>}` placeholders."},
+			{Label: "<regexp>", Doc: "Regular expression the path must match."},
+		}},
+	},
+	"protocol": {{Params: []ArgParam{
+		{Label: "http|https|grpc|http/<version>[+]", Doc: "Protocol; a trailing `+` also matches newer HTTP versions."},
+	}}},
+	"query": {{Params: []ArgParam{
+		{Label: "<key>=<val>...", Doc: "Query parameters; `*` matches any value.", Variadic: true},
+	}}},
+	"remote_ip": {{Params: []ArgParam{
+		{Label: "<ranges...>", Doc: "IP addresses or CIDR ranges of the immediate peer, or `private_ranges`.", Variadic: true},
+	}}},
+	"tls": {{Params: []ArgParam{
+		{Label: "[early_data]", Doc: "Only match requests sent as TLS early data, before the handshake completes."},
+	}}},
+	"vars": {{Params: []ArgParam{
+		{Label: "<variable>", Doc: "Variable name or placeholder."},
+		{Label: "<values...>", Doc: "Values to match.", Variadic: true},
+	}}},
+	"vars_regexp": {
+		{Params: []ArgParam{
+			{Label: "<variable>", Doc: "Variable name or placeholder."},
+			{Label: "<regexp>", Doc: "Regular expression the value must match."},
+		}},
+		{Params: []ArgParam{
+			{Label: "<name>", Doc: "Name for the `{re.<name>.<group>}` placeholders."},
+			{Label: "<variable>", Doc: "Variable name or placeholder."},
+			{Label: "<regexp>", Doc: "Regular expression the value must match."},
+		}},
+	},
+}
+
+// MatcherForms returns the argument forms of the request matcher name.
+func MatcherForms(name string) ([]ArgForm, bool) {
+	forms, ok := matcherForms[name]
+	return forms, ok
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleInvalidMatcher is the code of the rule flagging unknown or malformed
+// matchers in named matcher definitions.
+const RuleInvalidMatcher = "invalid-matcher"
+
+// analyzeMatcherDefinition validates the matcher lines of a named matcher
+// definition, the same way for the one-line (`@name <type> <args...>`) and
+// the block form. Caddy looks each type up as an http.matchers module and
+// fails to adapt the config when there is none.
+func (a *analyzer) analyzeMatcherDefinition(d *parser.Directive) []protocol.Diagnostic {
+	return withRule(RuleInvalidMatcher, a.analyzeMatcherSet(MatcherLines(d), false))
+}
+
+// analyzeMatcherSet validates lines, descending into the matchers negated
+// by `not`, at any depth. nested is set below a `not`.
+func (a *analyzer) analyzeMatcherSet(lines []MatcherLine, nested bool) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, line := range lines {
+		switch {
+		case nested && line.Token.Type == parser.STRING:
+			diags = append(diags, warningf(line.Token.Range(),
+				"a quoted expression is only shorthand for an expression matcher right after the matcher name; write expression %s", line.Token.Value))
+		case !a.schema.IsMatcher(line.Type):
+			diags = append(diags, warningf(line.Token.Range(), "unknown matcher type %q%s", line.Type, didYouMean(line.Type, a.schema.Matchers())))
+		case line.Type == "not":
+			inner := line.Nested()
+			if len(inner) == 0 {
+				diags = append(diags, warningf(line.Token.Range(), "not has no matchers to negate, so it never matches; give them on its line or in a block"))
+				continue
+			}
+			diags = append(diags, a.analyzeMatcherSet(inner, true)...)
+		default:
+			diags = append(diags, analyzeVarsMatcher(line.Token, line.Args)...)
+		}
+	}
+	return diags
+}
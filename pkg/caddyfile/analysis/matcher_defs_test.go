@@ -0,0 +1,106 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestMatcherLines_BothForms(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\t@one header X-Api 1\n\t@block {\n\t\theader X-Api 1\n\t}\n\t@expr `{method} == 'POST'`\n\t@not not {\n\t\tpath /admin/*\n\t}\n}\n")
+	ds := f.SiteBlocks[0].Directives
+	one, block := MatcherLines(ds[0]), MatcherLines(ds[1])
+	if len(one) != 1 || len(block) != 1 {
+		t.Fatalf("want one line each, got %+v and %+v", one, block)
+	}
+	for _, line := range []MatcherLine{one[0], block[0]} {
+		if line.Type != "header" || line.Token.Value != "header" || len(line.Args) != 2 || line.Args[0].Token.Value != "X-Api" {
+			t.Errorf("got %+v", line)
+		}
+	}
+	if expr := MatcherLines(ds[2]); len(expr) != 1 || expr[0].Type != "expression" || expr[0].Token.Value != "`{method} == 'POST'`" {
+		t.Errorf("expression shorthand: got %+v", expr)
+	}
+	if not := MatcherLines(ds[3]); len(not) != 1 || not[0].Type != "not" || len(not[0].Body) != 1 {
+		t.Errorf("one-line form with a block: got %+v", not)
+	}
+}
+
+func TestAnalyzeMatcherDefinition_UnknownType(t *testing.T) {
+	diags := analyze("example.com {\n\t@api pth /api/*\n\t@admin {\n\t\tremote_ip 10.0.0.0/8\n\t\tmethd GET\n\t}\n\trespond @api 200\n\trespond @admin 403\n}\n")
+	want := map[protocol.Position]string{
+		{Line: 1, Character: 6}: `unknown matcher type "pth" (did you mean "path"?)`,
+		{Line: 4, Character: 2}: `unknown matcher type "methd" (did you mean "method"?)`,
+	}
+	if len(diags) != len(want) {
+		t.Fatalf("got %v", diags)
+	}
+	for _, d := range diags {
+		if want[d.Range.Start] != d.Message {
+			t.Errorf("%v: got %q, want %q", d.Range.Start, d.Message, want[d.Range.Start])
+		}
+	}
+}
+
+func TestAnalyzeMatcherDefinition_KnownTypes(t *testing.T) {
+	src := "example.com {\n\t@post `{method} == 'POST'`\n\t@early tls early_data\n\t@both {\n\t\tpath /api/*\n\t\tnot header X-Skip\n\t}\n\treverse_proxy app:8080 {\n\t\t@err status 5xx\n\t\thandle_response @err {\n\t\t\trespond 502\n\t\t}\n\t}\n\trespond @post 405\n\trespond @early 425\n\trespond @both 200\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("want no diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyzeMatcherDefinition_PluginType(t *testing.T) {
+	src := "example.com {\n\t@geo maxmind_geolocation {\n\t\tdeny_countries RU\n\t}\n\trespond @geo 403\n}\n"
+	f, _ := parser.Parse(src)
+	if !hasMsg(AnalyzeWith(f, Options{}), `unknown matcher type "maxmind_geolocation"`) {
+		t.Error("undeclared plugin matcher: want a warning")
+	}
+	schema := NewSchema(SchemaLayer{Origin: OriginUser, Matchers: []string{"maxmind_geolocation"}})
+	if diags := AnalyzeWith(f, Options{Schema: schema}); len(diags) != 0 {
+		t.Errorf("declared plugin matcher: want no diagnostics, got %v", diags)
+	}
+}
+
+func TestMatcherLine_Nested(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\t@a not path /admin/*\n\t@b {\n\t\tnot {\n\t\t\theader X-Skip\n\t\t\tnot method GET\n\t\t}\n\t}\n}\n")
+	ds := f.SiteBlocks[0].Directives
+	one := MatcherLines(ds[0])[0].Nested()
+	if len(one) != 1 || one[0].Type != "path" || len(one[0].Args) != 1 {
+		t.Errorf("one-line not: got %+v", one)
+	}
+	block := MatcherLines(ds[1])[0].Nested()
+	if len(block) != 2 || block[0].Type != "header" || block[1].Type != "not" {
+		t.Fatalf("not block: got %+v", block)
+	}
+	if inner := block[1].Nested(); len(inner) != 1 || inner[0].Type != "method" {
+		t.Errorf("not within not: got %+v", inner)
+	}
+	if got := block[0].Nested(); got != nil {
+		t.Errorf("header negates nothing, got %+v", got)
+	}
+}
+
+func TestAnalyzeMatcherDefinition_Nested(t *testing.T) {
+	cases := []struct {
+		src  string
+		want string
+	}{
+		{"@a not pth /admin/*", `unknown matcher type "pth" (did you mean "path"?)`},
+		{"@a not {\n\t\thost example.com\n\t\tnot mthod GET\n\t}", `unknown matcher type "mthod" (did you mean "method"?)`},
+		{"@a {\n\t\tnot\n\t}", "not has no matchers to negate"},
+		{"@a not", "not has no matchers to negate"},
+		{"@a not `{method} == 'GET'`", "write expression `{method} == 'GET'`"},
+		{"@a not vars", "vars matcher requires a variable and at least one value"},
+	}
+	for _, c := range cases {
+		diags := analyze("example.com {\n\t" + c.src + "\n\trespond @a 403\n}\n")
+		if len(diags) != 1 || !hasMsg(diags, c.want) {
+			t.Errorf("%q: want %q, got %v", c.src, c.want, diags)
+		}
+	}
+	ok := "example.com {\n\t@a {\n\t\tpath /api/*\n\t\tnot {\n\t\t\tremote_ip private_ranges\n\t\t\tnot header X-Internal\n\t\t}\n\t\tnot expression `{method} == 'GET'`\n\t}\n\trespond @a 403\n}\n"
+	if diags := analyze(ok); len(diags) != 0 {
+		t.Errorf("want no diagnostics, got %v", diags)
+	}
+}
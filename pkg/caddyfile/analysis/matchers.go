@@ -0,0 +1,294 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Matcher is a named matcher definition (`@name ...`) together with every
+// reference to it.
+//
+// Caddy scopes matcher definitions lexically: a matcher declared at the top
+// of a site block is visible throughout it, while one declared inside a
+// directive body (handle, route, or the response matchers of reverse_proxy)
+// is only visible within that body. References resolve innermost scope first.
+type Matcher struct {
+	Name  string         // including the leading "@"
+	Decl  protocol.Range // the @name token of the first definition
+	Types []string       // matcher types used, in order, e.g. "path", "header"
+	Refs  []protocol.Range
+
+	// Redecls holds the @name tokens of further definitions of the same
+	// name in the same scope, which Caddy rejects.
+	Redecls []protocol.Range
+
+	// Site is the site block or snippet the matcher belongs to. Scope is the
+	// block that declares it: Site itself, or a directive within it.
+	Site  *parser.SiteBlock
+	Scope parser.Node
+}
+
+// MatcherRef is a reference to a named matcher that no enclosing scope
+// defines. Inside snippets this is expected, since the definition may come
+// from the site block that imports the snippet.
+type MatcherRef struct {
+	Name string
+	Rng  protocol.Range
+	Site *parser.SiteBlock
+}
+
+// MatcherTable holds every named matcher in a file. It is built once per
+// parse so that features such as go-to-definition, references, rename and
+// unused-matcher checks share one resolution of names to definitions.
+type MatcherTable struct {
+	matchers   []*Matcher
+	unresolved []MatcherRef
+	scopes     []matcherScope
+}
+
+// matcherScope is one block that declares matchers. Body is the block's
+// braces, used to decide which matchers are visible at a position.
+type matcherScope struct {
+	body     *parser.Braces
+	parent   int // index into scopes, or -1
+	matchers map[string]*Matcher
+}
+
+// CollectMatchers builds the matcher table for f. Matchers only exist in
+// site blocks and snippets; the global options block is ignored.
+func CollectMatchers(f *parser.File) *MatcherTable {
+	t := &MatcherTable{}
+	for _, sb := range f.SiteBlocks {
+		b := matcherBuilder{t: t, site: sb}
+		b.block(sb, &sb.Braces, sb.Directives, -1)
+	}
+	sort.SliceStable(t.matchers, func(i, j int) bool {
+		return posBefore(t.matchers[i].Decl.Start, t.matchers[j].Decl.Start)
+	})
+	return t
+}
+
+// matcherBuilder walks a single site block.
+type matcherBuilder struct {
+	t    *MatcherTable
+	site *parser.SiteBlock
+}
+
+// block declares the matchers defined directly in ds, then resolves the
+// references in ds and recurses into nested bodies. Definitions are
+// collected first because Caddy allows a reference to precede its
+// definition within the same block.
+func (b *matcherBuilder) block(scope parser.Node, body *parser.Braces, ds []*parser.Directive, parent int) {
+	idx := len(b.t.scopes)
+	b.t.scopes = append(b.t.scopes, matcherScope{body: body, parent: parent, matchers: make(map[string]*Matcher)})
+	for _, d := range ds {
+		if !isMatcherName(d.Name.Value) {
+			continue
+		}
+		if m, ok := b.t.scopes[idx].matchers[d.Name.Value]; ok {
+			m.Redecls = append(m.Redecls, d.Name.Range())
+			m.Types = append(m.Types, matcherTypes(d)...)
+			continue
+		}
+		m := &Matcher{
+			Name:  d.Name.Value,
+			Decl:  d.Name.Range(),
+			Types: matcherTypes(d),
+			Site:  b.site,
+			Scope: scope,
+		}
+		b.t.scopes[idx].matchers[m.Name] = m
+		b.t.matchers = append(b.t.matchers, m)
+	}
+	for _, d := range ds {
+		if isMatcherName(d.Name.Value) {
+			continue
+		}
+		for _, arg := range d.Args {
+			if isMatcherName(arg.Token.Value) {
+				b.reference(idx, arg.Token)
+			}
+		}
+		if len(d.Body) > 0 {
+			b.block(d, &d.Braces, d.Body, idx)
+		}
+	}
+}
+
+// reference records tok against the innermost visible definition of its
+// name, or as unresolved.
+func (b *matcherBuilder) reference(scope int, tok parser.Token) {
+	if m := b.t.resolve(scope, tok.Value); m != nil {
+		m.Refs = append(m.Refs, tok.Range())
+		return
+	}
+	b.t.unresolved = append(b.t.unresolved, MatcherRef{Name: tok.Value, Rng: tok.Range(), Site: b.site})
+}
+
+// resolve looks name up from scope outwards.
+func (t *MatcherTable) resolve(scope int, name string) *Matcher {
+	for i := scope; i >= 0; i = t.scopes[i].parent {
+		if m, ok := t.scopes[i].matchers[name]; ok {
+			return m
+		}
+	}
+	return nil
+}
+
+// builtinMatchers are the request matcher types of a standard Caddy build.
+// Source: modules/caddyhttp/matchers.go, ip_matchers.go, fileserver/matcher.go
+var builtinMatchers = []string{
+	"client_ip", "expression", "file", "header", "header_regexp", "host",
+	"method", "not", "path", "path_regexp", "protocol", "query", "remote_ip",
+	"tls", "vars", "vars_regexp",
+}
+
+// MatcherLine is one matcher of a named matcher definition. The one-line
+// form `@name <type> <args...>` has a single line, and the block form one
+// per line of its body, so both forms read the same.
+type MatcherLine struct {
+	// Type is the matcher type, e.g. "path". A quoted token on its own in
+	// the one-line form is shorthand for an expression matcher.
+	Type string
+	// Token names the type; for the expression shorthand it is the quoted
+	// expression.
+	Token parser.Token
+	Args  []*parser.Argument
+	// Body is the block of the line, which belongs to its matcher, as for
+	// `not { ... }`.
+	Body []*parser.Directive
+}
+
+// MatcherLines returns the matchers of the named matcher definition d.
+// Source: caddyconfig/httpcaddyfile/httptype.go (parseMatcherDefinitions)
+func MatcherLines(d *parser.Directive) []MatcherLine {
+	if len(d.Args) > 0 && d.Args[0].Token.Type == parser.STRING {
+		return []MatcherLine{{Type: "expression", Token: d.Args[0].Token, Args: d.Args[:1]}}
+	}
+	return matcherSet(d.Args, d.Body)
+}
+
+// Nested returns the matchers a `not` line negates, given on its line or
+// in its block; other matchers have none. Unlike after the matcher name, a
+// quoted token is not shorthand for an expression here: Caddy takes its
+// text as the matcher type.
+// Source: modules/caddyhttp/matchers.go (ParseCaddyfileNestedMatcherSet)
+func (l MatcherLine) Nested() []MatcherLine {
+	if l.Type != "not" {
+		return nil
+	}
+	return matcherSet(l.Args, l.Body)
+}
+
+// matcherSet returns the matcher lines of a set given as args, the first
+// naming the type and the block belonging to it, or else as the lines of
+// body.
+func matcherSet(args []*parser.Argument, body []*parser.Directive) []MatcherLine {
+	if len(args) > 0 {
+		first := args[0].Token
+		return []MatcherLine{{Type: trimQuotes(first.Value), Token: first, Args: args[1:], Body: body}}
+	}
+	lines := make([]MatcherLine, 0, len(body))
+	for _, sub := range body {
+		lines = append(lines, MatcherLine{Type: sub.Name.Value, Token: sub.Name, Args: sub.Args, Body: sub.Body})
+	}
+	return lines
+}
+
+// matcherTypes returns the matcher types a definition uses, in order.
+func matcherTypes(d *parser.Directive) []string {
+	var types []string
+	for _, line := range MatcherLines(d) {
+		types = append(types, line.Type)
+	}
+	return types
+}
+
+// isMatcherName reports whether s names a matcher, i.e. "@" followed by at
+// least one character.
+func isMatcherName(s string) bool {
+	return len(s) > 1 && strings.HasPrefix(s, "@")
+}
+
+// All returns every matcher in document order of its definition.
+func (t *MatcherTable) All() []*Matcher {
+	return t.matchers
+}
+
+// Unresolved returns the references that match no definition in scope.
+func (t *MatcherTable) Unresolved() []MatcherRef {
+	return t.unresolved
+}
+
+// Unused returns the matchers that are defined but never referenced.
+func (t *MatcherTable) Unused() []*Matcher {
+	var unused []*Matcher
+	for _, m := range t.matchers {
+		if len(m.Refs) == 0 {
+			unused = append(unused, m)
+		}
+	}
+	return unused
+}
+
+// At returns the matcher whose definition or a reference to which lies
+// under pos, along with the range of that token.
+func (t *MatcherTable) At(pos protocol.Position) (*Matcher, protocol.Range, bool) {
+	for _, m := range t.matchers {
+		if rangeTouches(m.Decl, pos) {
+			return m, m.Decl, true
+		}
+		for _, r := range m.Redecls {
+			if rangeTouches(r, pos) {
+				return m, r, true
+			}
+		}
+		for _, r := range m.Refs {
+			if rangeTouches(r, pos) {
+				return m, r, true
+			}
+		}
+	}
+	return nil, protocol.Range{}, false
+}
+
+// Visible returns the matchers that a reference at pos could use, innermost
+// scope first. A name shadowed by an inner definition is listed once.
+func (t *MatcherTable) Visible(pos protocol.Position) []*Matcher {
+	inner := -1
+	for i, s := range t.scopes {
+		if s.body.BodyContains(pos) {
+			inner = i // nested scopes are appended after their parents
+		}
+	}
+	var visible []*Matcher
+	seen := make(map[string]bool)
+	for i := inner; i >= 0; i = t.scopes[i].parent {
+		var names []string
+		for name := range t.scopes[i].matchers {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		for _, name := range names {
+			if !seen[name] {
+				seen[name] = true
+				visible = append(visible, t.scopes[i].matchers[name])
+			}
+		}
+	}
+	return visible
+}
+
+// rangeTouches reports whether pos lies within the single-line range r,
+// including the position just past its end.
+func rangeTouches(r protocol.Range, pos protocol.Position) bool {
+	return pos.Line == r.Start.Line && pos.Character >= r.Start.Character && pos.Character <= r.End.Character
+}
+
+// posBefore reports whether a comes strictly before b.
+func posBefore(a, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"reflect"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func collectMatchers(t *testing.T, src string) *MatcherTable {
+	t.Helper()
+	f, errs := parser.Parse(src)
+	if len(errs) != 0 {
+		t.Fatalf("parse errors: %v", errs)
+	}
+	return CollectMatchers(f)
+}
+
+func TestCollectMatchers_DeclarationsAndRefs(t *testing.T) {
+	src := `example.com {
+	@api path /api/*
+	@static {
+		file
+		not path /admin/*
+	}
+	reverse_proxy @api app:8080
+	file_server @static
+	rewrite @api /v1{uri}
+}
+`
+	table := collectMatchers(t, src)
+	all := table.All()
+	if len(all) != 2 {
+		t.Fatalf("got %d matchers, want 2: %+v", len(all), all)
+	}
+	api, static := all[0], all[1]
+	if api.Name != "@api" || api.Decl.Start != (protocol.Position{Line: 1, Character: 1}) {
+		t.Errorf("api = %s at %v", api.Name, api.Decl)
+	}
+	if !reflect.DeepEqual(api.Types, []string{"path"}) {
+		t.Errorf("api types = %v", api.Types)
+	}
+	if len(api.Refs) != 2 || api.Refs[0].Start.Line != 6 || api.Refs[1].Start.Line != 8 {
+		t.Errorf("api refs = %v", api.Refs)
+	}
+	if !reflect.DeepEqual(static.Types, []string{"file", "not"}) {
+		t.Errorf("static types = %v", static.Types)
+	}
+	if static.Scope != parser.Node(static.Site) {
+		t.Error("static: want site-level scope")
+	}
+	if len(table.Unused()) != 0 || len(table.Unresolved()) != 0 {
+		t.Errorf("unused = %v, unresolved = %v", table.Unused(), table.Unresolved())
+	}
+}
+
+func TestCollectMatchers_ExpressionShorthand(t *testing.T) {
+	table := collectMatchers(t, "example.com {\n\t@post `{method} == 'POST'`\n\trespond @post 405\n}\n")
+	if m := table.All()[0]; !reflect.DeepEqual(m.Types, []string{"expression"}) {
+		t.Errorf("types = %v, want [expression]", m.Types)
+	}
+}
+
+func TestCollectMatchers_Scopes(t *testing.T) {
+	src := `example.com {
+	@a path /a
+	handle /x/* {
+		@a path /x/a
+		@b header X-B
+		respond @a 200
+	}
+	respond @a 201
+	respond @b 202
+	reverse_proxy app:8080 {
+		@err status 5xx
+		handle_response @err {
+			respond "upstream failed"
+		}
+	}
+}
+`
+	table := collectMatchers(t, src)
+	all := table.All()
+	if len(all) != 4 {
+		t.Fatalf("got %d matchers, want 4", len(all))
+	}
+	outer, inner, b, errM := all[0], all[1], all[2], all[3]
+	if len(outer.Refs) != 1 || outer.Refs[0].Start.Line != 7 {
+		t.Errorf("outer @a refs = %v, want only line 7", outer.Refs)
+	}
+	if len(inner.Refs) != 1 || inner.Refs[0].Start.Line != 5 {
+		t.Errorf("inner @a refs = %v, want only line 5", inner.Refs)
+	}
+	if len(b.Refs) != 0 {
+		t.Errorf("@b declared in handle must not resolve outside it, got %v", b.Refs)
+	}
+	if len(errM.Refs) != 1 {
+		t.Errorf("@err refs = %v", errM.Refs)
+	}
+	if d, ok := errM.Scope.(*parser.Directive); !ok || d.Name.Value != "reverse_proxy" {
+		t.Errorf("@err scope = %v, want reverse_proxy", errM.Scope)
+	}
+	unresolved := table.Unresolved()
+	if len(unresolved) != 1 || unresolved[0].Name != "@b" || unresolved[0].Rng.Start.Line != 8 {
+		t.Errorf("unresolved = %+v, want @b on line 8", unresolved)
+	}
+	if unused := table.Unused(); len(unused) != 1 || unused[0] != b {
+		t.Errorf("unused = %v, want @b", unused)
+	}
+}
+
+func TestCollectMatchers_Redeclared(t *testing.T) {
+	table := collectMatchers(t, "example.com {\n\t@m path /a\n\t@m header X\n\trespond @m 200\n}\n")
+	all := table.All()
+	if len(all) != 1 {
+		t.Fatalf("got %d matchers, want 1", len(all))
+	}
+	if len(all[0].Redecls) != 1 || all[0].Redecls[0].Start.Line != 2 {
+		t.Errorf("redecls = %v", all[0].Redecls)
+	}
+}
+
+func TestMatcherTable_AtAndVisible(t *testing.T) {
+	src := `example.com {
+	@a path /a
+	handle {
+		@b path /b
+		respond @a 200
+	}
+}
+other.com {
+	@c path /c
+}
+`
+	table := collectMatchers(t, src)
+	m, rng, ok := table.At(protocol.Position{Line: 4, Character: 11})
+	if !ok || m.Name != "@a" || rng.Start != (protocol.Position{Line: 4, Character: 10}) {
+		t.Errorf("At = %v %v %v, want @a reference", m, rng, ok)
+	}
+	if _, _, ok := table.At(protocol.Position{Line: 4, Character: 1}); ok {
+		t.Error("At on directive name: want no matcher")
+	}
+
+	names := func(ms []*Matcher) []string {
+		var out []string
+		for _, m := range ms {
+			out = append(out, m.Name)
+		}
+		return out
+	}
+	if got := names(table.Visible(protocol.Position{Line: 4, Character: 2})); !reflect.DeepEqual(got, []string{"@b", "@a"}) {
+		t.Errorf("Visible in handle = %v, want [@b @a]", got)
+	}
+	if got := names(table.Visible(protocol.Position{Line: 6, Character: 0})); !reflect.DeepEqual(got, []string{"@a"}) {
+		t.Errorf("Visible in site = %v, want [@a]", got)
+	}
+	if got := names(table.Visible(protocol.Position{Line: 9, Character: 0})); !reflect.DeepEqual(got, []string{"@c"}) {
+		t.Errorf("Visible in other site = %v, want [@c]", got)
+	}
+}
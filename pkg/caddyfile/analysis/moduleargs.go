@@ -0,0 +1,119 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"sort"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Module namespaces whose names the schema keeps in dedicated sets. They
+// can be extended through SchemaLayer.Modules like the others.
+const (
+	storageNamespace   = "caddy.storage"
+	transportNamespace = "http.reverse_proxy.transport"
+	upstreamNamespace  = "http.reverse_proxy.upstreams"
+)
+
+// moduleNamespace describes a Caddy module namespace whose modules are
+// named by a directive argument.
+type moduleNamespace struct {
+	// kind describes a module of the namespace in messages.
+	kind    string
+	builtin []string
+}
+
+// moduleNamespaces are the namespaces of the modules named by the
+// arguments in moduleArguments, other than those with dedicated sets.
+// Source: modules/caddytls (acmeissuer.go, zerosslissuer.go,
+// internalissuer.go, certmanagers.go, connpolicy.go, capools.go)
+var moduleNamespaces = map[string]moduleNamespace{
+	"tls.issuance":             {kind: "issuer", builtin: []string{"acme", "internal", "zerossl"}},
+	"tls.get_certificate":      {kind: "certificate manager", builtin: []string{"http", "tailscale"}},
+	"tls.client_auth.verifier": {kind: "client certificate verifier", builtin: []string{"leaf"}},
+	"tls.ca_pool.source":       {kind: "trust pool provider", builtin: trustPoolProviders},
+}
+
+// moduleArguments maps directives whose first argument names a module to
+// the namespace of that module. Keys are the directive's name preceded by
+// its block: "global" for the global options block, the key of
+// ClientAuthBlockKey inside client_auth and trust pools, and otherwise the
+// name of the enclosing directive.
+var moduleArguments = map[string]string{
+	"global>cert_issuer":         "tls.issuance",
+	"global>storage":             storageNamespace,
+	"tls>issuer":                 "tls.issuance",
+	"tls>get_certificate":        "tls.get_certificate",
+	"reverse_proxy>transport":    transportNamespace,
+	"reverse_proxy>dynamic":      upstreamNamespace,
+	"transport>tls_trust_pool":   "tls.ca_pool.source",
+	"client_auth>trust_pool":     "tls.ca_pool.source",
+	"client_auth>verifier":       "tls.client_auth.verifier",
+	"trust_pool storage>storage": storageNamespace,
+	"trust_pool http>tls>ca":     "tls.ca_pool.source",
+}
+
+// ModuleNamespaces returns the module namespaces SchemaLayer.Modules
+// accepts, sorted.
+func ModuleNamespaces() []string {
+	names := []string{storageNamespace, transportNamespace, upstreamNamespace}
+	for ns := range moduleNamespaces {
+		names = append(names, ns)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// ModuleNamespace returns the namespace of the module that the directive
+// called name takes as its first argument, in the body of the innermost
+// directive of parents, given outermost first. global is set for the
+// global options block, where parents is empty for top-level options.
+func ModuleNamespace(parents []*parser.Directive, name string, global bool) (string, bool) {
+	block := "global"
+	if len(parents) > 0 {
+		if block = ClientAuthBlockKey(parents); block == "" {
+			block = parents[len(parents)-1].Name.Value
+		}
+	} else if !global {
+		return "", false
+	}
+	ns, ok := moduleArguments[block+">"+name]
+	return ns, ok
+}
+
+// analyzeModuleArgument checks that d names a module of namespace ns the
+// schema knows as its first argument. Modules from plugins cannot be told
+// apart from typos, so an unknown one is only a warning.
+func (a *analyzer) analyzeModuleArgument(d *parser.Directive, ns string) []protocol.Diagnostic {
+	kind := moduleKind(ns)
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{errorf(d.Name.Range(), "%s requires a module name: one of %s", d.Name.Value, joinQuoted(a.schema.Modules(ns)))}
+	}
+	module := d.Args[0].Token
+	if isCaddyPlaceholder(module.Value) || a.schema.IsModule(ns, module.Value) {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(module.Range(),
+		"unknown %s %q%s; declare plugin modules in the caddy.plugins.modules setting under %q",
+		kind, module.Value, didYouMean(module.Value, a.schema.Modules(ns)), ns)}
+}
+
+// moduleKind describes a module of namespace ns in messages.
+func moduleKind(ns string) string {
+	switch ns {
+	case storageNamespace:
+		return "storage module"
+	case transportNamespace:
+		return "transport"
+	case upstreamNamespace:
+		return "dynamic upstream module"
+	}
+	return moduleNamespaces[ns].kind
+}
+
+// isBuiltinModule reports whether name is a module of namespace ns shipped
+// with Caddy.
+func isBuiltinModule(ns, name string) bool {
+	return slices.Contains(moduleNamespaces[ns].builtin, name)
+}
@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func TestAnalyzeModuleArguments(t *testing.T) {
+	for _, src := range []string{
+		"example.com {\n\ttls {\n\t\tissuer zerossl API_KEY\n\t}\n}\n",
+		"example.com {\n\ttls {\n\t\tget_certificate tailscale\n\t\tget_certificate http https://certs.local/\n\t}\n}\n",
+		"example.com {\n\ttls {\n\t\tissuer {$ISSUER}\n\t}\n}\n",
+		"{\n\tcert_issuer internal\n}\n",
+		"example.com {\n\ttls {\n\t\tclient_auth {\n\t\t\tverifier leaf {\n\t\t\t\tfile leaf.pem\n\t\t\t}\n\t\t}\n\t}\n}\n",
+		"example.com {\n\ttls {\n\t\tclient_auth {\n\t\t\ttrust_pool storage ca.pem {\n\t\t\t\tstorage file_system /data\n\t\t\t}\n\t\t}\n\t}\n}\n",
+	} {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: want no diagnostics, got %v", src, diags)
+		}
+	}
+
+	for src, want := range map[string][]string{
+		"example.com {\n\ttls {\n\t\tissuer acmee\n\t}\n}\n":                                                                            {`unknown issuer "acmee"`, `did you mean "acme"?`, `caddy.plugins.modules setting under "tls.issuance"`},
+		"example.com {\n\ttls {\n\t\tissuer\n\t}\n}\n":                                                                                  {`issuer requires a module name: one of "acme", "internal", "zerossl"`},
+		"example.com {\n\ttls {\n\t\tget_certificate tailscal\n\t}\n}\n":                                                                {`unknown certificate manager "tailscal"`, `did you mean "tailscale"?`},
+		"{\n\tcert_issuer zerosl\n}\n":                                                                                                  {`unknown issuer "zerosl"`, `did you mean "zerossl"?`},
+		"example.com {\n\ttls {\n\t\tclient_auth {\n\t\t\tverifier lef\n\t\t}\n\t}\n}\n":                                                {`unknown client certificate verifier "lef"`},
+		"example.com {\n\ttls {\n\t\tclient_auth {\n\t\t\ttrust_pool storage ca.pem {\n\t\t\t\tstorage redis\n\t\t\t}\n\t\t}\n\t}\n}\n": {`unknown storage module "redis"`},
+	} {
+		if diags := analyze(src); !hasMsg(diags, want...) {
+			t.Errorf("%q: want %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestAnalyzeModuleArguments_Plugins(t *testing.T) {
+	src := "{\n\tcert_issuer acmedns\n}\n\nexample.com {\n\ttls {\n\t\tissuer acmedns\n\t\tclient_auth {\n\t\t\ttrust_pool vault {\n\t\t\t\tpath pki\n\t\t\t}\n\t\t}\n\t}\n}\n"
+	f, _ := parser.Parse(src)
+	plugins := Plugins{Modules: map[string][]string{"tls.issuance": {"acmedns"}, "tls.ca_pool.source": {"vault"}}}
+	if diags := AnalyzeWith(f, Options{Plugins: plugins}); len(diags) != 0 {
+		t.Errorf("declared plugin modules: want no diagnostics, got %v", diags)
+	}
+}
+
+func TestModuleNamespace(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\ttls {\n\t\tclient_auth {\n\t\t\ttrust_pool http {\n\t\t\t\ttls {\n\t\t\t\t}\n\t\t\t}\n\t\t}\n\t}\n}\n")
+	tls := f.SiteBlocks[0].Directives[0]
+	clientAuth := tls.Body[0]
+	pool := clientAuth.Body[0]
+	poolTLS := pool.Body[0]
+	for _, tc := range []struct {
+		parents []*parser.Directive
+		name    string
+		global  bool
+		want    string
+	}{
+		{nil, "storage", true, "caddy.storage"},
+		{nil, "storage", false, ""},
+		{[]*parser.Directive{tls}, "issuer", false, "tls.issuance"},
+		{[]*parser.Directive{tls, clientAuth}, "trust_pool", false, "tls.ca_pool.source"},
+		{[]*parser.Directive{tls, clientAuth, pool, poolTLS}, "ca", false, "tls.ca_pool.source"},
+		{[]*parser.Directive{tls}, "ca", false, ""},
+	} {
+		if got, _ := ModuleNamespace(tc.parents, tc.name, tc.global); got != tc.want {
+			t.Errorf("%s (%d parents, global %v) = %q, want %q", tc.name, len(tc.parents), tc.global, got, tc.want)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package analysis
+
+import (
+	"slices"
+	"strings"
+)
+
+// ModulesLayer returns the generated schema layer for the plugin modules of
+// a Caddy build, given their IDs as listed by `caddy list-modules`, e.g.
+// "http.handlers.rate_limit". HTTP handlers become site-level directives of
+// the same name, the convention plugins follow, and reverse_proxy
+// transports, dynamic upstream sources, storage modules and request
+// matchers are declared as such, as are the modules of the other
+// namespaces of ModuleNamespaces, such as issuers. Other modules and names
+// the built-in schema already has are left out.
+func ModulesLayer(ids []string) SchemaLayer {
+	l := SchemaLayer{Origin: OriginGenerated}
+	for _, id := range ids {
+		if name, ok := strings.CutPrefix(id, "http.handlers."); ok && !KnownTopLevel[name] {
+			l.Directives = append(l.Directives, name)
+		}
+		if name, ok := strings.CutPrefix(id, "http.reverse_proxy.transport."); ok && !slices.Contains(builtinTransports, name) {
+			l.Transports = append(l.Transports, name)
+		}
+		if name, ok := strings.CutPrefix(id, "http.reverse_proxy.upstreams."); ok && !slices.Contains(dynamicUpstreamNames, name) {
+			l.Upstreams = append(l.Upstreams, name)
+		}
+		if name, ok := strings.CutPrefix(id, "caddy.storage."); ok && !slices.Contains(builtinStorage, name) {
+			l.Storage = append(l.Storage, name)
+		}
+		if name, ok := strings.CutPrefix(id, "http.matchers."); ok && !strings.Contains(name, ".") && !slices.Contains(builtinMatchers, name) {
+			l.Matchers = append(l.Matchers, name)
+		}
+		for ns := range moduleNamespaces {
+			if name, ok := strings.CutPrefix(id, ns+"."); ok && !strings.Contains(name, ".") && !isBuiltinModule(ns, name) {
+				if l.Modules == nil {
+					l.Modules = make(map[string][]string)
+				}
+				l.Modules[ns] = append(l.Modules[ns], name)
+			}
+		}
+	}
+	return l
+}
@@ -0,0 +1,63 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// argumentlessDirectives are the site directives that take no arguments
+// after their matcher. Caddy rejects arguments to those mapped to true and
+// silently ignores them for the others.
+// Source: the directives' parse functions in caddyconfig/httpcaddyfile and
+// modules/caddyhttp
+var argumentlessDirectives = map[string]bool{
+	"abort":        true,
+	"acme_server":  true,
+	"log_skip":     true,
+	"skip_log":     true,
+	"metrics":      true,
+	"tracing":      true,
+	"intercept":    false,
+	"request_body": false,
+	"templates":    false,
+}
+
+// flagGlobalOptions are the global options that are switched on by being
+// present. Caddy ignores any arguments given to them.
+// Source: caddyconfig/httpcaddyfile/options.go (parseOptTrue)
+var flagGlobalOptions = map[string]bool{
+	"debug":              true,
+	"local_certs":        true,
+	"skip_install_trust": true,
+}
+
+// analyzeNoArgs reports the first argument after the matcher of a directive
+// that takes none: an error when Caddy rejects it, a warning when Caddy
+// would drop it without notice.
+func analyzeNoArgs(d *parser.Directive) []protocol.Diagnostic {
+	rejected, ok := argumentlessDirectives[d.Name.Value]
+	if !ok {
+		return nil
+	}
+	args := d.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return nil
+	}
+	if rejected {
+		return []protocol.Diagnostic{errorf(args[0].Range(), "unexpected argument %q: %s takes no arguments besides a matcher", args[0].Token.Value, d.Name.Value)}
+	}
+	return []protocol.Diagnostic{warningf(args[0].Range(), "unexpected argument %q: %s takes no arguments besides a matcher, so Caddy ignores it", args[0].Token.Value, d.Name.Value)}
+}
+
+// analyzeFlagOption warns about arguments to a global option that is
+// switched on by being present.
+func analyzeFlagOption(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(d.Args[0].Range(), "unexpected argument %q: %s takes no arguments, so Caddy ignores it; remove the option to turn it off", d.Args[0].Token.Value, d.Name.Value)}
+}
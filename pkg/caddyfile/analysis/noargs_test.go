@@ -0,0 +1,37 @@
+package analysis
+
+import (
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyze_NoArgs_Valid(t *testing.T) {
+	src := "{\n\tdebug\n\tlocal_certs\n}\nexample.com {\n\tabort @bots\n\tabort /admin/*\n\tlog_skip /health\n\tmetrics /metrics\n\ttracing {\n\t\tspan api\n\t}\n\trequest_body {\n\t\tmax_size 1MB\n\t}\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyze_NoArgs_Rejected(t *testing.T) {
+	for _, line := range []string{"abort extra words", "abort @bots now", "log_skip yes", "metrics on", "tracing span"} {
+		diags := analyze("example.com {\n\t@bots header User-Agent *bot*\n\t" + line + "\n}\n")
+		if len(diags) != 1 || *diags[0].Severity != protocol.DiagnosticSeverityError || !hasMsg(diags, "takes no arguments besides a matcher") {
+			t.Errorf("%s: got %v", line, diags)
+		}
+	}
+}
+
+func TestAnalyze_NoArgs_Ignored(t *testing.T) {
+	diags := analyze("example.com {\n\trequest_body 10MB\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, `"10MB"`, "Caddy ignores it") {
+		t.Errorf("got %v", diags)
+	}
+}
+
+func TestAnalyze_NoArgs_FlagOptions(t *testing.T) {
+	diags := analyze("{\n\tlocal_certs foo\n\tdebug off\n}\n")
+	if len(diags) != 2 || !hasMsg(diags, `"foo"`, "local_certs takes no arguments") || !hasMsg(diags, `"off"`, "remove the option to turn it off") {
+		t.Errorf("got %v", diags)
+	}
+}
@@ -0,0 +1,137 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleNoHandler is the code of the opt-in rule flagging site blocks that
+// never write a response, such as one holding only header and log. Caddy
+// answers every request to such a site with an empty 200.
+const RuleNoHandler = "no-handler"
+
+// passThroughDirectives are site-level directives that configure the site or
+// change the request or response, but leave writing the response to a later
+// handler. handle_errors and intercept only act once another handler has
+// answered or failed.
+var passThroughDirectives = map[string]bool{
+	"basic_auth":     true,
+	"basicauth":      true,
+	"bind":           true,
+	"encode":         true,
+	"fs":             true,
+	"handle_errors":  true,
+	"header":         true,
+	"intercept":      true,
+	"log":            true,
+	"log_append":     true,
+	"log_name":       true,
+	"log_skip":       true,
+	"map":            true,
+	"method":         true,
+	"request_body":   true,
+	"request_header": true,
+	"rewrite":        true,
+	"root":           true,
+	"skip_log":       true,
+	"templates":      true,
+	"tls":            true,
+	"tracing":        true,
+	"try_files":      true,
+	"uri":            true,
+	"vars":           true,
+}
+
+// noHandlerStub is the directive the fix inserts into a site with no handler.
+const noHandlerStub = `respond "OK"`
+
+// AnalyzeNoOpSites flags the site blocks of f, parsed from src, in which no
+// directive writes a response, when the no-handler rule is enabled in rules.
+// Each gets an information diagnostic on its addresses with a fix inserting
+// a respond stub before the closing brace. Only sites made entirely of
+// known pass-through directives are flagged: a plugin directive or an
+// import this file cannot resolve may well be a handler.
+func AnalyzeNoOpSites(src string, f *parser.File, rules map[string]bool) []Fix {
+	if !rules[RuleNoHandler] {
+		return nil
+	}
+	snippets := make(map[string][]*parser.Directive)
+	for _, sb := range f.SiteBlocks {
+		if isSnippet(sb) {
+			snippets[strings.Trim(sb.Addresses[0].Value, "()")] = sb.Directives
+		}
+	}
+	lines := strings.Split(src, "\n")
+	var fixes []Fix
+	for _, sb := range f.SiteBlocks {
+		if isSnippet(sb) || len(sb.Addresses) == 0 || len(sb.Directives) == 0 || sb.RBrace == nil {
+			continue
+		}
+		if !passesThrough(sb.Directives, snippets, make(map[string]bool)) {
+			continue
+		}
+		first, last := sb.Addresses[0], sb.Addresses[len(sb.Addresses)-1]
+		diag := newDiag(protocol.Range{Start: first.Range().Start, End: last.Range().End},
+			protocol.DiagnosticSeverityInformation,
+			"no directive in this site writes a response, so Caddy answers every request with an empty 200; add respond, file_server or reverse_proxy")
+		SetRuleCode(&diag, RuleNoHandler)
+		fixes = append(fixes, Fix{
+			Diagnostic: diag,
+			Title:      "Add " + noHandlerStub,
+			Edit:       stubEdit(sb, lines),
+		})
+	}
+	return fixes
+}
+
+// passesThrough reports whether none of ds writes a response: each is a
+// matcher definition, a pass-through directive, a routing block of such
+// directives, or an import of a snippet of such directives. seen holds the
+// snippets being expanded, so that an import cycle ends.
+func passesThrough(ds []*parser.Directive, snippets map[string][]*parser.Directive, seen map[string]bool) bool {
+	for _, d := range ds {
+		name := d.Name.Value
+		switch {
+		case isMatcherName(name), passThroughDirectives[name]:
+		case containerDirectives[name]:
+			if !passesThrough(d.Body, snippets, seen) {
+				return false
+			}
+		case name == "import":
+			if len(d.Args) == 0 {
+				return false
+			}
+			snippet, ok := snippets[d.Args[0].Token.Value]
+			if !ok || seen[d.Args[0].Token.Value] {
+				return false
+			}
+			seen[d.Args[0].Token.Value] = true
+			if !passesThrough(snippet, snippets, seen) {
+				return false
+			}
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// stubEdit returns the edit inserting noHandlerStub as the last directive of
+// sb, indented like its first directive. A closing brace sharing a line with
+// other tokens is moved to a line of its own.
+func stubEdit(sb *parser.SiteBlock, lines []string) protocol.TextEdit {
+	indent := "\t"
+	if name := sb.Directives[0].Name; blankBetween(lines, protocol.Position{Line: name.Line}, name.Range().Start) {
+		indent = lines[name.Line][:name.Char]
+	}
+	brace := sb.RBrace.Range().Start
+	lineStart := protocol.Position{Line: brace.Line}
+	if blankBetween(lines, lineStart, brace) {
+		return protocol.TextEdit{Range: protocol.Range{Start: lineStart, End: lineStart}, NewText: indent + noHandlerStub + "\n"}
+	}
+	addr := lines[sb.Addresses[0].Line]
+	closing := addr[:len(addr)-len(strings.TrimLeft(addr, " \t"))]
+	return protocol.TextEdit{Range: protocol.Range{Start: brace, End: brace}, NewText: "\n" + indent + noHandlerStub + "\n" + closing}
+}
@@ -0,0 +1,69 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func noOpSiteFixes(src string) []Fix {
+	f, _ := parser.Parse(src)
+	return AnalyzeNoOpSites(src, f, map[string]bool{RuleNoHandler: true})
+}
+
+func TestAnalyzeNoOpSites(t *testing.T) {
+	src := "(common) {\n" +
+		"\tencode gzip\n" +
+		"}\n" +
+		"a.com, b.com {\n" +
+		"\timport common\n" +
+		"\theader X-Frame-Options DENY\n" +
+		"\t@api path /api/*\n" +
+		"\thandle @api {\n" +
+		"\t\theader Cache-Control no-store\n" +
+		"\t}\n" +
+		"\thandle_errors {\n" +
+		"\t\trespond \"oops\"\n" +
+		"\t}\n" +
+		"}\n" +
+		"c.com {\n" +
+		"\tlog\n" +
+		"\thandle /x {\n" +
+		"\t\trespond \"x\"\n" +
+		"\t}\n" +
+		"}\n" +
+		"d.com {\n" +
+		"\tcustom_plugin\n" +
+		"}\n" +
+		"e.com {\n" +
+		"\timport other.caddy\n" +
+		"}\n" +
+		"f.com {\n" +
+		"}\n" +
+		"  g.com { tls internal }\n"
+	fixes := noOpSiteFixes(src)
+	if len(fixes) != 2 {
+		t.Fatalf("got %d fixes, want 2: %+v", len(fixes), fixes)
+	}
+
+	d := fixes[0].Diagnostic
+	wantRange := protocol.Range{Start: protocol.Position{Line: 3, Character: 0}, End: protocol.Position{Line: 3, Character: 12}}
+	if d.Range != wantRange || *d.Severity != protocol.DiagnosticSeverityInformation || d.Code.Value != RuleNoHandler {
+		t.Errorf("diagnostic = %+v", d)
+	}
+	at := protocol.Position{Line: 13, Character: 0}
+	if e := fixes[0].Edit; e.Range != (protocol.Range{Start: at, End: at}) || e.NewText != "\trespond \"OK\"\n" || fixes[0].Title != `Add respond "OK"` {
+		t.Errorf("fix = %+v", fixes[0])
+	}
+
+	at = protocol.Position{Line: 28, Character: 23}
+	if e := fixes[1].Edit; e.Range != (protocol.Range{Start: at, End: at}) || e.NewText != "\n\trespond \"OK\"\n  " {
+		t.Errorf("one-line site fix = %+v", fixes[1].Edit)
+	}
+
+	f, _ := parser.Parse(src)
+	if fixes := AnalyzeNoOpSites(src, f, nil); len(fixes) != 0 {
+		t.Errorf("the rule should be opt-in, got %+v", fixes)
+	}
+}
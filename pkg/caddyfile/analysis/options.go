@@ -0,0 +1,53 @@
+package analysis
+
+// Options tunes analysis for a particular Caddy build.
+type Options struct {
+	Plugins Plugins
+	// Schema replaces the built-in schema when set. Plugins are then
+	// expected to be merged into it already.
+	Schema *Schema
+	// URI identifies the document in related information. Without it,
+	// diagnostics point at no other locations.
+	URI string
+	// Imported reports whether another file imports the document, which
+	// makes import argument placeholders such as {args[0]} valid outside
+	// its snippets. It is only called when the document has such
+	// placeholders; without it, they are flagged.
+	Imported func() bool
+	// CaddyVersion is the Caddy version the document targets, such as
+	// "2.8" or "v2.8.4". When set, directives that version lacks or has
+	// deprecated are reported.
+	CaddyVersion string
+}
+
+// Plugins declares modules provided by Caddy plugins, so that names the
+// built-in schema does not know are accepted instead of flagged.
+type Plugins struct {
+	// Transports are extra reverse_proxy transport modules, e.g. "h2c".
+	// Their bodies are not validated.
+	Transports []string
+	// Upstreams are extra dynamic upstream source modules.
+	Upstreams []string
+	// Modules maps a module namespace of ModuleNamespaces, such as
+	// "tls.issuance", to extra modules in it.
+	Modules map[string][]string
+	// Placeholders are extra runtime placeholders, e.g. "http.auth.jwt.".
+	// Names ending in "." cover every placeholder under them.
+	Placeholders []string
+}
+
+// Layer returns the plugin declarations as a schema layer.
+func (p Plugins) Layer() SchemaLayer {
+	return SchemaLayer{Origin: OriginPlugin, Transports: p.Transports, Upstreams: p.Upstreams, Modules: p.Modules}
+}
+
+// schema returns the schema to analyze against.
+func (o Options) schema() *Schema {
+	switch {
+	case o.Schema != nil:
+		return o.Schema
+	case len(o.Plugins.Transports) == 0 && len(o.Plugins.Upstreams) == 0 && len(o.Plugins.Modules) == 0:
+		return DefaultSchema()
+	}
+	return NewSchema(o.Plugins.Layer())
+}
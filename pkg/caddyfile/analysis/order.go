@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"sort"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// orderPositions are the keywords accepted by the `order` global option.
+var orderPositions = []string{"first", "last", "before", "after"}
+
+// OrderedDirectives returns the directive names positioned by `order`
+// global options in f, sorted. These are usually plugin directives; once
+// ordered they are valid in site blocks like any built-in directive.
+func OrderedDirectives(f *parser.File) []string {
+	var names []string
+	for name := range collectOrdered(f) {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// collectOrdered returns the set of directive names positioned by `order`
+// in the global options block of f.
+func collectOrdered(f *parser.File) map[string]bool {
+	ordered := make(map[string]bool)
+	if f.GlobalBlock == nil {
+		return ordered
+	}
+	for _, d := range f.GlobalBlock.Directives {
+		if d.Name.Value == "order" && len(d.Args) > 0 {
+			ordered[d.Args[0].Token.Value] = true
+		}
+	}
+	return ordered
+}
+
+// analyzeOrder validates `order <directive> first|last` and
+// `order <directive> before|after <directive>`. The directive being placed
+// may be anything, but the one it is positioned against must be known:
+// either built in or itself placed by another `order`.
+func (a *analyzer) analyzeOrder(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "order requires a directive name and a position: first, last, before <directive> or after <directive>")}
+	}
+	name := d.Args[0].Token
+	if len(d.Args) == 1 {
+		return []protocol.Diagnostic{warningf(name.Range(), "order %q requires a position: first, last, before <directive> or after <directive>", name.Value)}
+	}
+	pos := d.Args[1].Token
+	if diags := checkOneOf(pos, "order position", orderPositions); diags != nil {
+		return diags
+	}
+	want := 2
+	if pos.Value == "before" || pos.Value == "after" {
+		want = 3
+		if len(d.Args) < 3 {
+			return []protocol.Diagnostic{warningf(pos.Range(), "order %q %s requires a directive to position against", name.Value, pos.Value)}
+		}
+	}
+	if len(d.Args) > want {
+		return []protocol.Diagnostic{warningf(d.Args[want].Range(), "unexpected argument %q after order %s", d.Args[want].Token.Value, pos.Value)}
+	}
+	if want == 2 {
+		return nil
+	}
+	target := d.Args[2].Token
+	switch {
+	case target.Value == name.Value:
+		return []protocol.Diagnostic{warningf(target.Range(), "%q cannot be ordered relative to itself", name.Value)}
+	case a.schema.IsDirective(target.Value), a.ordered[target.Value], isCaddyPlaceholder(target.Value):
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(target.Range(), "unknown directive %q%s", target.Value, didYouMean(target.Value, a.schema.Directives()))}
+}
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// php_fastcgi expands into a route whose last handler is a reverse_proxy
+// with the fastcgi transport, and passes every subdirective it does not
+// handle itself on to that reverse_proxy. Its body therefore accepts the
+// reverse_proxy subdirectives as well as its own.
+func init() {
+	for name := range knownSubDirectives["reverse_proxy"] {
+		knownSubDirectives["php_fastcgi"][name] = true
+	}
+}
+
+// phpSubDirectiveArgs gives the accepted argument counts of php_fastcgi's own
+// subdirectives. maxArgs < 0 means unlimited.
+// Source: modules/caddyhttp/reverseproxy/fastcgi/caddyfile.go
+var phpSubDirectiveArgs = map[string]struct {
+	minArgs, maxArgs int
+	usage            string
+	duration         bool
+}{
+	"root":                 {minArgs: 1, maxArgs: 1, usage: "<path>"},
+	"split":                {minArgs: 1, maxArgs: -1, usage: "<substrings...>"},
+	"env":                  {minArgs: 1, maxArgs: 2, usage: "<key> [<value>]"},
+	"index":                {minArgs: 1, maxArgs: 1, usage: "<filename>|off"},
+	"try_files":            {minArgs: 1, maxArgs: -1, usage: "<files...>"},
+	"resolve_root_symlink": {},
+	"capture_stderr":       {},
+	"dial_timeout":         {minArgs: 1, maxArgs: 1, usage: "<duration>", duration: true},
+	"read_timeout":         {minArgs: 1, maxArgs: 1, usage: "<duration>", duration: true},
+	"write_timeout":        {minArgs: 1, maxArgs: 1, usage: "<duration>", duration: true},
+}
+
+// analyzePHPFastCGI validates the php_fastcgi-specific subdirectives and
+// runs the reverse_proxy value checks on the options it passes through.
+func (a *analyzer) analyzePHPFastCGI(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	var indexOff, tryFiles *parser.Directive
+	var passThrough []*parser.Directive
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		if name == "transport" {
+			diags = append(diags, warningf(sub.Name.Range(), "php_fastcgi always uses the fastcgi transport; use its root, split, env and timeout subdirectives instead"))
+			continue
+		}
+		spec, ok := phpSubDirectiveArgs[name]
+		if !ok {
+			passThrough = append(passThrough, sub)
+			continue
+		}
+		switch {
+		case len(sub.Args) < spec.minArgs:
+			diags = append(diags, warningf(sub.Name.Range(), "%s expects %s", name, spec.usage))
+		case spec.maxArgs == 0 && len(sub.Args) > 0:
+			diags = append(diags, warningf(sub.Args[0].Range(), "unexpected argument %q: %s takes no arguments", sub.Args[0].Token.Value, name))
+		case spec.maxArgs > 0 && len(sub.Args) > spec.maxArgs:
+			extra := sub.Args[spec.maxArgs]
+			diags = append(diags, warningf(extra.Range(), "unexpected argument %q: %s expects %s", extra.Token.Value, name, spec.usage))
+		case spec.duration:
+			diags = append(diags, checkDuration(sub.Args[0].Token)...)
+		}
+		switch name {
+		case "index":
+			if len(sub.Args) == 1 && sub.Args[0].Token.Value == "off" {
+				indexOff = sub
+			}
+		case "try_files":
+			tryFiles = sub
+		}
+	}
+	if indexOff != nil && tryFiles != nil {
+		diags = append(diags, warningf(tryFiles.Name.Range(), "try_files has no effect with index off: the index rewrite is disabled"))
+	}
+	diags = append(diags, a.analyzeReverseProxy(&parser.Directive{Name: d.Name, Body: passThrough})...)
+	return diags
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import "testing"
+
+// phpWith wraps lines in a php_fastcgi block inside a site block.
+func phpWith(lines string) string {
+	return "example.com {\n\tphp_fastcgi localhost:9000 {\n" + lines + "\t}\n}\n"
+}
+
+func TestAnalyze_PHPFastCGI_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\t\troot /var/www\n\t\tsplit .php .phar\n\t\tenv APP_ENV production\n\t\tenv DEBUG\n",
+		"\t\tindex index.php\n\t\ttry_files {path} {path}/index.php index.php\n",
+		"\t\tindex off\n",
+		"\t\tresolve_root_symlink\n\t\tcapture_stderr\n\t\tdial_timeout 3s\n\t\tread_timeout 1m\n",
+		// reverse_proxy subdirectives pass through
+		"\t\tlb_policy round_robin\n\t\theader_up Host {host}\n\t\tto localhost:9001\n",
+	}
+	for _, lines := range cases {
+		src := phpWith(lines)
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_PHPFastCGI_Problems(t *testing.T) {
+	cases := map[string]string{
+		"\t\tsplit\n":                           "split expects <substrings...>",
+		"\t\tenv\n":                             "env expects <key> [<value>]",
+		"\t\tenv A b c\n":                       `unexpected argument "c": env expects <key> [<value>]`,
+		"\t\tindex a.php b.php\n":               `unexpected argument "b.php"`,
+		"\t\tcapture_stderr yes\n":              `unexpected argument "yes": capture_stderr takes no arguments`,
+		"\t\tread_timeout forever\n":            `invalid duration "forever"`,
+		"\t\tindex off\n\t\ttry_files {path}\n": "try_files has no effect with index off",
+		"\t\ttransport fastcgi\n":               "php_fastcgi always uses the fastcgi transport",
+		"\t\tlb_policy round_robbin\n":          `did you mean "round_robin"`,
+		"\t\tbogus\n":                           `unknown subdirective "bogus" for "php_fastcgi"`,
+	}
+	for lines, want := range cases {
+		src := phpWith(lines)
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", src, want, diags)
+		}
+	}
+}
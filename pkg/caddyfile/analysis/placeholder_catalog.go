@@ -0,0 +1,241 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"regexp"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleUnknownPlaceholder is the code of the rule flagging runtime
+// placeholders that no module fills in.
+const RuleUnknownPlaceholder = "unknown-placeholder"
+
+// placeholderCatalog lists the runtime placeholders Caddy sets, as full
+// names and as the Caddyfile shorthands for them. A name ending in "."
+// covers every placeholder under it, such as a header field or a path
+// segment.
+// Source: replacer.go (globalDefaultReplacements), modules/caddyhttp/
+// replacer.go (addHTTPVarsToReplacer), reverseproxy/reverseproxy.go and
+// caddyconfig/httpcaddyfile/shorthands.go
+var placeholderCatalog = []string{
+	"system.hostname", "system.slash", "system.os", "system.arch", "system.wd",
+	"time.now", "time.now.http", "time.now.common_log", "time.now.year", "time.now.unix", "time.now.unix_ms",
+	"env.", "file.",
+
+	"http.request.method", "http.request.scheme", "http.request.proto",
+	"http.request.host", "http.request.port", "http.request.hostport",
+	"http.request.local", "http.request.local.host", "http.request.local.port",
+	"http.request.remote", "http.request.remote.host", "http.request.remote.port",
+	"http.request.uri", "http.request.uri_escaped",
+	"http.request.uri.path", "http.request.uri.path_escaped", "http.request.uri.path.dir",
+	"http.request.uri.path.file", "http.request.uri.path.file.base", "http.request.uri.path.file.ext",
+	"http.request.uri.query", "http.request.uri.query_escaped", "http.request.uri.prefixed_query",
+	"http.request.duration", "http.request.duration_ms", "http.request.uuid",
+	"http.request.body", "http.request.body_base64",
+	"http.request.orig_method", "http.request.orig_uri", "http.request.orig_uri.path",
+	"http.request.orig_uri.path.dir", "http.request.orig_uri.path.file",
+	"http.request.orig_uri.query", "http.request.orig_uri.prefixed_query",
+	"http.request.header.", "http.request.cookie.", "http.request.host.labels.",
+	"http.request.uri.path.", "http.request.uri.query.", "http.request.orig_uri.path.",
+	"http.request.tls.",
+	"http.response.header.", "http.vars.", "http.regexp.", "http.matchers.",
+	"http.auth.user.", "http.intercept.",
+	"http.shutting_down", "http.time_until_shutdown",
+	"http.error.status_code", "http.error.status_text", "http.error.message", "http.error.trace", "http.error.id",
+	"http.reverse_proxy.upstream.address", "http.reverse_proxy.upstream.hostport",
+	"http.reverse_proxy.upstream.host", "http.reverse_proxy.upstream.port",
+	"http.reverse_proxy.upstream.requests", "http.reverse_proxy.upstream.max_requests",
+	"http.reverse_proxy.upstream.fails", "http.reverse_proxy.upstream.latency",
+	"http.reverse_proxy.upstream.latency_ms", "http.reverse_proxy.upstream.duration",
+	"http.reverse_proxy.upstream.duration_ms",
+	"http.reverse_proxy.header.", "http.reverse_proxy.status_code", "http.reverse_proxy.status_text",
+	"http.reverse_proxy.duration", "http.reverse_proxy.duration_ms", "http.reverse_proxy.retries",
+
+	// Caddyfile shorthands.
+	"host", "hostport", "port", "method", "scheme", "uri", "%uri", "path", "%path",
+	"dir", "file", "query", "%query", "?query", "remote", "remote_host", "remote_port",
+	"uuid", "client_ip", "upstream_hostport",
+	"orig_method", "orig_uri", "orig_path", "orig_dir", "orig_file", "orig_query", "orig_?query",
+	"tls_cipher", "tls_version", "tls_client_fingerprint", "tls_client_issuer", "tls_client_serial",
+	"tls_client_subject", "tls_client_certificate_pem", "tls_client_certificate_der_base64",
+	"header.", "cookie.", "labels.", "path.", "query.", "re.", "vars.", "rp.", "resp.",
+	"file_match.", "err.status_code", "err.status_text", "err.message", "err.trace", "err.id",
+	// Arguments and blocks of imported snippets.
+	"args.", "block", "blocks.",
+}
+
+// placeholderName matches the names worth checking against the catalog.
+// Braces around anything else, such as regexp repetitions like {2,3} or
+// JSON and CSS in response bodies, are not taken for placeholders.
+var placeholderName = regexp.MustCompile(`^[%?]?[A-Za-z_][\w\-.%?]*$`)
+
+// placeholderSet is a catalog of placeholder names, split into exact names
+// and namespace prefixes.
+type placeholderSet struct {
+	exact    map[string]bool
+	prefixes []string
+	roots    map[string]bool // first segments of dotted names
+}
+
+func newPlaceholderSet(names ...[]string) placeholderSet {
+	s := placeholderSet{exact: make(map[string]bool), roots: make(map[string]bool)}
+	for _, list := range names {
+		for _, n := range list {
+			if strings.HasSuffix(n, ".") {
+				s.prefixes = append(s.prefixes, n)
+			} else {
+				s.exact[n] = true
+			}
+			if root, _, ok := strings.Cut(n, "."); ok {
+				s.roots[root] = true
+			}
+		}
+	}
+	return s
+}
+
+func (s placeholderSet) known(name string) bool {
+	// {http.request.remote.host/24,64} masks the address to a prefix.
+	name, _, _ = strings.Cut(name, "/")
+	if s.exact[name] {
+		return true
+	}
+	for _, p := range s.prefixes {
+		if strings.HasPrefix(name, p) && len(name) > len(p) {
+			return true
+		}
+	}
+	return false
+}
+
+// candidates returns the names of the catalog that name might be a typo
+// of: those in the same namespace, or the bare shorthands for a bare name.
+func (s placeholderSet) candidates(name string) []string {
+	root, _, dotted := strings.Cut(name, ".")
+	var out []string
+	for n := range s.exact {
+		r, _, ok := strings.Cut(n, ".")
+		if ok == dotted && (!dotted || r == root) {
+			out = append(out, n)
+		}
+	}
+	sort.Strings(out)
+	return out
+}
+
+// analyzePlaceholderNames warns about the runtime placeholders in sb that
+// are not in set, the catalog extended with the plugin placeholders and
+// the placeholders set by the map directives of the file. Names of a known
+// namespace that are not in it are likely typos; other names are in a
+// namespace no module fills in unless a plugin does.
+func analyzePlaceholderNames(sb *parser.SiteBlock, set placeholderSet) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	var walk func(ds []*parser.Directive, keys map[*parser.Argument]bool)
+	walk = func(ds []*parser.Directive, keys map[*parser.Argument]bool) {
+		for _, d := range ds {
+			keys := keys
+			if strings.HasPrefix(d.Name.Value, "@") {
+				keys = varsMatcherKeys(MatcherLines(d), make(map[*parser.Argument]bool))
+			}
+			for _, arg := range d.Args {
+				if !keys[arg] {
+					diags = append(diags, checkPlaceholderNames(arg.Token, set)...)
+				}
+			}
+			walk(d.Body, keys)
+		}
+	}
+	walk(sb.Directives, nil)
+	return diags
+}
+
+// varsMatcherKeys adds to keys the arguments of the vars and vars_regexp
+// matchers among lines, including negated ones, that name the variable to
+// match, which may be any placeholder, such as one set by a plugin or in
+// another file.
+func varsMatcherKeys(lines []MatcherLine, keys map[*parser.Argument]bool) map[*parser.Argument]bool {
+	for _, line := range lines {
+		switch {
+		case line.Type == "vars" && len(line.Args) > 0:
+			keys[line.Args[0]] = true
+		case line.Type == "vars_regexp" && len(line.Args) > 1:
+			keys[line.Args[len(line.Args)-2]] = true
+		}
+		varsMatcherKeys(line.Nested(), keys)
+	}
+	return keys
+}
+
+// checkPlaceholderNames checks the placeholders in the value of tok.
+func checkPlaceholderNames(tok parser.Token, set placeholderSet) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, p := range placeholdersIn(tok.Value) {
+		name := tok.Value[p.start:p.end]
+		if !placeholderName.MatchString(name) || set.known(name) {
+			continue
+		}
+		rng := protocol.Range{Start: tok.Position(p.start - 1), End: tok.Position(p.end + 1)}
+		root, _, dotted := strings.Cut(name, ".")
+		if s, ok := closestMatch(name, set.candidates(name)); ok {
+			diags = append(diags, warningf(rng, "unknown placeholder {%s} (did you mean {%s}?)", name, s))
+		} else if dotted && !set.roots[root] {
+			diags = append(diags, warningf(rng, "unknown placeholder namespace %q in {%s}", root, name))
+		} else {
+			diags = append(diags, warningf(rng, "unknown placeholder {%s}", name))
+		}
+	}
+	return withRule(RuleUnknownPlaceholder, diags)
+}
+
+// placeholderSpan is the name of a placeholder in a token value, between
+// its braces.
+type placeholderSpan struct{ start, end int }
+
+// placeholdersIn returns the innermost "{...}" spans of s, skipping escaped
+// braces and {$ENV} placeholders, which are resolved when the Caddyfile is
+// loaded.
+func placeholdersIn(s string) []placeholderSpan {
+	var spans []placeholderSpan
+	open := -1
+	for i := 0; i < len(s); i++ {
+		switch {
+		case s[i] == '\\' && i+1 < len(s) && (s[i+1] == '{' || s[i+1] == '}'):
+			i++
+		case s[i] == '{':
+			open = i
+		case s[i] == '}' && open >= 0:
+			if i > open+1 && s[open+1] != '$' {
+				spans = append(spans, placeholderSpan{open + 1, i})
+			}
+			open = -1
+		}
+	}
+	return spans
+}
+
+// mapDestinations returns the names of the placeholders set by the map
+// directives of f, without braces.
+func mapDestinations(f *parser.File) []string {
+	var names []string
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			if d.Name.Value == "map" {
+				args := DirectiveArgs(d)
+				for i := 1; i < len(args); i++ {
+					if v := args[i].Token.Value; isCaddyPlaceholder(v) {
+						names = append(names, v[1:len(v)-1])
+					}
+				}
+			}
+			walk(d.Body)
+		}
+	}
+	for _, sb := range f.SiteBlocks {
+		walk(sb.Directives)
+	}
+	return names
+}
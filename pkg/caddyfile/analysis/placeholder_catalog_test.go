@@ -0,0 +1,77 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyzePlaceholderNames_Known(t *testing.T) {
+	for _, line := range []string{
+		"respond \"{http.request.uri} {http.request.header.X-Forwarded-For}\"",
+		"redir https://{host}{uri} permanent",
+		"header X-Upstream {http.reverse_proxy.upstream.hostport}",
+		"respond {system.hostname}-{time.now.unix}-{env.HOME}",
+		"respond {http.request.remote.host/24,64}",
+		"respond {path.0}/{labels.1}/{query.page}/{re.api.1}/{vars.x}",
+		"respond {file./etc/motd}",
+		"handle_errors {\n\t\trespond \"{err.status_code} {err.message}\"\n\t}",
+		"respond {$HOME}",
+		"respond \\{not a placeholder\\}",
+		"@m path_regexp ^/a{2,3}$\n\trespond @m \"body{color:red}\"",
+		"@dbg vars {debug} on\n\trespond @dbg 1",
+		"@live {\n\t\tnot vars_regexp {jwt.sub} ^svc-\n\t}\n\trespond @live 1",
+		"map {host} {backend} {tier}\n\treverse_proxy {backend}\n\theader X-Tier {tier}",
+	} {
+		src := "example.com {\n\t" + line + "\n}\n"
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: want no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyzePlaceholderNames_Unknown(t *testing.T) {
+	for line, want := range map[string]string{
+		"respond {http.request.urI}":                       "unknown placeholder {http.request.urI} (did you mean {http.request.uri}?)",
+		"respond {http.reverse_proxy.upstream.hostprt}":    "(did you mean {http.reverse_proxy.upstream.hostport}?)",
+		"respond {err.mesage}":                             "unknown placeholder {err.mesage} (did you mean {err.message}?)",
+		"respond {methd}":                                  "unknown placeholder {methd} (did you mean {method}?)",
+		"respond {system.hostnam}":                         "(did you mean {system.hostname}?)",
+		"respond {jwt.sub}":                                `unknown placeholder namespace "jwt" in {jwt.sub}`,
+		"respond {backend}":                                "unknown placeholder {backend}",
+		"redir https://example.net{http.request.urii} 301": "(did you mean {http.request.uri}?)",
+	} {
+		src := "example.com {\n\t" + line + "\n}\n"
+		diags := analyze(src)
+		if len(diags) != 1 || !hasMsg(diags, want) {
+			t.Errorf("%q: want %q, got %v", src, want, diags)
+			continue
+		}
+		if *diags[0].Severity != protocol.DiagnosticSeverityWarning {
+			t.Errorf("%q: want a warning, got severity %v", src, *diags[0].Severity)
+		}
+	}
+}
+
+func TestAnalyzePlaceholderNames_Range(t *testing.T) {
+	diags := analyze("example.com {\n\trespond \"a {http.request.urI} b\"\n}\n")
+	if len(diags) != 1 {
+		t.Fatalf("want one diagnostic, got %v", diags)
+	}
+	want := protocol.Range{Start: protocol.Position{Line: 1, Character: 12}, End: protocol.Position{Line: 1, Character: 30}}
+	if diags[0].Range != want {
+		t.Errorf("range: want %v, got %v", want, diags[0].Range)
+	}
+}
+
+func TestAnalyzePlaceholderNames_Plugins(t *testing.T) {
+	f, _ := parser.Parse("example.com {\n\trespond \"{http.auth.jwt.sub} {geo}\"\n}\n")
+	if diags := AnalyzeWith(f, Options{}); len(diags) != 2 {
+		t.Fatalf("without plugins: want two diagnostics, got %v", diags)
+	}
+	opts := Options{Plugins: Plugins{Placeholders: []string{"http.auth.jwt.", "geo"}}}
+	if diags := AnalyzeWith(f, opts); len(diags) != 0 {
+		t.Errorf("with plugins: want no diagnostics, got %v", diags)
+	}
+}
@@ -1,11 +1,15 @@
 package analysis
 
 import (
-	"caddy-ls/internal/parser"
+	"caddy-ls/pkg/caddyfile/parser"
 
 	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
+// RulePlaceholderSyntax is the code of the rule flagging unbalanced
+// placeholder braces.
+const RulePlaceholderSyntax = "placeholder-syntax"
+
 // checkPlaceholderBalance returns an error message if the curly braces in s
 // are unbalanced, or "" if they are balanced. Escape sequences \{ and \} are
 // treated as literal characters and do not affect bracket depth.
@@ -45,37 +49,22 @@ func placeholderDiag(tok parser.Token) *protocol.Diagnostic {
 	if msg == "" {
 		return nil
 	}
-	sev := protocol.DiagnosticSeverityError
-	return &protocol.Diagnostic{
-		Range:    tok.Range(),
-		Severity: &sev,
-		Source:   strPtr("caddy-ls"),
-		Message:  msg,
-	}
+	diag := ruleError(RulePlaceholderSyntax, tok.Range(), "%s", msg)
+	return &diag
 }
 
-// analyzeFilePlaceholders walks every token value in the AST and reports
-// unbalanced placeholder braces.
-func analyzeFilePlaceholders(f *parser.File) []protocol.Diagnostic {
+// analyzeSitePlaceholders reports unbalanced placeholder braces in the
+// addresses and directives of sb.
+func analyzeSitePlaceholders(sb *parser.SiteBlock) []protocol.Diagnostic {
 	var diags []protocol.Diagnostic
-
-	if f.GlobalBlock != nil {
-		for _, d := range f.GlobalBlock.Directives {
-			diags = append(diags, analyzeDirectivePlaceholders(d)...)
+	for _, addr := range sb.Addresses {
+		if d := placeholderDiag(addr); d != nil {
+			diags = append(diags, *d)
 		}
 	}
-
-	for _, sb := range f.SiteBlocks {
-		for _, addr := range sb.Addresses {
-			if d := placeholderDiag(addr); d != nil {
-				diags = append(diags, *d)
-			}
-		}
-		for _, d := range sb.Directives {
-			diags = append(diags, analyzeDirectivePlaceholders(d)...)
-		}
+	for _, d := range sb.Directives {
+		diags = append(diags, analyzeDirectivePlaceholders(d)...)
 	}
-
 	return diags
 }
 
@@ -0,0 +1,81 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleMisplacedDirective is the code of the rule flagging subdirectives
+// used outside their directive's block.
+const RuleMisplacedDirective = "misplaced-directive"
+
+// placementUsage is the argument syntax of the parents in
+// knownSubDirectiveParent, shown when one of their subdirectives is used
+// outside of them.
+var placementUsage = map[string]string{
+	"reverse_proxy": "[<matcher>] [<upstreams...>]",
+	"tls":           "[internal|<email>] | [<cert_file> <key_file>]",
+	"encode":        "[<matcher>] [<formats...>]",
+}
+
+// placementDiagnostic reports the subdirective d of parent used directly in
+// a site block. The message shows where d belongs, and the nearest parent
+// block in site, if any, is attached as related information.
+func (a *analyzer) placementDiagnostic(d *parser.Directive, parent string) protocol.Diagnostic {
+	diag := warningf(d.Name.Range(), "%q must appear inside a %q block, not at the site level: %s",
+		d.Name.Value, parent, placementExcerpt(d, parent))
+	SetRuleCode(&diag, RuleMisplacedDirective)
+	if a.opts.URI == "" || a.site == nil {
+		return diag
+	}
+	if near := nearestDirective(a.site.Directives, parent, d.Name.Line); near != nil {
+		diag.RelatedInformation = []protocol.DiagnosticRelatedInformation{{
+			Location: protocol.Location{URI: a.opts.URI, Range: near.Name.Range()},
+			Message:  fmt.Sprintf("nearest %q in this site", parent),
+		}}
+	}
+	return diag
+}
+
+// placementExcerpt renders d nested in a block of parent, e.g.
+// "reverse_proxy [<matcher>] [<upstreams...>] { to localhost:8080 }".
+func placementExcerpt(d *parser.Directive, parent string) string {
+	var b strings.Builder
+	b.WriteString(parent)
+	if usage := placementUsage[parent]; usage != "" {
+		b.WriteString(" " + usage)
+	}
+	b.WriteString(" { " + d.Name.Value)
+	for _, arg := range d.Args {
+		b.WriteString(" " + arg.Token.Value)
+	}
+	if len(d.Body) > 0 {
+		b.WriteString(" { ... }")
+	}
+	b.WriteString(" }")
+	return b.String()
+}
+
+// nearestDirective returns the directive named name in directives or their
+// bodies whose line is closest to line, preferring the earlier one on a tie.
+func nearestDirective(directives []*parser.Directive, name string, line uint32) *parser.Directive {
+	var best *parser.Directive
+	var bestDist uint32
+	var walk func([]*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			if d.Name.Value == name {
+				dist := max(d.Name.Line, line) - min(d.Name.Line, line)
+				if best == nil || dist < bestDist {
+					best, bestDist = d, dist
+				}
+			}
+			walk(d.Body)
+		}
+	}
+	walk(directives)
+	return best
+}
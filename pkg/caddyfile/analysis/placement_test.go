@@ -0,0 +1,55 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func TestPlacementDiagnostic(t *testing.T) {
+	src := "a.com {\n" +
+		"\treverse_proxy backend:1\n" +
+		"\thandle /api* {\n" +
+		"\t\treverse_proxy api:8080\n" +
+		"\t}\n" +
+		"\tto localhost:9000\n" +
+		"}\n" +
+		"b.com {\n" +
+		"\treverse_proxy other:1\n" +
+		"}\n"
+	f, _ := parser.Parse(src)
+	diags := AnalyzeWith(f, Options{URI: "file:///Caddyfile"})
+	if len(diags) != 1 {
+		t.Fatalf("want one diagnostic, got %+v", diags)
+	}
+	d := diags[0]
+	want := `"to" must appear inside a "reverse_proxy" block, not at the site level: reverse_proxy [<matcher>] [<upstreams...>] { to localhost:9000 }`
+	if d.Message != want {
+		t.Errorf("message = %q, want %q", d.Message, want)
+	}
+	if len(d.RelatedInformation) != 1 {
+		t.Fatalf("want the nearest reverse_proxy as related information, got %+v", d.RelatedInformation)
+	}
+	rel := d.RelatedInformation[0]
+	if rel.Location.URI != "file:///Caddyfile" || rel.Location.Range.Start.Line != 3 {
+		t.Errorf("related location = %+v, want the nested reverse_proxy on line 3", rel.Location)
+	}
+}
+
+func TestPlacementDiagnostic_NoParentInSite(t *testing.T) {
+	src := "a.com {\n\tgzip\n}\nb.com {\n\tencode zstd\n}\n"
+	f, _ := parser.Parse(src)
+	diags := AnalyzeWith(f, Options{URI: "file:///Caddyfile"})
+	if !hasMsg(diags, `"gzip" must appear inside a "encode" block`, "encode [<matcher>] [<formats...>] { gzip }") {
+		t.Fatalf("want a placement hint with the encode syntax, got %+v", diags)
+	}
+	if len(diags[0].RelatedInformation) != 0 {
+		t.Errorf("an encode in another site must not be related, got %+v", diags[0].RelatedInformation)
+	}
+
+	// Without a URI there is nothing to point at.
+	src = "a.com {\n\treverse_proxy x:1\n\tto y:1\n}\n"
+	f, _ = parser.Parse(src)
+	if diags := Analyze(f); len(diags) != 1 || len(diags[0].RelatedInformation) != 0 {
+		t.Errorf("want one diagnostic without related information, got %+v", diags)
+	}
+}
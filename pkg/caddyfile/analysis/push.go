@@ -0,0 +1,90 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// pushMethods are the methods a push resource line may start with. Any other
+// first token of a line in a push block is the resource itself.
+var pushMethods = []string{"GET", "HEAD"}
+
+// otherMethods are HTTP methods push does not send. A line starting with one
+// of them is read by Caddy as two resources rather than a method and a path.
+var otherMethods = []string{"POST", "PUT", "PATCH", "DELETE", "OPTIONS", "CONNECT", "TRACE"}
+
+// analyzePush validates a push directive: at most one inline resource, block
+// lines of the form `[GET|HEAD] <resource>` and a headers block holding
+// request header operations, as in
+//
+//	push {
+//		headers {
+//			+Link "</a.css>; rel=preload"
+//		}
+//		GET /style.css
+//	}
+func analyzePush(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	args := d.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	for _, extra := range args[min(len(args), 1):] {
+		diags = append(diags, warningf(extra.Range(), "unexpected argument %q: push takes one inline resource; list more in its block, one per line", extra.Token.Value))
+	}
+
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		switch {
+		case name == "import", strings.HasPrefix(name, "@"):
+			continue
+		case name == "headers":
+			diags = append(diags, analyzePushHeaders(sub)...)
+			continue
+		case slices.Contains(pushMethods, name):
+			if len(sub.Args) == 0 {
+				diags = append(diags, warningf(sub.Name.Range(), "%s requires a resource to push", name))
+				continue
+			}
+			diags = append(diags, extraPushResources(sub.Args[1:])...)
+			continue
+		case len(sub.Args) > 0 && slices.Contains(pushMethods, strings.ToUpper(name)):
+			diags = append(diags, warningf(sub.Name.Range(), "push methods are case-sensitive: write %s, or %q is pushed as a resource", strings.ToUpper(name), name))
+			continue
+		case len(sub.Args) > 0 && slices.Contains(otherMethods, strings.ToUpper(name)):
+			diags = append(diags, warningf(sub.Name.Range(), "push only sends %s requests; %q would be pushed as a resource", strings.Join(pushMethods, " or "), name))
+			continue
+		}
+		diags = append(diags, extraPushResources(sub.Args)...)
+	}
+	return diags
+}
+
+// extraPushResources reports the tokens after the resource of a push block
+// line. Caddy reads each of them as another resource line.
+func extraPushResources(extra []*parser.Argument) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, arg := range extra {
+		diags = append(diags, warningf(arg.Range(), "unexpected argument %q: a push line takes one resource", arg.Token.Value))
+	}
+	return diags
+}
+
+// analyzePushHeaders validates the headers block of push, whose lines use the
+// syntax of request_header: the default operator '?' is not available.
+func analyzePushHeaders(sub *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, arg := range sub.Args {
+		diags = append(diags, warningf(arg.Range(), "unexpected argument %q: headers takes its operations in a block", arg.Token.Value))
+	}
+	for _, op := range sub.Body {
+		if op.Name.Value == "import" {
+			continue
+		}
+		diags = append(diags, analyzeHeaderOp(op.Name, op.Args, true)...)
+	}
+	return diags
+}
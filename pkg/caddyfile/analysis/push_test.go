@@ -0,0 +1,60 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_Push_Valid(t *testing.T) {
+	cases := []string{
+		"example.com {\n\tpush\n}\n",
+		"example.com {\n\tpush /style.css\n}\n",
+		"example.com {\n\tpush @html /app.js\n}\n",
+		"example.com {\n\tpush {\n\t\theaders {\n\t\t\t+Link \"</a.css>; rel=preload\"\n\t\t\t-Cookie\n\t\t\tAccept-Encoding gzip\n\t\t}\n\t\tGET /style.css\n\t\tHEAD /probe\n\t\t/app.js\n\t}\n}\n",
+	}
+	for _, src := range cases {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_Push_ExtraResources(t *testing.T) {
+	diags := analyze("example.com {\n\tpush * /a.css /b.css\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, `"/b.css"`, "one inline resource") {
+		t.Errorf("inline: got %v", diags)
+	}
+	diags = analyze("example.com {\n\tpush {\n\t\tGET /a.css /b.css\n\t\t/c.js /d.js\n\t}\n}\n")
+	if len(diags) != 2 || !hasMsg(diags, `"/b.css"`, "one resource") || !hasMsg(diags, `"/d.js"`, "one resource") {
+		t.Errorf("block: got %v", diags)
+	}
+}
+
+func TestAnalyze_Push_Methods(t *testing.T) {
+	diags := analyze("example.com {\n\tpush {\n\t\tGET\n\t\tget /a.css\n\t\tPOST /form\n\t}\n}\n")
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %v", diags)
+	}
+	if !hasMsg(diags, "GET requires a resource") {
+		t.Errorf("missing resource not reported: %v", diags)
+	}
+	if !hasMsg(diags, "case-sensitive", "write GET") {
+		t.Errorf("lowercase method not reported: %v", diags)
+	}
+	if !hasMsg(diags, "only sends GET or HEAD", `"POST"`) {
+		t.Errorf("unsupported method not reported: %v", diags)
+	}
+}
+
+func TestAnalyze_Push_Headers(t *testing.T) {
+	diags := analyze("example.com {\n\tpush {\n\t\theaders X-Foo {\n\t\t\t?X-Default a\n\t\t\t+X-Add\n\t\t}\n\t}\n}\n")
+	if len(diags) != 3 {
+		t.Fatalf("expected 3 diagnostics, got %v", diags)
+	}
+	if !hasMsg(diags, `"X-Foo"`, "in a block") {
+		t.Errorf("headers argument not reported: %v", diags)
+	}
+	if !hasMsg(diags, "'?'", "response headers") {
+		t.Errorf("default operator not reported: %v", diags)
+	}
+	if !hasMsg(diags, "missing value", "X-Add") {
+		t.Errorf("missing value not reported: %v", diags)
+	}
+}
@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Result is the outcome of analyzing a file: its diagnostics and the names
+// it defines and uses. It is meant for programs that embed the analyzer,
+// such as CI tools, and marshals to JSON as is.
+type Result struct {
+	Diagnostics []protocol.Diagnostic `json:"diagnostics"`
+	Symbols     Symbols               `json:"symbols"`
+}
+
+// Symbols is the symbol table of a file, each list in source order.
+type Symbols struct {
+	Sites    []Site          `json:"sites"`
+	Snippets []Snippet       `json:"snippets"`
+	Matchers []MatcherSymbol `json:"matchers"`
+	Imports  []Import        `json:"imports"`
+}
+
+// Site is a site block.
+type Site struct {
+	Addresses []Address      `json:"addresses"`
+	Range     protocol.Range `json:"range"`
+}
+
+// Address is one address of a site block, without the comma separating it
+// from the next.
+type Address struct {
+	Value string         `json:"value"`
+	Range protocol.Range `json:"range"`
+}
+
+// Snippet is a snippet definition, `(name) { ... }`.
+type Snippet struct {
+	Name string `json:"name"`
+	// Range is the range of the (name) token; Block that of the whole
+	// definition.
+	Range protocol.Range `json:"range"`
+	Block protocol.Range `json:"block"`
+	// Args is the number of import arguments the snippet uses through
+	// {args[...]} placeholders.
+	Args int `json:"args"`
+}
+
+// MatcherSymbol is a named matcher definition and its references.
+type MatcherSymbol struct {
+	Name  string           `json:"name"` // including the leading "@"
+	Range protocol.Range   `json:"range"`
+	Types []string         `json:"types"`
+	Refs  []protocol.Range `json:"refs"`
+	// Site is the first address of the site block, or the (name) of the
+	// snippet, that declares the matcher.
+	Site string `json:"site"`
+}
+
+// Import is an import line, at any depth.
+type Import struct {
+	// Pattern is the snippet name or file pattern imported; Range is the
+	// range of its token.
+	Pattern string         `json:"pattern"`
+	Range   protocol.Range `json:"range"`
+	Args    []string       `json:"args"`
+	// File is set for file imports, as opposed to snippet imports.
+	File bool `json:"file"`
+}
+
+// AnalyzeFile is like AnalyzeWith but also returns the symbol table of f.
+func AnalyzeFile(f *parser.File, opts Options) Result {
+	return Result{Diagnostics: AnalyzeWith(f, opts), Symbols: CollectSymbols(f)}
+}
+
+// CollectSymbols returns the symbol table of f.
+func CollectSymbols(f *parser.File) Symbols {
+	s := Symbols{Sites: []Site{}, Snippets: []Snippet{}, Matchers: []MatcherSymbol{}, Imports: []Import{}}
+	args := collectSnippetArgs(f)
+	for _, sb := range f.SiteBlocks {
+		if name, ok := parseSnippetName(firstAddress(sb)); ok && isSnippet(sb) {
+			s.Snippets = append(s.Snippets, Snippet{Name: name, Range: sb.Addresses[0].Range(), Block: sb.Range(), Args: args[name]})
+			continue
+		}
+		site := Site{Addresses: []Address{}, Range: sb.Range()}
+		for _, tok := range sb.Addresses {
+			value := strings.TrimSuffix(tok.Value, ",")
+			if value == "" {
+				continue
+			}
+			rng := tok.Range()
+			rng.End.Character = rng.Start.Character + uint32(len(value))
+			site.Addresses = append(site.Addresses, Address{Value: value, Range: rng})
+		}
+		s.Sites = append(s.Sites, site)
+	}
+	for _, m := range CollectMatchers(f).All() {
+		refs := append([]protocol.Range{}, m.Refs...)
+		s.Matchers = append(s.Matchers, MatcherSymbol{Name: m.Name, Range: m.Decl, Types: m.Types, Refs: refs, Site: firstAddress(m.Site)})
+	}
+	addImport := func(d *parser.Directive) {
+		if d.Name.Value != "import" || len(d.Args) == 0 {
+			return
+		}
+		imp := Import{Pattern: d.Args[0].Token.Value, Range: d.Args[0].Range(), Args: []string{}, File: IsFileImport(d.Args[0].Token.Value)}
+		for _, arg := range d.Args[1:] {
+			imp.Args = append(imp.Args, arg.Token.Value)
+		}
+		s.Imports = append(s.Imports, imp)
+	}
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			addImport(d)
+			walk(d.Body)
+		}
+	}
+	if f.GlobalBlock != nil {
+		walk(f.GlobalBlock.Directives)
+	}
+	for _, d := range f.Imports {
+		addImport(d)
+	}
+	for _, sb := range f.SiteBlocks {
+		walk(sb.Directives)
+	}
+	sort.SliceStable(s.Imports, func(i, j int) bool { return posBefore(s.Imports[i].Range.Start, s.Imports[j].Range.Start) })
+	return s
+}
+
+// firstAddress returns the first address of sb, or "" if it has none.
+func firstAddress(sb *parser.SiteBlock) string {
+	if sb == nil || len(sb.Addresses) == 0 {
+		return ""
+	}
+	return strings.TrimSuffix(sb.Addresses[0].Value, ",")
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"encoding/json"
+	"reflect"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestAnalyzeFile_Symbols(t *testing.T) {
+	src := `import sites/*.caddy
+
+(proxy) {
+	reverse_proxy {args[0]}
+}
+
+example.com, www.example.com {
+	@api path /api/*
+	handle @api {
+		import proxy localhost:8080
+	}
+	respond @api 404
+}
+`
+	f, _ := parser.Parse(src)
+	res := AnalyzeFile(f, Options{})
+	rng := func(line, start, end uint32) protocol.Range {
+		return protocol.Range{Start: protocol.Position{Line: line, Character: start}, End: protocol.Position{Line: line, Character: end}}
+	}
+	want := Symbols{
+		Sites: []Site{{
+			Addresses: []Address{{"example.com", rng(6, 0, 11)}, {"www.example.com", rng(6, 13, 28)}},
+			Range:     f.SiteBlocks[1].Range(),
+		}},
+		Snippets: []Snippet{{Name: "proxy", Range: rng(2, 0, 7), Block: f.SiteBlocks[0].Range(), Args: 1}},
+		Matchers: []MatcherSymbol{{Name: "@api", Range: rng(7, 1, 5), Types: []string{"path"}, Refs: []protocol.Range{rng(8, 8, 12), rng(11, 9, 13)}, Site: "example.com"}},
+		Imports: []Import{
+			{Pattern: "sites/*.caddy", Range: rng(0, 7, 20), Args: []string{}, File: true},
+			{Pattern: "proxy", Range: rng(9, 9, 14), Args: []string{"localhost:8080"}},
+		},
+	}
+	if !reflect.DeepEqual(res.Symbols, want) {
+		t.Errorf("symbols:\n got %+v\nwant %+v", res.Symbols, want)
+	}
+	if !reflect.DeepEqual(res.Diagnostics, AnalyzeWith(f, Options{})) {
+		t.Errorf("diagnostics differ from AnalyzeWith: %v", res.Diagnostics)
+	}
+}
+
+func TestAnalyzeFile_EmptyJSON(t *testing.T) {
+	f, _ := parser.Parse("")
+	data, err := json.Marshal(AnalyzeFile(f, Options{}).Symbols)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := `{"sites":[],"snippets":[],"matchers":[],"imports":[]}`; string(data) != want {
+		t.Errorf("got %s, want %s", data, want)
+	}
+}
@@ -0,0 +1,144 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"sort"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// lbPolicy describes the arguments of one reverse_proxy selection policy.
+type lbPolicy struct {
+	minArgs, maxArgs int    // maxArgs < 0 means unlimited
+	usage            string // argument syntax shown in messages
+	integerArgs      bool   // every argument must be a non-negative integer
+	fallback         bool   // accepts a `fallback <policy>` block
+}
+
+// lbPolicies are the selection policies accepted by `lb_policy`.
+// Source: modules/caddyhttp/reverseproxy/selectionpolicies.go
+var lbPolicies = map[string]lbPolicy{
+	"random":               {},
+	"random_choose":        {minArgs: 1, maxArgs: 1, usage: "<n>", integerArgs: true},
+	"first":                {},
+	"round_robin":          {},
+	"weighted_round_robin": {minArgs: 1, maxArgs: -1, usage: "<weights...>", integerArgs: true},
+	"least_conn":           {},
+	"ip_hash":              {},
+	"client_ip_hash":       {},
+	"uri_hash":             {},
+	"query":                {minArgs: 1, maxArgs: 1, usage: "<key>", fallback: true},
+	"header":               {minArgs: 1, maxArgs: 1, usage: "<field>", fallback: true},
+	"cookie":               {minArgs: 0, maxArgs: 2, usage: "[<name> [<secret>]]", fallback: true},
+}
+
+// lbPolicyNames is the sorted list of lbPolicies keys.
+var lbPolicyNames = func() []string {
+	names := make([]string, 0, len(lbPolicies))
+	for name := range lbPolicies {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// builtinTransports are the transport modules whose bodies the schema
+// describes, derived from the "transport:<name>" keys of
+// knownSubSubDirectives.
+var builtinTransports = func() []string {
+	var names []string
+	for key := range knownSubSubDirectives {
+		if name, ok := strings.CutPrefix(key, "transport:"); ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// analyzeReverseProxy runs value checks on the subdirectives of a
+// reverse_proxy block.
+func (a *analyzer) analyzeReverseProxy(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "lb_policy":
+			diags = append(diags, analyzeLBPolicy(sub)...)
+		case "transport":
+			diags = append(diags, a.analyzeTransport(sub)...)
+			for _, opt := range sub.Body {
+				if opt.Name.Value == "tls_trust_pool" {
+					diags = append(diags, a.analyzeTrustPool(opt)...)
+				}
+			}
+		case "dynamic":
+			diags = append(diags, a.analyzeDynamic(sub)...)
+		}
+	}
+	return diags
+}
+
+// analyzeTransport validates `transport <module> [{ ... }]`. Transports
+// declared by a schema layer other than the built-in one are accepted as-is.
+func (a *analyzer) analyzeTransport(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{newDiag(d.Name.Range(), protocol.DiagnosticSeverityHint,
+			"transport requires a module name, e.g. %s", joinQuoted(builtinTransports))}
+	}
+	name := d.Args[0].Token
+	if isCaddyPlaceholder(name.Value) || a.schema.IsTransport(name.Value) {
+		return nil
+	}
+	return []protocol.Diagnostic{warningf(name.Range(),
+		"unknown transport %q%s; built-in transports are %s (declare plugin transports in the plugin settings)",
+		name.Value, didYouMean(name.Value, a.schema.Transports()), joinQuoted(builtinTransports))}
+}
+
+// analyzeLBPolicy validates `lb_policy <name> [<args...>]`.
+func analyzeLBPolicy(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "lb_policy requires a selection policy: one of %s", joinQuoted(lbPolicyNames))}
+	}
+	name := d.Args[0].Token
+	if diags := checkOneOf(name, "load balancing policy", lbPolicyNames); len(diags) > 0 || isCaddyPlaceholder(name.Value) {
+		return diags
+	}
+	policy := lbPolicies[name.Value]
+
+	var diags []protocol.Diagnostic
+	args := d.Args[1:]
+	if len(args) < policy.minArgs {
+		diags = append(diags, warningf(name.Range(), "lb_policy %s requires %s", name.Value, policy.usage))
+	}
+	if policy.maxArgs >= 0 {
+		for _, extra := range args[min(len(args), policy.maxArgs):] {
+			if policy.maxArgs == 0 {
+				diags = append(diags, warningf(extra.Range(), "unexpected argument %q: lb_policy %s takes no arguments", extra.Token.Value, name.Value))
+			} else {
+				diags = append(diags, warningf(extra.Range(), "unexpected argument %q: lb_policy %s expects %s", extra.Token.Value, name.Value, policy.usage))
+			}
+		}
+	}
+	if policy.integerArgs {
+		for _, arg := range args {
+			if n, err := strconv.Atoi(arg.Token.Value); (err != nil || n < 0) && !isCaddyPlaceholder(arg.Token.Value) {
+				diags = append(diags, warningf(arg.Range(), "lb_policy %s expects a non-negative integer, got %q", name.Value, arg.Token.Value))
+			}
+		}
+	}
+
+	for _, sub := range d.Body {
+		if !policy.fallback || sub.Name.Value != "fallback" {
+			diags = append(diags, warningf(sub.Name.Range(), "unknown option %q for lb_policy %s", sub.Name.Value, name.Value))
+			continue
+		}
+		if len(sub.Args) == 0 {
+			diags = append(diags, warningf(sub.Name.Range(), "fallback requires a selection policy"))
+			continue
+		}
+		diags = append(diags, checkOneOf(sub.Args[0].Token, "load balancing policy", lbPolicyNames)...)
+	}
+	return diags
+}
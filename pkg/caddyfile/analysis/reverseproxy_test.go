@@ -0,0 +1,109 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// proxyWith wraps lines in a reverse_proxy block inside a site block.
+func proxyWith(lines string) string {
+	return "example.com {\n\treverse_proxy localhost:8080 {\n" + lines + "\t}\n}\n"
+}
+
+func TestAnalyze_LBPolicy_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\t\tlb_policy round_robin\n",
+		"\t\tlb_policy random_choose 2\n",
+		"\t\tlb_policy weighted_round_robin 3 1 0\n",
+		"\t\tlb_policy header X-Upstream\n",
+		"\t\tlb_policy cookie\n",
+		"\t\tlb_policy cookie lb s3cret\n",
+		"\t\tlb_policy query user {\n\t\t\tfallback first\n\t\t}\n",
+	}
+	for _, lines := range cases {
+		if diags := analyze(proxyWith(lines)); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", lines, diags)
+		}
+	}
+}
+
+func TestAnalyze_LBPolicy_UnknownPolicy(t *testing.T) {
+	diags := analyze(proxyWith("\t\tlb_policy round_robbin\n"))
+	if len(diags) != 1 || !hasMsg(diags, "unrecognized load balancing policy", `did you mean "round_robin"`) {
+		t.Errorf("expected unknown policy diagnostic, got %v", diags)
+	}
+}
+
+func TestAnalyze_LBPolicy_MissingRequiredArgs(t *testing.T) {
+	for _, policy := range []string{"header", "query", "random_choose", "weighted_round_robin"} {
+		diags := analyze(proxyWith("\t\tlb_policy " + policy + "\n"))
+		if len(diags) != 1 || !hasMsg(diags, "lb_policy "+policy+" requires") {
+			t.Errorf("%s: expected missing argument diagnostic, got %v", policy, diags)
+		}
+	}
+}
+
+func TestAnalyze_LBPolicy_ExtraAndInvalidArgs(t *testing.T) {
+	cases := map[string]string{
+		"\t\tlb_policy first extra\n":                           "takes no arguments",
+		"\t\tlb_policy header A B\n":                            `unexpected argument "B"`,
+		"\t\tlb_policy random_choose two\n":                     "non-negative integer",
+		"\t\tlb_policy weighted_round_robin 1 -2\n":             "non-negative integer",
+		"\t\tlb_policy\n":                                       "requires a selection policy",
+		"\t\tlb_policy first {\n\t\t\tfallback random\n\t\t}\n": "unknown option",
+	}
+	for lines, want := range cases {
+		if diags := analyze(proxyWith(lines)); !hasMsg(diags, want) {
+			t.Errorf("%q: expected diagnostic containing %q, got %v", lines, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_Transport_Builtin_NoWarning(t *testing.T) {
+	for _, src := range []string{
+		proxyWith("\t\ttransport http {\n\t\t\tdial_timeout 5s\n\t\t}\n"),
+		proxyWith("\t\ttransport fastcgi\n"),
+		proxyWith("\t\ttransport {$TRANSPORT}\n"),
+	} {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_Transport_Unknown_Warns(t *testing.T) {
+	diags := analyze(proxyWith("\t\ttransport grpc {\n\t\t\tfoo\n\t\t}\n"))
+	if len(diags) != 1 || !hasMsg(diags, `unknown transport "grpc"`, `"fastcgi", "http"`) {
+		t.Fatalf("expected unknown transport warning, got %v", diags)
+	}
+	if *diags[0].Severity != protocol.DiagnosticSeverityWarning || diags[0].Range.Start.Character != 12 {
+		t.Errorf("want warning on the module name, got %v", diags[0])
+	}
+}
+
+func TestAnalyze_Transport_Typo_Suggests(t *testing.T) {
+	diags := analyze(proxyWith("\t\ttransport htp\n"))
+	if !hasMsg(diags, `did you mean "http"`) {
+		t.Errorf("expected suggestion, got %v", diags)
+	}
+}
+
+func TestAnalyze_Transport_PluginDeclared_NoWarning(t *testing.T) {
+	f, _ := parser.Parse(proxyWith("\t\ttransport h2c {\n\t\t\tanything\n\t\t}\n"))
+	diags := AnalyzeWith(f, Options{Plugins: Plugins{Transports: []string{"h2c"}}})
+	if len(diags) != 0 {
+		t.Errorf("plugin transport: expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyze_Transport_MissingModule_Hint(t *testing.T) {
+	diags := analyze(proxyWith("\t\ttransport {\n\t\t\tdial_timeout 5s\n\t\t}\n"))
+	if len(diags) != 1 || !hasMsg(diags, "transport requires a module name") {
+		t.Fatalf("expected missing module hint, got %v", diags)
+	}
+	if *diags[0].Severity != protocol.DiagnosticSeverityHint {
+		t.Errorf("severity = %v, want hint", *diags[0].Severity)
+	}
+}
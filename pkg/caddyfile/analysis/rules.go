@@ -0,0 +1,337 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RulesDocURL is the page documenting the rules, generated from Rules by
+// RulesMarkdown. Each rule has an anchor named after its code.
+const RulesDocURL = "https://github.com/teemuteemu/caddy-language-server/blob/main/docs/rules.md"
+
+// Codes of the rules reported by package workspace, which checks the files
+// a Caddyfile refers to.
+const (
+	RuleImportPath  = "import-path"
+	RuleMissingFile = "missing-file"
+)
+
+// Rule describes a lint rule.
+type Rule struct {
+	// Code is the diagnostic code, also the key enabling an opt-in rule.
+	Code string `json:"code"`
+	// Severity is the severity of the rule's diagnostics. Rules reporting
+	// both certain and likely problems use a lower one for the latter.
+	Severity protocol.DiagnosticSeverity `json:"severity"`
+	// OptIn is set for the rules that only run when enabled in the lint
+	// setting.
+	OptIn bool `json:"optIn"`
+	// Summary is a one-line description.
+	Summary string `json:"summary"`
+	// Doc explains what the rule flags, why, and how to resolve it.
+	Doc string `json:"doc"`
+	// Fix names the quick fixes offered, if any.
+	Fix string `json:"fix,omitempty"`
+}
+
+// DocURL returns the address of the documentation of r.
+func (r Rule) DocURL() string {
+	return RulesDocURL + "#" + r.Code
+}
+
+// Rules lists the lint rules, sorted by code.
+var Rules = []Rule{
+	{
+		Code:     RuleCaddyVersion,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "directive not available in the targeted Caddy version",
+		Doc: "Flags directives that the Caddy version set with `caddyVersion` does not have yet or no longer has, and, as warnings struck through as deprecated, those it has deprecated. " +
+			"Nothing is reported when no version is set.",
+	},
+	{
+		Code:     RuleConfusableCharacter,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "look-alike or invisible Unicode character",
+		Doc: "Flags characters that look like ASCII but are not, such as typographic quotes, dashes and non-breaking spaces, and invisible ones such as the zero-width space, as commonly pasted from web pages and word processors. " +
+			"Caddy treats them as ordinary characters, so a smart quote does not start a string. " +
+			"Comments are not checked, and inside quoted strings and heredocs only invisible characters are flagged.",
+		Fix: "Replace the character with its ASCII equivalent, or remove an invisible one.",
+	},
+	{
+		Code:     RuleDuplicateDirectives,
+		Severity: protocol.DiagnosticSeverityHint,
+		OptIn:    true,
+		Summary:  "directives repeated across site blocks",
+		Doc: "Flags runs of three or more consecutive directives that appear, written the same way, in two or more site blocks. " +
+			"A snippet imported by each site keeps such shared configuration in one place, so that a later change cannot miss a site. " +
+			"Directives are compared by their tokens, so layout and comments do not matter.",
+		Fix: "Move the directives into a new snippet, defined before the first site using it, and import it in each site.",
+	},
+	{
+		Code:     RuleEmptyBlock,
+		Severity: protocol.DiagnosticSeverityHint,
+		OptIn:    true,
+		Summary:  "directive with an empty block",
+		Doc: "Flags directives whose block holds nothing, such as `tls { }` or `handle { }`. " +
+			"Such blocks are usually left over from editing. The braces are faded as unnecessary. " +
+			"Blocks holding only a comment are not flagged.",
+		Fix: "Remove the empty braces, or the whole directive.",
+	},
+	{
+		Code:     RuleEncodeOrder,
+		Severity: protocol.DiagnosticSeverityInformation,
+		Summary:  "encode after a handler in a route",
+		Doc: "Notes `encode` directives that come after a handler writing the response, or after `templates`, in a `route`. " +
+			"A route runs its directives as written and encode only compresses what the handlers after it write, so the earlier responses go out uncompressed. " +
+			"Nothing is reported when the `order` global option moves encode.",
+	},
+	{
+		Code:     RuleFinalNewline,
+		Severity: protocol.DiagnosticSeverityWarning,
+		OptIn:    true,
+		Summary:  "file does not end with a newline",
+		Doc:      "Flags a file whose last line has no newline, which makes diffs of the next change to it noisier.",
+		Fix:      "Add the final newline.",
+	},
+	{
+		Code:     RuleHandlePathStrip,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "path prefix used after handle_path stripped it",
+		Doc: "Flags a `uri strip_prefix` of the prefix a `handle_path` block has already stripped, and path matchers in the block starting with that prefix. " +
+			"The directives in the block see the path without the prefix, so the strip happens twice and the matchers never match.",
+	},
+	{
+		Code:     RuleImportArgs,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "misused import argument placeholder",
+		Doc: "Flags `{args[n]}` placeholders outside snippets and imported files, where Caddy leaves them as they are, malformed or deprecated ones, and imports of a snippet that pass fewer arguments than it uses. " +
+			"The last two are warnings.",
+	},
+	{
+		Code:     RuleImportPath,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "import of a file that cannot be read",
+		Doc: "Flags `import` lines naming a file that does not exist or is a directory, and invalid glob patterns, which stop Caddy from loading the config. " +
+			"A glob matching no files is a warning, since Caddy accepts it.",
+	},
+	{
+		Code:     RuleInvalidArgument,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "argument or option value Caddy does not accept",
+		Doc: "Flags arguments and subdirective values of directives and global options that Caddy rejects, such as a malformed duration, an unknown module or conflicting settings. " +
+			"Values Caddy ignores, or that a plugin or a later Caddy version may accept, are warnings.",
+	},
+	{
+		Code:     RuleInvalidMatcher,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "unknown or malformed matcher in a named matcher",
+		Doc: "Flags matcher types that no module provides in named matcher definitions, including those negated by `not`, " +
+			"and `not` lines without matchers to negate.",
+	},
+	{
+		Code:     RuleMisplacedDirective,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "subdirective outside its directive's block",
+		Doc: "Flags subdirectives used directly in a site block, such as `header_up` outside `reverse_proxy`, showing where they belong, " +
+			"and options of the `log` directive that only the `log` global option accepts, or the other way round. " +
+			"The former are warnings, since a snippet may be imported inside the right block.",
+	},
+	{
+		Code:     RuleMissingFile,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "file named by tls not found",
+		Doc: "Flags certificate, key and other files named by `tls` that do not exist or are of the wrong kind. " +
+			"Paths are resolved like Caddy does, against the `files.deployRoot` setting when set.",
+	},
+	{
+		Code:     RuleMixedIndentation,
+		Severity: protocol.DiagnosticSeverityWarning,
+		OptIn:    true,
+		Summary:  "indentation mixes tabs and spaces",
+		Doc: "Flags a directive indented with both tabs and spaces, or with tabs where the rest of its block uses spaces, or the other way round. " +
+			"Caddy ignores indentation, but mixed indentation renders differently in every editor. " +
+			"Lines inside multi-line strings and heredocs are not checked.",
+		Fix: "Re-indent the line like the rest of its block.",
+	},
+	{
+		Code:     RuleNoHandler,
+		Severity: protocol.DiagnosticSeverityInformation,
+		OptIn:    true,
+		Summary:  "site block never writes a response",
+		Doc: "Flags site blocks in which no directive writes a response, such as one holding only `header` and `log`. " +
+			"Caddy answers every request to such a site with an empty 200 response, which often surprises users. " +
+			"Sites using plugin directives or imports from other files are not flagged, since those may handle requests.",
+		Fix: "Add a `respond` stub to replace with `respond`, `file_server` or `reverse_proxy`.",
+	},
+	{
+		Code:     RulePlaceholderSyntax,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "unbalanced placeholder braces",
+		Doc:      "Flags addresses and arguments whose placeholder braces do not pair up, such as `{http.request.host`.",
+	},
+	{
+		Code:     RuleShadowedHandler,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "terminal handler that never runs",
+		Doc: "Flags handlers such as `respond`, `file_server` or `reverse_proxy` that can never run because another one without a matcher handles every request first. " +
+			"Caddy sorts a site block or `handle` by directive order, while a `route` keeps the order as written. " +
+			"Directives moved with the `order` global option are not checked.",
+	},
+	{
+		Code:     RuleTLSIssuerConflict,
+		Severity: protocol.DiagnosticSeverityError,
+		Summary:  "tls options that set up conflicting issuers",
+		Doc: "Flags `tls` blocks that combine `issuer` or ACME options such as `dns` with `tls internal`, " +
+			"`issuer` with an email argument, or ACME options with `issuer`, which Caddy refuses.",
+	},
+	{
+		Code:     RuleTLSProtocol,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "TLS protocol range Caddy does not support",
+		Doc:      "Flags `protocols` values that are not TLS versions Caddy supports, including ones older than TLS 1.2, and a maximum lower than the minimum.",
+	},
+	{
+		Code:     RuleTLSSwappedFiles,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "tls certificate and key files look swapped",
+		Doc:      "Flags `tls <cert_file> <key_file>` when the extensions of the files suggest that the key comes first, such as `tls site.key site.crt`.",
+	},
+	{
+		Code:     RuleTrailingWhitespace,
+		Severity: protocol.DiagnosticSeverityWarning,
+		OptIn:    true,
+		Summary:  "line ends with whitespace",
+		Doc:      "Flags spaces and tabs at the end of lines. Lines inside multi-line strings and heredocs are not checked, since their whitespace is part of a value.",
+		Fix:      "Remove the trailing whitespace.",
+	},
+	{
+		Code:     RuleUndefinedEnv,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "environment variable not defined",
+		Doc: "Flags `{$VAR}` placeholders that none of the environment sources configured in the `env` setting defines. " +
+			"Placeholders with a default, such as `{$VAR:default}`, are not flagged, and nothing is flagged without configured sources.",
+	},
+	{
+		Code:     RuleUndefinedSnippet,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "import of a snippet that is not defined",
+		Doc:      "Flags `import` lines naming a snippet that the file does not define. File paths, globs and placeholders are not checked here.",
+	},
+	{
+		Code:     RuleUnknownDirective,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "unknown directive",
+		Doc:      "Flags directives that neither Caddy nor a declared plugin provides, nor the `order` global option names. The block of an unknown directive is not checked.",
+	},
+	{
+		Code:     RuleUnknownOption,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "unknown global option",
+		Doc:      "Flags names in the global options block that are not global options of Caddy or of a declared plugin.",
+	},
+	{
+		Code:     RuleUnknownPlaceholder,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "unknown runtime placeholder",
+		Doc: "Flags placeholders such as `{http.request.hots}` that no module fills in, suggesting the closest known name. " +
+			"Placeholders set by `map` directives of the file and by declared plugins are known.",
+	},
+	{
+		Code:     RuleUnknownSubdirective,
+		Severity: protocol.DiagnosticSeverityWarning,
+		Summary:  "unknown subdirective",
+		Doc: "Flags names in the block of a directive that it does not accept, including those pulled into the block from imported files. " +
+			"Directives whose blocks are free-form are not checked.",
+	},
+}
+
+// RuleByCode returns the rule with the given code.
+func RuleByCode(code string) (Rule, bool) {
+	for _, r := range Rules {
+		if r.Code == code {
+			return r, true
+		}
+	}
+	return Rule{}, false
+}
+
+// SeverityName returns the lower-case name of severity, as in "warning".
+func SeverityName(severity protocol.DiagnosticSeverity) string {
+	switch severity {
+	case protocol.DiagnosticSeverityError:
+		return "error"
+	case protocol.DiagnosticSeverityWarning:
+		return "warning"
+	case protocol.DiagnosticSeverityInformation:
+		return "information"
+	}
+	return "hint"
+}
+
+// RulesMarkdown returns the documentation of Rules as a Markdown page, the
+// one RulesDocURL points to.
+func RulesMarkdown() string {
+	var b strings.Builder
+	b.WriteString("# Lint rules\n\n")
+	b.WriteString("<!-- Generated from pkg/caddyfile/analysis/rules.go; run `go test ./pkg/caddyfile/analysis -run TestRulesMarkdown -update` after changing it. -->\n\n")
+	b.WriteString("Every diagnostic of caddy-ls carries the code of the rule reporting it, except syntax errors and the results of `caddy validate`. ")
+	b.WriteString("Most rules always run. The opt-in rules are off by default; enable them by code in the `lint` setting or in a project configuration file:\n\n")
+	b.WriteString("```json\n{ \"lint\": { \"trailing-whitespace\": true } }\n```\n\n")
+	b.WriteString("`caddy-ls rules` lists them too.\n")
+	for _, r := range Rules {
+		optIn := ""
+		if r.OptIn {
+			optIn = " Opt-in."
+		}
+		fmt.Fprintf(&b, "\n## %s\n\n%s. Severity: %s.%s\n\n%s\n", r.Code, capitalize(r.Summary), SeverityName(r.Severity), optIn, r.Doc)
+		if r.Fix != "" {
+			fmt.Fprintf(&b, "\nQuick fix: %s\n", r.Fix)
+		}
+	}
+	return b.String()
+}
+
+// capitalize returns s with its first byte in upper case.
+func capitalize(s string) string {
+	if s == "" {
+		return s
+	}
+	return strings.ToUpper(s[:1]) + s[1:]
+}
+
+// SetRuleCode marks d as coming from the rule with the given code, linking
+// it to the rule's documentation.
+func SetRuleCode(d *protocol.Diagnostic, code string) {
+	d.Code = &protocol.IntegerOrString{Value: code}
+	if r, ok := RuleByCode(code); ok {
+		d.CodeDescription = &protocol.CodeDescription{HRef: protocol.URI(r.DocURL())}
+	}
+}
+
+// ruleDiag builds a warning for the rule with the given code.
+func ruleDiag(code string, rng protocol.Range, format string, args ...any) protocol.Diagnostic {
+	d := warningf(rng, format, args...)
+	SetRuleCode(&d, code)
+	return d
+}
+
+// ruleError builds an error for the rule with the given code.
+func ruleError(code string, rng protocol.Range, format string, args ...any) protocol.Diagnostic {
+	d := errorf(rng, format, args...)
+	SetRuleCode(&d, code)
+	return d
+}
+
+// withRule marks the diagnostics in diags that have no code yet as coming
+// from the rule with the given code, and returns diags. Checks wrap the
+// results of more specific ones with it, which keep their own codes.
+func withRule(code string, diags []protocol.Diagnostic) []protocol.Diagnostic {
+	for i := range diags {
+		if diags[i].Code == nil {
+			SetRuleCode(&diags[i], code)
+		}
+	}
+	return diags
+}
@@ -0,0 +1,132 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// TestRulesMarkdown checks that docs/rules.md, which the diagnostics of the
+// rules link to, is up to date. Run with -update to regenerate it.
+func TestRulesMarkdown(t *testing.T) {
+	const path = "../../../docs/rules.md"
+	got := RulesMarkdown()
+	if *update {
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != string(want) {
+		t.Errorf("%s is out of date; run go test ./pkg/caddyfile/analysis -run TestRulesMarkdown -update", path)
+	}
+}
+
+func TestRules(t *testing.T) {
+	codes := []string{RuleDuplicateDirectives, RuleEmptyBlock, RuleNoHandler,
+		RuleCaddyVersion, RuleConfusableCharacter, RuleEncodeOrder, RuleHandlePathStrip, RuleImportArgs, RuleImportPath,
+		RuleInvalidArgument, RuleInvalidMatcher, RuleMisplacedDirective, RuleMissingFile, RulePlaceholderSyntax,
+		RuleShadowedHandler, RuleTLSIssuerConflict, RuleTLSProtocol, RuleTLSSwappedFiles, RuleUndefinedEnv,
+		RuleUndefinedSnippet, RuleUnknownDirective, RuleUnknownOption, RuleUnknownPlaceholder, RuleUnknownSubdirective}
+	codes = append(codes, WhitespaceRules...)
+	var listed []string
+	for _, r := range Rules {
+		listed = append(listed, r.Code)
+		if !strings.Contains(RulesMarkdown(), "\n## "+r.Code+"\n") {
+			t.Errorf("%s has no section for its anchor", r.Code)
+		}
+	}
+	slices.Sort(codes)
+	if !slices.Equal(listed, codes) {
+		t.Errorf("Rules lists %v, want every rule code sorted: %v", listed, codes)
+	}
+
+	fixes := append(emptyBlockFixes("a {\n\ttls { }\n}\n"), noOpSiteFixes("a {\n\tlog\n}\n")...)
+	fixes = append(fixes, whitespaceFixes("a {\n}  ", RuleTrailingWhitespace)...)
+	if len(fixes) != 4 {
+		t.Fatalf("got %d fixes: %+v", len(fixes), fixes)
+	}
+	for _, f := range fixes {
+		code := f.Diagnostic.Code.Value.(string)
+		rule, _ := RuleByCode(code)
+		if d := f.Diagnostic.CodeDescription; d == nil || string(d.HRef) != RulesDocURL+"#"+code {
+			t.Errorf("%s: code description = %+v", code, d)
+		}
+		if *f.Diagnostic.Severity != rule.Severity {
+			t.Errorf("%s: severity %d, documented as %d", code, *f.Diagnostic.Severity, rule.Severity)
+		}
+	}
+}
+
+// TestRuleCodes checks that the built-in checks mark their diagnostics with
+// the code of a rule documenting a severity no lower than theirs.
+func TestRuleCodes(t *testing.T) {
+	check := func(name string, d protocol.Diagnostic) {
+		t.Helper()
+		if d.Code == nil {
+			t.Errorf("%s: %q has no code", name, d.Message)
+			return
+		}
+		rule, ok := RuleByCode(d.Code.Value.(string))
+		if !ok || d.CodeDescription == nil {
+			t.Errorf("%s: %q has the unregistered code %v", name, d.Message, d.Code.Value)
+		} else if *d.Severity < rule.Severity {
+			t.Errorf("%s: %q is more severe than %s documents", name, d.Message, rule.Code)
+		}
+	}
+
+	files, err := filepath.Glob("../testdata/corpus/*.caddyfile")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no corpus files: %v", err)
+	}
+	for _, file := range files {
+		src, err := os.ReadFile(file)
+		if err != nil {
+			t.Fatal(err)
+		}
+		f, _ := parser.Parse(string(src))
+		for _, d := range AnalyzeWith(f, Options{CaddyVersion: "2.6"}) {
+			check(filepath.Base(file), d)
+		}
+		for _, fix := range AnalyzeConfusables(string(src)) {
+			check(filepath.Base(file), fix.Diagnostic)
+		}
+	}
+
+	cases := []struct{ src, code string }{
+		{"{\n\tsevers\n}\n", RuleUnknownOption},
+		{"a.com {\n\trespnd hi\n}\n", RuleUnknownDirective},
+		{"a.com {\n\treverse_proxy app {\n\t\tlb_polcy first\n\t}\n}\n", RuleUnknownSubdirective},
+		{"a.com {\n\theader_up Host x\n}\n", RuleMisplacedDirective},
+		{"a.com {\n\timport missing\n}\n", RuleUndefinedSnippet},
+		{"a.com {\n\trespond {args[0]}\n}\n", RuleImportArgs},
+		{"a.com {\n\trespond {http.request.hots}\n}\n", RuleUnknownPlaceholder},
+		{"a.com {\n\trespond {http.request.host\n}\n", RulePlaceholderSyntax},
+		{"a.com {\n\t@m {\n\t\tpaht /x\n\t}\n}\n", RuleInvalidMatcher},
+		{"a.com {\n\tencode {\n\t\tgzip 12\n\t}\n}\n", RuleInvalidArgument},
+		{"a.com {\n\thandle_path /api/* {\n\t\turi strip_prefix /api\n\t}\n}\n", RuleHandlePathStrip},
+		{"a.com {\n\troute {\n\t\trespond hi\n\t\tencode gzip\n\t}\n}\n", RuleEncodeOrder},
+		{"a.com {\n\tfile_server\n\trespond hi\n}\n", RuleShadowedHandler},
+		{"a.com {\n\ttls internal {\n\t\tdns cloudflare token\n\t}\n}\n", RuleTLSIssuerConflict},
+		{"a.com {\n\ttls {\n\t\tprotocols tls1.1\n\t}\n}\n", RuleTLSProtocol},
+		{"a.com {\n\ttls site.key site.crt\n}\n", RuleTLSSwappedFiles},
+	}
+	for _, c := range cases {
+		f, _ := parser.Parse(c.src)
+		diags := Analyze(f)
+		if len(diags) != 1 || diags[0].Code == nil || diags[0].Code.Value != c.code {
+			t.Errorf("%q: want one %s diagnostic, got %+v", c.src, c.code, diags)
+			continue
+		}
+		check(c.code, diags[0])
+	}
+}
@@ -0,0 +1,512 @@
+package analysis
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// Origin names the layer of the schema a name comes from.
+type Origin string
+
+const (
+	// OriginBuiltin is the schema compiled into the server.
+	OriginBuiltin Origin = "builtin"
+	// OriginGenerated is a schema generated from a Caddy build.
+	OriginGenerated Origin = "generated"
+	// OriginPlugin is the modules declared in the plugin settings.
+	OriginPlugin Origin = "plugin"
+	// OriginUser is the user's schema overrides.
+	OriginUser Origin = "user"
+)
+
+// Precedence lists the schema layers from lowest to highest precedence.
+// Every layer may add names; a name declared by several layers is
+// attributed to the highest one, and a name disabled by a layer is removed
+// unless a higher layer declares it again.
+var Precedence = []Origin{OriginBuiltin, OriginGenerated, OriginPlugin, OriginUser}
+
+// SchemaLayer is one source of directive names merged into a Schema.
+type SchemaLayer struct {
+	Origin Origin
+	// Directives are names valid at the site-block level.
+	Directives []string
+	// GlobalOptions are names valid in the global options block.
+	GlobalOptions []string
+	// SubDirectives maps a directive or global option to names valid in
+	// its body. Declaring them for a directive whose body was not validated
+	// before makes it validated.
+	SubDirectives map[string][]string
+	// Transports and Upstreams are extra reverse_proxy transport and
+	// dynamic upstream modules. Their bodies are not validated.
+	Transports []string
+	Upstreams  []string
+	// Storage are extra storage modules for the storage global option.
+	// Their bodies are not validated.
+	Storage []string
+	// Matchers are extra request matcher types for named matchers.
+	// Their arguments are not validated.
+	Matchers []string
+	// Modules maps a module namespace, such as "tls.issuance", to extra
+	// modules in it, for the directives naming one as their argument, such
+	// as `issuer`. Their bodies are not validated.
+	Modules map[string][]string
+	// Disable removes site-level directives declared by lower layers, for
+	// Caddy builds that lack them.
+	Disable []string
+}
+
+// SchemaEntry records where a name in the merged schema comes from.
+type SchemaEntry struct {
+	Origin Origin `json:"origin"`
+	// Shadows lists the lower layers that declared the name as well.
+	Shadows []Origin `json:"shadows,omitempty"`
+	// Once is set for subdirectives a block may hold only once.
+	Once bool `json:"once,omitempty"`
+	// Template is the snippet, in LSP snippet syntax, of the arguments
+	// completion inserts after a subdirective name.
+	Template string `json:"template,omitempty"`
+}
+
+// SchemaProblem is a mistake in a schema layer, such as a name that cannot
+// be a directive. The offending declaration is ignored.
+type SchemaProblem struct {
+	Origin  Origin `json:"origin"`
+	Message string `json:"message"`
+}
+
+// entrySet maps names to their schema entries.
+type entrySet map[string]*SchemaEntry
+
+// Schema is the merged set of names the analyzer accepts. Build one with
+// NewSchema; the zero value is not usable.
+type Schema struct {
+	directives    entrySet
+	globalOptions entrySet
+	subDirectives map[string]entrySet // a nil set marks a freeform body
+	transports    entrySet
+	upstreams     entrySet
+	storage       entrySet
+	matchers      entrySet
+	modules       map[string]entrySet
+	disabled      entrySet
+	problems      []SchemaProblem
+
+	// subNames mirrors subDirectives as plain sets for validation.
+	subNames map[string]map[string]bool
+}
+
+// DefaultSchema returns the built-in schema alone. It is built once and
+// must not be modified.
+var DefaultSchema = sync.OnceValue(func() *Schema { return NewSchema() })
+
+// NewSchema merges the built-in schema with layers, applied in Precedence
+// order whatever order they are passed in. Layers with the same origin are
+// applied in the order given.
+func NewSchema(layers ...SchemaLayer) *Schema {
+	s := &Schema{
+		directives:    make(entrySet),
+		globalOptions: make(entrySet),
+		subDirectives: make(map[string]entrySet),
+		transports:    make(entrySet),
+		upstreams:     make(entrySet),
+		storage:       make(entrySet),
+		matchers:      make(entrySet),
+		modules:       make(map[string]entrySet, len(moduleNamespaces)),
+		disabled:      make(entrySet),
+	}
+	for name := range KnownTopLevel {
+		s.directives[name] = &SchemaEntry{Origin: OriginBuiltin}
+	}
+	for name := range KnownGlobalOptions {
+		s.globalOptions[name] = &SchemaEntry{Origin: OriginBuiltin}
+	}
+	for parent, subs := range knownSubDirectives {
+		if subs == nil {
+			s.subDirectives[parent] = nil
+			continue
+		}
+		set := make(entrySet, len(subs))
+		for name := range subs {
+			set[name] = &SchemaEntry{
+				Origin:   OriginBuiltin,
+				Once:     onceSubDirectives[parent][name],
+				Template: subDirectiveTemplates[parent][name],
+			}
+		}
+		s.subDirectives[parent] = set
+	}
+	for _, name := range builtinTransports {
+		s.transports[name] = &SchemaEntry{Origin: OriginBuiltin}
+	}
+	for _, name := range dynamicUpstreamNames {
+		s.upstreams[name] = &SchemaEntry{Origin: OriginBuiltin}
+	}
+	for _, name := range builtinStorage {
+		s.storage[name] = &SchemaEntry{Origin: OriginBuiltin}
+	}
+	for _, name := range builtinMatchers {
+		s.matchers[name] = &SchemaEntry{Origin: OriginBuiltin}
+	}
+	for ns, m := range moduleNamespaces {
+		set := make(entrySet, len(m.builtin))
+		for _, name := range m.builtin {
+			set[name] = &SchemaEntry{Origin: OriginBuiltin}
+		}
+		s.modules[ns] = set
+	}
+
+	layers = slices.Clone(layers)
+	sort.SliceStable(layers, func(i, j int) bool {
+		return originRank(layers[i].Origin) < originRank(layers[j].Origin)
+	})
+	for _, l := range layers {
+		s.apply(l)
+	}
+
+	s.subNames = make(map[string]map[string]bool, len(s.subDirectives))
+	for parent, set := range s.subDirectives {
+		if set == nil {
+			s.subNames[parent] = nil
+			continue
+		}
+		names := make(map[string]bool, len(set))
+		for name := range set {
+			names[name] = true
+		}
+		s.subNames[parent] = names
+	}
+	return s
+}
+
+// originRank returns the position of o in Precedence. Unknown origins rank
+// highest so that they are never silently overridden.
+func originRank(o Origin) int {
+	if i := slices.Index(Precedence, o); i >= 0 {
+		return i
+	}
+	return len(Precedence)
+}
+
+// apply merges one layer into s.
+func (s *Schema) apply(l SchemaLayer) {
+	if originRank(l.Origin) == len(Precedence) {
+		s.problemf(l.Origin, "unknown schema origin %q; want one of %s", l.Origin, joinQuoted(originNames()))
+	}
+	for _, name := range l.Disable {
+		if !s.validName(l.Origin, "directive", name) {
+			continue
+		}
+		if _, ok := s.directives[name]; !ok {
+			s.problemf(l.Origin, "cannot disable unknown directive %q", name)
+			continue
+		}
+		delete(s.directives, name)
+		s.disabled[name] = &SchemaEntry{Origin: l.Origin}
+	}
+	for _, name := range l.Directives {
+		if s.declare(s.directives, l.Origin, "directive", name) {
+			delete(s.disabled, name)
+		}
+	}
+	for _, name := range l.GlobalOptions {
+		s.declare(s.globalOptions, l.Origin, "global option", name)
+	}
+	parents := make([]string, 0, len(l.SubDirectives))
+	for parent := range l.SubDirectives {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+	for _, parent := range parents {
+		set, known := s.subDirectives[parent]
+		switch {
+		case known && set == nil:
+			s.problemf(l.Origin, "%q has a freeform body whose contents are not validated; its subdirectives are ignored", parent)
+			continue
+		case !known && s.directives[parent] == nil && s.globalOptions[parent] == nil:
+			s.problemf(l.Origin, "subdirectives declared for unknown directive %q", parent)
+			continue
+		case !known:
+			set = make(entrySet)
+			s.subDirectives[parent] = set
+		}
+		for _, name := range l.SubDirectives[parent] {
+			s.declare(set, l.Origin, "subdirective of "+strconv.Quote(parent), name)
+		}
+	}
+	for _, name := range l.Transports {
+		s.declare(s.transports, l.Origin, "transport", name)
+	}
+	for _, name := range l.Upstreams {
+		s.declare(s.upstreams, l.Origin, "dynamic upstream module", name)
+	}
+	for _, name := range l.Storage {
+		s.declare(s.storage, l.Origin, "storage module", name)
+	}
+	for _, name := range l.Matchers {
+		s.declare(s.matchers, l.Origin, "matcher", name)
+	}
+	namespaces := make([]string, 0, len(l.Modules))
+	for ns := range l.Modules {
+		namespaces = append(namespaces, ns)
+	}
+	sort.Strings(namespaces)
+	for _, ns := range namespaces {
+		set := s.moduleSet(ns)
+		if set == nil {
+			s.problemf(l.Origin, "unknown module namespace %q; want one of %s", ns, joinQuoted(ModuleNamespaces()))
+			continue
+		}
+		for _, name := range l.Modules[ns] {
+			s.declare(set, l.Origin, moduleKind(ns), name)
+		}
+	}
+}
+
+// moduleSet returns the set of modules of namespace ns, or nil for
+// namespaces the schema does not track.
+func (s *Schema) moduleSet(ns string) entrySet {
+	switch ns {
+	case storageNamespace:
+		return s.storage
+	case transportNamespace:
+		return s.transports
+	case upstreamNamespace:
+		return s.upstreams
+	}
+	return s.modules[ns]
+}
+
+// declare adds name to set on behalf of origin and reports whether it was
+// added. A name already declared by a lower layer is taken over and the
+// redundancy reported.
+func (s *Schema) declare(set entrySet, origin Origin, kind, name string) bool {
+	if !s.validName(origin, kind, name) {
+		return false
+	}
+	e, ok := set[name]
+	if !ok {
+		set[name] = &SchemaEntry{Origin: origin}
+		return true
+	}
+	if e.Origin == origin {
+		return true
+	}
+	s.problemf(origin, "%s %q is already declared by the %s schema", kind, name, e.Origin)
+	set[name] = &SchemaEntry{Origin: origin, Shadows: append(slices.Clone(e.Shadows), e.Origin), Once: e.Once, Template: e.Template}
+	return true
+}
+
+// validName reports whether name can be declared, recording a problem if
+// not. Matcher names, placeholders and snippet names are never directives.
+func (s *Schema) validName(origin Origin, kind, name string) bool {
+	switch {
+	case name == "":
+		s.problemf(origin, "empty %s name", kind)
+	case strings.ContainsAny(name, " \t\r\n{}"):
+		s.problemf(origin, "invalid %s name %q: names cannot contain whitespace or braces", kind, name)
+	case strings.HasPrefix(name, "@"), strings.HasPrefix(name, "("):
+		s.problemf(origin, "invalid %s name %q: names cannot start with %q", kind, name, name[:1])
+	default:
+		return true
+	}
+	return false
+}
+
+func (s *Schema) problemf(origin Origin, format string, args ...any) {
+	s.problems = append(s.problems, SchemaProblem{Origin: origin, Message: fmt.Sprintf(format, args...)})
+}
+
+// originNames returns Precedence as strings.
+func originNames() []string {
+	names := make([]string, len(Precedence))
+	for i, o := range Precedence {
+		names[i] = string(o)
+	}
+	return names
+}
+
+// IsDirective reports whether name is valid at the site-block level.
+func (s *Schema) IsDirective(name string) bool {
+	return s.directives[name] != nil
+}
+
+// IsGlobalOption reports whether name is valid in the global options block.
+func (s *Schema) IsGlobalOption(name string) bool {
+	return s.globalOptions[name] != nil
+}
+
+// SubDirectivesFor is like the package-level SubDirectivesFor but includes
+// the names declared by the schema's layers.
+func (s *Schema) SubDirectivesFor(parentName string) (subs map[string]bool, ok bool) {
+	subs, ok = s.subNames[parentName]
+	return
+}
+
+// IsRepeatable reports whether the body of parent may hold the subdirective
+// name more than once. Names the schema does not know are repeatable.
+func (s *Schema) IsRepeatable(parent, name string) bool {
+	e := s.subDirectives[parent][name]
+	return e == nil || !e.Once
+}
+
+// InsertTemplate returns the snippet of the arguments completion inserts
+// after the subdirective name in the body of parent, or "" when there is
+// none.
+func (s *Schema) InsertTemplate(parent, name string) string {
+	if e := s.subDirectives[parent][name]; e != nil {
+		return e.Template
+	}
+	return ""
+}
+
+// IsTransport reports whether name is a reverse_proxy transport module.
+func (s *Schema) IsTransport(name string) bool {
+	return s.transports[name] != nil
+}
+
+// IsUpstream reports whether name is a dynamic upstream module.
+func (s *Schema) IsUpstream(name string) bool {
+	return s.upstreams[name] != nil
+}
+
+// IsStorage reports whether name is a storage module.
+func (s *Schema) IsStorage(name string) bool {
+	return s.storage[name] != nil
+}
+
+// IsMatcher reports whether name is a request matcher type.
+func (s *Schema) IsMatcher(name string) bool {
+	return s.matchers[name] != nil
+}
+
+// IsModule reports whether name is a module of namespace ns, as listed by
+// ModuleNamespaces.
+func (s *Schema) IsModule(ns, name string) bool {
+	return s.moduleSet(ns)[name] != nil
+}
+
+// Modules returns the modules of namespace ns, sorted.
+func (s *Schema) Modules(ns string) []string {
+	return sortedKeys(s.moduleSet(ns))
+}
+
+// Directives returns the site-level directive names, sorted.
+func (s *Schema) Directives() []string {
+	return sortedKeys(s.directives)
+}
+
+// Transports returns the transport module names, sorted.
+func (s *Schema) Transports() []string {
+	return sortedKeys(s.transports)
+}
+
+// StorageModules returns the storage module names, sorted.
+func (s *Schema) StorageModules() []string {
+	return sortedKeys(s.storage)
+}
+
+// Matchers returns the request matcher types, sorted.
+func (s *Schema) Matchers() []string {
+	return sortedKeys(s.matchers)
+}
+
+// Problems returns the mistakes found while merging the layers.
+func (s *Schema) Problems() []SchemaProblem {
+	return s.problems
+}
+
+func sortedKeys(set entrySet) []string {
+	names := make([]string, 0, len(set))
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// SchemaDump is a serializable view of a Schema for inspection.
+type SchemaDump struct {
+	Precedence    []Origin            `json:"precedence"`
+	Directives    []NamedSchemaEntry  `json:"directives"`
+	GlobalOptions []NamedSchemaEntry  `json:"globalOptions"`
+	SubDirectives []SubDirectivesDump `json:"subdirectives"`
+	Transports    []NamedSchemaEntry  `json:"transports"`
+	Upstreams     []NamedSchemaEntry  `json:"upstreams"`
+	Storage       []NamedSchemaEntry  `json:"storage"`
+	Matchers      []NamedSchemaEntry  `json:"matchers"`
+	// Modules lists the modules of the namespaces without a list of their
+	// own above.
+	Modules []ModulesDump `json:"modules"`
+	// Disabled lists the directives removed by a layer.
+	Disabled []NamedSchemaEntry `json:"disabled"`
+	Problems []SchemaProblem    `json:"problems"`
+}
+
+// NamedSchemaEntry is a SchemaEntry together with its name.
+type NamedSchemaEntry struct {
+	Name string `json:"name"`
+	SchemaEntry
+}
+
+// SubDirectivesDump lists the subdirectives of one parent. Freeform bodies
+// have no list and are not validated.
+type SubDirectivesDump struct {
+	Parent        string             `json:"parent"`
+	Freeform      bool               `json:"freeform,omitempty"`
+	SubDirectives []NamedSchemaEntry `json:"subdirectives,omitempty"`
+}
+
+// ModulesDump lists the modules of one namespace.
+type ModulesDump struct {
+	Namespace string             `json:"namespace"`
+	Modules   []NamedSchemaEntry `json:"modules"`
+}
+
+// Dump returns the merged schema with the origin of every name, sorted by
+// name.
+func (s *Schema) Dump() SchemaDump {
+	d := SchemaDump{
+		Precedence:    Precedence,
+		Directives:    namedEntries(s.directives),
+		GlobalOptions: namedEntries(s.globalOptions),
+		Transports:    namedEntries(s.transports),
+		Upstreams:     namedEntries(s.upstreams),
+		Storage:       namedEntries(s.storage),
+		Matchers:      namedEntries(s.matchers),
+		Disabled:      namedEntries(s.disabled),
+		Problems:      s.problems,
+	}
+	if d.Problems == nil {
+		d.Problems = []SchemaProblem{}
+	}
+	for _, ns := range slices.Sorted(maps.Keys(s.modules)) {
+		d.Modules = append(d.Modules, ModulesDump{Namespace: ns, Modules: namedEntries(s.modules[ns])})
+	}
+	parents := make([]string, 0, len(s.subDirectives))
+	for parent := range s.subDirectives {
+		parents = append(parents, parent)
+	}
+	sort.Strings(parents)
+	for _, parent := range parents {
+		set := s.subDirectives[parent]
+		d.SubDirectives = append(d.SubDirectives, SubDirectivesDump{
+			Parent:        parent,
+			Freeform:      set == nil,
+			SubDirectives: namedEntries(set),
+		})
+	}
+	return d
+}
+
+func namedEntries(set entrySet) []NamedSchemaEntry {
+	entries := make([]NamedSchemaEntry, 0, len(set))
+	for _, name := range sortedKeys(set) {
+		entries = append(entries, NamedSchemaEntry{Name: name, SchemaEntry: *set[name]})
+	}
+	return entries
+}
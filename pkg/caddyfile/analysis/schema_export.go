@@ -0,0 +1,170 @@
+package analysis
+
+import (
+	"maps"
+	"slices"
+	"sort"
+	"strings"
+)
+
+// SchemaExportVersion is the version of the SchemaExport format. It is
+// raised when a field is removed or changes meaning; fields may be added
+// without raising it.
+const SchemaExportVersion = 1
+
+// SchemaExport is the directive model of a Schema in a form other tools,
+// such as web-based Caddyfile editors, can read without linking this
+// package: every name with the layer it comes from, the argument forms of
+// directives and matchers, and the names valid in their blocks. It
+// marshals to JSON as is; lists are sorted by name.
+type SchemaExport struct {
+	Version       int               `json:"version"`
+	Directives    []DirectiveSchema `json:"directives"`
+	GlobalOptions []DirectiveSchema `json:"globalOptions"`
+	Matchers      []MatcherSchema   `json:"matchers"`
+	Transports    []string          `json:"transports"`
+	Upstreams     []string          `json:"upstreams"`
+	Storage       []string          `json:"storage"`
+	// Modules lists the modules of the other namespaces of
+	// ModuleNamespaces, such as "tls.issuance", by namespace.
+	Modules map[string][]string `json:"modules"`
+}
+
+// DirectiveSchema describes a directive or global option.
+type DirectiveSchema struct {
+	Name   string `json:"name"`
+	Origin Origin `json:"origin"`
+	// Matcher is set when the first argument may be a matcher token; Forms
+	// leave it out.
+	Matcher bool      `json:"matcher,omitempty"`
+	Forms   []ArgForm `json:"forms,omitempty"`
+	// Freeform is set for bodies whose contents are not names, such as the
+	// header fields of `header`. Otherwise SubDirectives, when present,
+	// lists the names valid in the body; without it the body is not
+	// validated.
+	Freeform      bool                 `json:"freeform,omitempty"`
+	SubDirectives []SubDirectiveSchema `json:"subdirectives,omitempty"`
+	// Version is the release history of directives added to Caddy after
+	// 2.4 or deprecated since.
+	Version *DirectiveVersion `json:"version,omitempty"`
+}
+
+// SubDirectiveSchema describes a name valid in the body of a directive or
+// global option.
+type SubDirectiveSchema struct {
+	Name   string `json:"name"`
+	Origin Origin `json:"origin"`
+	// Once is set when a block may hold it only once.
+	Once bool `json:"once,omitempty"`
+	// Template is the snippet, in LSP snippet syntax, of typical arguments
+	// to insert after the name.
+	Template string `json:"template,omitempty"`
+	// Body lists the names valid in its block, e.g. for the timeouts of the
+	// servers global option.
+	Body []string `json:"body,omitempty"`
+	// Blocks lists the names valid in its block by its first argument, e.g.
+	// "http" for `transport http`.
+	Blocks map[string][]string `json:"blocks,omitempty"`
+}
+
+// MatcherSchema describes a request matcher type.
+type MatcherSchema struct {
+	Name   string    `json:"name"`
+	Origin Origin    `json:"origin"`
+	Forms  []ArgForm `json:"forms,omitempty"`
+}
+
+// Export returns the directive model of s.
+func (s *Schema) Export() SchemaExport {
+	e := SchemaExport{
+		Version:       SchemaExportVersion,
+		Directives:    []DirectiveSchema{},
+		GlobalOptions: []DirectiveSchema{},
+		Matchers:      []MatcherSchema{},
+		Transports:    s.Transports(),
+		Upstreams:     sortedKeys(s.upstreams),
+		Storage:       s.StorageModules(),
+		Modules:       make(map[string][]string, len(s.modules)),
+	}
+	for ns, set := range s.modules {
+		e.Modules[ns] = sortedKeys(set)
+	}
+	for _, name := range sortedKeys(s.directives) {
+		d := DirectiveSchema{Name: name, Origin: s.directives[name].Origin}
+		d.Forms, d.Matcher, _ = DirectiveForms(name)
+		if v, ok := directiveVersions[name]; ok {
+			d.Version = &v
+		}
+		set, known := s.subDirectives[name]
+		d.Freeform = known && set == nil
+		for _, sub := range sortedKeys(set) {
+			d.SubDirectives = append(d.SubDirectives, SubDirectiveSchema{Name: sub, Origin: set[sub].Origin, Once: set[sub].Once, Template: set[sub].Template, Body: clientAuthBody(name, sub), Blocks: nestedBlocks(sub)})
+		}
+		e.Directives = append(e.Directives, d)
+	}
+	for _, name := range sortedKeys(s.globalOptions) {
+		e.GlobalOptions = append(e.GlobalOptions, s.exportGlobalOption(name))
+	}
+	for _, name := range s.Matchers() {
+		forms, _ := MatcherForms(name)
+		e.Matchers = append(e.Matchers, MatcherSchema{Name: name, Origin: s.matchers[name].Origin, Forms: forms})
+	}
+	return e
+}
+
+// exportGlobalOption describes the global option name. Its body is the one
+// the analyzer checks, merged with the subdirectives the layers declare
+// for it unless a directive has the same name, as `log` does.
+func (s *Schema) exportGlobalOption(name string) DirectiveSchema {
+	d := DirectiveSchema{Name: name, Origin: s.globalOptions[name].Origin}
+	subs := make(map[string]SubDirectiveSchema)
+	if names, ok := GlobalBlockNames([]string{name}); ok {
+		for _, sub := range names {
+			body, _ := GlobalBlockNames([]string{name, sub})
+			subs[sub] = SubDirectiveSchema{Name: sub, Origin: OriginBuiltin, Body: body}
+		}
+	}
+	if !s.IsDirective(name) {
+		for sub, entry := range s.subDirectives[name] {
+			if _, ok := subs[sub]; !ok || entry.Origin != OriginBuiltin {
+				subs[sub] = SubDirectiveSchema{Name: sub, Origin: entry.Origin, Body: subs[sub].Body}
+			}
+		}
+	}
+	for _, sub := range subs {
+		d.SubDirectives = append(d.SubDirectives, sub)
+	}
+	sort.Slice(d.SubDirectives, func(i, j int) bool { return d.SubDirectives[i].Name < d.SubDirectives[j].Name })
+	return d
+}
+
+// clientAuthBody returns the names valid in the client_auth block of the
+// directive name, or nil for other subdirectives.
+func clientAuthBody(name, sub string) []string {
+	if name != "tls" || sub != "client_auth" {
+		return nil
+	}
+	return slices.Sorted(maps.Keys(clientAuthBlocks["client_auth"]))
+}
+
+// nestedBlocks returns the names valid in the block of the subdirective
+// name by its first argument, as the analyzer validates them.
+func nestedBlocks(name string) map[string][]string {
+	var blocks map[string][]string
+	for key, set := range knownSubSubDirectives {
+		sub, arg, _ := strings.Cut(key, ":")
+		if sub != name {
+			continue
+		}
+		if blocks == nil {
+			blocks = make(map[string][]string)
+		}
+		names := make([]string, 0, len(set))
+		for n := range set {
+			names = append(names, n)
+		}
+		sort.Strings(names)
+		blocks[arg] = names
+	}
+	return blocks
+}
@@ -0,0 +1,241 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"encoding/json"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestNewSchema_BuiltinOnly(t *testing.T) {
+	s := NewSchema()
+	if !s.IsDirective("reverse_proxy") || !s.IsGlobalOption("email") || !s.IsTransport("http") || !s.IsUpstream("srv") {
+		t.Error("built-in names should be in the default schema")
+	}
+	if len(s.Problems()) != 0 {
+		t.Errorf("built-in schema should have no problems, got %v", s.Problems())
+	}
+	if subs, ok := s.SubDirectivesFor("basic_auth"); !ok || subs != nil {
+		t.Error("freeform bodies should stay freeform")
+	}
+}
+
+func TestNewSchema_Precedence(t *testing.T) {
+	// Passed out of order on purpose: the user layer must still win.
+	s := NewSchema(
+		SchemaLayer{Origin: OriginUser, Directives: []string{"rate_limit"}, Disable: []string{"php_fastcgi"}},
+		SchemaLayer{Origin: OriginPlugin, Directives: []string{"rate_limit", "php_fastcgi"}},
+	)
+	d := s.Dump()
+	i := slices.IndexFunc(d.Directives, func(e NamedSchemaEntry) bool { return e.Name == "rate_limit" })
+	if i < 0 || d.Directives[i].Origin != OriginUser || !slices.Equal(d.Directives[i].Shadows, []Origin{OriginPlugin}) {
+		t.Fatalf("rate_limit should come from the user layer, shadowing the plugin one: %+v", d.Directives)
+	}
+	if s.IsDirective("php_fastcgi") {
+		t.Error("the user layer should disable php_fastcgi despite the plugin layer declaring it")
+	}
+	if len(d.Disabled) != 1 || d.Disabled[0].Name != "php_fastcgi" || d.Disabled[0].Origin != OriginUser {
+		t.Errorf("disabled = %+v", d.Disabled)
+	}
+}
+
+func TestNewSchema_SubDirectives(t *testing.T) {
+	s := NewSchema(SchemaLayer{Origin: OriginUser,
+		Directives: []string{"rate_limit"},
+		SubDirectives: map[string][]string{
+			"rate_limit":    {"zone"},
+			"reverse_proxy": {"my_option"},
+		},
+	})
+	if subs, _ := s.SubDirectivesFor("rate_limit"); !subs["zone"] {
+		t.Error("subdirectives of a user directive should be validated")
+	}
+	if subs, _ := s.SubDirectivesFor("reverse_proxy"); !subs["my_option"] || !subs["to"] {
+		t.Error("user subdirectives should extend the built-in ones")
+	}
+	if subs, _ := SubDirectivesFor("reverse_proxy"); subs["my_option"] {
+		t.Error("the built-in tables must not be modified")
+	}
+}
+
+func TestSchema_IsRepeatable(t *testing.T) {
+	s := NewSchema(SchemaLayer{Origin: OriginUser,
+		SubDirectives: map[string][]string{"reverse_proxy": {"lb_policy", "my_option"}},
+	})
+	tests := []struct {
+		parent, name string
+		want         bool
+	}{
+		{"reverse_proxy", "lb_policy", false},
+		{"reverse_proxy", "flush_interval", false},
+		{"reverse_proxy", "header_up", true},
+		{"reverse_proxy", "to", true},
+		{"reverse_proxy", "my_option", true},
+		{"php_fastcgi", "split", false},
+		{"php_fastcgi", "env", true},
+		{"header", "X-A", true},
+		{"rate_limit", "zone", true},
+	}
+	for _, tc := range tests {
+		if got := s.IsRepeatable(tc.parent, tc.name); got != tc.want {
+			t.Errorf("IsRepeatable(%q, %q) = %v, want %v", tc.parent, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestNewSchema_Problems(t *testing.T) {
+	s := NewSchema(SchemaLayer{Origin: OriginUser,
+		Directives:    []string{"reverse_proxy", "@bad", "two words", ""},
+		SubDirectives: map[string][]string{"basic_auth": {"x"}, "nope": {"y"}},
+		Disable:       []string{"nonexistent"},
+		Modules:       map[string][]string{"tls.issuance": {"acme"}, "dns.providers": {"cloudflare"}},
+	})
+	var msgs []string
+	for _, p := range s.Problems() {
+		if p.Origin != OriginUser {
+			t.Errorf("problem attributed to %q, want user", p.Origin)
+		}
+		msgs = append(msgs, p.Message)
+	}
+	all := strings.Join(msgs, "\n")
+	for _, want := range []string{
+		`directive "reverse_proxy" is already declared by the builtin schema`,
+		`invalid directive name "@bad"`,
+		`invalid directive name "two words"`,
+		"empty directive name",
+		`"basic_auth" has a freeform body`,
+		`subdirectives declared for unknown directive "nope"`,
+		`cannot disable unknown directive "nonexistent"`,
+		`issuer "acme" is already declared by the builtin schema`,
+		`unknown module namespace "dns.providers"`,
+	} {
+		if !strings.Contains(all, want) {
+			t.Errorf("missing problem %q in:\n%s", want, all)
+		}
+	}
+	if s.IsDirective("@bad") {
+		t.Error("invalid names should be ignored")
+	}
+}
+
+func TestAnalyzeWith_Schema(t *testing.T) {
+	src := "example.com {\n\trate_limit {\n\t\tzone\n\t\tbogus\n\t}\n\tphp_fastcgi localhost:9000\n}\n"
+	f, _ := parser.Parse(src)
+	s := NewSchema(SchemaLayer{Origin: OriginUser,
+		Directives:    []string{"rate_limit"},
+		SubDirectives: map[string][]string{"rate_limit": {"zone"}},
+		Disable:       []string{"php_fastcgi"},
+	})
+	var msgs []string
+	for _, d := range AnalyzeWith(f, Options{Schema: s}) {
+		msgs = append(msgs, d.Message)
+	}
+	want := []string{`unknown subdirective "bogus" for "rate_limit"`, `unknown directive "php_fastcgi"`}
+	if !slices.Equal(msgs, want) {
+		t.Errorf("diagnostics = %q, want %q", msgs, want)
+	}
+}
+
+func TestModulesLayer(t *testing.T) {
+	l := ModulesLayer([]string{
+		"http.handlers.rate_limit",
+		"http.handlers.reverse_proxy", // built in
+		"http.reverse_proxy.transport.h2c",
+		"http.reverse_proxy.transport.http", // built in
+		"http.reverse_proxy.upstreams.docker",
+		"caddy.storage.redis",
+		"caddy.storage.file_system", // built in
+		"http.matchers.maxmind_geolocation",
+		"http.matchers.path", // built in
+		"dns.providers.cloudflare",
+		"tls.issuance.acmedns",
+		"tls.issuance.acme", // built in
+		"tls.get_certificate.vault",
+	})
+	if l.Origin != OriginGenerated || !slices.Equal(l.Directives, []string{"rate_limit"}) ||
+		!slices.Equal(l.Transports, []string{"h2c"}) || !slices.Equal(l.Upstreams, []string{"docker"}) ||
+		!slices.Equal(l.Storage, []string{"redis"}) || !slices.Equal(l.Matchers, []string{"maxmind_geolocation"}) ||
+		!slices.Equal(l.Modules["tls.issuance"], []string{"acmedns"}) || !slices.Equal(l.Modules["tls.get_certificate"], []string{"vault"}) ||
+		len(l.Modules) != 2 {
+		t.Errorf("got %+v", l)
+	}
+	if s := NewSchema(l); len(s.Problems()) != 0 {
+		t.Errorf("want no problems merging the layer, got %v", s.Problems())
+	}
+}
+
+func TestSchema_InsertTemplate(t *testing.T) {
+	s := NewSchema(SchemaLayer{Origin: OriginUser,
+		SubDirectives: map[string][]string{"reverse_proxy": {"health_uri", "my_option"}},
+	})
+	for _, tc := range []struct{ parent, name, want string }{
+		{"reverse_proxy", "health_uri", "${1:/healthz}"},
+		{"reverse_proxy", "lb_policy", choice(lbPolicyNames)},
+		{"request_body", "max_size", "${1:10MB}"},
+		{"reverse_proxy", "my_option", ""},
+		{"reverse_proxy", "dynamic", ""},
+		{"rate_limit", "zone", ""},
+	} {
+		if got := s.InsertTemplate(tc.parent, tc.name); got != tc.want {
+			t.Errorf("InsertTemplate(%q, %q) = %q, want %q", tc.parent, tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestSchemaExport(t *testing.T) {
+	s := NewSchema(SchemaLayer{
+		Origin:        OriginUser,
+		Directives:    []string{"rate_limit"},
+		SubDirectives: map[string][]string{"rate_limit": {"zone"}},
+		Matchers:      []string{"maxmind_geolocation"},
+	})
+	data, err := json.Marshal(s.Export())
+	if err != nil {
+		t.Fatal(err)
+	}
+	var e SchemaExport
+	if err := json.Unmarshal(data, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.Version != SchemaExportVersion {
+		t.Errorf("version = %d", e.Version)
+	}
+	directive := func(list []DirectiveSchema, name string) DirectiveSchema {
+		t.Helper()
+		i := slices.IndexFunc(list, func(d DirectiveSchema) bool { return d.Name == name })
+		if i < 0 {
+			t.Fatalf("%s missing", name)
+		}
+		return list[i]
+	}
+	if d := directive(e.Directives, "rate_limit"); d.Origin != OriginUser || len(d.SubDirectives) != 1 || d.SubDirectives[0].Name != "zone" {
+		t.Errorf("rate_limit = %+v", d)
+	}
+	if d := directive(e.Directives, "redir"); !d.Matcher || len(d.Forms) == 0 || d.Forms[0].Params[0].Label != "<to>" {
+		t.Errorf("redir = %+v", d)
+	}
+	if d := directive(e.Directives, "header"); !d.Freeform || d.SubDirectives != nil {
+		t.Errorf("header = %+v", d)
+	}
+	if d := directive(e.Directives, "fs"); d.Version == nil || d.Version.Since != "2.8" {
+		t.Errorf("fs version = %+v", d.Version)
+	}
+	proxy := directive(e.Directives, "reverse_proxy")
+	i := slices.IndexFunc(proxy.SubDirectives, func(s SubDirectiveSchema) bool { return s.Name == "transport" })
+	if i < 0 || !slices.Contains(proxy.SubDirectives[i].Blocks["http"], "dial_timeout") || !proxy.SubDirectives[i].Once || !strings.HasPrefix(proxy.SubDirectives[i].Template, "${1|") {
+		t.Errorf("reverse_proxy transport = %+v", proxy.SubDirectives)
+	}
+	servers := directive(e.GlobalOptions, "servers")
+	i = slices.IndexFunc(servers.SubDirectives, func(s SubDirectiveSchema) bool { return s.Name == "timeouts" })
+	if i < 0 || !slices.Contains(servers.SubDirectives[i].Body, "read_body") {
+		t.Errorf("servers = %+v", servers.SubDirectives)
+	}
+	if !slices.ContainsFunc(e.Matchers, func(m MatcherSchema) bool { return m.Name == "maxmind_geolocation" && m.Origin == OriginUser }) ||
+		!slices.ContainsFunc(e.Matchers, func(m MatcherSchema) bool { return m.Name == "path" && len(m.Forms) == 1 }) {
+		t.Errorf("matchers = %+v", e.Matchers)
+	}
+	if !slices.Contains(e.Transports, "fastcgi") || !slices.Contains(e.Storage, "file_system") {
+		t.Errorf("transports = %v, storage = %v", e.Transports, e.Storage)
+	}
+}
@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"slices"
+	"sort"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// globalOptionBlocks maps the path of a block inside the global options,
+// its option names joined by ">", to the names valid directly inside it.
+// Source: caddyconfig/httpcaddyfile/serveroptions.go
+var globalOptionBlocks = map[string]map[string]bool{
+	// Source: caddyconfig/httpcaddyfile/options.go (parseOptAdmin)
+	"admin": {"origins": true, "enforce_origin": true},
+	// Source: caddyconfig/httpcaddyfile/options.go (parseOptDefaultBind)
+	"default_bind": {"protocols": true},
+	// Source: caddyconfig/httpcaddyfile/builtins.go (parseLogHelper)
+	"log": {
+		"output": true, "format": true, "level": true, "sampling": true,
+		"core": true, "include": true, "exclude": true,
+	},
+	"servers": {
+		"name": true, "listener_wrappers": true, "packet_conn_wrappers": true,
+		"timeouts": true, "keepalive_interval": true, "keepalive_idle": true,
+		"keepalive_count": true, "max_header_size": true,
+		"enable_full_duplex": true, "log_credentials": true, "protocols": true,
+		"strict_sni_host": true, "trusted_proxies": true,
+		"trusted_proxies_strict": true, "trusted_proxies_unix": true,
+		"client_ip_headers": true, "metrics": true, "trace": true, "0rtt": true,
+	},
+	// caddy.listeners.* modules shipped with Caddy
+	"servers>listener_wrappers": {
+		"http_redirect": true, "proxy_protocol": true, "tls": true,
+	},
+	"servers>timeouts": {
+		"read_body": true, "read_header": true, "write": true, "idle": true,
+	},
+}
+
+// serverProtocols are the values accepted by `servers { protocols … }`.
+var serverProtocols = []string{"h1", "h2", "h2c", "h3"}
+
+// GlobalBlockNames returns the sorted names valid inside the global options
+// block reached by path, e.g. ["servers", "timeouts"]. ok is false when the
+// block is not known.
+func GlobalBlockNames(path []string) (names []string, ok bool) {
+	set, ok := globalOptionBlocks[strings.Join(path, ">")]
+	if !ok {
+		return nil, false
+	}
+	for name := range set {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, true
+}
+
+// ServerProtocols returns the values accepted by `servers { protocols … }`.
+func ServerProtocols() []string {
+	return slices.Clone(serverProtocols)
+}
+
+// analyzeServers checks the body of a `servers` global option: its option
+// names, the listener wrappers and timeouts it configures, and the
+// protocols it enables.
+func analyzeServers(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, sub := range d.Body {
+		name := sub.Name.Value
+		if name == "import" {
+			continue
+		}
+		if !globalOptionBlocks["servers"][name] {
+			diags = append(diags, unknownInBlock(sub.Name, "servers option", "servers")...)
+			continue
+		}
+		switch name {
+		case "listener_wrappers":
+			for _, w := range sub.Body {
+				if w.Name.Value != "import" && !globalOptionBlocks["servers>listener_wrappers"][w.Name.Value] {
+					diags = append(diags, unknownInBlock(w.Name, "listener wrapper", "servers>listener_wrappers")...)
+				}
+			}
+		case "timeouts":
+			for _, t := range sub.Body {
+				if t.Name.Value != "import" && !globalOptionBlocks["servers>timeouts"][t.Name.Value] {
+					diags = append(diags, unknownInBlock(t.Name, "timeouts option", "servers>timeouts")...)
+					continue
+				}
+				for _, arg := range t.Args {
+					diags = append(diags, checkDuration(arg.Token)...)
+				}
+			}
+		case "protocols":
+			diags = append(diags, analyzeServerProtocols(sub)...)
+		}
+	}
+	return diags
+}
+
+// analyzeServerProtocols reports protocols Caddy does not know and ones
+// listed twice.
+func analyzeServerProtocols(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	var seen []string
+	for _, arg := range d.Args {
+		proto := arg.Token.Value
+		if !slices.Contains(serverProtocols, proto) {
+			diags = append(diags, warningf(arg.Range(), "unknown protocol %q: expected h1, h2, h2c, or h3", proto))
+			continue
+		}
+		if slices.Contains(seen, proto) {
+			diags = append(diags, warningf(arg.Range(), "protocol %s specified more than once", proto))
+		}
+		seen = append(seen, proto)
+	}
+	return diags
+}
+
+// unknownInBlock reports the name tok that the global options block at path
+// does not accept, suggesting the closest valid name. what names the kind of
+// entry in the message, e.g. "listener wrapper".
+func unknownInBlock(tok parser.Token, what, path string) []protocol.Diagnostic {
+	names, _ := GlobalBlockNames(strings.Split(path, ">"))
+	return []protocol.Diagnostic{warningf(tok.Range(), "unrecognized %s %q%s", what, tok.Value, didYouMean(tok.Value, names))}
+}
@@ -0,0 +1,46 @@
+package analysis
+
+import "testing"
+
+func TestAnalyzeServers(t *testing.T) {
+	src := "{\n" +
+		"\tservers :443 {\n" +
+		"\t\tname https\n" +
+		"\t\tlistener_wrappers {\n" +
+		"\t\t\tproxy_protocol\n" +
+		"\t\t\ttls\n" +
+		"\t\t\thttp_redirct\n" +
+		"\t\t}\n" +
+		"\t\ttimeouts {\n" +
+		"\t\t\tread_body 10s\n" +
+		"\t\t\tidle forever\n" +
+		"\t\t\twrit 5s\n" +
+		"\t\t}\n" +
+		"\t\tprotocols h1 h2 h2 h4\n" +
+		"\t\tstrict_sni_hosts on\n" +
+		"\t}\n" +
+		"}\n"
+	diags := analyze(src)
+	for _, want := range [][]string{
+		{`unrecognized listener wrapper "http_redirct"`, `did you mean "http_redirect"?`},
+		{`invalid duration "forever"`},
+		{`unrecognized timeouts option "writ"`, `did you mean "write"?`},
+		{"protocol h2 specified more than once"},
+		{`unknown protocol "h4": expected h1, h2, h2c, or h3`},
+		{`unrecognized servers option "strict_sni_hosts"`, `did you mean "strict_sni_host"?`},
+	} {
+		if !hasMsg(diags, want...) {
+			t.Errorf("missing diagnostic %q in %v", want, diags)
+		}
+	}
+	if len(diags) != 6 {
+		t.Errorf("got %d diagnostics, want 6: %v", len(diags), diags)
+	}
+}
+
+func TestAnalyzeServers_Valid(t *testing.T) {
+	src := "{\n\tservers {\n\t\tprotocols h1 h2 h3\n\t\ttrusted_proxies static private_ranges\n\t\ttimeouts {\n\t\t\twrite {$WRITE_TIMEOUT}\n\t\t}\n\t\tmetrics\n\t}\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("want no diagnostics, got %v", diags)
+	}
+}
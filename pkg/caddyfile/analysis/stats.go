@@ -0,0 +1,96 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+)
+
+// Stats counts what a Caddyfile is made of, to size up sprawling configs.
+type Stats struct {
+	SiteBlocks int `json:"siteBlocks"`
+	Snippets   int `json:"snippets"`
+	// Directives counts the site-level directives, including those in
+	// snippets and routing containers such as handle, but not their
+	// subdirectives, named matchers or imports.
+	Directives int `json:"directives"`
+	// DirectiveCounts maps each directive name to its uses.
+	DirectiveCounts map[string]int `json:"directiveCounts"`
+	// Matchers counts the named matcher definitions.
+	Matchers int `json:"matchers"`
+	// Imports counts the import directives, wherever they appear.
+	Imports       int `json:"imports"`
+	GlobalOptions int `json:"globalOptions"`
+}
+
+// CollectStats returns the counts of f.
+func CollectStats(f *parser.File) Stats {
+	s := Stats{DirectiveCounts: map[string]int{}}
+	s.Imports = len(f.Imports)
+	if f.GlobalBlock != nil {
+		for _, d := range f.GlobalBlock.Directives {
+			if d.Name.Value == "import" {
+				s.Imports++
+				continue
+			}
+			s.GlobalOptions++
+			s.countImports(d.Body)
+		}
+	}
+	for _, sb := range f.SiteBlocks {
+		if isSnippet(sb) {
+			s.Snippets++
+		} else {
+			s.SiteBlocks++
+		}
+		s.countDirectives(sb.Directives)
+	}
+	return s
+}
+
+// countDirectives counts a list of site-level directives, descending into
+// routing containers.
+func (s *Stats) countDirectives(ds []*parser.Directive) {
+	for _, d := range ds {
+		switch name := d.Name.Value; {
+		case name == "import":
+			s.Imports++
+		case strings.HasPrefix(name, "@"):
+			s.Matchers++
+		default:
+			s.Directives++
+			s.DirectiveCounts[name]++
+			if containerDirectives[name] {
+				s.countDirectives(d.Body)
+			} else {
+				s.countImports(d.Body)
+			}
+		}
+	}
+}
+
+// countImports counts the imports in a directive body and the bodies
+// nested in it.
+func (s *Stats) countImports(ds []*parser.Directive) {
+	for _, d := range ds {
+		if d.Name.Value == "import" {
+			s.Imports++
+		}
+		s.countImports(d.Body)
+	}
+}
+
+// Add adds the counts of o to s.
+func (s *Stats) Add(o Stats) {
+	s.SiteBlocks += o.SiteBlocks
+	s.Snippets += o.Snippets
+	s.Directives += o.Directives
+	s.Matchers += o.Matchers
+	s.Imports += o.Imports
+	s.GlobalOptions += o.GlobalOptions
+	if s.DirectiveCounts == nil {
+		s.DirectiveCounts = map[string]int{}
+	}
+	for name, n := range o.DirectiveCounts {
+		s.DirectiveCounts[name] += n
+	}
+}
@@ -0,0 +1,32 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"maps"
+	"reflect"
+	"testing"
+)
+
+func TestCollectStats(t *testing.T) {
+	src := "import common.caddy\n\n{\n\temail admin@example.com\n\timport globals\n}\n\n(logging) {\n\tlog\n}\n\nexample.com {\n\t@api path /api/*\n\timport logging\n\thandle @api {\n\t\treverse_proxy app:8080 {\n\t\t\timport upstream\n\t\t}\n\t}\n\thandle {\n\t\tfile_server\n\t}\n}\n\nwww.example.com {\n\tredir https://example.com{uri}\n}\n"
+	f, _ := parser.Parse(src)
+	s := CollectStats(f)
+	want := Stats{
+		SiteBlocks: 2, Snippets: 1, Directives: 6, Matchers: 1, Imports: 4, GlobalOptions: 1,
+		DirectiveCounts: map[string]int{"log": 1, "handle": 2, "reverse_proxy": 1, "file_server": 1, "redir": 1},
+	}
+	if !maps.Equal(s.DirectiveCounts, want.DirectiveCounts) {
+		t.Errorf("directive counts = %v, want %v", s.DirectiveCounts, want.DirectiveCounts)
+	}
+	s.DirectiveCounts, want.DirectiveCounts = nil, nil
+	if !reflect.DeepEqual(s, want) {
+		t.Errorf("got %+v, want %+v", s, want)
+	}
+
+	total := Stats{}
+	total.Add(CollectStats(f))
+	total.Add(CollectStats(f))
+	if total.SiteBlocks != 4 || total.DirectiveCounts["handle"] != 4 {
+		t.Errorf("sum = %+v", total)
+	}
+}
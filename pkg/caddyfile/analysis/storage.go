@@ -0,0 +1,56 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// builtinStorage are the storage modules shipped with Caddy.
+// Source: modules/filestorage/filestorage.go
+var builtinStorage = []string{"file_system"}
+
+// analyzeStorage checks the storage global option: a storage module the
+// schema knows, and for the built-in file_system module its root path,
+// given either as an argument or as `root` in its body. The bodies of plugin
+// modules are not validated.
+// Source: caddyconfig/httpcaddyfile/options.go (parseOptStorage)
+func (a *analyzer) analyzeStorage(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{errorf(d.Name.Range(), "storage requires a module name, e.g. file_system")}
+	}
+	module := d.Args[0].Token
+	if !a.schema.IsStorage(module.Value) {
+		return []protocol.Diagnostic{warningf(module.Range(),
+			"unknown storage module %q%s; declare plugin storage modules in the caddy.schema.storage setting",
+			module.Value, didYouMean(module.Value, a.schema.StorageModules()))}
+	}
+	if module.Value != "file_system" {
+		return nil
+	}
+
+	var diags []protocol.Diagnostic
+	root := len(d.Args) > 1
+	if len(d.Args) > 2 {
+		diags = append(diags, errorf(d.Args[2].Range(), "file_system storage takes at most one root path"))
+	}
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "import":
+		case "root":
+			switch {
+			case len(sub.Args) != 1:
+				diags = append(diags, errorf(sub.Name.Range(), "root takes exactly one path"))
+			case root:
+				diags = append(diags, errorf(sub.Name.Range(), "root already set"))
+			}
+			root = true
+		default:
+			diags = append(diags, errorf(sub.Name.Range(), "unrecognized file_system option %q%s", sub.Name.Value, didYouMean(sub.Name.Value, []string{"root"})))
+		}
+	}
+	if !root {
+		diags = append(diags, errorf(module.Range(), "file_system storage needs a root path; to use the default location, remove the storage option"))
+	}
+	return diags
+}
@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func TestAnalyzeStorage(t *testing.T) {
+	for _, src := range []string{
+		"{\n\tstorage file_system /var/lib/caddy\n}\n",
+		"{\n\tstorage file_system {\n\t\troot /var/lib/caddy\n\t}\n}\n",
+		"{\n\tstorage file_system {$DATA_DIR}\n}\n",
+	} {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: want no diagnostics, got %v", src, diags)
+		}
+	}
+
+	for src, want := range map[string][]string{
+		"{\n\tstorage\n}\n":                                     {"storage requires a module name"},
+		"{\n\tstorage file_sytem /data\n}\n":                    {`unknown storage module "file_sytem"`, `did you mean "file_system"?`, "caddy.schema.storage"},
+		"{\n\tstorage redis\n}\n":                               {`unknown storage module "redis"`},
+		"{\n\tstorage file_system\n}\n":                         {"file_system storage needs a root path"},
+		"{\n\tstorage file_system /a /b\n}\n":                   {"file_system storage takes at most one root path"},
+		"{\n\tstorage file_system /a {\n\t\troot /b\n\t}\n}\n":  {"root already set"},
+		"{\n\tstorage file_system {\n\t\troot\n\t}\n}\n":        {"root takes exactly one path"},
+		"{\n\tstorage file_system {\n\t\troots /data\n\t}\n}\n": {`unrecognized file_system option "roots"`, `did you mean "root"?`},
+	} {
+		if diags := analyze(src); !hasMsg(diags, want...) {
+			t.Errorf("%q: want %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestAnalyzeStorage_PluginModule(t *testing.T) {
+	schema := NewSchema(SchemaLayer{Origin: OriginUser, Storage: []string{"redis"}})
+	f, _ := parser.Parse("{\n\tstorage redis {\n\t\thost 127.0.0.1\n\t}\n}\n")
+	if diags := AnalyzeWith(f, Options{Schema: schema}); len(diags) != 0 {
+		t.Errorf("declared plugin module: want no diagnostics, got %v", diags)
+	}
+}
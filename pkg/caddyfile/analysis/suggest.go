@@ -0,0 +1,54 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+)
+
+// closestMatch returns the candidate nearest to word by case-insensitive
+// edit distance, if it is close enough to be a plausible typo: at most a
+// quarter of the word's length, and always allowing a single edit.
+func closestMatch(word string, candidates []string) (string, bool) {
+	best, bestDist := "", -1
+	for _, c := range candidates {
+		d := editDistance(strings.ToLower(word), strings.ToLower(c))
+		if bestDist < 0 || d < bestDist {
+			best, bestDist = c, d
+		}
+	}
+	limit := max(len(word)/4, 1)
+	if bestDist < 0 || bestDist > limit {
+		return "", false
+	}
+	return best, true
+}
+
+// didYouMean returns a " (did you mean X?)" suffix for messages about an
+// unrecognized word, or "" when no candidate is close enough.
+func didYouMean(word string, candidates []string) string {
+	if s, ok := closestMatch(word, candidates); ok {
+		return fmt.Sprintf(" (did you mean %q?)", s)
+	}
+	return ""
+}
+
+// editDistance returns the Levenshtein distance between a and b.
+func editDistance(a, b string) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
@@ -0,0 +1,30 @@
+package analysis
+
+import "testing"
+
+func TestClosestMatch(t *testing.T) {
+	candidates := []string{"strip_prefix", "strip_suffix", "replace"}
+	if got, ok := closestMatch("strip_perfix", candidates); !ok || got != "strip_prefix" {
+		t.Errorf("want strip_prefix, got %q (ok=%v)", got, ok)
+	}
+	if _, ok := closestMatch("completely_different", candidates); ok {
+		t.Error("distant word: want no match")
+	}
+}
+
+func TestEditDistance(t *testing.T) {
+	cases := []struct {
+		a, b string
+		want int
+	}{
+		{"", "", 0},
+		{"abc", "abc", 0},
+		{"abc", "", 3},
+		{"kitten", "sitting", 3},
+	}
+	for _, c := range cases {
+		if got := editDistance(c.a, c.b); got != c.want {
+			t.Errorf("editDistance(%q, %q) = %d, want %d", c.a, c.b, got, c.want)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// analyzeTemplates checks the values of the templates options: the MIME
+// types it renders, the two action delimiters of between and the single
+// file root.
+func analyzeTemplates(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	for _, sub := range d.Body {
+		switch sub.Name.Value {
+		case "mime":
+			if len(sub.Args) == 0 {
+				diags = append(diags, warningf(sub.Name.Range(), "mime requires at least one MIME type, e.g. text/html"))
+			}
+			for _, arg := range sub.Args {
+				diags = append(diags, checkTemplatesMIME(arg)...)
+			}
+		case "between":
+			if len(sub.Args) != 2 {
+				diags = append(diags, warningf(sub.Name.Range(), "between takes exactly two delimiters, the opening and the closing one, e.g. between [[ ]]"))
+			}
+		case "root":
+			if len(sub.Args) == 0 {
+				diags = append(diags, warningf(sub.Name.Range(), "root requires a path"))
+			}
+			for _, extra := range sub.Args[min(len(sub.Args), 1):] {
+				diags = append(diags, warningf(extra.Range(), "unexpected argument %q: root takes a single path", extra.Token.Value))
+			}
+		}
+	}
+	return diags
+}
+
+// checkTemplatesMIME warns about a mime value that is not of the form
+// type/subtype. Caddy renders a response when its Content-Type contains the
+// value anywhere, so a wildcard never matches and a partial value such as
+// "html" matches more than it seems to.
+func checkTemplatesMIME(arg *parser.Argument) []protocol.Diagnostic {
+	v := arg.Token.Value
+	if strings.Contains(v, "{") {
+		return nil
+	}
+	if strings.Contains(v, "*") {
+		return []protocol.Diagnostic{warningf(arg.Range(), "MIME type %q never matches: templates compares MIME types literally and has no wildcards", v)}
+	}
+	typ, sub, ok := strings.Cut(v, "/")
+	valid := ok && typ != "" && sub != "" && !strings.Contains(sub, "/")
+	for _, r := range typ + sub {
+		valid = valid && isHeaderTokenChar(r)
+	}
+	if !valid {
+		return []protocol.Diagnostic{warningf(arg.Range(), "%q is not a MIME type of the form type/subtype, e.g. text/html; templates renders any response whose Content-Type contains it", v)}
+	}
+	return nil
+}
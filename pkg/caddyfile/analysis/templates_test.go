@@ -0,0 +1,49 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_Templates_Valid(t *testing.T) {
+	src := "example.com {\n\ttemplates {\n\t\tmime text/html application/json+ld {http.vars.mime}\n\t\tbetween \"[[\" \"]]\"\n\t\troot /srv/templates\n\t}\n}\n"
+	if diags := analyze(src); len(diags) != 0 {
+		t.Errorf("expected no diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyze_Templates_OldOptionNames(t *testing.T) {
+	diags := analyze("example.com {\n\ttemplates {\n\t\tmime_type text/html\n\t\tdelimiters [[ ]]\n\t}\n}\n")
+	if len(diags) != 2 || !hasMsg(diags, `unknown subdirective "mime_type"`) || !hasMsg(diags, `unknown subdirective "delimiters"`) {
+		t.Errorf("got %v", diags)
+	}
+}
+
+func TestAnalyze_Templates_Between(t *testing.T) {
+	for _, line := range []string{"between [[", "between [[ ]] x", "between"} {
+		diags := analyze("example.com {\n\ttemplates {\n\t\t" + line + "\n\t}\n}\n")
+		if len(diags) != 1 || !hasMsg(diags, "exactly two delimiters") {
+			t.Errorf("%s: got %v", line, diags)
+		}
+	}
+}
+
+func TestAnalyze_Templates_MIME(t *testing.T) {
+	diags := analyze("example.com {\n\ttemplates {\n\t\tmime html text/* text/html/x\n\t\tmime\n\t}\n}\n")
+	if len(diags) != 4 {
+		t.Fatalf("expected 4 diagnostics, got %v", diags)
+	}
+	if !hasMsg(diags, `"html"`, "type/subtype") || !hasMsg(diags, `"text/html/x"`, "type/subtype") {
+		t.Errorf("malformed types not reported: %v", diags)
+	}
+	if !hasMsg(diags, `"text/*"`, "no wildcards") {
+		t.Errorf("wildcard not reported: %v", diags)
+	}
+	if !hasMsg(diags, "at least one MIME type") {
+		t.Errorf("empty mime not reported: %v", diags)
+	}
+}
+
+func TestAnalyze_Templates_Root(t *testing.T) {
+	diags := analyze("example.com {\n\ttemplates {\n\t\troot /a /b\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, `"/b"`, "single path") {
+		t.Errorf("got %v", diags)
+	}
+}
@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"slices"
+	"sort"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleShadowedHandler is the code of the rule flagging terminal handlers
+// that never run because another one handles every request first.
+const RuleShadowedHandler = "shadowed-handler"
+
+// terminalOrder lists the handlers that write a response and never pass the
+// request on, in Caddy's default directive order. php_fastcgi is left out
+// because it only proxies PHP files, and acme_server because it only serves
+// its own paths.
+// Source: caddyconfig/httpcaddyfile/directives.go (defaultDirectiveOrder)
+var terminalOrder = []string{"redir", "abort", "error", "respond", "metrics", "reverse_proxy", "file_server"}
+
+// terminalRank returns the position of name in terminalOrder, or -1 when
+// name is not a terminal handler.
+func terminalRank(name string) int {
+	return slices.Index(terminalOrder, name)
+}
+
+// isUnconditionalTerminal reports whether d handles every request that
+// reaches it: a terminal handler without a matcher, or with the `*` one.
+func isUnconditionalTerminal(d *parser.Directive) bool {
+	if terminalRank(d.Name.Value) < 0 {
+		return false
+	}
+	if len(d.Args) > 0 && d.Args[0].Token.Value != "*" && isMatcherToken(d.Args[0].Token.Value) {
+		return false
+	}
+	// file_server pass_thru hands missing files on to the next handler.
+	for _, sub := range d.Body {
+		if d.Name.Value == "file_server" && sub.Name.Value == "pass_thru" {
+			return false
+		}
+	}
+	return true
+}
+
+// analyzeTerminals warns about unconditional terminal handlers in one block
+// that can never run because another one handles every request first. Caddy
+// sorts a site block or handle by directive order, so the earliest directive
+// in that order wins, while a route keeps the order as written. Nested
+// routing containers are checked on their own. Directives repositioned with
+// the `order` global option are skipped since their order is not known.
+func (a *analyzer) analyzeTerminals(block []*parser.Directive, asWritten bool) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	var terminals []*parser.Directive
+	for _, d := range block {
+		if containerDirectives[d.Name.Value] {
+			diags = append(diags, a.analyzeTerminals(d.Body, d.Name.Value == "route")...)
+			continue
+		}
+		if isUnconditionalTerminal(d) && !a.ordered[d.Name.Value] {
+			terminals = append(terminals, d)
+		}
+	}
+	if len(terminals) < 2 {
+		return diags
+	}
+	if !asWritten {
+		sort.SliceStable(terminals, func(i, j int) bool {
+			return terminalRank(terminals[i].Name.Value) < terminalRank(terminals[j].Name.Value)
+		})
+	}
+	first := terminals[0]
+	for _, d := range terminals[1:] {
+		diag := warningf(d.Name.Range(), "%s never runs: %s on line %d has no matcher and handles every request first",
+			d.Name.Value, first.Name.Value, first.Name.Line+1)
+		SetRuleCode(&diag, RuleShadowedHandler)
+		if a.opts.URI != "" {
+			diag.RelatedInformation = []protocol.DiagnosticRelatedInformation{{
+				Location: protocol.Location{URI: a.opts.URI, Range: first.Name.Range()},
+				Message:  fmt.Sprintf("%s handles every request here", first.Name.Value),
+			}}
+		}
+		diags = append(diags, diag)
+	}
+	return diags
+}
@@ -0,0 +1,87 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+)
+
+func TestAnalyzeTerminals(t *testing.T) {
+	tests := []struct {
+		name string
+		src  string
+		want []string // messages, in order
+	}{
+		{
+			name: "directive order decides",
+			src:  "a.com {\n\tfile_server\n\trespond \"a\"\n}\n",
+			want: []string{"file_server never runs: respond on line 3 has no matcher and handles every request first"},
+		},
+		{
+			name: "wildcard matcher is unconditional",
+			src:  "a.com {\n\treverse_proxy * localhost:8080\n\tfile_server\n}\n",
+			want: []string{"file_server never runs: reverse_proxy on line 2 has no matcher and handles every request first"},
+		},
+		{
+			name: "same directive twice",
+			src:  "a.com {\n\trespond \"a\"\n\trespond \"b\"\n}\n",
+			want: []string{"respond never runs: respond on line 2 has no matcher and handles every request first"},
+		},
+		{
+			name: "route keeps written order",
+			src:  "a.com {\n\troute {\n\t\tfile_server\n\t\trespond \"a\"\n\t}\n}\n",
+			want: []string{"respond never runs: file_server on line 3 has no matcher and handles every request first"},
+		},
+		{
+			name: "handle bodies are checked separately",
+			src:  "a.com {\n\thandle /api/* {\n\t\treverse_proxy api:80\n\t}\n\thandle {\n\t\tfile_server\n\t}\n\trespond 404\n}\n",
+		},
+		{
+			name: "matchers make handlers conditional",
+			src:  "a.com {\n\t@api path /api/*\n\treverse_proxy @api api:80\n\trespond /health 200\n\tfile_server\n}\n",
+		},
+		{
+			name: "php_fastcgi falls through",
+			src:  "a.com {\n\tphp_fastcgi localhost:9000\n\tfile_server\n}\n",
+		},
+		{
+			name: "pass_thru falls through",
+			src:  "a.com {\n\tfile_server {\n\t\tpass_thru\n\t}\n\treverse_proxy app:80\n}\n",
+		},
+		{
+			name: "reordered directives are skipped",
+			src:  "{\n\torder respond after file_server\n}\na.com {\n\trespond \"a\"\n\tfile_server\n}\n",
+		},
+		{
+			name: "snippets are skipped",
+			src:  "(s) {\n\trespond \"a\"\n\tfile_server\n}\n",
+		},
+	}
+	for _, tc := range tests {
+		t.Run(tc.name, func(t *testing.T) {
+			f, _ := parser.Parse(tc.src)
+			var got []string
+			for _, d := range AnalyzeWith(f, Options{URI: "file:///Caddyfile"}) {
+				got = append(got, d.Message)
+			}
+			if len(got) != len(tc.want) {
+				t.Fatalf("got %q, want %q", got, tc.want)
+			}
+			for i := range got {
+				if got[i] != tc.want[i] {
+					t.Errorf("got %q, want %q", got[i], tc.want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestAnalyzeTerminals_RelatedInformation(t *testing.T) {
+	f, _ := parser.Parse("a.com {\n\tfile_server\n\tredir https://b.com\n}\n")
+	diags := AnalyzeWith(f, Options{URI: "file:///Caddyfile"})
+	if len(diags) != 1 || len(diags[0].RelatedInformation) != 1 {
+		t.Fatalf("want one diagnostic with related information, got %+v", diags)
+	}
+	if diags[0].Range.Start.Line != 1 || diags[0].RelatedInformation[0].Location.Range.Start.Line != 2 {
+		t.Errorf("want file_server flagged and redir related, got %+v", diags[0])
+	}
+}
@@ -0,0 +1,209 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"cmp"
+	"crypto/tls"
+	"maps"
+	"net/mail"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2/modules/caddytls"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Codes of the rules flagging tls configurations that Caddy refuses or that
+// likely do not do what was meant.
+const (
+	RuleTLSIssuerConflict = "tls-issuer-conflict"
+	RuleTLSProtocol       = "tls-protocol"
+	RuleTLSSwappedFiles   = "tls-swapped-files"
+)
+
+// tlsProtocols maps the protocol names accepted by `protocols` to their TLS
+// version numbers, which order them. It is Caddy's own table, so that it
+// follows the Caddy version built against.
+var tlsProtocols = caddytls.SupportedProtocols
+
+// tlsProtocolNames are the names in tlsProtocols, oldest first.
+var tlsProtocolNames = slices.SortedFunc(maps.Keys(tlsProtocols), func(a, b string) int {
+	return cmp.Compare(tlsProtocols[a], tlsProtocols[b])
+})
+
+// legacyTLSProtocols are protocol names Caddy recognizes but no longer
+// supports.
+var legacyTLSProtocols = map[string]bool{"ssl3.0": true, "tls1.0": true, "tls1.1": true}
+
+// tlsCurves are the key exchange mechanisms accepted by `curves`, taken
+// from Caddy's own table so that they follow the Caddy version built
+// against.
+var tlsCurves = slices.Sorted(maps.Keys(caddytls.SupportedCurves))
+
+// tlsCipherSuites are the cipher suite names accepted by `ciphers`. Caddy
+// delegates to crypto/tls, so the list always matches the Go toolchain.
+var tlsCipherSuites = func() []string {
+	var names []string
+	for _, cs := range tls.CipherSuites() {
+		names = append(names, cs.Name)
+	}
+	sort.Strings(names)
+	return names
+}()
+
+// acmeTLSOptions are the tls subdirectives that configure an ACME issuer.
+// Source: caddyconfig/httpcaddyfile/builtins.go (parseTLS)
+var acmeTLSOptions = map[string]bool{
+	"ca": true, "ca_root": true, "dns": true, "dns_challenge_override_domain": true, "dns_ttl": true,
+	"eab": true, "propagation_delay": true, "propagation_timeout": true, "resolvers": true,
+}
+
+// tlsKeywords are the keywords accepted as the single argument of tls.
+var tlsKeywords = []string{"internal", "force_automate"}
+
+// certFileExts and keyFileExts are the usual extensions of PEM
+// certificate and private key files.
+var (
+	certFileExts = map[string]bool{".cer": true, ".cert": true, ".crt": true}
+	keyFileExts  = map[string]bool{".key": true}
+)
+
+// analyzeTLS checks the positional form of tls, which is `tls internal`,
+// `tls force_automate`, `tls <email>` or `tls <cert_file> <key_file>`,
+// runs value checks on the subdirectives of its block, including the
+// modules named by issuer and get_certificate and the trust pools of
+// client_auth, and flags those that conflict with the issuer the form sets
+// up.
+// Source: caddyconfig/httpcaddyfile/builtins.go (parseTLS)
+func (a *analyzer) analyzeTLS(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	// issuer is what the arguments set up: "internal", "email" or "".
+	issuer := ""
+	switch len(d.Args) {
+	case 0:
+	case 1:
+		arg := d.Args[0].Token
+		switch v := arg.Value; {
+		case isCaddyPlaceholder(v):
+		case v == "internal":
+			issuer = "internal"
+		case v == "force_automate":
+		case strings.Contains(v, "@"):
+			issuer = "email"
+			if _, err := mail.ParseAddress(v); err != nil {
+				diags = append(diags, warningf(arg.Range(), "%q is not a valid email address", v))
+			}
+		case v == "off":
+			diags = append(diags, errorf(arg.Range(), "`tls off` is Caddy 1 syntax; to serve this site over HTTP, use an http:// address, or the auto_https off global option"))
+		default:
+			diags = append(diags, errorf(arg.Range(), "a single tls argument must be internal, force_automate or an email address, got %q%s", v, didYouMean(v, tlsKeywords)))
+		}
+	case 2:
+		diags = append(diags, withRule(RuleTLSSwappedFiles, analyzeTLSFiles(d.Args[0].Token, d.Args[1].Token))...)
+	default:
+		for _, extra := range d.Args[2:] {
+			diags = append(diags, errorf(extra.Range(), "unexpected argument %q: tls takes at most <cert_file> <key_file>", extra.Token.Value))
+		}
+	}
+
+	var explicit *parser.Directive
+	for _, sub := range d.Body {
+		switch name := sub.Name.Value; {
+		case name == "protocols":
+			diags = append(diags, analyzeTLSProtocols(sub)...)
+		case name == "curves":
+			diags = append(diags, analyzeTLSValues(sub, "curve", tlsCurves)...)
+		case name == "ciphers":
+			diags = append(diags, analyzeTLSValues(sub, "cipher suite", tlsCipherSuites)...)
+		case name == "client_auth":
+			diags = append(diags, a.analyzeClientAuth(sub)...)
+		case name == "issuer":
+			explicit = sub
+			diags = append(diags, a.analyzeModuleArgument(sub, "tls.issuance")...)
+		case name == "get_certificate":
+			diags = append(diags, a.analyzeModuleArgument(sub, "tls.get_certificate")...)
+		case acmeTLSOptions[name] && issuer == "internal":
+			diags = append(diags, ruleError(RuleTLSIssuerConflict, sub.Name.Range(), "%s configures an ACME issuer, which cannot be combined with `tls internal`", name))
+		}
+	}
+	if explicit != nil {
+		switch {
+		case issuer == "internal":
+			diags = append(diags, ruleError(RuleTLSIssuerConflict, explicit.Name.Range(), "issuer cannot be combined with `tls internal`; use `issuer internal` instead"))
+		case issuer == "email":
+			diags = append(diags, ruleError(RuleTLSIssuerConflict, explicit.Name.Range(), "issuer cannot be combined with an email argument; set the email in the issuer's block"))
+		}
+		for _, sub := range d.Body {
+			if acmeTLSOptions[sub.Name.Value] {
+				diags = append(diags, ruleError(RuleTLSIssuerConflict, sub.Name.Range(), "%s cannot be combined with issuer; set it in the issuer's block", sub.Name.Value))
+			}
+		}
+	}
+	return diags
+}
+
+// analyzeTLSFiles checks the file arguments of `tls <cert_file> <key_file>`,
+// warning when their extensions suggest they are the wrong way round.
+func analyzeTLSFiles(cert, key parser.Token) []protocol.Diagnostic {
+	if isCaddyPlaceholder(cert.Value) || isCaddyPlaceholder(key.Value) {
+		return nil
+	}
+	certExt := strings.ToLower(filepath.Ext(cert.Value))
+	keyExt := strings.ToLower(filepath.Ext(key.Value))
+	switch {
+	case keyFileExts[certExt] && certFileExts[keyExt]:
+		return []protocol.Diagnostic{warningf(protocol.Range{Start: cert.Range().Start, End: key.Range().End},
+			"the certificate and key files look swapped: tls takes <cert_file> <key_file>")}
+	case keyFileExts[certExt]:
+		return []protocol.Diagnostic{warningf(cert.Range(), "%s looks like a private key, but the first tls argument is the certificate file", cert.Value)}
+	case certFileExts[keyExt]:
+		return []protocol.Diagnostic{warningf(key.Range(), "%s looks like a certificate, but the second tls argument is the private key file", key.Value)}
+	}
+	return nil
+}
+
+// analyzeTLSProtocols validates `protocols <min> [<max>]`. Values that are
+// not supported TLS versions, and a range ending before it starts, are
+// reported under RuleTLSProtocol.
+func analyzeTLSProtocols(d *parser.Directive) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "protocols requires one or two arguments: <min> [<max>]")}
+	}
+	var diags []protocol.Diagnostic
+	valid := true
+	for _, arg := range d.Args[:min(len(d.Args), 2)] {
+		v := arg.Token.Value
+		switch {
+		case isCaddyPlaceholder(v):
+			valid = false
+		case legacyTLSProtocols[v]:
+			diags = append(diags, ruleDiag(RuleTLSProtocol, arg.Range(), "protocol %q is not supported by Caddy; the minimum is %s", v, tlsProtocolNames[0]))
+			valid = false
+		case tlsProtocols[v] == 0:
+			diags = append(diags, ruleDiag(RuleTLSProtocol, arg.Range(), "unrecognized TLS protocol %q (expected %s)%s", v, strings.Join(tlsProtocolNames, " or "), didYouMean(v, tlsProtocolNames)))
+			valid = false
+		}
+	}
+	for _, extra := range d.Args[min(len(d.Args), 2):] {
+		diags = append(diags, warningf(extra.Range(), "unexpected argument %q: protocols takes <min> [<max>]", extra.Token.Value))
+	}
+	if valid && len(d.Args) >= 2 && tlsProtocols[d.Args[0].Token.Value] > tlsProtocols[d.Args[1].Token.Value] {
+		diags = append(diags, ruleDiag(RuleTLSProtocol, d.Args[1].Range(), "maximum protocol %s is lower than minimum %s", d.Args[1].Token.Value, d.Args[0].Token.Value))
+	}
+	return diags
+}
+
+// analyzeTLSValues checks that every argument of a list-valued tls option is
+// one of allowed. what names the kind of value in messages.
+func analyzeTLSValues(d *parser.Directive, what string, allowed []string) []protocol.Diagnostic {
+	if len(d.Args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "%s requires at least one %s", d.Name.Value, what)}
+	}
+	var diags []protocol.Diagnostic
+	for _, arg := range d.Args {
+		diags = append(diags, checkOneOf(arg.Token, what, allowed)...)
+	}
+	return diags
+}
@@ -0,0 +1,120 @@
+package analysis
+
+import "testing"
+
+// tlsWith wraps lines in a tls block inside a site block.
+func tlsWith(lines string) string {
+	return "example.com {\n\ttls {\n" + lines + "\t}\n}\n"
+}
+
+func TestAnalyze_TLSValues_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\t\tprotocols tls1.2\n",
+		"\t\tprotocols tls1.2 tls1.3\n",
+		"\t\tcurves x25519 secp256r1 x25519mlkem768\n",
+		"\t\tciphers TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384 TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256\n",
+		"\t\tprotocols {$TLS_MIN}\n",
+	}
+	for _, lines := range cases {
+		if diags := analyze(tlsWith(lines)); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", lines, diags)
+		}
+	}
+}
+
+func TestAnalyze_TLSProtocols_MinGreaterThanMax(t *testing.T) {
+	diags := analyze(tlsWith("\t\tprotocols tls1.3 tls1.2\n"))
+	if len(diags) != 1 || !hasMsg(diags, "lower than minimum") {
+		t.Fatalf("expected min/max diagnostic, got %v", diags)
+	}
+	if diags[0].Range.Start.Character != 19 {
+		t.Errorf("diagnostic should point at the max argument, got %v", diags[0].Range)
+	}
+}
+
+func TestAnalyze_TLSProtocols_LegacyAndUnknown(t *testing.T) {
+	cases := map[string]string{
+		"\t\tprotocols tls1.0\n":               "not supported by Caddy; the minimum is tls1.2",
+		"\t\tprotocols tls13\n":                `did you mean "tls1.3"`,
+		"\t\tprotocols ssl\n":                  "(expected tls1.2 or tls1.3)",
+		"\t\tprotocols\n":                      "requires one or two arguments",
+		"\t\tprotocols tls1.2 tls1.3 tls1.3\n": "unexpected argument",
+	}
+	for lines, want := range cases {
+		if diags := analyze(tlsWith(lines)); !hasMsg(diags, want) {
+			t.Errorf("%q: expected diagnostic containing %q, got %v", lines, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_TLSCurves_Unknown(t *testing.T) {
+	diags := analyze(tlsWith("\t\tcurves x25519 secp256k1\n"))
+	if len(diags) != 1 || !hasMsg(diags, "unrecognized curve", `"secp256k1"`, `did you mean "secp256r1"`) {
+		t.Errorf("expected unknown curve diagnostic, got %v", diags)
+	}
+}
+
+func TestAnalyze_TLSCiphers_TypoSuggestion(t *testing.T) {
+	cases := map[string]string{
+		"TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA348": "TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384",
+		"tls_ecdhe_rsa_with_aes_128_gcm_sha256":   "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+		"ECDHE_RSA_WITH_AES_128_GCM_SHA256":       "TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256",
+	}
+	for typo, want := range cases {
+		diags := analyze(tlsWith("\t\tciphers " + typo + "\n"))
+		if len(diags) != 1 || !hasMsg(diags, "unrecognized cipher suite", `did you mean "`+want+`"`) {
+			t.Errorf("%s: expected suggestion %s, got %v", typo, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_TLSArgs_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"example.com {\n\ttls internal\n}\n",
+		"example.com {\n\ttls force_automate\n}\n",
+		"example.com {\n\ttls admin@example.com\n}\n",
+		"example.com {\n\ttls {$ACME_EMAIL}\n}\n",
+		"example.com {\n\ttls cert.pem key.pem\n}\n",
+		"example.com {\n\ttls /etc/ssl/site.crt /etc/ssl/site.key\n}\n",
+		"example.com {\n\ttls admin@example.com {\n\t\tca https://acme.example.com/directory\n\t}\n}\n",
+		"example.com {\n\ttls {\n\t\tissuer internal\n\t}\n}\n",
+		"example.com {\n\ttls internal {\n\t\ton_demand\n\t}\n}\n",
+	}
+	for _, src := range cases {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_TLSArgs_Invalid(t *testing.T) {
+	cases := map[string]string{
+		"example.com {\n\ttls off\n}\n":                    "Caddy 1 syntax",
+		"example.com {\n\ttls interal\n}\n":                `did you mean "internal"`,
+		"example.com {\n\ttls a@b@c\n}\n":                  "not a valid email address",
+		"example.com {\n\ttls cert.pem key.pem extra\n}\n": `unexpected argument "extra"`,
+		"example.com {\n\ttls site.key site.crt\n}\n":      "look swapped",
+		"example.com {\n\ttls site.key site.pem\n}\n":      "looks like a private key",
+		"example.com {\n\ttls site.pem site.crt\n}\n":      "looks like a certificate",
+	}
+	for src, want := range cases {
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected diagnostic containing %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestAnalyze_TLSArgs_ConflictingSubdirectives(t *testing.T) {
+	cases := map[string]string{
+		"example.com {\n\ttls internal {\n\t\tdns cloudflare {env.CF_TOKEN}\n\t}\n}\n": "cannot be combined with `tls internal`",
+		"example.com {\n\ttls internal {\n\t\tissuer acme\n\t}\n}\n":                   "use `issuer internal` instead",
+		"example.com {\n\ttls admin@example.com {\n\t\tissuer acme\n\t}\n}\n":          "cannot be combined with an email argument",
+		"example.com {\n\ttls {\n\t\tissuer acme\n\t\tca_root root.pem\n\t}\n}\n":      "ca_root cannot be combined with issuer",
+	}
+	for src, want := range cases {
+		diags := analyze(src)
+		if len(diags) != 1 || !hasMsg(diags, want) {
+			t.Errorf("%q: expected one diagnostic containing %q, got %v", src, want, diags)
+		}
+	}
+}
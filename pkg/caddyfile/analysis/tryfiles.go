@@ -0,0 +1,41 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// tryPolicies are the values accepted by `try_files … { policy <x> }`.
+var tryPolicies = []string{
+	"first_exist", "first_exist_fallback", "smallest_size", "largest_size", "most_recently_modified",
+}
+
+// analyzeTryFiles checks that try_files lists at least one file and that its
+// optional policy is one Caddy recognizes.
+func analyzeTryFiles(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	if len(d.Args) == 0 {
+		diags = append(diags, warningf(d.Name.Range(), "try_files requires at least one file to try"))
+	}
+
+	var seenPolicy bool
+	for _, sub := range d.Body {
+		if sub.Name.Value != "policy" {
+			continue // unknown names are reported by analyzeDirectiveBody
+		}
+		if seenPolicy {
+			diags = append(diags, warningf(sub.Name.Range(), "try policy already configured"))
+		}
+		seenPolicy = true
+		if len(sub.Args) == 0 {
+			diags = append(diags, warningf(sub.Name.Range(), "policy requires a value: one of %s", joinQuoted(tryPolicies)))
+			continue
+		}
+		diags = append(diags, checkOneOf(sub.Args[0].Token, "try policy", tryPolicies)...)
+		for _, extra := range sub.Args[1:] {
+			diags = append(diags, warningf(extra.Range(), "unexpected argument %q: policy takes a single value", extra.Token.Value))
+		}
+	}
+	return diags
+}
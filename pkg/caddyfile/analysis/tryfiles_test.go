@@ -0,0 +1,33 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_TryFiles_ValidPolicy_NoWarning(t *testing.T) {
+	for _, policy := range tryPolicies {
+		src := "example.com {\n\ttry_files {path} /index.html {\n\t\tpolicy " + policy + "\n\t}\n}\n"
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("policy %s: expected no diagnostics, got %v", policy, diags)
+		}
+	}
+}
+
+func TestAnalyze_TryFiles_UnknownPolicy_Suggests(t *testing.T) {
+	diags := analyze("example.com {\n\ttry_files {path} {\n\t\tpolicy first_exists\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "unrecognized try policy", `"first_exists"`, `did you mean "first_exist"`) {
+		t.Errorf("expected policy diagnostic with suggestion, got %v", diags)
+	}
+}
+
+func TestAnalyze_TryFiles_MissingPolicyValueAndFiles(t *testing.T) {
+	diags := analyze("example.com {\n\ttry_files {\n\t\tpolicy\n\t}\n}\n")
+	if !hasMsg(diags, "at least one file") || !hasMsg(diags, "policy requires a value") {
+		t.Errorf("expected missing files and policy value diagnostics, got %v", diags)
+	}
+}
+
+func TestAnalyze_TryFiles_UnknownSubdirective(t *testing.T) {
+	diags := analyze("example.com {\n\ttry_files {path} {\n\t\tpolcy first_exist\n\t}\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "unknown subdirective", `"polcy"`) {
+		t.Errorf("expected unknown subdirective diagnostic, got %v", diags)
+	}
+}
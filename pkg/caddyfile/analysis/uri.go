@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strconv"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// uriOperations are the manipulations accepted by the uri directive.
+var uriOperations = []string{"strip_prefix", "strip_suffix", "replace", "path_regexp", "query"}
+
+// analyzeURI validates `uri [<matcher>] <operation> <args...>`: the operation
+// name, the number of arguments it takes, and the integer limit of replace.
+func analyzeURI(d *parser.Directive) []protocol.Diagnostic {
+	args := d.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	if len(args) == 0 {
+		return []protocol.Diagnostic{warningf(d.Name.Range(), "uri requires an operation: one of %s", joinQuoted(uriOperations))}
+	}
+
+	op := args[0]
+	if diags := checkOneOf(op.Token, "URI manipulation", uriOperations); len(diags) > 0 {
+		return diags
+	}
+
+	var diags []protocol.Diagnostic
+	if op.Token.Value != "query" && len(d.Body) > 0 {
+		diags = append(diags, warningf(op.Range(), "only uri query accepts a block"))
+	}
+
+	// Allowed number of arguments after the operation name.
+	minArgs, maxArgs, usage := 0, 0, ""
+	switch op.Token.Value {
+	case "strip_prefix", "strip_suffix":
+		minArgs, maxArgs, usage = 1, 1, "<target>"
+	case "replace":
+		minArgs, maxArgs, usage = 2, 3, "<find> <replace> [<limit>]"
+	case "path_regexp":
+		minArgs, maxArgs, usage = 2, 2, "<find> <replace>"
+	case "query":
+		minArgs, maxArgs, usage = 0, 3, "[<key> [<value>] [<replacement>]]"
+	}
+	rest := args[1:]
+	if len(rest) < minArgs {
+		diags = append(diags, warningf(op.Range(), "uri %s expects %s", op.Token.Value, usage))
+	}
+	for _, extra := range rest[min(len(rest), maxArgs):] {
+		diags = append(diags, warningf(extra.Range(), "unexpected argument %q: uri %s expects %s", extra.Token.Value, op.Token.Value, usage))
+	}
+	if op.Token.Value == "replace" && len(rest) == 3 {
+		limit := rest[2].Token
+		if _, err := strconv.Atoi(limit.Value); err != nil && !isCaddyPlaceholder(limit.Value) {
+			diags = append(diags, warningf(limit.Range(), "replace limit must be an integer, got %q", limit.Value))
+		}
+	}
+	return diags
+}
@@ -0,0 +1,47 @@
+package analysis
+
+import "testing"
+
+func TestAnalyze_URI_ValidOperations_NoWarning(t *testing.T) {
+	cases := []string{
+		"example.com {\n\turi strip_prefix /api\n}\n",
+		"example.com {\n\turi /old/* strip_suffix .html\n}\n",
+		"example.com {\n\turi replace /a /b\n}\n",
+		"example.com {\n\turi replace /a /b 1\n}\n",
+		"example.com {\n\turi path_regexp ^/x/(.*) /y/$1\n}\n",
+		"example.com {\n\turi query +foo bar\n}\n",
+		"example.com {\n\turi query {\n\t\t-debug\n\t\tfoo bar\n\t}\n}\n",
+	}
+	for _, src := range cases {
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_URI_Typo_Suggests(t *testing.T) {
+	diags := analyze("example.com {\n\turi strip_perfix /api\n}\n")
+	if len(diags) != 1 || !hasMsg(diags, "unrecognized URI manipulation", `did you mean "strip_prefix"`) {
+		t.Fatalf("expected typo diagnostic, got %v", diags)
+	}
+	if diags[0].Range.Start.Character != 5 {
+		t.Errorf("diagnostic should point at the operation, got %v", diags[0].Range)
+	}
+}
+
+func TestAnalyze_URI_ArgumentCounts(t *testing.T) {
+	cases := map[string]string{
+		"example.com {\n\turi strip_prefix\n}\n":                    "expects <target>",
+		"example.com {\n\turi strip_prefix /a /b\n}\n":              `unexpected argument "/b"`,
+		"example.com {\n\turi replace /a\n}\n":                      "expects <find> <replace>",
+		"example.com {\n\turi replace /a /b many\n}\n":              "limit must be an integer",
+		"example.com {\n\turi path_regexp a b c\n}\n":               `unexpected argument "c"`,
+		"example.com {\n\turi\n}\n":                                 "requires an operation",
+		"example.com {\n\turi strip_prefix /a {\n\t\tfoo\n\t}\n}\n": "only uri query accepts a block",
+	}
+	for src, want := range cases {
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected diagnostic containing %q, got %v", src, want, diags)
+		}
+	}
+}
@@ -0,0 +1,100 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"sort"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// `vars` is both a directive that sets variables and a request matcher that
+// tests them:
+//
+//	vars [<matcher>] [<name> <value>] {
+//		<name> <value>
+//	}
+//
+//	@debug vars {debug} on
+//
+// Variables set by the directive are read back as {vars.<name>} placeholders
+// or by name in the matcher.
+
+// analyzeVars checks that the directive form sets name/value pairs: inline
+// arguments after the matcher and each body line must come in pairs.
+func analyzeVars(d *parser.Directive) []protocol.Diagnostic {
+	var diags []protocol.Diagnostic
+	args := d.Args
+	if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+		args = args[1:]
+	}
+	diags = append(diags, checkVarPairs(args)...)
+	if len(args) == 0 && len(d.Body) == 0 {
+		diags = append(diags, warningf(d.Name.Range(), "vars requires a <name> <value> pair or a block of them"))
+	}
+	for _, sub := range d.Body {
+		pair := append([]*parser.Argument{{Token: sub.Name}}, sub.Args...)
+		diags = append(diags, checkVarPairs(pair)...)
+	}
+	return diags
+}
+
+// checkVarPairs reports an odd number of name/value tokens, pointing at the
+// name left without a value.
+func checkVarPairs(args []*parser.Argument) []protocol.Diagnostic {
+	if len(args)%2 == 0 {
+		return nil
+	}
+	last := args[len(args)-1].Token
+	return []protocol.Diagnostic{warningf(last.Range(), "vars expects <name> <value> pairs: variable %q has no value", last.Value)}
+}
+
+// analyzeVarsMatcher checks one `vars` or `vars_regexp` line of a named
+// matcher. args excludes the matcher type itself; problems are reported on
+// the type token.
+func analyzeVarsMatcher(typ parser.Token, args []*parser.Argument) []protocol.Diagnostic {
+	switch typ.Value {
+	case "vars":
+		if len(args) < 2 {
+			return []protocol.Diagnostic{warningf(typ.Range(), "vars matcher requires a variable and at least one value")}
+		}
+	case "vars_regexp":
+		if len(args) < 2 || len(args) > 3 {
+			return []protocol.Diagnostic{warningf(typ.Range(), "vars_regexp matcher expects [<name>] <variable> <regexp>, got %d argument(s)", len(args))}
+		}
+	}
+	return nil
+}
+
+// VarNames returns the names of the variables set by `vars` directives in f,
+// sorted and without duplicates.
+func VarNames(f *parser.File) []string {
+	seen := make(map[string]bool)
+	var walk func(ds []*parser.Directive)
+	walk = func(ds []*parser.Directive) {
+		for _, d := range ds {
+			if d.Name.Value != "vars" {
+				walk(d.Body)
+				continue
+			}
+			args := d.Args
+			if len(args) > 0 && isMatcherToken(args[0].Token.Value) {
+				args = args[1:]
+			}
+			for i := 0; i+1 < len(args); i += 2 {
+				seen[args[i].Token.Value] = true
+			}
+			for _, sub := range d.Body {
+				seen[sub.Name.Value] = true
+			}
+		}
+	}
+	for _, sb := range f.SiteBlocks {
+		walk(sb.Directives)
+	}
+	names := make([]string, 0, len(seen))
+	for name := range seen {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,49 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"reflect"
+	"testing"
+)
+
+func TestAnalyze_Vars_Valid_NoWarning(t *testing.T) {
+	cases := []string{
+		"\tvars debug on\n",
+		"\tvars @api upstream api:8080\n",
+		"\tvars {\n\t\tdebug on\n\t\tregion eu\n\t}\n",
+		"\t@debug vars {debug} on\n\trespond @debug \"debug\"\n",
+		"\t@debug {\n\t\tvars {vars.debug} on yes\n\t\tvars_regexp dbg {debug} ^on$\n\t}\n\trespond @debug \"debug\"\n",
+	}
+	for _, lines := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); len(diags) != 0 {
+			t.Errorf("%q: expected no diagnostics, got %v", src, diags)
+		}
+	}
+}
+
+func TestAnalyze_Vars_Problems(t *testing.T) {
+	cases := map[string]string{
+		"\tvars\n":            "vars requires a <name> <value> pair or a block of them",
+		"\tvars debug\n":      `variable "debug" has no value`,
+		"\tvars @api a 1 b\n": `variable "b" has no value`,
+		"\tvars {\n\t\tdebug on\n\t\tregion\n\t}\n": `variable "region" has no value`,
+		"\t@m vars {debug}\n":                       "vars matcher requires a variable and at least one value",
+		"\t@m {\n\t\tvars\n\t}\n":                   "vars matcher requires a variable and at least one value",
+		"\t@m vars_regexp {debug}\n":                "vars_regexp matcher expects [<name>] <variable> <regexp>, got 1 argument(s)",
+	}
+	for lines, want := range cases {
+		src := "example.com {\n" + lines + "}\n"
+		if diags := analyze(src); !hasMsg(diags, want) {
+			t.Errorf("%q: expected %q, got %v", src, want, diags)
+		}
+	}
+}
+
+func TestVarNames(t *testing.T) {
+	src := "example.com {\n\tvars debug on\n\thandle {\n\t\tvars @api {\n\t\t\tregion eu\n\t\t\tdebug off\n\t\t}\n\t}\n\t@m vars {x} y\n}\n"
+	f, _ := parser.Parse(src)
+	if got, want := VarNames(f), []string{"debug", "region"}; !reflect.DeepEqual(got, want) {
+		t.Errorf("VarNames = %v, want %v", got, want)
+	}
+}
@@ -0,0 +1,130 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"fmt"
+	"strconv"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// RuleCaddyVersion is the code of the rule flagging directives that the
+// targeted Caddy version does not have, or has deprecated.
+const RuleCaddyVersion = "caddy-version"
+
+// DirectiveVersion is the release history of a directive: the Caddy
+// versions, such as "2.8", that added, deprecated or removed it. Empty
+// fields mean the directive has been there since 2.0, is not deprecated or
+// was not removed.
+type DirectiveVersion struct {
+	Since      string `json:"since,omitempty"`
+	Deprecated string `json:"deprecated,omitempty"`
+	Removed    string `json:"removed,omitempty"`
+	// Replacement is the directive to use instead of a deprecated or
+	// removed one.
+	Replacement string `json:"replacement,omitempty"`
+}
+
+// directiveVersions holds the release history of the directives added
+// after Caddy 2.4 or deprecated since.
+// Source: https://github.com/caddyserver/caddy/releases
+var directiveVersions = map[string]DirectiveVersion{
+	"copy_response":         {Since: "2.5"},
+	"copy_response_headers": {Since: "2.5"},
+	"forward_auth":          {Since: "2.5"},
+	"tracing":               {Since: "2.5"},
+	"invoke":                {Since: "2.7"},
+	"basic_auth":            {Since: "2.8"},
+	"basicauth":             {Deprecated: "2.8", Replacement: "basic_auth"},
+	"fs":                    {Since: "2.8"},
+	"intercept":             {Since: "2.8"},
+	"log_name":              {Since: "2.8"},
+	"log_skip":              {Since: "2.8"},
+	"skip_log":              {Deprecated: "2.8", Replacement: "log_skip"},
+	"log_append":            {Since: "2.9"},
+}
+
+// DirectiveVersionOf returns the release history of the directive name, if
+// it changed after Caddy 2.4.
+func DirectiveVersionOf(name string) (DirectiveVersion, bool) {
+	v, ok := directiveVersions[name]
+	return v, ok
+}
+
+// Note describes v in a sentence or two of Markdown, such as "Available
+// since Caddy v2.8.", for documentation.
+func (v DirectiveVersion) Note() string {
+	var notes []string
+	if v.Since != "" {
+		notes = append(notes, fmt.Sprintf("Available since Caddy v%s.", v.Since))
+	}
+	switch {
+	case v.Removed != "":
+		notes = append(notes, fmt.Sprintf("Removed in Caddy v%s%s.", v.Removed, v.instead("`")))
+	case v.Deprecated != "":
+		notes = append(notes, fmt.Sprintf("Deprecated since Caddy v%s%s.", v.Deprecated, v.instead("`")))
+	}
+	return strings.Join(notes, " ")
+}
+
+// instead suggests the replacement of v, if any, quoted with quote.
+func (v DirectiveVersion) instead(quote string) string {
+	if v.Replacement == "" {
+		return ""
+	}
+	return fmt.Sprintf("; use %s%s%s instead", quote, v.Replacement, quote)
+}
+
+// compareVersions compares two Caddy versions such as "2.8", "v2.8.4" or
+// "2.10" numerically, returning -1, 0 or +1. Missing components count as 0.
+func compareVersions(a, b string) int {
+	pa, pb := versionParts(a), versionParts(b)
+	for i := range max(len(pa), len(pb)) {
+		var x, y int
+		if i < len(pa) {
+			x = pa[i]
+		}
+		if i < len(pb) {
+			y = pb[i]
+		}
+		switch {
+		case x < y:
+			return -1
+		case x > y:
+			return 1
+		}
+	}
+	return 0
+}
+
+func versionParts(v string) []int {
+	var parts []int
+	for _, p := range strings.Split(strings.TrimPrefix(v, "v"), ".") {
+		n, _ := strconv.Atoi(p)
+		parts = append(parts, n)
+	}
+	return parts
+}
+
+// checkDirectiveVersion reports a directive that the target Caddy version
+// of the options does not have yet, no longer has, or has deprecated.
+// Nothing is reported without a target version.
+func (a *analyzer) checkDirectiveVersion(name parser.Token) []protocol.Diagnostic {
+	target := a.opts.CaddyVersion
+	v, ok := directiveVersions[name.Value]
+	if target == "" || !ok {
+		return nil
+	}
+	switch {
+	case v.Since != "" && compareVersions(target, v.Since) < 0:
+		return []protocol.Diagnostic{errorf(name.Range(), "%s requires Caddy %s or newer, but the Caddyfile targets Caddy %s", name.Value, v.Since, strings.TrimPrefix(target, "v"))}
+	case v.Removed != "" && compareVersions(target, v.Removed) >= 0:
+		return []protocol.Diagnostic{errorf(name.Range(), "%s was removed in Caddy %s%s", name.Value, v.Removed, v.instead(""))}
+	case v.Deprecated != "" && compareVersions(target, v.Deprecated) >= 0:
+		diag := warningf(name.Range(), "%s is deprecated since Caddy %s%s", name.Value, v.Deprecated, v.instead(""))
+		diag.Tags = []protocol.DiagnosticTag{protocol.DiagnosticTagDeprecated}
+		return []protocol.Diagnostic{diag}
+	}
+	return nil
+}
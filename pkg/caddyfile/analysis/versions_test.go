@@ -0,0 +1,78 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func analyzeFor(version, src string) []protocol.Diagnostic {
+	f, _ := parser.Parse(src)
+	return AnalyzeWith(f, Options{CaddyVersion: version})
+}
+
+func TestCompareVersions(t *testing.T) {
+	for _, tc := range []struct {
+		a, b string
+		want int
+	}{
+		{"2.8", "2.8", 0},
+		{"v2.8.4", "2.8", 1},
+		{"2.7.6", "2.8", -1},
+		{"2.10", "2.9", 1},
+	} {
+		if got := compareVersions(tc.a, tc.b); got != tc.want {
+			t.Errorf("compareVersions(%q, %q) = %d, want %d", tc.a, tc.b, got, tc.want)
+		}
+	}
+}
+
+func TestAnalyze_DirectiveVersion(t *testing.T) {
+	src := "example.com {\n\tfs assets\n\tbasicauth {\n\t\tbob $2a$14$hash\n\t}\n}\n"
+	if diags := analyzeFor("", src); hasMsg(diags, "Caddy 2.8") {
+		t.Errorf("no target version: got %v", diags)
+	}
+
+	diags := analyzeFor("v2.7.6", src)
+	if !hasMsg(diags, "fs requires Caddy 2.8 or newer", "targets Caddy 2.7.6") {
+		t.Errorf("2.7: missing fs diagnostic in %v", diags)
+	}
+	if hasMsg(diags, "deprecated") {
+		t.Errorf("2.7: basicauth is not deprecated yet: %v", diags)
+	}
+
+	diags = analyzeFor("2.8", src)
+	if hasMsg(diags, "requires Caddy") {
+		t.Errorf("2.8: fs is available: %v", diags)
+	}
+	found := false
+	for _, d := range diags {
+		if d.Message == "basicauth is deprecated since Caddy 2.8; use basic_auth instead" {
+			found = len(d.Tags) == 1 && d.Tags[0] == protocol.DiagnosticTagDeprecated
+		}
+	}
+	if !found {
+		t.Errorf("2.8: missing tagged basicauth deprecation in %v", diags)
+	}
+}
+
+func TestDirectiveVersion_Note(t *testing.T) {
+	for _, tc := range []struct {
+		v    DirectiveVersion
+		want string
+	}{
+		{DirectiveVersion{Since: "2.8"}, "Available since Caddy v2.8."},
+		{DirectiveVersion{Deprecated: "2.8", Replacement: "log_skip"}, "Deprecated since Caddy v2.8; use `log_skip` instead."},
+		{DirectiveVersion{Since: "2.5", Removed: "2.9"}, "Available since Caddy v2.5. Removed in Caddy v2.9."},
+	} {
+		if got := tc.v.Note(); got != tc.want {
+			t.Errorf("%+v: got %q, want %q", tc.v, got, tc.want)
+		}
+	}
+	for name := range directiveVersions {
+		if !KnownTopLevel[name] && !responseOnlyDirectives[name] {
+			t.Errorf("%s has a version but is not a directive", name)
+		}
+	}
+}
@@ -0,0 +1,156 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// Codes of the whitespace rules. These rules are opt-in: Caddy ignores
+// layout, but teams that keep Caddyfiles in git often want it consistent.
+const (
+	RuleMixedIndentation   = "mixed-indentation"
+	RuleTrailingWhitespace = "trailing-whitespace"
+	RuleFinalNewline       = "final-newline"
+)
+
+// WhitespaceRules lists the codes of the whitespace rules.
+var WhitespaceRules = []string{RuleMixedIndentation, RuleTrailingWhitespace, RuleFinalNewline}
+
+// Fix is a diagnostic together with the edit that resolves it.
+type Fix struct {
+	Diagnostic protocol.Diagnostic
+	Title      string
+	Edit       protocol.TextEdit
+	// Also holds further edits made together with Edit, elsewhere in the
+	// document.
+	Also []protocol.TextEdit
+	// Alternative marks a fix offered besides the preferred one for the
+	// same diagnostic.
+	Alternative bool
+}
+
+// AnalyzeWhitespace checks the layout of src, whose parse is f, against the
+// whitespace rules enabled in rules, keyed by rule code. Lines inside
+// multi-line strings and heredocs are left alone since their whitespace is
+// part of a value.
+func AnalyzeWhitespace(src string, f *parser.File, rules map[string]bool) []Fix {
+	var fixes []Fix
+	lines := strings.Split(src, "\n")
+	verbatim := parser.VerbatimLines(src)
+	if rules[RuleMixedIndentation] {
+		fixes = append(fixes, mixedIndentation(f, lines, verbatim)...)
+	}
+	if rules[RuleTrailingWhitespace] {
+		fixes = append(fixes, trailingWhitespace(lines, verbatim)...)
+	}
+	if rules[RuleFinalNewline] && src != "" && !strings.HasSuffix(src, "\n") {
+		last := len(lines) - 1
+		end := protocol.Position{Line: uint32(last), Character: parser.UTF16Len(lines[last])}
+		fixes = append(fixes, Fix{
+			Diagnostic: ruleDiag(RuleFinalNewline, protocol.Range{Start: end, End: end}, "missing newline at end of file"),
+			Title:      "Add final newline",
+			Edit:       protocol.TextEdit{Range: protocol.Range{Start: end, End: end}, NewText: "\n"},
+		})
+	}
+	return fixes
+}
+
+// trailingWhitespace flags spaces and tabs at the end of lines. A carriage
+// return ending the line is not counted.
+func trailingWhitespace(lines []string, verbatim map[uint32]bool) []Fix {
+	var fixes []Fix
+	for i, line := range lines {
+		line = strings.TrimSuffix(line, "\r")
+		trimmed := strings.TrimRight(line, " \t")
+		if len(trimmed) == len(line) || verbatim[uint32(i)] {
+			continue
+		}
+		start := parser.UTF16Len(trimmed)
+		rng := protocol.Range{
+			Start: protocol.Position{Line: uint32(i), Character: start},
+			End:   protocol.Position{Line: uint32(i), Character: start + uint32(len(line)-len(trimmed))},
+		}
+		fixes = append(fixes, Fix{
+			Diagnostic: ruleDiag(RuleTrailingWhitespace, rng, "trailing whitespace"),
+			Title:      "Remove trailing whitespace",
+			Edit:       protocol.TextEdit{Range: rng},
+		})
+	}
+	return fixes
+}
+
+// mixedIndentation flags directives whose indentation mixes tabs and spaces,
+// or uses a different one than the rest of their block. A block's style is
+// set by its first directive indented with only tabs or only spaces, and the
+// fix copies that directive's indentation, as siblings share a depth.
+func mixedIndentation(f *parser.File, lines []string, verbatim map[uint32]bool) []Fix {
+	var fixes []Fix
+	var block func(ds []*parser.Directive)
+	block = func(ds []*parser.Directive) {
+		ref := ""
+		for _, d := range ds {
+			if indent, ok := directiveIndent(d, lines, verbatim); ok && indentStyle(indent) != "" && indentStyle(indent) != "mixed" {
+				ref = indent
+				break
+			}
+		}
+		for _, d := range ds {
+			indent, ok := directiveIndent(d, lines, verbatim)
+			if !ok || ref == "" || indent == "" || indentStyle(indent) == indentStyle(ref) {
+				block(d.Body)
+				continue
+			}
+			msg := "indentation mixes tabs and spaces"
+			if style := indentStyle(indent); style != "mixed" {
+				msg = "indented with " + style + " but the rest of the block uses " + indentStyle(ref)
+			}
+			rng := protocol.Range{
+				Start: protocol.Position{Line: d.Name.Line},
+				End:   protocol.Position{Line: d.Name.Line, Character: uint32(len(indent))},
+			}
+			fixes = append(fixes, Fix{
+				Diagnostic: ruleDiag(RuleMixedIndentation, rng, "%s", msg),
+				Title:      "Indent with " + indentStyle(ref),
+				Edit:       protocol.TextEdit{Range: rng, NewText: ref},
+			})
+			block(d.Body)
+		}
+	}
+	if f.GlobalBlock != nil {
+		block(f.GlobalBlock.Directives)
+	}
+	for _, sb := range f.SiteBlocks {
+		block(sb.Directives)
+	}
+	return fixes
+}
+
+// directiveIndent returns the whitespace before d's name when the name
+// starts its line.
+func directiveIndent(d *parser.Directive, lines []string, verbatim map[uint32]bool) (string, bool) {
+	if int(d.Name.Line) >= len(lines) || verbatim[d.Name.Line] {
+		return "", false
+	}
+	line := lines[d.Name.Line]
+	if int(d.Name.Char) > len(line) {
+		return "", false
+	}
+	indent := line[:d.Name.Char]
+	return indent, strings.Trim(indent, " \t") == ""
+}
+
+// indentStyle names the characters indent is made of: "tabs", "spaces",
+// "mixed", or "" when it is empty.
+func indentStyle(indent string) string {
+	switch {
+	case indent == "":
+		return ""
+	case strings.Trim(indent, "\t") == "":
+		return "tabs"
+	case strings.Trim(indent, " ") == "":
+		return "spaces"
+	}
+	return "mixed"
+}
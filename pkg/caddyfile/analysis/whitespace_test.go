@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"caddy-ls/pkg/caddyfile/parser"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func whitespaceFixes(src string, rules ...string) []Fix {
+	f, _ := parser.Parse(src)
+	enabled := make(map[string]bool)
+	for _, r := range rules {
+		enabled[r] = true
+	}
+	return AnalyzeWhitespace(src, f, enabled)
+}
+
+func TestAnalyzeWhitespace_OffByDefault(t *testing.T) {
+	src := "example.com {\n\troot * /srv  \n    file_server\n}"
+	if fixes := whitespaceFixes(src); len(fixes) != 0 {
+		t.Errorf("whitespace rules should be opt-in, got %+v", fixes)
+	}
+}
+
+func TestAnalyzeWhitespace_TrailingWhitespace(t *testing.T) {
+	src := "example.com {  \r\n\trespond <<EOF\n\t\tkept  \n\t\tEOF 200\n}\n"
+	fixes := whitespaceFixes(src, RuleTrailingWhitespace)
+	if len(fixes) != 1 {
+		t.Fatalf("want only the line outside the heredoc flagged, got %+v", fixes)
+	}
+	want := protocol.Range{Start: protocol.Position{Line: 0, Character: 13}, End: protocol.Position{Line: 0, Character: 15}}
+	if fixes[0].Edit.Range != want || fixes[0].Edit.NewText != "" {
+		t.Errorf("edit = %+v, want deletion of %+v", fixes[0].Edit, want)
+	}
+	if fixes[0].Diagnostic.Code == nil || fixes[0].Diagnostic.Code.Value != RuleTrailingWhitespace {
+		t.Errorf("code = %+v", fixes[0].Diagnostic.Code)
+	}
+}
+
+func TestAnalyzeWhitespace_FinalNewline(t *testing.T) {
+	fixes := whitespaceFixes("example.com {\n}", RuleFinalNewline)
+	if len(fixes) != 1 || fixes[0].Edit.NewText != "\n" || fixes[0].Edit.Range.Start != (protocol.Position{Line: 1, Character: 1}) {
+		t.Fatalf("fixes = %+v", fixes)
+	}
+	if fixes := whitespaceFixes("example.com {\n}\n", RuleFinalNewline); len(fixes) != 0 {
+		t.Errorf("terminated file flagged: %+v", fixes)
+	}
+}
+
+func TestAnalyzeWhitespace_NonASCIILine(t *testing.T) {
+	src := "example.com {\n\trespond \"h\u00e9llo\"  \n}\n# \U0001F600"
+	fixes := whitespaceFixes(src, RuleTrailingWhitespace, RuleFinalNewline)
+	if len(fixes) != 2 {
+		t.Fatalf("want 2 fixes, got %+v", fixes)
+	}
+	want := protocol.Range{Start: protocol.Position{Line: 1, Character: 16}, End: protocol.Position{Line: 1, Character: 18}}
+	if got := fixes[0].Edit.Range; got != want {
+		t.Errorf("trailing whitespace range = %+v, want %+v", got, want)
+	}
+	if got := fixes[1].Edit.Range.Start; got != (protocol.Position{Line: 3, Character: 4}) {
+		t.Errorf("final newline at %+v, want 3:4", got)
+	}
+	got := applyEdit(applyEdit(src, fixes[1].Edit), fixes[0].Edit)
+	if want := "example.com {\n\trespond \"h\u00e9llo\"\n}\n# \U0001F600\n"; got != want {
+		t.Errorf("fixed source = %q, want %q", got, want)
+	}
+}
+
+func TestAnalyzeWhitespace_MixedIndentation(t *testing.T) {
+	src := "example.com {\n\troot * /srv\n    file_server\n\t \tencode gzip\n\thandle {\n\t\trespond ok\n\t    respond no\n\t}\n}\n"
+	fixes := whitespaceFixes(src, RuleMixedIndentation)
+	if len(fixes) != 3 {
+		t.Fatalf("want 3 fixes, got %+v", fixes)
+	}
+	wantMsgs := []string{
+		"indented with spaces but the rest of the block uses tabs",
+		"indentation mixes tabs and spaces",
+		"indentation mixes tabs and spaces",
+	}
+	wantLines := []uint32{2, 3, 6}
+	wantText := []string{"\t", "\t", "\t\t"}
+	for i, fix := range fixes {
+		if fix.Diagnostic.Message != wantMsgs[i] || fix.Diagnostic.Range.Start.Line != wantLines[i] || fix.Edit.NewText != wantText[i] {
+			t.Errorf("fix %d = %q on line %d replacing with %q", i, fix.Diagnostic.Message, fix.Diagnostic.Range.Start.Line, fix.Edit.NewText)
+		}
+	}
+}
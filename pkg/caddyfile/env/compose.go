@@ -0,0 +1,78 @@
+package env
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+
+	"go.yaml.in/yaml/v2"
+)
+
+// ParseCompose collects the variables declared in the environment sections
+// of every service in a docker-compose file. Both the list form
+// ("- KEY=value") and the map form ("KEY: value") are supported; entries
+// without a value map to "".
+func ParseCompose(src string) (map[string]string, error) {
+	var doc struct {
+		Services map[string]struct {
+			Environment any `yaml:"environment"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return nil, err
+	}
+	vars := make(map[string]string)
+	for _, svc := range doc.Services {
+		switch envs := svc.Environment.(type) {
+		case []any:
+			for _, item := range envs {
+				key, value, _ := strings.Cut(fmt.Sprint(item), "=")
+				if key != "" {
+					vars[key] = value
+				}
+			}
+		case map[any]any:
+			for k, v := range envs {
+				value := ""
+				if v != nil {
+					value = fmt.Sprint(v)
+				}
+				vars[fmt.Sprint(k)] = value
+			}
+		}
+	}
+	return vars, nil
+}
+
+// ParseComposeServices returns the sorted names of the services of a
+// docker-compose file, which are also their host names on the compose
+// network, along with the host names services set with `hostname`.
+func ParseComposeServices(src string) ([]string, error) {
+	var doc struct {
+		Services map[string]struct {
+			Hostname string `yaml:"hostname"`
+		} `yaml:"services"`
+	}
+	if err := yaml.Unmarshal([]byte(src), &doc); err != nil {
+		return nil, err
+	}
+	var names []string
+	for name, svc := range doc.Services {
+		names = append(names, name)
+		if svc.Hostname != "" && svc.Hostname != name {
+			names = append(names, svc.Hostname)
+		}
+	}
+	sort.Strings(names)
+	return names, nil
+}
+
+// IsComposeFile reports whether name looks like a docker-compose file.
+func IsComposeFile(name string) bool {
+	base := strings.ToLower(name)
+	if i := strings.LastIndexAny(base, `/\`); i >= 0 {
+		base = base[i+1:]
+	}
+	return (strings.HasPrefix(base, "docker-compose") || strings.HasPrefix(base, "compose")) &&
+		(strings.HasSuffix(base, ".yml") || strings.HasSuffix(base, ".yaml"))
+}
@@ -0,0 +1,144 @@
+// Package env resolves the {$VAR} environment placeholders that Caddy
+// substitutes when it loads a Caddyfile, using sources configured by the
+// user: .env files, explicit mappings and optionally the server's own
+// process environment.
+package env
+
+import (
+	"bufio"
+	"strings"
+)
+
+// Source is a named set of variables, e.g. the contents of one .env file.
+type Source struct {
+	Name string
+	Vars map[string]string
+}
+
+// Resolver looks variables up across an ordered list of sources. Earlier
+// sources take precedence over later ones.
+type Resolver struct {
+	sources []Source
+}
+
+// NewResolver returns a Resolver over sources, in precedence order.
+func NewResolver(sources ...Source) *Resolver {
+	return &Resolver{sources: sources}
+}
+
+// With returns a resolver that consults r's sources first and then extra.
+func (r *Resolver) With(extra ...Source) *Resolver {
+	var sources []Source
+	if r != nil {
+		sources = append(sources, r.sources...)
+	}
+	return &Resolver{sources: append(sources, extra...)}
+}
+
+// Empty reports whether no sources are configured. Undefined variables are
+// only worth reporting when at least one source is known.
+func (r *Resolver) Empty() bool {
+	return r == nil || len(r.sources) == 0
+}
+
+// Lookup returns the value of name and the name of the source that defined
+// it.
+func (r *Resolver) Lookup(name string) (value, source string, ok bool) {
+	if r == nil {
+		return "", "", false
+	}
+	for _, s := range r.sources {
+		if v, ok := s.Vars[name]; ok {
+			return v, s.Name, true
+		}
+	}
+	return "", "", false
+}
+
+// Names returns every variable name defined by any source, without
+// duplicates, in source order.
+func (r *Resolver) Names() []string {
+	if r == nil {
+		return nil
+	}
+	seen := make(map[string]bool)
+	var names []string
+	for _, s := range r.sources {
+		for name := range s.Vars {
+			if !seen[name] {
+				seen[name] = true
+				names = append(names, name)
+			}
+		}
+	}
+	return names
+}
+
+// ParseDotenv parses the KEY=VALUE lines of a .env file. Blank lines,
+// comments and an optional leading "export" are ignored; values may be
+// wrapped in single or double quotes. Malformed lines are skipped.
+func ParseDotenv(src string) map[string]string {
+	vars := make(map[string]string)
+	sc := bufio.NewScanner(strings.NewReader(src))
+	for sc.Scan() {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		line = strings.TrimPrefix(line, "export ")
+		key, value, ok := strings.Cut(line, "=")
+		key = strings.TrimSpace(key)
+		if !ok || key == "" || strings.ContainsAny(key, " \t") {
+			continue
+		}
+		vars[key] = unquote(strings.TrimSpace(value))
+	}
+	return vars
+}
+
+// unquote strips matching surrounding quotes from v, or a trailing
+// " # comment" from an unquoted value.
+func unquote(v string) string {
+	if len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		return v[1 : len(v)-1]
+	}
+	if i := strings.Index(v, " #"); i >= 0 {
+		return strings.TrimSpace(v[:i])
+	}
+	return v
+}
+
+// Ref is one {$NAME} or {$NAME:default} reference within a string. Start and
+// End are byte offsets of the whole placeholder, End exclusive.
+type Ref struct {
+	Name       string
+	Default    string
+	HasDefault bool
+	Start, End int
+}
+
+// FindRefs returns the environment placeholders in s, in order.
+func FindRefs(s string) []Ref {
+	var refs []Ref
+	for i := 0; i < len(s); {
+		open := strings.Index(s[i:], "{$")
+		if open < 0 {
+			break
+		}
+		open += i
+		end := strings.IndexByte(s[open:], '}')
+		if end < 0 {
+			break
+		}
+		end += open
+		ref := Ref{Name: s[open+2 : end], Start: open, End: end + 1}
+		if name, def, ok := strings.Cut(ref.Name, ":"); ok {
+			ref.Name, ref.Default, ref.HasDefault = name, def, true
+		}
+		if ref.Name != "" {
+			refs = append(refs, ref)
+		}
+		i = end + 1
+	}
+	return refs
+}
@@ -0,0 +1,135 @@
+package env
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestParseDotenv(t *testing.T) {
+	src := "# comment\n\nUPSTREAM=app:8080\nexport DOMAIN=example.com\nQUOTED=\"a b\"\nSINGLE='x'\nTRAILING=v # note\nbad line\n=novalue\n"
+	got := ParseDotenv(src)
+	want := map[string]string{
+		"UPSTREAM": "app:8080",
+		"DOMAIN":   "example.com",
+		"QUOTED":   "a b",
+		"SINGLE":   "x",
+		"TRAILING": "v",
+	}
+	if len(got) != len(want) {
+		t.Errorf("got %d vars %v, want %d", len(got), got, len(want))
+	}
+	for k, v := range want {
+		if got[k] != v {
+			t.Errorf("%s = %q, want %q", k, got[k], v)
+		}
+	}
+}
+
+func TestResolver_Precedence(t *testing.T) {
+	r := NewResolver(
+		Source{Name: "settings", Vars: map[string]string{"A": "1"}},
+		Source{Name: ".env", Vars: map[string]string{"A": "2", "B": "3"}},
+	)
+	if v, src, ok := r.Lookup("A"); !ok || v != "1" || src != "settings" {
+		t.Errorf("A = %q from %q (ok=%v), want 1 from settings", v, src, ok)
+	}
+	if v, src, ok := r.Lookup("B"); !ok || v != "3" || src != ".env" {
+		t.Errorf("B = %q from %q (ok=%v), want 3 from .env", v, src, ok)
+	}
+	if _, _, ok := r.Lookup("C"); ok {
+		t.Error("C: want not found")
+	}
+	if len(r.Names()) != 2 {
+		t.Errorf("Names() = %v, want 2 unique names", r.Names())
+	}
+}
+
+func TestResolver_Empty(t *testing.T) {
+	var nilResolver *Resolver
+	if !nilResolver.Empty() || !NewResolver().Empty() {
+		t.Error("resolver without sources: want Empty")
+	}
+	if _, _, ok := nilResolver.Lookup("X"); ok {
+		t.Error("nil resolver lookup: want not found")
+	}
+}
+
+func TestFindRefs(t *testing.T) {
+	refs := FindRefs("{$HOST}:{$PORT:8080}/{path}")
+	if len(refs) != 2 {
+		t.Fatalf("got %d refs, want 2: %+v", len(refs), refs)
+	}
+	if refs[0].Name != "HOST" || refs[0].HasDefault || refs[0].Start != 0 || refs[0].End != 7 {
+		t.Errorf("refs[0] = %+v", refs[0])
+	}
+	if refs[1].Name != "PORT" || refs[1].Default != "8080" || !refs[1].HasDefault || refs[1].Start != 8 {
+		t.Errorf("refs[1] = %+v", refs[1])
+	}
+}
+
+func TestFindRefs_Unterminated(t *testing.T) {
+	if refs := FindRefs("{$HOST"); len(refs) != 0 {
+		t.Errorf("unterminated placeholder: want no refs, got %+v", refs)
+	}
+}
+
+func TestParseCompose(t *testing.T) {
+	src := `services:
+  app:
+    image: app
+    environment:
+      - DATABASE_URL=postgres://db
+      - DEBUG
+  web:
+    environment:
+      DOMAIN: example.com
+      PORT: 8080
+`
+	vars, err := ParseCompose(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{
+		"DATABASE_URL": "postgres://db",
+		"DEBUG":        "",
+		"DOMAIN":       "example.com",
+		"PORT":         "8080",
+	}
+	for k, v := range want {
+		if got, ok := vars[k]; !ok || got != v {
+			t.Errorf("%s = %q (ok=%v), want %q", k, got, ok, v)
+		}
+	}
+}
+
+func TestParseCompose_Invalid(t *testing.T) {
+	if _, err := ParseCompose("services: [\n"); err == nil {
+		t.Error("malformed YAML: want error")
+	}
+}
+
+func TestParseComposeServices(t *testing.T) {
+	src := "services:\n  web:\n    image: nginx\n  db:\n    hostname: postgres\n  cache:\n    hostname: cache\n"
+	got, err := ParseComposeServices(src)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"cache", "db", "postgres", "web"}; !slices.Equal(got, want) {
+		t.Errorf("got %v, want %v", got, want)
+	}
+}
+
+func TestIsComposeFile(t *testing.T) {
+	for name, want := range map[string]bool{
+		"docker-compose.yml":        true,
+		"docker-compose.prod.yaml":  true,
+		"compose.yaml":              true,
+		"deploy/docker-compose.yml": true,
+		".env":                      false,
+		"composer.json":             false,
+	} {
+		if got := IsComposeFile(name); got != want {
+			t.Errorf("IsComposeFile(%q) = %v, want %v", name, got, want)
+		}
+	}
+}
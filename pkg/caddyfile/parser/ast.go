@@ -0,0 +1,143 @@
+package parser
+
+import protocol "github.com/tliron/glsp/protocol_3_16"
+
+// Node is the interface implemented by every AST node.
+type Node interface {
+	Range() protocol.Range
+}
+
+// Token is the smallest unit produced by the lexer.
+type Token struct {
+	Type    TokenType
+	Value   string
+	Line    uint32 // 0-based
+	Char    uint32 // 0-based byte offset on the line
+
+	// breaks is the number of line breaks Caddy counts inside the token,
+	// which is more than zero for multi-line strings and heredocs.
+	breaks uint32
+	// shift is how many more bytes than UTF-16 code units the line holds
+	// before the token, for converting Char to a protocol position.
+	shift uint32
+}
+
+// Range returns the range of the token in UTF-16 code units, which is how
+// LSP clients count columns.
+func (t Token) Range() protocol.Range {
+	return protocol.Range{Start: t.Position(0), End: t.Position(len(t.Value))}
+}
+
+// Position returns the position of the byte at offset in Value, in UTF-16
+// code units like Range.
+func (t Token) Position(offset int) protocol.Position {
+	offset = min(max(offset, 0), len(t.Value))
+	return protocol.Position{Line: t.Line, Character: t.Char - t.shift + UTF16Len(t.Value[:offset])}
+}
+
+
+// Braces records where a block's "{" and "}" were found. LBrace is nil when
+// the node has no block; RBrace is nil when the block was never closed, in
+// which case the body runs up to wherever the parser stopped looking for "}".
+//
+// Tracking the braces themselves, rather than comparing line numbers, keeps
+// one-line blocks such as `header { X-A 1 }` distinguishable from
+// directives without a body.
+type Braces struct {
+	LBrace *Token
+	RBrace *Token
+	open   protocol.Position // exclusive end of an unclosed body
+}
+
+// HasBody reports whether the node has a "{ ... }" block, even an empty one.
+func (b *Braces) HasBody() bool { return b.LBrace != nil }
+
+// BodyContains reports whether pos lies inside the block, between its braces.
+// A position directly before the closing brace counts as inside.
+func (b *Braces) BodyContains(pos protocol.Position) bool {
+	if b.LBrace == nil || posBefore(pos, b.LBrace.Range().End) {
+		return false
+	}
+	if b.RBrace != nil {
+		return !posBefore(b.RBrace.Range().Start, pos)
+	}
+	return posBefore(pos, b.open)
+}
+
+// posBefore reports whether a comes strictly before b.
+func posBefore(a, b protocol.Position) bool {
+	return a.Line < b.Line || (a.Line == b.Line && a.Character < b.Character)
+}
+
+// Argument is a single token value used as an argument to a directive.
+type Argument struct {
+	Token Token
+}
+
+func (a *Argument) Range() protocol.Range { return a.Token.Range() }
+
+// Directive is a named directive with optional arguments and a body block.
+type Directive struct {
+	Name      Token
+	Args      []*Argument
+	Body      []*Directive // sub-directives inside { }
+	StartLine uint32
+	EndLine   uint32
+	Braces
+}
+
+func (d *Directive) Range() protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: d.StartLine, Character: 0},
+		End:   protocol.Position{Line: d.EndLine, Character: 0},
+	}
+}
+
+// SiteBlock represents a site address block, e.g. `example.com { ... }`.
+type SiteBlock struct {
+	Addresses  []Token
+	Directives []*Directive
+	StartLine  uint32
+	EndLine    uint32
+	Braces
+}
+
+func (s *SiteBlock) Range() protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: s.StartLine, Character: 0},
+		End:   protocol.Position{Line: s.EndLine, Character: 0},
+	}
+}
+
+// GlobalBlock represents the global options block `{ ... }` at the top of a Caddyfile.
+type GlobalBlock struct {
+	Directives []*Directive
+	StartLine  uint32
+	EndLine    uint32
+	Braces
+}
+
+func (g *GlobalBlock) Range() protocol.Range {
+	return protocol.Range{
+		Start: protocol.Position{Line: g.StartLine, Character: 0},
+		End:   protocol.Position{Line: g.EndLine, Character: 0},
+	}
+}
+
+// File is the root AST node for a Caddyfile.
+type File struct {
+	GlobalBlock *GlobalBlock // optional; nil if absent
+	Imports     []*Directive // `import` lines outside any block
+	SiteBlocks  []*SiteBlock
+}
+
+func (f *File) Range() protocol.Range {
+	if len(f.SiteBlocks) == 0 {
+		return protocol.Range{}
+	}
+	last := f.SiteBlocks[len(f.SiteBlocks)-1]
+	return protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 0},
+		End:   protocol.Position{Line: last.EndLine, Character: 0},
+	}
+}
@@ -0,0 +1,63 @@
+package parser
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+)
+
+// largeCaddyfile builds a synthetic multi-tenant Caddyfile with roughly the
+// given number of lines.
+func largeCaddyfile(lines int) string {
+	var b strings.Builder
+	b.WriteString("{\n\temail admin@example.com\n}\n\n(common) {\n\tencode zstd gzip\n\theader -Server\n}\n\n")
+	for i, n := 0, 9; n < lines; i, n = i+1, n+11 {
+		fmt.Fprintf(&b, "tenant%d.example.com, www.tenant%d.example.com {\n", i, i)
+		b.WriteString("\timport common\n")
+		fmt.Fprintf(&b, "\t@api path /api/* # tenant %d\n", i)
+		b.WriteString("\treverse_proxy @api app:8080 {\n")
+		b.WriteString("\t\tlb_policy round_robin\n")
+		b.WriteString("\t\theader_up X-Tenant \"{host}\"\n")
+		b.WriteString("\t}\n")
+		fmt.Fprintf(&b, "\troot * /srv/tenants/%d\n", i)
+		b.WriteString("\tfile_server\n")
+		b.WriteString("}\n\n")
+	}
+	return b.String()
+}
+
+func BenchmarkTokenize10k(b *testing.B) {
+	src := largeCaddyfile(10000)
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		Tokenize(src)
+	}
+}
+
+func BenchmarkParse10k(b *testing.B) {
+	src := largeCaddyfile(10000)
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		Parse(src)
+	}
+}
+
+// BenchmarkTokenizeUnterminatedQuotes covers a file that has one
+// unterminated quote per line, such as a large file being edited.
+func BenchmarkTokenizeUnterminatedQuotes(b *testing.B) {
+	src := "example.com {\n" + strings.Repeat("\trespond \"x\n", 10000) + "}\n"
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		Tokenize(src)
+	}
+}
+
+// BenchmarkTokenizeEscapes covers tokens whose text differs from the source
+// because of escapes, which are not found when columns are assigned.
+func BenchmarkTokenizeEscapes(b *testing.B) {
+	src := "example.com {\n" + strings.Repeat("\theader X-A a\\\"b\n", 10000) + "}\n"
+	b.SetBytes(int64(len(src)))
+	for b.Loop() {
+		Tokenize(src)
+	}
+}
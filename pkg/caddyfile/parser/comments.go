@@ -0,0 +1,37 @@
+package parser
+
+import (
+	"slices"
+	"strings"
+)
+
+// LeadingComment returns the text of the comment block directly above the
+// 0-based line of src: the run of lines holding nothing but a comment that
+// ends on the previous line. The "#" and one space after it are removed
+// from each line, and lines are joined with "\n". A blank line ends the
+// block, so a comment separated from the line by one is not returned.
+func LeadingComment(src string, line uint32) string {
+	lineStarts := buildLineStarts(src)
+	whole := make(map[uint32]string) // lines that are a comment and nothing else
+	for _, sp := range Spans(src) {
+		if sp.Kind != SpanComment {
+			continue
+		}
+		pos := offsetRange(lineStarts, sp.Start, 0).Start
+		if strings.TrimLeft(src[lineStarts[pos.Line]:sp.Start], " \t"+BOM) != "" {
+			continue
+		}
+		text := strings.TrimSuffix(src[sp.Start+1:sp.End], "\r")
+		whole[pos.Line] = strings.TrimPrefix(text, " ")
+	}
+	var lines []string
+	for l := int64(line) - 1; l >= 0; l-- {
+		text, ok := whole[uint32(l)]
+		if !ok {
+			break
+		}
+		lines = append(lines, text)
+	}
+	slices.Reverse(lines)
+	return strings.Join(lines, "\n")
+}
@@ -0,0 +1,28 @@
+package parser
+
+import "testing"
+
+func TestLeadingComment(t *testing.T) {
+	src := "# unrelated\n\n# Proxies to the app.\n#\n#   Pass the port as the first argument.\n(proxy) {\n\treverse_proxy app:{args[0]} # inline\n}\n\t# indented\n\t#no space\n(tls) {\n}\n(bare) {\n}\n"
+	cases := []struct {
+		line uint32
+		want string
+	}{
+		{5, "Proxies to the app.\n\n  Pass the port as the first argument."},
+		{10, "indented\nno space"},
+		{7, ""}, // the comment after a directive is not a whole line
+		{12, ""},
+		{0, ""},
+	}
+	for _, c := range cases {
+		if got := LeadingComment(src, c.line); got != c.want {
+			t.Errorf("line %d: got %q, want %q", c.line, got, c.want)
+		}
+	}
+}
+
+func TestLeadingComment_BlankLineEndsBlock(t *testing.T) {
+	if got := LeadingComment("# about something else\n\n(proxy) {\n}\n", 2); got != "" {
+		t.Errorf("got %q, want none", got)
+	}
+}
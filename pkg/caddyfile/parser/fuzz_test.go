@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"strings"
+	"testing"
+)
+
+// fuzzSeeds are inputs that stress the column recovery of the lexer:
+// unbalanced braces in strings, heredocs, escapes, comments, CRLF line
+// endings and NUL bytes.
+var fuzzSeeds = []string{
+	"",
+	"example.com {\n\trespond \"ok\" 200\n}\n",
+	"{\n\tdebug\n}\n(snip) {\n\theader X-A \"{\"\n}\nexample.com {\n\timport snip\n}\n",
+	"a.com {\n\trespond \"}\" # }\n\trespond `{`\n}\n",
+	"a.com {\n\trespond <<HTML\n\t\t<p>{</p>\n\t\tHTML 200\n}\n",
+	"a.com {\n\trespond <<EOF\n",
+	"a.com {\n\trespond \"unterminated\n}\n",
+	"a.com {\n\theader X-A a\\\"b\\ c\n}\n",
+	"a.com {\r\n\trespond ok\r\n}\r\n",
+	"a.com {\x00\n\trespond \x00ok\n}\n",
+	BOM + "a.com {\n\trespond ok\n}\n",
+	"a.com {\n\trespond \"multi\nline\" 200\n}\n",
+	"}}}{{{\n",
+	"a.com b.com, c.com {\n\t@m {\n\t\tnot path /x\n\t}\n\thandle @m {\n\t\trespond {path}\n\t}\n}\n",
+	"a.com {\n\trespond " + strings.Repeat("x", 1<<12) + "\n}\n",
+}
+
+// checkTokenColumns reports tokens that are not placed within their line.
+func checkTokenColumns(t *testing.T, src string, tokens []Token) {
+	t.Helper()
+	lines := strings.Split(src, "\n")
+	for _, tok := range tokens {
+		if int(tok.Line) >= len(lines) {
+			t.Fatalf("token %q on line %d of %d", tok.Value, tok.Line, len(lines))
+		}
+		if tok.Type != EOF && int(tok.Char) > len(lines[tok.Line]) {
+			t.Fatalf("token %q at %d:%d past the end of its line %q", tok.Value, tok.Line, tok.Char, lines[tok.Line])
+		}
+	}
+}
+
+func FuzzTokenize(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		src = StripBOM(src)
+		checkTokenColumns(t, src, Tokenize(src))
+	})
+}
+
+func FuzzParse(f *testing.F) {
+	for _, s := range fuzzSeeds {
+		f.Add(s)
+	}
+	f.Fuzz(func(t *testing.T, src string) {
+		src = StripBOM(src)
+		file, _ := Parse(src)
+		if file == nil {
+			t.Fatal("Parse returned no file")
+		}
+		var tokens []Token
+		var walk func(ds []*Directive)
+		walk = func(ds []*Directive) {
+			for _, d := range ds {
+				tokens = append(tokens, d.Name)
+				for _, a := range d.Args {
+					tokens = append(tokens, a.Token)
+				}
+				walk(d.Body)
+			}
+		}
+		walk(file.Imports)
+		if file.GlobalBlock != nil {
+			walk(file.GlobalBlock.Directives)
+		}
+		for _, sb := range file.SiteBlocks {
+			tokens = append(tokens, sb.Addresses...)
+			walk(sb.Directives)
+		}
+		checkTokenColumns(t, src, tokens)
+		ParseDirectives(src)
+		VerbatimLines(src)
+		Spans(src)
+	})
+}
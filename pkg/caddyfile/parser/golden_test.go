@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+var update = flag.Bool("update", false, "rewrite the golden files of the corpus tests")
+
+// TestGolden tokenizes and parses each Caddyfile of the shared corpus and
+// compares the token positions, the shape of the AST and the parse errors
+// with testdata/golden. Run with -update after an intended change, and
+// review the diff: a shifted column here is a shifted diagnostic in editors.
+// Columns are UTF-16 code units, as LSP clients count them, not the byte
+// offsets of Token.Char.
+func TestGolden(t *testing.T) {
+	files, err := filepath.Glob("../testdata/corpus/*.caddyfile")
+	if err != nil || len(files) == 0 {
+		t.Fatalf("no corpus files: %v", err)
+	}
+	for _, file := range files {
+		name := strings.TrimSuffix(filepath.Base(file), ".caddyfile")
+		t.Run(name, func(t *testing.T) {
+			src, err := os.ReadFile(file)
+			if err != nil {
+				t.Fatal(err)
+			}
+			checkGolden(t, filepath.Join("testdata", "golden", name+".golden"), dumpFile(string(src)))
+		})
+	}
+}
+
+// checkGolden compares got with the golden file at path, or rewrites the
+// file with -update.
+func checkGolden(t *testing.T, path, got string) {
+	t.Helper()
+	if *update {
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(got), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		return
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatalf("%v (run with -update to create it)", err)
+	}
+	if got != string(want) {
+		t.Errorf("%s is out of date (run with -update and review the diff)\ngot:\n%s", path, got)
+	}
+}
+
+// dumpFile renders the tokens, the AST and the parse errors of src, one
+// item per line with 0-based positions.
+func dumpFile(src string) string {
+	var b strings.Builder
+	b.WriteString("-- tokens --\n")
+	for _, tok := range Tokenize(src) {
+		fmt.Fprintf(&b, "%s %s %q\n", positionString(tok.Range().Start), tok.Type, tok.Value)
+	}
+
+	f, errs := Parse(src)
+	b.WriteString("-- ast --\n")
+	if f.GlobalBlock != nil {
+		fmt.Fprintf(&b, "global %s\n", rangeString(f.GlobalBlock.Range()))
+		dumpDirectives(&b, f.GlobalBlock.Directives, 1)
+	}
+	dumpDirectives(&b, f.Imports, 0)
+	for _, sb := range f.SiteBlocks {
+		var addrs []string
+		for _, a := range sb.Addresses {
+			addrs = append(addrs, fmt.Sprintf("%q@%s", a.Value, positionString(a.Range().Start)))
+		}
+		fmt.Fprintf(&b, "site %s %s\n", rangeString(sb.Range()), strings.Join(addrs, " "))
+		dumpDirectives(&b, sb.Directives, 1)
+	}
+
+	b.WriteString("-- errors --\n")
+	for _, e := range errs {
+		fmt.Fprintf(&b, "%s %s\n", rangeString(e.Rng), e.Message)
+		for _, r := range e.Related {
+			fmt.Fprintf(&b, "\t%s %s\n", rangeString(r.Rng), r.Message)
+		}
+	}
+	return b.String()
+}
+
+func dumpDirectives(b *strings.Builder, ds []*Directive, depth int) {
+	for _, d := range ds {
+		fmt.Fprintf(b, "%s%s %s", strings.Repeat("\t", depth), d.Name.Value, rangeString(d.Range()))
+		for _, a := range d.Args {
+			fmt.Fprintf(b, " %q@%s", a.Token.Value, positionString(a.Range().Start))
+		}
+		b.WriteString("\n")
+		dumpDirectives(b, d.Body, depth+1)
+	}
+}
+
+func rangeString(r protocol.Range) string {
+	return positionString(r.Start) + "-" + positionString(r.End)
+}
+
+func positionString(p protocol.Position) string {
+	return fmt.Sprintf("%d:%d", p.Line, p.Character)
+}
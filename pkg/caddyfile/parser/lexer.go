@@ -44,7 +44,7 @@ func (t TokenType) String() string {
 // buildLineStarts returns a slice where lineStarts[i] is the byte offset of
 // the first character of line i (0-based) within src.
 func buildLineStarts(src string) []int {
-	starts := []int{0}
+	starts := make([]int, 1, strings.Count(src, "\n")+1)
 	for i := 0; i < len(src); i++ {
 		if src[i] == '\n' {
 			starts = append(starts, i+1)
@@ -53,6 +53,29 @@ func buildLineStarts(src string) []int {
 	return starts
 }
 
+// lineLength returns the length of line0 in bytes, without its newline.
+func lineLength(src string, lineStarts []int, line0 uint32) int {
+	if int(line0) >= len(lineStarts) {
+		return 0
+	}
+	end := len(src)
+	if int(line0)+1 < len(lineStarts) {
+		end = lineStarts[line0+1] - 1
+	}
+	return end - lineStarts[line0]
+}
+
+// BOM is the UTF-8 byte order mark some Windows editors write at the start
+// of a file.
+const BOM = "\ufeff"
+
+// StripBOM returns src without a leading byte order mark. Editors do not
+// show the mark, so positions on the first line are counted after it;
+// sources are stripped before any position math.
+func StripBOM(src string) string {
+	return strings.TrimPrefix(src, BOM)
+}
+
 // Tokenize uses Caddy's official Caddyfile tokenizer and enriches each token
 // with column information derived by scanning the source text.
 //
@@ -60,12 +83,8 @@ func buildLineStarts(src string) []int {
 // strips comments and does not emit newlines as separate tokens. The NEWLINE
 // and COMMENT enum values are retained for backward compatibility only.
 func Tokenize(src string) []Token {
-	caddyTokens, err := caddyfile.Tokenize([]byte(src), "Caddyfile")
-	if err != nil {
-		// Return just an EOF so the parser can report errors gracefully.
-		return []Token{{Type: EOF}}
-	}
-	return addColumns(src, caddyTokens)
+	tokens, _ := tokenize(src)
+	return tokens
 }
 
 // addColumns converts a slice of Caddy tokens into our internal Token slice,
@@ -82,9 +101,14 @@ func addColumns(src string, caddyTokens []caddyfile.Token) []Token {
 	result := make([]Token, 0, len(caddyTokens)+1)
 
 	// lineEnd[line0] is the byte offset just past the end of the last token
-	// we matched on line0. Used to avoid re-matching an earlier occurrence of
-	// the same text.
-	lineEnd := make(map[uint32]int)
+	// we matched on line0 (0 when none yet). Used to avoid re-matching an
+	// earlier occurrence of the same text.
+	lineEnd := make([]int, len(lineStarts))
+	setLineEnd := func(line0 uint32, end int) {
+		if int(line0) < len(lineEnd) {
+			lineEnd[line0] = end
+		}
+	}
 
 	for _, ct := range caddyTokens {
 		if ct.Line <= 0 {
@@ -100,21 +124,30 @@ func addColumns(src string, caddyTokens []caddyfile.Token) []Token {
 		// Search starts at the line start or after the previous token on this
 		// line, whichever is later.
 		searchFrom := lineStart
-		if prev, ok := lineEnd[line0]; ok && prev > searchFrom {
-			searchFrom = prev
+		if int(line0) < len(lineEnd) && lineEnd[line0] > searchFrom {
+			searchFrom = lineEnd[line0]
 		}
+		// The search stays on the line, so that a token whose text is not
+		// found there, such as one with escapes, does not cost a scan of the
+		// rest of the file. A token continued onto a later line with an
+		// escaped newline keeps the line Caddy reports and is placed after
+		// the previous token.
+		lineStop := lineStart + lineLength(src, lineStarts, line0)
+		searchFrom = min(searchFrom, lineStop)
 
 		var (
-			tt    TokenType
-			value string
-			col   uint32
+			tt     TokenType
+			value  string
+			col    = uint32(searchFrom - lineStart)
+			breaks uint32
 		)
 
 		if ct.Quoted() {
 			tt = STRING
+			breaks = uint32(ct.NumLineBreaks())
 			// Locate the opening quote (or heredoc marker) in the source.
 			qpos := -1
-			for i := searchFrom; i < len(src); i++ {
+			for i := searchFrom; i < lineStop; i++ {
 				ch := src[i]
 				if ch == '"' || ch == '`' {
 					qpos = i
@@ -135,8 +168,8 @@ func addColumns(src string, caddyTokens []caddyfile.Token) []Token {
 					for end < len(src) && src[end] != '\n' {
 						end++
 					}
-					value = src[qpos:end]
-					lineEnd[line0] = end
+					value = strings.TrimSuffix(src[qpos:end], "\r")
+					setLineEnd(line0, end)
 				} else {
 					// Regular quoted string: read through matching closing quote.
 					q := src[qpos]
@@ -149,9 +182,11 @@ func addColumns(src string, caddyTokens []caddyfile.Token) []Token {
 					}
 					if end < len(src) && src[end] == q {
 						end++ // include closing quote
+					} else {
+						end = qpos + len(strings.TrimRight(src[qpos:end], "\r"))
 					}
 					value = src[qpos:end]
-					lineEnd[line0] = end
+					setLineEnd(line0, end)
 				}
 			} else {
 				// Fallback: reconstruct a quoted value from the token text.
@@ -168,22 +203,32 @@ func addColumns(src string, caddyTokens []caddyfile.Token) []Token {
 			}
 			value = ct.Text
 
-			idx := strings.Index(src[searchFrom:], ct.Text)
+			idx := strings.Index(src[searchFrom:lineStop], ct.Text)
 			if idx >= 0 {
 				absPos := searchFrom + idx
 				col = uint32(absPos - lineStart)
-				lineEnd[line0] = absPos + len(ct.Text)
+				setLineEnd(line0, absPos+len(ct.Text))
 			}
 		}
 
 		result = append(result, Token{
-			Type:  tt,
-			Value: value,
-			Line:  line0,
-			Char:  col,
+			Type:   tt,
+			Value:  value,
+			Line:   line0,
+			Char:   col,
+			breaks: breaks,
+			shift:  byteShift(src[lineStart : lineStart+int(col)]),
 		})
 	}
 
-	result = append(result, Token{Type: EOF})
+	// Place EOF at the very end of the source so errors reported there point
+	// at the last line rather than the top of the file.
+	last := len(lineStarts) - 1
+	result = append(result, Token{
+		Type:  EOF,
+		Line:  uint32(last),
+		Char:  uint32(len(src) - lineStarts[last]),
+		shift: byteShift(src[lineStarts[last]:]),
+	})
 	return result
 }
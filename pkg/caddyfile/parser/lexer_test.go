@@ -1,6 +1,8 @@
 package parser
 
 import (
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -145,6 +147,14 @@ func TestTokenize_Empty(t *testing.T) {
 	}
 }
 
+func TestTokenize_EOFAtEndOfSource(t *testing.T) {
+	tokens := Tokenize("foo {\n\tbar\n}")
+	eof := tokens[len(tokens)-1]
+	if eof.Type != EOF || eof.Line != 2 || eof.Char != 1 {
+		t.Errorf("EOF at %d:%d, want 2:1", eof.Line, eof.Char)
+	}
+}
+
 func TestTokenize_CRLF(t *testing.T) {
 	// \r\n line endings are handled; foo and bar end up on separate lines.
 	// Caddy's tokenizer does not emit NEWLINE tokens.
@@ -274,3 +284,74 @@ func TestBuildLineStarts_MultipleNewlines(t *testing.T) {
 		}
 	}
 }
+
+// lineEndingSources have a token of every kind, strings spanning lines, an
+// unterminated string and a stray token after "}" so that both the tokens
+// and the parse errors are compared.
+var lineEndingSources = []string{
+	"example.com {\n\trespond \"ok\" 200\n}\n",
+	"{\n\temail a@b.c\n}\n\n(snip) {\n\theader X-A `b`\n}\n\nexample.com, www.example.com {\n\timport snip\n\t@api path /api/*\n\treverse_proxy @api localhost:8080 {\n\t\tlb_policy first\n\t}\n}\n",
+	"example.com {\n\trespond \"line one\nline two\" 200\n}\n",
+	"example.com {\n\trespond <<EOF\n\t\tbody\n\tEOF 200\n}\n",
+	"example.com {\n\trespond \"oops\n}\n",
+	"example.com {\n\tfile_server\n} stray\n",
+}
+
+func TestParse_CRLFMatchesLF(t *testing.T) {
+	for _, lf := range lineEndingSources {
+		crlf := strings.ReplaceAll(lf, "\n", "\r\n")
+		if got, want := Tokenize(crlf), Tokenize(lf); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: CRLF tokens differ:\ngot  %v\nwant %v", lf, got, want)
+		}
+		_, gotErrs := Parse(crlf)
+		_, wantErrs := Parse(lf)
+		if !reflect.DeepEqual(gotErrs, wantErrs) {
+			t.Errorf("%q: CRLF errors differ:\ngot  %v\nwant %v", lf, gotErrs, wantErrs)
+		}
+	}
+}
+
+func TestParse_BOMDoesNotShiftPositions(t *testing.T) {
+	for _, src := range lineEndingSources {
+		withBOM := BOM + src
+		if got, want := Tokenize(withBOM), Tokenize(src); !reflect.DeepEqual(got, want) {
+			t.Errorf("%q: tokens differ with BOM:\ngot  %v\nwant %v", src, got, want)
+		}
+		gotFile, gotErrs := Parse(withBOM)
+		wantFile, wantErrs := Parse(src)
+		if !reflect.DeepEqual(gotErrs, wantErrs) {
+			t.Errorf("%q: errors differ with BOM:\ngot  %v\nwant %v", src, gotErrs, wantErrs)
+		}
+		if !reflect.DeepEqual(gotFile, wantFile) {
+			t.Errorf("%q: AST differs with BOM", src)
+		}
+	}
+}
+
+func TestParse_BOMAddress(t *testing.T) {
+	f, errs := Parse(BOM + "example.com {\n}\n")
+	if len(errs) != 0 {
+		t.Fatalf("unexpected errors: %v", errs)
+	}
+	addr := f.SiteBlocks[0].Addresses[0]
+	if addr.Value != "example.com" || addr.Char != 0 {
+		t.Errorf("address = %q at char %d, want \"example.com\" at char 0", addr.Value, addr.Char)
+	}
+}
+
+func TestTokenRange_UTF16Columns(t *testing.T) {
+	tokens := Tokenize("respond \"📚 ü\" {$HOST}\n")
+	str, env := tokens[1], tokens[2]
+	if r := str.Range(); r.Start.Character != 8 || r.End.Character != 14 {
+		t.Errorf("string range %v, want 8-14: the emoji takes two code units", r)
+	}
+	if r := env.Range(); r.Start.Character != 15 || r.End.Character != 22 {
+		t.Errorf("placeholder range %v, want 15-22", r)
+	}
+	if env.Char != 18 {
+		t.Errorf("Char = %d, want the byte offset 18", env.Char)
+	}
+	if p := str.Position(6); p.Character != 12 {
+		t.Errorf("Position(6) = %d, want 12 after the quote, the emoji and the space", p.Character)
+	}
+}
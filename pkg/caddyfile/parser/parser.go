@@ -0,0 +1,432 @@
+// Package parser turns Caddyfile source into a syntax tree that keeps the
+// position of every token: the global options block, site blocks with their
+// addresses, and directives with their arguments and nested blocks. Parse
+// always returns a tree, also for input with syntax errors, so that editors
+// and linters can work on files being typed. Tokenizing is done by Caddy's
+// own lexer, so the tree follows Caddy's syntax rules.
+package parser
+
+import (
+	"fmt"
+	"strings"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// ParseError holds a diagnostic-friendly parse error. Related lists
+// secondary locations that help explain it, such as where the parser gave up
+// looking for a closing brace.
+type ParseError struct {
+	Message string
+	Rng     protocol.Range
+	Related []RelatedInfo
+}
+
+// RelatedInfo is a secondary location attached to a ParseError.
+type RelatedInfo struct {
+	Message string
+	Rng     protocol.Range
+}
+
+func (e *ParseError) Error() string { return e.Message }
+
+// Parse tokenizes src and builds an AST. It returns a (possibly partial) File
+// along with any parse errors encountered.
+func Parse(src string) (*File, []*ParseError) {
+	tokens, errs := tokenize(src)
+	p := &parser{tokens: tokens, errors: errs}
+	return p.parseFile()
+}
+
+// ParseDirectives parses src as the body of a block, the way Caddy reads a
+// file imported inside a site block or directive: a list of directives
+// without site addresses.
+func ParseDirectives(src string) ([]*Directive, []*ParseError) {
+	tokens, errs := tokenize(src)
+	p := &parser{tokens: tokens, errors: errs}
+	var ds []*Directive
+	for tok := p.peek(); tok.Type != EOF; tok = p.peek() {
+		if tok.Type == RBRACE {
+			p.errorf(tok.Range(), "unexpected '}'")
+			p.next()
+			continue
+		}
+		if d := p.parseDirective(); d != nil {
+			ds = append(ds, d)
+		}
+	}
+	return ds, p.errors
+}
+
+type parser struct {
+	tokens []Token
+	pos    int
+	errors []*ParseError
+
+	// Arguments and directives are carved out of chunked slabs: large
+	// generated Caddyfiles contain tens of thousands of them and one
+	// allocation each dominates parse time.
+	argSlab []Argument
+	dirSlab []Directive
+}
+
+// slabSize is the number of nodes allocated at once by newArgument and
+// newDirective.
+const slabSize = 256
+
+func (p *parser) newArgument(tok Token) *Argument {
+	if len(p.argSlab) == 0 {
+		p.argSlab = make([]Argument, slabSize)
+	}
+	a := &p.argSlab[0]
+	p.argSlab = p.argSlab[1:]
+	a.Token = tok
+	return a
+}
+
+func (p *parser) newDirective() *Directive {
+	if len(p.dirSlab) == 0 {
+		p.dirSlab = make([]Directive, slabSize)
+	}
+	d := &p.dirSlab[0]
+	p.dirSlab = p.dirSlab[1:]
+	return d
+}
+
+// --- token navigation helpers ---
+
+func (p *parser) peek() Token {
+	for p.pos < len(p.tokens) {
+		t := p.tokens[p.pos]
+		if t.Type == COMMENT || t.Type == NEWLINE {
+			p.pos++
+			continue
+		}
+		return t
+	}
+	return Token{Type: EOF}
+}
+
+func (p *parser) next() Token {
+	t := p.peek()
+	if t.Type != EOF {
+		p.pos++
+	}
+	return t
+}
+
+// nextBrace consumes the brace token at the current position and returns a
+// pointer into the token slice, so recording brace positions costs no
+// allocation. The caller must have peeked a LBRACE or RBRACE.
+func (p *parser) nextBrace() *Token {
+	p.next()
+	return &p.tokens[p.pos-1]
+}
+
+// closeBrace consumes the "}" at the current position. Tokens after it on
+// the same line are nearly always a paste error, and would otherwise start a
+// directive or site block that the user never meant to write, so they are
+// reported and skipped. A further "}" closing an outer block is allowed, and
+// a line ending in "{" is left alone since it opens a block of its own.
+func (p *parser) closeBrace() *Token {
+	rbrace := p.nextBrace()
+	tok := p.peek()
+	if tok.Type == EOF || tok.Type == RBRACE || tok.Line != rbrace.Line {
+		return rbrace
+	}
+	p.errorf(tok.Range(), "unexpected token %q after '}'", tok.Value)
+	end := p.pos
+	for end < len(p.tokens) && p.tokens[end].Type != EOF && p.tokens[end].Line == rbrace.Line {
+		end++
+	}
+	if p.tokens[end-1].Type != LBRACE {
+		p.pos = end
+	}
+	return rbrace
+}
+
+func (p *parser) errorf(rng protocol.Range, format string, args ...any) {
+	p.errors = append(p.errors, &ParseError{
+		Message: fmt.Sprintf(format, args...),
+		Rng:     rng,
+	})
+}
+
+// unclosedf reports a block opened by open that was never closed. The error
+// points at the opening brace; at, the token where the parser stopped
+// looking (EOF or the start of the next site block), is attached as related
+// information.
+func (p *parser) unclosedf(open, at Token, format string, args ...any) {
+	reason := "end of file reached before '}'"
+	if at.Type != EOF {
+		reason = "block assumed closed before this site block"
+	}
+	p.errors = append(p.errors, &ParseError{
+		Message: fmt.Sprintf(format, args...),
+		Rng:     open.Range(),
+		Related: []RelatedInfo{{Message: reason, Rng: at.Range()}},
+	})
+}
+
+// unclosedEnd returns the exclusive end of a block body that stopped at
+// token at without a closing brace. At EOF the body extends through the last
+// line; at a resync point it ends where the next site block begins.
+func unclosedEnd(at Token) protocol.Position {
+	if at.Type == EOF {
+		return protocol.Position{Line: at.Line + 1}
+	}
+	return protocol.Position{Line: at.Line}
+}
+
+// --- grammar ---
+
+// parseFile parses the top-level structure of a Caddyfile.
+// Grammar (simplified):
+//
+//	File        = GlobalBlock? SiteBlock*
+//	GlobalBlock = "{" Directive* "}"         (when first non-comment token is "{")
+//	SiteBlock   = Address+ "{" Directive* "}" | Address+ Directive*
+//	Directive   = IDENT Argument* ("{" Directive* "}")?
+func (p *parser) parseFile() (*File, []*ParseError) {
+	f := &File{}
+
+	// Optional global block: starts with bare "{"
+	if p.peek().Type == LBRACE {
+		f.GlobalBlock = p.parseGlobalBlock()
+	}
+
+	for p.peek().Type != EOF {
+		if p.atTopLevelImport() {
+			f.Imports = append(f.Imports, p.parseTopLevelImport())
+			continue
+		}
+		if p.peek().Type == LBRACE && f.GlobalBlock == nil && len(f.SiteBlocks) == 0 {
+			// Imports may precede the global options block.
+			f.GlobalBlock = p.parseGlobalBlock()
+			continue
+		}
+		if tok := p.peek(); tok.Type == LBRACE {
+			// Caddy reads a later block without addresses as a misplaced
+			// global options block and rejects it; it is skipped.
+			p.errorf(tok.Range(), "a block without site addresses is the global options block, which must come first")
+			p.parseGlobalBlock()
+			continue
+		}
+		sb := p.parseSiteBlock()
+		if sb != nil {
+			f.SiteBlocks = append(f.SiteBlocks, sb)
+		}
+	}
+
+	return f, p.errors
+}
+
+// atTopLevelImport reports whether the next token starts an `import` line
+// outside any block. Such a line is an import, not the address list of a
+// site block, unless the line ends in "{".
+func (p *parser) atTopLevelImport() bool {
+	tok := p.peek()
+	if tok.Type != IDENT || tok.Value != "import" {
+		return false
+	}
+	for i := p.pos + 1; i < len(p.tokens); i++ {
+		t := p.tokens[i]
+		if !sameLine(p.tokens[i-1], t) || t.Type == EOF {
+			return true
+		}
+		if t.Type == LBRACE {
+			return false
+		}
+	}
+	return true
+}
+
+// parseTopLevelImport parses an `import` line outside any block. Only the
+// tokens on the same line are its arguments.
+func (p *parser) parseTopLevelImport() *Directive {
+	name := p.next()
+	d := p.newDirective()
+	d.Name, d.StartLine, d.EndLine = name, name.Line, name.Line
+	for prev, tok := name, p.peek(); tok.Type != EOF && sameLine(prev, tok); prev, tok = tok, p.peek() {
+		d.Args = append(d.Args, p.newArgument(p.next()))
+		d.EndLine = tok.Line
+	}
+	return d
+}
+
+// sameLine reports whether tok, which follows prev, is on the same logical
+// line. As in Caddy, the line breaks inside a multi-line string or heredoc
+// do not end the line, so an argument after its closing marker still
+// belongs to the directive.
+func sameLine(prev, tok Token) bool {
+	return tok.Line <= prev.Line+prev.breaks
+}
+
+func (p *parser) parseGlobalBlock() *GlobalBlock {
+	lbrace := p.nextBrace() // consume "{"
+	g := &GlobalBlock{StartLine: lbrace.Line}
+	g.LBrace = lbrace
+	for {
+		tok := p.peek()
+		if tok.Type == EOF || p.atResyncPoint() {
+			p.unclosedf(*lbrace, tok, "unclosed global options block")
+			g.EndLine = tok.Line
+			g.open = unclosedEnd(tok)
+			break
+		}
+		if tok.Type == RBRACE {
+			g.EndLine = tok.Line
+			g.RBrace = p.closeBrace()
+			break
+		}
+		d := p.parseDirective()
+		if d != nil {
+			g.Directives = append(g.Directives, d)
+		}
+	}
+	return g
+}
+
+func (p *parser) parseSiteBlock() *SiteBlock {
+	sb := &SiteBlock{}
+
+	// Collect address tokens until "{" or EOF
+	for {
+		tok := p.peek()
+		if tok.Type == EOF || tok.Type == LBRACE {
+			break
+		}
+		if tok.Type == RBRACE {
+			// stray "}" — skip with error
+			p.errorf(tok.Range(), "unexpected '}'")
+			p.next()
+			return nil
+		}
+		if len(sb.Addresses) == 0 {
+			sb.StartLine = tok.Line
+		}
+		sb.Addresses = append(sb.Addresses, p.next())
+	}
+
+	if len(sb.Addresses) == 0 {
+		return nil
+	}
+
+	// Expect "{"
+	if p.peek().Type != LBRACE {
+		p.errorf(p.peek().Range(), "expected '{' after site address(es)")
+		return sb
+	}
+	lbrace := p.nextBrace() // consume "{"
+	sb.LBrace = lbrace
+
+	for {
+		tok := p.peek()
+		if tok.Type == EOF || p.atResyncPoint() {
+			p.unclosedf(*lbrace, tok, "unclosed site block for %q", sb.Addresses[0].Value)
+			sb.EndLine = tok.Line
+			sb.open = unclosedEnd(tok)
+			break
+		}
+		if tok.Type == RBRACE {
+			sb.EndLine = tok.Line
+			sb.RBrace = p.closeBrace()
+			break
+		}
+		d := p.parseDirective()
+		if d != nil {
+			sb.Directives = append(sb.Directives, d)
+		}
+	}
+
+	return sb
+}
+
+func (p *parser) parseDirective() *Directive {
+	tok := p.peek()
+	if tok.Type != IDENT && tok.Type != STRING {
+		// skip unexpected token
+		p.errorf(tok.Range(), "expected directive name, got %s", tok.Type)
+		p.next()
+		return nil
+	}
+
+	name := p.next()
+	d := p.newDirective()
+	d.Name, d.StartLine, d.EndLine = name, name.Line, name.Line
+
+	// Collect arguments on the same line
+	for prev := name; ; prev = tok {
+		tok = p.peek()
+		if tok.Type == EOF || tok.Type == LBRACE || tok.Type == RBRACE {
+			break
+		}
+		// Arguments must be on the same line as the directive name
+		if !sameLine(prev, tok) {
+			break
+		}
+		d.Args = append(d.Args, p.newArgument(p.next()))
+		d.EndLine = tok.Line
+	}
+
+	// Optional body block
+	if p.peek().Type == LBRACE {
+		lbrace := p.nextBrace() // consume "{"
+		d.LBrace = lbrace
+		for {
+			tok = p.peek()
+			if tok.Type == EOF || p.atResyncPoint() {
+				p.unclosedf(*lbrace, tok, "unclosed block for directive %q", name.Value)
+				d.EndLine = tok.Line
+				d.open = unclosedEnd(tok)
+				break
+			}
+			if tok.Type == RBRACE {
+				d.EndLine = tok.Line
+				d.RBrace = p.closeBrace()
+				break
+			}
+			sub := p.parseDirective()
+			if sub != nil {
+				d.Body = append(d.Body, sub)
+			}
+		}
+	}
+
+	return d
+}
+
+// --- error recovery ---
+
+// atResyncPoint reports whether the next token starts a line that looks like
+// the beginning of a new site block or snippet: an unindented, address-like
+// token on a line that ends with "{". Block parsers stop there so that a
+// single missing "}" does not swallow the rest of the file.
+func (p *parser) atResyncPoint() bool {
+	tok := p.peek()
+	if tok.Type != IDENT || tok.Char != 0 || !looksLikeSiteAddress(tok.Value) {
+		return false
+	}
+	last := tok
+	for i := p.pos; i < len(p.tokens) && p.tokens[i].Type != EOF && p.tokens[i].Line == tok.Line; i++ {
+		last = p.tokens[i]
+	}
+	return last.Type == LBRACE
+}
+
+// looksLikeSiteAddress reports whether s could be a site address or snippet
+// definition rather than a directive name. Directive names never contain dots
+// or colons, which nearly every address does.
+func looksLikeSiteAddress(s string) bool {
+	switch {
+	case strings.HasPrefix(s, "(") && strings.HasSuffix(s, ")"),
+		strings.HasPrefix(s, "&(") && strings.HasSuffix(s, ")"):
+		return true // snippet or named route
+	case strings.HasPrefix(s, "@"):
+		return false // named matcher
+	}
+	return strings.ContainsAny(s, ".:") ||
+		strings.HasPrefix(s, "{$") ||
+		strings.HasPrefix(s, "*.") ||
+		s == "localhost"
+}
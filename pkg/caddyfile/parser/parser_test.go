@@ -1,7 +1,10 @@
 package parser
 
 import (
+	"strings"
 	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
 )
 
 // ---- helpers ----------------------------------------------------------------
@@ -104,6 +107,27 @@ func TestParse_NoGlobalBlock(t *testing.T) {
 	}
 }
 
+func TestParse_TopLevelImports(t *testing.T) {
+	src := "import ./snippets/*.caddy\n{\n\temail admin@example.com\n}\nimport common\nexample.com {\n\trespond \"ok\"\n}\n"
+	f := mustParse(t, src)
+
+	if len(f.Imports) != 2 {
+		t.Fatalf("want 2 top-level imports, got %d", len(f.Imports))
+	}
+	if got := f.Imports[0].Args[0].Token.Value; got != "./snippets/*.caddy" {
+		t.Errorf("first import arg: got %q", got)
+	}
+	if got := f.Imports[1].Args[0].Token.Value; got != "common" || len(f.Imports[1].Args) != 1 {
+		t.Errorf("second import: got %q with %d args", got, len(f.Imports[1].Args))
+	}
+	if f.GlobalBlock == nil {
+		t.Error("global block after an import: want parsed, got nil")
+	}
+	if len(f.SiteBlocks) != 1 || len(f.SiteBlocks[0].Addresses) != 1 {
+		t.Errorf("want 1 site block with 1 address, got %+v", f.SiteBlocks)
+	}
+}
+
 // ---- directive argument tests -----------------------------------------------
 
 func TestParse_DirectiveNoArgs(t *testing.T) {
@@ -235,6 +259,124 @@ func TestParse_UnclosedGlobalBlock(t *testing.T) {
 	}
 }
 
+func TestParse_UnclosedSiteBlockRecoversAtNextSite(t *testing.T) {
+	src := "a.example.com {\n\trespond \"a\"\n\nb.example.com {\n\tfile_server\n}\n"
+	f, errs := Parse(src)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 parse error, got %d: %v", len(errs), errs)
+	}
+	if len(f.SiteBlocks) != 2 {
+		t.Fatalf("expected 2 site blocks, got %d", len(f.SiteBlocks))
+	}
+	second := f.SiteBlocks[1]
+	if second.Addresses[0].Value != "b.example.com" {
+		t.Errorf("second site address = %q, want b.example.com", second.Addresses[0].Value)
+	}
+	if len(second.Directives) != 1 || second.Directives[0].Name.Value != "file_server" {
+		t.Errorf("second site directives not parsed: %+v", second.Directives)
+	}
+	if got := f.SiteBlocks[0].EndLine; got != 3 {
+		t.Errorf("first site EndLine = %d, want 3", got)
+	}
+}
+
+func TestParse_UnclosedDirectiveRecoversAtNextSite(t *testing.T) {
+	src := "a.example.com {\n\treverse_proxy app:8080 {\n\t\tlb_policy first\n}\n(common) {\n\tencode gzip\n}\n"
+	f, errs := Parse(src)
+	if len(errs) == 0 {
+		t.Fatal("expected parse errors for unclosed blocks, got none")
+	}
+	if len(f.SiteBlocks) != 2 {
+		t.Fatalf("expected 2 site blocks, got %d", len(f.SiteBlocks))
+	}
+	if got := f.SiteBlocks[1].Addresses[0].Value; got != "(common)" {
+		t.Errorf("second block = %q, want (common)", got)
+	}
+}
+
+func TestParse_NoResyncOnIndentedOrDirectiveLines(t *testing.T) {
+	// An unindented directive with a block is not an address and must stay
+	// inside the unclosed site block.
+	src := "example.com {\nhandle {\n\trespond ok\n}\n"
+	f, errs := Parse(src)
+	if len(errs) == 0 {
+		t.Fatal("expected parse error for unclosed site block, got none")
+	}
+	if len(f.SiteBlocks) != 1 {
+		t.Fatalf("expected 1 site block, got %d", len(f.SiteBlocks))
+	}
+	if len(f.SiteBlocks[0].Directives) != 1 || f.SiteBlocks[0].Directives[0].Name.Value != "handle" {
+		t.Errorf("handle directive not kept in site block: %+v", f.SiteBlocks[0].Directives)
+	}
+}
+
+func TestParse_UnclosedBlockPointsAtOpeningBrace(t *testing.T) {
+	src := "example.com {\n\trespond \"ok\"\n"
+	_, errs := Parse(src)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 parse error, got %d: %v", len(errs), errs)
+	}
+	got := errs[0]
+	want := protocol.Range{
+		Start: protocol.Position{Line: 0, Character: 12},
+		End:   protocol.Position{Line: 0, Character: 13},
+	}
+	if got.Rng != want {
+		t.Errorf("range = %+v, want opening brace %+v", got.Rng, want)
+	}
+	if len(got.Related) != 1 {
+		t.Fatalf("expected 1 related location, got %d", len(got.Related))
+	}
+	eof := protocol.Position{Line: 2, Character: 0}
+	if got.Related[0].Rng.Start != eof {
+		t.Errorf("related start = %+v, want EOF %+v", got.Related[0].Rng.Start, eof)
+	}
+}
+
+func TestParse_UnclosedSiteRelatedAtResyncPoint(t *testing.T) {
+	// The "}" on line 3 closes handle, leaving the site block open.
+	src := "a.example.com {\n\thandle {\n\t\trespond ok\n}\nb.example.com {\n}\n"
+	_, errs := Parse(src)
+	if len(errs) != 1 {
+		t.Fatalf("expected 1 parse error, got %d: %v", len(errs), errs)
+	}
+	if got := errs[0].Rng.Start; got.Line != 0 || got.Character != 14 {
+		t.Errorf("range start = %+v, want site brace at 0:14", got)
+	}
+	if len(errs[0].Related) != 1 || errs[0].Related[0].Rng.Start.Line != 4 {
+		t.Errorf("related = %+v, want next site block on line 4", errs[0].Related)
+	}
+}
+
+func TestParse_TokenAfterClosingBrace(t *testing.T) {
+	src := "a.example.com {\n\thandle {\n\t\trespond ok\n\t} foo bar\n\tfile_server\n} b\n"
+	f, errs := Parse(src)
+	if len(errs) != 2 {
+		t.Fatalf("expected 2 parse errors, got %v", errs)
+	}
+	want := protocol.Range{Start: protocol.Position{Line: 3, Character: 3}, End: protocol.Position{Line: 3, Character: 6}}
+	if errs[0].Message != `unexpected token "foo" after '}'` || errs[0].Rng != want {
+		t.Errorf("error = %q at %+v, want at %+v", errs[0].Message, errs[0].Rng, want)
+	}
+	if errs[1].Message != `unexpected token "b" after '}'` {
+		t.Errorf("error = %q", errs[1].Message)
+	}
+	if len(f.SiteBlocks) != 1 || len(f.SiteBlocks[0].Directives) != 2 {
+		t.Errorf("trailing tokens should be skipped, got %+v", f.SiteBlocks)
+	}
+}
+
+func TestParse_ClosingBracesAndBlockAfterBrace(t *testing.T) {
+	// Two closing braces on one line are fine.
+	mustParse(t, "a.example.com {\n\thandle {\n\t\trespond ok\n\t} }\n")
+
+	// A block opened after "}" is reported but still parsed.
+	f, errs := Parse("a.example.com {\n} b.example.com {\n}\n")
+	if len(errs) != 1 || len(f.SiteBlocks) != 2 {
+		t.Errorf("errors = %v, site blocks = %d", errs, len(f.SiteBlocks))
+	}
+}
+
 // ---- line number tests ------------------------------------------------------
 
 func TestParse_DirectiveLineNumbers(t *testing.T) {
@@ -384,3 +526,77 @@ func TestFileRange_NonEmpty(t *testing.T) {
 		t.Errorf("file range end line: want >0, got 0")
 	}
 }
+
+// ---- brace tracking tests ---------------------------------------------------
+
+func TestParse_BracePositions(t *testing.T) {
+	src := "example.com {\n\theader { X-A 1 }\n\trespond ok\n}\n"
+	f := mustParse(t, src)
+	sb := f.SiteBlocks[0]
+	if sb.LBrace == nil || sb.LBrace.Line != 0 || sb.LBrace.Char != 12 {
+		t.Errorf("site LBrace = %+v, want 0:12", sb.LBrace)
+	}
+	if sb.RBrace == nil || sb.RBrace.Line != 3 || sb.RBrace.Char != 0 {
+		t.Errorf("site RBrace = %+v, want 3:0", sb.RBrace)
+	}
+	header := sb.Directives[0]
+	if !header.HasBody() || header.RBrace == nil || header.RBrace.Line != 1 || header.RBrace.Char != 16 {
+		t.Errorf("header braces = %+v / %+v, want one-line block closing at 1:16", header.LBrace, header.RBrace)
+	}
+	if sb.Directives[1].HasBody() {
+		t.Error("respond: want no body")
+	}
+}
+
+func TestParse_UnclosedBlockHasNoRBrace(t *testing.T) {
+	f, _ := Parse("example.com {\n\trespond ok\n")
+	sb := f.SiteBlocks[0]
+	if sb.LBrace == nil || sb.RBrace != nil {
+		t.Errorf("unclosed site block braces = %+v / %+v, want LBrace only", sb.LBrace, sb.RBrace)
+	}
+	if !sb.BodyContains(protocol.Position{Line: 2, Character: 0}) {
+		t.Error("unclosed site block should extend to EOF")
+	}
+}
+
+func TestParseDirectives(t *testing.T) {
+	ds, errs := ParseDirectives("lb_policy first\nheader_up Host {host}\ntransport http {\n\tdial_timeout 5s\n}\n}\n")
+	if len(ds) != 3 || ds[0].Name.Value != "lb_policy" || ds[2].Name.Value != "transport" || len(ds[2].Body) != 1 {
+		t.Fatalf("got %d directives: %v", len(ds), ds)
+	}
+	if len(errs) != 1 || errs[0].Message != "unexpected '}'" || errs[0].Rng.Start.Line != 5 {
+		t.Errorf("want the stray brace reported, got %v", errs)
+	}
+}
+
+func TestParse_LaterBlockWithoutAddresses(t *testing.T) {
+	src := "{\n\tdebug\n}\n{\n\tadmin off\n}\nexample.com {\n\trespond ok\n}\n"
+	f, errs := Parse(src)
+	if len(errs) != 1 || !strings.Contains(errs[0].Message, "must come first") || errs[0].Rng.Start.Line != 3 {
+		t.Fatalf("errors = %v", errs)
+	}
+	if len(f.GlobalBlock.Directives) != 1 || len(f.SiteBlocks) != 1 {
+		t.Errorf("global directives = %d, site blocks = %d", len(f.GlobalBlock.Directives), len(f.SiteBlocks))
+	}
+}
+
+func TestParse_ArgumentAfterMultiLineToken(t *testing.T) {
+	for name, src := range map[string]string{
+		"heredoc": "a.com {\n\trespond <<HTML\n\t\t<p>hi</p>\n\t\tHTML 503\n\tfile_server\n}\n",
+		"string":  "a.com {\n\trespond \"multi\nline\" 503\n\tfile_server\n}\n",
+	} {
+		t.Run(name, func(t *testing.T) {
+			f, errs := Parse(src)
+			if len(errs) != 0 {
+				t.Fatalf("errors = %v", errs)
+			}
+			ds := f.SiteBlocks[0].Directives
+			if len(ds) != 2 || ds[1].Name.Value != "file_server" {
+				t.Fatalf("directives = %d, want respond and file_server", len(ds))
+			}
+			if args := ds[0].Args; len(args) != 2 || args[1].Token.Value != "503" {
+				t.Errorf("respond args = %d, want the body and 503", len(args))
+			}
+		})
+	}
+}
@@ -0,0 +1,34 @@
+package parser
+
+import "unicode/utf16"
+
+// LSP clients count columns in UTF-16 code units, while the parser and
+// the analyzer slice lines by byte. These helpers convert between the two.
+
+// UTF16Len returns the length of s in UTF-16 code units.
+func UTF16Len(s string) uint32 {
+	n := uint32(0)
+	for _, r := range s {
+		n += uint32(max(utf16.RuneLen(r), 1))
+	}
+	return n
+}
+
+// ByteOffset returns the byte offset in line of the column char, counted in
+// UTF-16 code units. A column inside a character gives the offset after it,
+// and one past the end of line gives its length.
+func ByteOffset(line string, char uint32) int {
+	n := uint32(0)
+	for i, r := range line {
+		if n >= char {
+			return i
+		}
+		n += uint32(max(utf16.RuneLen(r), 1))
+	}
+	return len(line)
+}
+
+// byteShift returns how many more bytes than UTF-16 code units s holds.
+func byteShift(s string) uint32 {
+	return uint32(len(s)) - UTF16Len(s)
+}
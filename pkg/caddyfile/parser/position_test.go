@@ -0,0 +1,15 @@
+package parser
+
+import "testing"
+
+func TestUTF16Columns(t *testing.T) {
+	line := "a📚ü b"
+	if n := UTF16Len(line); n != 6 {
+		t.Errorf("UTF16Len = %d, want 6: the emoji takes two code units", n)
+	}
+	for char, want := range map[uint32]int{0: 0, 1: 1, 2: 5, 3: 5, 4: 7, 5: 8, 6: 9, 99: 9} {
+		if got := ByteOffset(line, char); got != want {
+			t.Errorf("ByteOffset(%d) = %d, want %d", char, got, want)
+		}
+	}
+}
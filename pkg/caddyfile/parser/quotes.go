@@ -0,0 +1,238 @@
+package parser
+
+import (
+	"sort"
+	"strings"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+// tokenize is Tokenize that also reports unterminated quoted strings.
+//
+// Caddy's tokenizer lets quoted strings span lines, so a missing closing
+// quote either swallows the rest of the file or pairs up with the opening
+// quote of a later string, and the parser then reports confusing errors far
+// from the cause. The opening quote is reported instead, then blanked out and
+// the source tokenized again, so that the rest of the file still parses.
+func tokenize(src string) ([]Token, []*ParseError) {
+	src = StripBOM(src)
+	var errs []*ParseError
+	// Unterminated quotes are blanked out, so that the rest of the source is
+	// read as Caddy reads it once they are closed. The search for the next
+	// one resumes after the last, as if it was a space already; the source
+	// is only scanned forward, so they are all blanked out at the end.
+	var opens []int
+	lineStarts := buildLineStarts(src)
+	for from := 0; ; {
+		open := unterminatedQuote(src, from)
+		if open < 0 {
+			break
+		}
+		errs = append(errs, &ParseError{
+			Message: "unterminated quoted string: missing closing " + src[open:open+1],
+			Rng:     offsetRange(lineStarts, open, 1),
+		})
+		opens = append(opens, open)
+		from = open + 1
+	}
+	if len(opens) > 0 {
+		b := []byte(src)
+		for _, open := range opens {
+			b[open] = ' '
+		}
+		src = string(b)
+	}
+	caddyTokens, err := caddyfile.Tokenize([]byte(src), "Caddyfile")
+	if err != nil {
+		// Return just an EOF so the parser can report errors gracefully.
+		return []Token{{Type: EOF}}, errs
+	}
+	return addColumns(src, caddyTokens), errs
+}
+
+// offsetRange returns the range of n bytes starting at byte offset off.
+func offsetRange(lineStarts []int, off, n int) protocol.Range {
+	line := sort.Search(len(lineStarts), func(i int) bool { return lineStarts[i] > off }) - 1
+	start := protocol.Position{Line: protocol.UInteger(line), Character: protocol.UInteger(off - lineStarts[line])}
+	end := start
+	end.Character += protocol.UInteger(n)
+	return protocol.Range{Start: start, End: end}
+}
+
+// unterminatedQuote returns the offset of the first opening quote at or
+// after from that lacks its closing quote, or -1. from must be outside any
+// quoted string, comment or heredoc. A string is unterminated when it is still open at
+// the end of the source, or when it spans lines and its closing quote runs
+// straight into the next word: that quote was meant to open a later string.
+func unterminatedQuote(src string, from int) int {
+	open := -1
+	walkSpansFrom(src, from, func(sp Span) bool {
+		switch {
+		case sp.Kind != SpanQuoted:
+		case sp.Unterminated:
+			open = sp.Start
+		case strings.Contains(src[sp.Start:sp.End], "\n") && sp.End < len(src) && !strings.ContainsRune(" \t\r\n", rune(src[sp.End])):
+			open = sp.Start
+		}
+		return open < 0
+	})
+	return open
+}
+
+// VerbatimLines reports, by 0-based line number, the lines of src that lie
+// within a quoted string or heredoc opened on an earlier line. Whitespace on
+// those lines is part of a value rather than layout.
+func VerbatimLines(src string) map[uint32]bool {
+	lines := make(map[uint32]bool)
+	lineStarts := buildLineStarts(src)
+	for _, sp := range Spans(src) {
+		if sp.Kind == SpanComment {
+			continue
+		}
+		first := offsetRange(lineStarts, sp.Start, 0).Start.Line
+		last := offsetRange(lineStarts, sp.End-1, 0).Start.Line
+		for l := first + 1; l <= last; l++ {
+			lines[l] = true
+		}
+	}
+	return lines
+}
+
+// SpanKind classifies a Span.
+type SpanKind int
+
+const (
+	SpanQuoted  SpanKind = iota // "…" or `…`
+	SpanHeredoc                 // <<MARKER … MARKER
+	SpanComment                 // # … up to the end of the line
+)
+
+// Span is a byte range [Start, End) of a source holding a quoted string,
+// heredoc or comment, including its delimiters.
+type Span struct {
+	Kind  SpanKind
+	Start int
+	End   int
+	// Unterminated is set on a quoted string still open at the end of the
+	// source; End is then len(src).
+	Unterminated bool
+}
+
+// Spans returns the quoted strings, heredocs and comments of src in order.
+func Spans(src string) []Span {
+	var spans []Span
+	walkSpans(src, func(sp Span) bool {
+		spans = append(spans, sp)
+		return true
+	})
+	return spans
+}
+
+// walkSpans calls fn with every span of src, in order, until fn returns
+// false. It follows the quoting rules of Caddy's tokenizer: a quote,
+// backtick, "<<" or "#" only has a meaning at the start of a token, and a
+// backslash escapes the next character except inside backticks. A leading
+// byte order mark is skipped, as Caddy does.
+func walkSpans(src string, fn func(Span) bool) {
+	start := 0
+	if strings.HasPrefix(src, BOM) {
+		start = len(BOM)
+	}
+	walkSpansFrom(src, start, fn)
+}
+
+// walkSpansFrom is walkSpans starting at offset start of src, which must be
+// outside any span and at the start of a token.
+func walkSpansFrom(src string, start int, fn func(Span) bool) {
+	atTokenStart := true
+	for i := start; i < len(src); i++ {
+		ch := src[i]
+		switch {
+		case ch == ' ' || ch == '\t' || ch == '\r' || ch == '\n':
+			atTokenStart = true
+		case ch == '\\':
+			atTokenStart = false
+			i++
+		case !atTokenStart:
+		case ch == '#':
+			end := strings.IndexByte(src[i:], '\n')
+			if end < 0 {
+				end = len(src)
+			} else {
+				end += i
+			}
+			if !fn(Span{Kind: SpanComment, Start: i, End: end}) {
+				return
+			}
+			i = end - 1
+		case ch == '<' && strings.HasPrefix(src[i:], "<<"):
+			end := skipHeredoc(src, i)
+			if !fn(Span{Kind: SpanHeredoc, Start: i, End: end + 1}) {
+				return
+			}
+			i = end
+			atTokenStart = false
+		case ch == '"' || ch == '`':
+			end, ok := closingQuote(src, i)
+			if !ok {
+				fn(Span{Kind: SpanQuoted, Start: i, End: len(src), Unterminated: true})
+				return
+			}
+			if !fn(Span{Kind: SpanQuoted, Start: i, End: end + 1}) {
+				return
+			}
+			i = end
+			atTokenStart = false
+		default:
+			atTokenStart = false
+		}
+	}
+}
+
+// closingQuote returns the offset of the quote that closes the string
+// opened at src[open].
+func closingQuote(src string, open int) (int, bool) {
+	q := src[open]
+	for i := open + 1; i < len(src); i++ {
+		switch src[i] {
+		case '\\':
+			if q == '"' {
+				i++
+			}
+		case q:
+			return i, true
+		}
+	}
+	return 0, false
+}
+
+// skipHeredoc returns the offset of the last byte of the heredoc opened by
+// the "<<MARKER" at src[start], or of the marker line when it is not a
+// valid heredoc; Caddy's tokenizer reports those itself.
+func skipHeredoc(src string, start int) int {
+	eol := strings.IndexByte(src[start:], '\n')
+	if eol < 0 {
+		return len(src) - 1
+	}
+	eol += start
+	marker := strings.TrimSpace(strings.TrimRight(src[start+2:eol], "\r"))
+	if marker == "" {
+		return eol
+	}
+	for i := eol + 1; i < len(src); {
+		next := strings.IndexByte(src[i:], '\n')
+		lineEnd := len(src)
+		if next >= 0 {
+			lineEnd = i + next
+		}
+		if strings.TrimSpace(src[i:lineEnd]) == marker {
+			return lineEnd - 1
+		}
+		if next < 0 {
+			break
+		}
+		i = lineEnd + 1
+	}
+	return len(src) - 1
+}
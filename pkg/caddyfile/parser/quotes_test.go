@@ -0,0 +1,57 @@
+package parser
+
+import (
+	"testing"
+
+	protocol "github.com/tliron/glsp/protocol_3_16"
+)
+
+func TestParse_UnterminatedQuote(t *testing.T) {
+	src := "example.com {\n\trespond \"hello\n\tfile_server\n}\n"
+	f, errs := Parse(src)
+	if len(errs) != 1 {
+		t.Fatalf("want exactly the unterminated string error, got %v", errs)
+	}
+	want := protocol.Range{Start: protocol.Position{Line: 1, Character: 9}, End: protocol.Position{Line: 1, Character: 10}}
+	if errs[0].Rng != want || errs[0].Message != `unterminated quoted string: missing closing "` {
+		t.Errorf("error = %q at %v, want at %v", errs[0].Message, errs[0].Rng, want)
+	}
+	if len(f.SiteBlocks) != 1 || len(f.SiteBlocks[0].Directives) != 2 || f.SiteBlocks[0].Directives[1].Name.Value != "file_server" {
+		t.Errorf("the rest of the file should still parse: %+v", f.SiteBlocks)
+	}
+}
+
+func TestParse_UnterminatedBacktick(t *testing.T) {
+	_, errs := Parse("example.com {\n\trespond `say \"hi\"\n}\n")
+	if len(errs) != 1 || errs[0].Message != "unterminated quoted string: missing closing `" {
+		t.Fatalf("errors = %v", errs)
+	}
+}
+
+func TestParse_UnterminatedQuoteBeforeLaterString(t *testing.T) {
+	// The first string lacks its closing quote, so Caddy closes it at the
+	// quote opening "b"; that quote then runs into the word after it.
+	src := "example.com {\n\trespond \"a\n\theader X \"b\"\n}\n"
+	f, errs := Parse(src)
+	if len(errs) != 1 || errs[0].Rng.Start != (protocol.Position{Line: 1, Character: 9}) {
+		t.Fatalf("errors = %v, want one at the first quote", errs)
+	}
+	ds := f.SiteBlocks[0].Directives
+	if len(ds) != 2 || ds[1].Name.Value != "header" || ds[1].Args[1].Token.Value != `"b"` {
+		t.Errorf("the later string should parse normally: %+v", ds)
+	}
+}
+
+func TestParse_QuotesThatAreNotStrings(t *testing.T) {
+	for _, src := range []string{
+		"example.com {\n\trespond \"multi\nline\"\n}\n",
+		"example.com {\n\t# don't \"quote\n\trespond ok\n}\n",
+		"example.com {\n\trespond a\\\"b\n}\n",
+		"example.com {\n\trespond \"a \\\" b\"\n}\n",
+		"example.com {\n\trespond <<EOF\n\t\tit's \"fine\n\t\tEOF 200\n}\n",
+	} {
+		if _, errs := Parse(src); len(errs) != 0 {
+			t.Errorf("%q: unexpected errors %v", src, errs)
+		}
+	}
+}
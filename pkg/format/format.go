@@ -0,0 +1,170 @@
+// Package format formats Caddyfiles the way `caddy fmt` does, with a few
+// options on top, so that tools embedding caddy-ls lay out Caddyfiles
+// consistently with it.
+//
+// Formatting is done by Caddy's own formatter; the options are applied to
+// its output line by line. Heredoc bodies are left as they are.
+package format
+
+import (
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+// Options adjust the output of Format. The zero value formats exactly like
+// `caddy fmt`.
+type Options struct {
+	// Indent is one level of indentation, such as four spaces. Empty means
+	// a tab, as Caddy writes.
+	Indent string
+	// DropBlankLines removes the blank lines inside blocks, which otherwise
+	// separate groups of directives. Blank lines between top-level blocks
+	// are kept.
+	DropBlankLines bool
+	// AlignComments lines up the comments that end consecutive lines of the
+	// same indentation in one column.
+	AlignComments bool
+}
+
+// Format returns src formatted with opts. Like Caddy's formatter it never
+// fails: input that does not parse is formatted as far as it can be.
+func Format(src []byte, opts Options) []byte {
+	out := string(caddyfile.Format(src))
+	if opts == (Options{}) {
+		return []byte(out)
+	}
+
+	lines := strings.Split(out, "\n")
+	verbatim := heredocLines(lines)
+	if opts.Indent != "" && opts.Indent != "\t" {
+		for i, line := range lines {
+			if !verbatim[i] {
+				trimmed := strings.TrimLeft(line, "\t")
+				lines[i] = strings.Repeat(opts.Indent, len(line)-len(trimmed)) + trimmed
+			}
+		}
+	}
+	if opts.AlignComments {
+		alignComments(lines, verbatim)
+	}
+	if opts.DropBlankLines {
+		lines = dropBlankLines(lines, verbatim)
+	}
+	return []byte(strings.Join(lines, "\n"))
+}
+
+// heredocOpen matches a line ending in the opening marker of a heredoc.
+var heredocOpen = regexp.MustCompile(`<<([^\s<]+)$`)
+
+// heredocLines marks the lines of heredoc bodies and their closing marker,
+// whose whitespace is part of the text.
+func heredocLines(lines []string) []bool {
+	verbatim := make([]bool, len(lines))
+	marker := ""
+	for i, line := range lines {
+		if marker != "" {
+			verbatim[i] = true
+			if strings.TrimSpace(line) == marker {
+				marker = ""
+			}
+			continue
+		}
+		code, _ := splitComment(line)
+		if m := heredocOpen.FindStringSubmatch(strings.TrimRight(code, " \t")); m != nil {
+			marker = m[1]
+		}
+	}
+	return verbatim
+}
+
+// alignComments pads the code of consecutive lines that end in a comment
+// and share their indentation, so that the comments start in one column.
+func alignComments(lines []string, verbatim []bool) {
+	type commented struct {
+		i          int
+		code, text string
+	}
+	var run []commented
+	flush := func() {
+		if len(run) > 1 {
+			col := 0
+			for _, c := range run {
+				col = max(col, utf8.RuneCountInString(c.code))
+			}
+			for _, c := range run {
+				lines[c.i] = c.code + strings.Repeat(" ", col-utf8.RuneCountInString(c.code)+1) + c.text
+			}
+		}
+		run = run[:0]
+	}
+	indent := func(s string) string { return s[:len(s)-len(strings.TrimLeft(s, " \t"))] }
+
+	for i, line := range lines {
+		code, text := splitComment(line)
+		code = strings.TrimRight(code, " \t")
+		if verbatim[i] || text == "" || strings.TrimSpace(code) == "" {
+			flush()
+			continue
+		}
+		if len(run) > 0 && indent(run[0].code) != indent(code) {
+			flush()
+		}
+		run = append(run, commented{i, code, text})
+	}
+	flush()
+}
+
+// splitComment splits line before the "#" that starts its comment, if any.
+// As in Caddy's lexer, a comment starts with a "#" at the start of a token
+// that is not quoted.
+func splitComment(line string) (code, comment string) {
+	var quote rune
+	escaped := false
+	prev := ' '
+	for i, r := range line {
+		switch {
+		case escaped:
+			escaped = false
+		case r == '\\' && quote != '`':
+			escaped = true
+		case quote != 0:
+			if r == quote {
+				quote = 0
+			}
+		case r == '"' || r == '`':
+			quote = r
+		case r == '#' && (prev == ' ' || prev == '\t'):
+			return line[:i], line[i:]
+		}
+		prev = r
+	}
+	return line, ""
+}
+
+// dropBlankLines removes the blank lines that have an indented line, one
+// inside a block, before or after them.
+func dropBlankLines(lines []string, verbatim []bool) []string {
+	indented := func(s string) bool { return s != "" && (s[0] == ' ' || s[0] == '\t') }
+	kept := lines[:0:0]
+	for i, line := range lines {
+		if verbatim[i] || strings.TrimSpace(line) != "" {
+			kept = append(kept, line)
+			continue
+		}
+		next := ""
+		for _, l := range lines[i+1:] {
+			if strings.TrimSpace(l) != "" {
+				next = l
+				break
+			}
+		}
+		if len(kept) > 0 && (indented(kept[len(kept)-1]) || indented(next)) {
+			continue
+		}
+		kept = append(kept, line)
+	}
+	return kept
+}
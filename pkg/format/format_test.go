@@ -0,0 +1,49 @@
+package format
+
+import (
+	"testing"
+
+	"github.com/caddyserver/caddy/v2/caddyconfig/caddyfile"
+)
+
+func TestFormat(t *testing.T) {
+	src := "{\ndebug\n\n\nemail a@example.com # contact\n}\nexample.com {\nroot * /srv # site root\nencode gzip  # compress\n\nfile_server\nrespond \"# not a comment\" # a comment\nheader X-Id abc#def\n}\n"
+	for _, tc := range []struct {
+		name string
+		opts Options
+		want string
+	}{
+		{"caddy fmt", Options{}, string(caddyfile.Format([]byte(src)))},
+		{"indent", Options{Indent: "    "}, "{\n    debug\n\n    email a@example.com # contact\n}\nexample.com {\n    root * /srv # site root\n    encode gzip # compress\n\n    file_server\n    respond \"# not a comment\" # a comment\n    header X-Id abc#def\n}\n"},
+		{"drop blank lines", Options{DropBlankLines: true}, "{\n\tdebug\n\temail a@example.com # contact\n}\nexample.com {\n\troot * /srv # site root\n\tencode gzip # compress\n\tfile_server\n\trespond \"# not a comment\" # a comment\n\theader X-Id abc#def\n}\n"},
+		{"align comments", Options{AlignComments: true}, "{\n\tdebug\n\n\temail a@example.com # contact\n}\nexample.com {\n\troot * /srv # site root\n\tencode gzip # compress\n\n\tfile_server\n\trespond \"# not a comment\" # a comment\n\theader X-Id abc#def\n}\n"},
+	} {
+		if got := string(Format([]byte(src), tc.opts)); got != tc.want {
+			t.Errorf("%s:\ngot  %q\nwant %q", tc.name, got, tc.want)
+		}
+	}
+}
+
+func TestFormat_AlignComments(t *testing.T) {
+	src := "example.com {\n\troot * /srv # site root\n\tencode gzip # compress\n\t# a line of its own\n\tfile_server # serve\n\treverse_proxy /api/* localhost:8080 # backend\n\thandle {\n\t\trespond ok # inner\n\t}\n}\n"
+	want := "example.com {\n\troot * /srv # site root\n\tencode gzip # compress\n\t# a line of its own\n\tfile_server                         # serve\n\treverse_proxy /api/* localhost:8080 # backend\n\thandle {\n\t\trespond ok # inner\n\t}\n}\n"
+	if got := string(Format([]byte(src), Options{AlignComments: true})); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestFormat_Heredoc(t *testing.T) {
+	src := "example.com {\n\trespond <<HTML\n\t\t<p>\n\n\t\t  hi</p> # kept\n\t\tHTML 200\n}\n"
+	want := "example.com {\n    respond <<HTML\n\t\t<p>\n\n\t\t  hi</p> # kept\n\t\tHTML 200\n}\n"
+	if got := string(Format([]byte(src), Options{Indent: "    ", DropBlankLines: true, AlignComments: true})); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}
+
+func TestFormat_DropBlankLinesBetweenBlocks(t *testing.T) {
+	src := "import common\n\na.com {\n\n\trespond a\n\n}\n\nb.com {\n\trespond b\n}\n"
+	want := "import common\n\na.com {\n\trespond a\n}\n\nb.com {\n\trespond b\n}\n"
+	if got := string(Format([]byte(src), Options{DropBlankLines: true})); got != want {
+		t.Errorf("got  %q\nwant %q", got, want)
+	}
+}